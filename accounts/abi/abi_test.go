@@ -83,7 +83,7 @@ func TestTypeCheck(t *testing.T) {
 		{"bytes32[]", [][32]byte{{}}, ""},
 		{"function", [24]byte{}, ""},
 	} {
-		typ, err := NewType(test.typ)
+		typ, err := NewType(test.typ, "", nil)
 		if err != nil {
 			t.Fatal("unexpected parse error:", err)
 		}
@@ -371,7 +371,7 @@ func TestPack(t *testing.T) {
 		{"bytes32[]", []common.Hash{{1}, {2}}, formatSliceOutput(pad([]byte{1}, 32, false), pad([]byte{2}, 32, false))},
 		{"function", [24]byte{1}, pad([]byte{1}, 32, false)},
 	} {
-		typ, err := NewType(test.typ)
+		typ, err := NewType(test.typ, "", nil)
 		if err != nil {
 			t.Fatal("unexpected parse error:", err)
 		}
@@ -479,7 +479,7 @@ const jsondata2 = `
 ]`
 
 func TestReader(t *testing.T) {
-	Uint256, _ := NewType("uint256")
+	Uint256, _ := NewType("uint256", "", nil)
 	exp := ABI{
 		Methods: map[string]Method{
 			"balance": {
@@ -585,7 +585,7 @@ func TestTestSlice(t *testing.T) {
 }
 
 func TestMethodSignature(t *testing.T) {
-	String, _ := NewType("string")
+	String, _ := NewType("string", "", nil)
 	m := Method{"foo", false, []Argument{{"bar", String, false}, {"baz", String, false}}, nil}
 	exp := "foo(string,string)"
 	if m.Sig() != exp {
@@ -597,7 +597,7 @@ func TestMethodSignature(t *testing.T) {
 		t.Errorf("expected ids to match %x != %x", m.Id(), idexp)
 	}
 
-	uintt, _ := NewType("uint")
+	uintt, _ := NewType("uint", "", nil)
 	m = Method{"foo", false, []Argument{{"bar", uintt, false}}, nil}
 	exp = "foo(uint256)"
 	if m.Sig() != exp {
@@ -793,8 +793,8 @@ func TestBareEvents(t *testing.T) {
 	{ "type" : "event", "name" : "args", "inputs" : [{ "indexed":false, "name":"arg0", "type":"uint256" }, { "indexed":true, "name":"arg1", "type":"address" }] }
 	]`
 
-	arg0, _ := NewType("uint256")
-	arg1, _ := NewType("address")
+	arg0, _ := NewType("uint256", "", nil)
+	arg1, _ := NewType("address", "", nil)
 
 	expectedEvents := map[string]struct {
 		Anonymous bool
@@ -1239,3 +1239,199 @@ func TestUnmarshal(t *testing.T) {
 		t.Fatal("expected error:", err)
 	}
 }
+
+// Tests that dynamic and nested dynamic arguments are packed according to the
+// examples of the ABI specification, and that the packed data unpacks into the
+// original values.
+func TestPackSpecVectors(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "f", "inputs" : [ { "type" : "uint256" }, { "type" : "uint32[]" }, { "type" : "bytes10" }, { "type" : "bytes" } ],
+	  "outputs" : [ { "name" : "a", "type" : "uint256" }, { "name" : "b", "type" : "uint32[]" }, { "name" : "c", "type" : "bytes10" }, { "name" : "d", "type" : "bytes" } ] },
+	{ "type" : "function", "name" : "g", "inputs" : [ { "type" : "uint256[][]" }, { "type" : "string[]" } ],
+	  "outputs" : [ { "name" : "a", "type" : "uint256[][]" }, { "name" : "b", "type" : "string[]" } ] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// f(uint256,uint32[],bytes10,bytes) with (0x123, [0x456, 0x789], "1234567890", "Hello, world!")
+	var bytes10 [10]byte
+	copy(bytes10[:], "1234567890")
+
+	packed, err := abi.Pack("f", big.NewInt(0x123), []uint32{0x456, 0x789}, bytes10, []byte("Hello, world!"))
+	if err != nil {
+		t.Fatalf("failed to pack f: %v", err)
+	}
+	want := common.Hex2Bytes("8be65246" +
+		"0000000000000000000000000000000000000000000000000000000000000123" +
+		"0000000000000000000000000000000000000000000000000000000000000080" +
+		"3132333435363738393000000000000000000000000000000000000000000000" +
+		"00000000000000000000000000000000000000000000000000000000000000e0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000456" +
+		"0000000000000000000000000000000000000000000000000000000000000789" +
+		"000000000000000000000000000000000000000000000000000000000000000d" +
+		"48656c6c6f2c20776f726c642100000000000000000000000000000000000000")
+	if !bytes.Equal(packed, want) {
+		t.Errorf("f packing mismatch:\nhave %x\nwant %x", packed, want)
+	}
+	var f struct {
+		A *big.Int
+		B []uint32
+		C [10]byte
+		D []byte
+	}
+	if err := abi.Unpack(&f, "f", packed[4:]); err != nil {
+		t.Fatalf("failed to unpack f: %v", err)
+	}
+	if f.A.Cmp(big.NewInt(0x123)) != 0 || !reflect.DeepEqual(f.B, []uint32{0x456, 0x789}) || f.C != bytes10 || string(f.D) != "Hello, world!" {
+		t.Errorf("f unpacking mismatch: have %+v", f)
+	}
+	// g(uint256[][],string[]) with ([[1, 2], [3]], ["one", "two", "three"])
+	ints := [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}}
+	strs := []string{"one", "two", "three"}
+
+	packed, err = abi.Pack("g", ints, strs)
+	if err != nil {
+		t.Fatalf("failed to pack g: %v", err)
+	}
+	want = common.Hex2Bytes("2289b18c" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"0000000000000000000000000000000000000000000000000000000000000140" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"00000000000000000000000000000000000000000000000000000000000000e0" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"6f6e650000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"74776f0000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"7468726565000000000000000000000000000000000000000000000000000000")
+	if !bytes.Equal(packed, want) {
+		t.Errorf("g packing mismatch:\nhave %x\nwant %x", packed, want)
+	}
+	var g struct {
+		A [][]*big.Int
+		B []string
+	}
+	if err := abi.Unpack(&g, "g", packed[4:]); err != nil {
+		t.Fatalf("failed to unpack g: %v", err)
+	}
+	if !reflect.DeepEqual(g.A, ints) || !reflect.DeepEqual(g.B, strs) {
+		t.Errorf("g unpacking mismatch: have %v %v", g.A, g.B)
+	}
+}
+
+// Tests that tuples, both static and dynamic ones, are packed and unpacked
+// to and from Go structs according to the ABIEncoderV2 encoding.
+func TestPackTuples(t *testing.T) {
+	const definition = `[{ "type" : "function", "name" : "h",
+	  "inputs" : [ { "name" : "s", "type" : "tuple", "components" : [ { "name" : "a", "type" : "uint256" }, { "name" : "b", "type" : "uint256[]" },
+	    { "name" : "c", "type" : "tuple[]", "components" : [ { "name" : "x", "type" : "uint256" }, { "name" : "y", "type" : "uint256" } ] } ] },
+	    { "name" : "t", "type" : "tuple", "components" : [ { "name" : "x", "type" : "uint256" }, { "name" : "y", "type" : "uint256" } ] },
+	    { "name" : "z", "type" : "uint256" } ],
+	  "outputs" : [ { "name" : "s", "type" : "tuple", "components" : [ { "name" : "a", "type" : "uint256" }, { "name" : "b", "type" : "uint256[]" },
+	    { "name" : "c", "type" : "tuple[]", "components" : [ { "name" : "x", "type" : "uint256" }, { "name" : "y", "type" : "uint256" } ] } ] },
+	    { "name" : "t", "type" : "tuple", "components" : [ { "name" : "x", "type" : "uint256" }, { "name" : "y", "type" : "uint256" } ] },
+	    { "name" : "z", "type" : "uint256" } ] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := abi.Methods["h"].Sig(); sig != "h((uint256,uint256[],(uint256,uint256)[]),(uint256,uint256),uint256)" {
+		t.Errorf("signature mismatch: have %s", sig)
+	}
+	type T struct {
+		X *big.Int
+		Y *big.Int
+	}
+	type S struct {
+		A *big.Int
+		B []*big.Int
+		C []T
+	}
+	s := S{big.NewInt(1), []*big.Int{big.NewInt(2), big.NewInt(3)}, []T{{big.NewInt(4), big.NewInt(5)}, {big.NewInt(6), big.NewInt(7)}}}
+	tt := T{big.NewInt(8), big.NewInt(9)}
+
+	packed, err := abi.Pack("h", s, &tt, big.NewInt(10))
+	if err != nil {
+		t.Fatalf("failed to pack h: %v", err)
+	}
+	want := common.Hex2Bytes(
+		"0000000000000000000000000000000000000000000000000000000000000080" + // offset of s
+			"0000000000000000000000000000000000000000000000000000000000000008" + // t.x
+			"0000000000000000000000000000000000000000000000000000000000000009" + // t.y
+			"000000000000000000000000000000000000000000000000000000000000000a" + // z
+			"0000000000000000000000000000000000000000000000000000000000000001" + // s.a
+			"0000000000000000000000000000000000000000000000000000000000000060" + // offset of s.b
+			"00000000000000000000000000000000000000000000000000000000000000c0" + // offset of s.c
+			"0000000000000000000000000000000000000000000000000000000000000002" + // len(s.b)
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000000000000000002" + // len(s.c)
+			"0000000000000000000000000000000000000000000000000000000000000004" +
+			"0000000000000000000000000000000000000000000000000000000000000005" +
+			"0000000000000000000000000000000000000000000000000000000000000006" +
+			"0000000000000000000000000000000000000000000000000000000000000007")
+	if !bytes.Equal(packed[4:], want) {
+		t.Errorf("h packing mismatch:\nhave %x\nwant %x", packed[4:], want)
+	}
+	var out struct {
+		S S
+		T T
+		Z *big.Int
+	}
+	if err := abi.Unpack(&out, "h", want); err != nil {
+		t.Fatalf("failed to unpack h: %v", err)
+	}
+	if !reflect.DeepEqual(out.S, s) || !reflect.DeepEqual(out.T, tt) || out.Z.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("h unpacking mismatch: have %+v", out)
+	}
+	// Missing struct fields must be reported instead of silently packed as zero
+	if _, err := abi.Pack("h", struct{ A *big.Int }{big.NewInt(1)}, tt, big.NewInt(10)); err == nil {
+		t.Errorf("packing incomplete tuple succeeded")
+	}
+}
+
+// Tests that underscored output names are unpacked into camel-cased struct fields,
+// as well as into the capitalised ones of earlier bindings and hand written structs.
+func TestUnpackUnderscoredNames(t *testing.T) {
+	const definition = `[{ "type" : "function", "name" : "pair", "outputs" : [ { "name" : "first_value", "type" : "uint256" }, { "name" : "second_value", "type" : "uint256" } ] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{2}, 32)...)
+
+	var camel struct {
+		FirstValue  *big.Int
+		SecondValue *big.Int
+	}
+	if err := abi.Unpack(&camel, "pair", data); err != nil {
+		t.Fatalf("failed to unpack into camel-cased fields: %v", err)
+	}
+	if camel.FirstValue == nil || camel.FirstValue.Int64() != 1 || camel.SecondValue == nil || camel.SecondValue.Int64() != 2 {
+		t.Errorf("camel-cased fields mismatch: have %v %v, want 1 2", camel.FirstValue, camel.SecondValue)
+	}
+	var capitalised struct {
+		First_value  *big.Int
+		Second_value *big.Int
+	}
+	if err := abi.Unpack(&capitalised, "pair", data); err != nil {
+		t.Fatalf("failed to unpack into capitalised fields: %v", err)
+	}
+	if capitalised.First_value == nil || capitalised.First_value.Int64() != 1 || capitalised.Second_value == nil || capitalised.Second_value.Int64() != 2 {
+		t.Errorf("capitalised fields mismatch: have %v %v, want 1 2", capitalised.First_value, capitalised.Second_value)
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"math/big"
 
+	"github.com/bazacoin/go-bazacoin/accounts"
 	"github.com/bazacoin/go-bazacoin/accounts/keystore"
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core/types"
@@ -60,3 +62,19 @@ func NewKeyedTransactor(key *ecdsa.PrivateKey) *TransactOpts {
 		},
 	}
 }
+
+// NewWalletTransactor is a utility method to easily create a transaction signer
+// delegating to an account of a wallet, e.g. an external signer connected via
+// accounts/external. The transactions are signed for the given chain, ignoring
+// the signer requested by the contract binding.
+func NewWalletTransactor(wallet accounts.Wallet, account accounts.Account, chainID *big.Int) *TransactOpts {
+	return &TransactOpts{
+		From: account.Address,
+		Signer: func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != account.Address {
+				return nil, errors.New("not authorized to sign this account")
+			}
+			return wallet.SignTx(account, tx, chainID)
+		},
+	}
+}
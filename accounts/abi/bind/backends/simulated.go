@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/bazacoin/go-bazacoin"
 	"github.com/bazacoin/go-bazacoin/accounts/abi/bind"
@@ -32,15 +33,26 @@ import (
 	"github.com/bazacoin/go-bazacoin/core/state"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/core/vm"
+	"github.com/bazacoin/go-bazacoin/bzc/filters"
 	"github.com/bazacoin/go-bazacoin/bzcdb"
 	"github.com/bazacoin/go-bazacoin/event"
 	"github.com/bazacoin/go-bazacoin/params"
+	"github.com/bazacoin/go-bazacoin/rpc"
 )
 
-// This nil assignment ensures compile time that SimulatedBackend implements bind.ContractBackend.
-var _ bind.ContractBackend = (*SimulatedBackend)(nil)
+// These nil assignments ensure compile time that SimulatedBackend implements
+// bind.ContractBackend and the chain access interfaces of a real node client.
+var (
+	_ bind.ContractBackend       = (*SimulatedBackend)(nil)
+	_ bazacoin.ChainReader       = (*SimulatedBackend)(nil)
+	_ bazacoin.TransactionReader = (*SimulatedBackend)(nil)
+	_ bazacoin.ChainStateReader  = (*SimulatedBackend)(nil)
+)
 
-var errBlockNumberUnsupported = errors.New("SimulatedBackend cannot access blocks other than the latest block")
+var (
+	errBlockNumberUnsupported = errors.New("SimulatedBackend cannot access blocks other than the latest block")
+	errTimeBeforeParent       = errors.New("SimulatedBackend cannot move the pending block before its parent")
+)
 
 // SimulatedBackend implements bind.ContractBackend, simulating a blockchain in
 // the background. Its main purpose is to allow easily testing contract bindings.
@@ -52,17 +64,33 @@ type SimulatedBackend struct {
 	pendingBlock *types.Block   // Currently pending block that will be imported on request
 	pendingState *state.StateDB // Currently pending state that will be the active on on request
 
+	mux    *event.TypeMux       // Event multiplexer the chain events are posted to
+	events *filters.EventSystem // Event system for filtering log events live
+
 	config *params.ChainConfig
 }
 
 // NewSimulatedBackend creates a new binding backend using a simulated blockchain
-// for testing purposes.
+// for testing purposes, with the default genesis gas limit.
 func NewSimulatedBackend(alloc core.GenesisAlloc) *SimulatedBackend {
+	return NewSimulatedBackendWithGasLimit(alloc, params.GenesisGasLimit.Uint64())
+}
+
+// NewSimulatedBackendWithGasLimit creates a new binding backend using a simulated
+// blockchain for testing purposes, starting out with the given block gas limit.
+func NewSimulatedBackendWithGasLimit(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
 	database, _ := bzcdb.NewMemDatabase()
-	genesis := core.Genesis{Config: params.AllProtocolChanges, Alloc: alloc}
+	genesis := core.Genesis{Config: params.AllProtocolChanges, GasLimit: gasLimit, Alloc: alloc}
 	genesis.MustCommit(database)
-	blockchain, _ := core.NewBlockChain(database, genesis.Config, bzhash.NewFaker(), new(event.TypeMux), vm.Config{})
-	backend := &SimulatedBackend{database: database, blockchain: blockchain, config: genesis.Config}
+	mux := new(event.TypeMux)
+	blockchain, _ := core.NewBlockChain(database, genesis.Config, bzhash.NewFaker(), mux, vm.Config{})
+	backend := &SimulatedBackend{
+		database:   database,
+		blockchain: blockchain,
+		config:     genesis.Config,
+		mux:        mux,
+		events:     filters.NewEventSystem(mux, &filterBackend{database, blockchain, mux}, false),
+	}
 	backend.rollback()
 	return backend
 }
@@ -147,6 +175,140 @@ func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common
 	return core.GetReceipt(b.database, txHash), nil
 }
 
+// TransactionByHash checks the pool of pending transactions in addition to the
+// blockchain. The isPending return value indicates whether the transaction has
+// been mined yet.
+func (b *SimulatedBackend) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if tx := b.pendingBlock.Transaction(txHash); tx != nil {
+		return tx, true, nil
+	}
+	if tx, _, _, _ := core.GetTransaction(b.database, txHash); tx != nil {
+		return tx, false, nil
+	}
+	return nil, false, bazacoin.NotFound
+}
+
+// BlockByHash retrieves a block from the simulated chain, including the pending
+// one not yet committed.
+func (b *SimulatedBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.blockByHash(hash)
+}
+
+// blockByHash retrieves a block by hash, the caller must hold the lock.
+func (b *SimulatedBackend) blockByHash(hash common.Hash) (*types.Block, error) {
+	if hash == b.pendingBlock.Hash() {
+		return b.pendingBlock, nil
+	}
+	if block := b.blockchain.GetBlockByHash(hash); block != nil {
+		return block, nil
+	}
+	return nil, bazacoin.NotFound
+}
+
+// BlockByNumber retrieves a block from the canonical simulated chain. If number
+// is nil, the latest committed block is returned.
+func (b *SimulatedBackend) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == nil || number.Cmp(b.blockchain.CurrentBlock().Number()) == 0 {
+		return b.blockchain.CurrentBlock(), nil
+	}
+	if block := b.blockchain.GetBlockByNumber(number.Uint64()); block != nil {
+		return block, nil
+	}
+	return nil, bazacoin.NotFound
+}
+
+// HeaderByHash returns a block header from the simulated chain, including the
+// pending one not yet committed.
+func (b *SimulatedBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if hash == b.pendingBlock.Hash() {
+		return b.pendingBlock.Header(), nil
+	}
+	if header := b.blockchain.GetHeaderByHash(hash); header != nil {
+		return header, nil
+	}
+	return nil, bazacoin.NotFound
+}
+
+// HeaderByNumber returns a block header from the canonical simulated chain. If
+// number is nil, the latest committed header is returned.
+func (b *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == nil || number.Cmp(b.blockchain.CurrentBlock().Number()) == 0 {
+		return b.blockchain.CurrentHeader(), nil
+	}
+	if header := b.blockchain.GetHeaderByNumber(number.Uint64()); header != nil {
+		return header, nil
+	}
+	return nil, bazacoin.NotFound
+}
+
+// TransactionCount returns the number of transactions in a given block.
+func (b *SimulatedBackend) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	block, err := b.blockByHash(blockHash)
+	if err != nil {
+		return 0, err
+	}
+	return uint(block.Transactions().Len()), nil
+}
+
+// TransactionInBlock returns the transaction for a specific block at a specific index.
+func (b *SimulatedBackend) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	block, err := b.blockByHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	transactions := block.Transactions()
+	if uint(len(transactions)) <= index {
+		return nil, bazacoin.NotFound
+	}
+	return transactions[index], nil
+}
+
+// SubscribeNewHead returns a subscription delivering the header of every block
+// committed to the simulated chain.
+func (b *SimulatedBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (bazacoin.Subscription, error) {
+	sub := b.mux.Subscribe(core.ChainHeadEvent{})
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev, ok := <-sub.Chan():
+				if !ok {
+					return nil
+				}
+				select {
+				case ch <- ev.Data.(core.ChainHeadEvent).Block.Header():
+				case <-quit:
+					return nil
+				}
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
 // PendingCodeAt returns the code associated with an account in the pending state.
 func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
 	b.mu.Lock()
@@ -190,6 +352,15 @@ func (b *SimulatedBackend) PendingNonceAt(ctx context.Context, account common.Ad
 	return b.pendingState.GetOrNewStateObject(account).Nonce(), nil
 }
 
+// PendingBalanceAt implements PendingStateReader.PendingBalanceAt, retrieving
+// the balance currently pending for the account.
+func (b *SimulatedBackend) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.pendingState.GetBalance(account), nil
+}
+
 // SuggestGasPrice implements ContractTransactor.SuggestGasPrice. Since the simulated
 // chain doens't have miners, we just return a gas price of 1 for any call.
 func (b *SimulatedBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
@@ -272,17 +443,111 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
 
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
+	return b.regenerate(0, tx)
+}
+
+// AdjustTime shifts the timestamp of the pending block by the given duration,
+// allowing time-dependent contract logic to be tested without waiting. The shift
+// is cumulative with earlier adjustments until the block is committed.
+func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.regenerate(int64(adjustment.Seconds()))
+}
+
+// regenerate recreates the pending block on top of the current head, keeping its
+// transactions and time offset, appending the given ones and shifting the time by
+// the given number of seconds.
+func (b *SimulatedBackend) regenerate(seconds int64, txs ...*types.Transaction) error {
+	parent := b.blockchain.CurrentBlock()
+
+	// Blocks are generated 10 seconds apart, anything more is a previous adjustment
+	offset := new(big.Int).Sub(b.pendingBlock.Time(), parent.Time()).Int64() - 10 + seconds
+	if 10+offset <= 0 {
+		return errTimeBeforeParent
+	}
+
+	blocks, _ := core.GenerateChain(b.config, parent, b.database, 1, func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
 			block.AddTx(tx)
 		}
-		block.AddTx(tx)
+		for _, tx := range txs {
+			block.AddTx(tx)
+		}
+		if offset != 0 {
+			block.OffsetTime(offset)
+		}
 	})
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), b.database)
 	return nil
 }
 
+// FilterLogs executes a log filter operation, blocking during execution and
+// returning all the results in one batch.
+func (b *SimulatedBackend) FilterLogs(ctx context.Context, query bazacoin.FilterQuery) ([]types.Log, error) {
+	// Initialize unset filter boundaries to run from genesis to chain head
+	from := int64(0)
+	if query.FromBlock != nil {
+		from = query.FromBlock.Int64()
+	}
+	to := int64(-1)
+	if query.ToBlock != nil {
+		to = query.ToBlock.Int64()
+	}
+	// Construct and execute the filter
+	filter := filters.New(&filterBackend{b.database, b.blockchain, nil}, false)
+	filter.SetBeginBlock(from)
+	filter.SetEndBlock(to)
+	filter.SetAddresses(query.Addresses)
+	filter.SetTopics(query.Topics)
+
+	logs, err := filter.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.Log, len(logs))
+	for i, log := range logs {
+		res[i] = *log
+	}
+	return res, nil
+}
+
+// SubscribeFilterLogs creates a background log filtering operation, returning a
+// subscription immediately, which can be used to stream the found events.
+func (b *SimulatedBackend) SubscribeFilterLogs(ctx context.Context, query bazacoin.FilterQuery, ch chan<- types.Log) (bazacoin.Subscription, error) {
+	// Subscribe to contract events
+	sink := make(chan []*types.Log)
+
+	sub, err := b.events.SubscribeLogs(filters.FilterCriteria(query), sink)
+	if err != nil {
+		return nil, err
+	}
+	// Since we're getting logs in batches, we need to flatten them into a plain stream
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case logs := <-sink:
+				for _, log := range logs {
+					select {
+					case ch <- *log:
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
 // callmsg implements core.Message to allow passing it as a transaction simulator.
 type callmsg struct {
 	bazacoin.CallMsg
@@ -296,3 +561,25 @@ func (m callmsg) GasPrice() *big.Int   { return m.CallMsg.GasPrice }
 func (m callmsg) Gas() *big.Int        { return m.CallMsg.Gas }
 func (m callmsg) Value() *big.Int      { return m.CallMsg.Value }
 func (m callmsg) Data() []byte         { return m.CallMsg.Data }
+
+// filterBackend implements filters.Backend to support filtering for logs without
+// taking bloom-bits acceleration structures into account.
+type filterBackend struct {
+	db  bzcdb.Database
+	bc  *core.BlockChain
+	mux *event.TypeMux
+}
+
+func (fb *filterBackend) ChainDb() bzcdb.Database  { return fb.db }
+func (fb *filterBackend) EventMux() *event.TypeMux { return fb.mux }
+
+func (fb *filterBackend) HeaderByNumber(ctx context.Context, block rpc.BlockNumber) (*types.Header, error) {
+	if block == rpc.LatestBlockNumber {
+		return fb.bc.CurrentHeader(), nil
+	}
+	return fb.bc.GetHeaderByNumber(uint64(block.Int64())), nil
+}
+
+func (fb *filterBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return core.GetBlockReceipts(fb.db, hash, core.GetBlockNumber(fb.db, hash)), nil
+}
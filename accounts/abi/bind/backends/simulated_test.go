@@ -0,0 +1,161 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/params"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(1000000000000000000)
+)
+
+// newTestTransfer creates a signed value transfer from the test account.
+func newTestTransfer(t *testing.T, nonce uint64, value int64) *types.Transaction {
+	tx := types.NewTransaction(nonce, common.Address{1}, big.NewInt(value), new(big.Int).SetUint64(params.TxGas), big.NewInt(1), nil)
+	signed, err := types.SignTx(tx, types.HomesteadSigner{}, testKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return signed
+}
+
+// Tests that blocks, headers and transactions can be retrieved from the simulated
+// chain, both pending and committed.
+func TestSimulatedChainAccess(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{testAddr: {Balance: testBalance}})
+	ctx := context.Background()
+
+	tx := newTestTransfer(t, 0, 1000)
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	if _, pending, err := sim.TransactionByHash(ctx, tx.Hash()); err != nil || !pending {
+		t.Fatalf("pending transaction lookup mismatch: pending %v, err %v", pending, err)
+	}
+	if nonce, _ := sim.PendingNonceAt(ctx, testAddr); nonce != 1 {
+		t.Errorf("pending nonce mismatch: have %d, want %d", nonce, 1)
+	}
+	if balance, _ := sim.PendingBalanceAt(ctx, common.Address{1}); balance.Int64() != 1000 {
+		t.Errorf("pending balance mismatch: have %v, want %d", balance, 1000)
+	}
+	if balance, _ := sim.BalanceAt(ctx, common.Address{1}, nil); balance.Sign() != 0 {
+		t.Errorf("committed balance mismatch: have %v, want 0", balance)
+	}
+	sim.Commit()
+
+	if _, pending, err := sim.TransactionByHash(ctx, tx.Hash()); err != nil || pending {
+		t.Fatalf("mined transaction lookup mismatch: pending %v, err %v", pending, err)
+	}
+	header, err := sim.HeaderByNumber(ctx, nil)
+	if err != nil || header.Number.Uint64() != 1 {
+		t.Fatalf("head header mismatch: %v, err %v", header, err)
+	}
+	block, err := sim.BlockByNumber(ctx, big.NewInt(1))
+	if err != nil || block.Hash() != header.Hash() {
+		t.Fatalf("block by number mismatch: %v, err %v", block, err)
+	}
+	if count, _ := sim.TransactionCount(ctx, block.Hash()); count != 1 {
+		t.Errorf("transaction count mismatch: have %d, want %d", count, 1)
+	}
+	if included, err := sim.TransactionInBlock(ctx, block.Hash(), 0); err != nil || included.Hash() != tx.Hash() {
+		t.Errorf("included transaction mismatch: %v, err %v", included, err)
+	}
+	if _, err := sim.BlockByNumber(ctx, big.NewInt(2)); err != bazacoin.NotFound {
+		t.Errorf("future block lookup error mismatch: have %v, want %v", err, bazacoin.NotFound)
+	}
+	if _, _, err := sim.TransactionByHash(ctx, common.Hash{1}); err != bazacoin.NotFound {
+		t.Errorf("unknown transaction lookup error mismatch: have %v, want %v", err, bazacoin.NotFound)
+	}
+}
+
+// Tests that the time of the pending block can be fast forwarded, keeping the
+// pending transactions included.
+func TestSimulatedAdjustTime(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{testAddr: {Balance: testBalance}})
+	ctx := context.Background()
+
+	parent, _ := sim.HeaderByNumber(ctx, nil)
+	if err := sim.SendTransaction(ctx, newTestTransfer(t, 0, 1)); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	if err := sim.AdjustTime(time.Hour); err != nil {
+		t.Fatalf("failed to adjust time: %v", err)
+	}
+	if err := sim.AdjustTime(time.Minute); err != nil {
+		t.Fatalf("failed to adjust time: %v", err)
+	}
+	if err := sim.AdjustTime(-2 * time.Hour); err == nil {
+		t.Fatalf("adjusted time before parent block")
+	}
+	sim.Commit()
+
+	head, _ := sim.BlockByNumber(ctx, nil)
+	if have, want := head.Time().Uint64()-parent.Time.Uint64(), uint64(10+3600+60); have != want {
+		t.Errorf("block time shift mismatch: have %d, want %d", have, want)
+	}
+	if len(head.Transactions()) != 1 {
+		t.Errorf("pending transactions dropped: have %d, want %d", len(head.Transactions()), 1)
+	}
+}
+
+// Tests that the simulated chain uses the requested block gas limit.
+func TestSimulatedGasLimit(t *testing.T) {
+	sim := NewSimulatedBackendWithGasLimit(core.GenesisAlloc{testAddr: {Balance: testBalance}}, 8000000)
+
+	genesis, err := sim.HeaderByNumber(context.Background(), big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to retrieve genesis header: %v", err)
+	}
+	if genesis.GasLimit.Uint64() != 8000000 {
+		t.Errorf("gas limit mismatch: have %v, want %d", genesis.GasLimit, 8000000)
+	}
+}
+
+// Tests that committed blocks are announced to the head subscribers.
+func TestSimulatedSubscribeNewHead(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{testAddr: {Balance: testBalance}})
+
+	heads := make(chan *types.Header, 1)
+	sub, err := sim.SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("failed to subscribe to new heads: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	sim.Commit()
+	select {
+	case head := <-heads:
+		if head.Number.Uint64() != 1 {
+			t.Errorf("announced head number mismatch: have %v, want %d", head.Number, 1)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("new head announcement timeout")
+	}
+}
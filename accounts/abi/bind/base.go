@@ -27,6 +27,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/event"
 )
 
 // SignerFn is a signer function callback when a contract requires a method to
@@ -55,6 +56,23 @@ type TransactOpts struct {
 	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
 }
 
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	Start uint64  // Start of the queried range
+	End   *uint64 // End of the range (nil = latest)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// WatchOpts is the collection of options to fine tune subscribing for events
+// within a bound contract.
+type WatchOpts struct {
+	Start *uint64 // Start of the queried range (nil = latest)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
 // BoundContract is the base wrapper object that reflects a contract on the
 // Bazacoin network. It contains a collection of methods that are used by the
 // higher level contract bindings to operate.
@@ -63,16 +81,18 @@ type BoundContract struct {
 	abi        abi.ABI            // Reflect based ABI to access the correct Bazacoin methods
 	caller     ContractCaller     // Read interface to interact with the blockchain
 	transactor ContractTransactor // Write interface to interact with the blockchain
+	filterer   ContractFilterer   // Event filtering to interact with the blockchain
 }
 
-// NewBoundContract creates a low level contract interface through which calls
-// and transactions may be made through.
-func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor) *BoundContract {
+// NewBoundContract creates a low level contract interface through which calls,
+// transactions and event filters may be made through.
+func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *BoundContract {
 	return &BoundContract{
 		address:    address,
 		abi:        abi,
 		caller:     caller,
 		transactor: transactor,
+		filterer:   filterer,
 	}
 }
 
@@ -80,7 +100,7 @@ func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller
 // deployment address with a Go wrapper.
 func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *BoundContract, error) {
 	// Otherwise try to deploy the contract
-	c := NewBoundContract(common.Address{}, abi, backend, backend)
+	c := NewBoundContract(common.Address{}, abi, backend, backend, backend)
 
 	input, err := c.abi.Pack("", params...)
 	if err != nil {
@@ -225,6 +245,115 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 	return signedTx, nil
 }
 
+// FilterLogs filters contract logs for past blocks, returning the necessary
+// channels to construct a strongly typed bound iterator on top of them.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(FilterOpts)
+	}
+	// Construct the topic set of the requested event
+	config, err := c.filterQuery(name, query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.FromBlock = new(big.Int).SetUint64(opts.Start)
+	if opts.End != nil {
+		config.ToBlock = new(big.Int).SetUint64(*opts.End)
+	}
+	// Retrieve the matching logs and stream them from the background
+	buff, err := c.filterer.FilterLogs(ensureContext(opts.Context), config)
+	if err != nil {
+		return nil, nil, err
+	}
+	logs := make(chan types.Log, 128)
+	sub := event.NewSubscription(func(quit <-chan struct{}) error {
+		for _, log := range buff {
+			select {
+			case logs <- log:
+			case <-quit:
+				return nil
+			}
+		}
+		return nil
+	})
+	return logs, sub, nil
+}
+
+// WatchLogs filters subscribes to contract logs for future blocks, returning a
+// subscription object that can be used to tear down the watcher.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(WatchOpts)
+	}
+	// Construct the topic set of the requested event
+	config, err := c.filterQuery(name, query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Start != nil {
+		config.FromBlock = new(big.Int).SetUint64(*opts.Start)
+	}
+	// Start the background filtering
+	logs := make(chan types.Log, 128)
+	sub, err := c.filterer.SubscribeFilterLogs(ensureContext(opts.Context), config, logs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logs, sub, nil
+}
+
+// UnpackLog unpacks a retrieved log into the provided output structure.
+func (c *BoundContract) UnpackLog(out interface{}, name string, log types.Log) error {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return fmt.Errorf("event '%s' not found", name)
+	}
+	if len(log.Data) > 0 {
+		if err := c.abi.Unpack(out, name, log.Data); err != nil {
+			return err
+		}
+	}
+	// Non-anonymous events reserve the first topic for the event signature
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 {
+			return errors.New("missing event signature topic")
+		}
+		topics = topics[1:]
+	}
+	// Unnamed arguments are named after their position, same as in the bindings
+	var indexed []abi.Argument
+	for i, arg := range event.Inputs {
+		if arg.Indexed {
+			if arg.Name == "" {
+				arg.Name = fmt.Sprintf("arg%d", i)
+			}
+			indexed = append(indexed, arg)
+		}
+	}
+	return parseTopics(out, indexed, topics)
+}
+
+// filterQuery assembles the log filter query matching the given event of the
+// bound contract, restricted by the indexed argument values.
+func (c *BoundContract) filterQuery(name string, query ...[]interface{}) (bazacoin.FilterQuery, error) {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return bazacoin.FilterQuery{}, fmt.Errorf("event '%s' not found", name)
+	}
+	// Non-anonymous events are selected by their signature in the first topic
+	if !event.Anonymous {
+		query = append([][]interface{}{{event.Id()}}, query...)
+	}
+	topics, err := makeTopics(query...)
+	if err != nil {
+		return bazacoin.FilterQuery{}, err
+	}
+	return bazacoin.FilterQuery{Addresses: []common.Address{c.address}, Topics: topics}, nil
+}
+
 func ensureContext(ctx context.Context) context.Context {
 	if ctx == nil {
 		return context.TODO()
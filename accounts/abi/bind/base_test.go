@@ -0,0 +1,70 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/accounts/abi"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/types"
+)
+
+// Tests that logs of events with unnamed arguments, both indexed and not, are
+// unpacked into the positional fields of the generated event structs.
+func TestUnpackLogUnnamed(t *testing.T) {
+	definition := `[{"anonymous":false,"inputs":[
+		{"indexed":true,"name":"","type":"address"},
+		{"indexed":false,"name":"","type":"uint256"},
+		{"indexed":true,"name":"","type":"uint256"},
+		{"indexed":false,"name":"","type":"uint256"}
+	],"name":"Unnamed","type":"event"}]`
+
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	contract := NewBoundContract(common.Address{}, parsed, nil, nil, nil)
+
+	log := types.Log{
+		Topics: []common.Hash{
+			parsed.Events["Unnamed"].Id(),
+			common.HexToHash("0x0102"),
+			common.BigToHash(big.NewInt(3)),
+		},
+		Data: append(common.LeftPadBytes([]byte{2}, 32), common.LeftPadBytes([]byte{4}, 32)...),
+	}
+	var out struct {
+		Arg0 common.Address
+		Arg1 *big.Int
+		Arg2 *big.Int
+		Arg3 *big.Int
+	}
+	if err := contract.UnpackLog(&out, "Unnamed", log); err != nil {
+		t.Fatalf("failed to unpack log: %v", err)
+	}
+	if out.Arg0 != common.HexToAddress("0x0102") {
+		t.Errorf("arg0 mismatch: have %x, want %x", out.Arg0, common.HexToAddress("0x0102"))
+	}
+	for i, arg := range []*big.Int{out.Arg1, out.Arg2, out.Arg3} {
+		if arg == nil || arg.Int64() != int64(i+2) {
+			t.Errorf("arg%d mismatch: have %v, want %d", i+1, arg, i+2)
+		}
+	}
+}
@@ -22,11 +22,11 @@ package bind
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
 	"text/template"
-	"unicode"
 
 	"github.com/bazacoin/go-bazacoin/accounts/abi"
 	"golang.org/x/tools/imports"
@@ -47,7 +47,10 @@ const (
 // manually maintain hard coded strings that break on runtime.
 func Bind(types []string, abis []string, bytecodes []string, pkg string, lang Lang) (string, error) {
 	// Process each individual contract requested binding
-	contracts := make(map[string]*tmplContract)
+	var (
+		contracts = make(map[string]*tmplContract)
+		structs   = make(map[string]*tmplStruct)
+	)
 
 	for i := 0; i < len(types); i++ {
 		// Parse the actual ABI to generate the binding for
@@ -55,18 +58,19 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 		if err != nil {
 			return "", err
 		}
-		// Strip any whitespace from the JSON ABI
-		strippedABI := strings.Map(func(r rune) rune {
-			if unicode.IsSpace(r) {
-				return -1
-			}
-			return r
-		}, abis[i])
+		// Strip any insignificant whitespace from the JSON ABI, keeping the ones
+		// inside strings (e.g. struct names)
+		compacted := new(bytes.Buffer)
+		if err := json.Compact(compacted, []byte(abis[i])); err != nil {
+			return "", err
+		}
+		strippedABI := compacted.String()
 
-		// Extract the call and transact methods, and sort them alphabetically
+		// Extract the call and transact methods, and the events raised by the contract
 		var (
 			calls     = make(map[string]*tmplMethod)
 			transacts = make(map[string]*tmplMethod)
+			events    = make(map[string]*tmplEvent)
 		)
 		for _, original := range evmABI.Methods {
 			// Normalize the method for capital cases and non-anonymous inputs/outputs
@@ -87,6 +91,12 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 					normalized.Outputs[j].Name = capitalise(output.Name)
 				}
 			}
+			for _, input := range original.Inputs {
+				collectStructs(input.Type, structs)
+			}
+			for _, output := range original.Outputs {
+				collectStructs(output.Type, structs)
+			}
 			// Append the methods to the call or transact lists
 			if original.Const {
 				calls[original.Name] = &tmplMethod{Original: original, Normalized: normalized, Structured: structured(original)}
@@ -94,27 +104,66 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 				transacts[original.Name] = &tmplMethod{Original: original, Normalized: normalized, Structured: structured(original)}
 			}
 		}
+		for _, original := range evmABI.Events {
+			// Normalize the event for capital cases and non-anonymous inputs
+			normalized := original
+			normalized.Name = methodNormalizer[lang](original.Name)
+
+			normalized.Inputs = make([]abi.Argument, len(original.Inputs))
+			copy(normalized.Inputs, original.Inputs)
+			for j, input := range normalized.Inputs {
+				if input.Name == "" {
+					normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
+				}
+				if !input.Indexed {
+					collectStructs(input.Type, structs)
+				}
+			}
+			// Append the event to the accumulator list
+			events[original.Name] = &tmplEvent{Original: original, Normalized: normalized}
+		}
+		for _, input := range evmABI.Constructor.Inputs {
+			collectStructs(input.Type, structs)
+		}
 		contracts[types[i]] = &tmplContract{
 			Type:        capitalise(types[i]),
 			InputABI:    strings.Replace(strippedABI, "\"", "\\\"", -1),
-			InputBin:    strings.TrimSpace(bytecodes[i]),
+			InputBin:    strings.TrimPrefix(strings.TrimSpace(bytecodes[i]), "0x"),
 			Constructor: evmABI.Constructor,
 			Calls:       calls,
 			Transacts:   transacts,
+			Events:      events,
 		}
 	}
+	// Mobile bindings can only use the types the mobile wrappers can carry
+	if lang != LangGo {
+		for _, contract := range contracts {
+			if err := checkMobileTypes(contract); err != nil {
+				return "", err
+			}
+		}
+	}
+	nameStructs(structs)
+
 	// Generate the contract template data content and render it
 	data := &tmplData{
 		Package:   pkg,
 		Contracts: contracts,
+		Structs:   structs,
 	}
 	buffer := new(bytes.Buffer)
 
 	funcs := map[string]interface{}{
-		"bindtype":     bindType[lang],
-		"namedtype":    namedType[lang],
-		"capitalise":   capitalise,
-		"decapitalise": decapitalise,
+		"bindtype":        func(kind abi.Type) string { return bindType[lang](kind, structs) },
+		"bindtopictype":   func(kind abi.Type) string { return bindTopicType[lang](kind, structs) },
+		"bindtopicrule":   func(kind abi.Type) string { return bindMobileType[lang](mobileTopicRule(kind)) },
+		"mobiletype":      mobileType,
+		"mobiletopictype": mobileTopicType,
+		"mobiletopicrule": mobileTopicRule,
+		"indexed":         indexed,
+		"zerovalue":       zeroValue[lang],
+		"capitalise":      capitalise,
+		"decapitalise":    decapitalise,
 	}
 	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSource[lang]))
 	if err := tmpl.Execute(buffer, data); err != nil {
@@ -134,166 +183,319 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 
 // bindType is a set of type binders that convert Solidity types to some supported
 // programming language.
-var bindType = map[Lang]func(kind abi.Type) string{
+var bindType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
 	LangGo:   bindTypeGo,
 	LangJava: bindTypeJava,
+	LangObjC: bindTypeObjC,
 }
 
 // bindTypeGo converts a Solidity type to a Go one. Since there is no clear mapping
 // from all Solidity types to Go ones (e.g. uint17), those that cannot be exactly
-// mapped will use an upscaled type (e.g. *big.Int).
-func bindTypeGo(kind abi.Type) string {
-	stringKind := kind.String()
-
-	switch {
-	case strings.HasPrefix(stringKind, "address"):
-		parts := regexp.MustCompile(`address(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 2 {
-			return stringKind
-		}
-		return fmt.Sprintf("%scommon.Address", parts[1])
-
-	case strings.HasPrefix(stringKind, "bytes"):
-		parts := regexp.MustCompile(`bytes([0-9]*)(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 3 {
-			return stringKind
+// mapped will use an upscaled type (e.g. *big.Int). Tuples are mapped to the
+// generated structs, arrays and slices are converted recursively.
+func bindTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	if composite(kind) {
+		if kind.IsSlice {
+			return "[]" + bindTypeGo(*kind.Elem, structs)
 		}
-		return fmt.Sprintf("%s[%s]byte", parts[2], parts[1])
-
-	case strings.HasPrefix(stringKind, "int") || strings.HasPrefix(stringKind, "uint"):
-		parts := regexp.MustCompile(`(u)?int([0-9]*)(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 4 {
-			return stringKind
+		return fmt.Sprintf("[%d]", kind.SliceSize) + bindTypeGo(*kind.Elem, structs)
+	}
+	switch kind.T {
+	case abi.AddressTy:
+		return "common.Address"
+	case abi.HashTy:
+		return "common.Hash"
+	case abi.IntTy, abi.UintTy:
+		prefix := ""
+		if kind.T == abi.UintTy {
+			prefix = "u"
 		}
-		switch parts[2] {
-		case "8", "16", "32", "64":
-			return fmt.Sprintf("%s%sint%s", parts[3], parts[1], parts[2])
+		switch kind.Size {
+		case 8, 16, 32, 64:
+			return fmt.Sprintf("%sint%d", prefix, kind.Size)
 		}
-		return fmt.Sprintf("%s*big.Int", parts[3])
+		return "*big.Int"
+	case abi.BoolTy:
+		return "bool"
+	case abi.StringTy:
+		return "string"
+	case abi.BytesTy:
+		return "[]byte"
+	case abi.FixedBytesTy, abi.FunctionTy:
+		return fmt.Sprintf("[%d]byte", kind.SliceSize)
+	case abi.TupleTy:
+		return structs[structKey(kind)].Name
+	default:
+		return kind.String()
+	}
+}
 
-	case strings.HasPrefix(stringKind, "bool") || strings.HasPrefix(stringKind, "string"):
-		parts := regexp.MustCompile(`([a-z]+)(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 3 {
-			return stringKind
-		}
-		return fmt.Sprintf("%s%s", parts[2], parts[1])
+// bindTypeJava converts a Solidity type to a Java one, using the wrapper types
+// of the mobile package for everything that has no native Java counterpart.
+func bindTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	return javaType(mobileType(kind))
+}
 
-	default:
-		return stringKind
+// bindTypeObjC converts a Solidity type to an Objective-C one, using the wrapper
+// types of the mobile package for everything that has no native counterpart.
+func bindTypeObjC(kind abi.Type, structs map[string]*tmplStruct) string {
+	return objcType(mobileType(kind))
+}
+
+// bindTopicType is a set of type binders that convert Solidity types of indexed
+// event arguments to some supported programming language.
+var bindTopicType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:   bindTopicTypeGo,
+	LangJava: bindTopicTypeJava,
+	LangObjC: bindTopicTypeObjC,
+}
+
+// bindTopicTypeGo converts a Solidity topic type to a Go one. It is almost the
+// same functionality as for simple types, but dynamic types get converted to
+// hashes, as only their hashes are stored in the log topics.
+func bindTopicTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	if hashedTopic(kind) {
+		return "common.Hash"
 	}
+	return bindTypeGo(kind, structs)
 }
 
-// bindTypeJava converts a Solidity type to a Java one. Since there is no clear mapping
-// from all Solidity types to Java ones (e.g. uint17), those that cannot be exactly
-// mapped will use an upscaled type (e.g. BigDecimal).
-func bindTypeJava(kind abi.Type) string {
-	stringKind := kind.String()
-
-	switch {
-	case strings.HasPrefix(stringKind, "address"):
-		parts := regexp.MustCompile(`address(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 2 {
-			return stringKind
-		}
-		if parts[1] == "" {
-			return fmt.Sprintf("Address")
-		}
-		return fmt.Sprintf("Addresses")
+// bindTopicTypeJava converts a Solidity topic type to a Java one. It is almost
+// the same functionality as for simple types, but dynamic types get converted
+// to hashes, as only their hashes are stored in the log topics.
+func bindTopicTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	return javaType(mobileTopicType(kind))
+}
 
-	case strings.HasPrefix(stringKind, "bytes"):
-		parts := regexp.MustCompile(`bytes([0-9]*)(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 3 {
-			return stringKind
-		}
-		if parts[2] != "" {
-			return "byte[][]"
+// bindTopicTypeObjC converts a Solidity topic type to an Objective-C one, with
+// dynamic types converted to hashes similarly to the other languages.
+func bindTopicTypeObjC(kind abi.Type, structs map[string]*tmplStruct) string {
+	return objcType(mobileTopicType(kind))
+}
+
+// bindMobileType is a set of converters from the wrapper types of the mobile
+// package to the matching types of the supported mobile languages.
+var bindMobileType = map[Lang]func(string) string{
+	LangJava: javaType,
+	LangObjC: objcType,
+}
+
+// mobilePlurals maps the mobile wrapper types to the wrapper of a list of them.
+// Small integers are carried as big ints in lists, as the mobile platforms have
+// no way to pass arrays of primitive numbers across the language boundary.
+var mobilePlurals = map[string]string{
+	"Address": "Addresses",
+	"Hash":    "Hashes",
+	"Int8":    "BigInts",
+	"Int16":   "BigInts",
+	"Int32":   "BigInts",
+	"Int64":   "BigInts",
+	"BigInt":  "BigInts",
+	"Bool":    "Bools",
+	"String":  "Strings",
+	"Binary":  "Binaries",
+}
+
+// mobileType converts a Solidity type to the name of the mobile wrapper type it
+// is carried in across the language boundary. The names double as suffixes of
+// the mobile Interface setters and getters. Types without a mobile counterpart
+// (tuples and nested arrays) are converted to the empty string.
+func mobileType(kind abi.Type) string {
+	if composite(kind) {
+		return mobilePlurals[mobileType(*kind.Elem)]
+	}
+	switch kind.T {
+	case abi.AddressTy:
+		return "Address"
+	case abi.HashTy:
+		return "Hash"
+	case abi.IntTy:
+		switch kind.Size {
+		case 8, 16, 32, 64:
+			return fmt.Sprintf("Int%d", kind.Size)
 		}
+		return "BigInt"
+	case abi.UintTy:
+		return "BigInt"
+	case abi.BoolTy:
+		return "Bool"
+	case abi.StringTy:
+		return "String"
+	case abi.BytesTy, abi.FixedBytesTy, abi.FunctionTy:
+		return "Binary"
+	}
+	return ""
+}
+
+// mobileTopicType converts a Solidity topic type to a mobile wrapper type, with
+// dynamic types converted to hashes.
+func mobileTopicType(kind abi.Type) string {
+	if hashedTopic(kind) {
+		return "Hash"
+	}
+	return mobileType(kind)
+}
+
+// mobileTopicRule converts a Solidity topic type to the mobile wrapper type of
+// the list of values accepted when filtering for it.
+func mobileTopicRule(kind abi.Type) string {
+	return mobilePlurals[mobileTopicType(kind)]
+}
+
+// javaType converts a mobile wrapper type to the Java type it is exposed as.
+func javaType(kind string) string {
+	switch kind {
+	case "Int8":
+		return "byte"
+	case "Int16":
+		return "short"
+	case "Int32":
+		return "int"
+	case "Int64":
+		return "long"
+	case "Bool":
+		return "boolean"
+	case "Binary":
 		return "byte[]"
+	}
+	return kind
+}
 
-	case strings.HasPrefix(stringKind, "int") || strings.HasPrefix(stringKind, "uint"):
-		parts := regexp.MustCompile(`(u)?int([0-9]*)(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 4 {
-			return stringKind
+// objcType converts a mobile wrapper type to the Objective-C type it is exposed
+// as by gomobile, prefixing the wrapper classes with the package name.
+func objcType(kind string) string {
+	switch kind {
+	case "Int8", "Int16", "Int32", "Int64":
+		return fmt.Sprintf("int%s_t", kind[3:])
+	case "Bool":
+		return "BOOL"
+	case "String":
+		return "NSString*"
+	case "Binary":
+		return "NSData*"
+	}
+	return "Geth" + kind + "*"
+}
+
+// zeroValue is a set of functions returning the zero value literal of a type in
+// the supported programming languages, used to bail out of generated methods.
+var zeroValue = map[Lang]func(string) string{
+	LangGo:   func(string) string { panic("this shouldn't be needed") },
+	LangJava: func(string) string { panic("this shouldn't be needed") },
+	LangObjC: func(kind string) string {
+		if strings.HasSuffix(kind, "*") {
+			return "nil"
 		}
-		switch parts[2] {
-		case "8", "16", "32", "64":
-			if parts[1] == "" {
-				if parts[3] == "" {
-					return fmt.Sprintf("int%s", parts[2])
-				}
-				return fmt.Sprintf("int%s[]", parts[2])
+		return "0"
+	},
+}
+
+// checkMobileTypes ensures that all the types used by a contract's methods and
+// events can be carried by the mobile wrappers.
+func checkMobileTypes(contract *tmplContract) error {
+	check := func(name string, args []abi.Argument) error {
+		for _, arg := range args {
+			if mobileType(arg.Type) == "" {
+				return fmt.Errorf("bind: type %v of %s.%s is not supported by the mobile bindings", arg.Type, contract.Type, name)
 			}
 		}
-		if parts[3] == "" {
-			return fmt.Sprintf("BigInt")
-		}
-		return fmt.Sprintf("BigInts")
-
-	case strings.HasPrefix(stringKind, "bool"):
-		parts := regexp.MustCompile(`bool(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 2 {
-			return stringKind
+		return nil
+	}
+	if err := check("constructor", contract.Constructor.Inputs); err != nil {
+		return err
+	}
+	for _, methods := range []map[string]*tmplMethod{contract.Calls, contract.Transacts} {
+		for _, method := range methods {
+			if err := check(method.Original.Name, method.Original.Inputs); err != nil {
+				return err
+			}
+			if err := check(method.Original.Name, method.Original.Outputs); err != nil {
+				return err
+			}
 		}
-		if parts[1] == "" {
-			return fmt.Sprintf("bool")
+	}
+	for _, event := range contract.Events {
+		if err := check(event.Original.Name, event.Original.Inputs); err != nil {
+			return err
 		}
-		return fmt.Sprintf("bool[]")
+	}
+	return nil
+}
 
-	case strings.HasPrefix(stringKind, "string"):
-		parts := regexp.MustCompile(`string(\[[0-9]*\])?`).FindStringSubmatch(stringKind)
-		if len(parts) != 2 {
-			return stringKind
+// indexed filters the indexed arguments out of an event's input list.
+func indexed(args []abi.Argument) []abi.Argument {
+	var res []abi.Argument
+	for _, arg := range args {
+		if arg.Indexed {
+			res = append(res, arg)
 		}
-		if parts[1] == "" {
-			return fmt.Sprintf("String")
-		}
-		return fmt.Sprintf("String[]")
-
-	default:
-		return stringKind
 	}
+	return res
 }
 
-// namedType is a set of functions that transform language specific types to
-// named versions that my be used inside method names.
-var namedType = map[Lang]func(string, abi.Type) string{
-	LangGo:   func(string, abi.Type) string { panic("this shouldn't be needed") },
-	LangJava: namedTypeJava,
+// composite returns whether the type is an array or slice of some element type,
+// as opposed to the natively encoded bytes, bytesN and function types.
+func composite(kind abi.Type) bool {
+	return (kind.IsSlice || kind.IsArray) && kind.T != abi.BytesTy && kind.T != abi.FixedBytesTy && kind.T != abi.FunctionTy
 }
 
-// namedTypeJava converts some primitive data types to named variants that can
-// be used as parts of method names.
-func namedTypeJava(javaKind string, solKind abi.Type) string {
-	switch javaKind {
-	case "byte[]":
-		return "Binary"
-	case "byte[][]":
-		return "Binaries"
-	case "string":
-		return "String"
-	case "string[]":
-		return "Strings"
-	case "bool":
-		return "Bool"
-	case "bool[]":
-		return "Bools"
-	case "BigInt":
-		parts := regexp.MustCompile(`(u)?int([0-9]*)(\[[0-9]*\])?`).FindStringSubmatch(solKind.String())
-		if len(parts) != 4 {
-			return javaKind
+// structKey returns a unique identifier for a tuple type, based on both the
+// names and the types of its fields.
+func structKey(kind abi.Type) string {
+	return kind.Type.String()
+}
+
+// collectStructs gathers all the tuple types contained within the given type,
+// registering a struct to generate for each of them.
+func collectStructs(kind abi.Type, structs map[string]*tmplStruct) {
+	if composite(kind) {
+		collectStructs(*kind.Elem, structs)
+		return
+	}
+	if kind.T != abi.TupleTy {
+		return
+	}
+	for _, elem := range kind.TupleElems {
+		collectStructs(*elem, structs)
+	}
+	key := structKey(kind)
+	if _, ok := structs[key]; ok {
+		return
+	}
+	fields := make([]*tmplField, len(kind.TupleElems))
+	for i, elem := range kind.TupleElems {
+		fields[i] = &tmplField{Type: *elem, Name: abi.ToCamelCase(kind.TupleRawNames[i])}
+	}
+	structs[key] = &tmplStruct{Name: kind.TupleRawName, Fields: fields}
+}
+
+// nameStructs assigns a unique Go type name to all the collected structs. The
+// struct names defined in the Solidity source are used when available, the
+// others get a deterministic positional name.
+func nameStructs(structs map[string]*tmplStruct) {
+	keys := make([]string, 0, len(structs))
+	for key := range structs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	taken := make(map[string]bool)
+	for _, key := range keys {
+		if name := structs[key].Name; name != "" && !taken[capitalise(name)] {
+			structs[key].Name = capitalise(name)
+			taken[structs[key].Name] = true
+		} else {
+			structs[key].Name = ""
 		}
-		switch parts[2] {
-		case "8", "16", "32", "64":
-			if parts[3] == "" {
-				return capitalise(fmt.Sprintf("%sint%s", parts[1], parts[2]))
+	}
+	for i, key := range keys {
+		for structs[key].Name == "" {
+			if name := fmt.Sprintf("Struct%d", i); !taken[name] {
+				structs[key].Name = name
+				taken[name] = true
 			}
-			return capitalise(fmt.Sprintf("%sint%ss", parts[1], parts[2]))
-
-		default:
-			return javaKind
+			i++
 		}
-	default:
-		return javaKind
 	}
 }
 
@@ -302,6 +504,7 @@ func namedTypeJava(javaKind string, solKind abi.Type) string {
 var methodNormalizer = map[Lang]func(string) string{
 	LangGo:   capitalise,
 	LangJava: decapitalise,
+	LangObjC: decapitalise,
 }
 
 // capitalise makes the first character of a string upper case.
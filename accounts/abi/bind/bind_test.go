@@ -18,14 +18,22 @@ package bind
 
 import (
 	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/bazacoin/go-bazacoin/accounts/abi"
 	"github.com/bazacoin/go-bazacoin/common"
 	"golang.org/x/tools/imports"
 )
@@ -447,6 +455,196 @@ var bindTests = []struct {
 			}
 		`,
 	},
+	// Tests that events can be filtered and watched through the generated bindings
+	{
+		`Eventer`,
+		`
+			contract Eventer {
+				event Transfer(address indexed from, address indexed to, uint256 value);
+
+				function transfer(address to, uint256 value) {
+					Transfer(msg.sender, to, value);
+				}
+			}
+		`,
+		`6031600c60003960316000f3602435600052600435337fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60206000a300`,
+		`[{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[],"type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"}]`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}})
+
+			// Deploy an eventer contract and raise a few events through it
+			_, _, eventer, err := DeployEventer(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy eventer contract: %v", err)
+			}
+			sim.Commit()
+
+			recipients := []common.Address{{1}, {2}, {1}}
+			for i, to := range recipients {
+				if _, err := eventer.Transfer(auth, to, big.NewInt(int64(i+1))); err != nil {
+					t.Fatalf("Failed to raise transfer event %d: %v", i, err)
+				}
+				sim.Commit()
+			}
+			// Filter the past events, both unrestricted and by indexed argument
+			it, err := eventer.FilterTransfer(nil, nil, nil)
+			if err != nil {
+				t.Fatalf("Failed to filter transfer events: %v", err)
+			}
+			for i := 0; i < len(recipients); i++ {
+				if !it.Next() {
+					t.Fatalf("Transfer event %d missing: %v", i, it.Error())
+				}
+				if it.Event.From != auth.From {
+					t.Errorf("Transfer event %d sender mismatch: have %x, want %x", i, it.Event.From, auth.From)
+				}
+				if it.Event.To != recipients[i] {
+					t.Errorf("Transfer event %d recipient mismatch: have %x, want %x", i, it.Event.To, recipients[i])
+				}
+				if it.Event.Value.Int64() != int64(i+1) {
+					t.Errorf("Transfer event %d value mismatch: have %v, want %d", i, it.Event.Value, i+1)
+				}
+			}
+			if it.Next() {
+				t.Fatalf("Unexpected transfer event: %+v", it.Event)
+			}
+			if err := it.Error(); err != nil {
+				t.Fatalf("Transfer event iteration failed: %v", err)
+			}
+			it.Close()
+
+			it, err = eventer.FilterTransfer(nil, nil, []common.Address{{1}})
+			if err != nil {
+				t.Fatalf("Failed to filter transfer events: %v", err)
+			}
+			var values []int64
+			for it.Next() {
+				values = append(values, it.Event.Value.Int64())
+			}
+			if len(values) != 2 || values[0] != 1 || values[1] != 3 {
+				t.Fatalf("Filtered transfer values mismatch: have %v, want [1 3]", values)
+			}
+			it.Close()
+
+			// Watch the new events raised towards a single recipient
+			sink := make(chan *EventerTransfer, 4)
+			sub, err := eventer.WatchTransfer(nil, sink, nil, []common.Address{{2}})
+			if err != nil {
+				t.Fatalf("Failed to watch transfer events: %v", err)
+			}
+			defer sub.Unsubscribe()
+
+			if _, err := eventer.Transfer(auth, common.Address{1}, big.NewInt(4)); err != nil {
+				t.Fatalf("Failed to raise transfer event: %v", err)
+			}
+			if _, err := eventer.Transfer(auth, common.Address{2}, big.NewInt(5)); err != nil {
+				t.Fatalf("Failed to raise transfer event: %v", err)
+			}
+			sim.Commit()
+
+			select {
+			case event := <-sink:
+				if event.To != (common.Address{2}) || event.Value.Int64() != 5 {
+					t.Fatalf("Watched transfer event mismatch: have %+v", event)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Watched transfer event timeout")
+			}
+			select {
+			case event := <-sink:
+				t.Fatalf("Unexpected watched transfer event: %+v", event)
+			case <-time.After(100 * time.Millisecond):
+			}
+		`,
+	},
+	// Tests that tuples and nested dynamic arrays are bound to Go structs and round
+	// trip through the EVM correctly
+	{
+		`Tuple`,
+		`
+			pragma experimental ABIEncoderV2;
+
+			contract Tuple {
+				struct S { uint a; uint[] b; T[] c; }
+				struct T { uint x; uint y; }
+
+				function echo(S s, T[2] t, uint[][] m) constant returns (S, T[2], uint[][]) {
+					assembly {
+						calldatacopy(0, 4, sub(calldatasize, 4))
+						return(0, sub(calldatasize, 4))
+					}
+				}
+			}
+		`,
+		`600e600c600039600e6000f336600490038060046000376000f3`,
+		`[{"constant":true,"inputs":[{"name":"s","type":"tuple","internalType":"struct Tuple.S","components":[{"name":"a","type":"uint256"},{"name":"b","type":"uint256[]"},{"name":"c","type":"tuple[]","internalType":"struct Tuple.T[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}]},{"name":"t","type":"tuple[2]","internalType":"struct Tuple.T[2]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]},{"name":"m","type":"uint256[][]"}],"name":"echo","outputs":[{"name":"","type":"tuple","internalType":"struct Tuple.S","components":[{"name":"a","type":"uint256"},{"name":"b","type":"uint256[]"},{"name":"c","type":"tuple[]","internalType":"struct Tuple.T[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]}]},{"name":"","type":"tuple[2]","internalType":"struct Tuple.T[2]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]},{"name":"","type":"uint256[][]"}],"type":"function"}]`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}})
+
+			// Deploy a tuple tester contract and call it with complex arguments
+			_, _, tuple, err := DeployTuple(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy tuple contract: %v", err)
+			}
+			sim.Commit()
+
+			s := TupleS{
+				A: big.NewInt(1),
+				B: []*big.Int{big.NewInt(2), big.NewInt(3)},
+				C: []TupleT{{X: big.NewInt(4), Y: big.NewInt(5)}, {X: big.NewInt(6), Y: big.NewInt(7)}},
+			}
+			ts := [2]TupleT{{X: big.NewInt(8), Y: big.NewInt(9)}, {X: big.NewInt(10), Y: big.NewInt(11)}}
+			m := [][]*big.Int{{big.NewInt(12)}, {}, {big.NewInt(13), big.NewInt(14)}}
+
+			resS, resT, resM, err := tuple.Echo(nil, s, ts, m)
+			if err != nil {
+				t.Fatalf("Failed to echo tuples: %v", err)
+			}
+			if !reflect.DeepEqual(resS, s) {
+				t.Errorf("Struct mismatch: have %+v, want %+v", resS, s)
+			}
+			if !reflect.DeepEqual(resT, ts) {
+				t.Errorf("Struct array mismatch: have %+v, want %+v", resT, ts)
+			}
+			if !reflect.DeepEqual(resM, m) {
+				t.Errorf("Nested slice mismatch: have %v, want %v", resM, m)
+			}
+		`,
+	},
+	// Tests that underscores in method and event names are kept, so differently
+	// capitalised names don't collide and earlier bindings keep their identifiers
+	{
+		`Underscorer`,
+		`
+			contract Underscorer {
+				event value_set(uint indexed old_value, uint new_value);
+
+				function set_value(uint value) {}
+				function foo() constant returns (uint) { return 1; }
+				function _foo() constant returns (uint) { return 2; }
+				function get_pair() constant returns (uint first_value, uint second_value) { return (1, 2); }
+			}
+		`,
+		``,
+		`[{"constant":false,"inputs":[{"name":"value","type":"uint256"}],"name":"set_value","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"foo","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":true,"inputs":[],"name":"_foo","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":true,"inputs":[],"name":"get_pair","outputs":[{"name":"first_value","type":"uint256"},{"name":"second_value","type":"uint256"}],"type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"name":"old_value","type":"uint256"},{"indexed":false,"name":"new_value","type":"uint256"}],"name":"value_set","type":"event"}]`,
+		`
+			var (
+				_ = (*UnderscorerTransactor).Set_value
+				_ = (*UnderscorerCaller).Foo
+				_ = (*UnderscorerCaller)._foo
+				_ = (*UnderscorerCaller).Get_pair
+				_ = (*UnderscorerFilterer).FilterValue_set
+			)
+			var event UnderscorerValue_set
+			_, _ = event.Old_value, event.New_value
+		`,
+	},
 }
 
 // Tests that packages generated by the binder can be successfully compiled and
@@ -504,3 +702,148 @@ func TestBindings(t *testing.T) {
 		t.Fatalf("failed to run binding test: %v\n%s", err, out)
 	}
 }
+
+// Tests that the packages generated by the binder type check against the current
+// sources. Contrary to TestBindings this runs in-process, so it catches template
+// breakages even if no Go toolchain is available to run the generated testers.
+func TestBindingsTypeCheck(t *testing.T) {
+	// Skip the test if the go-bazacoin sources cannot be located for importing
+	if _, err := build.Import("github.com/bazacoin/go-bazacoin/accounts/abi/bind", "", build.FindOnly); err != nil {
+		t.Skip("go-bazacoin sources not found for type checking")
+	}
+	fset := token.NewFileSet()
+	config := &types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+
+	for i, tt := range bindTests {
+		code, err := Bind([]string{tt.name}, []string{tt.abi}, []string{tt.bytecode}, "bindtest", LangGo)
+		if err != nil {
+			t.Fatalf("test %d: failed to generate binding: %v", i, err)
+		}
+		file, err := parser.ParseFile(fset, strings.ToLower(tt.name)+".go", code, 0)
+		if err != nil {
+			t.Fatalf("test %d: failed to parse binding: %v", i, err)
+		}
+		if _, err := config.Check("bindtest", fset, []*ast.File{file}, nil); err != nil {
+			t.Errorf("test %d: failed to type check binding: %v", i, err)
+		}
+	}
+}
+
+// mobileTypesABI is a contract interface touching all the types supported by the
+// mobile bindings, in all the places they may appear in.
+const mobileTypesABI = `[
+	{"constant":true,"inputs":[{"name":"a","type":"address"},{"name":"b","type":"address[]"},{"name":"c","type":"bool"},{"name":"d","type":"bool[2]"},{"name":"e","type":"string"},{"name":"f","type":"string[]"}],"name":"echoA","outputs":[{"name":"a","type":"address"},{"name":"b","type":"address[]"},{"name":"c","type":"bool"},{"name":"d","type":"bool[2]"},{"name":"e","type":"string"},{"name":"f","type":"string[]"}],"type":"function"},
+	{"constant":true,"inputs":[{"name":"a","type":"int8"},{"name":"b","type":"int16"},{"name":"c","type":"int32"},{"name":"d","type":"int64"},{"name":"e","type":"int256"},{"name":"f","type":"uint8"},{"name":"g","type":"uint256[3]"},{"name":"h","type":"int8[]"}],"name":"echoB","outputs":[{"name":"a","type":"int8"},{"name":"b","type":"int16"},{"name":"c","type":"int32"},{"name":"d","type":"int64"},{"name":"e","type":"int256"},{"name":"f","type":"uint8"},{"name":"g","type":"uint256[3]"},{"name":"h","type":"int8[]"}],"type":"function"},
+	{"constant":true,"inputs":[{"name":"a","type":"bytes"},{"name":"b","type":"bytes32"},{"name":"c","type":"bytes1[]"},{"name":"d","type":"bytes[2]"}],"name":"echoC","outputs":[{"name":"","type":"bytes"},{"name":"","type":"bytes32"},{"name":"","type":"bytes1[]"},{"name":"","type":"bytes[2]"}],"type":"function"},
+	{"constant":true,"inputs":[],"name":"single","outputs":[{"name":"","type":"uint64"}],"type":"function"},
+	{"constant":false,"inputs":[{"name":"a","type":"bytes"},{"name":"b","type":"uint256[]"}],"name":"store","outputs":[],"type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"a","type":"address"},{"indexed":true,"name":"b","type":"string"},{"indexed":true,"name":"c","type":"int8"},{"indexed":false,"name":"d","type":"bytes32[]"},{"indexed":false,"name":"e","type":"bool"}],"name":"Stored","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"uint256"}],"name":"Unnamed","type":"event"},
+	{"inputs":[{"name":"owner","type":"address"},{"name":"salt","type":"bytes32"}],"type":"constructor"}
+]`
+
+// mobileAccessors are patterns extracting the mobile wrapper methods and functions
+// invoked by the generated bindings, keyed by the wrapper they belong to (an empty
+// key meaning package level functions).
+var mobileAccessors = map[Lang]map[string][]*regexp.Regexp{
+	LangJava: {
+		"":              {regexp.MustCompile(`Geth\.([a-z]\w*)\(`)},
+		"Interface":     {regexp.MustCompile(`\b(?:arg|result|rule)\d+\.([a-z]\w*)\(`), regexp.MustCompile(`\bresults\.get\(\d+\)\.([a-z]\w*)\(`)},
+		"Interfaces":    {regexp.MustCompile(`\b(?:args|results|query)\.([a-z]\w*)\(`)},
+		"BoundContract": {regexp.MustCompile(`\b(?:this\.Contract|deployment)\.([a-z]\w*)\(`)},
+	},
+	LangObjC: {
+		"":              {regexp.MustCompile(`\bGeth([A-Z]\w*)\(`)},
+		"Interface":     {regexp.MustCompile(`\[(?:arg|result|rule)\d+ ([a-z]\w*)[:\]]`)},
+		"Interfaces":    {regexp.MustCompile(`\[(?:args|results|query) ([a-z]\w*)[:\]]`)},
+		"BoundContract": {regexp.MustCompile(`\[(?:_contract|contract) ([a-z]\w*)[:\]]`)},
+	},
+}
+
+// Tests that the Java and Objective-C bindings are generated for all the methods
+// and events of the test contracts, and that they only rely on functionality the
+// mobile wrappers actually export.
+func TestMobileBindings(t *testing.T) {
+	// Collect all the functions and methods exported by the mobile wrappers
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, filepath.Join("..", "..", "..", "mobile"), func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to parse mobile package: %v", err)
+	}
+	exported := make(map[string]map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fun, ok := decl.(*ast.FuncDecl)
+				if !ok || !fun.Name.IsExported() {
+					continue
+				}
+				recv := ""
+				if fun.Recv != nil {
+					switch typ := fun.Recv.List[0].Type.(type) {
+					case *ast.StarExpr:
+						recv = typ.X.(*ast.Ident).Name
+					case *ast.Ident:
+						recv = typ.Name
+					}
+				}
+				if exported[recv] == nil {
+					exported[recv] = make(map[string]bool)
+				}
+				exported[recv][fun.Name.Name] = true
+			}
+		}
+	}
+	// Generate the mobile bindings of all the test contracts and verify them
+	tests := append(bindTests, struct {
+		name     string
+		contract string
+		bytecode string
+		abi      string
+		tester   string
+	}{`MobileTypes`, ``, `0x6060`, mobileTypesABI, ``})
+
+	for _, lang := range []Lang{LangJava, LangObjC} {
+		for i, tt := range tests {
+			code, err := Bind([]string{tt.name}, []string{tt.abi}, []string{tt.bytecode}, "bindtest", lang)
+			if strings.Contains(tt.abi, `"type":"tuple`) {
+				if err == nil {
+					t.Errorf("lang %d, test %d: tuple binding generated for mobile", lang, i)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("lang %d, test %d: failed to generate binding: %v", lang, i, err)
+			}
+			parsed, err := abi.JSON(strings.NewReader(tt.abi))
+			if err != nil {
+				t.Fatalf("lang %d, test %d: failed to parse ABI: %v", lang, i, err)
+			}
+			for _, method := range parsed.Methods {
+				name := methodNormalizer[lang](method.Name)
+				if (lang == LangJava && !strings.Contains(code, " "+name+"(")) || (lang == LangObjC && !strings.Contains(code, ")"+name+":")) {
+					t.Errorf("lang %d, test %d: method %s missing from binding", lang, i, method.Name)
+				}
+			}
+			for _, event := range parsed.Events {
+				name := capitalise(methodNormalizer[lang](event.Name))
+				for _, op := range []string{"filter", "watch", "parse"} {
+					if !strings.Contains(code, op+name) {
+						t.Errorf("lang %d, test %d: %s%s missing from binding", lang, i, op, name)
+					}
+				}
+			}
+			for recv, patterns := range mobileAccessors[lang] {
+				for _, pattern := range patterns {
+					for _, match := range pattern.FindAllStringSubmatch(code, -1) {
+						if !exported[recv][capitalise(match[1])] {
+							t.Errorf("lang %d, test %d: binding uses missing mobile method %s.%s", lang, i, recv, capitalise(match[1]))
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -22,6 +22,7 @@ import "github.com/bazacoin/go-bazacoin/accounts/abi"
 type tmplData struct {
 	Package   string                   // Name of the package to place the generated file in
 	Contracts map[string]*tmplContract // List of contracts to generate into this file
+	Structs   map[string]*tmplStruct   // Contract struct type definitions (tuples)
 }
 
 // tmplContract contains the data needed to generate an individual contract binding.
@@ -32,6 +33,7 @@ type tmplContract struct {
 	Constructor abi.Method             // Contract constructor for deploy parametrization
 	Calls       map[string]*tmplMethod // Contract calls that only read state data
 	Transacts   map[string]*tmplMethod // Contract calls that write state data
+	Events      map[string]*tmplEvent  // Contract events accessors
 }
 
 // tmplMethod is a wrapper around an abi.Method that contains a few preprocessed
@@ -42,11 +44,33 @@ type tmplMethod struct {
 	Structured bool       // Whether the returns should be accumulated into a contract
 }
 
+// tmplEvent is a wrapper around an abi.Event that contains a few preprocessed
+// and cached data fields.
+type tmplEvent struct {
+	Original   abi.Event // Original event as parsed by the abi package
+	Normalized abi.Event // Normalized version of the parsed fields
+}
+
+// tmplField is a wrapper around a struct field with its Solidity type and the
+// name of the Go field it is bound to.
+type tmplField struct {
+	Type abi.Type // Solidity type of the field
+	Name string   // Go field name of the struct, as used by the abi package
+}
+
+// tmplStruct is a wrapper around an abi.tuple type and contains the auto-generated
+// struct name and its fields.
+type tmplStruct struct {
+	Name   string       // Auto-generated struct name (taken from the Solidity source if possible)
+	Fields []*tmplField // Struct fields definition
+}
+
 // tmplSource is language to template mapping containing all the supported
 // programming languages the package can generate to.
 var tmplSource = map[Lang]string{
 	LangGo:   tmplSourceGo,
 	LangJava: tmplSourceJava,
+	LangObjC: tmplSourceObjC,
 }
 
 // tmplSourceGo is the Go source template use to generate the contract binding
@@ -57,6 +81,14 @@ const tmplSourceGo = `
 
 package {{.Package}}
 
+{{range $structs := .Structs}}
+	// {{.Name}} is an auto generated low-level Go binding around an user-defined struct.
+	type {{.Name}} struct {
+	{{range $field := .Fields}}
+	{{$field.Name}} {{bindtype $field.Type}}{{end}}
+	}
+{{end}}
+
 {{range $contract := .Contracts}}
 	// {{.Type}}ABI is the input ABI used to generate the binding from.
 	const {{.Type}}ABI = "{{.InputABI}}"
@@ -75,7 +107,7 @@ package {{.Package}}
 		  if err != nil {
 		    return common.Address{}, nil, nil, err
 		  }
-		  return address, tx, &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract} }, nil
+		  return address, tx, &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
 		}
 	{{end}}
 
@@ -83,6 +115,7 @@ package {{.Package}}
 	type {{.Type}} struct {
 	  {{.Type}}Caller     // Read-only binding to the contract
 	  {{.Type}}Transactor // Write-only binding to the contract
+	  {{.Type}}Filterer   // Log filterer for contract events
 	}
 
 	// {{.Type}}Caller is an auto generated read-only Go binding around an Bazacoin contract.
@@ -95,6 +128,11 @@ package {{.Package}}
 	  contract *bind.BoundContract // Generic contract wrapper for the low level calls
 	}
 
+	// {{.Type}}Filterer is an auto generated log filtering Go binding around an Bazacoin contract events.
+	type {{.Type}}Filterer struct {
+	  contract *bind.BoundContract // Generic contract wrapper for the low level calls
+	}
+
 	// {{.Type}}Session is an auto generated Go binding around an Bazacoin contract,
 	// with pre-set call and transact options.
 	type {{.Type}}Session struct {
@@ -134,16 +172,16 @@ package {{.Package}}
 
 	// New{{.Type}} creates a new instance of {{.Type}}, bound to a specific deployed contract.
 	func New{{.Type}}(address common.Address, backend bind.ContractBackend) (*{{.Type}}, error) {
-	  contract, err := bind{{.Type}}(address, backend, backend)
+	  contract, err := bind{{.Type}}(address, backend, backend, backend)
 	  if err != nil {
 	    return nil, err
 	  }
-	  return &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract} }, nil
+	  return &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
 	}
 
 	// New{{.Type}}Caller creates a new read-only instance of {{.Type}}, bound to a specific deployed contract.
 	func New{{.Type}}Caller(address common.Address, caller bind.ContractCaller) (*{{.Type}}Caller, error) {
-	  contract, err := bind{{.Type}}(address, caller, nil)
+	  contract, err := bind{{.Type}}(address, caller, nil, nil)
 	  if err != nil {
 	    return nil, err
 	  }
@@ -152,20 +190,29 @@ package {{.Package}}
 
 	// New{{.Type}}Transactor creates a new write-only instance of {{.Type}}, bound to a specific deployed contract.
 	func New{{.Type}}Transactor(address common.Address, transactor bind.ContractTransactor) (*{{.Type}}Transactor, error) {
-	  contract, err := bind{{.Type}}(address, nil, transactor)
+	  contract, err := bind{{.Type}}(address, nil, transactor, nil)
 	  if err != nil {
 	    return nil, err
 	  }
 	  return &{{.Type}}Transactor{contract: contract}, nil
 	}
 
+	// New{{.Type}}Filterer creates a new log filterer instance of {{.Type}}, bound to a specific deployed contract.
+	func New{{.Type}}Filterer(address common.Address, filterer bind.ContractFilterer) (*{{.Type}}Filterer, error) {
+	  contract, err := bind{{.Type}}(address, nil, nil, filterer)
+	  if err != nil {
+	    return nil, err
+	  }
+	  return &{{.Type}}Filterer{contract: contract}, nil
+	}
+
 	// bind{{.Type}} binds a generic wrapper to an already deployed contract.
-	func bind{{.Type}}(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor) (*bind.BoundContract, error) {
+	func bind{{.Type}}(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
 	  parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
 	  if err != nil {
 	    return nil, err
 	  }
-	  return bind.NewBoundContract(address, parsed, caller, transactor), nil
+	  return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
 	}
 
 	// Call invokes the (constant) contract method with params as input values and
@@ -263,6 +310,140 @@ package {{.Package}}
 		  return _{{$contract.Type}}.Contract.{{.Normalized.Name}}(&_{{$contract.Type}}.TransactOpts {{range $i, $_ := .Normalized.Inputs}}, {{.Name}}{{end}})
 		}
 	{{end}}
+
+	{{range .Events}}
+		// {{$contract.Type}}{{.Normalized.Name}}Iterator is returned from Filter{{.Normalized.Name}} and is used to iterate over the raw logs and unpacked data for {{.Normalized.Name}} events raised by the {{$contract.Type}} contract.
+		type {{$contract.Type}}{{.Normalized.Name}}Iterator struct {
+			Event *{{$contract.Type}}{{.Normalized.Name}} // Event containing the contract specifics and raw log
+
+			contract *bind.BoundContract // Generic contract to use for unpacking event data
+			event    string              // Event name to use for unpacking event data
+
+			logs chan types.Log      // Log channel receiving the found contract events
+			sub  event.Subscription  // Subscription for errors, completion and termination
+			done bool                // Whether the subscription completed delivering logs
+			fail error               // Occurred error to stop iteration
+		}
+
+		// Next advances the iterator to the subsequent event, returning whether there
+		// are any more events found. In case of a retrieval or parsing error, false is
+		// returned and Error() can be queried for the exact failure.
+		func (it *{{$contract.Type}}{{.Normalized.Name}}Iterator) Next() bool {
+			// If the iterator failed, stop iterating
+			if (it.fail != nil) {
+				return false
+			}
+			// If the iterator completed, deliver directly whatever's available
+			if (it.done) {
+				select {
+				case log := <-it.logs:
+					it.Event = new({{$contract.Type}}{{.Normalized.Name}})
+					if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+						it.fail = err
+						return false
+					}
+					it.Event.Raw = log
+					return true
+
+				default:
+					return false
+				}
+			}
+			// Iterator still in progress, wait for either a data or an error event
+			select {
+			case log := <-it.logs:
+				it.Event = new({{$contract.Type}}{{.Normalized.Name}})
+				if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+					it.fail = err
+					return false
+				}
+				it.Event.Raw = log
+				return true
+
+			case err := <-it.sub.Err():
+				it.done = true
+				it.fail = err
+				return it.Next()
+			}
+		}
+
+		// Error returns any retrieval or parsing error occurred during filtering.
+		func (it *{{$contract.Type}}{{.Normalized.Name}}Iterator) Error() error {
+			return it.fail
+		}
+
+		// Close terminates the iteration process, releasing any pending underlying
+		// resources.
+		func (it *{{$contract.Type}}{{.Normalized.Name}}Iterator) Close() error {
+			it.sub.Unsubscribe()
+			return nil
+		}
+
+		// {{$contract.Type}}{{.Normalized.Name}} represents a {{.Normalized.Name}} event raised by the {{$contract.Type}} contract.
+		type {{$contract.Type}}{{.Normalized.Name}} struct { {{range .Normalized.Inputs}}
+			{{capitalise .Name}} {{if .Indexed}}{{bindtopictype .Type}}{{else}}{{bindtype .Type}}{{end}}; {{end}}
+			Raw types.Log // Blockchain specific contextual infos
+		}
+
+		// Filter{{.Normalized.Name}} is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Filter{{.Normalized.Name}}(opts *bind.FilterOpts{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}} []{{bindtype .Type}}{{end}}{{end}}) (*{{$contract.Type}}{{.Normalized.Name}}Iterator, error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			logs, sub, err := _{{$contract.Type}}.contract.FilterLogs(opts, "{{.Original.Name}}"{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+			if err != nil {
+				return nil, err
+			}
+			return &{{$contract.Type}}{{.Normalized.Name}}Iterator{contract: _{{$contract.Type}}.contract, event: "{{.Original.Name}}", logs: logs, sub: sub}, nil
+		}
+
+		// Watch{{.Normalized.Name}} is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Watch{{.Normalized.Name}}(opts *bind.WatchOpts, sink chan<- *{{$contract.Type}}{{.Normalized.Name}}{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}} []{{bindtype .Type}}{{end}}{{end}}) (event.Subscription, error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			logs, sub, err := _{{$contract.Type}}.contract.WatchLogs(opts, "{{.Original.Name}}"{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+			if err != nil {
+				return nil, err
+			}
+			return event.NewSubscription(func(quit <-chan struct{}) error {
+				defer sub.Unsubscribe()
+				for {
+					select {
+					case log := <-logs:
+						// New log arrived, parse the event and forward to the user
+						event := new({{$contract.Type}}{{.Normalized.Name}})
+						if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+							return err
+						}
+						event.Raw = log
+
+						select {
+						case sink <- event:
+						case err := <-sub.Err():
+							return err
+						case <-quit:
+							return nil
+						}
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			}), nil
+		}
+	{{end}}
 {{end}}
 `
 
@@ -274,6 +455,9 @@ const tmplSourceJava = `
 
 package {{.Package}};
 
+import java.util.ArrayList;
+import java.util.List;
+
 import org.bazacoin.geth.*;
 import org.bazacoin.geth.internal.*;
 
@@ -284,25 +468,24 @@ import org.bazacoin.geth.internal.*;
 
 		{{if .InputBin}}
 			// BYTECODE is the compiled bytecode used for deploying new contracts.
-			public final static byte[] BYTECODE = "{{.InputBin}}".getBytes();
+			public final static String BYTECODE = "0x{{.InputBin}}";
 
 			// deploy deploys a new Bazacoin contract, binding an instance of {{.Type}} to it.
 			public static {{.Type}} deploy(TransactOpts auth, BazacoinClient client{{range .Constructor.Inputs}}, {{bindtype .Type}} {{.Name}}{{end}}) throws Exception {
 				Interfaces args = Geth.newInterfaces({{(len .Constructor.Inputs)}});
-				{{range $index, $element := .Constructor.Inputs}}
-				  args.set({{$index}}, Geth.newInterface()); args.get({{$index}}).set{{namedtype (bindtype .Type) .Type}}({{.Name}});
+				{{range $index, $element := .Constructor.Inputs}}Interface arg{{$index}} = Geth.newInterface(); arg{{$index}}.set{{mobiletype .Type}}({{.Name}}); args.set({{$index}}, arg{{$index}});
 				{{end}}
-				return new {{.Type}}(Geth.deployContract(auth, ABI, BYTECODE, client, args));
-			}
-
-			// Internal constructor used by contract deployment.
-			private {{.Type}}(BoundContract deployment) {
-				this.Address  = deployment.getAddress();
-				this.Deployer = deployment.getDeployer();
-				this.Contract = deployment;
+				return new {{.Type}}(Geth.deployContract(auth, ABI, Geth.decodeFromHex(BYTECODE), client, args));
 			}
 		{{end}}
 
+		// Internal constructor used by contract deployment and binding.
+		private {{.Type}}(BoundContract deployment) {
+			this.Address  = deployment.getAddress();
+			this.Deployer = deployment.getDeployer();
+			this.Contract = deployment;
+		}
+
 		// Bazacoin address where this contract is located at.
 		public final Address Address;
 
@@ -320,7 +503,7 @@ import org.bazacoin.geth.internal.*;
 		{{range .Calls}}
 			{{if gt (len .Normalized.Outputs) 1}}
 			// {{capitalise .Normalized.Name}}Results is the output of a call to {{.Normalized.Name}}.
-			public class {{capitalise .Normalized.Name}}Results {
+			public static class {{capitalise .Normalized.Name}}Results {
 				{{range $index, $item := .Normalized.Outputs}}public {{bindtype .Type}} {{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}};
 				{{end}}
 			}
@@ -329,13 +512,13 @@ import org.bazacoin.geth.internal.*;
 			// {{.Normalized.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.Id}}.
 			//
 			// Solidity: {{.Original.String}}
-			public {{if gt (len .Normalized.Outputs) 1}}{{capitalise .Normalized.Name}}Results{{else}}{{range .Normalized.Outputs}}{{bindtype .Type}}{{end}}{{end}} {{.Normalized.Name}}(CallOpts opts{{range .Normalized.Inputs}}, {{bindtype .Type}} {{.Name}}{{end}}) throws Exception {
+			public {{if gt (len .Normalized.Outputs) 1}}{{capitalise .Normalized.Name}}Results{{else}}{{range .Normalized.Outputs}}{{bindtype .Type}}{{else}}void{{end}}{{end}} {{.Normalized.Name}}(CallOpts opts{{range .Normalized.Inputs}}, {{bindtype .Type}} {{.Name}}{{end}}) throws Exception {
 				Interfaces args = Geth.newInterfaces({{(len .Normalized.Inputs)}});
-				{{range $index, $item := .Normalized.Inputs}}args.set({{$index}}, Geth.newInterface()); args.get({{$index}}).set{{namedtype (bindtype .Type) .Type}}({{.Name}});
+				{{range $index, $item := .Normalized.Inputs}}Interface arg{{$index}} = Geth.newInterface(); arg{{$index}}.set{{mobiletype .Type}}({{.Name}}); args.set({{$index}}, arg{{$index}});
 				{{end}}
 
 				Interfaces results = Geth.newInterfaces({{(len .Normalized.Outputs)}});
-				{{range $index, $item := .Normalized.Outputs}}Interface result{{$index}} = Geth.newInterface(); result{{$index}}.setDefault{{namedtype (bindtype .Type) .Type}}(); results.set({{$index}}, result{{$index}});
+				{{range $index, $item := .Normalized.Outputs}}Interface result{{$index}} = Geth.newInterface(); result{{$index}}.setDefault{{mobiletype .Type}}(); results.set({{$index}}, result{{$index}});
 				{{end}}
 
 				if (opts == null) {
@@ -344,10 +527,10 @@ import org.bazacoin.geth.internal.*;
 				this.Contract.call(opts, results, "{{.Original.Name}}", args);
 				{{if gt (len .Normalized.Outputs) 1}}
 					{{capitalise .Normalized.Name}}Results result = new {{capitalise .Normalized.Name}}Results();
-					{{range $index, $item := .Normalized.Outputs}}result.{{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}} = results.get({{$index}}).get{{namedtype (bindtype .Type) .Type}}();
+					{{range $index, $item := .Normalized.Outputs}}result.{{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}} = results.get({{$index}}).get{{mobiletype .Type}}();
 					{{end}}
 					return result;
-				{{else}}{{range .Normalized.Outputs}}return results.get(0).get{{namedtype (bindtype .Type) .Type}}();{{end}}
+				{{else}}{{range .Normalized.Outputs}}return results.get(0).get{{mobiletype .Type}}();{{end}}
 				{{end}}
 			}
 		{{end}}
@@ -358,12 +541,351 @@ import org.bazacoin.geth.internal.*;
 			// Solidity: {{.Original.String}}
 			public Transaction {{.Normalized.Name}}(TransactOpts opts{{range .Normalized.Inputs}}, {{bindtype .Type}} {{.Name}}{{end}}) throws Exception {
 				Interfaces args = Geth.newInterfaces({{(len .Normalized.Inputs)}});
-				{{range $index, $item := .Normalized.Inputs}}args.set({{$index}}, Geth.newInterface()); args.get({{$index}}).set{{namedtype (bindtype .Type) .Type}}({{.Name}});
+				{{range $index, $item := .Normalized.Inputs}}Interface arg{{$index}} = Geth.newInterface(); arg{{$index}}.set{{mobiletype .Type}}({{.Name}}); args.set({{$index}}, arg{{$index}});
 				{{end}}
 
-				return this.Contract.transact(opts, "{{.Original.Name}}"	, args);
+				return this.Contract.transact(opts, "{{.Original.Name}}", args);
+			}
+		{{end}}
+
+		{{range .Events}}
+			// {{capitalise .Normalized.Name}}Event represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+			public static class {{capitalise .Normalized.Name}}Event {
+				{{range .Normalized.Inputs}}public {{if .Indexed}}{{bindtopictype .Type}}{{else}}{{bindtype .Type}}{{end}} {{capitalise .Name}};
+				{{end}}
+				public Log Raw; // Blockchain specific contextual infos
+			}
+
+			// {{capitalise .Normalized.Name}}Handler is the callback interface for the events delivered by watch{{capitalise .Normalized.Name}}.
+			public interface {{capitalise .Normalized.Name}}Handler {
+				void on{{capitalise .Normalized.Name}}({{capitalise .Normalized.Name}}Event event);
+				void onError(String failure);
+			}
+
+			// filter{{capitalise .Normalized.Name}} is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+			// A null filter for an indexed argument matches any value.
+			//
+			// Solidity: {{.Original.String}}
+			public List<{{capitalise .Normalized.Name}}Event> filter{{capitalise .Normalized.Name}}(FilterOpts opts{{range indexed .Normalized.Inputs}}, {{bindtopicrule .Type}} {{.Name}}{{end}}) throws Exception {
+				Logs logs = this.Contract.filterLogs(opts, "{{.Original.Name}}", {{.Normalized.Name}}Query({{range $index, $item := indexed .Normalized.Inputs}}{{if $index}}, {{end}}{{.Name}}{{end}}));
+
+				List<{{capitalise .Normalized.Name}}Event> events = new ArrayList<{{capitalise .Normalized.Name}}Event>();
+				for (int i = 0; i < logs.size(); i++) {
+					events.add(parse{{capitalise .Normalized.Name}}(logs.get(i)));
+				}
+				return events;
+			}
+
+			// watch{{capitalise .Normalized.Name}} is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+			// A null filter for an indexed argument matches any value.
+			//
+			// Solidity: {{.Original.String}}
+			public Subscription watch{{capitalise .Normalized.Name}}(WatchOpts opts, final {{capitalise .Normalized.Name}}Handler handler{{range indexed .Normalized.Inputs}}, {{bindtopicrule .Type}} {{.Name}}{{end}}) throws Exception {
+				return this.Contract.watchLogs(opts, "{{.Original.Name}}", {{.Normalized.Name}}Query({{range $index, $item := indexed .Normalized.Inputs}}{{if $index}}, {{end}}{{.Name}}{{end}}), new FilterLogsHandler() {
+					@Override public void onFilterLogs(Log log) {
+						{{capitalise .Normalized.Name}}Event event;
+						try {
+							event = parse{{capitalise .Normalized.Name}}(log);
+						} catch (Exception e) {
+							handler.onError(e.getMessage());
+							return;
+						}
+						handler.on{{capitalise .Normalized.Name}}(event);
+					}
+
+					@Override public void onError(String failure) {
+						handler.onError(failure);
+					}
+				});
+			}
+
+			// parse{{capitalise .Normalized.Name}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+			//
+			// Solidity: {{.Original.String}}
+			public {{capitalise .Normalized.Name}}Event parse{{capitalise .Normalized.Name}}(Log log) throws Exception {
+				Interfaces results = Geth.newInterfaces({{(len .Normalized.Inputs)}});
+				{{range $index, $item := .Normalized.Inputs}}Interface result{{$index}} = Geth.newInterface(); result{{$index}}.setDefault{{if .Indexed}}{{mobiletopictype .Type}}{{else}}{{mobiletype .Type}}{{end}}(); results.set({{$index}}, result{{$index}});
+				{{end}}
+				this.Contract.unpackLog(results, "{{.Original.Name}}", log);
+
+				{{capitalise .Normalized.Name}}Event event = new {{capitalise .Normalized.Name}}Event();
+				{{range $index, $item := .Normalized.Inputs}}event.{{capitalise .Name}} = results.get({{$index}}).get{{if .Indexed}}{{mobiletopictype .Type}}{{else}}{{mobiletype .Type}}{{end}}();
+				{{end}}
+				event.Raw = log;
+				return event;
+			}
+
+			// {{.Normalized.Name}}Query assembles the indexed argument filters of the {{.Original.Name}} event.
+			private static Interfaces {{.Normalized.Name}}Query({{range $index, $item := indexed .Normalized.Inputs}}{{if $index}}, {{end}}{{bindtopicrule .Type}} {{.Name}}{{end}}) throws Exception {
+				Interfaces query = Geth.newInterfaces({{len (indexed .Normalized.Inputs)}});
+				{{range $index, $item := indexed .Normalized.Inputs}}if ({{.Name}} != null) {
+					Interface rule{{$index}} = Geth.newInterface(); rule{{$index}}.set{{mobiletopicrule .Type}}({{.Name}}); query.set({{$index}}, rule{{$index}});
+				}
+				{{end}}
+				return query;
 			}
 		{{end}}
 	}
 {{end}}
 `
+
+// tmplSourceObjC is the Objective-C source template use to generate the contract
+// binding based on. The generated classes wrap the Geth framework built by gomobile
+// and may be used from Swift too through a bridging header.
+const tmplSourceObjC = `
+// This file is an automatically generated Objective-C binding. Do not modify as
+// any change will likely be lost upon the next re-generation!
+
+#import <Foundation/Foundation.h>
+#import <Geth/Geth.h>
+
+{{range $contract := .Contracts}}
+	{{range .Calls}}{{if gt (len .Normalized.Outputs) 1}}
+		// {{$contract.Type}}{{capitalise .Normalized.Name}}Results is the output of a call to {{.Normalized.Name}}.
+		@interface {{$contract.Type}}{{capitalise .Normalized.Name}}Results : NSObject
+		{{range $index, $item := .Normalized.Outputs}}@property (nonatomic) {{bindtype .Type}} {{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}};
+		{{end}}
+		@end
+	{{end}}{{end}}
+
+	{{range .Events}}
+		// {{$contract.Type}}{{capitalise .Normalized.Name}}Event represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+		@interface {{$contract.Type}}{{capitalise .Normalized.Name}}Event : NSObject
+		{{range .Normalized.Inputs}}@property (nonatomic) {{if .Indexed}}{{bindtopictype .Type}}{{else}}{{bindtype .Type}}{{end}} {{capitalise .Name}};
+		{{end}}
+		@property (nonatomic) GethLog* Raw; // Blockchain specific contextual infos
+		@end
+	{{end}}
+
+	// {{.Type}} is an auto generated Objective-C binding around a Bazacoin contract.
+	@interface {{.Type}} : NSObject
+
+	// abi returns the input ABI used to generate the binding from.
+	+ (NSString*)abi;
+
+	{{if .InputBin}}
+		// bytecode returns the compiled bytecode used for deploying new contracts.
+		+ (NSString*)bytecode;
+
+		// deploy deploys a new Bazacoin contract, binding an instance of {{.Type}} to it.
+		+ ({{.Type}}*)deploy:(GethTransactOpts*)auth client:(GethBazacoinClient*)client{{range .Constructor.Inputs}} {{.Name}}:({{bindtype .Type}}){{.Name}}{{end}} error:(NSError**)error;
+	{{end}}
+
+	// initWithAddress creates a new instance of {{.Type}}, bound to a specific deployed contract.
+	- (instancetype)initWithAddress:(GethAddress*)address client:(GethBazacoinClient*)client error:(NSError**)error;
+
+	// Bazacoin address where this contract is located at.
+	@property (nonatomic, readonly) GethAddress* address;
+
+	// Bazacoin transaction in which this contract was deployed (if known!).
+	@property (nonatomic, readonly) GethTransaction* deployer;
+
+	{{range .Calls}}
+		// {{.Normalized.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.Id}}.
+		//
+		// Solidity: {{.Original.String}}
+		- ({{if gt (len .Normalized.Outputs) 1}}{{$contract.Type}}{{capitalise .Normalized.Name}}Results*{{else}}{{range .Normalized.Outputs}}{{bindtype .Type}}{{else}}BOOL{{end}}{{end}}){{.Normalized.Name}}:(GethCallOpts*)opts{{range .Normalized.Inputs}} {{.Name}}:({{bindtype .Type}}){{.Name}}{{end}} error:(NSError**)error;
+	{{end}}
+
+	{{range .Transacts}}
+		// {{.Normalized.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
+		//
+		// Solidity: {{.Original.String}}
+		- (GethTransaction*){{.Normalized.Name}}:(GethTransactOpts*)opts{{range .Normalized.Inputs}} {{.Name}}:({{bindtype .Type}}){{.Name}}{{end}} error:(NSError**)error;
+	{{end}}
+
+	{{range .Events}}
+		// filter{{capitalise .Normalized.Name}} is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		// A nil filter for an indexed argument matches any value.
+		//
+		// Solidity: {{.Original.String}}
+		- (NSArray<{{$contract.Type}}{{capitalise .Normalized.Name}}Event*>*)filter{{capitalise .Normalized.Name}}:(GethFilterOpts*)opts{{range indexed .Normalized.Inputs}} {{.Name}}:({{bindtopicrule .Type}}){{.Name}}{{end}} error:(NSError**)error;
+
+		// watch{{capitalise .Normalized.Name}} is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		// A nil filter for an indexed argument matches any value.
+		//
+		// Solidity: {{.Original.String}}
+		- (GethSubscription*)watch{{capitalise .Normalized.Name}}:(GethWatchOpts*)opts{{range indexed .Normalized.Inputs}} {{.Name}}:({{bindtopicrule .Type}}){{.Name}}{{end}} onEvent:(void (^)({{$contract.Type}}{{capitalise .Normalized.Name}}Event*))onEvent onError:(void (^)(NSString*))onError error:(NSError**)error;
+
+		// parse{{capitalise .Normalized.Name}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		//
+		// Solidity: {{.Original.String}}
+		- ({{$contract.Type}}{{capitalise .Normalized.Name}}Event*)parse{{capitalise .Normalized.Name}}:(GethLog*)log error:(NSError**)error;
+	{{end}}
+	@end
+
+	{{range .Calls}}{{if gt (len .Normalized.Outputs) 1}}
+		@implementation {{$contract.Type}}{{capitalise .Normalized.Name}}Results
+		@end
+	{{end}}{{end}}
+
+	{{range .Events}}
+		@implementation {{$contract.Type}}{{capitalise .Normalized.Name}}Event
+		@end
+	{{end}}
+
+	// {{.Type}}LogsHandler adapts blocks to the log handler protocol of the Geth framework.
+	@interface {{.Type}}LogsHandler : NSObject <GethFilterLogsHandler>
+	@property (nonatomic, copy) void (^onLog)(GethLog*);
+	@property (nonatomic, copy) void (^onFailure)(NSString*);
+	@end
+
+	@implementation {{.Type}}LogsHandler
+	- (void)onFilterLogs:(GethLog*)log {
+		self.onLog(log);
+	}
+
+	- (void)onError:(NSString*)failure {
+		self.onFailure(failure);
+	}
+	@end
+
+	// Private initializer shared by contract deployment and binding.
+	@interface {{.Type}} ()
+	- (instancetype)initWithContract:(GethBoundContract*)contract;
+	@end
+
+	@implementation {{.Type}} {
+		GethBoundContract* _contract; // Contract instance bound to a blockchain address.
+	}
+
+	+ (NSString*)abi {
+		return @"{{.InputABI}}";
+	}
+
+	{{if .InputBin}}
+		+ (NSString*)bytecode {
+			return @"0x{{.InputBin}}";
+		}
+
+		+ ({{.Type}}*)deploy:(GethTransactOpts*)auth client:(GethBazacoinClient*)client{{range .Constructor.Inputs}} {{.Name}}:({{bindtype .Type}}){{.Name}}{{end}} error:(NSError**)error {
+			GethInterfaces* args = GethNewInterfaces({{(len .Constructor.Inputs)}});
+			{{range $index, $element := .Constructor.Inputs}}GethInterface* arg{{$index}} = GethNewInterface(); [arg{{$index}} set{{mobiletype .Type}}:{{.Name}}]; if (![args set:{{$index}} object:arg{{$index}} error:error]) return nil;
+			{{end}}
+			NSData* bytecode = GethDecodeFromHex([{{.Type}} bytecode], error);
+			if (bytecode == nil) {
+				return nil;
+			}
+			GethBoundContract* deployment = GethDeployContract(auth, [{{.Type}} abi], bytecode, client, args, error);
+			if (deployment == nil) {
+				return nil;
+			}
+			return [[{{.Type}} alloc] initWithContract:deployment];
+		}
+	{{end}}
+
+	- (instancetype)initWithContract:(GethBoundContract*)contract {
+		if ((self = [super init])) {
+			_contract = contract;
+			_address  = [contract getAddress];
+			_deployer = [contract getDeployer];
+		}
+		return self;
+	}
+
+	- (instancetype)initWithAddress:(GethAddress*)address client:(GethBazacoinClient*)client error:(NSError**)error {
+		GethBoundContract* contract = GethBindContract(address, [{{.Type}} abi], client, error);
+		if (contract == nil) {
+			return nil;
+		}
+		return [self initWithContract:contract];
+	}
+
+	{{range $method := .Calls}}
+		- ({{if gt (len .Normalized.Outputs) 1}}{{$contract.Type}}{{capitalise .Normalized.Name}}Results*{{else}}{{range .Normalized.Outputs}}{{bindtype .Type}}{{else}}BOOL{{end}}{{end}}){{.Normalized.Name}}:(GethCallOpts*)opts{{range .Normalized.Inputs}} {{.Name}}:({{bindtype .Type}}){{.Name}}{{end}} error:(NSError**)error {
+			GethInterfaces* args = GethNewInterfaces({{(len .Normalized.Inputs)}});
+			{{range $index, $item := .Normalized.Inputs}}GethInterface* arg{{$index}} = GethNewInterface(); [arg{{$index}} set{{mobiletype .Type}}:{{.Name}}]; if (![args set:{{$index}} object:arg{{$index}} error:error]) return {{template "objcfail" $method}};
+			{{end}}
+			GethInterfaces* results = GethNewInterfaces({{(len .Normalized.Outputs)}});
+			{{range $index, $item := .Normalized.Outputs}}GethInterface* result{{$index}} = GethNewInterface(); [result{{$index}} setDefault{{mobiletype .Type}}]; if (![results set:{{$index}} object:result{{$index}} error:error]) return {{template "objcfail" $method}};
+			{{end}}
+			if (opts == nil) {
+				opts = GethNewCallOpts();
+			}
+			if (![_contract call:opts out:results method:@"{{.Original.Name}}" args:args error:error]) {
+				return {{template "objcfail" .}};
+			}
+			{{if gt (len .Normalized.Outputs) 1}}
+				{{$contract.Type}}{{capitalise .Normalized.Name}}Results* result = [[{{$contract.Type}}{{capitalise .Normalized.Name}}Results alloc] init];
+				{{range $index, $item := .Normalized.Outputs}}result.{{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}} = [result{{$index}} get{{mobiletype .Type}}];
+				{{end}}
+				return result;
+			{{else}}{{range .Normalized.Outputs}}return [result0 get{{mobiletype .Type}}];{{else}}return YES;{{end}}
+			{{end}}
+		}
+	{{end}}
+
+	{{range .Transacts}}
+		- (GethTransaction*){{.Normalized.Name}}:(GethTransactOpts*)opts{{range .Normalized.Inputs}} {{.Name}}:({{bindtype .Type}}){{.Name}}{{end}} error:(NSError**)error {
+			GethInterfaces* args = GethNewInterfaces({{(len .Normalized.Inputs)}});
+			{{range $index, $item := .Normalized.Inputs}}GethInterface* arg{{$index}} = GethNewInterface(); [arg{{$index}} set{{mobiletype .Type}}:{{.Name}}]; if (![args set:{{$index}} object:arg{{$index}} error:error]) return nil;
+			{{end}}
+			return [_contract transact:opts method:@"{{.Original.Name}}" args:args error:error];
+		}
+	{{end}}
+
+	{{range .Events}}
+		- (NSArray<{{$contract.Type}}{{capitalise .Normalized.Name}}Event*>*)filter{{capitalise .Normalized.Name}}:(GethFilterOpts*)opts{{range indexed .Normalized.Inputs}} {{.Name}}:({{bindtopicrule .Type}}){{.Name}}{{end}} error:(NSError**)error {
+			{{template "objcquery" .}}
+			GethLogs* logs = [_contract filterLogs:opts name:@"{{.Original.Name}}" query:query error:error];
+			if (logs == nil) {
+				return nil;
+			}
+			NSMutableArray<{{$contract.Type}}{{capitalise .Normalized.Name}}Event*>* events = [NSMutableArray array];
+			for (long i = 0; i < [logs size]; i++) {
+				GethLog* log = [logs get:i error:error];
+				if (log == nil) {
+					return nil;
+				}
+				{{$contract.Type}}{{capitalise .Normalized.Name}}Event* event = [self parse{{capitalise .Normalized.Name}}:log error:error];
+				if (event == nil) {
+					return nil;
+				}
+				[events addObject:event];
+			}
+			return events;
+		}
+
+		- (GethSubscription*)watch{{capitalise .Normalized.Name}}:(GethWatchOpts*)opts{{range indexed .Normalized.Inputs}} {{.Name}}:({{bindtopicrule .Type}}){{.Name}}{{end}} onEvent:(void (^)({{$contract.Type}}{{capitalise .Normalized.Name}}Event*))onEvent onError:(void (^)(NSString*))onError error:(NSError**)error {
+			{{template "objcquery" .}}
+			{{$contract.Type}}LogsHandler* handler = [[{{$contract.Type}}LogsHandler alloc] init];
+			handler.onLog = ^(GethLog* log) {
+				NSError* failure = nil;
+				{{$contract.Type}}{{capitalise .Normalized.Name}}Event* event = [self parse{{capitalise .Normalized.Name}}:log error:&failure];
+				if (event == nil) {
+					onError([failure localizedDescription]);
+					return;
+				}
+				onEvent(event);
+			};
+			handler.onFailure = onError;
+
+			return [_contract watchLogs:opts name:@"{{.Original.Name}}" query:query handler:handler error:error];
+		}
+
+		- ({{$contract.Type}}{{capitalise .Normalized.Name}}Event*)parse{{capitalise .Normalized.Name}}:(GethLog*)log error:(NSError**)error {
+			GethInterfaces* results = GethNewInterfaces({{(len .Normalized.Inputs)}});
+			{{range $index, $item := .Normalized.Inputs}}GethInterface* result{{$index}} = GethNewInterface(); [result{{$index}} setDefault{{if .Indexed}}{{mobiletopictype .Type}}{{else}}{{mobiletype .Type}}{{end}}]; if (![results set:{{$index}} object:result{{$index}} error:error]) return nil;
+			{{end}}
+			if (![_contract unpackLog:results name:@"{{.Original.Name}}" log:log error:error]) {
+				return nil;
+			}
+			{{$contract.Type}}{{capitalise .Normalized.Name}}Event* event = [[{{$contract.Type}}{{capitalise .Normalized.Name}}Event alloc] init];
+			{{range $index, $item := .Normalized.Inputs}}event.{{capitalise .Name}} = [result{{$index}} get{{if .Indexed}}{{mobiletopictype .Type}}{{else}}{{mobiletype .Type}}{{end}}];
+			{{end}}
+			event.Raw = log;
+			return event;
+		}
+	{{end}}
+	@end
+{{end}}
+
+{{define "objcfail"}}{{if gt (len .Normalized.Outputs) 1}}nil{{else}}{{range .Normalized.Outputs}}{{zerovalue (bindtype .Type)}}{{else}}NO{{end}}{{end}}{{end}}
+
+{{define "objcquery"}}GethInterfaces* query = GethNewInterfaces({{len (indexed .Normalized.Inputs)}});
+			{{range $index, $item := indexed .Normalized.Inputs}}if ({{.Name}} != nil) {
+				GethInterface* rule{{$index}} = GethNewInterface(); [rule{{$index}} set{{mobiletopicrule .Type}}:{{.Name}}];
+				if (![query set:{{$index}} object:rule{{$index}} error:error]) return nil;
+			}
+			{{end}}{{end}}
+`
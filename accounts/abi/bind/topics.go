@@ -0,0 +1,178 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/bazacoin/go-bazacoin/accounts/abi"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/crypto"
+)
+
+// makeTopics converts a filter query argument list into a filter topic set.
+func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
+	topics := make([][]common.Hash, len(query))
+	for i, filter := range query {
+		for _, rule := range filter {
+			var topic common.Hash
+
+			// Try to generate the topic based on simple types
+			switch rule := rule.(type) {
+			case common.Hash:
+				copy(topic[:], rule[:])
+			case common.Address:
+				copy(topic[common.HashLength-common.AddressLength:], rule[:])
+			case *big.Int:
+				copy(topic[:], abi.U256(new(big.Int).Set(rule)))
+			case bool:
+				if rule {
+					topic[common.HashLength-1] = 1
+				}
+			case int8:
+				copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+			case int16:
+				copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+			case int32:
+				copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+			case int64:
+				copy(topic[:], abi.U256(big.NewInt(rule)))
+			case uint8:
+				topic[common.HashLength-1] = rule
+			case uint16:
+				copy(topic[:], abi.U256(new(big.Int).SetUint64(uint64(rule))))
+			case uint32:
+				copy(topic[:], abi.U256(new(big.Int).SetUint64(uint64(rule))))
+			case uint64:
+				copy(topic[:], abi.U256(new(big.Int).SetUint64(rule)))
+			case string:
+				topic = crypto.Keccak256Hash([]byte(rule))
+			case []byte:
+				topic = crypto.Keccak256Hash(rule)
+
+			default:
+				// Attempt to generate the topic from fixed size byte arrays
+				val := reflect.ValueOf(rule)
+				if val.Kind() != reflect.Array || val.Type().Elem().Kind() != reflect.Uint8 || val.Len() > common.HashLength {
+					return nil, fmt.Errorf("unsupported indexed type: %T", rule)
+				}
+				reflect.Copy(reflect.ValueOf(topic[:val.Len()]), val)
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return topics, nil
+}
+
+// parseTopics converts the indexed topic fields into actual log field values.
+// Dynamic types (strings, bytes and arrays) are only available as their hashes,
+// which are stored into fields of type common.Hash.
+func parseTopics(out interface{}, fields []abi.Argument, topics []common.Hash) error {
+	if len(fields) != len(topics) {
+		return fmt.Errorf("topic count mismatch: have %d, want %d", len(topics), len(fields))
+	}
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot parse topics into %T", out)
+	}
+	for i, arg := range fields {
+		if !arg.Indexed {
+			return errors.New("non-indexed field in topic reconstruction")
+		}
+		if arg.Name == "" {
+			return fmt.Errorf("unnamed field %d in topic reconstruction", i)
+		}
+		// Prefer the camel-cased field name, same as abi.Unpack does
+		field := value.Elem().FieldByName(abi.ToCamelCase(arg.Name))
+		if !field.IsValid() {
+			field = value.Elem().FieldByName(capitalise(arg.Name))
+		}
+		if !field.IsValid() {
+			return fmt.Errorf("no field named %s in %T", capitalise(arg.Name), out)
+		}
+		if err := setTopic(field, arg.Type, topics[i]); err != nil {
+			return fmt.Errorf("field %s: %v", arg.Name, err)
+		}
+	}
+	return nil
+}
+
+// setTopic assigns the value of a single topic to a struct field, decoding it
+// according to the abi type of the indexed argument.
+func setTopic(field reflect.Value, kind abi.Type, topic common.Hash) error {
+	// Dynamic types are only represented by their hash
+	if hashedTopic(kind) {
+		return assignTopic(field, reflect.ValueOf(topic))
+	}
+	switch kind.T {
+	case abi.AddressTy:
+		return assignTopic(field, reflect.ValueOf(common.BytesToAddress(topic[:])))
+
+	case abi.BoolTy:
+		return assignTopic(field, reflect.ValueOf(topic[common.HashLength-1] != 0))
+
+	case abi.IntTy, abi.UintTy:
+		switch field.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			num := new(big.Int).SetBytes(topic[common.HashLength-8:]).Uint64()
+			field.SetInt(int64(num))
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			field.SetUint(new(big.Int).SetBytes(topic[common.HashLength-8:]).Uint64())
+		default:
+			num := new(big.Int).SetBytes(topic[:])
+			if kind.T == abi.IntTy && topic[0]&0x80 != 0 {
+				num.Sub(num, new(big.Int).Lsh(big.NewInt(1), 256))
+			}
+			return assignTopic(field, reflect.ValueOf(num))
+		}
+		return nil
+
+	case abi.FixedBytesTy:
+		if field.Kind() != reflect.Array || field.Type().Elem().Kind() != reflect.Uint8 || field.Len() > common.HashLength {
+			return fmt.Errorf("cannot assign %v to %v", kind, field.Type())
+		}
+		reflect.Copy(field, reflect.ValueOf(topic[:field.Len()]))
+		return nil
+
+	default:
+		return assignTopic(field, reflect.ValueOf(topic))
+	}
+}
+
+// assignTopic sets the field to the decoded topic value if their types match.
+func assignTopic(field, value reflect.Value) error {
+	if !value.Type().AssignableTo(field.Type()) {
+		return fmt.Errorf("cannot assign %v to %v", value.Type(), field.Type())
+	}
+	field.Set(value)
+	return nil
+}
+
+// hashedTopic reports whether an indexed argument of the given type is stored in
+// the log topics as the hash of its value instead of the value itself.
+func hashedTopic(kind abi.Type) bool {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.TupleTy:
+		return true
+	case abi.FixedBytesTy, abi.FunctionTy:
+		return kind.IsSlice
+	}
+	return kind.IsSlice || kind.IsArray
+}
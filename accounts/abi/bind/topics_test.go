@@ -0,0 +1,122 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/accounts/abi"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/crypto"
+)
+
+// Tests that indexed event arguments are converted into the topics they would
+// be stored as in a log.
+func TestMakeTopics(t *testing.T) {
+	tests := []struct {
+		rule  interface{}
+		topic common.Hash
+	}{
+		{common.HexToHash("0x0102"), common.HexToHash("0x0102")},
+		{common.HexToAddress("0x0102"), common.HexToHash("0x0102")},
+		{big.NewInt(258), common.HexToHash("0x0102")},
+		{big.NewInt(-1), common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")},
+		{true, common.HexToHash("0x01")},
+		{false, common.Hash{}},
+		{int8(-2), common.HexToHash("0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe")},
+		{uint8(7), common.HexToHash("0x07")},
+		{uint64(1 << 40), common.HexToHash("0x010000000000")},
+		{"hello", crypto.Keccak256Hash([]byte("hello"))},
+		{[]byte("hello"), crypto.Keccak256Hash([]byte("hello"))},
+		{[4]byte{1, 2, 3, 4}, common.HexToHash("0x0102030400000000000000000000000000000000000000000000000000000000")},
+	}
+	for i, tt := range tests {
+		topics, err := makeTopics([]interface{}{tt.rule})
+		if err != nil {
+			t.Errorf("test %d: failed to make topic from %T: %v", i, tt.rule, err)
+			continue
+		}
+		if len(topics) != 1 || len(topics[0]) != 1 || topics[0][0] != tt.topic {
+			t.Errorf("test %d: topic mismatch: have %x, want %x", i, topics, tt.topic)
+		}
+	}
+	if _, err := makeTopics([]interface{}{struct{}{}}); err == nil {
+		t.Errorf("unsupported rule type accepted")
+	}
+	// Empty rule sets must be retained as wildcards
+	topics, err := makeTopics(nil, []interface{}{uint8(1)})
+	if err != nil {
+		t.Fatalf("failed to make topics: %v", err)
+	}
+	if len(topics) != 2 || len(topics[0]) != 0 || len(topics[1]) != 1 {
+		t.Errorf("wildcard topic mismatch: have %x", topics)
+	}
+}
+
+// Tests that log topics are decoded back into the indexed fields of an event.
+func TestParseTopics(t *testing.T) {
+	definition := `[{"anonymous":false,"inputs":[
+		{"indexed":true,"name":"from","type":"address"},
+		{"indexed":true,"name":"amount","type":"int256"},
+		{"indexed":true,"name":"small","type":"int8"},
+		{"indexed":true,"name":"flag","type":"bool"},
+		{"indexed":true,"name":"id","type":"bytes4"},
+		{"indexed":true,"name":"memo","type":"string"}
+	],"name":"Event","type":"event"}]`
+
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	var out struct {
+		From   common.Address
+		Amount *big.Int
+		Small  int8
+		Flag   bool
+		Id     [4]byte
+		Memo   common.Hash
+	}
+	want := out
+	want.From = common.HexToAddress("0x0102")
+	want.Amount = big.NewInt(-3)
+	want.Small = -4
+	want.Flag = true
+	want.Id = [4]byte{1, 2, 3, 4}
+	want.Memo = crypto.Keccak256Hash([]byte("memo"))
+
+	rules := [][]interface{}{{want.From}, {want.Amount}, {want.Small}, {want.Flag}, {want.Id}, {"memo"}}
+	topics, err := makeTopics(rules...)
+	if err != nil {
+		t.Fatalf("failed to make topics: %v", err)
+	}
+	flat := make([]common.Hash, len(topics))
+	for i := range topics {
+		flat[i] = topics[i][0]
+	}
+	if err := parseTopics(&out, parsed.Events["Event"].Inputs, flat); err != nil {
+		t.Fatalf("failed to parse topics: %v", err)
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("parsed event mismatch:\nhave %+v\nwant %+v", out, want)
+	}
+	if err := parseTopics(&out, parsed.Events["Event"].Inputs, flat[1:]); err == nil {
+		t.Errorf("missing topic accepted")
+	}
+}
@@ -44,3 +44,39 @@ func (e Event) Id() common.Hash {
 	}
 	return common.BytesToHash(crypto.Keccak256([]byte(fmt.Sprintf("%v(%v)", e.Name, strings.Join(types, ",")))))
 }
+
+// String returns the Solidity declaration of the event, marking the indexed
+// arguments as such.
+func (e Event) String() string {
+	inputs := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		inputs[i] = input.Type.String()
+		if input.Indexed {
+			inputs[i] += " indexed"
+		}
+		if len(input.Name) > 0 {
+			inputs[i] += " " + input.Name
+		}
+	}
+	anonymous := ""
+	if e.Anonymous {
+		anonymous = " anonymous"
+	}
+	return fmt.Sprintf("event %v(%v)%s", e.Name, strings.Join(inputs, ", "), anonymous)
+}
+
+// nonIndexed returns the event arguments which are stored in the data section
+// of a log, as opposed to its topics. Unnamed arguments are named after their
+// position among all the inputs (arg0, arg1, ...), same as abigen does.
+func (e Event) nonIndexed() []Argument {
+	var args []Argument
+	for i, input := range e.Inputs {
+		if !input.Indexed {
+			if input.Name == "" {
+				input.Name = fmt.Sprintf("arg%d", i)
+			}
+			args = append(args, input)
+		}
+	}
+	return args
+}
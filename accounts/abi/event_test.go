@@ -17,6 +17,7 @@
 package abi
 
 import (
+	"math/big"
 	"strings"
 	"testing"
 
@@ -54,3 +55,79 @@ func TestEventId(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the non-indexed arguments of an event can be unpacked from the log
+// data, with the indexed ones being skipped.
+func TestEventUnpack(t *testing.T) {
+	definition := `[{"type":"event","name":"transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"value","type":"uint256"},{"name":"memo","type":"bytes32"}]}]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := abi.Events["transfer"].String(), "event transfer(address indexed from, uint256 value, bytes32 memo)"; have != want {
+		t.Errorf("event string mismatch: have %q, want %q", have, want)
+	}
+	data := append(common.LeftPadBytes([]byte{0x2a}, 32), common.RightPadBytes([]byte("memo"), 32)...)
+
+	var out struct {
+		From  common.Address
+		Value *big.Int
+		Memo  [32]byte
+	}
+	if err := abi.Unpack(&out, "transfer", data); err != nil {
+		t.Fatalf("failed to unpack event: %v", err)
+	}
+	if out.From != (common.Address{}) {
+		t.Errorf("indexed field unpacked from data: %x", out.From)
+	}
+	if out.Value == nil || out.Value.Int64() != 0x2a {
+		t.Errorf("value mismatch: have %v, want %d", out.Value, 0x2a)
+	}
+	if string(out.Memo[:4]) != "memo" {
+		t.Errorf("memo mismatch: have %x", out.Memo)
+	}
+	if err := abi.Unpack(&out, "missing", data); err == nil {
+		t.Errorf("unpacking unknown event succeeded")
+	}
+}
+
+// Tests that unnamed event arguments are unpacked into the positional fields
+// abigen generates for them.
+func TestEventUnpackUnnamed(t *testing.T) {
+	definition := `[
+	{"type":"event","name":"One","inputs":[{"name":"","type":"uint256"}]},
+	{"type":"event","name":"Two","inputs":[{"name":"","type":"uint256"},{"name":"","type":"uint256"}]},
+	{"type":"event","name":"Mixed","inputs":[{"name":"","type":"address","indexed":true},{"name":"","type":"uint256"}]}
+	]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, two := common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{2}, 32)
+
+	var single struct{ Arg0 *big.Int }
+	if err := abi.Unpack(&single, "One", one); err != nil {
+		t.Fatalf("failed to unpack One: %v", err)
+	}
+	if single.Arg0 == nil || single.Arg0.Int64() != 1 {
+		t.Errorf("One mismatch: have %v, want 1", single.Arg0)
+	}
+	var double struct{ Arg0, Arg1 *big.Int }
+	if err := abi.Unpack(&double, "Two", append(one, two...)); err != nil {
+		t.Fatalf("failed to unpack Two: %v", err)
+	}
+	if double.Arg0 == nil || double.Arg0.Int64() != 1 || double.Arg1 == nil || double.Arg1.Int64() != 2 {
+		t.Errorf("Two mismatch: have %v %v, want 1 2", double.Arg0, double.Arg1)
+	}
+	// Unnamed arguments are numbered among all inputs, indexed ones included
+	var mixed struct {
+		Arg0 common.Address
+		Arg1 *big.Int
+	}
+	if err := abi.Unpack(&mixed, "Mixed", two); err != nil {
+		t.Fatalf("failed to unpack Mixed: %v", err)
+	}
+	if mixed.Arg1 == nil || mixed.Arg1.Int64() != 2 {
+		t.Errorf("Mixed mismatch: have %v, want 2", mixed.Arg1)
+	}
+}
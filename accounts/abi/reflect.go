@@ -19,6 +19,7 @@ package abi
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // indirect recursively dereferences the value until it either gets the value
@@ -69,25 +70,84 @@ func mustArrayToByteSlice(value reflect.Value) reflect.Value {
 // set attempts to assign src to dst by either setting, copying or otherwise.
 //
 // set is a bit more lenient when it comes to assignment and doesn't force an as
-// strict ruleset as bare `reflect` does.
-func set(dst, src reflect.Value, output Argument) error {
+// strict ruleset as bare `reflect` does. Arrays, slices and structs (tuples) are
+// assigned element by element, allowing the unpacked values to be stored into
+// user defined types of the same shape.
+func set(dst, src reflect.Value, typ Type) error {
 	dstType := dst.Type()
 	srcType := src.Type()
 
 	switch {
 	case dstType.AssignableTo(src.Type()):
 		dst.Set(src)
-	case dstType.Kind() == reflect.Array && srcType.Kind() == reflect.Slice:
-		if dst.Len() < output.Type.SliceSize {
-			return fmt.Errorf("abi: cannot unmarshal src (len=%d) in to dst (len=%d)", output.Type.SliceSize, dst.Len())
+	case dstType.Kind() == reflect.Array && (srcType.Kind() == reflect.Slice || srcType.Kind() == reflect.Array):
+		size := src.Len()
+		if !typ.composite() && (typ.IsArray || typ.IsSlice) {
+			size = typ.SliceSize
+		}
+		if dst.Len() < size {
+			return fmt.Errorf("abi: cannot unmarshal src (len=%d) in to dst (len=%d)", size, dst.Len())
+		}
+		if !typ.composite() {
+			reflect.Copy(dst, src)
+			return nil
+		}
+		for i := 0; i < src.Len(); i++ {
+			if err := set(dst.Index(i), src.Index(i), *typ.Elem); err != nil {
+				return err
+			}
+		}
+	case dstType.Kind() == reflect.Slice && srcType.Kind() == reflect.Slice && typ.composite():
+		slice := reflect.MakeSlice(dstType, src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := set(slice.Index(i), src.Index(i), *typ.Elem); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+	case dstType.Kind() == reflect.Struct && srcType.Kind() == reflect.Struct && typ.T == TupleTy:
+		for i := 0; i < srcType.NumField(); i++ {
+			field := dst.FieldByName(srcType.Field(i).Name)
+			if !field.IsValid() {
+				return fmt.Errorf("abi: field %s can't be found in %v", srcType.Field(i).Name, dstType)
+			}
+			if err := set(field, src.Field(i), *typ.TupleElems[i]); err != nil {
+				return err
+			}
 		}
-		reflect.Copy(dst, src)
 	case dstType.Kind() == reflect.Interface:
 		dst.Set(src)
 	case dstType.Kind() == reflect.Ptr:
-		return set(dst.Elem(), src, output)
+		return set(dst.Elem(), src, typ)
 	default:
 		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
 	}
 	return nil
 }
+
+// ToCamelCase converts an under-score separated abi name into the exported
+// Go field name the value is assigned to (e.g. "owner_address" to "OwnerAddress").
+func ToCamelCase(input string) string {
+	parts := strings.Split(input, "_")
+	for i, s := range parts {
+		if len(s) > 0 {
+			parts[i] = strings.ToUpper(s[:1]) + s[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// fieldIndex returns the index of the struct field the abi argument called name
+// is assigned to, or -1 if there is none. The camel-cased field name is preferred,
+// but the capitalised one (e.g. "Owner_address") of older bindings and hand
+// written structs is still accepted.
+func fieldIndex(typ reflect.Type, name string) int {
+	for _, candidate := range []string{ToCamelCase(name), strings.ToUpper(name[:1]) + name[1:]} {
+		for i := 0; i < typ.NumField(); i++ {
+			if typ.Field(i).Name == candidate {
+				return i
+			}
+		}
+	}
+	return -1
+}
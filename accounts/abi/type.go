@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -35,6 +36,7 @@ const (
 	HashTy
 	FixedpointTy
 	FunctionTy
+	TupleTy
 )
 
 // Type is the reflection of the supported argument type
@@ -49,15 +51,19 @@ type Type struct {
 	Size int
 	T    byte // Our own type checking
 
+	TupleElems    []*Type  // Type information of all tuple fields
+	TupleRawNames []string // Raw field names of all tuple fields, as defined in the ABI
+	TupleRawName  string   // Raw struct name defined in the source code, may be empty
+
 	stringKind string // holds the unparsed string for deriving signatures
 }
 
 var (
-	// fullTypeRegex parses the abi types
+	// sliceRegex parses the outermost array or slice dimension of an abi type
 	//
 	// Types can be in the format of:
 	//
-	// 	Input  = Type [ "[" [ Number ] "]" ] Name .
+	// 	Input  = Type { "[" [ Number ] "]" } Name .
 	// 	Type   = [ "u" ] "int" [ Number ] [ x ] [ Number ].
 	//
 	// Examples:
@@ -65,42 +71,60 @@ var (
 	//      string     int       uint       fixed
 	//      string32   int8      uint8      uint[]
 	//      address    int256    uint256    fixed128x128[2]
-	fullTypeRegex = regexp.MustCompile(`([a-zA-Z0-9]+)(\[([0-9]*)\])?`)
+	//      tuple      tuple[]   uint[][]   string[2][]
+	sliceRegex = regexp.MustCompile(`^\[([0-9]*)\]$`)
+	// fullTypeRegex validates the abi element types
+	fullTypeRegex = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
 	// typeRegex parses the abi sub types
 	typeRegex = regexp.MustCompile("([a-zA-Z]+)(([0-9]+)(x([0-9]+))?)?")
 )
 
-// NewType creates a new reflection type of abi type given in t.
-func NewType(t string) (typ Type, err error) {
-	res := fullTypeRegex.FindAllStringSubmatch(t, -1)[0]
-	// check if type is slice and parse type.
-	switch {
-	case res[3] != "":
-		// err is ignored. Already checked for number through the regexp
-		typ.SliceSize, _ = strconv.Atoi(res[3])
-		typ.IsArray = true
-	case res[2] != "":
-		typ.IsSlice, typ.SliceSize = true, -1
-	case res[0] == "":
+// NewType creates a new reflection type of abi type given in t. The internal
+// type and components are only used for tuples, describing the name of the
+// source struct and the fields of the tuple respectively.
+func NewType(t string, internalType string, components []ArgumentMarshaling) (typ Type, err error) {
+	// check that the array brackets are balanced
+	if strings.Count(t, "[") != strings.Count(t, "]") {
 		return Type{}, fmt.Errorf("abi: type parse error: %s", t)
 	}
-	if typ.IsArray || typ.IsSlice {
-		sliceType, err := NewType(res[1])
+	// check if type is slice or array and if so, recursively parse the element
+	// type in the outermost dimension.
+	base := t
+	if i := strings.LastIndex(t, "["); i >= 0 {
+		res := sliceRegex.FindStringSubmatch(t[i:])
+		if i == 0 || res == nil {
+			return Type{}, fmt.Errorf("abi: type parse error: %s", t)
+		}
+		if res[1] != "" {
+			// err is ignored. Already checked for number through the regexp
+			typ.SliceSize, _ = strconv.Atoi(res[1])
+			typ.IsArray = true
+		} else {
+			typ.IsSlice, typ.SliceSize = true, -1
+		}
+		// the internal type of the elements drops the same dimension
+		if j := strings.LastIndex(internalType, "["); j >= 0 {
+			internalType = internalType[:j]
+		}
+		sliceType, err := NewType(t[:i], internalType, components)
 		if err != nil {
 			return Type{}, err
 		}
 		typ.Elem = &sliceType
-		typ.stringKind = sliceType.stringKind + t[len(res[1]):]
+		typ.stringKind = sliceType.stringKind + t[i:]
 		// Although we know that this is an array, we cannot return
 		// as we don't know the type of the element, however, if it
 		// is still an array, then don't determine the type.
 		if typ.Elem.IsArray || typ.Elem.IsSlice {
 			return typ, nil
 		}
+		base = t[:i]
+	}
+	if !fullTypeRegex.MatchString(base) {
+		return Type{}, fmt.Errorf("abi: type parse error: %s", t)
 	}
-
 	// parse the type and size of the abi-type.
-	parsedType := typeRegex.FindAllStringSubmatch(res[1], -1)[0]
+	parsedType := typeRegex.FindAllStringSubmatch(base, -1)[0]
 	// varSize is the size of the variable
 	var varSize int
 	if len(parsedType[3]) > 0 {
@@ -148,7 +172,7 @@ func NewType(t string) (typ Type, err error) {
 		typ.Size = -1
 		typ.T = StringTy
 	case "bytes":
-		sliceType, _ := NewType("uint8")
+		sliceType, _ := NewType("uint8", "", nil)
 		typ.Elem = &sliceType
 		if varSize == 0 {
 			typ.IsSlice = true
@@ -160,11 +184,50 @@ func NewType(t string) (typ Type, err error) {
 			typ.SliceSize = varSize
 		}
 	case "function":
-		sliceType, _ := NewType("uint8")
+		sliceType, _ := NewType("uint8", "", nil)
 		typ.Elem = &sliceType
 		typ.IsArray = true
 		typ.T = FunctionTy
 		typ.SliceSize = 24
+	case "tuple":
+		var (
+			fields []reflect.StructField
+			names  = make(map[string]bool)
+			kinds  []string
+		)
+		for _, c := range components {
+			elem, err := NewType(c.Type, c.InternalType, c.Components)
+			if err != nil {
+				return Type{}, err
+			}
+			name := ToCamelCase(c.Name)
+			if name == "" {
+				return Type{}, fmt.Errorf("abi: anonymous tuple field in %s", t)
+			}
+			if names[name] {
+				return Type{}, fmt.Errorf("abi: duplicate tuple field %s in %s", name, t)
+			}
+			names[name] = true
+
+			fields = append(fields, reflect.StructField{
+				Name: name,
+				Type: elem.reflectType(),
+				Tag:  reflect.StructTag(`json:"` + c.Name + `"`),
+			})
+			typ.TupleElems = append(typ.TupleElems, &elem)
+			typ.TupleRawNames = append(typ.TupleRawNames, c.Name)
+			kinds = append(kinds, elem.stringKind)
+		}
+		typ.Kind = reflect.Struct
+		typ.Type = reflect.StructOf(fields)
+		typ.T = TupleTy
+		if !(typ.IsArray || typ.IsSlice) {
+			typ.stringKind = "(" + strings.Join(kinds, ",") + ")"
+		}
+		// Nested struct definitions (Foo.Bar) are flattened into a single name
+		if strings.HasPrefix(internalType, "struct ") {
+			typ.TupleRawName = strings.Replace(internalType[len("struct "):], ".", "", -1)
+		}
 	default:
 		return Type{}, fmt.Errorf("unsupported arg type: %s", t)
 	}
@@ -185,28 +248,154 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 		return nil, err
 	}
 
-	if (t.IsSlice || t.IsArray) && t.T != BytesTy && t.T != FixedBytesTy && t.T != FunctionTy {
-		var packed []byte
-
+	switch {
+	case t.composite():
+		elems := make([]*Type, v.Len())
+		values := make([]reflect.Value, v.Len())
 		for i := 0; i < v.Len(); i++ {
-			val, err := t.Elem.pack(v.Index(i))
-			if err != nil {
-				return nil, err
-			}
-			packed = append(packed, val...)
+			elems[i], values[i] = t.Elem, v.Index(i)
+		}
+		packed, err := packSequence(elems, values)
+		if err != nil {
+			return nil, err
 		}
 		if t.IsSlice {
-			return packBytesSlice(packed, v.Len()), nil
-		} else if t.IsArray {
-			return packed, nil
+			return append(packNum(reflect.ValueOf(v.Len())), packed...), nil
 		}
-	}
+		return packed, nil
 
+	case t.T == TupleTy:
+		values := make([]reflect.Value, len(t.TupleElems))
+		for i, name := range t.TupleRawNames {
+			field := v.FieldByName(ToCamelCase(name))
+			if !field.IsValid() {
+				return nil, fmt.Errorf("abi: field %s can't be found in the given value", ToCamelCase(name))
+			}
+			values[i] = field
+		}
+		return packSequence(t.TupleElems, values)
+	}
 	return packElement(t, v), nil
 }
 
-// requireLengthPrefix returns whether the type requires any sort of length
-// prefixing.
-func (t Type) requiresLengthPrefix() bool {
-	return t.T != FixedBytesTy && (t.T == StringTy || t.T == BytesTy || t.IsSlice)
+// packSequence packs a list of values one after the other, using the head/tail
+// encoding: static values are placed inline, dynamic ones are replaced with an
+// offset into the tail section following the heads.
+func packSequence(types []*Type, values []reflect.Value) ([]byte, error) {
+	offset := 0
+	for _, typ := range types {
+		offset += getTypeSize(*typ)
+	}
+	var head, tail []byte
+	for i, typ := range types {
+		packed, err := typ.pack(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if isDynamicType(*typ) {
+			head = append(head, packNum(reflect.ValueOf(offset))...)
+			tail = append(tail, packed...)
+			offset += len(packed)
+		} else {
+			head = append(head, packed...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+// composite returns whether the type is an array or slice of an element type,
+// as opposed to the natively encoded bytes, bytesN and function types.
+func (t Type) composite() bool {
+	return (t.IsSlice || t.IsArray) && t.T != BytesTy && t.T != FixedBytesTy && t.T != FunctionTy
+}
+
+// isDynamicType returns whether the encoding of the type is stored in the tail
+// section of its enclosing sequence, referenced by an offset from the head.
+func isDynamicType(t Type) bool {
+	switch {
+	case t.composite():
+		return t.IsSlice || isDynamicType(*t.Elem)
+	case t.T == TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+		return false
+	}
+	return t.T == StringTy || t.T == BytesTy
+}
+
+// getTypeSize returns the number of bytes the type occupies in the head section
+// of its enclosing sequence. Dynamic types only occupy a single offset word,
+// whereas static arrays and tuples are stored inline.
+func getTypeSize(t Type) int {
+	if isDynamicType(t) {
+		return 32
+	}
+	switch {
+	case t.composite():
+		return t.SliceSize * getTypeSize(*t.Elem)
+	case t.T == TupleTy:
+		size := 0
+		for _, elem := range t.TupleElems {
+			size += getTypeSize(*elem)
+		}
+		return size
+	}
+	return 32
+}
+
+// GoType returns the Go type that values of this abi type are packed from and
+// unpacked into, allowing callers to allocate outputs or convert loosely typed
+// inputs without knowing the abi type up front.
+func (t Type) GoType() reflect.Type {
+	return t.reflectType()
+}
+
+// reflectType returns the Go type that values of this abi type are unpacked into.
+func (t Type) reflectType() reflect.Type {
+	switch {
+	case t.composite() && t.IsSlice:
+		return reflect.SliceOf(t.Elem.reflectType())
+	case t.composite():
+		return reflect.ArrayOf(t.SliceSize, t.Elem.reflectType())
+	}
+	switch t.T {
+	case IntTy, UintTy:
+		switch t.Kind {
+		case reflect.Uint8:
+			return uint8_t
+		case reflect.Uint16:
+			return uint16_t
+		case reflect.Uint32:
+			return uint32_t
+		case reflect.Uint64:
+			return uint64_t
+		case reflect.Int8:
+			return int8_t
+		case reflect.Int16:
+			return int16_t
+		case reflect.Int32:
+			return int32_t
+		case reflect.Int64:
+			return int64_t
+		}
+		return reflect.PtrTo(big_t)
+	case BoolTy:
+		return reflect.TypeOf(false)
+	case StringTy:
+		return reflect.TypeOf("")
+	case AddressTy:
+		return address_t
+	case HashTy:
+		return hash_t
+	case BytesTy:
+		return byte_ts
+	case FixedBytesTy, FunctionTy:
+		return reflect.ArrayOf(t.SliceSize, byte_t)
+	case TupleTy:
+		return t.Type
+	}
+	return nil
 }
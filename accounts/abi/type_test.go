@@ -17,8 +17,11 @@
 package abi
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/bazacoin/go-bazacoin/common"
 )
 
 // typeWithoutStringer is a alias for the Type type which simply doesn't implement
@@ -57,6 +60,9 @@ func TestTypeRegexp(t *testing.T) {
 		{"address", Type{Kind: reflect.Array, Type: address_t, Size: 20, T: AddressTy, stringKind: "address"}},
 		{"address[]", Type{IsSlice: true, SliceSize: -1, Kind: reflect.Array, Type: address_t, T: AddressTy, Size: 20, Elem: &Type{Kind: reflect.Array, Type: address_t, Size: 20, T: AddressTy, stringKind: "address"}, stringKind: "address[]"}},
 		{"address[2]", Type{IsArray: true, SliceSize: 2, Kind: reflect.Array, Type: address_t, T: AddressTy, Size: 20, Elem: &Type{Kind: reflect.Array, Type: address_t, Size: 20, T: AddressTy, stringKind: "address"}, stringKind: "address[2]"}},
+		{"uint[][]", Type{IsSlice: true, SliceSize: -1, Elem: &Type{IsSlice: true, SliceSize: -1, Kind: reflect.Ptr, Type: ubig_t, Size: 256, T: UintTy, Elem: &Type{Kind: reflect.Ptr, Type: ubig_t, Size: 256, T: UintTy, stringKind: "uint256"}, stringKind: "uint256[]"}, stringKind: "uint256[][]"}},
+		{"uint[2][]", Type{IsSlice: true, SliceSize: -1, Elem: &Type{IsArray: true, SliceSize: 2, Kind: reflect.Ptr, Type: ubig_t, Size: 256, T: UintTy, Elem: &Type{Kind: reflect.Ptr, Type: ubig_t, Size: 256, T: UintTy, stringKind: "uint256"}, stringKind: "uint256[2]"}, stringKind: "uint256[2][]"}},
+		{"string[][2]", Type{IsArray: true, SliceSize: 2, Elem: &Type{IsSlice: true, SliceSize: -1, Kind: reflect.String, T: StringTy, Size: -1, Elem: &Type{Kind: reflect.String, T: StringTy, Size: -1, stringKind: "string"}, stringKind: "string[]"}, stringKind: "string[][2]"}},
 
 		// TODO when fixed types are implemented properly
 		// {"fixed", Type{}},
@@ -67,7 +73,7 @@ func TestTypeRegexp(t *testing.T) {
 		// {"fixed128x128[2]", Type{}},
 	}
 	for i, tt := range tests {
-		typ, err := NewType(tt.blob)
+		typ, err := NewType(tt.blob, "", nil)
 		if err != nil {
 			t.Errorf("type %d: failed to parse type string: %v", i, err)
 		}
@@ -76,3 +82,70 @@ func TestTypeRegexp(t *testing.T) {
 		}
 	}
 }
+
+// Tests that tuple types are parsed from their components, nested tuples and
+// tuple arrays included.
+func TestTupleTypes(t *testing.T) {
+	tests := []struct {
+		typ        string
+		internal   string
+		components []ArgumentMarshaling
+		kind       string
+		name       string
+		goType     reflect.Type
+	}{
+		{
+			"tuple", "struct Test.Point",
+			[]ArgumentMarshaling{{Name: "x", Type: "uint256"}, {Name: "y", Type: "uint256"}},
+			"(uint256,uint256)", "TestPoint",
+			reflect.TypeOf(struct {
+				X *big.Int `json:"x"`
+				Y *big.Int `json:"y"`
+			}{}),
+		},
+		{
+			"tuple[]", "struct Point[]",
+			[]ArgumentMarshaling{{Name: "x", Type: "uint8"}, {Name: "tags", Type: "string[]"}},
+			"(uint8,string[])[]", "Point",
+			reflect.TypeOf(struct {
+				X    uint8    `json:"x"`
+				Tags []string `json:"tags"`
+			}{}),
+		},
+		{
+			"tuple", "",
+			[]ArgumentMarshaling{{Name: "owner_address", Type: "address"}, {Name: "inner", Type: "tuple[2]", Components: []ArgumentMarshaling{{Name: "flag", Type: "bool"}}}},
+			"(address,(bool)[2])", "",
+			reflect.TypeOf(struct {
+				OwnerAddress common.Address `json:"owner_address"`
+				Inner        [2]struct {
+					Flag bool `json:"flag"`
+				} `json:"inner"`
+			}{}),
+		},
+	}
+	for i, tt := range tests {
+		typ, err := NewType(tt.typ, tt.internal, tt.components)
+		if err != nil {
+			t.Errorf("test %d: failed to parse type: %v", i, err)
+			continue
+		}
+		if typ.String() != tt.kind {
+			t.Errorf("test %d: signature mismatch: have %s, want %s", i, typ, tt.kind)
+		}
+		tuple := typ
+		if typ.IsSlice || typ.IsArray {
+			tuple = *typ.Elem
+		}
+		if tuple.TupleRawName != tt.name {
+			t.Errorf("test %d: struct name mismatch: have %s, want %s", i, tuple.TupleRawName, tt.name)
+		}
+		if tuple.Type != tt.goType {
+			t.Errorf("test %d: go type mismatch: have %v, want %v", i, tuple.Type, tt.goType)
+		}
+	}
+	// Anonymous tuple fields cannot be mapped to Go structs
+	if _, err := NewType("tuple", "", []ArgumentMarshaling{{Name: "", Type: "uint256"}}); err == nil {
+		t.Errorf("anonymous tuple field accepted")
+	}
+}
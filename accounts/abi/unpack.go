@@ -0,0 +1,216 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/bazacoin/go-bazacoin/common"
+)
+
+// unpackValues unpacks the given arguments one after the other from the output,
+// skipping over the inline encoding of static arrays and tuples.
+func unpackValues(args []Argument, output []byte) ([]interface{}, error) {
+	values := make([]interface{}, 0, len(args))
+
+	offset := 0
+	for _, arg := range args {
+		value, err := toGoType(offset, arg.Type, output)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		offset += getTypeSize(arg.Type)
+	}
+	return values, nil
+}
+
+// toGoType parses the output at the given index and recursively converts it to
+// the proper Go type defined by the ABI type in t.
+func toGoType(index int, t Type, output []byte) (interface{}, error) {
+	if index+32 > len(output) {
+		return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), index+32)
+	}
+	// Composite types are unpacked recursively, dynamic ones being referenced
+	// by an offset, static ones being stored inline.
+	switch {
+	case t.composite() && t.IsSlice:
+		begin, size, err := lengthPrefixPointsTo(index, output)
+		if err != nil {
+			return nil, err
+		}
+		return forEachUnpack(t, output[begin:], size)
+
+	case t.composite() || t.T == TupleTy:
+		data := output[index:]
+		if isDynamicType(t) {
+			offset, err := offsetPointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			data = output[offset:]
+		}
+		if t.T == TupleTy && !t.composite() {
+			return forTupleUnpack(t, data)
+		}
+		return forEachUnpack(t, data, t.SliceSize)
+	}
+	// Parse the given index output and check whether we need to read
+	// a different offset and length based on the type (i.e. string, bytes)
+	returnOutput := output[index : index+32]
+	switch t.T {
+	case StringTy, BytesTy: // variable arrays are written at the end of the return bytes
+		begin, size, err := lengthPrefixPointsTo(index, output)
+		if err != nil {
+			return nil, err
+		}
+		if begin+size > len(output) {
+			return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), begin+size)
+		}
+		returnOutput = output[begin : begin+size]
+	}
+
+	// convert the bytes to whatever is specified by the ABI.
+	switch t.T {
+	case IntTy, UintTy:
+		return readInteger(t.Kind, returnOutput), nil
+	case BoolTy:
+		return !allZero(returnOutput), nil
+	case AddressTy:
+		return common.BytesToAddress(returnOutput), nil
+	case HashTy:
+		return common.BytesToHash(returnOutput), nil
+	case BytesTy, FixedBytesTy, FunctionTy:
+		return returnOutput, nil
+	case StringTy:
+		return string(returnOutput), nil
+	}
+	return nil, fmt.Errorf("abi: unknown type %v", t.T)
+}
+
+// forEachUnpack unpacks size number of elements of an array or slice type from
+// the output, which must start at the first element.
+func forEachUnpack(t Type, output []byte, size int) (interface{}, error) {
+	elemSize := getTypeSize(*t.Elem)
+	if size*elemSize > len(output) {
+		return nil, fmt.Errorf("abi: cannot marshal in to go slice: insufficient size output %d require %d", len(output), size*elemSize)
+	}
+	var refSlice reflect.Value
+	if t.IsSlice {
+		refSlice = reflect.MakeSlice(t.reflectType(), size, size)
+	} else {
+		refSlice = reflect.New(t.reflectType()).Elem()
+	}
+	for i := 0; i < size; i++ {
+		inter, err := toGoType(i*elemSize, *t.Elem, output)
+		if err != nil {
+			return nil, err
+		}
+		if err := set(refSlice.Index(i), reflect.ValueOf(inter), *t.Elem); err != nil {
+			return nil, err
+		}
+	}
+	return refSlice.Interface(), nil
+}
+
+// forTupleUnpack unpacks the fields of a tuple type from the output, which must
+// start at the first field.
+func forTupleUnpack(t Type, output []byte) (interface{}, error) {
+	retval := reflect.New(t.Type).Elem()
+
+	offset := 0
+	for i, elem := range t.TupleElems {
+		inter, err := toGoType(offset, *elem, output)
+		if err != nil {
+			return nil, err
+		}
+		if err := set(retval.Field(i), reflect.ValueOf(inter), *elem); err != nil {
+			return nil, err
+		}
+		offset += getTypeSize(*elem)
+	}
+	return retval.Interface(), nil
+}
+
+// offsetPointsTo resolves the offset stored at the given index of the output,
+// ensuring that it falls within the output boundaries.
+func offsetPointsTo(index int, output []byte) (int, error) {
+	if !allZero(output[index : index+24]) {
+		return 0, fmt.Errorf("abi: offset larger than int64: %x", output[index:index+32])
+	}
+	offset := binary.BigEndian.Uint64(output[index+24 : index+32])
+	if offset >= uint64(len(output)) {
+		return 0, fmt.Errorf("abi: cannot marshal in to go type: offset %d would go over slice boundary (len=%d)", offset, len(output))
+	}
+	return int(offset), nil
+}
+
+// lengthPrefixPointsTo resolves the offset stored at the given index of the
+// output, returning the start of the data following the length prefix and the
+// length itself.
+func lengthPrefixPointsTo(index int, output []byte) (int, int, error) {
+	offset, err := offsetPointsTo(index, output)
+	if err != nil {
+		return 0, 0, err
+	}
+	if offset+32 > len(output) {
+		return 0, 0, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), offset+32)
+	}
+	if !allZero(output[offset : offset+24]) {
+		return 0, 0, fmt.Errorf("abi: length larger than int64: %x", output[offset:offset+32])
+	}
+	size := binary.BigEndian.Uint64(output[offset+24 : offset+32])
+	if size > uint64(len(output)) {
+		return 0, 0, fmt.Errorf("abi: cannot marshal in to go type: length %d would go over slice boundary (len=%d)", size, len(output))
+	}
+	return offset + 32, int(size), nil
+}
+
+func readInteger(kind reflect.Kind, b []byte) interface{} {
+	switch kind {
+	case reflect.Uint8:
+		return uint8(b[len(b)-1])
+	case reflect.Uint16:
+		return binary.BigEndian.Uint16(b[len(b)-2:])
+	case reflect.Uint32:
+		return binary.BigEndian.Uint32(b[len(b)-4:])
+	case reflect.Uint64:
+		return binary.BigEndian.Uint64(b[len(b)-8:])
+	case reflect.Int8:
+		return int8(b[len(b)-1])
+	case reflect.Int16:
+		return int16(binary.BigEndian.Uint16(b[len(b)-2:]))
+	case reflect.Int32:
+		return int32(binary.BigEndian.Uint32(b[len(b)-4:]))
+	case reflect.Int64:
+		return int64(binary.BigEndian.Uint64(b[len(b)-8:]))
+	default:
+		return new(big.Int).SetBytes(b)
+	}
+}
+
+func allZero(b []byte) bool {
+	for _, byte := range b {
+		if byte != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -112,6 +112,14 @@ type Wallet interface {
 	// the account in a keystore).
 	SignTx(account Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
 
+	// SignTypedData requests the wallet to sign the given EIP-712 style typed
+	// structured data. The produced signature is over the typed data signing hash
+	// and, similarly to SignHash, has the V value as 0 or 1.
+	//
+	// If the wallet requires additional authentication to sign the request, an
+	// AuthNeededError instance will be returned, similarly to SignHash.
+	SignTypedData(account Account, typedData *TypedData) ([]byte, error)
+
 	// SignHashWithPassphrase requests the wallet to sign the given hash with the
 	// given passphrase as extra authentication information.
 	//
@@ -125,6 +133,23 @@ type Wallet interface {
 	// It looks up the account specified either solely via its address contained within,
 	// or optionally with the aid of any location metadata from the embedded URL field.
 	SignTxWithPassphrase(account Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+	// SignTypedDataWithPassphrase requests the wallet to sign the given typed
+	// structured data, with the given passphrase as extra authentication information.
+	//
+	// It looks up the account specified either solely via its address contained within,
+	// or optionally with the aid of any location metadata from the embedded URL field.
+	SignTypedDataWithPassphrase(account Account, passphrase string, typedData *TypedData) ([]byte, error)
+}
+
+// TextSigner is implemented by wallets that sign bzc_sign style text messages
+// from the message itself instead of from its hash, e.g. because the hash alone
+// can't be shown to whoever approves the request.
+type TextSigner interface {
+	// SignText requests the wallet to sign the hash of the given message,
+	// keccak256("\x19Bazacoin Signed Message:\n" + len(text) + text). Similarly
+	// to SignHash, the signature has the V value as 0 or 1.
+	SignText(account Account, text []byte) ([]byte, error)
 }
 
 // Backend is a "wallet provider" that may contain a batch of accounts they can
@@ -0,0 +1,251 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an account backend delegating all signing to an
+// external signer daemon (cmd/signer) over RPC.
+package external
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	bazacoin "github.com/bazacoin/go-bazacoin"
+	"github.com/bazacoin/go-bazacoin/accounts"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/event"
+	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/rpc"
+	"github.com/bazacoin/go-bazacoin/signer/core"
+)
+
+// ExternalBackend is an account backend consisting of a single external signer.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend connects to the external signer at the given endpoint (IPC
+// path or URL) and creates a backend around it.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{signers: []accounts.Wallet{signer}}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer is always present,
+// so no wallet events are ever fired.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is a wallet forwarding all signing requests to an external
+// signer daemon, where they are subject to its approval.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+	status   string
+
+	cache   []accounts.Account // Accounts revealed by the signer, fetched on first use
+	cacheMu sync.RWMutex
+}
+
+// NewExternalSigner connects to the external signer at the given endpoint and
+// checks that it speaks a compatible API version.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	signer := &ExternalSigner{
+		client:   client,
+		endpoint: endpoint,
+	}
+	var version string
+	if err := client.Call(&version, "account_version"); err != nil {
+		client.Close()
+		return nil, err
+	}
+	signer.status = fmt.Sprintf("ok [version=%v]", version)
+	return signer, nil
+}
+
+// URL implements accounts.Wallet, returning the endpoint of the signer.
+func (api *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{
+		Scheme: "extapi",
+		Path:   api.endpoint,
+	}
+}
+
+// Status implements accounts.Wallet, returning the API version of the signer.
+func (api *ExternalSigner) Status() string {
+	return api.status
+}
+
+// Open implements accounts.Wallet. The connection is established on creation,
+// so this method is a no-op.
+func (api *ExternalSigner) Open(passphrase string) error {
+	return nil
+}
+
+// Close implements accounts.Wallet, tearing down the connection to the signer.
+func (api *ExternalSigner) Close() error {
+	api.client.Close()
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the accounts the signer agreed
+// to reveal. The list is requested once and cached, as the signer may ask its
+// operator for approval on every request.
+func (api *ExternalSigner) Accounts() []accounts.Account {
+	api.cacheMu.RLock()
+	if api.cache != nil {
+		defer api.cacheMu.RUnlock()
+		return api.cache
+	}
+	api.cacheMu.RUnlock()
+
+	api.cacheMu.Lock()
+	defer api.cacheMu.Unlock()
+
+	if api.cache != nil {
+		return api.cache
+	}
+	var addresses []common.Address
+	if err := api.client.Call(&addresses, "account_list"); err != nil {
+		log.Error("Failed to list accounts of external signer", "err", err)
+		return nil
+	}
+	api.cache = make([]accounts.Account, 0, len(addresses))
+	for _, addr := range addresses {
+		api.cache = append(api.cache, accounts.Account{
+			Address: addr,
+			URL:     api.URL(),
+		})
+	}
+	return api.cache
+}
+
+// Contains implements accounts.Wallet, checking whether the signer revealed
+// the given account.
+func (api *ExternalSigner) Contains(account accounts.Account) bool {
+	for _, acc := range api.Accounts() {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == acc.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, but is not supported by external signers.
+func (api *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a no-op for external signers.
+func (api *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain bazacoin.ChainStateReader) {
+}
+
+// SignHash implements accounts.Wallet. The signer never signs arbitrary hashes,
+// only messages it can show to its operator, see SignText.
+func (api *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignText implements accounts.TextSigner, sending the message to the signer for
+// approval and signing.
+func (api *ExternalSigner) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := api.client.Call(&signature, "account_sign", account.Address, hexutil.Bytes(text)); err != nil {
+		return nil, err
+	}
+	if len(signature) != 65 || (signature[64] != 27 && signature[64] != 28) {
+		return nil, errors.New("invalid signature from external signer")
+	}
+	signature[64] -= 27 // Transform V from 27/28 to 0/1, as returned by SignHash
+	return signature, nil
+}
+
+// SignTx implements accounts.Wallet, sending the transaction to the signer for
+// approval and signing.
+func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := core.SendTxArgs{
+		From:     account.Address,
+		To:       tx.To(),
+		Gas:      hexutil.Big(*tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+	}
+	var res core.SignTxResult
+	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	if res.Tx == nil {
+		return nil, errors.New("external signer returned no transaction")
+	}
+	// Make sure the signer signed what we asked for, on the chain we're on
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	from, err := types.Sender(signer, res.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature from external signer: %v", err)
+	}
+	if from != account.Address || signer.Hash(res.Tx) != signer.Hash(tx) {
+		return nil, errors.New("external signer returned a different transaction")
+	}
+	return res.Tx, nil
+}
+
+// SignTypedData implements accounts.Wallet, however the signer API has no typed
+// data support yet, so this method will always return an error.
+func (api *ExternalSigner) SignTypedData(account accounts.Account, typedData *accounts.TypedData) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, but passwords are never
+// sent to the external signer.
+func (api *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, but passwords are never sent
+// to the external signer.
+func (api *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTypedDataWithPassphrase implements accounts.Wallet, but passwords are never
+// sent to the external signer.
+func (api *ExternalSigner) SignTypedDataWithPassphrase(account accounts.Account, passphrase string, typedData *accounts.TypedData) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
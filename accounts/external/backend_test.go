@@ -0,0 +1,122 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/accounts"
+	"github.com/bazacoin/go-bazacoin/accounts/keystore"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/rpc"
+	"github.com/bazacoin/go-bazacoin/signer/core"
+	"github.com/bazacoin/go-bazacoin/signer/rules"
+)
+
+// Tests that transactions signed through the external backend are approved by
+// the signer's rules and signed with its keys.
+func TestExternalSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Create a signer with a single unlocked account, approving anything to a
+	// single recipient
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatalf("failed to unlock account: %v", err)
+	}
+	payee := common.HexToAddress("0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192")
+	ruleset, err := rules.Parse([]byte(`{"list": "approve", "sign": "approve", "recipients": {"` + payee.Hex() + `": {}}}`))
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	chainID := big.NewInt(1337)
+	api := core.NewSignerAPI(chainID, accounts.NewManager(ks), rules.NewRuleEvaluator(ruleset, core.HeadlessUI{}))
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", api); err != nil {
+		t.Fatalf("failed to register signer API: %v", err)
+	}
+	defer server.Stop()
+
+	endpoint := filepath.Join(dir, "signer.ipc")
+	listener, err := rpc.CreateIPCListener(endpoint)
+	if err != nil {
+		t.Fatalf("failed to open IPC endpoint: %v", err)
+	}
+	defer listener.Close()
+	go server.ServeListener(listener)
+
+	// Connect to the signer and sign through it
+	backend, err := NewExternalBackend(endpoint)
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	wallet := backend.Wallets()[0]
+	defer wallet.Close()
+
+	accs := wallet.Accounts()
+	if len(accs) != 1 || accs[0].Address != account.Address {
+		t.Fatalf("account list mismatch: have %v, want [%x]", accs, account.Address)
+	}
+	tx := types.NewTransaction(0, payee, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	signed, err := wallet.SignTx(accs[0], tx, chainID)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if from, _ := types.Sender(types.NewEIP155Signer(chainID), signed); from != account.Address {
+		t.Errorf("sender mismatch: have %x, want %x", from, account.Address)
+	}
+	// Requests not covered by the rules are rejected by the headless signer
+	tx = types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	if _, err := wallet.SignTx(accs[0], tx, chainID); err == nil || err.Error() != core.ErrRequestDenied.Error() {
+		t.Errorf("error mismatch: have %v, want %v", err, core.ErrRequestDenied)
+	}
+	// Signatures for a different chain must be refused
+	tx = types.NewTransaction(0, payee, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	if _, err := wallet.SignTx(accs[0], tx, big.NewInt(1)); err == nil {
+		t.Errorf("signature for the wrong chain accepted")
+	}
+	// Messages are forwarded to the signer, which hashes and signs them
+	text := []byte("hello")
+	sig, err := wallet.(accounts.TextSigner).SignText(accs[0], text)
+	if err != nil {
+		t.Fatalf("failed to sign text: %v", err)
+	}
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Bazacoin Signed Message:\n%d%s", len(text), text)))
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != account.Address {
+		t.Errorf("text signer mismatch: have %x, want %x", signer, account.Address)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -38,6 +39,14 @@ import (
 // exist yet, the code will attempt to create a watcher at most this often.
 const minReloadInterval = 2 * time.Second
 
+// keyIndexFile is the name of the hidden file within the keystore directory that
+// persists the state of the account cache between runs.
+const keyIndexFile = ".keyindex.json"
+
+// keyIndexVersion is the version of the persisted index format. Indexes with a
+// different version are discarded and the keystore is scanned from scratch.
+const keyIndexVersion = 1
+
 type accountsByURL []accounts.Account
 
 func (s accountsByURL) Len() int           { return len(s) }
@@ -62,6 +71,22 @@ func (err *AmbiguousAddrError) Error() string {
 	return fmt.Sprintf("multiple keys match address (%s)", files)
 }
 
+// cachedKeyFile is the state of a single file in the keystore directory as of
+// the last scan. Files are only re-read if their size or modification time
+// changes. Since key files are never modified in place to hold a different key,
+// this cannot miss an address change even on file systems with coarse mtimes.
+type cachedKeyFile struct {
+	Size    int64          `json:"size"`
+	ModTime int64          `json:"mtime"`
+	Address common.Address `json:"address"` // Zero if the file is not a valid key
+}
+
+// keyIndex is the persisted form of the account cache.
+type keyIndex struct {
+	Version int                      `json:"version"`
+	Files   map[string]cachedKeyFile `json:"files"`
+}
+
 // accountCache is a live index of all accounts in the keystore.
 type accountCache struct {
 	keydir   string
@@ -69,15 +94,19 @@ type accountCache struct {
 	mu       sync.Mutex
 	all      accountsByURL
 	byAddr   map[common.Address][]accounts.Account
+	reserved map[common.Address]bool  // Addresses being imported, not yet in the cache
+	files    map[string]cachedKeyFile // State of the files in keydir, keyed by name
+	index    string                   // Path of the persisted index (empty = disabled)
 	throttle *time.Timer
 	notify   chan struct{}
 }
 
 func newAccountCache(keydir string) (*accountCache, chan struct{}) {
 	ac := &accountCache{
-		keydir: keydir,
-		byAddr: make(map[common.Address][]accounts.Account),
-		notify: make(chan struct{}, 1),
+		keydir:   keydir,
+		byAddr:   make(map[common.Address][]accounts.Account),
+		reserved: make(map[common.Address]bool),
+		notify:   make(chan struct{}, 1),
 	}
 	ac.watcher = newWatcher(ac)
 	return ac, ac.notify
@@ -99,6 +128,35 @@ func (ac *accountCache) hasAddress(addr common.Address) bool {
 	return len(ac.byAddr[addr]) > 0
 }
 
+// reserve atomically checks that none of the given addresses is cached or being
+// imported already and marks all of them as being imported until released. This
+// prevents concurrent imports from storing the same key twice.
+func (ac *accountCache) reserve(addrs []common.Address) error {
+	ac.maybeReload()
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	for _, addr := range addrs {
+		if len(ac.byAddr[addr]) > 0 || ac.reserved[addr] {
+			return fmt.Errorf("account %x already exists", addr)
+		}
+	}
+	for _, addr := range addrs {
+		ac.reserved[addr] = true
+	}
+	return nil
+}
+
+// release drops the import reservation of the given addresses.
+func (ac *accountCache) release(addrs []common.Address) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	for _, addr := range addrs {
+		delete(ac.reserved, addr)
+	}
+}
+
 func (ac *accountCache) add(newAccount accounts.Account) {
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
@@ -205,20 +263,44 @@ func (ac *accountCache) close() {
 	ac.mu.Unlock()
 }
 
+// persist enables saving the account cache into the given index file after each
+// change, loading any previously saved index so that unchanged key files need not
+// be read again.
+func (ac *accountCache) persist(path string) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.index = path
+
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("Failed to load keystore index", "path", path, "err", err)
+		}
+		return
+	}
+	var index keyIndex
+	if err := json.Unmarshal(blob, &index); err != nil || index.Version != keyIndexVersion {
+		log.Debug("Discarding keystore index", "path", path, "version", index.Version, "err", err)
+		return
+	}
+	ac.files = index.Files
+	ac.rebuild()
+}
+
 // reload caches addresses of existing accounts.
 // Callers must hold ac.mu.
 func (ac *accountCache) reload() {
-	accounts, err := ac.scan()
+	changed, err := ac.scan()
 	if err != nil {
 		log.Debug("Failed to reload keystore contents", "err", err)
 	}
-	ac.all = accounts
-	sort.Sort(ac.all)
-	for k := range ac.byAddr {
-		delete(ac.byAddr, k)
+	if !changed {
+		return
 	}
-	for _, a := range accounts {
-		ac.byAddr[a.Address] = append(ac.byAddr[a.Address], a)
+	ac.rebuild()
+	if ac.index != "" {
+		ac.save()
 	}
 	select {
 	case ac.notify <- struct{}{}:
@@ -227,48 +309,140 @@ func (ac *accountCache) reload() {
 	log.Debug("Reloaded keystore contents", "accounts", len(ac.all))
 }
 
-func (ac *accountCache) scan() ([]accounts.Account, error) {
-	files, err := ioutil.ReadDir(ac.keydir)
+// rebuild regenerates the account lists from the file cache.
+// Callers must hold ac.mu.
+func (ac *accountCache) rebuild() {
+	ac.all = make(accountsByURL, 0, len(ac.files))
+	for name, file := range ac.files {
+		if (file.Address != common.Address{}) {
+			ac.all = append(ac.all, accounts.Account{Address: file.Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(ac.keydir, name)}})
+		}
+	}
+	sort.Sort(ac.all)
+
+	for k := range ac.byAddr {
+		delete(ac.byAddr, k)
+	}
+	for _, a := range ac.all {
+		ac.byAddr[a.Address] = append(ac.byAddr[a.Address], a)
+	}
+}
+
+// save writes the file cache into the index file. The index is not written if
+// the keystore directory does not exist, to avoid creating it as a side effect.
+// Callers must hold ac.mu.
+func (ac *accountCache) save() {
+	if _, err := os.Stat(ac.keydir); err != nil {
+		return
+	}
+	blob, err := json.Marshal(keyIndex{Version: keyIndexVersion, Files: ac.files})
+	if err == nil {
+		err = writeKeyFile(ac.index, blob)
+	}
 	if err != nil {
-		return nil, err
+		log.Debug("Failed to save keystore index", "path", ac.index, "err", err)
 	}
+}
 
+// scan lists the keystore directory and updates the file cache, reading only the
+// files that were added or modified since the last scan. It reports whether any
+// file was added, modified or removed.
+// Callers must hold ac.mu.
+func (ac *accountCache) scan() (bool, error) {
+	files, err := ioutil.ReadDir(ac.keydir)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
 	var (
-		buf     = new(bufio.Reader)
-		addrs   []accounts.Account
-		keyJSON struct {
-			Address string `json:"address"`
-		}
+		current = make(map[string]cachedKeyFile, len(files))
+		stale   []string
 	)
 	for _, fi := range files {
-		path := filepath.Join(ac.keydir, fi.Name())
 		if skipKeyFile(fi) {
-			log.Trace("Ignoring file on account scan", "path", path)
+			log.Trace("Ignoring file on account scan", "path", filepath.Join(ac.keydir, fi.Name()))
 			continue
 		}
-		logger := log.New("path", path)
-
-		fd, err := os.Open(path)
-		if err != nil {
-			logger.Trace("Failed to open keystore file", "err", err)
+		file := cachedKeyFile{Size: fi.Size(), ModTime: fi.ModTime().UnixNano()}
+		if cached, ok := ac.files[fi.Name()]; ok && cached.Size == file.Size && cached.ModTime == file.ModTime {
+			current[fi.Name()] = cached
 			continue
 		}
-		buf.Reset(fd)
-		// Parse the address.
-		keyJSON.Address = ""
-		err = json.NewDecoder(buf).Decode(&keyJSON)
-		addr := common.HexToAddress(keyJSON.Address)
-		switch {
-		case err != nil:
-			logger.Debug("Failed to decode keystore key", "err", err)
-		case (addr == common.Address{}):
-			logger.Debug("Failed to decode keystore key", "err", "missing or zero address")
-		default:
-			addrs = append(addrs, accounts.Account{Address: addr, URL: accounts.URL{Scheme: KeyStoreScheme, Path: path}})
+		current[fi.Name()] = file
+		stale = append(stale, fi.Name())
+	}
+	// Parse the addresses out of all new or modified files concurrently
+	addrs := make([]common.Address, len(stale))
+	valid := make([]bool, len(stale))
+
+	parallelize(len(stale), runtime.NumCPU(), func(i int) {
+		addrs[i], valid[i] = readKeyAddress(filepath.Join(ac.keydir, stale[i]))
+	})
+	for i, name := range stale {
+		if !valid[i] {
+			delete(current, name) // Unreadable file, retry on the next scan
+			continue
 		}
-		fd.Close()
+		file := current[name]
+		file.Address = addrs[i]
+		current[name] = file
+	}
+	changed := len(stale) > 0 || len(current) != len(ac.files)
+	ac.files = current
+
+	return changed, nil
+}
+
+// readKeyAddress parses the address out of a key file. The returned address is
+// zero if the file is not a valid key, and the flag is false if the file could
+// not be read at all.
+func readKeyAddress(path string) (common.Address, bool) {
+	logger := log.New("path", path)
+
+	fd, err := os.Open(path)
+	if err != nil {
+		logger.Trace("Failed to open keystore file", "err", err)
+		return common.Address{}, false
+	}
+	defer fd.Close()
+
+	var keyJSON struct {
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(bufio.NewReader(fd)).Decode(&keyJSON); err != nil {
+		logger.Debug("Failed to decode keystore key", "err", err)
+		return common.Address{}, true
+	}
+	addr := common.HexToAddress(keyJSON.Address)
+	if (addr == common.Address{}) {
+		logger.Debug("Failed to decode keystore key", "err", "missing or zero address")
+	}
+	return addr, true
+}
+
+// parallelize runs fn for every index in [0, n) concurrently on the given number
+// of workers, returning once all invocations are done.
+func parallelize(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	var (
+		tasks = make(chan int)
+		wg    sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		tasks <- i
 	}
-	return addrs, err
+	close(tasks)
+	wg.Wait()
 }
 
 func skipKeyFile(fi os.FileInfo) bool {
@@ -18,6 +18,7 @@ package keystore
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -295,3 +296,197 @@ func TestCacheFind(t *testing.T) {
 		}
 	}
 }
+
+// Tests that reloading the cache only re-reads files that were added or modified
+// since the last scan, and only notifies on actual changes.
+func TestCacheIncrementalReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"aaa", "zzz"} {
+		if err := cp.CopyFile(filepath.Join(dir, name), filepath.Join(cachetestDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache, notify := newAccountCache(dir)
+	cache.watcher.running = true // prevent unexpected reloads
+
+	reload := func() ([]accounts.Account, bool) {
+		cache.mu.Lock()
+		cache.reload()
+		cache.mu.Unlock()
+
+		select {
+		case <-notify:
+			return cache.accounts(), true
+		default:
+			return cache.accounts(), false
+		}
+	}
+	want := []accounts.Account{
+		{Address: cachetestAccounts[1].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(dir, "aaa")}},
+		{Address: cachetestAccounts[2].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(dir, "zzz")}},
+	}
+	if list, notified := reload(); !reflect.DeepEqual(list, want) || !notified {
+		t.Fatalf("initial reload mismatch: notified %v\nhave %v\nwant %v", notified, list, want)
+	}
+	if _, notified := reload(); notified {
+		t.Fatalf("notified without any changes")
+	}
+	// Overwrite a key with a same sized one, but retain its mtime: no reload
+	path := filepath.Join(dir, "zzz")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := ioutil.ReadFile(filepath.Join(cachetestDir, "aaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, blob, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if list, notified := reload(); !reflect.DeepEqual(list, want) || notified {
+		t.Fatalf("unmodified file re-read: notified %v\nhave %v\nwant %v", notified, list, want)
+	}
+	// Bump the mtime and ensure the file is picked up
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	want[1].Address = cachetestAccounts[1].Address
+	if list, notified := reload(); !reflect.DeepEqual(list, want) || !notified {
+		t.Fatalf("modified file not re-read: notified %v\nhave %v\nwant %v", notified, list, want)
+	}
+	// Add and delete a few files and ensure they are tracked
+	if err := cp.CopyFile(filepath.Join(dir, "bbb"), cachetestAccounts[0].URL.Path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "aaa")); err != nil {
+		t.Fatal(err)
+	}
+	want = []accounts.Account{
+		{Address: cachetestAccounts[0].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(dir, "bbb")}},
+		{Address: cachetestAccounts[1].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(dir, "zzz")}},
+	}
+	if list, notified := reload(); !reflect.DeepEqual(list, want) || !notified {
+		t.Fatalf("added/deleted files not tracked: notified %v\nhave %v\nwant %v", notified, list, want)
+	}
+}
+
+// Tests that the account cache index is persisted and reused across restarts,
+// and that corrupt indexes are discarded.
+func TestCacheIndexPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore-index-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"aaa", "zzz", "garbage"} {
+		if err := cp.CopyFile(filepath.Join(dir, name), filepath.Join(cachetestDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := filepath.Join(dir, keyIndexFile)
+	want := []accounts.Account{
+		{Address: cachetestAccounts[1].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(dir, "aaa")}},
+		{Address: cachetestAccounts[2].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(dir, "zzz")}},
+	}
+	// Create a cache and ensure the index is written
+	cache, _ := newAccountCache(dir)
+	cache.watcher.running = true
+	cache.persist(index)
+
+	cache.mu.Lock()
+	cache.reload()
+	cache.mu.Unlock()
+
+	if list := cache.accounts(); !reflect.DeepEqual(list, want) {
+		t.Fatalf("account mismatch:\nhave %v\nwant %v", list, want)
+	}
+	if _, err := os.Stat(index); err != nil {
+		t.Fatalf("index not persisted: %v", err)
+	}
+	// Corrupt a key retaining its size and mtime, the index should be trusted
+	path := filepath.Join(dir, "zzz")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, make([]byte, info.Size()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	cache, _ = newAccountCache(dir)
+	cache.watcher.running = true
+	cache.persist(index)
+
+	if list := cache.accounts(); !reflect.DeepEqual(list, want) {
+		t.Fatalf("index not loaded:\nhave %v\nwant %v", list, want)
+	}
+	cache.mu.Lock()
+	cache.reload()
+	cache.mu.Unlock()
+
+	if list := cache.accounts(); !reflect.DeepEqual(list, want) {
+		t.Fatalf("indexed files re-read:\nhave %v\nwant %v", list, want)
+	}
+	// Corrupt the index itself and ensure the keystore is rescanned
+	if err := ioutil.WriteFile(index, []byte("not an index"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cache, _ = newAccountCache(dir)
+	cache.watcher.running = true
+	cache.persist(index)
+
+	cache.mu.Lock()
+	cache.reload()
+	cache.mu.Unlock()
+
+	if list := cache.accounts(); !reflect.DeepEqual(list, want[:1]) {
+		t.Fatalf("corrupt index not discarded:\nhave %v\nwant %v", list, want[:1])
+	}
+}
+
+func BenchmarkCacheReload(b *testing.B) {
+	dir, err := ioutil.TempDir("", "keystore-cache-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 1000; i++ {
+		if err := cp.CopyFile(filepath.Join(dir, fmt.Sprintf("key-%04d", i)), cachetestAccounts[0].URL.Path); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cache, _ := newAccountCache(dir)
+			cache.mu.Lock()
+			cache.reload()
+			cache.mu.Unlock()
+		}
+	})
+	b.Run("warm", func(b *testing.B) {
+		cache, _ := newAccountCache(dir)
+		cache.mu.Lock()
+		cache.reload()
+		cache.mu.Unlock()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			cache.mu.Lock()
+			cache.reload()
+			cache.mu.Unlock()
+		}
+	})
+}
@@ -0,0 +1,419 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/accounts"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/crypto/randentropy"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// backupVersion is the current version of the backup bundle format.
+	backupVersion = 1
+
+	backupCipher = "aes-256-gcm"
+	backupDKLen  = 32
+
+	// maxBackupScryptN and maxBackupScryptP bound the scrypt parameters of backup
+	// bundles, so that a crafted bundle cannot exhaust memory or CPU on restore.
+	maxBackupScryptN = scryptMemoryBudget / (128 * scryptR)
+	maxBackupScryptP = 16
+)
+
+// ErrBackupCorrupted is returned if a backup bundle decrypts fine but its content
+// doesn't match its metadata.
+var ErrBackupCorrupted = errors.New("backup bundle corrupted")
+
+// BackupMetadata is the unencrypted descriptor of a backup bundle. It is bound
+// to the encrypted keys as additional authenticated data, so it cannot be altered
+// without the bundle failing verification on restore.
+type BackupMetadata struct {
+	Version  int              `json:"version"`  // Version of the bundle format
+	Created  time.Time        `json:"created"`  // Time the bundle was created at
+	Accounts []common.Address `json:"accounts"` // Addresses of the keys in the bundle, in order
+}
+
+// backupJSON is the serialized form of a backup bundle.
+type backupJSON struct {
+	Metadata json.RawMessage  `json:"metadata"`
+	Crypto   backupCryptoJSON `json:"crypto"`
+}
+
+// backupCryptoJSON contains the encrypted keys of a backup bundle along with the
+// parameters needed to decrypt them.
+type backupCryptoJSON struct {
+	Cipher     string           `json:"cipher"`
+	CipherText string           `json:"ciphertext"`
+	Nonce      string           `json:"nonce"`
+	KDF        string           `json:"kdf"`
+	KDFParams  scryptParamsJSON `json:"kdfparams"`
+}
+
+// Backup exports the given accounts into a single encrypted backup bundle. The
+// keys are decrypted with passphrase and the whole bundle is encrypted with
+// backupPassphrase, using the scrypt parameters of the keystore to derive an
+// AES-256-GCM key from it.
+func (ks *KeyStore) Backup(accs []accounts.Account, passphrase, backupPassphrase string) ([]byte, error) {
+	if len(accs) == 0 {
+		return nil, errors.New("no accounts to back up")
+	}
+	// Decrypt all the keys to back up concurrently
+	keys := make([]*Key, len(accs))
+	errs := make([]error, len(accs))
+
+	parallelize(len(accs), ks.decryptWorkers(), func(i int) {
+		_, keys[i], errs[i] = ks.getDecryptedKey(accs[i], passphrase)
+	})
+	defer func() {
+		for _, key := range keys {
+			if key != nil {
+				zeroKey(key.PrivateKey)
+			}
+		}
+	}()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("account %x: %v", accs[i].Address, err)
+		}
+	}
+	// Assemble the metadata and encrypt the keys, authenticating the metadata too
+	meta := &BackupMetadata{
+		Version:  backupVersion,
+		Created:  time.Now().UTC().Truncate(time.Second),
+		Accounts: make([]common.Address, len(keys)),
+	}
+	for i, key := range keys {
+		meta.Accounts[i] = key.Address
+	}
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	plainText, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(plainText)
+
+	scryptN, scryptP := ks.scryptParams()
+	if err := checkBackupScrypt(scryptN, scryptR, scryptP); err != nil {
+		return nil, err
+	}
+	salt := randentropy.GetEntropyCSPRNG(32)
+	derivedKey, err := scrypt.Key([]byte(backupPassphrase), salt, scryptN, scryptR, scryptP, backupDKLen)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newBackupCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := randentropy.GetEntropyCSPRNG(gcm.NonceSize())
+	cipherText := gcm.Seal(nil, nonce, plainText, metaJSON)
+
+	return json.Marshal(&backupJSON{
+		Metadata: metaJSON,
+		Crypto: backupCryptoJSON{
+			Cipher:     backupCipher,
+			CipherText: hex.EncodeToString(cipherText),
+			Nonce:      hex.EncodeToString(nonce),
+			KDF:        keyHeaderKDF,
+			KDFParams: scryptParamsJSON{
+				N:     scryptN,
+				R:     scryptR,
+				P:     scryptP,
+				DkLen: backupDKLen,
+				Salt:  hex.EncodeToString(salt),
+			},
+		},
+	})
+}
+
+// ReadBackupMetadata returns the metadata of a backup bundle without decrypting
+// it. As the metadata is only authenticated upon decryption, it must not be
+// trusted before the bundle is restored.
+func ReadBackupMetadata(bundle []byte) (*BackupMetadata, error) {
+	backup, err := parseBackup(bundle)
+	if err != nil {
+		return nil, err
+	}
+	meta := new(BackupMetadata)
+	if err := json.Unmarshal(backup.Metadata, meta); err != nil {
+		return nil, fmt.Errorf("invalid backup metadata: %v", err)
+	}
+	if meta.Version != backupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", meta.Version)
+	}
+	return meta, nil
+}
+
+// Restore verifies and decrypts a backup bundle with backupPassphrase and stores
+// the keys within into the key directory, encrypting them with passphrase. Keys
+// already present in the keystore are skipped, the restored accounts are returned.
+// Nothing is stored unless the entire bundle is verified successfully.
+//
+// If storing some of the keys fails, the ones successfully stored are kept and
+// returned along with the first error encountered.
+func (ks *KeyStore) Restore(bundle []byte, backupPassphrase, passphrase string) ([]accounts.Account, error) {
+	keys, err := openBackup(bundle, backupPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, key := range keys {
+			zeroKey(key.PrivateKey)
+		}
+	}()
+	var missing []*Key
+	for _, key := range keys {
+		if !ks.cache.hasAddress(key.Address) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	addrs := make([]common.Address, len(missing))
+	for i, key := range missing {
+		addrs[i] = key.Address
+	}
+	if err := ks.cache.reserve(addrs); err != nil {
+		return nil, err
+	}
+	defer ks.cache.release(addrs)
+
+	return ks.importKeys(missing, passphrase)
+}
+
+// parseBackup decodes a backup bundle, ensuring it uses supported algorithms.
+func parseBackup(bundle []byte) (*backupJSON, error) {
+	backup := new(backupJSON)
+	if err := json.Unmarshal(bundle, backup); err != nil {
+		return nil, fmt.Errorf("invalid backup bundle: %v", err)
+	}
+	if len(backup.Metadata) == 0 {
+		return nil, errors.New("invalid backup bundle: missing metadata")
+	}
+	if backup.Crypto.Cipher != backupCipher {
+		return nil, fmt.Errorf("unsupported backup cipher %q", backup.Crypto.Cipher)
+	}
+	if backup.Crypto.KDF != keyHeaderKDF {
+		return nil, fmt.Errorf("unsupported backup KDF %q", backup.Crypto.KDF)
+	}
+	if backup.Crypto.KDFParams.DkLen != backupDKLen {
+		return nil, fmt.Errorf("unsupported backup key length %d", backup.Crypto.KDFParams.DkLen)
+	}
+	params := backup.Crypto.KDFParams
+	if err := checkBackupScrypt(params.N, params.R, params.P); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// checkBackupScrypt ensures the scrypt parameters of a backup bundle are valid and
+// within the limits backups are created and restored with.
+func checkBackupScrypt(N, r, p int) error {
+	if N <= 1 || N&(N-1) != 0 || N > maxBackupScryptN {
+		return fmt.Errorf("unsupported backup scrypt N parameter %d", N)
+	}
+	if r != scryptR {
+		return fmt.Errorf("unsupported backup scrypt r parameter %d", r)
+	}
+	if p < 1 || p > maxBackupScryptP {
+		return fmt.Errorf("unsupported backup scrypt p parameter %d", p)
+	}
+	return nil
+}
+
+// openBackup decrypts a backup bundle and verifies its content against its
+// metadata, returning the keys within.
+func openBackup(bundle []byte, backupPassphrase string) ([]*Key, error) {
+	backup, err := parseBackup(bundle)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := ReadBackupMetadata(bundle)
+	if err != nil {
+		return nil, err
+	}
+	// The metadata is authenticated in its compacted form, so reformatting the
+	// bundle doesn't break it
+	var metaJSON bytes.Buffer
+	if err := json.Compact(&metaJSON, backup.Metadata); err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(backup.Crypto.KDFParams.Salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(backup.Crypto.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := hex.DecodeString(backup.Crypto.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	params := backup.Crypto.KDFParams
+	derivedKey, err := scrypt.Key([]byte(backupPassphrase), salt, params.N, params.R, params.P, backupDKLen)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newBackupCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid backup nonce length %d", len(nonce))
+	}
+	plainText, err := gcm.Open(nil, nonce, cipherText, metaJSON.Bytes())
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	defer zeroBytes(plainText)
+
+	// Ensure the decrypted keys are exactly the ones listed in the metadata
+	var keys []*Key
+	if err := json.Unmarshal(plainText, &keys); err != nil {
+		return nil, ErrBackupCorrupted
+	}
+	if len(keys) != len(meta.Accounts) {
+		return nil, ErrBackupCorrupted
+	}
+	for i, key := range keys {
+		if key == nil || key.PrivateKey == nil || key.Address != meta.Accounts[i] || crypto.PubkeyToAddress(key.PrivateKey.PublicKey) != key.Address {
+			return nil, ErrBackupCorrupted
+		}
+	}
+	return keys, nil
+}
+
+// newBackupCipher creates the authenticated cipher backup bundles are encrypted with.
+func newBackupCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Reencrypt re-encrypts the key files of the given accounts in place, with the
+// scrypt parameters of the keystore, keeping their passphrase. Only keys with
+// weaker parameters than the keystore's (or using a legacy format) are touched,
+// the re-encrypted accounts are returned. The keys are processed concurrently, but
+// exclusively of other modifications to the key files of the keystore.
+//
+// If re-encrypting some of the keys fails, the ones successfully re-encrypted are
+// kept and returned along with the first error encountered.
+func (ks *KeyStore) Reencrypt(accs []accounts.Account, passphrase string) ([]accounts.Account, error) {
+	store, ok := ks.storage.(*keyStorePassphrase)
+	if !ok {
+		return nil, errors.New("keystore is not encrypted")
+	}
+	ks.fileMu.Lock()
+	defer ks.fileMu.Unlock()
+
+	done := make([]bool, len(accs))
+	errs := make([]error, len(accs))
+
+	parallelize(len(accs), ks.decryptWorkers(), func(i int) {
+		a, err := ks.Find(accs[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		keyjson, err := ioutil.ReadFile(a.URL.Path)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		if weak, err := weakKey(keyjson, store.scryptN, store.scryptP); err != nil || !weak {
+			errs[i] = err
+			return
+		}
+		a, key, err := ks.getDecryptedKey(a, passphrase)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		defer zeroKey(key.PrivateKey)
+
+		if errs[i] = ks.storage.StoreKey(a.URL.Path, key, passphrase); errs[i] == nil {
+			done[i] = true
+		}
+	})
+	var (
+		upgraded []accounts.Account
+		err      error
+	)
+	for i, a := range accs {
+		if errs[i] != nil && err == nil {
+			err = fmt.Errorf("account %x: %v", a.Address, errs[i])
+		}
+		if done[i] {
+			upgraded = append(upgraded, a)
+		}
+	}
+	return upgraded, err
+}
+
+// weakKey reports whether an encrypted key file uses weaker protection than the
+// given scrypt parameters: a legacy format, a different KDF or a lower work factor.
+func weakKey(keyjson []byte, scryptN, scryptP int) (bool, error) {
+	var k struct {
+		Version interface{} `json:"version"`
+		Crypto  cryptoJSON  `json:"crypto"`
+	}
+	if err := json.Unmarshal(keyjson, &k); err != nil {
+		return false, err
+	}
+	if v, ok := k.Version.(float64); !ok || int(v) != version {
+		return true, nil
+	}
+	if k.Crypto.KDF != keyHeaderKDF {
+		return true, nil
+	}
+	n, ok := k.Crypto.KDFParams["n"].(float64)
+	if !ok {
+		return false, errors.New("invalid scrypt parameters")
+	}
+	p, ok := k.Crypto.KDFParams["p"].(float64)
+	if !ok {
+		return false, errors.New("invalid scrypt parameters")
+	}
+	return int(n) < scryptN || (int(n) == scryptN && int(p) < scryptP), nil
+}
+
+// zeroBytes zeroes a byte slice holding sensitive data.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
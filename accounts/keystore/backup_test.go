@@ -0,0 +1,206 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/accounts"
+)
+
+// Tests that accounts backed up into a bundle can be restored into a different
+// keystore, skipping the ones already present there.
+func TestBackupRestore(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	var accs []accounts.Account
+	for i := 0; i < 4; i++ {
+		a, err := ks.NewAccount("foo")
+		if err != nil {
+			t.Fatalf("failed to create account: %v", err)
+		}
+		accs = append(accs, a)
+	}
+	if _, err := ks.Backup(accs, "bar", "backup"); err == nil {
+		t.Errorf("backup succeeded with wrong passphrase")
+	}
+	bundle, err := ks.Backup(accs[:3], "foo", "backup")
+	if err != nil {
+		t.Fatalf("failed to back up accounts: %v", err)
+	}
+	meta, err := ReadBackupMetadata(bundle)
+	if err != nil {
+		t.Fatalf("failed to read backup metadata: %v", err)
+	}
+	if meta.Version != backupVersion || len(meta.Accounts) != 3 || meta.Created.IsZero() {
+		t.Errorf("metadata mismatch: have %+v", meta)
+	}
+	// Restore into a fresh keystore holding one of the accounts already
+	rdir, restored := tmpKeyStore(t, true)
+	defer os.RemoveAll(rdir)
+
+	blob, _ := ks.Export(accs[0], "foo", "baz")
+	if _, err := restored.Import(blob, "baz", "baz"); err != nil {
+		t.Fatalf("failed to import account: %v", err)
+	}
+	if _, err := restored.Restore(bundle, "wrong", "qux"); err != ErrDecrypt {
+		t.Errorf("restore with wrong passphrase: have %v, want %v", err, ErrDecrypt)
+	}
+	res, err := restored.Restore(bundle, "backup", "qux")
+	if err != nil {
+		t.Fatalf("failed to restore backup: %v", err)
+	}
+	if len(res) != 2 || res[0].Address != accs[1].Address || res[1].Address != accs[2].Address {
+		t.Fatalf("restored accounts mismatch: have %v", res)
+	}
+	for _, a := range res {
+		if err := restored.Unlock(a, "qux"); err != nil {
+			t.Errorf("failed to unlock restored account %x: %v", a.Address, err)
+		}
+	}
+	if restored.HasAddress(accs[3].Address) {
+		t.Errorf("account not in backup restored")
+	}
+	// Restoring again should be a noop
+	if res, err := restored.Restore(bundle, "backup", "qux"); err != nil || len(res) != 0 {
+		t.Errorf("repeated restore: have %v, %v, want nothing", res, err)
+	}
+}
+
+// Tests that tampering with any part of a backup bundle is detected on restore.
+func TestBackupTampering(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	a1, _ := ks.NewAccount("foo")
+	a2, _ := ks.NewAccount("foo")
+	bundle, err := ks.Backup([]accounts.Account{a1, a2}, "foo", "backup")
+	if err != nil {
+		t.Fatalf("failed to back up accounts: %v", err)
+	}
+	// Reformatting the bundle must not break it
+	var indented bytes.Buffer
+	json.Indent(&indented, bundle, "", "  ")
+	if _, err := openBackup(indented.Bytes(), "backup"); err != nil {
+		t.Errorf("failed to open reformatted bundle: %v", err)
+	}
+	tamper := func(fn func(backup map[string]interface{})) []byte {
+		var backup map[string]interface{}
+		if err := json.Unmarshal(bundle, &backup); err != nil {
+			t.Fatalf("failed to decode bundle: %v", err)
+		}
+		fn(backup)
+		blob, _ := json.Marshal(backup)
+		return blob
+	}
+	tests := map[string][]byte{
+		"dropped account": tamper(func(backup map[string]interface{}) {
+			meta := backup["metadata"].(map[string]interface{})
+			meta["accounts"] = meta["accounts"].([]interface{})[:1]
+		}),
+		"altered date": tamper(func(backup map[string]interface{}) {
+			backup["metadata"].(map[string]interface{})["created"] = "2001-01-01T00:00:00Z"
+		}),
+		"altered ciphertext": tamper(func(backup map[string]interface{}) {
+			crypto := backup["crypto"].(map[string]interface{})
+			blob, _ := hex.DecodeString(crypto["ciphertext"].(string))
+			blob[0] ^= 0x01
+			crypto["ciphertext"] = hex.EncodeToString(blob)
+		}),
+		"future version": tamper(func(backup map[string]interface{}) {
+			backup["metadata"].(map[string]interface{})["version"] = backupVersion + 1
+		}),
+		"unknown cipher": tamper(func(backup map[string]interface{}) {
+			backup["crypto"].(map[string]interface{})["cipher"] = "aes-128-ctr"
+		}),
+		"huge scrypt N": tamper(func(backup map[string]interface{}) {
+			backup["crypto"].(map[string]interface{})["kdfparams"].(map[string]interface{})["n"] = 1 << 30
+		}),
+		"invalid scrypt N": tamper(func(backup map[string]interface{}) {
+			backup["crypto"].(map[string]interface{})["kdfparams"].(map[string]interface{})["n"] = 1000
+		}),
+		"huge scrypt r": tamper(func(backup map[string]interface{}) {
+			backup["crypto"].(map[string]interface{})["kdfparams"].(map[string]interface{})["r"] = 1 << 20
+		}),
+		"huge scrypt p": tamper(func(backup map[string]interface{}) {
+			backup["crypto"].(map[string]interface{})["kdfparams"].(map[string]interface{})["p"] = 1 << 20
+		}),
+	}
+	for name, blob := range tests {
+		if _, err := openBackup(blob, "backup"); err == nil {
+			t.Errorf("%s: tampered bundle opened", name)
+		}
+	}
+}
+
+// Tests that keys with weak encryption are re-encrypted in place with the scrypt
+// parameters of the keystore, leaving strong ones alone.
+func TestReencrypt(t *testing.T) {
+	dir, weak := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	a1, _ := weak.NewAccount("foo")
+	a2, _ := weak.NewAccount("foo")
+
+	// Open the same directory with stronger parameters and upgrade one key
+	strong := NewKeyStore(dir, veryLightScryptN*4, veryLightScryptP)
+	upgraded, err := strong.Reencrypt([]accounts.Account{a1}, "foo")
+	if err != nil {
+		t.Fatalf("failed to re-encrypt keys: %v", err)
+	}
+	if len(upgraded) != 1 || upgraded[0].Address != a1.Address {
+		t.Fatalf("upgraded accounts mismatch: have %v", upgraded)
+	}
+	for _, a := range []accounts.Account{a1, a2} {
+		keyjson, err := ioutil.ReadFile(a.URL.Path)
+		if err != nil {
+			t.Fatalf("failed to read key: %v", err)
+		}
+		isWeak, err := weakKey(keyjson, veryLightScryptN*4, veryLightScryptP)
+		if err != nil {
+			t.Fatalf("failed to check key: %v", err)
+		}
+		if isWeak != (a == a2) {
+			t.Errorf("account %x: weakness mismatch: have %v, want %v", a.Address, isWeak, a == a2)
+		}
+		if _, err := DecryptKey(keyjson, "foo"); err != nil {
+			t.Errorf("account %x: failed to decrypt: %v", a.Address, err)
+		}
+	}
+	// Already upgraded keys should be skipped, wrong passphrases reported
+	if upgraded, err := strong.Reencrypt([]accounts.Account{a1}, "bar"); err != nil || len(upgraded) != 0 {
+		t.Errorf("strong key re-encrypted: have %v, %v", upgraded, err)
+	}
+	if upgraded, err := strong.Reencrypt([]accounts.Account{a2}, "bar"); err == nil || len(upgraded) != 0 {
+		t.Errorf("key re-encrypted with wrong passphrase: have %v, %v", upgraded, err)
+	}
+	// Legacy key formats should always be upgraded
+	for _, keyjson := range []string{
+		`{"version":"1","crypto":{"kdf":"scrypt","kdfparams":{"n":262144,"p":1}}}`,
+		`{"version":3,"crypto":{"kdf":"pbkdf2","kdfparams":{"c":262144}}}`,
+	} {
+		if isWeak, err := weakKey([]byte(keyjson), veryLightScryptN, veryLightScryptP); err != nil || !isWeak {
+			t.Errorf("legacy key %s not considered weak: %v", keyjson, err)
+		}
+	}
+}
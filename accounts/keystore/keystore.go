@@ -67,7 +67,8 @@ type KeyStore struct {
 	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
 	updating    bool                    // Whether the event notification loop is running
 
-	mu sync.RWMutex
+	mu     sync.RWMutex
+	fileMu sync.Mutex // Serialises in-place rewrites and removals of key files
 }
 
 type unlocked struct {
@@ -100,6 +101,7 @@ func (ks *KeyStore) init(keydir string) {
 	// Initialize the set of unlocked keys and the account cache
 	ks.unlocked = make(map[common.Address]*unlocked)
 	ks.cache, ks.changes = newAccountCache(keydir)
+	ks.cache.persist(filepath.Join(keydir, keyIndexFile))
 
 	// TODO: In order for this finalizer to work, there must be no references
 	// to ks. addressCache doesn't keep a reference but unlocked keys do,
@@ -231,6 +233,9 @@ func (ks *KeyStore) Accounts() []accounts.Account {
 // Delete deletes the key matched by account if the passphrase is correct.
 // If the account contains no filename, the address must match a unique key.
 func (ks *KeyStore) Delete(a accounts.Account, passphrase string) error {
+	ks.fileMu.Lock()
+	defer ks.fileMu.Unlock()
+
 	// Decrypting the key isn't really necessary, but we do
 	// it anyway to check the password and zero out the key
 	// immediately afterwards.
@@ -284,6 +289,17 @@ func (ks *KeyStore) SignTx(a accounts.Account, tx *types.Transaction, chainID *b
 	return types.SignTx(tx, types.HomesteadSigner{}, unlockedKey.PrivateKey)
 }
 
+// SignTypedData calculates the signing hash of the given EIP-712 style typed
+// data and signs it with the requested account. The produced signature is in
+// the [R || S || V] format where V is 0 or 1.
+func (ks *KeyStore) SignTypedData(a accounts.Account, typedData *accounts.TypedData) ([]byte, error) {
+	hash, err := typedData.SigningHash()
+	if err != nil {
+		return nil, err
+	}
+	return ks.SignHash(a, hash)
+}
+
 // SignHashWithPassphrase signs hash if the private key matching the given address
 // can be decrypted with the given passphrase. The produced signature is in the
 // [R || S || V] format where V is 0 or 1.
@@ -312,6 +328,17 @@ func (ks *KeyStore) SignTxWithPassphrase(a accounts.Account, passphrase string,
 	return types.SignTx(tx, types.HomesteadSigner{}, key.PrivateKey)
 }
 
+// SignTypedDataWithPassphrase signs the signing hash of the given typed data if
+// the private key matching the given address can be decrypted with the given
+// passphrase.
+func (ks *KeyStore) SignTypedDataWithPassphrase(a accounts.Account, passphrase string, typedData *accounts.TypedData) ([]byte, error) {
+	hash, err := typedData.SigningHash()
+	if err != nil {
+		return nil, err
+	}
+	return ks.SignHashWithPassphrase(a, passphrase, hash)
+}
+
 // Unlock unlocks the given account indefinitely.
 func (ks *KeyStore) Unlock(a accounts.Account, passphrase string) error {
 	return ks.TimedUnlock(a, passphrase, 0)
@@ -423,13 +450,28 @@ func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string)
 	if err != nil {
 		return nil, err
 	}
-	var N, P int
+	N, P := ks.scryptParams()
+	return EncryptKey(key, newPassphrase, N, P)
+}
+
+// scryptParams returns the scrypt parameters keys are encrypted with, falling
+// back to the standard ones for unencrypted keystores.
+func (ks *KeyStore) scryptParams() (int, int) {
 	if store, ok := ks.storage.(*keyStorePassphrase); ok {
-		N, P = store.scryptN, store.scryptP
-	} else {
-		N, P = StandardScryptN, StandardScryptP
+		return store.scryptN, store.scryptP
 	}
-	return EncryptKey(key, newPassphrase, N, P)
+	return StandardScryptN, StandardScryptP
+}
+
+// decryptWorkers returns the number of stored keys that can be decrypted (and
+// re-encrypted) concurrently. Keys may be encrypted with the standard scrypt
+// parameters regardless of the keystore's, so at least those are accounted for.
+func (ks *KeyStore) decryptWorkers() int {
+	N, _ := ks.scryptParams()
+	if N < StandardScryptN {
+		N = StandardScryptN
+	}
+	return scryptWorkers(N)
 }
 
 // Import stores the given encrypted JSON key into the key directory.
@@ -463,8 +505,87 @@ func (ks *KeyStore) importKey(key *Key, passphrase string) (accounts.Account, er
 	return a, nil
 }
 
+// ImportECDSABatch stores the given keys into the key directory, encrypting each
+// with the passphrase. The keys are encrypted concurrently, making this suitable
+// for importing large numbers of keys. Keys already present in the keystore or
+// duplicated within the batch (or concurrently being imported) are rejected before
+// any key is stored.
+//
+// If storing some of the keys fails, the ones successfully stored are kept and
+// returned along with the first error encountered.
+func (ks *KeyStore) ImportECDSABatch(privs []*ecdsa.PrivateKey, passphrase string) ([]accounts.Account, error) {
+	keys := make([]*Key, len(privs))
+	addrs := make([]common.Address, len(privs))
+	seen := make(map[common.Address]bool)
+	for i, priv := range privs {
+		keys[i] = newKeyFromECDSA(priv)
+		if seen[keys[i].Address] {
+			return nil, fmt.Errorf("account %x already exists", keys[i].Address)
+		}
+		seen[keys[i].Address] = true
+		addrs[i] = keys[i].Address
+	}
+	if err := ks.cache.reserve(addrs); err != nil {
+		return nil, err
+	}
+	defer ks.cache.release(addrs)
+
+	return ks.importKeys(keys, passphrase)
+}
+
+// importKeys concurrently encrypts and stores a batch of keys, adding all of the
+// ones successfully stored to the account cache.
+func (ks *KeyStore) importKeys(keys []*Key, passphrase string) ([]accounts.Account, error) {
+	accs := make([]accounts.Account, len(keys))
+	errs := make([]error, len(keys))
+
+	N, _ := ks.scryptParams()
+	parallelize(len(keys), scryptWorkers(N), func(i int) {
+		accs[i] = accounts.Account{Address: keys[i].Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: ks.storage.JoinPath(keyFileName(keys[i].Address))}}
+		errs[i] = ks.storage.StoreKey(accs[i].URL.Path, keys[i], passphrase)
+	})
+	// Add all the stored accounts to the cache and refresh the wallets only once
+	var (
+		stored = make([]accounts.Account, 0, len(accs))
+		err    error
+	)
+	for i, a := range accs {
+		if errs[i] != nil {
+			if err == nil {
+				err = errs[i]
+			}
+			continue
+		}
+		ks.cache.add(a)
+		stored = append(stored, a)
+	}
+	ks.refreshWallets()
+	return stored, err
+}
+
+// ExportBatch exports the given accounts as JSON keys encrypted with newPassphrase,
+// similarly to Export. The keys are decrypted and re-encrypted concurrently and
+// returned in the order of the accounts. Any failure aborts the whole export.
+func (ks *KeyStore) ExportBatch(accs []accounts.Account, passphrase, newPassphrase string) ([][]byte, error) {
+	keys := make([][]byte, len(accs))
+	errs := make([]error, len(accs))
+
+	parallelize(len(accs), ks.decryptWorkers(), func(i int) {
+		keys[i], errs[i] = ks.Export(accs[i], passphrase, newPassphrase)
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("account %x: %v", accs[i].Address, err)
+		}
+	}
+	return keys, nil
+}
+
 // Update changes the passphrase of an existing account.
 func (ks *KeyStore) Update(a accounts.Account, passphrase, newPassphrase string) error {
+	ks.fileMu.Lock()
+	defer ks.fileMu.Unlock()
+
 	a, key, err := ks.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return err
@@ -34,6 +34,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/common/math"
@@ -63,10 +66,50 @@ const (
 	// memory and taking approximately 100ms CPU time on a modern processor.
 	LightScryptP = 6
 
+	// BulkScryptN is the N parameter of Scrypt encryption algorithm meant for keys
+	// generated in bulk, using 32MB memory and taking approximately 125ms CPU time
+	// on a modern processor.
+	BulkScryptN = 1 << 15
+
+	// BulkScryptP is the P parameter of Scrypt encryption algorithm meant for keys
+	// generated in bulk, using 32MB memory and taking approximately 125ms CPU time
+	// on a modern processor.
+	BulkScryptP = 1
+
 	scryptR     = 8
 	scryptDKLen = 32
+
+	// scryptMemoryBudget is the maximum amount of memory the scrypt derivations of
+	// batch operations may use concurrently.
+	scryptMemoryBudget = 1 << 30
 )
 
+// ParseScryptTier converts a named scrypt work factor tier (standard, bulk or
+// light) or a custom N:P parameter pair into the scrypt N and P parameters.
+func ParseScryptTier(tier string) (int, int, error) {
+	switch tier {
+	case "standard":
+		return StandardScryptN, StandardScryptP, nil
+	case "bulk":
+		return BulkScryptN, BulkScryptP, nil
+	case "light":
+		return LightScryptN, LightScryptP, nil
+	}
+	parts := strings.Split(tier, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("unknown scrypt tier %q, want standard, bulk, light or N:P", tier)
+	}
+	n, err := strconv.Atoi(parts[0])
+	if err != nil || n <= 1 || n > 1<<30 || n&(n-1) != 0 {
+		return 0, 0, fmt.Errorf("invalid scrypt N parameter %q, must be a power of 2", parts[0])
+	}
+	p, err := strconv.Atoi(parts[1])
+	if err != nil || p < 1 || p > 1<<10 {
+		return 0, 0, fmt.Errorf("invalid scrypt P parameter %q", parts[1])
+	}
+	return n, p, nil
+}
+
 type keyStorePassphrase struct {
 	keysDirPath string
 	scryptN     int
@@ -304,3 +347,17 @@ func ensureInt(x interface{}) int {
 	}
 	return res
 }
+
+// scryptWorkers returns the number of scrypt key derivations with the given N
+// parameter that can run concurrently within scryptMemoryBudget, but at most one
+// per CPU core.
+func scryptWorkers(scryptN int) int {
+	workers := scryptMemoryBudget / (128 * scryptR * scryptN)
+	if cpus := runtime.NumCPU(); workers > cpus {
+		workers = cpus
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
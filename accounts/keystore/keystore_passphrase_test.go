@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	"crypto/rand"
 	"io/ioutil"
 	"testing"
 
@@ -58,3 +59,63 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that scrypt work factor tiers are parsed correctly.
+func TestParseScryptTier(t *testing.T) {
+	tests := []struct {
+		tier string
+		n, p int
+		fail bool
+	}{
+		{tier: "standard", n: StandardScryptN, p: StandardScryptP},
+		{tier: "bulk", n: BulkScryptN, p: BulkScryptP},
+		{tier: "light", n: LightScryptN, p: LightScryptP},
+		{tier: "1024:2", n: 1024, p: 2},
+		{tier: "", fail: true},
+		{tier: "heavy", fail: true},
+		{tier: "1000:1", fail: true},
+		{tier: "1:1", fail: true},
+		{tier: "1024:0", fail: true},
+		{tier: "1024", fail: true},
+		{tier: "1024:1:1", fail: true},
+	}
+	for i, test := range tests {
+		n, p, err := ParseScryptTier(test.tier)
+		if test.fail {
+			if err == nil {
+				t.Errorf("test %d: invalid tier %q accepted", i, test.tier)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to parse tier %q: %v", i, test.tier, err)
+			continue
+		}
+		if n != test.n || p != test.p {
+			t.Errorf("test %d: parameter mismatch: have %d:%d, want %d:%d", i, n, p, test.n, test.p)
+		}
+	}
+}
+
+func BenchmarkDecryptKeyLight(b *testing.B)    { benchmarkDecryptKey(b, LightScryptN, LightScryptP) }
+func BenchmarkDecryptKeyBulk(b *testing.B)     { benchmarkDecryptKey(b, BulkScryptN, BulkScryptP) }
+func BenchmarkDecryptKeyStandard(b *testing.B) { benchmarkDecryptKey(b, StandardScryptN, StandardScryptP) }
+
+func benchmarkDecryptKey(b *testing.B, scryptN, scryptP int) {
+	key, err := newKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	keyjson, err := EncryptKey(key, "foo", scryptN, scryptP)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := DecryptKey(keyjson, "foo"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	"crypto/ecdsa"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/accounts"
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/event"
 )
 
@@ -113,6 +115,142 @@ func TestSignWithPassphrase(t *testing.T) {
 	}
 }
 
+func TestSignTypedData(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	pass := "passwd"
+	acc, err := ks.NewAccount(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typedData := &accounts.TypedData{
+		Types: accounts.TypedDataTypes{
+			"EIP712Domain": {{Name: "name", Type: "string"}},
+			"Order":        {{Name: "amount", Type: "uint256"}},
+		},
+		PrimaryType: "Order",
+		Domain:      accounts.TypedDataDomain{Name: "Orderbook"},
+		Message:     map[string]interface{}{"amount": "1000"},
+	}
+	hash, err := typedData.SigningHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signing must require the account to be unlocked or the passphrase given
+	if _, err := ks.SignTypedData(acc, typedData); err != ErrLocked {
+		t.Fatalf("locked signing: error mismatch: have %v, want %v", err, ErrLocked)
+	}
+	signature, err := ks.SignTypedDataWithPassphrase(acc, pass, typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := crypto.SigToPub(hash, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != acc.Address {
+		t.Fatalf("signer mismatch: have %x, want %x", signer, acc.Address)
+	}
+	// Malformed typed data must be rejected before touching the keys
+	typedData.PrimaryType = "Unknown"
+	if _, err := ks.SignTypedDataWithPassphrase(acc, pass, typedData); err == nil {
+		t.Fatal("expected signing of malformed typed data to fail")
+	}
+}
+
+func TestImportExportBatch(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	privs := make([]*ecdsa.PrivateKey, 16)
+	for i := range privs {
+		privs[i], _ = crypto.GenerateKey()
+	}
+	accs, err := ks.ImportECDSABatch(privs, "foo")
+	if err != nil {
+		t.Fatalf("failed to import keys: %v", err)
+	}
+	if len(accs) != len(privs) {
+		t.Fatalf("imported account count mismatch: have %d, want %d", len(accs), len(privs))
+	}
+	for i, acc := range accs {
+		if acc.Address != crypto.PubkeyToAddress(privs[i].PublicKey) {
+			t.Errorf("account %d: address mismatch: have %x, want %x", i, acc.Address, crypto.PubkeyToAddress(privs[i].PublicKey))
+		}
+		if !ks.HasAddress(acc.Address) {
+			t.Errorf("account %d: not found in keystore", i)
+		}
+	}
+	// Importing duplicates must be rejected without storing anything
+	fresh, _ := crypto.GenerateKey()
+	if _, err := ks.ImportECDSABatch([]*ecdsa.PrivateKey{fresh, privs[0]}, "foo"); err == nil {
+		t.Errorf("existing key imported")
+	}
+	if _, err := ks.ImportECDSABatch([]*ecdsa.PrivateKey{fresh, fresh}, "foo"); err == nil {
+		t.Errorf("duplicate key imported")
+	}
+	if ks.HasAddress(crypto.PubkeyToAddress(fresh.PublicKey)) {
+		t.Errorf("key stored from rejected batch")
+	}
+	// Export the keys with a new passphrase and ensure they decrypt
+	blobs, err := ks.ExportBatch(accs, "foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to export keys: %v", err)
+	}
+	for i, blob := range blobs {
+		key, err := DecryptKey(blob, "bar")
+		if err != nil {
+			t.Fatalf("key %d: failed to decrypt: %v", i, err)
+		}
+		if key.Address != accs[i].Address {
+			t.Errorf("key %d: address mismatch: have %x, want %x", i, key.Address, accs[i].Address)
+		}
+	}
+	if _, err := ks.ExportBatch(accs, "bad", "bar"); err == nil {
+		t.Errorf("keys exported with invalid passphrase")
+	}
+}
+
+// Tests that concurrently importing the same keys stores them only once.
+func TestImportBatchConcurrent(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	privs := make([]*ecdsa.PrivateKey, 4)
+	for i := range privs {
+		privs[i], _ = crypto.GenerateKey()
+	}
+	errs := make(chan error)
+	for i := 0; i < 4; i++ {
+		go func() {
+			_, err := ks.ImportECDSABatch(privs, "foo")
+			errs <- err
+		}()
+	}
+	imported := 0
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err == nil {
+			imported++
+		}
+	}
+	if imported != 1 {
+		t.Fatalf("batch imported %d times, want once", imported)
+	}
+	if accs := ks.Accounts(); len(accs) != len(privs) {
+		t.Fatalf("account count mismatch: have %d, want %d", len(accs), len(privs))
+	}
+}
+
+func TestScryptWorkers(t *testing.T) {
+	if workers := scryptWorkers(StandardScryptN); workers > 4 || workers > runtime.NumCPU() {
+		t.Errorf("standard tier: too many workers %d", workers)
+	}
+	if workers := scryptWorkers(1 << 30); workers != 1 {
+		t.Errorf("huge N: have %d workers, want 1", workers)
+	}
+}
+
 func TestTimedUnlock(t *testing.T) {
 	dir, ks := tmpKeyStore(t, true)
 	defer os.RemoveAll(dir)
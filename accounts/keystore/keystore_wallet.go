@@ -110,6 +110,21 @@ func (w *keystoreWallet) SignTx(account accounts.Account, tx *types.Transaction,
 	return w.keystore.SignTx(account, tx, chainID)
 }
 
+// SignTypedData implements accounts.Wallet, attempting to sign the given typed
+// data with the given account. If the wallet does not wrap this particular
+// account, an error is returned to avoid account leakage.
+func (w *keystoreWallet) SignTypedData(account accounts.Account, typedData *accounts.TypedData) ([]byte, error) {
+	// Make sure the requested account is contained within
+	if account.Address != w.account.Address {
+		return nil, accounts.ErrUnknownAccount
+	}
+	if account.URL != (accounts.URL{}) && account.URL != w.account.URL {
+		return nil, accounts.ErrUnknownAccount
+	}
+	// Account seems valid, request the keystore to sign
+	return w.keystore.SignTypedData(account, typedData)
+}
+
 // SignHashWithPassphrase implements accounts.Wallet, attempting to sign the
 // given hash with the given account using passphrase as extra authentication.
 func (w *keystoreWallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
@@ -137,3 +152,17 @@ func (w *keystoreWallet) SignTxWithPassphrase(account accounts.Account, passphra
 	// Account seems valid, request the keystore to sign
 	return w.keystore.SignTxWithPassphrase(account, passphrase, tx, chainID)
 }
+
+// SignTypedDataWithPassphrase implements accounts.Wallet, attempting to sign the
+// given typed data with the given account using passphrase as extra authentication.
+func (w *keystoreWallet) SignTypedDataWithPassphrase(account accounts.Account, passphrase string, typedData *accounts.TypedData) ([]byte, error) {
+	// Make sure the requested account is contained within
+	if account.Address != w.account.Address {
+		return nil, accounts.ErrUnknownAccount
+	}
+	if account.URL != (accounts.URL{}) && account.URL != w.account.URL {
+		return nil, accounts.ErrUnknownAccount
+	}
+	// Account seems valid, request the keystore to sign
+	return w.keystore.SignTypedDataWithPassphrase(account, passphrase, typedData)
+}
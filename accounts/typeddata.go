@@ -0,0 +1,460 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	cmath "github.com/bazacoin/go-bazacoin/common/math"
+	"github.com/bazacoin/go-bazacoin/crypto"
+)
+
+// typedDataDomainType is the name of the type describing the signing domain,
+// which every typed data bundle must define.
+const typedDataDomainType = "EIP712Domain"
+
+// typedDataMaxDepth is the maximum nesting depth of structs and arrays accepted
+// when encoding a message, protecting against recursive type definitions.
+const typedDataMaxDepth = 64
+
+// TypedData is a bundle of EIP-712 style typed structured data: a set of struct
+// type definitions, the domain the signature is valid in and the message to be
+// signed, described by the primary type.
+//
+// The spec is available at https://github.com/ethereum/EIPs/blob/master/EIPS/eip-712.md
+type TypedData struct {
+	Types       TypedDataTypes         `json:"types"`
+	PrimaryType string                 `json:"primaryType"`
+	Domain      TypedDataDomain        `json:"domain"`
+	Message     map[string]interface{} `json:"message"`
+}
+
+// TypedDataTypes maps struct type names to their ordered list of members.
+type TypedDataTypes map[string][]TypedDataField
+
+// TypedDataField is a single named and typed member of a struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataDomain contains the fields of the signing domain. Only the fields set
+// are taken into account, and they must match the EIP712Domain type definition.
+type TypedDataDomain struct {
+	Name              string          `json:"name,omitempty"`
+	Version           string          `json:"version,omitempty"`
+	ChainId           *big.Int        `json:"chainId,omitempty"`
+	VerifyingContract *common.Address `json:"verifyingContract,omitempty"`
+	Salt              *common.Hash    `json:"salt,omitempty"`
+}
+
+// Map converts the set fields of the domain into a generic message, which can
+// be encoded as an EIP712Domain struct.
+func (domain *TypedDataDomain) Map() map[string]interface{} {
+	data := make(map[string]interface{})
+	if domain.Name != "" {
+		data["name"] = domain.Name
+	}
+	if domain.Version != "" {
+		data["version"] = domain.Version
+	}
+	if domain.ChainId != nil {
+		data["chainId"] = domain.ChainId.String()
+	}
+	if domain.VerifyingContract != nil {
+		data["verifyingContract"] = domain.VerifyingContract.Hex()
+	}
+	if domain.Salt != nil {
+		data["salt"] = domain.Salt.Hex()
+	}
+	return data
+}
+
+// Validate checks that all the type definitions are well formed, referencing
+// only atomic types or other defined structs, and that both the domain and the
+// primary types are defined.
+func (typedData *TypedData) Validate() error {
+	if _, ok := typedData.Types[typedDataDomainType]; !ok {
+		return fmt.Errorf("typed data lacks %s type", typedDataDomainType)
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return fmt.Errorf("primary type %q undefined", typedData.PrimaryType)
+	}
+	for name, fields := range typedData.Types {
+		if name == "" || strings.ContainsAny(name, "()[], ") {
+			return fmt.Errorf("invalid type name %q", name)
+		}
+		if isAtomicTypedDataType(name) {
+			return fmt.Errorf("type %q shadows an atomic type", name)
+		}
+		seen := make(map[string]bool)
+		for _, field := range fields {
+			if field.Name == "" || strings.ContainsAny(field.Name, "()[], ") {
+				return fmt.Errorf("type %s: invalid field name %q", name, field.Name)
+			}
+			if seen[field.Name] {
+				return fmt.Errorf("type %s: duplicate field %q", name, field.Name)
+			}
+			seen[field.Name] = true
+
+			base, err := typedDataBaseType(field.Type)
+			if err != nil {
+				return fmt.Errorf("type %s, field %s: %v", name, field.Name, err)
+			}
+			if _, ok := typedData.Types[base]; !ok && !isAtomicTypedDataType(base) {
+				return fmt.Errorf("type %s, field %s: unknown type %q", name, field.Name, field.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// Dependencies returns all the struct types the given type references, directly
+// or indirectly, including the type itself. The result is in no particular order.
+func (typedData *TypedData) Dependencies(primaryType string, found []string) []string {
+	for _, dep := range found {
+		if dep == primaryType {
+			return found
+		}
+	}
+	if _, ok := typedData.Types[primaryType]; !ok {
+		return found
+	}
+	found = append(found, primaryType)
+	for _, field := range typedData.Types[primaryType] {
+		base, err := typedDataBaseType(field.Type)
+		if err != nil {
+			continue
+		}
+		found = typedData.Dependencies(base, found)
+	}
+	return found
+}
+
+// EncodeType generates the canonical type signature of a struct: the primary
+// type followed by all the referenced struct types sorted by name, e.g.
+//
+//   Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (typedData *TypedData) EncodeType(primaryType string) string {
+	deps := typedData.Dependencies(primaryType, nil)
+	if len(deps) > 0 {
+		sort.Strings(deps[1:])
+	}
+	var buffer bytes.Buffer
+	for _, dep := range deps {
+		buffer.WriteString(dep)
+		buffer.WriteString("(")
+		for i, field := range typedData.Types[dep] {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString(field.Type)
+			buffer.WriteString(" ")
+			buffer.WriteString(field.Name)
+		}
+		buffer.WriteString(")")
+	}
+	return buffer.String()
+}
+
+// TypeHash returns the hash of the canonical type signature of a struct.
+func (typedData *TypedData) TypeHash(primaryType string) common.Hash {
+	return crypto.Keccak256Hash([]byte(typedData.EncodeType(primaryType)))
+}
+
+// EncodeData generates the encoding of a struct instance: the type hash followed
+// by the 32 byte encoding of every member, in the order of the type definition.
+// All the members need to be present in the data and no other fields are allowed.
+func (typedData *TypedData) EncodeData(primaryType string, data map[string]interface{}) ([]byte, error) {
+	return typedData.encodeData(primaryType, data, 0)
+}
+
+// HashStruct returns the hash of the encoding of a struct instance.
+func (typedData *TypedData) HashStruct(primaryType string, data map[string]interface{}) (common.Hash, error) {
+	encoded, err := typedData.EncodeData(primaryType, data)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// Hashes validates the typed data and returns the two hashes a signature is made
+// over: the domain separator and the hash of the message.
+func (typedData *TypedData) Hashes() (domainSeparator common.Hash, messageHash common.Hash, err error) {
+	if err := typedData.Validate(); err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	if domainSeparator, err = typedData.HashStruct(typedDataDomainType, typedData.Domain.Map()); err != nil {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("domain: %v", err)
+	}
+	if messageHash, err = typedData.HashStruct(typedData.PrimaryType, typedData.Message); err != nil {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("message: %v", err)
+	}
+	return domainSeparator, messageHash, nil
+}
+
+// SigningHash returns the hash to be signed for the typed data, calculated as
+//
+//   keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func (typedData *TypedData) SigningHash() ([]byte, error) {
+	domainSeparator, messageHash, err := typedData.Hashes()
+	if err != nil {
+		return nil, err
+	}
+	return TypedDataSigningHash(domainSeparator, messageHash), nil
+}
+
+// TypedDataSigningHash combines a domain separator and a message hash into the
+// final hash to be signed.
+func TypedDataSigningHash(domainSeparator, messageHash common.Hash) []byte {
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator[:], messageHash[:])
+}
+
+// encodeData is the depth tracking version of EncodeData.
+func (typedData *TypedData) encodeData(primaryType string, data map[string]interface{}, depth int) ([]byte, error) {
+	if depth > typedDataMaxDepth {
+		return nil, errors.New("data nested too deep")
+	}
+	fields, ok := typedData.Types[primaryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", primaryType)
+	}
+	if len(data) != len(fields) {
+		for name := range data {
+			known := false
+			for _, field := range fields {
+				known = known || field.Name == name
+			}
+			if !known {
+				return nil, fmt.Errorf("%s: unknown field %q", primaryType, name)
+			}
+		}
+	}
+	typeHash := typedData.TypeHash(primaryType)
+
+	encoded := make([]byte, 0, 32*(len(fields)+1))
+	encoded = append(encoded, typeHash[:]...)
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s: missing field %q", primaryType, field.Name)
+		}
+		enc, err := typedData.encodeValue(field.Type, value, depth)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", primaryType, field.Name, err)
+		}
+		encoded = append(encoded, enc...)
+	}
+	return encoded, nil
+}
+
+// encodeValue encodes a single member of a struct into 32 bytes. Dynamic types
+// (strings, byte slices, arrays and structs) are encoded as the hash of their
+// contents, atomic types are padded to 32 bytes.
+func (typedData *TypedData) encodeValue(typ string, value interface{}, depth int) ([]byte, error) {
+	// Arrays are encoded as the hash of their concatenated member encodings
+	if strings.HasSuffix(typ, "]") {
+		start := strings.LastIndex(typ, "[")
+		if start < 0 {
+			return nil, fmt.Errorf("invalid type %q", typ)
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %T", value)
+		}
+		if size := typ[start+1 : len(typ)-1]; size != "" {
+			if n, err := strconv.Atoi(size); err != nil || n != len(items) {
+				return nil, fmt.Errorf("expected %s items, got %d", size, len(items))
+			}
+		}
+		encoded := make([]byte, 0, 32*len(items))
+		for i, item := range items {
+			enc, err := typedData.encodeValue(typ[:start], item, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %v", i, err)
+			}
+			encoded = append(encoded, enc...)
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+	// Structs are encoded as their hash
+	if _, ok := typedData.Types[typ]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected struct, got %T", value)
+		}
+		encoded, err := typedData.encodeData(typ, data, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+	// Atomic or dynamic byte types
+	if !isAtomicTypedDataType(typ) {
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+	switch {
+	case typ == "string":
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", value)
+		}
+		return crypto.Keccak256([]byte(str)), nil
+
+	case typ == "bytes":
+		blob, err := parseTypedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(blob), nil
+
+	case typ == "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(str).Bytes(), 32), nil
+
+	case typ == "bool":
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool, got %T", value)
+		}
+		if flag {
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		}
+		return make([]byte, 32), nil
+
+	case strings.HasPrefix(typ, "bytes"):
+		size, _ := strconv.Atoi(typ[len("bytes"):])
+		blob, err := parseTypedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(blob) != size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", size, len(blob))
+		}
+		return common.RightPadBytes(blob, 32), nil
+
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		signed := strings.HasPrefix(typ, "int")
+		bits, _ := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"))
+
+		number, err := parseTypedDataInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		// Ensure the number fits into the requested type and encode in two's complement
+		min, max := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(bits))
+		if signed {
+			max.Rsh(max, 1)
+			min.Neg(max)
+		}
+		if number.Cmp(min) < 0 || number.Cmp(max) >= 0 {
+			return nil, fmt.Errorf("%v overflows %s", number, typ)
+		}
+		return cmath.PaddedBigBytes(cmath.U256(number), 32), nil
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+// typedDataBaseType strips all array suffixes from a member type, validating the
+// array sizes along the way.
+func typedDataBaseType(typ string) (string, error) {
+	for strings.HasSuffix(typ, "]") {
+		start := strings.LastIndex(typ, "[")
+		if start <= 0 {
+			return "", fmt.Errorf("invalid type %q", typ)
+		}
+		if size := typ[start+1 : len(typ)-1]; size != "" {
+			if n, err := strconv.Atoi(size); err != nil || n <= 0 || size[0] == '0' {
+				return "", fmt.Errorf("invalid array size in %q", typ)
+			}
+		}
+		typ = typ[:start]
+	}
+	return typ, nil
+}
+
+// isAtomicTypedDataType reports whether a type is one of the atomic or dynamic
+// types defined by the spec (i.e. not a struct or array).
+func isAtomicTypedDataType(typ string) bool {
+	switch typ {
+	case "address", "bool", "string", "bytes":
+		return true
+	}
+	var (
+		size int
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(typ, "bytes"):
+		if size, err = strconv.Atoi(typ[len("bytes"):]); err != nil || typ[len("bytes")] == '0' {
+			return false
+		}
+		return size >= 1 && size <= 32
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		bits := strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int")
+		if size, err = strconv.Atoi(bits); err != nil || bits[0] == '0' {
+			return false
+		}
+		return size >= 8 && size <= 256 && size%8 == 0
+	}
+	return false
+}
+
+// parseTypedDataBytes converts a hex encoded message member into a byte slice.
+func parseTypedDataBytes(value interface{}) ([]byte, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected hex string, got %T", value)
+	}
+	return hexutil.Decode(str)
+}
+
+// parseTypedDataInteger converts a message member into a big integer. Numbers
+// are accepted both as decimal or hex strings and as JSON numbers, the latter
+// only if they can be represented exactly.
+func parseTypedDataInteger(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case string:
+		negative := strings.HasPrefix(v, "-")
+		number, ok := cmath.ParseBig256(strings.TrimPrefix(v, "-"))
+		if !ok || strings.TrimPrefix(v, "-") == "" {
+			return nil, fmt.Errorf("invalid integer %q", v)
+		}
+		if negative {
+			number.Neg(number)
+		}
+		return number, nil
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return nil, fmt.Errorf("inexact integer %v, use a string instead", v)
+		}
+		return big.NewInt(int64(v)), nil
+	}
+	return nil, fmt.Errorf("expected integer, got %T", value)
+}
@@ -0,0 +1,192 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/crypto"
+)
+
+// mailTypedData is the example message from the EIP-712 specification.
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func parseTypedData(t *testing.T, blob string) *TypedData {
+	typedData := new(TypedData)
+	if err := json.Unmarshal([]byte(blob), typedData); err != nil {
+		t.Fatalf("failed to parse typed data: %v", err)
+	}
+	return typedData
+}
+
+// Tests that the EIP-712 example message is encoded and hashed as specified.
+func TestTypedDataHashing(t *testing.T) {
+	typedData := parseTypedData(t, mailTypedData)
+
+	if have, want := typedData.EncodeType("Mail"), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; have != want {
+		t.Errorf("type encoding mismatch: have %s, want %s", have, want)
+	}
+	if have, want := typedData.TypeHash("Mail"), common.HexToHash("0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"); have != want {
+		t.Errorf("type hash mismatch: have %x, want %x", have, want)
+	}
+	domain, message, err := typedData.Hashes()
+	if err != nil {
+		t.Fatalf("failed to hash typed data: %v", err)
+	}
+	if want := common.HexToHash("0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"); domain != want {
+		t.Errorf("domain separator mismatch: have %x, want %x", domain, want)
+	}
+	if want := common.HexToHash("0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"); message != want {
+		t.Errorf("message hash mismatch: have %x, want %x", message, want)
+	}
+	hash, err := typedData.SigningHash()
+	if err != nil {
+		t.Fatalf("failed to calculate signing hash: %v", err)
+	}
+	if want := common.FromHex("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"); !bytes.Equal(hash, want) {
+		t.Fatalf("signing hash mismatch: have %x, want %x", hash, want)
+	}
+	// Sign with the example key and ensure the signature matches the spec
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	signature, err := crypto.Sign(hash, key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	want := common.FromHex("0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b9156201")
+	if !bytes.Equal(signature, want) {
+		t.Errorf("signature mismatch: have %x, want %x", signature, want)
+	}
+}
+
+// Tests that atomic members and arrays are encoded correctly.
+func TestTypedDataEncoding(t *testing.T) {
+	typedData := &TypedData{
+		Types: TypedDataTypes{
+			"Order": {
+				{Name: "amounts", Type: "uint8[2]"},
+				{Name: "delta", Type: "int16"},
+				{Name: "tag", Type: "bytes2"},
+				{Name: "flag", Type: "bool"},
+				{Name: "payload", Type: "bytes"},
+			},
+		},
+	}
+	data := map[string]interface{}{
+		"amounts": []interface{}{float64(1), "0x02"},
+		"delta":   "-1",
+		"tag":     "0xbeef",
+		"flag":    true,
+		"payload": "0x",
+	}
+	encoded, err := typedData.EncodeData("Order", data)
+	if err != nil {
+		t.Fatalf("failed to encode data: %v", err)
+	}
+	typeHash := crypto.Keccak256([]byte("Order(uint8[2] amounts,int16 delta,bytes2 tag,bool flag,bytes payload)"))
+	want := append(typeHash, crypto.Keccak256(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{2}, 32))...)
+	want = append(want, bytes.Repeat([]byte{0xff}, 32)...)
+	want = append(want, common.RightPadBytes([]byte{0xbe, 0xef}, 32)...)
+	want = append(want, common.LeftPadBytes([]byte{1}, 32)...)
+	want = append(want, crypto.Keccak256(nil)...)
+	if !bytes.Equal(encoded, want) {
+		t.Fatalf("encoding mismatch:\nhave %x\nwant %x", encoded, want)
+	}
+	// Malformed values must be rejected
+	tests := []struct {
+		field string
+		value interface{}
+	}{
+		{"amounts", []interface{}{float64(1)}},        // wrong array length
+		{"amounts", []interface{}{float64(1), "256"}}, // uint8 overflow
+		{"delta", "32768"},                            // int16 overflow
+		{"delta", 1.5},                                // fractional number
+		{"tag", "0xbe"},                               // wrong fixed size
+		{"flag", "true"},                              // wrong type
+		{"payload", "beef"},                           // missing hex prefix
+	}
+	for i, test := range tests {
+		bad := make(map[string]interface{})
+		for k, v := range data {
+			bad[k] = v
+		}
+		bad[test.field] = test.value
+		if _, err := typedData.EncodeData("Order", bad); err == nil {
+			t.Errorf("test %d: invalid %s value %v accepted", i, test.field, test.value)
+		}
+	}
+	// Missing and unknown fields must be rejected
+	delete(data, "flag")
+	if _, err := typedData.EncodeData("Order", data); err == nil {
+		t.Errorf("missing field accepted")
+	}
+	data["flags"] = true
+	if _, err := typedData.EncodeData("Order", data); err == nil {
+		t.Errorf("unknown field accepted")
+	}
+}
+
+// Tests that malformed type definitions are rejected.
+func TestTypedDataValidation(t *testing.T) {
+	tests := []string{
+		`{"types": {"Mail": []}, "primaryType": "Mail"}`,
+		`{"types": {"EIP712Domain": []}, "primaryType": "Mail"}`,
+		`{"types": {"EIP712Domain": [], "Mail": [{"name": "to", "type": "Person"}]}, "primaryType": "Mail"}`,
+		`{"types": {"EIP712Domain": [], "Mail": [{"name": "to", "type": "uint7"}]}, "primaryType": "Mail"}`,
+		`{"types": {"EIP712Domain": [], "Mail": [{"name": "to", "type": "bytes33"}]}, "primaryType": "Mail"}`,
+		`{"types": {"EIP712Domain": [], "Mail": [{"name": "to", "type": "address[0]"}]}, "primaryType": "Mail"}`,
+		`{"types": {"EIP712Domain": [], "Mail": [{"name": "to", "type": "address"}, {"name": "to", "type": "address"}]}, "primaryType": "Mail"}`,
+		`{"types": {"EIP712Domain": [], "uint256": []}, "primaryType": "uint256"}`,
+	}
+	for i, test := range tests {
+		if err := parseTypedData(t, test).Validate(); err == nil {
+			t.Errorf("test %d: invalid typed data accepted: %s", i, test)
+		}
+	}
+}
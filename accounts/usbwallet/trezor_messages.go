@@ -0,0 +1,544 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// This file contains the subset of the Trezor protocol buffer messages needed to
+// derive addresses and sign transactions, along with a minimal protocol buffer
+// codec for them. The message definitions can be found in the trezor-common repo:
+// https://github.com/trezor/trezor-common/blob/master/protob/messages.proto
+
+package usbwallet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// trezorMessageType is the numeric identifier of a Trezor message on the wire.
+type trezorMessageType uint16
+
+const (
+	trezorInitialize        trezorMessageType = 0
+	trezorPing              trezorMessageType = 1
+	trezorSuccess           trezorMessageType = 2
+	trezorFailure           trezorMessageType = 3
+	trezorFeatures          trezorMessageType = 17
+	trezorPinMatrixRequest  trezorMessageType = 18
+	trezorPinMatrixAck      trezorMessageType = 19
+	trezorButtonRequest     trezorMessageType = 26
+	trezorButtonAck         trezorMessageType = 27
+	trezorPassphraseRequest trezorMessageType = 41
+	trezorPassphraseAck     trezorMessageType = 42
+	trezorEthGetAddress     trezorMessageType = 56
+	trezorEthAddress        trezorMessageType = 57
+	trezorEthSignTx         trezorMessageType = 58
+	trezorEthTxRequest      trezorMessageType = 59
+	trezorEthTxAck          trezorMessageType = 60
+)
+
+// trezorMessageNames maps the message types to their human readable names.
+var trezorMessageNames = map[trezorMessageType]string{
+	trezorInitialize:        "Initialize",
+	trezorPing:              "Ping",
+	trezorSuccess:           "Success",
+	trezorFailure:           "Failure",
+	trezorFeatures:          "Features",
+	trezorPinMatrixRequest:  "PinMatrixRequest",
+	trezorPinMatrixAck:      "PinMatrixAck",
+	trezorButtonRequest:     "ButtonRequest",
+	trezorButtonAck:         "ButtonAck",
+	trezorPassphraseRequest: "PassphraseRequest",
+	trezorPassphraseAck:     "PassphraseAck",
+	trezorEthGetAddress:     "EthereumGetAddress",
+	trezorEthAddress:        "EthereumAddress",
+	trezorEthSignTx:         "EthereumSignTx",
+	trezorEthTxRequest:      "EthereumTxRequest",
+	trezorEthTxAck:          "EthereumTxAck",
+}
+
+// String implements fmt.Stringer.
+func (t trezorMessageType) String() string {
+	if name, ok := trezorMessageNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("MessageType(%d)", uint16(t))
+}
+
+// trezorMessage is a protocol buffer message exchanged with a Trezor device.
+type trezorMessage interface {
+	kind() trezorMessageType
+	marshal() []byte
+	unmarshal(blob []byte) error
+}
+
+// Protocol buffer wire types used by the Trezor messages.
+const (
+	protoVarint = 0
+	protoBytes  = 2
+)
+
+// errProtoTruncated is returned if a protocol buffer message ends prematurely.
+var errProtoTruncated = errors.New("protobuf: truncated message")
+
+// protoEncoder is a minimal protocol buffer encoder.
+type protoEncoder []byte
+
+func (e *protoEncoder) key(field int, wire int) {
+	*e = appendUvarint(*e, uint64(field)<<3|uint64(wire))
+}
+
+func (e *protoEncoder) putUint(field int, value uint64) {
+	e.key(field, protoVarint)
+	*e = appendUvarint(*e, value)
+}
+
+func (e *protoEncoder) putBool(field int, value bool) {
+	if value {
+		e.putUint(field, 1)
+	} else {
+		e.putUint(field, 0)
+	}
+}
+
+func (e *protoEncoder) putBytes(field int, value []byte) {
+	e.key(field, protoBytes)
+	*e = appendUvarint(*e, uint64(len(value)))
+	*e = append(*e, value...)
+}
+
+// appendUvarint appends the varint encoding of x to buf.
+func appendUvarint(buf []byte, x uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], x)]...)
+}
+
+// protoDecode iterates over the fields of a protocol buffer message, calling fn
+// with the field number and either its varint value or its length delimited
+// content. Fields of other wire types are rejected.
+func protoDecode(blob []byte, fn func(field int, value uint64, data []byte) error) error {
+	for len(blob) > 0 {
+		key, n := binary.Uvarint(blob)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		blob = blob[n:]
+
+		var (
+			value uint64
+			data  []byte
+		)
+		switch wire := key & 0x07; wire {
+		case protoVarint:
+			if value, n = binary.Uvarint(blob); n <= 0 {
+				return errProtoTruncated
+			}
+			blob = blob[n:]
+		case protoBytes:
+			size, n := binary.Uvarint(blob)
+			if n <= 0 || uint64(len(blob)-n) < size {
+				return errProtoTruncated
+			}
+			data, blob = blob[n:n+int(size)], blob[n+int(size):]
+		default:
+			return fmt.Errorf("protobuf: unsupported wire type %d", wire)
+		}
+		if err := fn(int(key>>3), value, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trezorEmpty is a message without any fields, parameterized by its type.
+type trezorEmpty trezorMessageType
+
+func (m trezorEmpty) kind() trezorMessageType { return trezorMessageType(m) }
+func (m trezorEmpty) marshal() []byte         { return nil }
+func (m trezorEmpty) unmarshal([]byte) error  { return nil }
+
+// Messages without any fields sent to the device.
+var (
+	trezorInitializeMsg = trezorEmpty(trezorInitialize)
+	trezorButtonAckMsg  = trezorEmpty(trezorButtonAck)
+)
+
+// trezorPingMsg tests the device, optionally requiring the user to unlock it.
+type trezorPingMsg struct {
+	PinProtection        bool
+	PassphraseProtection bool
+}
+
+func (m *trezorPingMsg) kind() trezorMessageType { return trezorPing }
+
+func (m *trezorPingMsg) marshal() []byte {
+	var e protoEncoder
+	e.putBool(3, m.PinProtection)
+	e.putBool(4, m.PassphraseProtection)
+	return e
+}
+
+func (m *trezorPingMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 3:
+			m.PinProtection = value != 0
+		case 4:
+			m.PassphraseProtection = value != 0
+		}
+		return nil
+	})
+}
+
+// trezorSuccessMsg is the device's reply to a successful request.
+type trezorSuccessMsg struct {
+	Message string
+}
+
+func (m *trezorSuccessMsg) kind() trezorMessageType { return trezorSuccess }
+
+func (m *trezorSuccessMsg) marshal() []byte {
+	var e protoEncoder
+	e.putBytes(1, []byte(m.Message))
+	return e
+}
+
+func (m *trezorSuccessMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		if field == 1 {
+			m.Message = string(data)
+		}
+		return nil
+	})
+}
+
+// trezorFailureMsg is the device's reply to a failed request.
+type trezorFailureMsg struct {
+	Code    uint64
+	Message string
+}
+
+func (m *trezorFailureMsg) kind() trezorMessageType { return trezorFailure }
+
+func (m *trezorFailureMsg) marshal() []byte {
+	var e protoEncoder
+	e.putUint(1, m.Code)
+	e.putBytes(2, []byte(m.Message))
+	return e
+}
+
+func (m *trezorFailureMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 1:
+			m.Code = value
+		case 2:
+			m.Message = string(data)
+		}
+		return nil
+	})
+}
+
+// trezorFeaturesMsg describes the device in reply to an initialization.
+type trezorFeaturesMsg struct {
+	Vendor string
+	Major  uint32
+	Minor  uint32
+	Patch  uint32
+	Label  string
+}
+
+func (m *trezorFeaturesMsg) kind() trezorMessageType { return trezorFeatures }
+
+func (m *trezorFeaturesMsg) marshal() []byte {
+	var e protoEncoder
+	e.putBytes(1, []byte(m.Vendor))
+	e.putUint(2, uint64(m.Major))
+	e.putUint(3, uint64(m.Minor))
+	e.putUint(4, uint64(m.Patch))
+	e.putBytes(10, []byte(m.Label))
+	return e
+}
+
+func (m *trezorFeaturesMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 1:
+			m.Vendor = string(data)
+		case 2:
+			m.Major = uint32(value)
+		case 3:
+			m.Minor = uint32(value)
+		case 4:
+			m.Patch = uint32(value)
+		case 10:
+			m.Label = string(data)
+		}
+		return nil
+	})
+}
+
+// trezorPinMatrixRequestMsg asks for the PIN to be entered on the scrambled
+// matrix shown on the device.
+type trezorPinMatrixRequestMsg struct{}
+
+func (m *trezorPinMatrixRequestMsg) kind() trezorMessageType { return trezorPinMatrixRequest }
+func (m *trezorPinMatrixRequestMsg) marshal() []byte         { return nil }
+func (m *trezorPinMatrixRequestMsg) unmarshal([]byte) error  { return nil }
+
+// trezorPinMatrixAckMsg sends the PIN positions entered by the user.
+type trezorPinMatrixAckMsg struct {
+	Pin string
+}
+
+func (m *trezorPinMatrixAckMsg) kind() trezorMessageType { return trezorPinMatrixAck }
+
+func (m *trezorPinMatrixAckMsg) marshal() []byte {
+	var e protoEncoder
+	e.putBytes(1, []byte(m.Pin))
+	return e
+}
+
+func (m *trezorPinMatrixAckMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		if field == 1 {
+			m.Pin = string(data)
+		}
+		return nil
+	})
+}
+
+// trezorButtonRequestMsg signals that the device waits for a user confirmation.
+type trezorButtonRequestMsg struct{}
+
+func (m *trezorButtonRequestMsg) kind() trezorMessageType { return trezorButtonRequest }
+func (m *trezorButtonRequestMsg) marshal() []byte         { return nil }
+func (m *trezorButtonRequestMsg) unmarshal([]byte) error  { return nil }
+
+// trezorPassphraseRequestMsg asks for the passphrase protecting the wallet.
+type trezorPassphraseRequestMsg struct{}
+
+func (m *trezorPassphraseRequestMsg) kind() trezorMessageType { return trezorPassphraseRequest }
+func (m *trezorPassphraseRequestMsg) marshal() []byte         { return nil }
+func (m *trezorPassphraseRequestMsg) unmarshal([]byte) error  { return nil }
+
+// trezorPassphraseAckMsg sends the passphrase protecting the wallet.
+type trezorPassphraseAckMsg struct {
+	Passphrase string
+}
+
+func (m *trezorPassphraseAckMsg) kind() trezorMessageType { return trezorPassphraseAck }
+
+func (m *trezorPassphraseAckMsg) marshal() []byte {
+	var e protoEncoder
+	e.putBytes(1, []byte(m.Passphrase))
+	return e
+}
+
+func (m *trezorPassphraseAckMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		if field == 1 {
+			m.Passphrase = string(data)
+		}
+		return nil
+	})
+}
+
+// trezorEthGetAddressMsg requests the address at a derivation path.
+type trezorEthGetAddressMsg struct {
+	AddressN []uint32
+}
+
+func (m *trezorEthGetAddressMsg) kind() trezorMessageType { return trezorEthGetAddress }
+
+func (m *trezorEthGetAddressMsg) marshal() []byte {
+	var e protoEncoder
+	for _, index := range m.AddressN {
+		e.putUint(1, uint64(index))
+	}
+	return e
+}
+
+func (m *trezorEthGetAddressMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		if field == 1 {
+			m.AddressN = append(m.AddressN, uint32(value))
+		}
+		return nil
+	})
+}
+
+// trezorEthAddressMsg is the reply to an address derivation. Older firmwares
+// return the raw address, newer ones its hex encoding.
+type trezorEthAddressMsg struct {
+	Address    []byte
+	AddressHex string
+}
+
+func (m *trezorEthAddressMsg) kind() trezorMessageType { return trezorEthAddress }
+
+func (m *trezorEthAddressMsg) marshal() []byte {
+	var e protoEncoder
+	if m.Address != nil {
+		e.putBytes(1, m.Address)
+	}
+	if m.AddressHex != "" {
+		e.putBytes(2, []byte(m.AddressHex))
+	}
+	return e
+}
+
+func (m *trezorEthAddressMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 1:
+			m.Address = append([]byte{}, data...)
+		case 2:
+			m.AddressHex = string(data)
+		}
+		return nil
+	})
+}
+
+// trezorEthSignTxMsg starts a transaction signing, carrying the transaction
+// fields and the first chunk of its payload.
+type trezorEthSignTxMsg struct {
+	AddressN         []uint32
+	Nonce            []byte
+	GasPrice         []byte
+	GasLimit         []byte
+	To               []byte
+	Value            []byte
+	DataInitialChunk []byte
+	DataLength       uint32
+	ChainID          *uint32
+}
+
+func (m *trezorEthSignTxMsg) kind() trezorMessageType { return trezorEthSignTx }
+
+func (m *trezorEthSignTxMsg) marshal() []byte {
+	var e protoEncoder
+	for _, index := range m.AddressN {
+		e.putUint(1, uint64(index))
+	}
+	e.putBytes(2, m.Nonce)
+	e.putBytes(3, m.GasPrice)
+	e.putBytes(4, m.GasLimit)
+	if m.To != nil {
+		e.putBytes(5, m.To)
+	}
+	e.putBytes(6, m.Value)
+	if m.DataLength > 0 {
+		e.putBytes(7, m.DataInitialChunk)
+		e.putUint(8, uint64(m.DataLength))
+	}
+	if m.ChainID != nil {
+		e.putUint(9, uint64(*m.ChainID))
+	}
+	return e
+}
+
+func (m *trezorEthSignTxMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 1:
+			m.AddressN = append(m.AddressN, uint32(value))
+		case 2:
+			m.Nonce = append([]byte{}, data...)
+		case 3:
+			m.GasPrice = append([]byte{}, data...)
+		case 4:
+			m.GasLimit = append([]byte{}, data...)
+		case 5:
+			m.To = append([]byte{}, data...)
+		case 6:
+			m.Value = append([]byte{}, data...)
+		case 7:
+			m.DataInitialChunk = append([]byte{}, data...)
+		case 8:
+			m.DataLength = uint32(value)
+		case 9:
+			id := uint32(value)
+			m.ChainID = &id
+		}
+		return nil
+	})
+}
+
+// trezorEthTxRequestMsg either requests the next chunk of the transaction
+// payload, or carries the final signature.
+type trezorEthTxRequestMsg struct {
+	DataLength *uint32
+	SignatureV uint32
+	SignatureR []byte
+	SignatureS []byte
+}
+
+func (m *trezorEthTxRequestMsg) kind() trezorMessageType { return trezorEthTxRequest }
+
+func (m *trezorEthTxRequestMsg) marshal() []byte {
+	var e protoEncoder
+	if m.DataLength != nil {
+		e.putUint(1, uint64(*m.DataLength))
+	}
+	if m.SignatureR != nil {
+		e.putUint(2, uint64(m.SignatureV))
+		e.putBytes(3, m.SignatureR)
+		e.putBytes(4, m.SignatureS)
+	}
+	return e
+}
+
+func (m *trezorEthTxRequestMsg) unmarshal(blob []byte) error {
+	// Replies are reused across the payload streaming, reset the previous one
+	*m = trezorEthTxRequestMsg{}
+
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 1:
+			length := uint32(value)
+			m.DataLength = &length
+		case 2:
+			m.SignatureV = uint32(value)
+		case 3:
+			m.SignatureR = append([]byte{}, data...)
+		case 4:
+			m.SignatureS = append([]byte{}, data...)
+		}
+		return nil
+	})
+}
+
+// trezorEthTxAckMsg sends the next chunk of the transaction payload.
+type trezorEthTxAckMsg struct {
+	DataChunk []byte
+}
+
+func (m *trezorEthTxAckMsg) kind() trezorMessageType { return trezorEthTxAck }
+
+func (m *trezorEthTxAckMsg) marshal() []byte {
+	var e protoEncoder
+	e.putBytes(1, m.DataChunk)
+	return e
+}
+
+func (m *trezorEthTxAckMsg) unmarshal(blob []byte) error {
+	return protoDecode(blob, func(field int, value uint64, data []byte) error {
+		if field == 1 {
+			m.DataChunk = append([]byte{}, data...)
+		}
+		return nil
+	})
+}
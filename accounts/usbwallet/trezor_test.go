@@ -0,0 +1,317 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/accounts"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/log"
+)
+
+// fakeTrezor is an in-memory USB transport emulating a Trezor device protected
+// by a PIN and a passphrase.
+type fakeTrezor struct {
+	pin        string // PIN code unlocking the device
+	pinned     bool   // Whether the PIN was already entered
+	passphrase bool   // Whether the passphrase was already entered
+
+	inbound []byte            // Partially received request message
+	kind    trezorMessageType // Type of the partially received request
+	length  int               // Total length of the partially received request
+	replies []byte            // Framed reply reports waiting to be read
+
+	signing *trezorEthSignTxMsg // Transaction being signed
+	data    []byte              // Transaction payload received so far
+	chunks  int                 // Number of payload chunks requested
+	sig     trezorMessage       // Signature waiting for the button confirmation
+}
+
+// trezorKey returns the deterministic key the fake device derives for a path.
+func trezorKey(path []uint32) *ecdsa.PrivateKey {
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte(accounts.DerivationPath(path).String())))
+	return key
+}
+
+// Write implements io.Writer, accepting a single 64 byte report.
+func (d *fakeTrezor) Write(report []byte) (int, error) {
+	if len(report) != 64 || report[0] != 0x3f {
+		return 0, fmt.Errorf("invalid report: %x", report)
+	}
+	if d.inbound == nil {
+		if report[1] != '#' || report[2] != '#' {
+			return 0, fmt.Errorf("invalid header: %x", report)
+		}
+		d.kind = trezorMessageType(binary.BigEndian.Uint16(report[3:5]))
+		d.length = int(binary.BigEndian.Uint32(report[5:9]))
+		d.inbound = append([]byte{}, report[9:]...)
+	} else {
+		d.inbound = append(d.inbound, report[1:]...)
+	}
+	if len(d.inbound) >= d.length {
+		blob := d.inbound[:d.length]
+		d.inbound = nil
+		if err := d.handle(d.kind, blob); err != nil {
+			return 0, err
+		}
+	}
+	return len(report), nil
+}
+
+// Read implements io.Reader, returning the queued reply reports.
+func (d *fakeTrezor) Read(buf []byte) (int, error) {
+	if len(d.replies) == 0 {
+		return 0, fmt.Errorf("no pending reply")
+	}
+	n := copy(buf, d.replies[:64])
+	d.replies = d.replies[n:]
+	return n, nil
+}
+
+// reply frames a message into 64 byte reports and queues it for reading.
+func (d *fakeTrezor) reply(msg trezorMessage) {
+	data := msg.marshal()
+
+	payload := append([]byte{'#', '#', 0, 0, 0, 0, 0, 0}, data...)
+	binary.BigEndian.PutUint16(payload[2:], uint16(msg.kind()))
+	binary.BigEndian.PutUint32(payload[4:], uint32(len(data)))
+
+	for len(payload) > 0 {
+		report := make([]byte, 64)
+		report[0] = 0x3f
+		payload = payload[copy(report[1:], payload):]
+		d.replies = append(d.replies, report...)
+	}
+}
+
+// handle processes a fully received request message.
+func (d *fakeTrezor) handle(kind trezorMessageType, blob []byte) error {
+	switch kind {
+	case trezorInitialize:
+		d.reply(&trezorFeaturesMsg{Vendor: "bitcointrezor.com", Major: 1, Minor: 5, Patch: 2, Label: "test"})
+
+	case trezorPing:
+		ping := new(trezorPingMsg)
+		if err := ping.unmarshal(blob); err != nil {
+			return err
+		}
+		switch {
+		case ping.PinProtection && !d.pinned:
+			d.reply(new(trezorPinMatrixRequestMsg))
+		case ping.PassphraseProtection && !d.passphrase:
+			d.reply(new(trezorPassphraseRequestMsg))
+		default:
+			d.reply(new(trezorSuccessMsg))
+		}
+
+	case trezorPinMatrixAck:
+		ack := new(trezorPinMatrixAckMsg)
+		if err := ack.unmarshal(blob); err != nil {
+			return err
+		}
+		if ack.Pin != d.pin {
+			d.reply(&trezorFailureMsg{Code: 7, Message: "PIN invalid"})
+			return nil
+		}
+		d.pinned = true
+		if !d.passphrase {
+			d.reply(new(trezorPassphraseRequestMsg))
+		} else {
+			d.reply(new(trezorSuccessMsg))
+		}
+
+	case trezorPassphraseAck:
+		d.passphrase = true
+		d.reply(new(trezorSuccessMsg))
+
+	case trezorEthGetAddress:
+		req := new(trezorEthGetAddressMsg)
+		if err := req.unmarshal(blob); err != nil {
+			return err
+		}
+		d.reply(&trezorEthAddressMsg{Address: crypto.PubkeyToAddress(trezorKey(req.AddressN).PublicKey).Bytes()})
+
+	case trezorEthSignTx:
+		d.signing, d.chunks = new(trezorEthSignTxMsg), 0
+		if err := d.signing.unmarshal(blob); err != nil {
+			return err
+		}
+		d.data = d.signing.DataInitialChunk
+		return d.stream()
+
+	case trezorEthTxAck:
+		ack := new(trezorEthTxAckMsg)
+		if err := ack.unmarshal(blob); err != nil {
+			return err
+		}
+		d.data = append(d.data, ack.DataChunk...)
+		return d.stream()
+
+	case trezorButtonAck:
+		d.reply(d.sig)
+
+	default:
+		d.reply(&trezorFailureMsg{Code: 1, Message: "Unexpected message"})
+	}
+	return nil
+}
+
+// stream requests the next payload chunk of the transaction being signed, or
+// signs it after a button confirmation if the payload is complete.
+func (d *fakeTrezor) stream() error {
+	if left := int(d.signing.DataLength) - len(d.data); left > 0 {
+		if left > trezorDataChunkSize {
+			left = trezorDataChunkSize
+		}
+		length := uint32(left)
+		d.chunks++
+		d.reply(&trezorEthTxRequestMsg{DataLength: &length})
+		return nil
+	}
+	var (
+		m        = d.signing
+		nonce    = new(big.Int).SetBytes(m.Nonce).Uint64()
+		value    = new(big.Int).SetBytes(m.Value)
+		gasLimit = new(big.Int).SetBytes(m.GasLimit)
+		gasPrice = new(big.Int).SetBytes(m.GasPrice)
+		tx       *types.Transaction
+	)
+	if m.To == nil {
+		tx = types.NewContractCreation(nonce, value, gasLimit, gasPrice, d.data)
+	} else {
+		tx = types.NewTransaction(nonce, common.BytesToAddress(m.To), value, gasLimit, gasPrice, d.data)
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if m.ChainID != nil {
+		signer = types.NewEIP155Signer(big.NewInt(int64(*m.ChainID)))
+	}
+	hash := signer.Hash(tx)
+	sig, err := crypto.Sign(hash[:], trezorKey(m.AddressN))
+	if err != nil {
+		return err
+	}
+	v := uint32(sig[64]) + 27
+	if m.ChainID != nil {
+		v = uint32(sig[64]) + *m.ChainID*2 + 35
+	}
+	// Strip any leading zeroes from the signature values, as the device does
+	d.sig = &trezorEthTxRequestMsg{SignatureV: v, SignatureR: new(big.Int).SetBytes(sig[:32]).Bytes(), SignatureS: new(big.Int).SetBytes(sig[32:64]).Bytes()}
+	d.reply(new(trezorButtonRequestMsg))
+	return nil
+}
+
+// Tests that the multi phase opening of a PIN and passphrase protected Trezor
+// works, and that an invalid PIN is reported back without unlocking the device.
+func TestTrezorOpen(t *testing.T) {
+	device := &fakeTrezor{pin: "1234"}
+	driver := newTrezorDriver(log.Root()).(*trezorDriver)
+
+	if err := driver.Open(device, ""); err != ErrTrezorPINNeeded {
+		t.Fatalf("initial open: error mismatch: have %v, want %v", err, ErrTrezorPINNeeded)
+	}
+	if status, _ := driver.Status(); status != "Trezor v1.5.2 'test' waiting for PIN" {
+		t.Fatalf("status mismatch: have %q", status)
+	}
+	if err := driver.Open(device, ""); err != ErrTrezorPINNeeded {
+		t.Fatalf("empty PIN: error mismatch: have %v, want %v", err, ErrTrezorPINNeeded)
+	}
+	if err := driver.Open(device, "4321"); err == nil || !strings.Contains(err.Error(), "PIN invalid") {
+		t.Fatalf("invalid PIN: error mismatch: have %v, want PIN invalid", err)
+	}
+	// A failed PIN entry restarts the opening from scratch
+	if err := driver.Open(device, ""); err != ErrTrezorPINNeeded {
+		t.Fatalf("reopen: error mismatch: have %v, want %v", err, ErrTrezorPINNeeded)
+	}
+	if err := driver.Open(device, "1234"); err != ErrTrezorPassphraseNeeded {
+		t.Fatalf("valid PIN: error mismatch: have %v, want %v", err, ErrTrezorPassphraseNeeded)
+	}
+	if err := driver.Open(device, "secret"); err != nil {
+		t.Fatalf("passphrase: failed to open: %v", err)
+	}
+	if status, err := driver.Status(); err != nil || status != "Trezor v1.5.2 'test' online" {
+		t.Fatalf("status mismatch: have %q, %v", status, err)
+	}
+	if err := driver.Heartbeat(); err != nil {
+		t.Fatalf("heartbeat failed: %v", err)
+	}
+	if len(device.replies) != 0 || len(device.inbound) != 0 {
+		t.Fatalf("dangling data: %d reply bytes, %d request bytes", len(device.replies), len(device.inbound))
+	}
+}
+
+// Tests that an unlocked Trezor derives addresses and signs both legacy and
+// EIP155 transactions, streaming large payloads in chunks.
+func TestTrezorSignTx(t *testing.T) {
+	device := &fakeTrezor{pinned: true, passphrase: true}
+	driver := newTrezorDriver(log.Root()).(*trezorDriver)
+
+	if err := driver.Open(device, ""); err != nil {
+		t.Fatalf("failed to open unlocked device: %v", err)
+	}
+	path := append(accounts.DerivationPath{}, accounts.DefaultBaseDerivationPath...)
+	want := crypto.PubkeyToAddress(trezorKey(path).PublicKey)
+
+	if addr, err := driver.Derive(path); err != nil || addr != want {
+		t.Fatalf("derivation mismatch: have %x, %v, want %x", addr, err, want)
+	}
+	to := common.HexToAddress("0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192")
+	tests := []struct {
+		tx      *types.Transaction
+		chainID *big.Int
+		chunks  int
+	}{
+		{types.NewTransaction(0, to, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), nil, 0},
+		{types.NewTransaction(1, to, big.NewInt(0), big.NewInt(50000), big.NewInt(2), []byte{0xa9, 0x05, 0x9c, 0xbb}), big.NewInt(1), 0},
+		{types.NewContractCreation(2, big.NewInt(0), big.NewInt(900000), big.NewInt(1), bytes.Repeat([]byte{0x60}, 2500)), big.NewInt(1234567), 2},
+	}
+	for i, test := range tests {
+		sender, signed, err := driver.SignTx(path, test.tx, test.chainID)
+		if err != nil {
+			t.Errorf("test %d: failed to sign: %v", i, err)
+			continue
+		}
+		if sender != want {
+			t.Errorf("test %d: sender mismatch: have %x, want %x", i, sender, want)
+		}
+		var signer types.Signer = types.HomesteadSigner{}
+		if test.chainID != nil {
+			signer = types.NewEIP155Signer(test.chainID)
+		}
+		if from, err := types.Sender(signer, signed); err != nil || from != want {
+			t.Errorf("test %d: recovered sender mismatch: have %x, %v, want %x", i, from, err, want)
+		}
+		if signed.Hash() == test.tx.Hash() || !bytes.Equal(signed.Data(), test.tx.Data()) {
+			t.Errorf("test %d: signed transaction mismatch", i)
+		}
+		if device.chunks != test.chunks {
+			t.Errorf("test %d: streamed chunk mismatch: have %d, want %d", i, device.chunks, test.chunks)
+		}
+	}
+	// Chain IDs not fitting into 32 bits cannot be signed by the device
+	if _, _, err := driver.SignTx(path, tests[0].tx, new(big.Int).Lsh(big.NewInt(1), 32)); err == nil {
+		t.Errorf("oversized chain id accepted")
+	}
+}
@@ -53,6 +53,7 @@ type LesServer interface {
 	Start(srvr *p2p.Server)
 	Stop()
 	Protocols() []p2p.Protocol
+	APIs() []rpc.API
 }
 
 // Bazacoin implements the Bazacoin full node service.
@@ -137,7 +138,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Bazacoin, error) {
 		core.WriteBlockChainVersion(chainDb, core.BlockChainVersion)
 	}
 
-	vmConfig := vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
+	vmConfig := vm.Config{
+		EnablePreimageRecording: config.EnablePreimageRecording,
+		EVMInterpreter:          config.EVMInterpreter,
+	}
 	bzc.blockchain, err = core.NewBlockChain(chainDb, bzc.chainConfig, bzc.engine, bzc.eventMux, vmConfig)
 	if err != nil {
 		return nil, err
@@ -163,7 +167,11 @@ func New(ctx *node.ServiceContext, config *Config) (*Bazacoin, error) {
 		}
 	}
 
-	if bzc.protocolManager, err = NewProtocolManager(bzc.chainConfig, config.SyncMode, config.NetworkId, maxPeers, bzc.eventMux, bzc.txPool, bzc.engine, bzc.blockchain, chainDb); err != nil {
+	checkpoint := config.Checkpoint
+	if checkpoint == nil {
+		checkpoint = params.TrustedCheckpoints[genesisHash]
+	}
+	if bzc.protocolManager, err = NewProtocolManager(bzc.chainConfig, checkpoint, config.SyncMode, config.NetworkId, maxPeers, bzc.eventMux, bzc.txPool, bzc.engine, bzc.blockchain, chainDb); err != nil {
 		return nil, err
 	}
 
@@ -242,6 +250,11 @@ func (s *Bazacoin) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append any APIs exposed by the light server
+	if s.lesServer != nil {
+		apis = append(apis, s.lesServer.APIs()...)
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
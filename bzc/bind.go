@@ -24,6 +24,8 @@ import (
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/common/hexutil"
 	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/bzc/filters"
+	"github.com/bazacoin/go-bazacoin/event"
 	"github.com/bazacoin/go-bazacoin/internal/bzcapi"
 	"github.com/bazacoin/go-bazacoin/rlp"
 	"github.com/bazacoin/go-bazacoin/rpc"
@@ -40,15 +42,23 @@ type ContractBackend struct {
 	eapi  *bzcapi.PublicBazacoinAPI        // Wrapper around the Bazacoin object to access metadata
 	bcapi *bzcapi.PublicBlockChainAPI      // Wrapper around the blockchain to access chain data
 	txapi *bzcapi.PublicTransactionPoolAPI // Wrapper around the transaction pool to access transaction data
+
+	backend   bzcapi.Backend        // Backend to run one-off log filter queries against
+	events    *filters.EventSystem // Event system to subscribe to new logs through
+	lightMode bool                 // Whether the backend belongs to a light client
 }
 
 // NewContractBackend creates a new native contract backend using an existing
-// Bazacoin object.
-func NewContractBackend(apiBackend bzcapi.Backend) *ContractBackend {
+// Bazacoin object. The light mode flag selects how logs are filtered, as light
+// clients don't have the receipts of the blocks locally available.
+func NewContractBackend(apiBackend bzcapi.Backend, lightMode bool) *ContractBackend {
 	return &ContractBackend{
-		eapi:  bzcapi.NewPublicBazacoinAPI(apiBackend),
-		bcapi: bzcapi.NewPublicBlockChainAPI(apiBackend),
-		txapi: bzcapi.NewPublicTransactionPoolAPI(apiBackend, new(bzcapi.AddrLocker)),
+		eapi:      bzcapi.NewPublicBazacoinAPI(apiBackend),
+		bcapi:     bzcapi.NewPublicBlockChainAPI(apiBackend),
+		txapi:     bzcapi.NewPublicTransactionPoolAPI(apiBackend, new(bzcapi.AddrLocker)),
+		backend:   apiBackend,
+		events:    filters.NewEventSystem(apiBackend.EventMux(), apiBackend, lightMode),
+		lightMode: lightMode,
 	}
 }
 
@@ -138,3 +148,61 @@ func (b *ContractBackend) SendTransaction(ctx context.Context, tx *types.Transac
 	_, err := b.txapi.SendRawTransaction(ctx, raw)
 	return err
 }
+
+// FilterLogs implements bind.ContractFilterer executing a one-off log filter
+// query against the local chain, blocking until all the results are gathered.
+func (b *ContractBackend) FilterLogs(ctx context.Context, query bazacoin.FilterQuery) ([]types.Log, error) {
+	begin, end := int64(0), rpc.LatestBlockNumber.Int64()
+	if query.FromBlock != nil {
+		begin = query.FromBlock.Int64()
+	}
+	if query.ToBlock != nil {
+		end = query.ToBlock.Int64()
+	}
+	filter := filters.New(b.backend, !b.lightMode)
+	filter.SetBeginBlock(begin)
+	filter.SetEndBlock(end)
+	filter.SetAddresses(query.Addresses)
+	filter.SetTopics(query.Topics)
+
+	logs, err := filter.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.Log, len(logs))
+	for i, log := range logs {
+		res[i] = *log
+	}
+	return res, nil
+}
+
+// SubscribeFilterLogs implements bind.ContractFilterer creating a background
+// log filtering operation, streaming the logs of new blocks into the channel.
+func (b *ContractBackend) SubscribeFilterLogs(ctx context.Context, query bazacoin.FilterQuery, ch chan<- types.Log) (bazacoin.Subscription, error) {
+	logs := make(chan []*types.Log)
+	sub, err := b.events.SubscribeLogs(filters.FilterCriteria(query), logs)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case batch := <-logs:
+				for _, log := range batch {
+					select {
+					case ch <- *log:
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
@@ -81,6 +81,11 @@ type Config struct {
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 	MaxPeers   int `toml:"-"`          // Maximum number of global peers
 
+	// Trusted checkpoint to start light syncing from and the oracle contract to
+	// pick up newer checkpoints from. If nil, the built-in defaults are used.
+	Checkpoint       *params.TrustedCheckpoint      `toml:",omitempty"`
+	CheckpointOracle *params.CheckpointOracleConfig `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
@@ -109,6 +114,9 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Name of the interpreter executing EVM byte code
+	EVMInterpreter string
+
 	// Miscellaneous options
 	DocRoot   string `toml:"-"`
 	PowFake   bool   `toml:"-"`
@@ -34,6 +34,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/event"
 	"github.com/bazacoin/go-bazacoin/log"
 	"github.com/bazacoin/go-bazacoin/params"
+	"github.com/bazacoin/go-bazacoin/rlp"
 	"github.com/rcrowley/go-metrics"
 )
 
@@ -224,7 +225,7 @@ func (d *Downloader) Progress() bazacoin.SyncProgress {
 	switch d.mode {
 	case FullSync:
 		current = d.headBlock().NumberU64()
-	case FastSync:
+	case FastSync, SnapSync:
 		current = d.headFastBlock().NumberU64()
 	case LightSync:
 		current = d.headHeader().Number.Uint64()
@@ -260,6 +261,30 @@ func (d *Downloader) RegisterPeer(id string, version int, currentHead currentHea
 	return nil
 }
 
+// RegisterSnapPeer attaches the snap protocol range fetchers to an already
+// registered download peer, allowing it to serve snapshot based state syncs.
+func (d *Downloader) RegisterSnapPeer(id string, getAccountRange accountRangeFetcherFn, getStorageRange storageRangeFetcherFn) error {
+	p := d.peers.Peer(id)
+	if p == nil {
+		return errNotRegistered
+	}
+	p.log.Trace("Registering snap sync peer")
+	p.setSnap(getAccountRange, getStorageRange)
+	return nil
+}
+
+// UnregisterSnapPeer detaches the snap protocol range fetchers from a download
+// peer, leaving it registered for all other retrievals.
+func (d *Downloader) UnregisterSnapPeer(id string) error {
+	p := d.peers.Peer(id)
+	if p == nil {
+		return errNotRegistered
+	}
+	p.log.Trace("Unregistering snap sync peer")
+	p.setSnap(nil, nil)
+	return nil
+}
+
 // UnregisterPeer remove a peer from the known list, preventing any action from
 // the specified peer. An effort is also made to return any pending fetches into
 // the queue.
@@ -358,7 +383,7 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 
 	// Set the requested sync mode, unless it's forbidden
 	d.mode = mode
-	if d.mode == FastSync && atomic.LoadUint32(&d.fsPivotFails) >= fsCriticalTrials {
+	if (d.mode == FastSync || d.mode == SnapSync) && atomic.LoadUint32(&d.fsPivotFails) >= fsCriticalTrials {
 		d.mode = FullSync
 	}
 	// Retrieve the origin peer and initiate the downloading process
@@ -413,7 +438,7 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 	switch d.mode {
 	case LightSync:
 		pivot = height
-	case FastSync:
+	case FastSync, SnapSync:
 		// Calculate the new fast/slow sync pivot point
 		if d.fsPivotLock == nil {
 			pivotOffset, err := rand.Int(rand.Reader, big.NewInt(int64(fsPivotInterval)))
@@ -448,13 +473,13 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 		func() error { return d.fetchReceipts(origin + 1) }, // Receipts are retrieved during fast sync
 		func() error { return d.processHeaders(origin+1, td) },
 	}
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		fetchers = append(fetchers, func() error { return d.processFastSyncContent(latest) })
 	} else if d.mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
 	}
 	err = d.spawnSync(fetchers)
-	if err != nil && d.mode == FastSync && d.fsPivotLock != nil {
+	if err != nil && (d.mode == FastSync || d.mode == SnapSync) && d.fsPivotLock != nil {
 		// If sync failed in the critical section, bump the fail counter.
 		atomic.AddUint32(&d.fsPivotFails, 1)
 	}
@@ -577,7 +602,7 @@ func (d *Downloader) findAncestor(p *peer, height uint64) (uint64, error) {
 	p.log.Debug("Looking for common ancestor", "local", ceil, "remote", height)
 	if d.mode == FullSync {
 		ceil = d.headBlock().NumberU64()
-	} else if d.mode == FastSync {
+	} else if d.mode == FastSync || d.mode == SnapSync {
 		ceil = d.headFastBlock().NumberU64()
 	}
 	if ceil >= MaxForkAncestry {
@@ -1201,7 +1226,7 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 				// This check cannot be executed "as is" for full imports, since blocks may still be
 				// queued for processing when the header download completes. However, as long as the
 				// peer gave us something useful, we're already happy/progressed (above check).
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 					if td.Cmp(d.getTd(d.headHeader().Hash())) > 0 {
 						return errStallingPeer
 					}
@@ -1228,7 +1253,7 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 				chunk := headers[:limit]
 
 				// In case of header only syncing, validate the chunk immediately
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 					// Collect the yet unknown headers to mark them as uncertain
 					unknown := make([]*types.Header, 0, len(headers))
 					for _, header := range chunk {
@@ -1256,14 +1281,14 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 					}
 				}
 				// If we're fast syncing and just pulled in the pivot, make sure it's the one locked in
-				if d.mode == FastSync && d.fsPivotLock != nil && chunk[0].Number.Uint64() <= pivot && chunk[len(chunk)-1].Number.Uint64() >= pivot {
+				if (d.mode == FastSync || d.mode == SnapSync) && d.fsPivotLock != nil && chunk[0].Number.Uint64() <= pivot && chunk[len(chunk)-1].Number.Uint64() >= pivot {
 					if pivot := chunk[int(pivot-chunk[0].Number.Uint64())]; pivot.Hash() != d.fsPivotLock.Hash() {
 						log.Warn("Pivot doesn't match locked in one", "remoteNumber", pivot.Number, "remoteHash", pivot.Hash(), "localNumber", d.fsPivotLock.Number, "localHash", d.fsPivotLock.Hash())
 						return errInvalidChain
 					}
 				}
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
-				if d.mode == FullSync || d.mode == FastSync {
+				if d.mode == FullSync || d.mode == FastSync || d.mode == SnapSync {
 					// If we've reached the allowed number of pending headers, stall a bit
 					for d.queue.PendingBlocks() >= maxQueuedHeaders || d.queue.PendingReceipts() >= maxQueuedHeaders {
 						select {
@@ -1461,6 +1486,18 @@ func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
 	return d.deliver(id, d.stateCh, &statePack{id, data}, stateInMeter, stateDropMeter)
 }
 
+// DeliverAccountRange injects a new batch of consecutive accounts received from
+// a remote node over the snap protocol.
+func (d *Downloader) DeliverAccountRange(id string, hashes []common.Hash, accounts [][]byte, proof []rlp.RawValue) (err error) {
+	return d.deliver(id, d.stateCh, &rangePack{id, hashes, accounts, proof}, stateInMeter, stateDropMeter)
+}
+
+// DeliverStorageRange injects a new batch of consecutive storage slots received
+// from a remote node over the snap protocol.
+func (d *Downloader) DeliverStorageRange(id string, hashes []common.Hash, slots [][]byte, proof []rlp.RawValue) (err error) {
+	return d.deliver(id, d.stateCh, &rangePack{id, hashes, slots, proof}, stateInMeter, stateDropMeter)
+}
+
 // deliver injects a new batch of data received from a remote node.
 func (d *Downloader) deliver(id string, destCh chan dataPack, packet dataPack, inMeter, dropMeter metrics.Meter) (err error) {
 	// Update the delivery metrics for both good and failed deliveries
@@ -25,14 +25,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bazacoin/go-bazacoin/bzcdb"
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core"
 	"github.com/bazacoin/go-bazacoin/core/state"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/crypto"
-	"github.com/bazacoin/go-bazacoin/bzcdb"
 	"github.com/bazacoin/go-bazacoin/event"
 	"github.com/bazacoin/go-bazacoin/params"
+	"github.com/bazacoin/go-bazacoin/rlp"
 	"github.com/bazacoin/go-bazacoin/trie"
 )
 
@@ -413,6 +414,9 @@ func (dl *downloadTester) newSlowPeer(id string, version int, hashes []common.Ha
 		err = dl.downloader.RegisterPeer(id, version, dl.peerCurrentHeadFn(id), dl.peerGetRelHeadersFn(id, delay), dl.peerGetAbsHeadersFn(id, delay), dl.peerGetBodiesFn(id, delay), dl.peerGetReceiptsFn(id, delay), dl.peerGetNodeDataFn(id, delay))
 	case 64:
 		err = dl.downloader.RegisterPeer(id, version, dl.peerCurrentHeadFn(id), dl.peerGetRelHeadersFn(id, delay), dl.peerGetAbsHeadersFn(id, delay), dl.peerGetBodiesFn(id, delay), dl.peerGetReceiptsFn(id, delay), dl.peerGetNodeDataFn(id, delay))
+		if err == nil {
+			err = dl.downloader.RegisterSnapPeer(id, dl.peerGetAccountRangeFn(id, delay), dl.peerGetStorageRangeFn(id, delay))
+		}
 	}
 	if err == nil {
 		// Assign the owned hashes, headers and blocks to the peer (deep copy)
@@ -606,6 +610,78 @@ func (dl *downloadTester) peerGetNodeDataFn(id string, delay time.Duration) func
 	}
 }
 
+// peerGetAccountRangeFn constructs a getAccountRange method associated with a
+// particular peer in the download tester. The returned function can be used to
+// retrieve proven ranges of accounts from the particularly requested peer.
+func (dl *downloadTester) peerGetAccountRangeFn(id string, delay time.Duration) func(common.Hash, common.Hash, uint64) error {
+	return func(root common.Hash, origin common.Hash, bytes uint64) error {
+		time.Sleep(delay)
+
+		dl.lock.RLock()
+		defer dl.lock.RUnlock()
+
+		hashes, values, proof := dl.peerRange(id, root, origin, bytes)
+		go dl.downloader.DeliverAccountRange(id, hashes, values, proof)
+
+		return nil
+	}
+}
+
+// peerGetStorageRangeFn constructs a getStorageRange method associated with a
+// particular peer in the download tester. The returned function can be used to
+// retrieve proven ranges of storage slots from the particularly requested peer.
+func (dl *downloadTester) peerGetStorageRangeFn(id string, delay time.Duration) func(common.Hash, common.Hash, common.Hash, uint64) error {
+	return func(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error {
+		time.Sleep(delay)
+
+		dl.lock.RLock()
+		defer dl.lock.RUnlock()
+
+		var (
+			hashes []common.Hash
+			values [][]byte
+			proof  []rlp.RawValue
+		)
+		if tr, err := trie.New(root, dl.peerDb); err == nil {
+			var data state.Account
+			if blob := tr.Get(account[:]); blob != nil && rlp.DecodeBytes(blob, &data) == nil {
+				hashes, values, proof = dl.peerRange(id, data.Root, origin, bytes)
+			}
+		}
+		go dl.downloader.DeliverStorageRange(id, hashes, values, proof)
+
+		return nil
+	}
+}
+
+// peerRange collects the entries of the trie with the given root starting at
+// origin, along with the proofs of the range edges.
+func (dl *downloadTester) peerRange(id string, root common.Hash, origin common.Hash, bytes uint64) ([]common.Hash, [][]byte, []rlp.RawValue) {
+	if dl.peerMissingStates[id][root] {
+		return nil, nil, nil
+	}
+	tr, err := trie.New(root, dl.peerDb)
+	if err != nil {
+		return nil, nil, nil
+	}
+	var (
+		hashes []common.Hash
+		values [][]byte
+		size   uint64
+	)
+	it := trie.NewIterator(tr.NodeIterator(origin[:]))
+	for size < bytes && it.Next() {
+		hashes = append(hashes, common.BytesToHash(it.Key))
+		values = append(values, common.CopyBytes(it.Value))
+		size += uint64(common.HashLength + len(it.Value))
+	}
+	proof := tr.Prove(origin[:])
+	if len(hashes) > 0 {
+		proof = append(proof, tr.Prove(hashes[len(hashes)-1][:])...)
+	}
+	return hashes, values, proof
+}
+
 // assertOwnChain checks if the local chain contains the correct number of items
 // of the various chain components.
 func assertOwnChain(t *testing.T, tester *downloadTester, length int) {
@@ -649,7 +725,7 @@ func assertOwnForkedChain(t *testing.T, tester *downloadTester, common int, leng
 		t.Fatalf("synchronised receipts mismatch: have %v, want between [%v, %v]", rs, minReceipts, maxReceipts)
 	}
 	// Verify the state trie too for fast syncs
-	if tester.downloader.mode == FastSync {
+	if tester.downloader.mode == FastSync || tester.downloader.mode == SnapSync {
 		var index int
 		if pivot := int(tester.downloader.queue.fastSyncPivot); pivot < common {
 			index = pivot
@@ -673,6 +749,7 @@ func TestCanonicalSynchronisation63Fast(t *testing.T)  { testCanonicalSynchronis
 func TestCanonicalSynchronisation64Full(t *testing.T)  { testCanonicalSynchronisation(t, 64, FullSync) }
 func TestCanonicalSynchronisation64Fast(t *testing.T)  { testCanonicalSynchronisation(t, 64, FastSync) }
 func TestCanonicalSynchronisation64Light(t *testing.T) { testCanonicalSynchronisation(t, 64, LightSync) }
+func TestCanonicalSynchronisation64Snap(t *testing.T)  { testCanonicalSynchronisation(t, 64, SnapSync) }
 
 func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -786,6 +863,7 @@ func TestForkedSync63Fast(t *testing.T)  { testForkedSync(t, 63, FastSync) }
 func TestForkedSync64Full(t *testing.T)  { testForkedSync(t, 64, FullSync) }
 func TestForkedSync64Fast(t *testing.T)  { testForkedSync(t, 64, FastSync) }
 func TestForkedSync64Light(t *testing.T) { testForkedSync(t, 64, LightSync) }
+func TestForkedSync64Snap(t *testing.T)  { testForkedSync(t, 64, SnapSync) }
 
 func testForkedSync(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -821,6 +899,7 @@ func TestHeavyForkedSync63Fast(t *testing.T)  { testHeavyForkedSync(t, 63, FastS
 func TestHeavyForkedSync64Full(t *testing.T)  { testHeavyForkedSync(t, 64, FullSync) }
 func TestHeavyForkedSync64Fast(t *testing.T)  { testHeavyForkedSync(t, 64, FastSync) }
 func TestHeavyForkedSync64Light(t *testing.T) { testHeavyForkedSync(t, 64, LightSync) }
+func TestHeavyForkedSync64Snap(t *testing.T)  { testHeavyForkedSync(t, 64, SnapSync) }
 
 func testHeavyForkedSync(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -962,6 +1041,7 @@ func TestCancel63Fast(t *testing.T)  { testCancel(t, 63, FastSync) }
 func TestCancel64Full(t *testing.T)  { testCancel(t, 64, FullSync) }
 func TestCancel64Fast(t *testing.T)  { testCancel(t, 64, FastSync) }
 func TestCancel64Light(t *testing.T) { testCancel(t, 64, LightSync) }
+func TestCancel64Snap(t *testing.T)  { testCancel(t, 64, SnapSync) }
 
 func testCancel(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -1003,6 +1083,7 @@ func TestMultiSynchronisation63Fast(t *testing.T)  { testMultiSynchronisation(t,
 func TestMultiSynchronisation64Full(t *testing.T)  { testMultiSynchronisation(t, 64, FullSync) }
 func TestMultiSynchronisation64Fast(t *testing.T)  { testMultiSynchronisation(t, 64, FastSync) }
 func TestMultiSynchronisation64Light(t *testing.T) { testMultiSynchronisation(t, 64, LightSync) }
+func TestMultiSynchronisation64Snap(t *testing.T)  { testMultiSynchronisation(t, 64, SnapSync) }
 
 func testMultiSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -49,6 +49,10 @@ type blockBodyFetcherFn func([]common.Hash) error
 type receiptFetcherFn func([]common.Hash) error
 type stateFetcherFn func([]common.Hash) error
 
+// State range fetchers belonging to the snap satellite protocol
+type accountRangeFetcherFn func(root common.Hash, origin common.Hash, bytes uint64) error
+type storageRangeFetcherFn func(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error
+
 var (
 	errAlreadyFetching   = errors.New("already fetching blocks from peer")
 	errAlreadyRegistered = errors.New("peer is already registered")
@@ -87,6 +91,9 @@ type peer struct {
 	getReceipts receiptFetcherFn // [bzc/63] Method to retrieve a batch of block transaction receipts
 	getNodeData stateFetcherFn   // [bzc/63] Method to retrieve a batch of state trie data
 
+	getAccountRange accountRangeFetcherFn // [snap] Method to retrieve a range of accounts (nil if not supported)
+	getStorageRange storageRangeFetcherFn // [snap] Method to retrieve a range of storage slots (nil if not supported)
+
 	version int        // Bzc protocol version number to switch strategies
 	log     log.Logger // Contextual logger to add extra infos to peer logs
 	lock    sync.RWMutex
@@ -210,6 +217,63 @@ func (p *peer) FetchNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// FetchAccountRange sends an account range retrieval request to the remote peer
+// over the snap protocol.
+func (p *peer) FetchAccountRange(root common.Hash, origin common.Hash, bytes uint64) error {
+	p.lock.RLock()
+	fetch := p.getAccountRange
+	p.lock.RUnlock()
+
+	// Sanity check the protocol support
+	if fetch == nil {
+		panic("account range fetch requested on non-snap peer")
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+	go fetch(root, origin, bytes)
+	return nil
+}
+
+// FetchStorageRange sends a storage range retrieval request to the remote peer
+// over the snap protocol.
+func (p *peer) FetchStorageRange(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error {
+	p.lock.RLock()
+	fetch := p.getStorageRange
+	p.lock.RUnlock()
+
+	// Sanity check the protocol support
+	if fetch == nil {
+		panic("storage range fetch requested on non-snap peer")
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+	go fetch(root, account, origin, bytes)
+	return nil
+}
+
+// setSnap attaches (or detaches with nil arguments) the snap protocol range
+// fetchers to the peer.
+func (p *peer) setSnap(getAccountRange accountRangeFetcherFn, getStorageRange storageRangeFetcherFn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.getAccountRange, p.getStorageRange = getAccountRange, getStorageRange
+}
+
+// supportsSnap reports whether the peer runs the snap protocol.
+func (p *peer) supportsSnap() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.getAccountRange != nil
+}
+
 // SetHeadersIdle sets the peer to idle, allowing it to execute new header retrieval
 // requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
@@ -500,6 +564,20 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peer, int) {
 	return ps.idlePeers(63, 64, idle, throughput)
 }
 
+// SnapIdlePeers retrieves a flat list of all the currently state-idle peers
+// running the snap protocol, ordered by their reputation.
+func (ps *peerSet) SnapIdlePeers() ([]*peer, int) {
+	idle := func(p *peer) bool {
+		return p.supportsSnap() && atomic.LoadInt32(&p.stateIdle) == 0
+	}
+	throughput := func(p *peer) float64 {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.stateThroughput
+	}
+	return ps.idlePeers(62, 64, idle, throughput)
+}
+
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
 // protocol version constraints, using the provided function to check idleness.
 // The resulting set of peers are sorted by their measure throughput.
@@ -323,7 +323,7 @@ func (q *queue) Schedule(headers []*types.Header, from uint64) []*types.Header {
 		q.blockTaskPool[hash] = header
 		q.blockTaskQueue.Push(header, -float32(header.Number.Uint64()))
 
-		if q.mode == FastSync && header.Number.Uint64() <= q.fastSyncPivot {
+		if (q.mode == FastSync || q.mode == SnapSync) && header.Number.Uint64() <= q.fastSyncPivot {
 			// Fast phase of the fast sync, retrieve receipts too
 			q.receiptTaskPool[hash] = header
 			q.receiptTaskQueue.Push(header, -float32(header.Number.Uint64()))
@@ -378,7 +378,7 @@ func (q *queue) countProcessableItems() int {
 		// resultCache has space for fsHeaderForceVerify items. Not
 		// doing this could leave us unable to download the required
 		// amount of headers.
-		if q.mode == FastSync && result.Header.Number.Uint64() == q.fastSyncPivot {
+		if (q.mode == FastSync || q.mode == SnapSync) && result.Header.Number.Uint64() == q.fastSyncPivot {
 			for j := 0; j < fsHeaderForceVerify; j++ {
 				if i+j+1 >= len(q.resultCache) || q.resultCache[i+j+1] == nil {
 					return i
@@ -493,7 +493,7 @@ func (q *queue) reserveHeaders(p *peer, count int, taskPool map[common.Hash]*typ
 		}
 		if q.resultCache[index] == nil {
 			components := 1
-			if q.mode == FastSync && header.Number.Uint64() <= q.fastSyncPivot {
+			if (q.mode == FastSync || q.mode == SnapSync) && header.Number.Uint64() <= q.fastSyncPivot {
 				components = 2
 			}
 			q.resultCache[index] = &fetchResult{
@@ -0,0 +1,451 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/bazacoin/go-bazacoin/bzcdb"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/state"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/rlp"
+	"github.com/bazacoin/go-bazacoin/trie"
+)
+
+const (
+	snapAccountTasks = 16         // Number of chunks the account hash space is split into
+	snapRangeBytes   = 512 * 1024 // Soft size limit of a single range response
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+// accountTask is a contiguous chunk of the account hash space to download.
+type accountTask struct {
+	next common.Hash // Next account hash to request
+	last common.Hash // Last account hash covered by the task
+	busy bool        // Whether a request is in flight for the task
+	done bool        // Whether the whole chunk was downloaded
+}
+
+// storageTask is the storage trie of a single account to download.
+type storageTask struct {
+	account common.Hash // Hash of the account owning the storage
+	root    common.Hash // Storage root to verify the ranges against
+	next    common.Hash // Next slot hash to request
+	trie    *trie.Trie  // Storage trie being reconstructed
+	busy    bool        // Whether a request is in flight for the task
+}
+
+// snapState tracks the progress of the range download phase of a state sync.
+// Every verified range is inserted into a locally reconstructed trie which is
+// flushed to disk after each response. Nodes along unfinished range edges will
+// end up as unreferenced garbage, but every stored node always has its whole
+// subtrie stored too, so the trie sync can safely heal on top of it.
+type snapState struct {
+	trie      *trie.Trie                 // Account trie being reconstructed
+	accounts  []*accountTask             // Chunks of the account hash space
+	storages  []*storageTask             // Storage tries still to be downloaded
+	codes     map[common.Hash]*stateTask // Contract codes still to be downloaded
+	stateless map[string]bool            // Peers found not to have the state
+	inflight  int                        // Number of requests currently in flight
+}
+
+// newSnapState splits the account hash space into tasks for retrieving the
+// state with the given root.
+func newSnapState(root common.Hash, db bzcdb.Database) *snapState {
+	tr, _ := trie.New(common.Hash{}, db)
+	s := &snapState{
+		trie:      tr,
+		codes:     make(map[common.Hash]*stateTask),
+		stateless: make(map[string]bool),
+	}
+	step := new(big.Int).Div(new(big.Int).Lsh(common.Big1, 256), big.NewInt(snapAccountTasks))
+	for i := 0; i < snapAccountTasks; i++ {
+		next := new(big.Int).Mul(step, big.NewInt(int64(i)))
+		last := new(big.Int).Sub(new(big.Int).Add(next, step), common.Big1)
+		s.accounts = append(s.accounts, &accountTask{
+			next: common.BigToHash(next),
+			last: common.BigToHash(last),
+		})
+	}
+	return s
+}
+
+// done reports whether all ranges, storage tries and codes were downloaded.
+func (s *snapState) done() bool {
+	for _, task := range s.accounts {
+		if !task.done {
+			return false
+		}
+	}
+	return len(s.storages) == 0 && len(s.codes) == 0 && s.inflight == 0
+}
+
+// snapLoop is the main event loop of the range download phase. It assigns the
+// account ranges, storage ranges and contract codes to the snap capable peers
+// and processes their responses until everything was downloaded. If no peer is
+// able to serve the state, the loop returns early and leaves the rest of the
+// work to the trie sync.
+func (s *stateSync) snapLoop() error {
+	// Short circuit if the state is already available locally
+	if blob, err := s.d.stateDB.Get(s.root[:]); err == nil && len(blob) > 0 {
+		return nil
+	}
+	if s.root == emptyRoot {
+		return nil
+	}
+	// Listen for new peer events to assign tasks to them
+	newPeer := make(chan *peer, 1024)
+	peerSub := s.d.peers.SubscribeNewPeers(newPeer)
+	defer peerSub.Unsubscribe()
+
+	for !s.snap.done() {
+		if !s.assignSnapTasks() {
+			log.Warn("No snap peers available, falling back to trie sync", "root", s.root)
+			return nil
+		}
+		// Tasks assigned, wait for something to happen
+		select {
+		case <-newPeer:
+			// New peer arrived, try to assign it download tasks
+
+		case <-s.cancel:
+			return errCancelStateFetch
+
+		case req := <-s.deliver:
+			s.snap.inflight--
+			if err := s.processSnap(req); err != nil {
+				log.Warn("State range write error", "err", err)
+				return err
+			}
+		}
+	}
+	if root := s.snap.trie.Hash(); root != s.root {
+		log.Warn("Downloaded state ranges mismatch, healing", "have", root, "want", s.root)
+	}
+	return nil
+}
+
+// assignSnapTasks attempts to assign new range and code retrievals to all idle
+// snap peers. It returns false if there are no peers left that could serve the
+// state and there are no requests in flight either.
+func (s *stateSync) assignSnapTasks() bool {
+	peers, _ := s.d.peers.SnapIdlePeers()
+	for _, p := range peers {
+		if s.snap.stateless[p.id] {
+			continue
+		}
+		req := &stateReq{peer: p, timeout: s.d.requestTTL()}
+
+		// Prefer finishing storage and codes before discovering more accounts
+		if p.version >= 63 && len(s.snap.codes) > 0 {
+			s.fillCodeTasks(p.NodeDataCapacity(s.d.requestRTT()), req)
+		}
+		if len(req.items) == 0 {
+			for _, task := range s.snap.storages {
+				if !task.busy {
+					req.storage, req.origin = task, task.next
+					break
+				}
+			}
+		}
+		if len(req.items) == 0 && req.storage == nil {
+			for _, task := range s.snap.accounts {
+				if !task.busy && !task.done {
+					req.account, req.origin = task, task.next
+					break
+				}
+			}
+		}
+		// If the peer was assigned anything, send the network request
+		switch {
+		case len(req.items) > 0:
+			req.peer.log.Trace("Requesting contract codes", "count", len(req.items))
+			select {
+			case s.d.trackStateReq <- req:
+				req.peer.FetchNodeData(req.items)
+			case <-s.cancel:
+				return true
+			}
+		case req.storage != nil:
+			req.peer.log.Trace("Requesting storage range", "account", req.storage.account, "origin", req.origin)
+			req.storage.busy = true
+			select {
+			case s.d.trackStateReq <- req:
+				req.peer.FetchStorageRange(s.root, req.storage.account, req.origin, snapRangeBytes)
+			case <-s.cancel:
+				return true
+			}
+		case req.account != nil:
+			req.peer.log.Trace("Requesting account range", "origin", req.origin)
+			req.account.busy = true
+			select {
+			case s.d.trackStateReq <- req:
+				req.peer.FetchAccountRange(s.root, req.origin, snapRangeBytes)
+			case <-s.cancel:
+				return true
+			}
+		default:
+			continue
+		}
+		s.snap.inflight++
+	}
+	if s.snap.inflight > 0 {
+		return true
+	}
+	for _, p := range s.d.peers.AllPeers() {
+		if p.supportsSnap() && !s.snap.stateless[p.id] {
+			return true
+		}
+	}
+	return false
+}
+
+// fillCodeTasks fills the given request object with a maximum of n contract
+// code retrievals that haven't been tried with the request's peer yet.
+func (s *stateSync) fillCodeTasks(n int, req *stateReq) {
+	req.items = make([]common.Hash, 0, n)
+	req.tasks = make(map[common.Hash]*stateTask, n)
+	for hash, t := range s.snap.codes {
+		if len(req.items) == n {
+			break
+		}
+		if _, ok := t.attempts[req.peer.id]; ok {
+			continue
+		}
+		t.attempts[req.peer.id] = struct{}{}
+		req.items = append(req.items, hash)
+		req.tasks[hash] = t
+		delete(s.snap.codes, hash)
+	}
+}
+
+// processSnap handles a range or code response (or timeout) from a peer.
+func (s *stateSync) processSnap(req *stateReq) error {
+	var (
+		delivered int
+		err       error
+	)
+	switch {
+	case req.account != nil:
+		req.account.busy = false
+		delivered, err = s.processAccountRange(req)
+	case req.storage != nil:
+		req.storage.busy = false
+		delivered, err = s.processStorageRange(req)
+	default:
+		delivered, err = s.processCodes(req)
+	}
+	req.peer.SetNodeDataIdle(delivered)
+	return err
+}
+
+// verifyRange checks the delivered range against the given trie root, marking
+// the peer stateless if it didn't have the data and dropping it if the proof
+// is invalid. It returns false if the response cannot be used.
+func (s *stateSync) verifyRange(req *stateReq, root common.Hash) bool {
+	pack := req.ranges
+	if pack == nil {
+		log.Debug("State range request timed out", "peer", req.peer.id)
+		return false
+	}
+	if len(pack.hashes) == 0 && len(pack.proof) == 0 {
+		log.Debug("Peer doesn't have the requested state", "peer", req.peer.id, "root", s.root)
+		s.snap.stateless[req.peer.id] = true
+		return false
+	}
+	keys := make([][]byte, len(pack.hashes))
+	for i, hash := range pack.hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	if err := trie.VerifyRangeProof(root, req.origin[:], keys, pack.values, pack.proof); err != nil {
+		log.Warn("Invalid state range, dropping peer", "peer", req.peer.id, "err", err)
+		s.d.dropPeer(req.peer.id)
+		return false
+	}
+	return true
+}
+
+// processAccountRange imports a verified account range into the reconstructed
+// account trie and schedules the storage tries and codes it references.
+func (s *stateSync) processAccountRange(req *stateReq) (int, error) {
+	if !s.verifyRange(req, s.root) {
+		return 0, nil
+	}
+	var (
+		task = req.account
+		pack = req.ranges
+	)
+	imported := 0
+	for i, hash := range pack.hashes {
+		if bytes.Compare(hash[:], task.last[:]) > 0 {
+			break
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(pack.values[i], &account); err != nil {
+			return imported, fmt.Errorf("invalid account %x: %v", hash, err)
+		}
+		if err := s.snap.trie.TryUpdate(hash[:], pack.values[i]); err != nil {
+			return imported, err
+		}
+		imported++
+
+		if account.Root != emptyRoot && !s.known(account.Root) {
+			tr, _ := trie.New(common.Hash{}, s.d.stateDB)
+			s.snap.storages = append(s.snap.storages, &storageTask{account: hash, root: account.Root, trie: tr})
+		}
+		if code := common.BytesToHash(account.CodeHash); code != emptyCode && !s.known(code) {
+			s.snap.codes[code] = &stateTask{make(map[string]struct{})}
+		}
+	}
+	// Advance the task past the delivered range
+	if n := len(pack.hashes); n == 0 || bytes.Compare(pack.hashes[n-1][:], task.last[:]) >= 0 {
+		task.done = true
+	} else if next, ok := incHash(pack.hashes[n-1]); ok {
+		task.next = next
+	} else {
+		task.done = true
+	}
+	if err := s.flush(s.snap.trie); err != nil {
+		return imported, err
+	}
+	s.updateSnapStats(imported, "accounts")
+	return imported, nil
+}
+
+// processStorageRange imports a verified storage range into the reconstructed
+// storage trie of the task's account.
+func (s *stateSync) processStorageRange(req *stateReq) (int, error) {
+	task := req.storage
+	if !s.verifyRange(req, task.root) {
+		return 0, nil
+	}
+	pack := req.ranges
+	for i, hash := range pack.hashes {
+		if err := task.trie.TryUpdate(hash[:], pack.values[i]); err != nil {
+			return i, err
+		}
+	}
+	if err := s.flush(task.trie); err != nil {
+		return len(pack.hashes), err
+	}
+	// Advance the task, removing it if the storage trie was completed. A range
+	// without proofs is the entire trie.
+	next, ok := common.Hash{}, false
+	if n := len(pack.hashes); n > 0 && len(pack.proof) > 0 {
+		next, ok = incHash(pack.hashes[n-1])
+	}
+	if ok {
+		task.next = next
+	} else {
+		if root := task.trie.Hash(); root != task.root {
+			log.Warn("Downloaded storage mismatch, healing", "account", task.account, "have", root, "want", task.root)
+		}
+		for i, t := range s.snap.storages {
+			if t == task {
+				s.snap.storages = append(s.snap.storages[:i], s.snap.storages[i+1:]...)
+				break
+			}
+		}
+	}
+	s.updateSnapStats(len(pack.hashes), "slots")
+	return len(pack.hashes), nil
+}
+
+// processCodes writes the delivered contract codes to the database, re-queuing
+// any that were requested but not delivered.
+func (s *stateSync) processCodes(req *stateReq) (int, error) {
+	batch := s.d.stateDB.NewBatch()
+	delivered := 0
+	for _, blob := range req.response {
+		hash := crypto.Keccak256Hash(blob)
+		if _, ok := req.tasks[hash]; !ok {
+			continue
+		}
+		if err := batch.Put(hash[:], blob); err != nil {
+			return delivered, err
+		}
+		delete(req.tasks, hash)
+		delivered++
+	}
+	if err := batch.Write(); err != nil {
+		return delivered, err
+	}
+	// Put unfulfilled codes back into the retry queue
+	npeers := s.d.peers.Len()
+	for hash, task := range req.tasks {
+		if len(req.response) > 0 || req.timedOut() {
+			delete(task.attempts, req.peer.id)
+		}
+		if len(task.attempts) >= npeers {
+			return delivered, fmt.Errorf("contract code %s failed with all peers (%d tries, %d peers)", hash.TerminalString(), len(task.attempts), npeers)
+		}
+		s.snap.codes[hash] = task
+	}
+	if delivered > 0 {
+		s.updateSnapStats(delivered, "codes")
+	}
+	return delivered, nil
+}
+
+// known reports whether the state entry with the given hash is already stored.
+func (s *stateSync) known(hash common.Hash) bool {
+	blob, err := s.d.stateDB.Get(hash[:])
+	return err == nil && len(blob) > 0
+}
+
+// flush writes all the nodes of a reconstructed trie to the database.
+func (s *stateSync) flush(tr *trie.Trie) error {
+	batch := s.d.stateDB.NewBatch()
+	if _, err := tr.CommitTo(batch); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// updateSnapStats bumps the state sync progress counters and displays a log
+// message for the user to see.
+func (s *stateSync) updateSnapStats(count int, kind string) {
+	s.d.syncStatsLock.Lock()
+	defer s.d.syncStatsLock.Unlock()
+
+	s.d.syncStatsState.processed += uint64(count)
+
+	pending := len(s.snap.storages) + len(s.snap.codes)
+	log.Debug("Imported new state range", "type", kind, "count", count, "processed", s.d.syncStatsState.processed, "pending", pending)
+}
+
+// incHash returns the hash following h, or false if h is the last possible one.
+func incHash(h common.Hash) (common.Hash, bool) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			return h, true
+		}
+	}
+	return h, false
+}
@@ -39,10 +39,18 @@ type stateReq struct {
 	timer    *time.Timer                // Timer to fire when the RTT timeout expires
 	peer     *peer                      // Peer that we're requesting from
 	response [][]byte                   // Response data of the peer (nil for timeouts)
+
+	account *accountTask // Account range task being filled by a snap request
+	storage *storageTask // Storage range task being filled by a snap request
+	origin  common.Hash  // First hash of the requested snap range
+	ranges  *rangePack   // Snap range response of the peer (nil for timeouts)
 }
 
 // timedOut returns if this request timed out.
 func (req *stateReq) timedOut() bool {
+	if req.account != nil || req.storage != nil {
+		return req.ranges == nil
+	}
 	return req.response == nil
 }
 
@@ -137,7 +145,12 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 			}
 			// Finalize the request and queue up for processing
 			req.timer.Stop()
-			req.response = pack.(*statePack).states
+			switch pack := pack.(type) {
+			case *statePack:
+				req.response = pack.states
+			case *rangePack:
+				req.ranges = pack
+			}
 
 			finished = append(finished, req)
 			delete(active, pack.PeerId())
@@ -185,8 +198,10 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 // stateSync schedules requests for downloading a particular state trie defined
 // by a given state root.
 type stateSync struct {
-	d *Downloader // Downloader instance to access and manage current peerset
+	d    *Downloader // Downloader instance to access and manage current peerset
+	root common.Hash // State root being synced
 
+	snap   *snapState                 // Range download progress if snap syncing (nil otherwise)
 	sched  *state.StateSync           // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
 	tasks  map[common.Hash]*stateTask // Set of tasks currently queued for retrieval
@@ -207,8 +222,14 @@ type stateTask struct {
 // newStateSync creates a new state trie download scheduler. This method does not
 // yet start the sync. The user needs to call run to initiate.
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
+	var snap *snapState
+	if d.mode == SnapSync {
+		snap = newSnapState(root, d.stateDB)
+	}
 	return &stateSync{
 		d:       d,
+		root:    root,
+		snap:    snap,
 		sched:   state.NewStateSync(root, d.stateDB),
 		keccak:  sha3.NewKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
@@ -220,9 +241,17 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 
 // run starts the task assignment and response processing loop, blocking until
 // it finishes, and finally notifying any goroutines waiting for the loop to
-// finish.
+// finish. If snap syncing, the state ranges are downloaded first and the trie
+// sync only heals whatever is still missing afterwards.
 func (s *stateSync) run() {
-	s.err = s.loop()
+	if s.snap != nil {
+		if s.err = s.snapLoop(); s.err == nil {
+			s.sched = state.NewStateSync(s.root, s.d.stateDB)
+		}
+	}
+	if s.err == nil {
+		s.err = s.loop()
+	}
 	close(s.done)
 }
 
@@ -22,6 +22,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/rlp"
 )
 
 // headerCheckFn is a callback type for verifying a header's presence in the local chain.
@@ -118,3 +119,16 @@ type statePack struct {
 func (p *statePack) PeerId() string { return p.peerId }
 func (p *statePack) Items() int     { return len(p.states) }
 func (p *statePack) Stats() string  { return fmt.Sprintf("%d", len(p.states)) }
+
+// rangePack is a batch of consecutive trie entries with their edge proofs
+// returned by a peer for an account or storage range request.
+type rangePack struct {
+	peerId string
+	hashes []common.Hash
+	values [][]byte
+	proof  []rlp.RawValue
+}
+
+func (p *rangePack) PeerId() string { return p.peerId }
+func (p *rangePack) Items() int     { return len(p.hashes) }
+func (p *rangePack) Stats() string  { return fmt.Sprintf("%d:%d", len(p.hashes), len(p.proof)) }
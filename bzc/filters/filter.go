@@ -24,6 +24,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core"
+	"github.com/bazacoin/go-bazacoin/core/bloombits"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/bzcdb"
 	"github.com/bazacoin/go-bazacoin/event"
@@ -37,6 +38,17 @@ type Backend interface {
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
 }
 
+// BloomBitsBackend is implemented by backends able to retrieve the rotated bloom
+// bits of whole chain sections, allowing filters to look only at the headers and
+// receipts of the blocks which may match.
+type BloomBitsBackend interface {
+	BloomStatus() (uint64, uint64)
+	GetBloomBits(ctx context.Context, bit uint, sections []uint64) ([][]byte, error)
+}
+
+// bloomBitsBatch is the number of sections whose bloom bits are retrieved at once.
+const bloomBitsBatch = 16
+
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
 	backend   Backend
@@ -105,6 +117,13 @@ func (f *Filter) FindOnce(ctx context.Context) ([]*types.Log, error) {
 		endBlockNo = headBlockNumber
 	}
 
+	// if the backend serves rotated bloom bits, use them for the indexed sections
+	if backend, ok := f.backend.(BloomBitsBackend); ok && !f.useMipMap {
+		logs, blockNumber, err := f.indexedLogs(ctx, backend, beginBlockNo, endBlockNo)
+		f.begin = int64(blockNumber + 1)
+		return logs, err
+	}
+
 	// if no addresses are present we can't make use of fast search which
 	// uses the mipmap bloom filters to check for fast inclusion and uses
 	// higher range probability in order to ensure at least a false positive
@@ -174,26 +193,119 @@ func (f *Filter) getLogs(ctx context.Context, start, end uint64) (logs []*types.
 			return logs, end, err
 		}
 
-		// Use bloom filtering to see if this block is interesting given the
-		// current parameters
-		if f.bloomFilter(header.Bloom) {
-			// Get the logs of the block
-			receipts, err := f.backend.GetReceipts(ctx, header.Hash())
+		logs, err = f.blockLogs(ctx, header)
+		if err != nil {
+			return nil, end, err
+		}
+		if len(logs) > 0 {
+			return logs, uint64(blockNumber), nil
+		}
+	}
+
+	return logs, end, nil
+}
+
+// indexedLogs returns the logs of the first block in the given range matching
+// the filter criteria. Within the sections indexed by the backend, only blocks
+// whose bloom bits match are looked at; the rest is checked block by block.
+func (f *Filter) indexedLogs(ctx context.Context, backend BloomBitsBackend, start, end uint64) ([]*types.Log, uint64, error) {
+	size, sections := backend.BloomStatus()
+	matcher := bloombits.NewMatcher(size, f.bloomFilters())
+
+	for section := start / size; section < sections && section*size <= end; section += bloomBitsBatch {
+		// Retrieve the needed bloom bits for a batch of sections
+		var batch []uint64
+		for i := section; i < section+bloomBitsBatch && i < sections && i*size <= end; i++ {
+			batch = append(batch, i)
+		}
+		vectors := make([]map[uint][]byte, len(batch))
+		for i := range vectors {
+			vectors[i] = make(map[uint][]byte)
+		}
+		for _, bit := range matcher.Bits() {
+			bits, err := backend.GetBloomBits(ctx, bit, batch)
 			if err != nil {
 				return nil, end, err
 			}
-			var unfiltered []*types.Log
-			for _, receipt := range receipts {
-				unfiltered = append(unfiltered, ([]*types.Log)(receipt.Logs)...)
+			for i := range batch {
+				vectors[i][bit] = bits[i]
+			}
+		}
+		// Check the candidate blocks one by one
+		for i, idx := range batch {
+			matches, err := matcher.Match(idx, vectors[i])
+			if err != nil {
+				return nil, end, err
 			}
-			logs = filterLogs(unfiltered, nil, nil, f.addresses, f.topics)
-			if len(logs) > 0 {
-				return logs, uint64(blockNumber), nil
+			for _, number := range matches {
+				if number < start {
+					continue
+				}
+				if number > end {
+					return nil, end, nil
+				}
+				header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+				if header == nil || err != nil {
+					return nil, end, err
+				}
+				logs, err := f.blockLogs(ctx, header)
+				if err != nil {
+					return nil, end, err
+				}
+				if len(logs) > 0 {
+					return logs, number, nil
+				}
 			}
+			start = (idx + 1) * size
 		}
 	}
+	if start > end {
+		return nil, end, nil
+	}
+	return f.getLogs(ctx, start, end)
+}
 
-	return logs, end, nil
+// blockLogs returns the logs of the given block matching the filter criteria,
+// retrieving its receipts only if the header bloom indicates a possible match.
+func (f *Filter) blockLogs(ctx context.Context, header *types.Header) ([]*types.Log, error) {
+	// Use bloom filtering to see if this block is interesting given the
+	// current parameters
+	if !f.bloomFilter(header.Bloom) {
+		return nil, nil
+	}
+	// Get the logs of the block
+	receipts, err := f.backend.GetReceipts(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	var unfiltered []*types.Log
+	for _, receipt := range receipts {
+		unfiltered = append(unfiltered, ([]*types.Log)(receipt.Logs)...)
+	}
+	return filterLogs(unfiltered, nil, nil, f.addresses, f.topics), nil
+}
+
+// bloomFilters converts the filter criteria into bloom bits matcher groups, with
+// nil entries for the wildcard topics.
+func (f *Filter) bloomFilters() [][][]byte {
+	var filters [][][]byte
+	if len(f.addresses) > 0 {
+		group := make([][]byte, len(f.addresses))
+		for i, address := range f.addresses {
+			group[i] = address.Bytes()
+		}
+		filters = append(filters, group)
+	}
+	for _, topics := range f.topics {
+		group := make([][]byte, len(topics))
+		for i, topic := range topics {
+			if topic != (common.Hash{}) {
+				group[i] = topic.Bytes()
+			}
+		}
+		filters = append(filters, group)
+	}
+	return filters
 }
 
 func includes(addresses []common.Address, a common.Address) bool {
@@ -231,7 +343,7 @@ Logs:
 		}
 
 		for i, topics := range topics {
-			var match bool
+			match := len(topics) == 0 // empty rule set == wildcard
 			for _, topic := range topics {
 				// common.Hash{} is a match all (wildcard)
 				if (topic == common.Hash{}) || log.Topics[i] == topic {
@@ -270,7 +382,7 @@ func bloomFilter(bloom types.Bloom, addresses []common.Address, topics [][]commo
 	}
 
 	for _, sub := range topics {
-		var included bool
+		included := len(sub) == 0 // empty rule set == wildcard
 		for _, topic := range sub {
 			if (topic == common.Hash{}) || types.BloomLookup(bloom, topic) {
 				included = true
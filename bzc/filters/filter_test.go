@@ -25,11 +25,13 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core"
+	"github.com/bazacoin/go-bazacoin/core/bloombits"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/bzcdb"
 	"github.com/bazacoin/go-bazacoin/event"
 	"github.com/bazacoin/go-bazacoin/params"
+	"github.com/bazacoin/go-bazacoin/rpc"
 )
 
 func makeReceipt(addr common.Address) *types.Receipt {
@@ -286,3 +288,115 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// bloomBitsBackend is a test backend serving rotated bloom bits generated on
+// the fly from the headers stored in the database.
+type bloomBitsBackend struct {
+	*testBackend
+	sectionSize uint64
+	sections    uint64
+}
+
+func (b *bloomBitsBackend) BloomStatus() (uint64, uint64) {
+	return b.sectionSize, b.sections
+}
+
+func (b *bloomBitsBackend) GetBloomBits(ctx context.Context, bit uint, sections []uint64) ([][]byte, error) {
+	result := make([][]byte, len(sections))
+	for i, section := range sections {
+		gen, err := bloombits.NewGenerator(uint(b.sectionSize))
+		if err != nil {
+			return nil, err
+		}
+		for j := uint64(0); j < b.sectionSize; j++ {
+			header, err := b.HeaderByNumber(ctx, rpc.BlockNumber(section*b.sectionSize+j))
+			if header == nil || err != nil {
+				return nil, err
+			}
+			if err := gen.AddBloom(uint(j), header.Bloom); err != nil {
+				return nil, err
+			}
+		}
+		if result[i], err = gen.Bitset(bit); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func TestBloomBitsFilter(t *testing.T) {
+	var (
+		db, _   = bzcdb.NewMemDatabase()
+		mux     = new(event.TypeMux)
+		backend = &bloomBitsBackend{&testBackend{mux, db}, 16, 4}
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+	)
+	// Place logs both inside the indexed sections and beyond them
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, db, 100, func(i int, gen *core.BlockGen) {
+		var topic common.Hash
+		switch i {
+		case 4, 40:
+			topic = hash1
+		case 20, 80:
+			topic = hash2
+		default:
+			return
+		}
+		receipt := types.NewReceipt(nil, new(big.Int))
+		receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}, BlockNumber: uint64(i + 1)}}
+		gen.AddUncheckedReceipt(receipt)
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteHeadBlockHash(db, block.Hash()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i]); err != nil {
+			t.Fatal("error writing block receipts:", err)
+		}
+	}
+
+	tests := []struct {
+		begin, end int64
+		addresses  []common.Address
+		topics     [][]common.Hash
+		want       []uint64
+	}{
+		{0, -1, []common.Address{addr}, nil, []uint64{5, 21, 41, 81}},
+		{0, -1, nil, [][]common.Hash{{hash1}}, []uint64{5, 41}},
+		{0, -1, []common.Address{addr}, [][]common.Hash{{hash2}}, []uint64{21, 81}},
+		{0, -1, []common.Address{addr}, [][]common.Hash{{}}, []uint64{5, 21, 41, 81}},
+		{6, 60, nil, [][]common.Hash{{hash1, hash2}}, []uint64{21, 41}},
+		{22, 40, nil, [][]common.Hash{{hash2}}, nil},
+		{0, -1, nil, [][]common.Hash{{common.BytesToHash([]byte("fail"))}}, nil},
+	}
+	for i, tt := range tests {
+		filter := New(backend, false)
+		filter.SetAddresses(tt.addresses)
+		filter.SetTopics(tt.topics)
+		filter.SetBeginBlock(tt.begin)
+		filter.SetEndBlock(tt.end)
+
+		logs, err := filter.Find(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: failed to filter logs: %v", i, err)
+		}
+		if len(logs) != len(tt.want) {
+			t.Errorf("test %d: log count mismatch: have %d, want %d", i, len(logs), len(tt.want))
+			continue
+		}
+		for j, log := range logs {
+			if log.BlockNumber != tt.want[j] {
+				t.Errorf("test %d, log %d: block number mismatch: have %d, want %d", i, j, log.BlockNumber, tt.want[j])
+			}
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/core"
 	"github.com/bazacoin/go-bazacoin/bzc/downloader"
 	"github.com/bazacoin/go-bazacoin/bzc/gasprice"
+	"github.com/bazacoin/go-bazacoin/params"
 )
 
 func (c Config) MarshalTOML() (interface{}, error) {
@@ -17,11 +18,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		MaxPeers                int  `toml:"-"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
+		LightServ               int                            `toml:",omitempty"`
+		LightPeers              int                            `toml:",omitempty"`
+		MaxPeers                int                            `toml:"-"`
+		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
+		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
+		SkipBcVersionCheck      bool                           `toml:"-"`
+		DatabaseHandles         int                            `toml:"-"`
 		DatabaseCache           int
 		Bazacoinbase               common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
@@ -36,6 +39,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TxPool                  core.TxPoolConfig
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
+		EVMInterpreter          string
 		DocRoot                 string `toml:"-"`
 		PowFake                 bool   `toml:"-"`
 		PowTest                 bool   `toml:"-"`
@@ -48,6 +52,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
 	enc.MaxPeers = c.MaxPeers
+	enc.Checkpoint = c.Checkpoint
+	enc.CheckpointOracle = c.CheckpointOracle
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
@@ -64,6 +70,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.EVMInterpreter = c.EVMInterpreter
 	enc.DocRoot = c.DocRoot
 	enc.PowFake = c.PowFake
 	enc.PowTest = c.PowTest
@@ -76,11 +83,13 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		MaxPeers                *int  `toml:"-"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
+		LightServ               *int                           `toml:",omitempty"`
+		LightPeers              *int                           `toml:",omitempty"`
+		MaxPeers                *int                           `toml:"-"`
+		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
+		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
+		SkipBcVersionCheck      *bool                          `toml:"-"`
+		DatabaseHandles         *int                           `toml:"-"`
 		DatabaseCache           *int
 		Bazacoinbase               *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
@@ -95,6 +104,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TxPool                  *core.TxPoolConfig
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
+		EVMInterpreter          *string
 		DocRoot                 *string `toml:"-"`
 		PowFake                 *bool   `toml:"-"`
 		PowTest                 *bool   `toml:"-"`
@@ -122,6 +132,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.MaxPeers != nil {
 		c.MaxPeers = *dec.MaxPeers
 	}
+	if dec.Checkpoint != nil {
+		c.Checkpoint = dec.Checkpoint
+	}
+	if dec.CheckpointOracle != nil {
+		c.CheckpointOracle = dec.CheckpointOracle
+	}
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
@@ -170,6 +186,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.EVMInterpreter != nil {
+		c.EVMInterpreter = *dec.EVMInterpreter
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
@@ -30,6 +30,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/consensus"
 	"github.com/bazacoin/go-bazacoin/consensus/misc"
 	"github.com/bazacoin/go-bazacoin/core"
+	"github.com/bazacoin/go-bazacoin/core/forkid"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/bzc/downloader"
 	"github.com/bazacoin/go-bazacoin/bzc/fetcher"
@@ -48,7 +49,13 @@ const (
 )
 
 var (
-	daoChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the DAO handshake challenge
+	daoChallengeTimeout        = 15 * time.Second // Time allowance for a node to reply to the DAO handshake challenge
+	checkpointChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the checkpoint handshake challenge
+)
+
+var (
+	errCheckpointMismatch = errors.New("checkpoint header mismatch")
+	errCheckpointMissing  = errors.New("checkpoint header missing")
 )
 
 // errIncompatibleConfig is returned if the requested protocols and configs are
@@ -63,14 +70,19 @@ type ProtocolManager struct {
 	networkId uint64
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whether fast sync should retrieve the state via the snap protocol
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	txpool      txPool
 	blockchain  *core.BlockChain
 	chaindb     bzcdb.Database
 	chainconfig *params.ChainConfig
+	forkFilter  forkid.Filter // Fork ID filter, constant across the lifetime of the node
 	maxPeers    int
 
+	checkpointNumber uint64      // Block number of the trusted sync checkpoint head
+	checkpointHash   common.Hash // Block hash of the trusted sync checkpoint head (empty = no challenge)
+
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
 	peers      *peerSet
@@ -94,7 +106,7 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new bazacoin sub protocol manager. The Bazacoin sub protocol manages peers capable
 // with the bazacoin network.
-func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, networkId uint64, maxPeers int, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb bzcdb.Database) (*ProtocolManager, error) {
+func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCheckpoint, mode downloader.SyncMode, networkId uint64, maxPeers int, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb bzcdb.Database) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkId:   networkId,
@@ -103,6 +115,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		blockchain:  blockchain,
 		chaindb:     chaindb,
 		chainconfig: config,
+		forkFilter:  forkid.NewFilter(blockchain),
 		maxPeers:    maxPeers,
 		peers:       newPeerSet(),
 		newPeerCh:   make(chan *peer),
@@ -111,18 +124,26 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		quitSync:    make(chan struct{}),
 	}
 	// Figure out whether to allow fast sync or not
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
+	if (mode == downloader.FastSync || mode == downloader.SnapSync) && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
 		mode = downloader.FullSync
 	}
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		manager.fastSync = uint32(1)
 	}
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
+	// If we have a trusted checkpoint with a known head, enforce it on fast sync peers
+	if checkpoint != nil && checkpoint.SectionHead != (common.Hash{}) && atomic.LoadUint32(&manager.fastSync) == 1 {
+		manager.checkpointNumber = checkpoint.HeadNumber()
+		manager.checkpointHash = checkpoint.SectionHead
+	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
 		// Skip protocol version if incompatible with the mode of operation
-		if mode == downloader.FastSync && version < eth63 {
+		if (mode == downloader.FastSync || mode == downloader.SnapSync) && version < eth63 {
 			continue
 		}
 		// Compatible; initialise the sub-protocol
@@ -156,6 +177,8 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
+	manager.SubProtocols = append(manager.SubProtocols, manager.makeSnapProtocols()...)
+
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain.HasHeader, blockchain.HasBlockAndState, blockchain.GetHeaderByHash,
 		blockchain.GetBlockByHash, blockchain.CurrentHeader, blockchain.CurrentBlock, blockchain.CurrentFastBlock, blockchain.FastSyncCommitHead,
@@ -253,7 +276,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 
 	// Execute the Bazacoin handshake
 	td, head, genesis := pm.blockchain.Status()
-	if err := p.Handshake(pm.networkId, td, head, genesis); err != nil {
+	if err := p.Handshake(pm.networkId, td, head, genesis, forkid.NewID(pm.blockchain), pm.forkFilter); err != nil {
 		p.Log().Debug("Bazacoin handshake failed", "err", err)
 		return err
 	}
@@ -294,6 +317,25 @@ func (pm *ProtocolManager) handle(p *peer) error {
 			}
 		}()
 	}
+	// If we have a trusted checkpoint, make sure the peer is on the same chain
+	if pm.checkpointHash != (common.Hash{}) {
+		// Request the peer's checkpoint header for chain validation
+		if err := p.RequestHeadersByNumber(pm.checkpointNumber, 1, 0, false); err != nil {
+			return err
+		}
+		// Start a timer to disconnect if the peer doesn't reply in time
+		p.syncDrop = time.AfterFunc(checkpointChallengeTimeout, func() {
+			p.Log().Debug("Timed out checkpoint challenge, dropping")
+			pm.removePeer(p.id)
+		})
+		// Make sure it's cleaned up if the peer dies off
+		defer func() {
+			if p.syncDrop != nil {
+				p.syncDrop.Stop()
+				p.syncDrop = nil
+			}
+		}()
+	}
 	// main loop. handle incoming messages.
 	for {
 		if err := pm.handleMsg(p); err != nil {
@@ -427,6 +469,18 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				return nil
 			}
 		}
+		// If no headers were received and the DAO check is done, it's the reply to the
+		// checkpoint challenge: the peer doesn't have it, so it's useless for fast sync
+		if len(headers) == 0 && p.forkDrop == nil && p.syncDrop != nil {
+			p.syncDrop.Stop()
+			p.syncDrop = nil
+
+			if atomic.LoadUint32(&pm.fastSync) == 1 {
+				p.Log().Debug("Checkpoint challenge failed, dropping", "number", pm.checkpointNumber)
+				return errCheckpointMissing
+			}
+			return nil
+		}
 		// Filter out any explicitly requested headers, deliver the rest to the downloader
 		filter := len(headers) == 1
 		if filter {
@@ -444,6 +498,20 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				p.Log().Debug("Verified to be on the same side of the DAO fork")
 				return nil
 			}
+			// If it's the checkpoint challenge, validate the header against the checkpoint
+			if p.syncDrop != nil && headers[0].Number.Uint64() == pm.checkpointNumber {
+				// Disable the sync drop timer
+				p.syncDrop.Stop()
+				p.syncDrop = nil
+
+				// Validate the header and either drop the peer or continue
+				if headers[0].Hash() != pm.checkpointHash {
+					p.Log().Debug("Checkpoint challenge failed, dropping", "number", pm.checkpointNumber, "hash", headers[0].Hash())
+					return errCheckpointMismatch
+				}
+				p.Log().Debug("Verified to be on the same chain as the checkpoint")
+				return nil
+			}
 			// Irrelevant of the fork checks, send the header to the fetcher just in case
 			headers = pm.fetcher.FilterHeaders(headers, time.Now())
 		}
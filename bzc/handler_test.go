@@ -476,7 +476,7 @@ func testDAOChallenge(t *testing.T, localForked, remoteForked bool, timeout bool
 		genesis       = gspec.MustCommit(db)
 		blockchain, _ = core.NewBlockChain(db, config, pow, evmux, vm.Config{})
 	)
-	pm, err := NewProtocolManager(config, downloader.FullSync, DefaultConfig.NetworkId, 1000, evmux, new(testTxPool), pow, blockchain, db)
+	pm, err := NewProtocolManager(config, nil, downloader.FullSync, DefaultConfig.NetworkId, 1000, evmux, new(testTxPool), pow, blockchain, db)
 	if err != nil {
 		t.Fatalf("failed to start test protocol manager: %v", err)
 	}
@@ -522,3 +522,77 @@ func testDAOChallenge(t *testing.T, localForked, remoteForked bool, timeout bool
 		}
 	}
 }
+
+// Tests that fast syncing nodes challenge their peers with the trusted checkpoint
+// and drop the ones which reply with a different header, no header or not at all.
+func TestCheckpointChallengeMatch(t *testing.T)    { testCheckpointChallenge(t, true, false, false) }
+func TestCheckpointChallengeMismatch(t *testing.T) { testCheckpointChallenge(t, false, false, false) }
+func TestCheckpointChallengeEmpty(t *testing.T)    { testCheckpointChallenge(t, false, true, false) }
+func TestCheckpointChallengeTimeout(t *testing.T)  { testCheckpointChallenge(t, false, false, true) }
+
+func testCheckpointChallenge(t *testing.T, match bool, empty bool, timeout bool) {
+	// Reduce the checkpoint handshake challenge timeout
+	if timeout {
+		defer func(old time.Duration) { checkpointChallengeTimeout = old }(checkpointChallengeTimeout)
+		checkpointChallengeTimeout = 500 * time.Millisecond
+	}
+	// Create a checkpoint aware fast syncing protocol manager
+	var (
+		evmux         = new(event.TypeMux)
+		pow           = bzhash.NewFaker()
+		db, _         = bzcdb.NewMemDatabase()
+		config        = &params.ChainConfig{}
+		gspec         = &core.Genesis{Config: config}
+		_             = gspec.MustCommit(db)
+		blockchain, _ = core.NewBlockChain(db, config, pow, evmux, vm.Config{})
+		header        = &types.Header{Number: big.NewInt(params.CHTFrequency - 1), Difficulty: big.NewInt(1)}
+		checkpoint    = &params.TrustedCheckpoint{SectionIndex: 0, SectionHead: header.Hash(), CHTRoot: common.HexToHash("0x01")}
+	)
+	if !match {
+		checkpoint.SectionHead = common.HexToHash("0x02")
+	}
+	pm, err := NewProtocolManager(config, checkpoint, downloader.FastSync, DefaultConfig.NetworkId, 1000, evmux, new(testTxPool), pow, blockchain, db)
+	if err != nil {
+		t.Fatalf("failed to start test protocol manager: %v", err)
+	}
+	pm.Start()
+	defer pm.Stop()
+
+	// Connect a new peer and check that we receive the checkpoint challenge
+	peer, _ := newTestPeer("peer", eth63, pm, true)
+	defer peer.close()
+
+	challenge := &getBlockHeadersData{
+		Origin:  hashOrNumber{Number: checkpoint.HeadNumber()},
+		Amount:  1,
+		Skip:    0,
+		Reverse: false,
+	}
+	if err := p2p.ExpectMsg(peer.app, GetBlockHeadersMsg, challenge); err != nil {
+		t.Fatalf("challenge mismatch: %v", err)
+	}
+	// Reply to the challenge if no timeout is simulated
+	if !timeout {
+		var headers []*types.Header
+		if !empty {
+			headers = append(headers, header)
+		}
+		if err := p2p.Send(peer.app, BlockHeadersMsg, headers); err != nil {
+			t.Fatalf("failed to answer challenge: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond) // Sleep to avoid the verification racing with the drops
+	} else {
+		// Otherwise wait until the test timeout passes
+		time.Sleep(checkpointChallengeTimeout + 500*time.Millisecond)
+	}
+	// Verify that depending on the reply, the remote peer is maintained or dropped
+	if match && !empty && !timeout {
+		if peers := pm.peers.Len(); peers != 1 {
+			t.Fatalf("peer count mismatch: have %d, want %d", peers, 1)
+		}
+	} else {
+		if peers := pm.peers.Len(); peers != 0 {
+			t.Fatalf("peer count mismatch: have %d, want %d", peers, 0)
+		}
+	}
+}
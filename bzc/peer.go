@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/forkid"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/p2p"
 	"github.com/bazacoin/go-bazacoin/rlp"
@@ -54,10 +55,12 @@ type peer struct {
 	id string
 
 	*p2p.Peer
-	rw p2p.MsgReadWriter
+	rw   p2p.MsgReadWriter
+	snap p2p.MsgReadWriter // Satellite snap protocol connection (nil if not running)
 
 	version  int         // Protocol version negotiated
 	forkDrop *time.Timer // Timed connection dropper if forks aren't validated in time
+	syncDrop *time.Timer // Timed connection dropper if the sync checkpoint isn't validated in time
 
 	head common.Hash
 	td   *big.Int
@@ -228,14 +231,64 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	return p2p.Send(p.rw, GetReceiptsMsg, hashes)
 }
 
+// setSnap attaches (or detaches with nil) the snap protocol connection.
+func (p *peer) setSnap(rw p2p.MsgReadWriter) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.snap = rw
+}
+
+// RequestAccountRange fetches a range of consecutive accounts from the state
+// trie with the given root over the snap protocol.
+func (p *peer) RequestAccountRange(root common.Hash, origin common.Hash, bytes uint64) error {
+	p.lock.RLock()
+	rw := p.snap
+	p.lock.RUnlock()
+
+	if rw == nil {
+		return errSnapWithoutBzc
+	}
+	p.Log().Debug("Fetching account range", "root", root, "origin", origin, "bytes", bytes)
+	return p2p.Send(rw, GetAccountRangeMsg, &getAccountRangeData{Root: root, Origin: origin, Bytes: bytes})
+}
+
+// RequestStorageRange fetches a range of consecutive storage slots of an account
+// in the state trie with the given root over the snap protocol.
+func (p *peer) RequestStorageRange(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error {
+	p.lock.RLock()
+	rw := p.snap
+	p.lock.RUnlock()
+
+	if rw == nil {
+		return errSnapWithoutBzc
+	}
+	p.Log().Debug("Fetching storage range", "root", root, "account", account, "origin", origin, "bytes", bytes)
+	return p2p.Send(rw, GetStorageRangeMsg, &getStorageRangeData{Root: root, Account: account, Origin: origin, Bytes: bytes})
+}
+
 // Handshake executes the bzc protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash) error {
+// network IDs, difficulties, head and genesis blocks. Since bzc/64 the fork
+// identifiers are exchanged too and the remote one is validated by forkFilter.
+func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID, forkFilter forkid.Filter) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
-	var status statusData // safe to read after two values have been received from errc
-
+	var (
+		status   statusData   // safe to read after two values have been received from errc
+		status64 statusData64 // safe to read after two values have been received from errc
+	)
 	go func() {
+		if p.version >= eth64 {
+			errc <- p2p.Send(p.rw, StatusMsg, &statusData64{
+				ProtocolVersion: uint32(p.version),
+				NetworkId:       network,
+				TD:              td,
+				CurrentBlock:    head,
+				GenesisBlock:    genesis,
+				ForkID:          forkID,
+			})
+			return
+		}
 		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
 			ProtocolVersion: uint32(p.version),
 			NetworkId:       network,
@@ -245,6 +298,10 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		})
 	}()
 	go func() {
+		if p.version >= eth64 {
+			errc <- p.readStatus64(network, &status64, genesis, forkFilter)
+			return
+		}
 		errc <- p.readStatus(network, &status, genesis)
 	}()
 	timeout := time.NewTimer(handshakeTimeout)
@@ -259,7 +316,11 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 			return p2p.DiscReadTimeout
 		}
 	}
-	p.td, p.head = status.TD, status.CurrentBlock
+	if p.version >= eth64 {
+		p.td, p.head = status64.TD, status64.CurrentBlock
+	} else {
+		p.td, p.head = status.TD, status.CurrentBlock
+	}
 	return nil
 }
 
@@ -290,6 +351,36 @@ func (p *peer) readStatus(network uint64, status *statusData, genesis common.Has
 	return nil
 }
 
+func (p *peer) readStatus64(network uint64, status *statusData64, genesis common.Hash, forkFilter forkid.Filter) (err error) {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Code != StatusMsg {
+		return errResp(ErrNoStatusMsg, "first msg has code %x (!= %x)", msg.Code, StatusMsg)
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	// Decode the handshake and make sure everything matches
+	if err := msg.Decode(&status); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	if status.GenesisBlock != genesis {
+		return errResp(ErrGenesisBlockMismatch, "%x (!= %x)", status.GenesisBlock[:8], genesis[:8])
+	}
+	if status.NetworkId != network {
+		return errResp(ErrNetworkIdMismatch, "%d (!= %d)", status.NetworkId, network)
+	}
+	if int(status.ProtocolVersion) != p.version {
+		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
+	}
+	if err := forkFilter(status.ForkID); err != nil {
+		return errResp(ErrForkIDRejected, "%v", err)
+	}
+	return nil
+}
+
 // String implements fmt.Stringer.
 func (p *peer) String() string {
 	return fmt.Sprintf("Peer %s [%s]", p.id,
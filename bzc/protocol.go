@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/forkid"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/rlp"
 )
@@ -30,16 +31,17 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+	eth64 = 64
 )
 
 // Official short name of the protocol used during capability negotiation.
 var ProtocolName = "bzc"
 
 // Supported versions of the bzc protocol (first is primary).
-var ProtocolVersions = []uint{eth63, eth62}
+var ProtocolVersions = []uint{eth64, eth63, eth62}
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{17, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -74,6 +76,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -91,6 +94,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 type txPool interface {
@@ -111,6 +115,17 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// statusData64 is the network packet for the status message since bzc/64.
+// It extends the legacy status with the fork identifier of the local chain.
+type statusData64 struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	ForkID          forkid.ID
+}
+
 // newBlockHashesData is the network packet for the block announcements.
 type newBlockHashesData []struct {
 	Hash   common.Hash // Hash of one particular block being announced
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/forkid"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/bzc/downloader"
@@ -88,9 +89,65 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that bzc/64 handshake failures, including fork ID rejections, are
+// detected and reported correctly.
+func TestStatusMsgErrors64(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	td, currentBlock, genesis := pm.blockchain.Status()
+	forkID := forkid.NewID(pm.blockchain)
+	defer pm.Stop()
+
+	tests := []struct {
+		code      uint64
+		data      interface{}
+		wantError error
+	}{
+		{
+			code: TxMsg, data: []interface{}{},
+			wantError: errResp(ErrNoStatusMsg, "first msg has code 2 (!= 0)"),
+		},
+		{
+			code: StatusMsg, data: statusData64{10, DefaultConfig.NetworkId, td, currentBlock, genesis, forkID},
+			wantError: errResp(ErrProtocolVersionMismatch, "10 (!= %d)", eth64),
+		},
+		{
+			code: StatusMsg, data: statusData64{eth64, 999, td, currentBlock, genesis, forkID},
+			wantError: errResp(ErrNetworkIdMismatch, "999 (!= 1)"),
+		},
+		{
+			code: StatusMsg, data: statusData64{eth64, DefaultConfig.NetworkId, td, currentBlock, common.Hash{3}, forkID},
+			wantError: errResp(ErrGenesisBlockMismatch, "0300000000000000 (!= %x)", genesis[:8]),
+		},
+		{
+			code: StatusMsg, data: statusData64{eth64, DefaultConfig.NetworkId, td, currentBlock, genesis, forkid.ID{Hash: [4]byte{0x00, 0x01, 0x02, 0x03}}},
+			wantError: errResp(ErrForkIDRejected, "%v", forkid.ErrLocalIncompatibleOrStale),
+		},
+	}
+
+	for i, test := range tests {
+		p, errc := newTestPeer("peer", eth64, pm, false)
+		// The send call might hang until reset because
+		// the protocol might not read the payload.
+		go p2p.Send(p.app, test.code, test.data)
+
+		select {
+		case err := <-errc:
+			if err == nil {
+				t.Errorf("test %d: protocol returned nil error, want %q", i, test.wantError)
+			} else if err.Error() != test.wantError.Error() {
+				t.Errorf("test %d: wrong error: got %q, want %q", i, err, test.wantError)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("protocol did not shut down within 2 seconds")
+		}
+		p.close()
+	}
+}
+
 // This test checks that received transactions are added to the local pool.
 func TestRecvTransactions62(t *testing.T) { testRecvTransactions(t, 62) }
 func TestRecvTransactions63(t *testing.T) { testRecvTransactions(t, 63) }
+func TestRecvTransactions64(t *testing.T) { testRecvTransactions(t, 64) }
 
 func testRecvTransactions(t *testing.T, protocol int) {
 	txAdded := make(chan []*types.Transaction)
@@ -119,6 +176,7 @@ func testRecvTransactions(t *testing.T, protocol int) {
 // This test checks that pending transactions are sent.
 func TestSendTransactions62(t *testing.T) { testSendTransactions(t, 62) }
 func TestSendTransactions63(t *testing.T) { testSendTransactions(t, 63) }
+func TestSendTransactions64(t *testing.T) { testSendTransactions(t, 64) }
 
 func testSendTransactions(t *testing.T, protocol int) {
 	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
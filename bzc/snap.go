@@ -0,0 +1,281 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bzc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/state"
+	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/p2p"
+	"github.com/bazacoin/go-bazacoin/rlp"
+	"github.com/bazacoin/go-bazacoin/trie"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	snap1 = 1
+)
+
+// SnapProtocolName is the official short name of the snap protocol. It runs as
+// a satellite of bzc, serving contiguous ranges of the state for snap syncing.
+var SnapProtocolName = "snap"
+
+// Supported versions of the snap protocol (first is primary).
+var SnapProtocolVersions = []uint{snap1}
+
+// Number of implemented message corresponding to different protocol versions.
+var SnapProtocolLengths = []uint64{4}
+
+// snap protocol message codes
+const (
+	GetAccountRangeMsg = 0x00
+	AccountRangeMsg    = 0x01
+	GetStorageRangeMsg = 0x02
+	StorageRangeMsg    = 0x03
+)
+
+const (
+	maxRangeEntries  = 4096            // Maximum number of trie entries to serve in a single range
+	snapAttachPeriod = 5 * time.Second // Maximum time to wait for the bzc handshake of a snap peer
+)
+
+// errSnapWithoutBzc is returned if a snap connection isn't accompanied by bzc.
+var errSnapWithoutBzc = errors.New("snap peer without bzc connection")
+
+// getAccountRangeData represents an account range query.
+type getAccountRangeData struct {
+	Root   common.Hash // State root to retrieve the accounts from
+	Origin common.Hash // Hash of the first account to retrieve
+	Bytes  uint64      // Soft limit on the response size
+}
+
+// getStorageRangeData represents a storage range query.
+type getStorageRangeData struct {
+	Root    common.Hash // State root to retrieve the account from
+	Account common.Hash // Hash of the account to retrieve the storage of
+	Origin  common.Hash // Hash of the first storage slot to retrieve
+	Bytes   uint64      // Soft limit on the response size
+}
+
+// rangeData is the network packet for account and storage range responses. The
+// proof contains the merkle proofs of the origin and of the last entry, or is
+// empty if the entries make up the entire trie.
+type rangeData struct {
+	Entries []rangeEntry
+	Proof   []rlp.RawValue
+}
+
+// rangeEntry is a single trie leaf within a range response.
+type rangeEntry struct {
+	Hash  common.Hash // Hashed key of the leaf
+	Value []byte      // RLP encoded account or storage slot
+}
+
+// split returns the hashes and values of a range response separately.
+func (r *rangeData) split() ([]common.Hash, [][]byte) {
+	hashes := make([]common.Hash, len(r.Entries))
+	values := make([][]byte, len(r.Entries))
+	for i, entry := range r.Entries {
+		hashes[i], values[i] = entry.Hash, entry.Value
+	}
+	return hashes, values
+}
+
+// makeSnapProtocols creates the satellite snap protocols served alongside bzc.
+func (pm *ProtocolManager) makeSnapProtocols() []p2p.Protocol {
+	protos := make([]p2p.Protocol, len(SnapProtocolVersions))
+	for i, version := range SnapProtocolVersions {
+		protos[i] = p2p.Protocol{
+			Name:    SnapProtocolName,
+			Version: version,
+			Length:  SnapProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				pm.wg.Add(1)
+				defer pm.wg.Done()
+				return pm.handleSnap(p, rw)
+			},
+		}
+	}
+	return protos
+}
+
+// handleSnap is the callback invoked to manage the life cycle of a snap peer.
+// The connection is attached to the bzc peer of the same node, so it is only
+// accepted once the bzc handshake completed.
+func (pm *ProtocolManager) handleSnap(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	peer, err := pm.attachSnap(p, rw)
+	if err != nil {
+		p.Log().Debug("Snap peer rejected", "err", err)
+		return err
+	}
+	p.Log().Debug("Snap peer connected")
+	defer func() {
+		pm.downloader.UnregisterSnapPeer(peer.id)
+		peer.setSnap(nil)
+	}()
+	for {
+		if err := pm.handleSnapMsg(peer, rw); err != nil {
+			p.Log().Debug("Snap message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// attachSnap waits for the bzc peer belonging to a snap connection to finish
+// its handshake and registers the snap connection with it and the downloader.
+func (pm *ProtocolManager) attachSnap(p *p2p.Peer, rw p2p.MsgReadWriter) (*peer, error) {
+	id := p.ID()
+	key := fmt.Sprintf("%x", id[:8])
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.NewTimer(snapAttachPeriod)
+	defer timeout.Stop()
+
+	for {
+		if peer := pm.peers.Peer(key); peer != nil {
+			peer.setSnap(rw)
+			if err := pm.downloader.RegisterSnapPeer(key, peer.RequestAccountRange, peer.RequestStorageRange); err == nil {
+				return peer, nil
+			}
+			peer.setSnap(nil)
+		}
+		select {
+		case <-ticker.C:
+		case <-timeout.C:
+			return nil, errSnapWithoutBzc
+		case <-pm.quitSync:
+			return nil, p2p.DiscQuitting
+		}
+	}
+}
+
+// handleSnapMsg is invoked whenever an inbound message is received from a remote
+// snap peer. The remote connection is torn down upon returning any error.
+func (pm *ProtocolManager) handleSnapMsg(p *peer, rw p2p.MsgReadWriter) error {
+	// Read the next message from the remote peer, and ensure it's fully consumed
+	msg, err := rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	// Handle the message depending on its contents
+	switch msg.Code {
+	case GetAccountRangeMsg:
+		var query getAccountRangeData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p2p.Send(rw, AccountRangeMsg, serveRange(pm.chaindb, query.Root, query.Origin, query.Bytes))
+
+	case AccountRangeMsg:
+		var res rangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		hashes, accounts := res.split()
+		if err := pm.downloader.DeliverAccountRange(p.id, hashes, accounts, res.Proof); err != nil {
+			log.Debug("Failed to deliver account range", "err", err)
+		}
+
+	case GetStorageRangeMsg:
+		var query getStorageRangeData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p2p.Send(rw, StorageRangeMsg, pm.serveStorageRange(&query))
+
+	case StorageRangeMsg:
+		var res rangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		hashes, slots := res.split()
+		if err := pm.downloader.DeliverStorageRange(p.id, hashes, slots, res.Proof); err != nil {
+			log.Debug("Failed to deliver storage range", "err", err)
+		}
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+	return nil
+}
+
+// serveStorageRange looks up the storage root of the requested account and
+// serves a range of its storage trie.
+func (pm *ProtocolManager) serveStorageRange(query *getStorageRangeData) *rangeData {
+	tr, err := trie.New(query.Root, pm.chaindb)
+	if err != nil {
+		return new(rangeData)
+	}
+	blob, err := tr.TryGet(query.Account[:])
+	if err != nil || blob == nil {
+		return new(rangeData)
+	}
+	var account state.Account
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		return new(rangeData)
+	}
+	return serveRange(pm.chaindb, account.Root, query.Origin, query.Bytes)
+}
+
+// serveRange collects the consecutive entries of the trie with the given root,
+// starting at origin, until the size limit is reached. Unless the whole trie
+// fits into the response, the edges of the range are proven. An empty response
+// without proofs is returned if the trie is not available.
+func serveRange(db trie.Database, root common.Hash, origin common.Hash, limit uint64) *rangeData {
+	tr, err := trie.New(root, db)
+	if err != nil {
+		return new(rangeData)
+	}
+	if limit > softResponseLimit {
+		limit = softResponseLimit
+	}
+	var (
+		res  = new(rangeData)
+		size uint64
+		more bool
+	)
+	it := trie.NewIterator(tr.NodeIterator(origin[:]))
+	for it.Next() {
+		if size >= limit || len(res.Entries) >= maxRangeEntries {
+			more = true
+			break
+		}
+		res.Entries = append(res.Entries, rangeEntry{common.BytesToHash(it.Key), common.CopyBytes(it.Value)})
+		size += uint64(common.HashLength + len(it.Value))
+	}
+	if it.Err != nil {
+		return new(rangeData)
+	}
+	if origin == (common.Hash{}) && !more {
+		return res
+	}
+	res.Proof = tr.Prove(origin[:])
+	if n := len(res.Entries); n > 0 {
+		res.Proof = append(res.Proof, tr.Prove(res.Entries[n-1].Hash[:])...)
+	}
+	return res
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bzc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/bzc/downloader"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/p2p"
+	"github.com/bazacoin/go-bazacoin/trie"
+)
+
+// Tests that the account trie can be retrieved in proven consecutive ranges
+// over the snap protocol.
+func TestSnapAccountRange(t *testing.T) {
+	// Create a chain with a decent number of accounts
+	signer := types.HomesteadSigner{}
+	generator := func(i int, block *core.BlockGen) {
+		for j := 0; j < 10; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{byte(i), byte(j)}, big.NewInt(1000), bigTxGas, nil, nil), signer, testBankKey)
+			block.AddTx(tx)
+		}
+	}
+	pm := newTestProtocolManagerMust(t, downloader.FullSync, 4, generator, nil)
+	peer, _ := newTestPeer("peer", eth64, pm, true)
+	defer peer.close()
+
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	go pm.handleSnap(peer.Peer, net)
+
+	// Count the accounts in the head state
+	root := pm.blockchain.CurrentBlock().Root()
+	tr, _ := trie.New(root, pm.chaindb)
+	want := 0
+	for it := trie.NewIterator(tr.NodeIterator(nil)); it.Next(); {
+		want++
+	}
+	// Retrieve the whole account trie in small ranges, verifying each
+	var (
+		origin common.Hash
+		have   int
+	)
+	for {
+		if err := p2p.Send(app, GetAccountRangeMsg, &getAccountRangeData{Root: root, Origin: origin, Bytes: 500}); err != nil {
+			t.Fatalf("failed to send range request: %v", err)
+		}
+		msg, err := app.ReadMsg()
+		if err != nil {
+			t.Fatalf("failed to read range response: %v", err)
+		}
+		if msg.Code != AccountRangeMsg {
+			t.Fatalf("response packet code mismatch: have %x, want %x", msg.Code, AccountRangeMsg)
+		}
+		var res rangeData
+		if err := msg.Decode(&res); err != nil {
+			t.Fatalf("failed to decode range response: %v", err)
+		}
+		hashes, values := res.split()
+		keys := make([][]byte, len(hashes))
+		for i := range hashes {
+			keys[i] = hashes[i][:]
+		}
+		if err := trie.VerifyRangeProof(root, origin[:], keys, values, res.Proof); err != nil {
+			t.Fatalf("range at %x failed to verify: %v", origin, err)
+		}
+		if len(hashes) == 0 {
+			break
+		}
+		have += len(hashes)
+
+		last := hashes[len(hashes)-1].Big()
+		origin = common.BigToHash(last.Add(last, common.Big1))
+	}
+	if have != want {
+		t.Fatalf("account count mismatch: have %d, want %d", have, want)
+	}
+}
+
+// Tests that requests for unavailable state are answered with empty ranges.
+func TestSnapMissingState(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	peer, _ := newTestPeer("peer", eth64, pm, true)
+	defer peer.close()
+
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	go pm.handleSnap(peer.Peer, net)
+
+	p2p.Send(app, GetAccountRangeMsg, &getAccountRangeData{Root: common.Hash{1}, Bytes: 500})
+	if err := p2p.ExpectMsg(app, AccountRangeMsg, &rangeData{}); err != nil {
+		t.Fatalf("account range mismatch: %v", err)
+	}
+	p2p.Send(app, GetStorageRangeMsg, &getStorageRangeData{Root: pm.blockchain.CurrentBlock().Root(), Account: common.Hash{1}, Bytes: 500})
+	if err := p2p.ExpectMsg(app, StorageRangeMsg, &rangeData{}); err != nil {
+		t.Fatalf("storage range mismatch: %v", err)
+	}
+}
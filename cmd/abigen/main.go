@@ -33,9 +33,13 @@ var (
 	binFlag = flag.String("bin", "", "Path to the Bazacoin contract bytecode (generate deploy method)")
 	typFlag = flag.String("type", "", "Struct name for the binding (default = package name)")
 
-	solFlag  = flag.String("sol", "", "Path to the Bazacoin contract Solidity source to build and bind")
-	solcFlag = flag.String("solc", "solc", "Solidity compiler to use if source builds are requested")
-	excFlag  = flag.String("exc", "", "Comma separated types to exclude from binding")
+	solFlag     = flag.String("sol", "", "Path to the Bazacoin contract Solidity source to build and bind")
+	solcFlag    = flag.String("solc", "solc", "Solidity compiler to use if source builds are requested")
+	solcDirFlag = flag.String("solcdir", "", "Directory of solc binaries to pick the compiler from by version pragma (overrides --solc)")
+	remapFlag   = flag.String("remap", "", "Comma separated import remappings (prefix=path) for source builds")
+	runsFlag    = flag.Int("optimize-runs", 200, "Optimizer runs for source builds (0 = optimizer disabled)")
+	jsonFlag    = flag.String("stdjson", "", "Path to the solc standard JSON output of already built contracts to bind")
+	excFlag     = flag.String("exc", "", "Comma separated types to exclude from binding")
 
 	pkgFlag  = flag.String("pkg", "", "Package name to generate the binding into")
 	outFlag  = flag.String("out", "", "Output file for the generated binding (default = stdout)")
@@ -46,11 +50,14 @@ func main() {
 	// Parse and ensure all needed inputs are specified
 	flag.Parse()
 
-	if *abiFlag == "" && *solFlag == "" {
-		fmt.Printf("No contract ABI (--abi) or Solidity source (--sol) specified\n")
+	if *abiFlag == "" && *solFlag == "" && *jsonFlag == "" {
+		fmt.Printf("No contract ABI (--abi), Solidity source (--sol) or standard JSON output (--stdjson) specified\n")
 		os.Exit(-1)
-	} else if (*abiFlag != "" || *binFlag != "" || *typFlag != "") && *solFlag != "" {
-		fmt.Printf("Contract ABI (--abi), bytecode (--bin) and type (--type) flags are mutually exclusive with the Solidity source (--sol) flag\n")
+	} else if (*abiFlag != "" || *binFlag != "" || *typFlag != "") && (*solFlag != "" || *jsonFlag != "") {
+		fmt.Printf("Contract ABI (--abi), bytecode (--bin) and type (--type) flags are mutually exclusive with the Solidity source (--sol) and standard JSON output (--stdjson) flags\n")
+		os.Exit(-1)
+	} else if *solFlag != "" && *jsonFlag != "" {
+		fmt.Printf("Solidity source (--sol) and standard JSON output (--stdjson) flags are mutually exclusive\n")
 		os.Exit(-1)
 	}
 	if *pkgFlag == "" {
@@ -75,16 +82,32 @@ func main() {
 		bins  []string
 		types []string
 	)
-	if *solFlag != "" {
+	if *solFlag != "" || *jsonFlag != "" {
 		// Generate the list of types to exclude from binding
 		exclude := make(map[string]bool)
 		for _, kind := range strings.Split(*excFlag, ",") {
 			exclude[strings.ToLower(kind)] = true
 		}
-		contracts, err := compiler.CompileSolidity(*solcFlag, *solFlag)
-		if err != nil {
-			fmt.Printf("Failed to build Solidity contract: %v\n", err)
-			os.Exit(-1)
+		var (
+			contracts map[string]*compiler.Contract
+			err       error
+		)
+		if *solFlag != "" {
+			contracts, err = buildSolidity(*solFlag)
+			if err != nil {
+				fmt.Printf("Failed to build Solidity contract: %v\n", err)
+				os.Exit(-1)
+			}
+		} else {
+			output, err := ioutil.ReadFile(*jsonFlag)
+			if err != nil {
+				fmt.Printf("Failed to read standard JSON output: %v\n", err)
+				os.Exit(-1)
+			}
+			if contracts, err = compiler.ParseStandardOutput(output); err != nil {
+				fmt.Printf("Failed to parse standard JSON output: %v\n", err)
+				os.Exit(-1)
+			}
 		}
 		// Gather all non-excluded contract for binding
 		for name, contract := range contracts {
@@ -138,3 +161,44 @@ func main() {
 		os.Exit(-1)
 	}
 }
+
+// buildSolidity compiles a Solidity source file with the configured compiler,
+// using its standard JSON interface if available.
+func buildSolidity(path string) (map[string]*compiler.Contract, error) {
+	// Pick the compiler, either explicitly or by the version pragma of the source
+	var (
+		solc *compiler.Solidity
+		err  error
+	)
+	if *solcDirFlag != "" {
+		var source []byte
+		if source, err = ioutil.ReadFile(path); err != nil {
+			return nil, err
+		}
+		solc, err = compiler.FindSolidity(*solcDirFlag, string(source))
+	} else {
+		solc, err = compiler.SolidityVersion(*solcFlag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var remappings []string
+	if *remapFlag != "" {
+		remappings = strings.Split(*remapFlag, ",")
+	}
+	// Fall back to the combined JSON output for compilers predating standard JSON
+	if !solc.SupportsStandardJSON() {
+		if len(remappings) > 0 {
+			return nil, fmt.Errorf("import remappings not supported by solc %s", solc.Version)
+		}
+		return compiler.CompileSolidity(solc.Path, path)
+	}
+	input := compiler.NewStandardInput()
+	if err := input.AddSourceFile(path); err != nil {
+		return nil, err
+	}
+	input.Settings.Remappings = remappings
+	input.Settings.Optimizer = compiler.StandardOptimizer{Enabled: *runsFlag > 0, Runs: *runsFlag}
+
+	return compiler.CompileStandard(solc.Path, input)
+}
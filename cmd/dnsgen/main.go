@@ -0,0 +1,252 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of go-bazacoin.
+//
+// go-bazacoin is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-bazacoin is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-bazacoin. If not, see <http://www.gnu.org/licenses/>.
+
+// dnsgen creates DNS zone files for node lists.
+//
+// The tool collects signed node records by crawling the discovery network
+// and/or reading them from a file, arranges them into a signed merkle tree
+// and writes the TXT records of the tree as a zone file. Clients resolve the
+// list through the enrtree:// URL printed by the tool.
+//
+// Only nodes which advertise a reachable IP address in their record can be
+// included. Nodes listening on all interfaces need to be started with an
+// explicit external address (e.g. --nat extip:<IP>) to be found by the crawler.
+package main
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/cmd/utils"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/p2p/discover"
+	"github.com/bazacoin/go-bazacoin/p2p/dnsdisc"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
+	"github.com/bazacoin/go-bazacoin/p2p/netutil"
+)
+
+func main() {
+	var (
+		genKey      = flag.String("genkey", "", "generate a signing key")
+		keyFile     = flag.String("key", "", "signing key filename")
+		domain      = flag.String("domain", "", "domain name of the node list")
+		seq         = flag.Uint("seq", uint(time.Now().Unix()), "sequence number of the tree")
+		bootnodes   = flag.String("bootnodes", "", "comma separated enode URLs to start crawling from")
+		crawlTime   = flag.Duration("crawltime", 30*time.Second, "how long to crawl the network")
+		listenAddr  = flag.String("addr", ":0", "listen address of the crawler")
+		netrestrict = flag.String("netrestrict", "", "restrict crawling to the given IP networks (CIDR masks)")
+		nodesFile   = flag.String("nodes", "", "file containing additional node records, one per line")
+		links       = flag.String("links", "", "comma separated enrtree:// URLs of other node lists")
+		outFile     = flag.String("out", "", "zone file to write (default: standard output)")
+		verbosity   = flag.Int("verbosity", int(log.LvlInfo), "log verbosity (0-9)")
+
+		key *ecdsa.PrivateKey
+		err error
+	)
+	flag.Parse()
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(*verbosity))
+	log.Root().SetHandler(glogger)
+
+	switch {
+	case *genKey != "":
+		key, err = crypto.GenerateKey()
+		if err != nil {
+			utils.Fatalf("could not generate key: %v", err)
+		}
+		if err = crypto.SaveECDSA(*genKey, key); err != nil {
+			utils.Fatalf("%v", err)
+		}
+		return
+	case *keyFile == "":
+		utils.Fatalf("Use -key to specify a signing key")
+	case *domain == "":
+		utils.Fatalf("Use -domain to specify the domain of the node list")
+	}
+	if key, err = crypto.LoadECDSA(*keyFile); err != nil {
+		utils.Fatalf("-key: %v", err)
+	}
+
+	// Collect node records.
+	records := make(map[string]*enr.Record)
+	if *nodesFile != "" {
+		if err := readRecords(*nodesFile, records); err != nil {
+			utils.Fatalf("-nodes: %v", err)
+		}
+	}
+	if *bootnodes != "" {
+		var nodes []*discover.Node
+		for _, url := range strings.Split(*bootnodes, ",") {
+			n, err := discover.ParseNode(url)
+			if err != nil {
+				utils.Fatalf("-bootnodes: invalid node %q: %v", url, err)
+			}
+			nodes = append(nodes, n)
+		}
+		var restrict *netutil.Netlist
+		if *netrestrict != "" {
+			if restrict, err = netutil.ParseNetlist(*netrestrict); err != nil {
+				utils.Fatalf("-netrestrict: %v", err)
+			}
+		}
+		if err := crawl(nodes, *listenAddr, restrict, *crawlTime, records); err != nil {
+			utils.Fatalf("Crawl failed: %v", err)
+		}
+	}
+	var linkList []string
+	if *links != "" {
+		linkList = strings.Split(*links, ",")
+	}
+
+	// Create the tree and write the zone.
+	var list []*enr.Record
+	for _, r := range records {
+		list = append(list, r)
+	}
+	tree, err := dnsdisc.MakeTree(*seq, list, linkList)
+	if err != nil {
+		utils.Fatalf("Can't create tree: %v", err)
+	}
+	url, err := tree.Sign(key, *domain)
+	if err != nil {
+		utils.Fatalf("Can't sign tree: %v", err)
+	}
+	out := io.Writer(os.Stdout)
+	if *outFile != "" {
+		f, err := os.Create(*outFile)
+		if err != nil {
+			utils.Fatalf("%v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeZone(out, *domain, tree.ToTXT(*domain)); err != nil {
+		utils.Fatalf("Can't write zone: %v", err)
+	}
+	log.Info("Created node list", "nodes", len(list), "links", len(linkList), "seq", tree.Seq(), "url", url)
+}
+
+// readRecords reads text encoded node records from a file.
+func readRecords(file string, records map[string]*enr.Record) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		n, err := discover.ParseNode(text)
+		if err != nil || n.Record() == nil {
+			return fmt.Errorf("line %d: invalid node record", line)
+		}
+		records[n.ID.String()] = n.Record()
+	}
+	return scanner.Err()
+}
+
+// crawl runs random lookups on the discovery network and collects the
+// records of all nodes that have one with a usable endpoint.
+func crawl(bootnodes []*discover.Node, addr string, restrict *netutil.Netlist, duration time.Duration, records map[string]*enr.Record) error {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return err
+	}
+	tab, err := discover.ListenUDP(key, addr, nil, "", restrict)
+	if err != nil {
+		return err
+	}
+	defer tab.Close()
+	if err := tab.SetFallbackNodes(bootnodes); err != nil {
+		return err
+	}
+
+	buf := make([]*discover.Node, 1024)
+	for deadline := time.Now().Add(duration); time.Now().Before(deadline); {
+		var target discover.NodeID
+		rand.Read(target[:])
+		tab.Lookup(target)
+
+		n := tab.ReadRandomNodes(buf)
+		for _, node := range buf[:n] {
+			if node.Record() == nil {
+				continue
+			}
+			rn, err := discover.NodeFromRecord(node.Record())
+			if err != nil || rn.Incomplete() || rn.TCP == 0 {
+				continue
+			}
+			records[node.ID.String()] = node.Record()
+		}
+		log.Info("Crawling", "table", n, "records", len(records))
+	}
+	return nil
+}
+
+// writeZone writes the given TXT records as a zone file.
+func writeZone(w io.Writer, domain string, txt map[string]string) error {
+	// The root entry comes first, followed by the other entries in
+	// sorted order.
+	var names []string
+	for name := range txt {
+		if name != domain {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{domain}, names...)
+
+	if _, err := fmt.Fprintf(w, "$ORIGIN %s.\n", domain); err != nil {
+		return err
+	}
+	for _, name := range names {
+		rel := strings.TrimSuffix(name, "."+domain)
+		if name == domain {
+			rel = "@"
+		}
+		if _, err := fmt.Fprintf(w, "%-27s IN TXT %s\n", rel, quoteTXT(txt[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteTXT quotes a TXT record value. Values are split into strings
+// of at most 255 bytes, the limit for a single character string in DNS.
+func quoteTXT(s string) string {
+	var parts []string
+	for len(s) > 255 {
+		parts = append(parts, strconv.Quote(s[:255]))
+		s = s[255:]
+	}
+	parts = append(parts, strconv.Quote(s))
+	return strings.Join(parts, " ")
+}
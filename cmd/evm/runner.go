@@ -226,9 +226,8 @@ Gas used:           %d
 
 `, execTime, mem.HeapObjects, mem.Alloc, mem.TotalAlloc, mem.NumGC, initialGas-leftOverGas)
 	}
-	if tracer != nil {
-		tracer.CaptureEnd(ret, initialGas-leftOverGas, execTime)
-	} else {
+	// The machine readable tracer already emitted the output on CaptureEnd
+	if !ctx.GlobalBool(MachineFlag.Name) {
 		fmt.Printf("0x%x\n", ret)
 	}
 
@@ -17,8 +17,13 @@
 package main
 
 import (
+	"bufio"
+	"crypto/ecdsa"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/bazacoin/go-bazacoin/accounts"
 	"github.com/bazacoin/go-bazacoin/accounts/keystore"
@@ -54,6 +59,7 @@ passwordfile as argument containing the wallet password in plaintext.`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
 				},
 				Description: `
 	geth wallet [options] /path/to/my/presale.wallet
@@ -110,6 +116,7 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
 				},
 				Description: `
     geth account new
@@ -135,6 +142,7 @@ password to file or expose in any other way.
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
 					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
 				},
 				Description: `
     geth account update <address>
@@ -164,6 +172,7 @@ changing your password is only possible interactively.
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
 				},
 				ArgsUsage: "<keyFile>",
 				Description: `
@@ -186,6 +195,138 @@ Note:
 As you can directly copy your encrypted accounts to another bazacoin instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:   "bulkimport",
+				Usage:  "Import a list of private keys into new accounts",
+				Action: utils.MigrateFlags(accountBulkImport),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
+				},
+				ArgsUsage: "<keysFile>",
+				Description: `
+    geth account bulkimport <keysfile>
+
+Imports all the unencrypted private keys from <keysfile> and creates a new
+account for each. Prints the addresses.
+
+The keysfile is assumed to contain one unencrypted private key in hexadecimal
+format per line. Empty lines and lines starting with # are ignored.
+
+All the accounts are saved in encrypted format using the same passphrase. Keys
+are encrypted concurrently on all available CPU cores, use --scrypt=bulk to
+lower the work factor when importing many thousands of keys.
+
+If any of the keys already exists in the keystore, nothing is imported.
+`,
+			},
+			{
+				Name:   "bulkexport",
+				Usage:  "Export accounts re-encrypted with a new passphrase",
+				Action: utils.MigrateFlags(accountBulkExport),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
+				},
+				ArgsUsage: "<directory> [<address>...]",
+				Description: `
+    geth account bulkexport <directory> [<address>...]
+
+Exports the given accounts, or all of them if none are specified, into
+<directory> as encrypted key files. You are prompted for the passphrase of the
+accounts and for a new passphrase to encrypt the exported keys with.
+
+For non-interactive use the passphrases can be specified with the --password
+flag, the first line holding the current and the second the new passphrase.
+
+Existing files in the target directory are never overwritten.
+`,
+			},
+			{
+				Name:   "backup",
+				Usage:  "Back up accounts into an encrypted bundle",
+				Action: utils.MigrateFlags(accountBackup),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
+				},
+				ArgsUsage: "<bundleFile> [<address>...]",
+				Description: `
+    geth account backup <bundleFile> [<address>...]
+
+Backs up the given accounts, or all of them if none are specified, into a single
+encrypted bundle. You are prompted for the passphrase of the accounts and for a
+backup passphrase to encrypt the bundle with.
+
+The bundle is encrypted with AES-256-GCM using a key derived from the backup
+passphrase via scrypt, with the work factor set by --scrypt. The list of backed
+up accounts is stored unencrypted but authenticated, so restoring a bundle that
+was tampered with fails.
+
+For non-interactive use the passphrases can be specified with the --password
+flag, the first line holding the accounts' and the second the backup passphrase.
+
+An existing bundle file is never overwritten.
+`,
+			},
+			{
+				Name:   "restore",
+				Usage:  "Restore accounts from an encrypted bundle",
+				Action: utils.MigrateFlags(accountRestore),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
+				},
+				ArgsUsage: "<bundleFile>",
+				Description: `
+    geth account restore <bundleFile>
+
+Verifies and decrypts a bundle created by 'geth account backup' and stores the
+accounts within into the keystore. You are prompted for the backup passphrase
+and for a new passphrase to encrypt the restored accounts with.
+
+For non-interactive use the passphrases can be specified with the --password
+flag, the first line holding the backup and the second the new passphrase.
+
+Accounts already present in the keystore are skipped. Nothing is restored if
+the bundle fails verification.
+`,
+			},
+			{
+				Name:   "reencrypt",
+				Usage:  "Re-encrypt weakly protected accounts in place",
+				Action: utils.MigrateFlags(accountReencrypt),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.ScryptTierFlag,
+				},
+				ArgsUsage: "[<address>...]",
+				Description: `
+    geth account reencrypt [<address>...]
+
+Re-encrypts the key files of the given accounts, or all of them if none are
+specified, with the scrypt work factor set by --scrypt, keeping their
+passphrase. Only keys in a legacy format or with a lower work factor are
+rewritten, allowing to strengthen the protection of old keys in bulk.
+
+For non-interactive use the passphrase can be specified with the --password flag.
 `,
 			},
 		},
@@ -294,7 +435,7 @@ func accountCreate(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	password := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	ks := utils.FetchKeystore(stack.AccountManager())
 	account, err := ks.NewAccount(password)
 	if err != nil {
 		utils.Fatalf("Failed to create account: %v", err)
@@ -310,7 +451,7 @@ func accountUpdate(ctx *cli.Context) error {
 		utils.Fatalf("No accounts specified to update")
 	}
 	stack, _ := makeConfigNode(ctx)
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	ks := utils.FetchKeystore(stack.AccountManager())
 
 	for _, addr := range ctx.Args() {
 		account, oldPassword := unlockAccount(ctx, ks, addr, 0, nil)
@@ -335,7 +476,7 @@ func importWallet(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	passphrase := getPassPhrase("", false, 0, utils.MakePasswordList(ctx))
 
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	ks := utils.FetchKeystore(stack.AccountManager())
 	acct, err := ks.ImportPreSaleKey(keyJson, passphrase)
 	if err != nil {
 		utils.Fatalf("%v", err)
@@ -356,7 +497,7 @@ func accountImport(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	passphrase := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	ks := utils.FetchKeystore(stack.AccountManager())
 	acct, err := ks.ImportECDSA(key, passphrase)
 	if err != nil {
 		utils.Fatalf("Could not create the account: %v", err)
@@ -364,3 +505,219 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// accountBulkImport imports a list of hex encoded private keys into the keystore,
+// encrypting all of them with the same passphrase.
+func accountBulkImport(ctx *cli.Context) error {
+	keysfile := ctx.Args().First()
+	if len(keysfile) == 0 {
+		utils.Fatalf("keysfile must be given as argument")
+	}
+	fd, err := os.Open(keysfile)
+	if err != nil {
+		utils.Fatalf("Failed to open the keys file: %v", err)
+	}
+	defer fd.Close()
+
+	var (
+		keys    []*ecdsa.PrivateKey
+		scanner = bufio.NewScanner(fd)
+	)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, err := crypto.HexToECDSA(text)
+		if err != nil {
+			utils.Fatalf("Invalid private key on line %d: %v", line, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		utils.Fatalf("Failed to read the keys file: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	passphrase := getPassPhrase("Your new accounts are locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	ks := utils.FetchKeystore(stack.AccountManager())
+	accts, err := ks.ImportECDSABatch(keys, passphrase)
+	for _, acct := range accts {
+		fmt.Printf("Address: {%x}\n", acct.Address)
+	}
+	if err != nil {
+		utils.Fatalf("Could not create the accounts: %v", err)
+	}
+	return nil
+}
+
+// accountBulkExport exports a set of accounts into a directory, re-encrypting
+// all of them with a new passphrase.
+func accountBulkExport(ctx *cli.Context) error {
+	dir := ctx.Args().First()
+	if len(dir) == 0 {
+		utils.Fatalf("directory must be given as argument")
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := utils.FetchKeystore(stack.AccountManager())
+
+	accts := selectAccounts(ctx, ks, 1)
+	if len(accts) == 0 {
+		utils.Fatalf("No accounts to export")
+	}
+	passwords := utils.MakePasswordList(ctx)
+	passphrase := getPassPhrase("Please give the password of the accounts to export.", false, 0, passwords)
+	newPassphrase := getPassPhrase("Please give a new password for the exported keys. Do not forget this password.", true, 1, passwords)
+
+	keys, err := ks.ExportBatch(accts, passphrase, newPassphrase)
+	if err != nil {
+		utils.Fatalf("Could not export the accounts: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		utils.Fatalf("Could not create the export directory: %v", err)
+	}
+	for i, acct := range accts {
+		path := filepath.Join(dir, filepath.Base(acct.URL.Path))
+		if err := writeExportedKey(path, keys[i]); err != nil {
+			utils.Fatalf("Could not write the exported key: %v", err)
+		}
+		fmt.Printf("Exported {%x} to %s\n", acct.Address, path)
+	}
+	return nil
+}
+
+// writeExportedKey atomically writes an exported key into the given file. The
+// complete key is linked into place, which fails instead of replacing the file
+// if it already exists.
+func writeExportedKey(path string, key []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Link(f.Name(), path); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("refusing to overwrite existing file %s", path)
+		}
+		return err
+	}
+	return nil
+}
+
+// selectAccounts resolves the accounts given as command arguments starting at
+// the given index, or returns all the accounts of the keystore if there are none.
+func selectAccounts(ctx *cli.Context, ks *keystore.KeyStore, start int) []accounts.Account {
+	if len(ctx.Args()) <= start {
+		return ks.Accounts()
+	}
+	var accts []accounts.Account
+	for _, addr := range ctx.Args()[start:] {
+		acct, err := utils.MakeAddress(ks, addr)
+		if err != nil {
+			utils.Fatalf("Could not list accounts: %v", err)
+		}
+		accts = append(accts, acct)
+	}
+	return accts
+}
+
+// accountBackup backs up a set of accounts into a single encrypted bundle.
+func accountBackup(ctx *cli.Context) error {
+	path := ctx.Args().First()
+	if len(path) == 0 {
+		utils.Fatalf("bundle file must be given as argument")
+	}
+	if _, err := os.Stat(path); err == nil {
+		utils.Fatalf("Refusing to overwrite existing file %s", path)
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := utils.FetchKeystore(stack.AccountManager())
+
+	accts := selectAccounts(ctx, ks, 1)
+	if len(accts) == 0 {
+		utils.Fatalf("No accounts to back up")
+	}
+	passwords := utils.MakePasswordList(ctx)
+	passphrase := getPassPhrase("Please give the password of the accounts to back up.", false, 0, passwords)
+	backupPassphrase := getPassPhrase("Please give a password for the backup. Do not forget this password.", true, 1, passwords)
+
+	bundle, err := ks.Backup(accts, passphrase, backupPassphrase)
+	if err != nil {
+		utils.Fatalf("Could not back up the accounts: %v", err)
+	}
+	if err := writeExportedKey(path, bundle); err != nil {
+		utils.Fatalf("Could not write the backup: %v", err)
+	}
+	for _, acct := range accts {
+		fmt.Printf("Backed up {%x}\n", acct.Address)
+	}
+	return nil
+}
+
+// accountRestore verifies an encrypted backup bundle and imports the accounts
+// within into the keystore.
+func accountRestore(ctx *cli.Context) error {
+	path := ctx.Args().First()
+	if len(path) == 0 {
+		utils.Fatalf("bundle file must be given as argument")
+	}
+	bundle, err := ioutil.ReadFile(path)
+	if err != nil {
+		utils.Fatalf("Could not read the backup: %v", err)
+	}
+	meta, err := keystore.ReadBackupMetadata(bundle)
+	if err != nil {
+		utils.Fatalf("Could not read the backup: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := utils.FetchKeystore(stack.AccountManager())
+
+	passwords := utils.MakePasswordList(ctx)
+	backupPassphrase := getPassPhrase("Please give the password of the backup.", false, 0, passwords)
+	passphrase := getPassPhrase("Your restored accounts are locked with a password. Please give a password. Do not forget this password.", true, 1, passwords)
+
+	accts, err := ks.Restore(bundle, backupPassphrase, passphrase)
+	for _, acct := range accts {
+		fmt.Printf("Restored {%x}\n", acct.Address)
+	}
+	if err != nil {
+		utils.Fatalf("Could not restore the accounts: %v", err)
+	}
+	// The metadata is only trustworthy once Restore authenticated the bundle
+	fmt.Printf("Backup of %d accounts created at %v\n", len(meta.Accounts), meta.Created)
+	if skipped := len(meta.Accounts) - len(accts); skipped > 0 {
+		fmt.Printf("Skipped %d accounts already present\n", skipped)
+	}
+	return nil
+}
+
+// accountReencrypt re-encrypts weakly protected accounts with the configured
+// scrypt parameters.
+func accountReencrypt(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	ks := utils.FetchKeystore(stack.AccountManager())
+
+	accts := selectAccounts(ctx, ks, 0)
+	if len(accts) == 0 {
+		utils.Fatalf("No accounts to re-encrypt")
+	}
+	passphrase := getPassPhrase("Please give the password of the accounts to re-encrypt.", false, 0, utils.MakePasswordList(ctx))
+
+	upgraded, err := ks.Reencrypt(accts, passphrase)
+	for _, acct := range upgraded {
+		fmt.Printf("Re-encrypted {%x}\n", acct.Address)
+	}
+	if err != nil {
+		utils.Fatalf("Could not re-encrypt the accounts: %v", err)
+	}
+	fmt.Printf("%d of %d accounts re-encrypted\n", len(upgraded), len(accts))
+	return nil
+}
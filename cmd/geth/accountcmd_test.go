@@ -18,6 +18,7 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -42,6 +43,19 @@ func tmpDatadirWithKeystore(t *testing.T) string {
 	return datadir
 }
 
+// tmpDatadirWithDupes copies the ambiguous key files from package accounts into
+// a temporary keystore directory, so the keystore index is not written into the
+// source tree.
+func tmpDatadirWithDupes(t *testing.T) string {
+	datadir := tmpdir(t)
+	keystore := filepath.Join(datadir, "keystore")
+	source := filepath.Join("..", "..", "accounts", "keystore", "testdata", "dupes")
+	if err := cp.CopyAll(keystore, source); err != nil {
+		t.Fatal(err)
+	}
+	return datadir
+}
+
 func TestAccountListEmpty(t *testing.T) {
 	geth := runGeth(t, "account", "list")
 	geth.ExpectExit()
@@ -106,6 +120,96 @@ Repeat passphrase: {{.InputLine "foobar2"}}
 `)
 }
 
+func TestAccountBulkImport(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+
+	keys := filepath.Join(datadir, "keys.txt")
+	blob := "# example key from EIP-712\nc85ef7d79691fe79573b1a7064c19c1a9819ebdbd1faaab1a8ec92344438aaf4\n\n"
+	if err := ioutil.WriteFile(keys, []byte(blob), 0600); err != nil {
+		t.Fatal(err)
+	}
+	geth := runGeth(t, "account", "bulkimport", "--datadir", datadir, "--scrypt", "2:1", keys)
+	defer geth.ExpectExit()
+	geth.Expect(`
+Your new accounts are locked with a password. Please give a password. Do not forget this password.
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Repeat passphrase: {{.InputLine "foobar"}}
+Address: {cd2a3d9f938e13cd947ec05abc7fe734df8dd826}
+`)
+}
+
+func TestAccountBackupRestore(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+
+	keys := filepath.Join(datadir, "keys.txt")
+	if err := ioutil.WriteFile(keys, []byte("c85ef7d79691fe79573b1a7064c19c1a9819ebdbd1faaab1a8ec92344438aaf4\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	passwords := filepath.Join(datadir, "passwords.txt")
+	if err := ioutil.WriteFile(passwords, []byte("foobar\nbackup\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	geth := runGeth(t, "account", "bulkimport", "--datadir", datadir, "--scrypt", "2:1", "--password", passwords, keys)
+	geth.Expect(`
+Address: {cd2a3d9f938e13cd947ec05abc7fe734df8dd826}
+`)
+	geth.ExpectExit()
+
+	// Back up the account and restore it into an empty datadir
+	bundle := filepath.Join(datadir, "backup.json")
+	geth = runGeth(t, "account", "backup", "--datadir", datadir, "--scrypt", "2:1", "--password", passwords, bundle)
+	geth.Expect(`
+Backed up {cd2a3d9f938e13cd947ec05abc7fe734df8dd826}
+`)
+	geth.ExpectExit()
+
+	restoredir := tmpdir(t)
+	defer os.RemoveAll(restoredir)
+
+	if err := ioutil.WriteFile(passwords, []byte("backup\nfoobaz\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	geth = runGeth(t, "account", "restore", "--datadir", restoredir, "--scrypt", "2:1", "--password", passwords, bundle)
+	geth.ExpectRegexp(`Restored {cd2a3d9f938e13cd947ec05abc7fe734df8dd826}
+Backup of 1 accounts created at .*
+`)
+	geth.ExpectExit()
+
+	// Re-encrypt the restored account with stronger parameters
+	if err := ioutil.WriteFile(passwords, []byte("foobaz\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	geth = runGeth(t, "account", "reencrypt", "--datadir", restoredir, "--scrypt", "4:1", "--password", passwords)
+	geth.Expect(`
+Re-encrypted {cd2a3d9f938e13cd947ec05abc7fe734df8dd826}
+1 of 1 accounts re-encrypted
+`)
+	geth.ExpectExit()
+}
+
+// Tests that exported keys never replace existing files.
+func TestWriteExportedKey(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "key")
+	if err := writeExportedKey(path, []byte("first")); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if err := writeExportedKey(path, []byte("second")); err == nil {
+		t.Fatalf("existing key overwritten")
+	}
+	if blob, err := ioutil.ReadFile(path); err != nil || string(blob) != "first" {
+		t.Fatalf("key content mismatch: have %q (%v), want %q", blob, err, "first")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Fatalf("temporary files left behind: %d files", len(files))
+	}
+}
+
 func TestWalletImport(t *testing.T) {
 	geth := runGeth(t, "wallet", "import", "--lightkdf", "testdata/guswallet.json")
 	defer geth.ExpectExit()
@@ -233,7 +337,10 @@ Fatal: Failed to unlock account 0 (could not decrypt key with given passphrase)
 }
 
 func TestUnlockFlagAmbiguous(t *testing.T) {
-	store := filepath.Join("..", "..", "accounts", "keystore", "testdata", "dupes")
+	datadir := tmpDatadirWithDupes(t)
+	defer os.RemoveAll(datadir)
+
+	store := filepath.Join(datadir, "keystore")
 	geth := runGeth(t,
 		"--keystore", store, "--nat", "none", "--nodiscover", "--dev",
 		"--unlock", "f466859ead1932d743d622cb74fc058882e8648a",
@@ -271,7 +378,10 @@ In order to avoid this warning, you need to remove the following duplicate key f
 }
 
 func TestUnlockFlagAmbiguousWrongPassword(t *testing.T) {
-	store := filepath.Join("..", "..", "accounts", "keystore", "testdata", "dupes")
+	datadir := tmpDatadirWithDupes(t)
+	defer os.RemoveAll(datadir)
+
+	store := filepath.Join(datadir, "keystore")
 	geth := runGeth(t,
 		"--keystore", store, "--nat", "none", "--nodiscover", "--dev",
 		"--unlock", "f466859ead1932d743d622cb74fc058882e8648a")
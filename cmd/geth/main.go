@@ -26,6 +26,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/accounts"
 	"github.com/bazacoin/go-bazacoin/accounts/keystore"
+	"github.com/bazacoin/go-bazacoin/accounts/usbwallet"
 	"github.com/bazacoin/go-bazacoin/cmd/utils"
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/console"
@@ -60,6 +61,7 @@ var (
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ExternalSignerFlag,
 		utils.BzhashCacheDirFlag,
 		utils.BzhashCachesInMemoryFlag,
 		utils.BzhashCachesOnDiskFlag,
@@ -78,7 +80,9 @@ var (
 		utils.SyncModeFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
+		utils.CheckpointFlag,
 		utils.LightKDFFlag,
+		utils.ScryptTierFlag,
 		utils.CacheFlag,
 		utils.TrieCacheGenFlag,
 		utils.ListenPortFlag,
@@ -93,6 +97,7 @@ var (
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
 		utils.NetrestrictFlag,
+		utils.DNSDiscoveryFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.WhisperEnabledFlag,
@@ -100,6 +105,7 @@ var (
 		utils.TestnetFlag,
 		utils.RinkebyFlag,
 		utils.VMEnableDebugFlag,
+		utils.EVMInterpreterFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
 		utils.BzcStatsURLFlag,
@@ -206,14 +212,18 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	utils.StartNode(stack)
 
 	// Unlock any account specifically requested
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	if keystores := stack.AccountManager().Backends(keystore.KeyStoreType); len(keystores) > 0 {
+		ks := keystores[0].(*keystore.KeyStore)
 
-	passwords := utils.MakePasswordList(ctx)
-	unlocks := strings.Split(ctx.GlobalString(utils.UnlockedAccountFlag.Name), ",")
-	for i, account := range unlocks {
-		if trimmed := strings.TrimSpace(account); trimmed != "" {
-			unlockAccount(ctx, ks, trimmed, i, passwords)
+		passwords := utils.MakePasswordList(ctx)
+		unlocks := strings.Split(ctx.GlobalString(utils.UnlockedAccountFlag.Name), ",")
+		for i, account := range unlocks {
+			if trimmed := strings.TrimSpace(account); trimmed != "" {
+				unlockAccount(ctx, ks, trimmed, i, passwords)
+			}
 		}
+	} else if ctx.GlobalString(utils.UnlockedAccountFlag.Name) != "" {
+		utils.Fatalf("Accounts cannot be unlocked when signing is delegated to an external signer")
 	}
 	// Register wallet event handlers to open and auto-derive wallets
 	events := make(chan accounts.WalletEvent, 16)
@@ -229,20 +239,30 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 
 		// Open and self derive any wallets already attached
 		for _, wallet := range stack.AccountManager().Wallets() {
-			if err := wallet.Open(""); err != nil {
-				log.Warn("Failed to open wallet", "url", wallet.URL(), "err", err)
-			} else {
+			switch err := wallet.Open(""); err {
+			case nil:
+				wallet.SelfDerive(accounts.DefaultBaseDerivationPath, stateReader)
+			case usbwallet.ErrTrezorPINNeeded, usbwallet.ErrTrezorPassphraseNeeded:
+				log.Info("Wallet needs unlocking via personal.openWallet", "url", wallet.URL(), "err", err)
 				wallet.SelfDerive(accounts.DefaultBaseDerivationPath, stateReader)
+			default:
+				log.Warn("Failed to open wallet", "url", wallet.URL(), "err", err)
 			}
 		}
 		// Listen for wallet event till termination
 		for event := range events {
 			if event.Arrive {
-				if err := event.Wallet.Open(""); err != nil {
-					log.Warn("New wallet appeared, failed to open", "url", event.Wallet.URL(), "err", err)
-				} else {
+				switch err := event.Wallet.Open(""); err {
+				case nil:
 					log.Info("New wallet appeared", "url", event.Wallet.URL(), "status", event.Wallet.Status())
 					event.Wallet.SelfDerive(accounts.DefaultBaseDerivationPath, stateReader)
+				case usbwallet.ErrTrezorPINNeeded, usbwallet.ErrTrezorPassphraseNeeded:
+					// The PIN matrix or passphrase must be entered via personal.openWallet,
+					// configure self derivation already so accounts show up once unlocked.
+					log.Info("New wallet appeared, needs unlocking via personal.openWallet", "url", event.Wallet.URL(), "err", err)
+					event.Wallet.SelfDerive(accounts.DefaultBaseDerivationPath, stateReader)
+				default:
+					log.Warn("New wallet appeared, failed to open", "url", event.Wallet.URL(), "err", err)
 				}
 			} else {
 				log.Info("Old wallet dropped", "url", event.Wallet.URL())
@@ -0,0 +1,190 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of go-bazacoin.
+//
+// go-bazacoin is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-bazacoin is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-bazacoin. If not, see <http://www.gnu.org/licenses/>.
+
+// signer is a standalone daemon holding the keys of the node's accounts,
+// signing transactions and messages only after approval by its operator or a
+// declarative rule set. Nodes delegate signing to it with --signer.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/bazacoin/go-bazacoin/accounts"
+	"github.com/bazacoin/go-bazacoin/accounts/keystore"
+	"github.com/bazacoin/go-bazacoin/accounts/usbwallet"
+	"github.com/bazacoin/go-bazacoin/cmd/utils"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/node"
+	"github.com/bazacoin/go-bazacoin/params"
+	"github.com/bazacoin/go-bazacoin/rpc"
+	"github.com/bazacoin/go-bazacoin/signer/core"
+	"github.com/bazacoin/go-bazacoin/signer/rules"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+func main() {
+	var (
+		keydir     = flag.String("keystore", filepath.Join(node.DefaultDataDir(), "keystore"), "directory of the keystore")
+		lightKDF   = flag.Bool("lightkdf", false, "reduce key-derivation RAM & CPU usage at some expense of KDF strength")
+		noUSB      = flag.Bool("nousb", false, "disable monitoring for and managing USB hardware wallets")
+		chainID    = flag.Uint64("chainid", params.MainNetChainID.Uint64(), "chain id to sign transactions for")
+		rulesFile  = flag.String("rules", "", "JSON file of rules to approve or reject requests with")
+		headless   = flag.Bool("headless", false, "reject all requests not decided by the rules instead of prompting")
+		auditLog   = flag.String("auditlog", "audit.log", "file to log all requests and responses to (empty disables)")
+		unlock     = flag.String("unlock", "", "comma separated list of accounts to unlock for rule approved requests")
+		password   = flag.String("password", "", "file with the passwords of the unlocked accounts, one per line")
+		ipcDisable = flag.Bool("ipcdisable", false, "disable the IPC endpoint")
+		ipcPath    = flag.String("ipcpath", filepath.Join(node.DefaultDataDir(), "signer.ipc"), "filename of the IPC endpoint")
+		httpEnable = flag.Bool("rpc", false, "enable the HTTP endpoint")
+		httpAddr   = flag.String("rpcaddr", "localhost", "listening interface of the HTTP endpoint")
+		httpPort   = flag.Int("rpcport", 8550, "listening port of the HTTP endpoint")
+		verbosity  = flag.Int("verbosity", int(log.LvlInfo), "log verbosity (0-9)")
+	)
+	flag.Parse()
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(*verbosity))
+	log.Root().SetHandler(glogger)
+
+	// Assemble the account manager holding the keys
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if *lightKDF {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+	ks := keystore.NewKeyStore(*keydir, scryptN, scryptP)
+	backends := []accounts.Backend{ks}
+	if !*noUSB {
+		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
+			log.Warn(fmt.Sprintf("Failed to start Ledger hub, disabling: %v", err))
+		} else {
+			backends = append(backends, ledgerhub)
+		}
+		if trezorhub, err := usbwallet.NewTrezorHub(); err != nil {
+			log.Warn(fmt.Sprintf("Failed to start Trezor hub, disabling: %v", err))
+		} else {
+			backends = append(backends, trezorhub)
+		}
+	}
+	am := accounts.NewManager(backends...)
+	defer am.Close()
+
+	unlockAccounts(ks, *unlock, *password)
+
+	// Assemble the approval chain: rules first, then the operator
+	var ui core.SignerUI = core.NewCommandlineUI()
+	if *headless {
+		ui = core.HeadlessUI{}
+	}
+	if *rulesFile != "" {
+		ruleset, err := rules.Load(*rulesFile)
+		if err != nil {
+			utils.Fatalf("-rules: %v", err)
+		}
+		ui = rules.NewRuleEvaluator(ruleset, ui)
+	}
+	var api core.ExternalAPI = core.NewSignerAPI(new(big.Int).SetUint64(*chainID), am, ui)
+	if *auditLog != "" {
+		logger, err := core.NewAuditLogger(*auditLog, api)
+		if err != nil {
+			utils.Fatalf("-auditlog: %v", err)
+		}
+		api = logger
+		log.Info("Audit logs configured", "file", *auditLog)
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", api); err != nil {
+		utils.Fatalf("Could not register signer API: %v", err)
+	}
+	defer server.Stop()
+
+	// Expose the API on the requested endpoints
+	if !*ipcDisable {
+		listener, err := rpc.CreateIPCListener(*ipcPath)
+		if err != nil {
+			utils.Fatalf("Could not start IPC endpoint: %v", err)
+		}
+		defer listener.Close()
+		go server.ServeListener(listener)
+		log.Info("IPC endpoint opened", "url", *ipcPath)
+	}
+	if *httpEnable {
+		endpoint := fmt.Sprintf("%s:%d", *httpAddr, *httpPort)
+		listener, err := net.Listen("tcp", endpoint)
+		if err != nil {
+			utils.Fatalf("Could not start HTTP endpoint: %v", err)
+		}
+		defer listener.Close()
+		go rpc.NewHTTPServer(nil, server).Serve(listener)
+		log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint))
+	}
+	if *ipcDisable && !*httpEnable {
+		utils.Fatalf("No endpoint enabled, nobody can reach the signer")
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	<-sigc
+	log.Info("Got interrupt, shutting down")
+}
+
+// unlockAccounts unlocks the requested accounts in the keystore, so requests
+// approved by the rules can be signed without asking for a password.
+func unlockAccounts(ks *keystore.KeyStore, unlock string, passfile string) {
+	var passwords []string
+	if passfile != "" {
+		text, err := ioutil.ReadFile(passfile)
+		if err != nil {
+			utils.Fatalf("Failed to read password file: %v", err)
+		}
+		passwords = strings.Split(string(text), "\n")
+		for i := range passwords {
+			passwords[i] = strings.TrimRight(passwords[i], "\r")
+		}
+	}
+	for i, account := range strings.Split(unlock, ",") {
+		account = strings.TrimSpace(account)
+		if account == "" {
+			continue
+		}
+		if !common.IsHexAddress(account) {
+			utils.Fatalf("Invalid account to unlock: %q", account)
+		}
+		var pass string
+		if i < len(passwords) {
+			pass = passwords[i]
+		} else {
+			fmt.Printf("Password for %s: ", account)
+			blob, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Println()
+			if err != nil {
+				utils.Fatalf("Failed to read password: %v", err)
+			}
+			pass = string(blob)
+		}
+		if err := ks.Unlock(accounts.Account{Address: common.HexToAddress(account)}, pass); err != nil {
+			utils.Fatalf("Failed to unlock account %s: %v", account, err)
+		}
+		log.Info("Unlocked account", "address", account)
+	}
+}
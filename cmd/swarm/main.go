@@ -388,8 +388,7 @@ func getAccount(ctx *cli.Context, stack *node.Node) *ecdsa.PrivateKey {
 		return key
 	}
 	// Otherwise try getting it from the keystore.
-	am := stack.AccountManager()
-	ks := am.Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	ks := utils.FetchKeystore(stack.AccountManager())
 
 	return decryptStoreAccount(ks, keyid, utils.MakePasswordList(ctx))
 }
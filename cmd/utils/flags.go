@@ -31,6 +31,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/accounts"
 	"github.com/bazacoin/go-bazacoin/accounts/keystore"
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
 	"github.com/bazacoin/go-bazacoin/consensus/bzhash"
 	"github.com/bazacoin/go-bazacoin/core"
 	"github.com/bazacoin/go-bazacoin/core/state"
@@ -123,6 +124,11 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managine USB hardware wallets",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer (url or path to ipc file) to delegate account management to",
+		Value: "",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby)",
@@ -160,7 +166,7 @@ var (
 	defaultSyncMode = bzc.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "snap")`,
 		Value: &defaultSyncMode,
 	}
 
@@ -174,10 +180,18 @@ var (
 		Usage: "Maximum number of LES client peers",
 		Value: 20,
 	}
+	CheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "Trusted checkpoint to start syncing from (index,sectionhead,chtroot,bloomroot)",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	ScryptTierFlag = cli.StringFlag{
+		Name:  "scrypt",
+		Usage: "Scrypt work factor tier for newly encrypted keys (standard, bulk, light or N:P)",
+	}
 	// Bzhash settings
 	BzhashCacheDirFlag = DirectoryFlag{
 		Name:  "bzhash.cachedir",
@@ -300,6 +314,11 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	EVMInterpreterFlag = cli.StringFlag{
+		Name:  "vm.evm",
+		Usage: "Interpreter used to execute EVM byte code (" + strings.Join(vm.Interpreters(), ", ") + ")",
+		Value: vm.DefaultInterpreter,
+	}
 	// Logging and debug settings
 	BzcStatsURLFlag = cli.StringFlag{
 		Name:  "bzcstats",
@@ -439,6 +458,10 @@ var (
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
 	}
+	DNSDiscoveryFlag = cli.StringFlag{
+		Name:  "dnsdiscovery",
+		Usage: "Comma separated enrtree:// URLs of DNS node lists to use as peer sources",
+	}
 
 	WhisperEnabledFlag = cli.BoolFlag{
 		Name:  "shh",
@@ -690,6 +713,9 @@ func MakeAddress(ks *keystore.KeyStore, account string) (accounts.Account, error
 	if err != nil || index < 0 {
 		return accounts.Account{}, fmt.Errorf("invalid account address or index %q", account)
 	}
+	if ks == nil {
+		return accounts.Account{}, fmt.Errorf("account index %d unavailable without a local keystore", index)
+	}
 	accs := ks.Accounts()
 	if len(accs) <= index {
 		return accounts.Account{}, fmt.Errorf("index %d higher than number of accounts %d", index, len(accs))
@@ -698,7 +724,9 @@ func MakeAddress(ks *keystore.KeyStore, account string) (accounts.Account, error
 }
 
 // setBazacoinbase retrieves the bazacoinbase either from the directly specified
-// command line flags or from the keystore if CLI indexed.
+// command line flags or from the keystore if CLI indexed. Without a local
+// keystore (i.e. signing is delegated to an external signer) only explicit
+// addresses are accepted.
 func setBazacoinbase(ctx *cli.Context, ks *keystore.KeyStore, cfg *bzc.Config) {
 	if ctx.GlobalIsSet(BazacoinbaseFlag.Name) {
 		account, err := MakeAddress(ks, ctx.GlobalString(BazacoinbaseFlag.Name))
@@ -708,6 +736,9 @@ func setBazacoinbase(ctx *cli.Context, ks *keystore.KeyStore, cfg *bzc.Config) {
 		cfg.Bazacoinbase = account.Address
 		return
 	}
+	if ks == nil {
+		return
+	}
 	accounts := ks.Accounts()
 	if (cfg.Bazacoinbase == common.Address{}) {
 		if len(accounts) > 0 {
@@ -736,6 +767,16 @@ func MakePasswordList(ctx *cli.Context) []string {
 	return lines
 }
 
+// FetchKeystore retrieves the local keystore from the account manager, exiting
+// if there is none, e.g. because accounts are managed by an external signer.
+func FetchKeystore(am *accounts.Manager) *keystore.KeyStore {
+	keystores := am.Backends(keystore.KeyStoreType)
+	if len(keystores) == 0 {
+		Fatalf("Keystore not available")
+	}
+	return keystores[0].(*keystore.KeyStore)
+}
+
 func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setNodeKey(ctx, cfg)
 	setNAT(ctx, cfg)
@@ -772,6 +813,10 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		cfg.NetRestrict = list
 	}
 
+	if urls := ctx.GlobalString(DNSDiscoveryFlag.Name); urls != "" {
+		cfg.DNSDiscovery = strings.Split(urls, ",")
+	}
+
 	if ctx.GlobalBool(DevModeFlag.Name) {
 		// --dev mode can't use p2p networking.
 		cfg.MaxPeers = 0
@@ -807,9 +852,15 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(LightKDFFlag.Name) {
 		cfg.UseLightweightKDF = ctx.GlobalBool(LightKDFFlag.Name)
 	}
+	if ctx.GlobalIsSet(ScryptTierFlag.Name) {
+		cfg.KeyStoreScrypt = ctx.GlobalString(ScryptTierFlag.Name)
+	}
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -845,6 +896,35 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	}
 }
 
+// setCheckpoint parses the trusted checkpoint given on the command line, if any.
+func setCheckpoint(ctx *cli.Context, cfg *bzc.Config) {
+	if !ctx.GlobalIsSet(CheckpointFlag.Name) {
+		return
+	}
+	parts := strings.Split(ctx.GlobalString(CheckpointFlag.Name), ",")
+	if len(parts) != 4 {
+		Fatalf("Invalid checkpoint %q, want index,sectionhead,chtroot,bloomroot", ctx.GlobalString(CheckpointFlag.Name))
+	}
+	index, err := strconv.ParseUint(parts[0], 0, 64)
+	if err != nil {
+		Fatalf("Invalid checkpoint section index %q: %v", parts[0], err)
+	}
+	hashes := make([]common.Hash, 3)
+	for i, part := range parts[1:] {
+		blob, err := hexutil.Decode(part)
+		if err != nil || len(blob) != common.HashLength {
+			Fatalf("Invalid checkpoint hash %q", part)
+		}
+		hashes[i] = common.BytesToHash(blob)
+	}
+	cfg.Checkpoint = &params.TrustedCheckpoint{
+		SectionIndex: index,
+		SectionHead:  hashes[0],
+		CHTRoot:      hashes[1],
+		BloomRoot:    hashes[2],
+	}
+}
+
 func setBzhash(ctx *cli.Context, cfg *bzc.Config) {
 	if ctx.GlobalIsSet(BzhashCacheDirFlag.Name) {
 		cfg.BzhashCacheDir = ctx.GlobalString(BzhashCacheDirFlag.Name)
@@ -878,17 +958,34 @@ func checkExclusive(ctx *cli.Context, flags ...cli.Flag) {
 	}
 }
 
+// checkInterpreter returns the interpreter selected with --vm.evm, aborting if
+// no interpreter is registered under that name.
+func checkInterpreter(ctx *cli.Context) string {
+	name := ctx.GlobalString(EVMInterpreterFlag.Name)
+	for _, known := range vm.Interpreters() {
+		if name == known {
+			return name
+		}
+	}
+	Fatalf("Unknown EVM interpreter %q, available: %s", name, strings.Join(vm.Interpreters(), ", "))
+	return ""
+}
+
 // SetBzcConfig applies eth-related command line flags to the config.
 func SetBzcConfig(ctx *cli.Context, stack *node.Node, cfg *bzc.Config) {
 	// Avoid conflicting network flags
 	checkExclusive(ctx, DevModeFlag, TestnetFlag, RinkebyFlag)
 	checkExclusive(ctx, FastSyncFlag, LightModeFlag, SyncModeFlag)
 
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	var ks *keystore.KeyStore
+	if keystores := stack.AccountManager().Backends(keystore.KeyStoreType); len(keystores) > 0 {
+		ks = keystores[0].(*keystore.KeyStore)
+	}
 	setBazacoinbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
 	setBzhash(ctx, cfg)
+	setCheckpoint(ctx, cfg)
 
 	switch {
 	case ctx.GlobalIsSet(SyncModeFlag.Name):
@@ -933,6 +1030,9 @@ func SetBzcConfig(ctx *cli.Context, stack *node.Node, cfg *bzc.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(EVMInterpreterFlag.Name) {
+		cfg.EVMInterpreter = checkInterpreter(ctx)
+	}
 
 	// Override any default configs for hard coded networks.
 	switch {
@@ -1055,7 +1155,10 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if err != nil {
 		Fatalf("%v", err)
 	}
-	vmcfg := vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)}
+	vmcfg := vm.Config{
+		EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name),
+		EVMInterpreter:          checkInterpreter(ctx),
+	}
 	chain, err = core.NewBlockChain(chainDb, config, engine, new(event.TypeMux), vmcfg)
 	if err != nil {
 		Fatalf("Can't create BlockChain: %v", err)
@@ -0,0 +1,244 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pragmaRegexp     = regexp.MustCompile(`pragma\s+solidity\s+([^;]+);`)
+	comparatorRegexp = regexp.MustCompile(`^(\^|~|>=|<=|>|<|=)?\s*v?([0-9]+)(?:\.([0-9]+|x|\*))?(?:\.([0-9]+|x|\*))?`)
+)
+
+// version is a parsed compiler version, comparable component-wise.
+type version [3]int
+
+// cmp returns -1, 0 or +1 depending on whether v is lower, equal or higher than o.
+func (v version) cmp(o version) int {
+	for i := range v {
+		switch {
+		case v[i] < o[i]:
+			return -1
+		case v[i] > o[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// String implements fmt.Stringer.
+func (v version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// parseVersion extracts the first version number from an arbitrary string, like
+// a compiler binary name or its version output.
+func parseVersion(s string) (version, bool) {
+	matches := versionRegexp.FindStringSubmatch(s)
+	if len(matches) != 4 {
+		return version{}, false
+	}
+	var v version
+	for i := range v {
+		v[i], _ = strconv.Atoi(matches[i+1])
+	}
+	return v, true
+}
+
+// SolidityPragma returns the version constraint of the first solidity version
+// pragma in the source, or the empty string if there is none.
+func SolidityPragma(source string) string {
+	matches := pragmaRegexp.FindStringSubmatch(source)
+	if len(matches) != 2 {
+		return ""
+	}
+	return strings.TrimSpace(matches[1])
+}
+
+// MatchSolidityPragma reports whether a compiler version satisfies the version
+// constraint of a solidity pragma. Constraints follow the npm semver syntax used
+// by the compiler: comparators joined by whitespace must all match, and sets of
+// them joined by "||" are alternatives. An empty constraint matches anything.
+func MatchSolidityPragma(constraint string, ver string) (bool, error) {
+	v, ok := parseVersion(ver)
+	if !ok {
+		return false, fmt.Errorf("invalid version %q", ver)
+	}
+	if strings.TrimSpace(constraint) == "" {
+		return true, nil
+	}
+	// Check all the alternatives to reject malformed constraints consistently
+	var result bool
+	for _, alternative := range strings.Split(constraint, "||") {
+		matched, err := matchComparators(strings.TrimSpace(alternative), v)
+		if err != nil {
+			return false, err
+		}
+		result = result || matched
+	}
+	return result, nil
+}
+
+// matchComparators checks a version against a whitespace separated list of
+// comparators, all of which must be satisfied.
+func matchComparators(constraint string, v version) (bool, error) {
+	if constraint == "" {
+		return false, fmt.Errorf("empty version constraint")
+	}
+	for constraint != "" {
+		matches := comparatorRegexp.FindStringSubmatch(constraint)
+		if matches == nil {
+			return false, fmt.Errorf("invalid version constraint %q", constraint)
+		}
+		constraint = strings.TrimSpace(constraint[len(matches[0]):])
+
+		// Parse the version of the comparator, tracking how much of it was given
+		var (
+			bound     version
+			specified = 1
+		)
+		bound[0], _ = strconv.Atoi(matches[2])
+		for i, part := range matches[3:] {
+			if part == "" || part == "x" || part == "*" {
+				break
+			}
+			bound[i+1], _ = strconv.Atoi(part)
+			specified++
+		}
+		// Partially specified versions are ranges, widen them accordingly
+		upper := bound
+		switch op := matches[1]; op {
+		case "^":
+			switch {
+			case bound[0] > 0 || specified == 1:
+				upper = version{bound[0] + 1, 0, 0}
+			case bound[1] > 0 || specified == 2:
+				upper = version{0, bound[1] + 1, 0}
+			default:
+				upper = version{0, 0, bound[2] + 1}
+			}
+			if v.cmp(bound) < 0 || v.cmp(upper) >= 0 {
+				return false, nil
+			}
+		case "~":
+			if specified == 1 {
+				upper = version{bound[0] + 1, 0, 0}
+			} else {
+				upper = version{bound[0], bound[1] + 1, 0}
+			}
+			if v.cmp(bound) < 0 || v.cmp(upper) >= 0 {
+				return false, nil
+			}
+		case "", "=":
+			for i := 0; i < specified; i++ {
+				if v[i] != bound[i] {
+					return false, nil
+				}
+			}
+		case ">":
+			if specified < 3 {
+				upper[specified-1]++
+				for i := specified; i < 3; i++ {
+					upper[i] = 0
+				}
+				if v.cmp(upper) < 0 {
+					return false, nil
+				}
+			} else if v.cmp(bound) <= 0 {
+				return false, nil
+			}
+		case ">=":
+			if v.cmp(bound) < 0 {
+				return false, nil
+			}
+		case "<":
+			if v.cmp(bound) >= 0 {
+				return false, nil
+			}
+		case "<=":
+			if specified < 3 {
+				upper[specified-1]++
+				for i := specified; i < 3; i++ {
+					upper[i] = 0
+				}
+				if v.cmp(upper) >= 0 {
+					return false, nil
+				}
+			} else if v.cmp(bound) > 0 {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// FindSolidity looks up the compiler to build a source with from a directory of
+// solc binaries. The binaries must have their versions in their names (e.g.
+// solc-0.4.24 or solc-v0.4.24+commit.e67f0147), out of which the newest one that
+// satisfies the version pragma of the source is picked.
+func FindSolidity(dir string, source string) (*Solidity, error) {
+	// Resolve the directory to stop exec from searching the binaries in the PATH
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	constraint := SolidityPragma(source)
+
+	var (
+		best     string
+		bestVers version
+	)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasPrefix(file.Name(), "solc") {
+			continue
+		}
+		v, ok := parseVersion(file.Name())
+		if !ok {
+			continue
+		}
+		if matched, err := MatchSolidityPragma(constraint, v.String()); err != nil {
+			return nil, err
+		} else if !matched {
+			continue
+		}
+		if best == "" || v.cmp(bestVers) > 0 {
+			best, bestVers = file.Name(), v
+		}
+	}
+	if best == "" {
+		return nil, fmt.Errorf("solc: no compiler in %s matching pragma %q", dir, constraint)
+	}
+	// Make sure the binary is indeed the version it claims to be
+	s, err := SolidityVersion(filepath.Join(dir, best))
+	if err != nil {
+		return nil, err
+	}
+	if s.Major != bestVers[0] || s.Minor != bestVers[1] || s.Patch != bestVers[2] {
+		return nil, fmt.Errorf("solc: compiler %s reports mismatching version %s", best, s.Version)
+	}
+	return s, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSolidityPragma(t *testing.T) {
+	tests := []struct {
+		source, pragma string
+	}{
+		{"contract test {}", ""},
+		{"pragma solidity ^0.4.24;\ncontract test {}", "^0.4.24"},
+		{"pragma experimental ABIEncoderV2;\npragma  solidity >=0.4.0 <0.6.0 ;", ">=0.4.0 <0.6.0"},
+	}
+	for i, tt := range tests {
+		if pragma := SolidityPragma(tt.source); pragma != tt.pragma {
+			t.Errorf("test %d: pragma mismatch: have %q, want %q", i, pragma, tt.pragma)
+		}
+	}
+}
+
+func TestMatchSolidityPragma(t *testing.T) {
+	tests := []struct {
+		pragma  string
+		version string
+		match   bool
+	}{
+		{"", "0.4.24", true},
+		{"0.4.24", "0.4.24", true},
+		{"=0.4.24", "0.4.25", false},
+		{"0.4", "0.4.1", true},
+		{"^0.4.24", "0.4.24", true},
+		{"^0.4.24", "0.4.99", true},
+		{"^0.4.24", "0.4.23", false},
+		{"^0.4.24", "0.5.0", false},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~0.4.10", "0.4.26", true},
+		{"~0.4.10", "0.5.0", false},
+		{">=0.4.0 <0.6.0", "0.5.17", true},
+		{">=0.4.0 <0.6.0", "0.6.0", false},
+		{">0.4", "0.4.26", false},
+		{">0.4", "0.5.0", true},
+		{"<=0.4", "0.4.26", true},
+		{"<=0.4.5", "0.4.6", false},
+		{"^0.4.0 || ^0.5.0", "0.5.3", true},
+		{"^0.4.0 || ^0.5.0", "0.6.3", false},
+		{"0.4.x", "0.4.11", true},
+	}
+	for i, tt := range tests {
+		match, err := MatchSolidityPragma(tt.pragma, tt.version)
+		if err != nil {
+			t.Errorf("test %d: failed to match %q against %q: %v", i, tt.version, tt.pragma, err)
+			continue
+		}
+		if match != tt.match {
+			t.Errorf("test %d: match mismatch for %q against %q: have %v, want %v", i, tt.version, tt.pragma, match, tt.match)
+		}
+	}
+	for _, pragma := range []string{"^", "0.4.24 foo", ">=0.4.0 ||"} {
+		if _, err := MatchSolidityPragma(pragma, "0.4.24"); err == nil {
+			t.Errorf("invalid pragma %q accepted", pragma)
+		}
+	}
+}
+
+// Tests that the newest compiler satisfying a source's pragma is picked from a
+// directory of solc binaries.
+func TestFindSolidity(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake compilers are shell scripts, skipping")
+	}
+	dir, err := ioutil.TempDir("", "solc-")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Create a few fake compilers reporting their versions
+	for _, version := range []string{"0.4.11", "0.4.24", "0.5.2"} {
+		script := "#!/bin/sh\necho 'solc, the solidity compiler commandline interface'\necho 'Version: " + version + "+commit.00000000'\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, "solc-v"+version), []byte(script), 0700); err != nil {
+			t.Fatalf("failed to create fake compiler: %v", err)
+		}
+	}
+	ioutil.WriteFile(filepath.Join(dir, "README"), []byte("0.9.9"), 0600)
+
+	tests := []struct {
+		source  string
+		version string
+	}{
+		{"contract test {}", "0.5.2"},
+		{"pragma solidity ^0.4.0;", "0.4.24"},
+		{"pragma solidity >=0.4.0 <0.4.20;", "0.4.11"},
+		{"pragma solidity ^0.6.0;", ""},
+	}
+	for i, tt := range tests {
+		solc, err := FindSolidity(dir, tt.source)
+		if tt.version == "" {
+			if err == nil {
+				t.Errorf("test %d: found compiler %s for unsatisfiable pragma", i, solc.Version)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to find compiler: %v", i, err)
+			continue
+		}
+		if solc.Version != tt.version {
+			t.Errorf("test %d: version mismatch: have %s, want %s", i, solc.Version, tt.version)
+		}
+	}
+}
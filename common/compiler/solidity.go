@@ -32,20 +32,23 @@ import (
 var versionRegexp = regexp.MustCompile(`([0-9]+)\.([0-9]+)\.([0-9]+)`)
 
 type Contract struct {
-	Code string       `json:"code"`
-	Info ContractInfo `json:"info"`
+	Code        string       `json:"code"`
+	RuntimeCode string       `json:"runtimeCode"`
+	Info        ContractInfo `json:"info"`
 }
 
 type ContractInfo struct {
-	Source          string      `json:"source"`
-	Language        string      `json:"language"`
-	LanguageVersion string      `json:"languageVersion"`
-	CompilerVersion string      `json:"compilerVersion"`
-	CompilerOptions string      `json:"compilerOptions"`
-	AbiDefinition   interface{} `json:"abiDefinition"`
-	UserDoc         interface{} `json:"userDoc"`
-	DeveloperDoc    interface{} `json:"developerDoc"`
-	Metadata        string      `json:"metadata"`
+	Source           string      `json:"source"`
+	Language         string      `json:"language"`
+	LanguageVersion  string      `json:"languageVersion"`
+	CompilerVersion  string      `json:"compilerVersion"`
+	CompilerOptions  string      `json:"compilerOptions"`
+	SourceMap        string      `json:"sourceMap"`
+	SourceMapRuntime string      `json:"sourceMapRuntime"`
+	AbiDefinition    interface{} `json:"abiDefinition"`
+	UserDoc          interface{} `json:"userDoc"`
+	DeveloperDoc     interface{} `json:"developerDoc"`
+	Metadata         string      `json:"metadata"`
 }
 
 // Solidity contains information about the solidity compiler.
@@ -58,18 +61,21 @@ type Solidity struct {
 type solcOutput struct {
 	Contracts map[string]struct {
 		Bin, Abi, Devdoc, Userdoc, Metadata string
+		BinRuntime                          string `json:"bin-runtime"`
+		SrcMap                              string `json:"srcmap"`
+		SrcMapRuntime                       string `json:"srcmap-runtime"`
 	}
 	Version string
 }
 
 func (s *Solidity) makeArgs() []string {
 	p := []string{
-		"--combined-json", "bin,abi,userdoc,devdoc",
+		"--combined-json", "bin,bin-runtime,abi,userdoc,devdoc",
 		"--add-std",  // include standard lib contracts
 		"--optimize", // code optimizer switched on
 	}
 	if s.Major > 0 || s.Minor > 4 || s.Patch > 6 {
-		p[1] += ",metadata"
+		p[1] += ",metadata,srcmap,srcmap-runtime"
 	}
 	return p
 }
@@ -165,17 +171,20 @@ func (s *Solidity) run(cmd *exec.Cmd, source string) (map[string]*Contract, erro
 			return nil, fmt.Errorf("solc: error reading dev doc: %v", err)
 		}
 		contracts[name] = &Contract{
-			Code: "0x" + info.Bin,
+			Code:        "0x" + info.Bin,
+			RuntimeCode: "0x" + info.BinRuntime,
 			Info: ContractInfo{
-				Source:          source,
-				Language:        "Solidity",
-				LanguageVersion: s.Version,
-				CompilerVersion: s.Version,
-				CompilerOptions: strings.Join(s.makeArgs(), " "),
-				AbiDefinition:   abi,
-				UserDoc:         userdoc,
-				DeveloperDoc:    devdoc,
-				Metadata:        info.Metadata,
+				Source:           source,
+				Language:         "Solidity",
+				LanguageVersion:  s.Version,
+				CompilerVersion:  s.Version,
+				CompilerOptions:  strings.Join(s.makeArgs(), " "),
+				SourceMap:        info.SrcMap,
+				SourceMapRuntime: info.SrcMapRuntime,
+				AbiDefinition:    abi,
+				UserDoc:          userdoc,
+				DeveloperDoc:     devdoc,
+				Metadata:         info.Metadata,
 			},
 		}
 	}
@@ -0,0 +1,320 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultOutputSelection is the output requested from the compiler for all the
+// contracts of all the sources if no explicit selection is made.
+var DefaultOutputSelection = map[string]map[string][]string{
+	"*": {
+		"*": {
+			"abi", "metadata", "userdoc", "devdoc",
+			"evm.bytecode.object", "evm.bytecode.sourceMap",
+			"evm.deployedBytecode.object", "evm.deployedBytecode.sourceMap",
+		},
+	},
+}
+
+// StandardInput is the compilation request of the solc standard JSON interface.
+type StandardInput struct {
+	Language string                    `json:"language"`
+	Sources  map[string]StandardSource `json:"sources"`
+	Settings StandardSettings          `json:"settings"`
+
+	paths []string // Directories of the source files, allowed for imports
+}
+
+// StandardSource is a single source unit of a standard JSON compilation request,
+// either specified inline or via a list of URLs.
+type StandardSource struct {
+	Content string   `json:"content,omitempty"`
+	URLs    []string `json:"urls,omitempty"`
+}
+
+// StandardSettings contains the compiler options of a standard JSON compilation.
+type StandardSettings struct {
+	Remappings      []string                       `json:"remappings,omitempty"`
+	Optimizer       StandardOptimizer              `json:"optimizer"`
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+// StandardOptimizer contains the optimizer settings of a standard JSON compilation.
+type StandardOptimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs"`
+}
+
+// NewStandardInput creates an empty Solidity compilation request with the code
+// optimizer switched on and the default output selection.
+func NewStandardInput() *StandardInput {
+	return &StandardInput{
+		Language: "Solidity",
+		Sources:  make(map[string]StandardSource),
+		Settings: StandardSettings{
+			Optimizer:       StandardOptimizer{Enabled: true, Runs: 200},
+			OutputSelection: DefaultOutputSelection,
+		},
+	}
+}
+
+// AddSource adds an inline source unit to the compilation request.
+func (in *StandardInput) AddSource(name, content string) {
+	in.Sources[name] = StandardSource{Content: content}
+}
+
+// AddSourceFile reads a source file and adds it to the compilation request under
+// its path, allowing the compiler to resolve imports relative to it.
+func (in *StandardInput) AddSourceFile(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	in.AddSource(path, string(content))
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	in.paths = append(in.paths, dir)
+	return nil
+}
+
+// allowedPaths returns the directories the compiler may read imports from: the
+// ones of the source files and the targets of the import remappings.
+func (in *StandardInput) allowedPaths() ([]string, error) {
+	paths := append([]string{}, in.paths...)
+	for _, remapping := range in.Settings.Remappings {
+		// Remappings are of the form [context:]prefix=target
+		eq := strings.Index(remapping, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("solc: invalid import remapping %q", remapping)
+		}
+		target, err := filepath.Abs(remapping[eq+1:])
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, target)
+	}
+	return paths, nil
+}
+
+// source returns the concatenated content of all the inline sources, in name
+// order, for reporting in the contract infos.
+func (in *StandardInput) source() string {
+	names := make([]string, 0, len(in.Sources))
+	for name := range in.Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var concat bytes.Buffer
+	for _, name := range names {
+		concat.WriteString(in.Sources[name].Content)
+	}
+	return concat.String()
+}
+
+// StandardError is an error or warning reported by the standard JSON interface.
+type StandardError struct {
+	Type             string `json:"type"`
+	Component        string `json:"component"`
+	Severity         string `json:"severity"`
+	Message          string `json:"message"`
+	FormattedMessage string `json:"formattedMessage"`
+}
+
+// Error implements error, preferring the formatted message if there is one.
+func (err *StandardError) Error() string {
+	if err.FormattedMessage != "" {
+		return strings.TrimSpace(err.FormattedMessage)
+	}
+	return fmt.Sprintf("%s: %s", err.Type, err.Message)
+}
+
+// standardOutput is the compilation result of the solc standard JSON interface.
+type standardOutput struct {
+	Errors    []*StandardError                       `json:"errors"`
+	Contracts map[string]map[string]standardContract `json:"contracts"`
+}
+
+// standardContract is the compilation result of a single contract.
+type standardContract struct {
+	ABI      json.RawMessage `json:"abi"`
+	Metadata string          `json:"metadata"`
+	Userdoc  json.RawMessage `json:"userdoc"`
+	Devdoc   json.RawMessage `json:"devdoc"`
+	EVM      struct {
+		Bytecode         standardBytecode `json:"bytecode"`
+		DeployedBytecode standardBytecode `json:"deployedBytecode"`
+	} `json:"evm"`
+}
+
+// standardBytecode is the bytecode of a compiled contract, with its source map.
+type standardBytecode struct {
+	Object    string `json:"object"`
+	SourceMap string `json:"sourceMap"`
+}
+
+// standardMetadata is the subset of the contract metadata used to fill in the
+// contract infos when parsing stored compilation artifacts.
+type standardMetadata struct {
+	Compiler struct {
+		Version string `json:"version"`
+	} `json:"compiler"`
+	Language string          `json:"language"`
+	Settings json.RawMessage `json:"settings"`
+}
+
+// SupportsStandardJSON reports whether the compiler has the standard JSON
+// interface, which was introduced in solc 0.4.11.
+func (s *Solidity) SupportsStandardJSON() bool {
+	return s.Major > 0 || s.Minor > 4 || (s.Minor == 4 && s.Patch >= 11)
+}
+
+// CompileStandard compiles the given request through the standard JSON interface
+// of the compiler, returning all the contracts from all the sources, named by
+// their source unit and contract name separated by a colon.
+func CompileStandard(solc string, input *StandardInput) (map[string]*Contract, error) {
+	if len(input.Sources) == 0 {
+		return nil, errors.New("solc: no sources")
+	}
+	s, err := SolidityVersion(solc)
+	if err != nil {
+		return nil, err
+	}
+	if !s.SupportsStandardJSON() {
+		return nil, fmt.Errorf("solc: standard JSON not supported by version %s", s.Version)
+	}
+	blob, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--standard-json"}
+	paths, err := input.allowedPaths()
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) > 0 {
+		args = append(args, "--allow-paths", strings.Join(paths, ","))
+	}
+	var stderr, stdout bytes.Buffer
+	cmd := exec.Command(s.Path, args...)
+	cmd.Stdin = bytes.NewReader(blob)
+	cmd.Stderr = &stderr
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("solc: %v\n%s", err, stderr.Bytes())
+	}
+	settings, _ := json.Marshal(input.Settings)
+
+	info := ContractInfo{
+		Source:          input.source(),
+		Language:        input.Language,
+		LanguageVersion: s.Version,
+		CompilerVersion: s.Version,
+		CompilerOptions: string(settings),
+	}
+	return parseStandardOutput(stdout.Bytes(), info)
+}
+
+// ParseStandardOutput parses a stored compilation artifact produced by the solc
+// standard JSON interface. As the sources and the compiler invocation are not
+// part of the output, the contract infos are filled in from the metadata.
+func ParseStandardOutput(output []byte) (map[string]*Contract, error) {
+	return parseStandardOutput(output, ContractInfo{})
+}
+
+// parseStandardOutput parses the output of the standard JSON interface, using the
+// given template for all the contract infos not contained within.
+func parseStandardOutput(output []byte, template ContractInfo) (map[string]*Contract, error) {
+	var parsed standardOutput
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("solc: error reading standard JSON output: %v", err)
+	}
+	for _, err := range parsed.Errors {
+		if err.Severity == "error" {
+			return nil, fmt.Errorf("solc: %v", err)
+		}
+	}
+	contracts := make(map[string]*Contract)
+	for source, units := range parsed.Contracts {
+		for name, unit := range units {
+			info := template
+			if err := unmarshalOptional(unit.ABI, &info.AbiDefinition); err != nil {
+				return nil, fmt.Errorf("solc: error reading abi definition of %s (%v)", name, err)
+			}
+			if err := unmarshalOptional(unit.Userdoc, &info.UserDoc); err != nil {
+				return nil, fmt.Errorf("solc: error reading user doc of %s: %v", name, err)
+			}
+			if err := unmarshalOptional(unit.Devdoc, &info.DeveloperDoc); err != nil {
+				return nil, fmt.Errorf("solc: error reading dev doc of %s: %v", name, err)
+			}
+			info.Metadata = unit.Metadata
+			info.SourceMap = unit.EVM.Bytecode.SourceMap
+			info.SourceMapRuntime = unit.EVM.DeployedBytecode.SourceMap
+
+			// Fill any gaps in the compiler details from the contract metadata
+			if unit.Metadata != "" {
+				var meta standardMetadata
+				if err := json.Unmarshal([]byte(unit.Metadata), &meta); err != nil {
+					return nil, fmt.Errorf("solc: error reading metadata of %s: %v", name, err)
+				}
+				if info.Language == "" {
+					info.Language = meta.Language
+				}
+				if info.CompilerVersion == "" {
+					info.CompilerVersion = meta.Compiler.Version
+					if version := versionRegexp.FindString(meta.Compiler.Version); version != "" {
+						info.LanguageVersion = version
+					}
+				}
+				if info.CompilerOptions == "" && len(meta.Settings) > 0 {
+					info.CompilerOptions = string(meta.Settings)
+				}
+			}
+			contract := &Contract{Info: info}
+			if unit.EVM.Bytecode.Object != "" {
+				contract.Code = "0x" + unit.EVM.Bytecode.Object
+			}
+			if unit.EVM.DeployedBytecode.Object != "" {
+				contract.RuntimeCode = "0x" + unit.EVM.DeployedBytecode.Object
+			}
+			contracts[source+":"+name] = contract
+		}
+	}
+	return contracts, nil
+}
+
+// unmarshalOptional decodes a JSON value if it was present in the output.
+func unmarshalOptional(blob json.RawMessage, val interface{}) error {
+	if len(blob) == 0 {
+		return nil
+	}
+	return json.Unmarshal(blob, val)
+}
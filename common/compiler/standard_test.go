@@ -0,0 +1,207 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testStandardOutput is a trimmed down standard JSON compilation artifact of the
+// test contract, as produced by solc 0.4.24.
+const testStandardOutput = `{
+	"contracts": {
+		"test.sol": {
+			"test": {
+				"abi": [{"constant":false,"inputs":[{"name":"a","type":"uint256"}],"name":"multiply","outputs":[{"name":"d","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"}],
+				"devdoc": {"methods": {}},
+				"userdoc": {"methods": {"multiply(uint256)": {"notice": "Will multiply ` + "`a`" + ` by 7."}}},
+				"metadata": "{\"compiler\":{\"version\":\"0.4.24+commit.e67f0147\"},\"language\":\"Solidity\",\"settings\":{\"optimizer\":{\"enabled\":true,\"runs\":200}}}",
+				"evm": {
+					"bytecode": {"object": "6080604052", "sourceMap": "25:111:0:-;;;;;;;;"},
+					"deployedBytecode": {"object": "60806040", "sourceMap": "25:111:0:-;;;;;"}
+				}
+			}
+		}
+	},
+	"errors": [{"type": "Warning", "component": "general", "severity": "warning", "message": "No visibility specified.", "formattedMessage": "test.sol:3:4: Warning: No visibility specified."}],
+	"sources": {"test.sol": {"id": 0}}
+}`
+
+// Tests that stored standard JSON artifacts are parsed into contracts, with the
+// compiler details filled in from the metadata.
+func TestParseStandardOutput(t *testing.T) {
+	contracts, err := ParseStandardOutput([]byte(testStandardOutput))
+	if err != nil {
+		t.Fatalf("failed to parse standard JSON output: %v", err)
+	}
+	if len(contracts) != 1 {
+		t.Fatalf("one contract expected, got %d", len(contracts))
+	}
+	c, ok := contracts["test.sol:test"]
+	if !ok {
+		t.Fatal("info for contract 'test.sol:test' not present in result")
+	}
+	if c.Code != "0x6080604052" || c.RuntimeCode != "0x60806040" {
+		t.Errorf("code mismatch: have %s/%s, want 0x6080604052/0x60806040", c.Code, c.RuntimeCode)
+	}
+	if c.Info.SourceMap != "25:111:0:-;;;;;;;;" || c.Info.SourceMapRuntime != "25:111:0:-;;;;;" {
+		t.Errorf("source map mismatch: have %s/%s", c.Info.SourceMap, c.Info.SourceMapRuntime)
+	}
+	if c.Info.Language != "Solidity" || c.Info.LanguageVersion != "0.4.24" || c.Info.CompilerVersion != "0.4.24+commit.e67f0147" {
+		t.Errorf("compiler infos mismatch: have %s %s %s", c.Info.Language, c.Info.LanguageVersion, c.Info.CompilerVersion)
+	}
+	if c.Info.CompilerOptions != `{"optimizer":{"enabled":true,"runs":200}}` {
+		t.Errorf("compiler options mismatch: have %s", c.Info.CompilerOptions)
+	}
+	if abi, _ := json.Marshal(c.Info.AbiDefinition); len(abi) == 0 || string(abi) == "null" {
+		t.Errorf("missing abi definition")
+	}
+	if c.Info.UserDoc == nil || c.Info.DeveloperDoc == nil || c.Info.Metadata == "" {
+		t.Errorf("missing documentation or metadata")
+	}
+	// Compilation errors should be reported, warnings not
+	failed := `{"errors": [{"type": "ParserError", "severity": "error", "message": "Expected pragma.", "formattedMessage": "test.sol:1:1: ParserError: Expected pragma."}]}`
+	if _, err := ParseStandardOutput([]byte(failed)); err == nil {
+		t.Errorf("compilation error not reported")
+	}
+}
+
+func TestCompileStandard(t *testing.T) {
+	skipWithoutSolc(t)
+
+	s, err := SolidityVersion("")
+	if err != nil {
+		t.Fatalf("failed to retrieve compiler version: %v", err)
+	}
+	if !s.SupportsStandardJSON() {
+		t.Skipf("solc %s has no standard JSON interface", s.Version)
+	}
+	input := NewStandardInput()
+	input.AddSource("test.sol", testSource)
+
+	contracts, err := CompileStandard("", input)
+	if err != nil {
+		t.Fatalf("error compiling source. result %v: %v", contracts, err)
+	}
+	c, ok := contracts["test.sol:test"]
+	if !ok {
+		t.Fatal("info for contract 'test.sol:test' not present in result")
+	}
+	if c.Code == "" || c.RuntimeCode == "" {
+		t.Error("empty code")
+	}
+	if c.Info.SourceMap == "" {
+		t.Error("empty source map")
+	}
+	if c.Info.Source != testSource {
+		t.Error("wrong source")
+	}
+}
+
+// Tests that the compiler is allowed to read imports from the directories of the
+// source files as well as from the targets of the import remappings.
+func TestAllowedPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "src", "test.sol")
+	if err := os.MkdirAll(filepath.Dir(source), 0700); err != nil {
+		t.Fatalf("failed to create source directory: %v", err)
+	}
+	if err := ioutil.WriteFile(source, []byte(testSource), 0600); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+	input := NewStandardInput()
+	if err := input.AddSourceFile(source); err != nil {
+		t.Fatalf("failed to add source file: %v", err)
+	}
+	input.Settings.Remappings = []string{"lib=" + filepath.Join(dir, "lib"), "src/test.sol:math=vendor/math"}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to retrieve working directory: %v", err)
+	}
+	paths, err := input.allowedPaths()
+	if err != nil {
+		t.Fatalf("failed to assemble allowed paths: %v", err)
+	}
+	want := []string{filepath.Join(dir, "src"), filepath.Join(dir, "lib"), filepath.Join(cwd, "vendor", "math")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("allowed paths mismatch: have %v, want %v", paths, want)
+	}
+	input.Settings.Remappings = []string{"lib"}
+	if _, err := input.allowedPaths(); err == nil {
+		t.Errorf("invalid remapping accepted")
+	}
+}
+
+func TestCompileStandardRemapping(t *testing.T) {
+	skipWithoutSolc(t)
+
+	s, err := SolidityVersion("")
+	if err != nil {
+		t.Fatalf("failed to retrieve compiler version: %v", err)
+	}
+	if !s.SupportsStandardJSON() {
+		t.Skipf("solc %s has no standard JSON interface", s.Version)
+	}
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Place the imported library outside of the source directory
+	for path, content := range map[string]string{
+		filepath.Join(dir, "lib", "test.sol"): testSource,
+		filepath.Join(dir, "src", "user.sol"): "import \"lib/test.sol\";\ncontract user is test {}\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatalf("failed to create source directory: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write source: %v", err)
+		}
+	}
+	input := NewStandardInput()
+	if err := input.AddSourceFile(filepath.Join(dir, "src", "user.sol")); err != nil {
+		t.Fatalf("failed to add source file: %v", err)
+	}
+	input.Settings.Remappings = []string{"lib/=" + filepath.Join(dir, "lib") + "/"}
+
+	contracts, err := CompileStandard("", input)
+	if err != nil {
+		t.Fatalf("error compiling remapped import: %v", err)
+	}
+	var found bool
+	for name := range contracts {
+		if filepath.Base(name) == "user.sol:user" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("contract 'user' not present in result: %v", contracts)
+	}
+}
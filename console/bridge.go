@@ -23,6 +23,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bazacoin/go-bazacoin/accounts/usbwallet"
 	"github.com/bazacoin/go-bazacoin/log"
 	"github.com/bazacoin/go-bazacoin/rpc"
 	"github.com/robertkrimen/otto"
@@ -126,6 +127,53 @@ func (b *bridge) UnlockAccount(call otto.FunctionCall) (response otto.Value) {
 	return val
 }
 
+// OpenWallet is a wrapper around personal.openWallet which can interpret and
+// react to certain error messages, such as the Trezor PIN matrix request.
+func (b *bridge) OpenWallet(call otto.FunctionCall) (response otto.Value) {
+	// Make sure we have a wallet specified to open
+	if !call.Argument(0).IsString() {
+		throwJSException("first argument must be the wallet URL to open")
+	}
+	wallet := call.Argument(0)
+
+	var passwd otto.Value
+	if call.Argument(1).IsUndefined() || call.Argument(1).IsNull() {
+		passwd, _ = otto.ToValue("")
+	} else {
+		passwd = call.Argument(1)
+	}
+	// Open the wallet, prompting for any secrets the device requests. A Trezor
+	// may request a PIN first and a passphrase afterwards, so loop until done.
+	for {
+		val, err := call.Otto.Call("jbzc.openWallet", nil, wallet, passwd)
+		if err == nil {
+			return val
+		}
+		// Wallet open failed, report error unless it's a PIN or passphrase request
+		var input string
+		switch {
+		case strings.HasSuffix(err.Error(), usbwallet.ErrTrezorPINNeeded.Error()):
+			// Trezor PIN matrix input requested, display the matrix to the user and fetch the data
+			fmt.Fprintf(b.printer, "Look at the device for number positions\n\n")
+			fmt.Fprintf(b.printer, "7 | 8 | 9\n")
+			fmt.Fprintf(b.printer, "--+---+--\n")
+			fmt.Fprintf(b.printer, "4 | 5 | 6\n")
+			fmt.Fprintf(b.printer, "--+---+--\n")
+			fmt.Fprintf(b.printer, "1 | 2 | 3\n\n")
+
+			input, err = b.prompter.PromptPassword("Please enter current PIN: ")
+
+		case strings.HasSuffix(err.Error(), usbwallet.ErrTrezorPassphraseNeeded.Error()):
+			// Trezor wallet passphrase requested, fetch it from the user
+			input, err = b.prompter.PromptPassword("Please enter wallet passphrase: ")
+		}
+		if err != nil {
+			throwJSException(err.Error())
+		}
+		passwd, _ = otto.ToValue(input)
+	}
+}
+
 // Sign is a wrapper around the personal.sign RPC method that uses a non-echoing password
 // prompt to acquire the passphrase and executes the original RPC method (saved in
 // jbzc.sign) with it to actually execute the RPC call.
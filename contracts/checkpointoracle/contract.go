@@ -0,0 +1,647 @@
+// This file is an automatically generated Go binding. Do not modify as any
+// change will likely be lost upon the next re-generation!
+
+package checkpointoracle
+
+import (
+	"strings"
+
+	"github.com/bazacoin/go-bazacoin/accounts/abi"
+	"github.com/bazacoin/go-bazacoin/accounts/abi/bind"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/event"
+)
+
+// CheckpointOracleABI is the input ABI used to generate the binding from.
+const CheckpointOracleABI = "[{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"address\"}],\"name\":\"admins\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"threshold\",\"outputs\":[{\"name\":\"\",\"type\":\"uint64\"}],\"payable\":false,\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"uint64\"},{\"name\":\"\",\"type\":\"address\"}],\"name\":\"votes\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"name\":\"checkpoints\",\"outputs\":[{\"name\":\"sectionIndex\",\"type\":\"uint64\"},{\"name\":\"sectionHead\",\"type\":\"bytes32\"},{\"name\":\"chtRoot\",\"type\":\"bytes32\"},{\"name\":\"bloomRoot\",\"type\":\"bytes32\"}],\"payable\":false,\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"latestSection\",\"outputs\":[{\"name\":\"\",\"type\":\"uint64\"}],\"payable\":false,\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"latestHash\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"getAdmins\",\"outputs\":[{\"name\":\"\",\"type\":\"address[]\"}],\"payable\":false,\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"_index\",\"type\":\"uint64\"},{\"name\":\"_head\",\"type\":\"bytes32\"},{\"name\":\"_cht\",\"type\":\"bytes32\"},{\"name\":\"_bloom\",\"type\":\"bytes32\"}],\"name\":\"vote\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"type\":\"function\"},{\"inputs\":[{\"name\":\"_admins\",\"type\":\"address[]\"},{\"name\":\"_threshold\",\"type\":\"uint64\"}],\"payable\":false,\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"name\":\"index\",\"type\":\"uint64\"},{\"indexed\":false,\"name\":\"checkpointHash\",\"type\":\"bytes32\"},{\"indexed\":false,\"name\":\"signer\",\"type\":\"address\"}],\"name\":\"NewCheckpointVote\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"name\":\"index\",\"type\":\"uint64\"},{\"indexed\":false,\"name\":\"checkpointHash\",\"type\":\"bytes32\"}],\"name\":\"NewCheckpoint\",\"type\":\"event\"}]"
+
+// CheckpointOracle is an auto generated Go binding around an Bazacoin contract.
+type CheckpointOracle struct {
+	CheckpointOracleCaller     // Read-only binding to the contract
+	CheckpointOracleTransactor // Write-only binding to the contract
+	CheckpointOracleFilterer   // Log filterer for contract events
+}
+
+// CheckpointOracleCaller is an auto generated read-only Go binding around an Bazacoin contract.
+type CheckpointOracleCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CheckpointOracleTransactor is an auto generated write-only Go binding around an Bazacoin contract.
+type CheckpointOracleTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CheckpointOracleFilterer is an auto generated log filtering Go binding around an Bazacoin contract events.
+type CheckpointOracleFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CheckpointOracleSession is an auto generated Go binding around an Bazacoin contract,
+// with pre-set call and transact options.
+type CheckpointOracleSession struct {
+	Contract     *CheckpointOracle // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// CheckpointOracleCallerSession is an auto generated read-only Go binding around an Bazacoin contract,
+// with pre-set call options.
+type CheckpointOracleCallerSession struct {
+	Contract *CheckpointOracleCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts           // Call options to use throughout this session
+}
+
+// CheckpointOracleTransactorSession is an auto generated write-only Go binding around an Bazacoin contract,
+// with pre-set transact options.
+type CheckpointOracleTransactorSession struct {
+	Contract     *CheckpointOracleTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts           // Transaction auth options to use throughout this session
+}
+
+// CheckpointOracleRaw is an auto generated low-level Go binding around an Bazacoin contract.
+type CheckpointOracleRaw struct {
+	Contract *CheckpointOracle // Generic contract binding to access the raw methods on
+}
+
+// CheckpointOracleCallerRaw is an auto generated low-level read-only Go binding around an Bazacoin contract.
+type CheckpointOracleCallerRaw struct {
+	Contract *CheckpointOracleCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// CheckpointOracleTransactorRaw is an auto generated low-level write-only Go binding around an Bazacoin contract.
+type CheckpointOracleTransactorRaw struct {
+	Contract *CheckpointOracleTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewCheckpointOracle creates a new instance of CheckpointOracle, bound to a specific deployed contract.
+func NewCheckpointOracle(address common.Address, backend bind.ContractBackend) (*CheckpointOracle, error) {
+	contract, err := bindCheckpointOracle(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracle{CheckpointOracleCaller: CheckpointOracleCaller{contract: contract}, CheckpointOracleTransactor: CheckpointOracleTransactor{contract: contract}, CheckpointOracleFilterer: CheckpointOracleFilterer{contract: contract}}, nil
+}
+
+// NewCheckpointOracleCaller creates a new read-only instance of CheckpointOracle, bound to a specific deployed contract.
+func NewCheckpointOracleCaller(address common.Address, caller bind.ContractCaller) (*CheckpointOracleCaller, error) {
+	contract, err := bindCheckpointOracle(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleCaller{contract: contract}, nil
+}
+
+// NewCheckpointOracleTransactor creates a new write-only instance of CheckpointOracle, bound to a specific deployed contract.
+func NewCheckpointOracleTransactor(address common.Address, transactor bind.ContractTransactor) (*CheckpointOracleTransactor, error) {
+	contract, err := bindCheckpointOracle(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleTransactor{contract: contract}, nil
+}
+
+// NewCheckpointOracleFilterer creates a new log filterer instance of CheckpointOracle, bound to a specific deployed contract.
+func NewCheckpointOracleFilterer(address common.Address, filterer bind.ContractFilterer) (*CheckpointOracleFilterer, error) {
+	contract, err := bindCheckpointOracle(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleFilterer{contract: contract}, nil
+}
+
+// bindCheckpointOracle binds a generic wrapper to an already deployed contract.
+func bindCheckpointOracle(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(CheckpointOracleABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_CheckpointOracle *CheckpointOracleRaw) Call(opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+	return _CheckpointOracle.Contract.CheckpointOracleCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_CheckpointOracle *CheckpointOracleRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.CheckpointOracleTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_CheckpointOracle *CheckpointOracleRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.CheckpointOracleTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_CheckpointOracle *CheckpointOracleCallerRaw) Call(opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+	return _CheckpointOracle.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_CheckpointOracle *CheckpointOracleTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_CheckpointOracle *CheckpointOracleTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.contract.Transact(opts, method, params...)
+}
+
+// Admins is a free data retrieval call binding the contract method 0x429b62e5.
+//
+// Solidity: function admins( address) constant returns(bool)
+func (_CheckpointOracle *CheckpointOracleCaller) Admins(opts *bind.CallOpts, arg0 common.Address) (bool, error) {
+	var (
+		ret0 = new(bool)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "admins", arg0)
+	return *ret0, err
+}
+
+// Admins is a free data retrieval call binding the contract method 0x429b62e5.
+//
+// Solidity: function admins( address) constant returns(bool)
+func (_CheckpointOracle *CheckpointOracleSession) Admins(arg0 common.Address) (bool, error) {
+	return _CheckpointOracle.Contract.Admins(&_CheckpointOracle.CallOpts, arg0)
+}
+
+// Admins is a free data retrieval call binding the contract method 0x429b62e5.
+//
+// Solidity: function admins( address) constant returns(bool)
+func (_CheckpointOracle *CheckpointOracleCallerSession) Admins(arg0 common.Address) (bool, error) {
+	return _CheckpointOracle.Contract.Admins(&_CheckpointOracle.CallOpts, arg0)
+}
+
+// Checkpoints is a free data retrieval call binding the contract method 0xeb5e91ff.
+//
+// Solidity: function checkpoints( bytes32) constant returns(sectionIndex uint64, sectionHead bytes32, chtRoot bytes32, bloomRoot bytes32)
+func (_CheckpointOracle *CheckpointOracleCaller) Checkpoints(opts *bind.CallOpts, arg0 [32]byte) (struct {
+	SectionIndex uint64
+	SectionHead  [32]byte
+	ChtRoot      [32]byte
+	BloomRoot    [32]byte
+}, error) {
+	ret := new(struct {
+		SectionIndex uint64
+		SectionHead  [32]byte
+		ChtRoot      [32]byte
+		BloomRoot    [32]byte
+	})
+	out := ret
+	err := _CheckpointOracle.contract.Call(opts, out, "checkpoints", arg0)
+	return *ret, err
+}
+
+// Checkpoints is a free data retrieval call binding the contract method 0xeb5e91ff.
+//
+// Solidity: function checkpoints( bytes32) constant returns(sectionIndex uint64, sectionHead bytes32, chtRoot bytes32, bloomRoot bytes32)
+func (_CheckpointOracle *CheckpointOracleSession) Checkpoints(arg0 [32]byte) (struct {
+	SectionIndex uint64
+	SectionHead  [32]byte
+	ChtRoot      [32]byte
+	BloomRoot    [32]byte
+}, error) {
+	return _CheckpointOracle.Contract.Checkpoints(&_CheckpointOracle.CallOpts, arg0)
+}
+
+// Checkpoints is a free data retrieval call binding the contract method 0xeb5e91ff.
+//
+// Solidity: function checkpoints( bytes32) constant returns(sectionIndex uint64, sectionHead bytes32, chtRoot bytes32, bloomRoot bytes32)
+func (_CheckpointOracle *CheckpointOracleCallerSession) Checkpoints(arg0 [32]byte) (struct {
+	SectionIndex uint64
+	SectionHead  [32]byte
+	ChtRoot      [32]byte
+	BloomRoot    [32]byte
+}, error) {
+	return _CheckpointOracle.Contract.Checkpoints(&_CheckpointOracle.CallOpts, arg0)
+}
+
+// GetAdmins is a free data retrieval call binding the contract method 0x31ae450b.
+//
+// Solidity: function getAdmins() constant returns(address[])
+func (_CheckpointOracle *CheckpointOracleCaller) GetAdmins(opts *bind.CallOpts) ([]common.Address, error) {
+	var (
+		ret0 = new([]common.Address)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "getAdmins")
+	return *ret0, err
+}
+
+// GetAdmins is a free data retrieval call binding the contract method 0x31ae450b.
+//
+// Solidity: function getAdmins() constant returns(address[])
+func (_CheckpointOracle *CheckpointOracleSession) GetAdmins() ([]common.Address, error) {
+	return _CheckpointOracle.Contract.GetAdmins(&_CheckpointOracle.CallOpts)
+}
+
+// GetAdmins is a free data retrieval call binding the contract method 0x31ae450b.
+//
+// Solidity: function getAdmins() constant returns(address[])
+func (_CheckpointOracle *CheckpointOracleCallerSession) GetAdmins() ([]common.Address, error) {
+	return _CheckpointOracle.Contract.GetAdmins(&_CheckpointOracle.CallOpts)
+}
+
+// LatestHash is a free data retrieval call binding the contract method 0x6f17d258.
+//
+// Solidity: function latestHash() constant returns(bytes32)
+func (_CheckpointOracle *CheckpointOracleCaller) LatestHash(opts *bind.CallOpts) ([32]byte, error) {
+	var (
+		ret0 = new([32]byte)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "latestHash")
+	return *ret0, err
+}
+
+// LatestHash is a free data retrieval call binding the contract method 0x6f17d258.
+//
+// Solidity: function latestHash() constant returns(bytes32)
+func (_CheckpointOracle *CheckpointOracleSession) LatestHash() ([32]byte, error) {
+	return _CheckpointOracle.Contract.LatestHash(&_CheckpointOracle.CallOpts)
+}
+
+// LatestHash is a free data retrieval call binding the contract method 0x6f17d258.
+//
+// Solidity: function latestHash() constant returns(bytes32)
+func (_CheckpointOracle *CheckpointOracleCallerSession) LatestHash() ([32]byte, error) {
+	return _CheckpointOracle.Contract.LatestHash(&_CheckpointOracle.CallOpts)
+}
+
+// LatestSection is a free data retrieval call binding the contract method 0xcde52e30.
+//
+// Solidity: function latestSection() constant returns(uint64)
+func (_CheckpointOracle *CheckpointOracleCaller) LatestSection(opts *bind.CallOpts) (uint64, error) {
+	var (
+		ret0 = new(uint64)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "latestSection")
+	return *ret0, err
+}
+
+// LatestSection is a free data retrieval call binding the contract method 0xcde52e30.
+//
+// Solidity: function latestSection() constant returns(uint64)
+func (_CheckpointOracle *CheckpointOracleSession) LatestSection() (uint64, error) {
+	return _CheckpointOracle.Contract.LatestSection(&_CheckpointOracle.CallOpts)
+}
+
+// LatestSection is a free data retrieval call binding the contract method 0xcde52e30.
+//
+// Solidity: function latestSection() constant returns(uint64)
+func (_CheckpointOracle *CheckpointOracleCallerSession) LatestSection() (uint64, error) {
+	return _CheckpointOracle.Contract.LatestSection(&_CheckpointOracle.CallOpts)
+}
+
+// Threshold is a free data retrieval call binding the contract method 0x42cde4e8.
+//
+// Solidity: function threshold() constant returns(uint64)
+func (_CheckpointOracle *CheckpointOracleCaller) Threshold(opts *bind.CallOpts) (uint64, error) {
+	var (
+		ret0 = new(uint64)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "threshold")
+	return *ret0, err
+}
+
+// Threshold is a free data retrieval call binding the contract method 0x42cde4e8.
+//
+// Solidity: function threshold() constant returns(uint64)
+func (_CheckpointOracle *CheckpointOracleSession) Threshold() (uint64, error) {
+	return _CheckpointOracle.Contract.Threshold(&_CheckpointOracle.CallOpts)
+}
+
+// Threshold is a free data retrieval call binding the contract method 0x42cde4e8.
+//
+// Solidity: function threshold() constant returns(uint64)
+func (_CheckpointOracle *CheckpointOracleCallerSession) Threshold() (uint64, error) {
+	return _CheckpointOracle.Contract.Threshold(&_CheckpointOracle.CallOpts)
+}
+
+// Votes is a free data retrieval call binding the contract method 0xa060c2aa.
+//
+// Solidity: function votes( uint64,  address) constant returns(bytes32)
+func (_CheckpointOracle *CheckpointOracleCaller) Votes(opts *bind.CallOpts, arg0 uint64, arg1 common.Address) ([32]byte, error) {
+	var (
+		ret0 = new([32]byte)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "votes", arg0, arg1)
+	return *ret0, err
+}
+
+// Votes is a free data retrieval call binding the contract method 0xa060c2aa.
+//
+// Solidity: function votes( uint64,  address) constant returns(bytes32)
+func (_CheckpointOracle *CheckpointOracleSession) Votes(arg0 uint64, arg1 common.Address) ([32]byte, error) {
+	return _CheckpointOracle.Contract.Votes(&_CheckpointOracle.CallOpts, arg0, arg1)
+}
+
+// Votes is a free data retrieval call binding the contract method 0xa060c2aa.
+//
+// Solidity: function votes( uint64,  address) constant returns(bytes32)
+func (_CheckpointOracle *CheckpointOracleCallerSession) Votes(arg0 uint64, arg1 common.Address) ([32]byte, error) {
+	return _CheckpointOracle.Contract.Votes(&_CheckpointOracle.CallOpts, arg0, arg1)
+}
+
+// Vote is a paid mutator transaction binding the contract method 0xff0aee64.
+//
+// Solidity: function vote(_index uint64, _head bytes32, _cht bytes32, _bloom bytes32) returns(bool)
+func (_CheckpointOracle *CheckpointOracleTransactor) Vote(opts *bind.TransactOpts, _index uint64, _head [32]byte, _cht [32]byte, _bloom [32]byte) (*types.Transaction, error) {
+	return _CheckpointOracle.contract.Transact(opts, "vote", _index, _head, _cht, _bloom)
+}
+
+// Vote is a paid mutator transaction binding the contract method 0xff0aee64.
+//
+// Solidity: function vote(_index uint64, _head bytes32, _cht bytes32, _bloom bytes32) returns(bool)
+func (_CheckpointOracle *CheckpointOracleSession) Vote(_index uint64, _head [32]byte, _cht [32]byte, _bloom [32]byte) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.Vote(&_CheckpointOracle.TransactOpts, _index, _head, _cht, _bloom)
+}
+
+// Vote is a paid mutator transaction binding the contract method 0xff0aee64.
+//
+// Solidity: function vote(_index uint64, _head bytes32, _cht bytes32, _bloom bytes32) returns(bool)
+func (_CheckpointOracle *CheckpointOracleTransactorSession) Vote(_index uint64, _head [32]byte, _cht [32]byte, _bloom [32]byte) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.Vote(&_CheckpointOracle.TransactOpts, _index, _head, _cht, _bloom)
+}
+
+// CheckpointOracleNewCheckpointIterator is returned from FilterNewCheckpoint and is used to iterate over the raw logs and unpacked data for NewCheckpoint events raised by the CheckpointOracle contract.
+type CheckpointOracleNewCheckpointIterator struct {
+	Event *CheckpointOracleNewCheckpoint // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log     // Log channel receiving the found contract events
+	sub  event.Subscription // Subscription for errors, completion and termination
+	done bool               // Whether the subscription completed delivering logs
+	fail error              // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *CheckpointOracleNewCheckpointIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(CheckpointOracleNewCheckpoint)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(CheckpointOracleNewCheckpoint)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *CheckpointOracleNewCheckpointIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *CheckpointOracleNewCheckpointIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// CheckpointOracleNewCheckpoint represents a NewCheckpoint event raised by the CheckpointOracle contract.
+type CheckpointOracleNewCheckpoint struct {
+	Index          uint64
+	CheckpointHash [32]byte
+	Raw            types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewCheckpoint is a free log retrieval operation binding the contract event 0x6ed2a44ae7c4d8df24691e0a03c6dc555ff35892b002305ae60ab84d3ea99af1.
+//
+// Solidity: event NewCheckpoint(uint64 indexed index, bytes32 checkpointHash)
+func (_CheckpointOracle *CheckpointOracleFilterer) FilterNewCheckpoint(opts *bind.FilterOpts, index []uint64) (*CheckpointOracleNewCheckpointIterator, error) {
+
+	var indexRule []interface{}
+	for _, indexItem := range index {
+		indexRule = append(indexRule, indexItem)
+	}
+
+	logs, sub, err := _CheckpointOracle.contract.FilterLogs(opts, "NewCheckpoint", indexRule)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleNewCheckpointIterator{contract: _CheckpointOracle.contract, event: "NewCheckpoint", logs: logs, sub: sub}, nil
+}
+
+// WatchNewCheckpoint is a free log subscription operation binding the contract event 0x6ed2a44ae7c4d8df24691e0a03c6dc555ff35892b002305ae60ab84d3ea99af1.
+//
+// Solidity: event NewCheckpoint(uint64 indexed index, bytes32 checkpointHash)
+func (_CheckpointOracle *CheckpointOracleFilterer) WatchNewCheckpoint(opts *bind.WatchOpts, sink chan<- *CheckpointOracleNewCheckpoint, index []uint64) (event.Subscription, error) {
+
+	var indexRule []interface{}
+	for _, indexItem := range index {
+		indexRule = append(indexRule, indexItem)
+	}
+
+	logs, sub, err := _CheckpointOracle.contract.WatchLogs(opts, "NewCheckpoint", indexRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(CheckpointOracleNewCheckpoint)
+				if err := _CheckpointOracle.contract.UnpackLog(event, "NewCheckpoint", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// CheckpointOracleNewCheckpointVoteIterator is returned from FilterNewCheckpointVote and is used to iterate over the raw logs and unpacked data for NewCheckpointVote events raised by the CheckpointOracle contract.
+type CheckpointOracleNewCheckpointVoteIterator struct {
+	Event *CheckpointOracleNewCheckpointVote // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log     // Log channel receiving the found contract events
+	sub  event.Subscription // Subscription for errors, completion and termination
+	done bool               // Whether the subscription completed delivering logs
+	fail error              // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *CheckpointOracleNewCheckpointVoteIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(CheckpointOracleNewCheckpointVote)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(CheckpointOracleNewCheckpointVote)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *CheckpointOracleNewCheckpointVoteIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *CheckpointOracleNewCheckpointVoteIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// CheckpointOracleNewCheckpointVote represents a NewCheckpointVote event raised by the CheckpointOracle contract.
+type CheckpointOracleNewCheckpointVote struct {
+	Index          uint64
+	CheckpointHash [32]byte
+	Signer         common.Address
+	Raw            types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewCheckpointVote is a free log retrieval operation binding the contract event 0x4badc96e53a0ddeb3fd2d4b0090ebde839be92683884b88fa23aa224138ed35c.
+//
+// Solidity: event NewCheckpointVote(uint64 indexed index, bytes32 checkpointHash, address signer)
+func (_CheckpointOracle *CheckpointOracleFilterer) FilterNewCheckpointVote(opts *bind.FilterOpts, index []uint64) (*CheckpointOracleNewCheckpointVoteIterator, error) {
+
+	var indexRule []interface{}
+	for _, indexItem := range index {
+		indexRule = append(indexRule, indexItem)
+	}
+
+	logs, sub, err := _CheckpointOracle.contract.FilterLogs(opts, "NewCheckpointVote", indexRule)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleNewCheckpointVoteIterator{contract: _CheckpointOracle.contract, event: "NewCheckpointVote", logs: logs, sub: sub}, nil
+}
+
+// WatchNewCheckpointVote is a free log subscription operation binding the contract event 0x4badc96e53a0ddeb3fd2d4b0090ebde839be92683884b88fa23aa224138ed35c.
+//
+// Solidity: event NewCheckpointVote(uint64 indexed index, bytes32 checkpointHash, address signer)
+func (_CheckpointOracle *CheckpointOracleFilterer) WatchNewCheckpointVote(opts *bind.WatchOpts, sink chan<- *CheckpointOracleNewCheckpointVote, index []uint64) (event.Subscription, error) {
+
+	var indexRule []interface{}
+	for _, indexItem := range index {
+		indexRule = append(indexRule, indexItem)
+	}
+
+	logs, sub, err := _CheckpointOracle.contract.WatchLogs(opts, "NewCheckpointVote", indexRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(CheckpointOracleNewCheckpointVote)
+				if err := _CheckpointOracle.contract.UnpackLog(event, "NewCheckpointVote", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
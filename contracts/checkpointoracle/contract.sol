@@ -0,0 +1,111 @@
+// Copyright 2016 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// CheckpointOracle is a Bazacoin contract to publish trusted light client
+// checkpoints (section index, section head, CHT root and bloom trie root), so
+// that clients can pick up newer checkpoints without a new release.
+//
+// A fixed set of admins, chosen at deployment, vote on checkpoints. Once the
+// configured threshold of admins agree on the same checkpoint for a section, it
+// becomes the latest stable one. Votes are kept on chain, so clients can verify
+// the approval against their own list of trusted signers.
+contract CheckpointOracle {
+  // Checkpoint is the set of trie roots covering a single chain section.
+  struct Checkpoint {
+    uint64  sectionIndex;
+    bytes32 sectionHead;
+    bytes32 chtRoot;
+    bytes32 bloomRoot;
+  }
+
+  // NewCheckpointVote is fired whenever an admin votes on a checkpoint.
+  event NewCheckpointVote(uint64 indexed index, bytes32 checkpointHash, address signer);
+
+  // NewCheckpoint is fired when a checkpoint collects enough votes to become
+  // the latest stable one.
+  event NewCheckpoint(uint64 indexed index, bytes32 checkpointHash);
+
+  // Admins allowed to vote on checkpoints and the number of them needed to
+  // agree before a checkpoint is accepted.
+  mapping(address => bool) public admins;
+  address[] adminList;
+  uint64 public threshold;
+
+  // Votes of each admin per section and the checkpoints voted upon.
+  mapping(uint64 => mapping(address => bytes32)) public votes;
+  mapping(bytes32 => Checkpoint) public checkpoints;
+
+  // Latest checkpoint that reached the threshold.
+  uint64 public latestSection;
+  bytes32 public latestHash;
+
+  // isAdmin is a modifier to reject anyone not being an admin.
+  modifier isAdmin() {
+    if (!admins[msg.sender]) {
+      throw;
+    }
+    _;
+  }
+
+  // Constructor to create a checkpoint oracle with the given admins and the
+  // number of them required to approve a checkpoint. Duplicate admins count
+  // once, so the threshold is checked against the deduplicated list.
+  function CheckpointOracle(address[] _admins, uint64 _threshold) {
+    for (uint i = 0; i < _admins.length; i++) {
+      if (!admins[_admins[i]]) {
+        admins[_admins[i]] = true;
+        adminList.push(_admins[i]);
+      }
+    }
+    if (_threshold == 0 || _threshold > adminList.length) {
+      throw;
+    }
+    threshold = _threshold;
+  }
+
+  // getAdmins retrieves the list of admins allowed to vote on checkpoints.
+  function getAdmins() constant returns (address[]) {
+    return adminList;
+  }
+
+  // vote casts the sender's vote on the checkpoint of a section newer than the
+  // latest accepted one, returning whether it reached the threshold.
+  function vote(uint64 _index, bytes32 _head, bytes32 _cht, bytes32 _bloom) isAdmin returns (bool) {
+    if (latestHash != 0 && _index <= latestSection) {
+      throw;
+    }
+    bytes32 hash = sha3(_index, _head, _cht, _bloom);
+
+    checkpoints[hash] = Checkpoint(_index, _head, _cht, _bloom);
+    votes[_index][msg.sender] = hash;
+    NewCheckpointVote(_index, hash, msg.sender);
+
+    uint count = 0;
+    for (uint i = 0; i < adminList.length; i++) {
+      if (votes[_index][adminList[i]] == hash) {
+        count++;
+      }
+    }
+    if (count < threshold) {
+      return false;
+    }
+    latestSection = _index;
+    latestHash = hash;
+    NewCheckpoint(_index, hash);
+
+    return true;
+  }
+}
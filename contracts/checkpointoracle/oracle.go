@@ -0,0 +1,128 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package checkpointoracle is a wrapper of the checkpoint oracle contract, which
+// light clients use to pick up new trusted checkpoints without a client release.
+package checkpointoracle
+
+//go:generate abigen --abi ./contract.abi --pkg checkpointoracle --type CheckpointOracle --out ./contract.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bazacoin/go-bazacoin/accounts/abi/bind"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/params"
+)
+
+var (
+	errNoCheckpoint       = errors.New("no stable checkpoint")
+	errNotEnoughVotes     = errors.New("not enough trusted votes")
+	errCheckpointMismatch = errors.New("checkpoint doesn't match its hash")
+)
+
+// Oracle is a Go wrapper around an on-chain checkpoint oracle contract, which
+// only accepts checkpoints approved by enough of the locally trusted signers.
+type Oracle struct {
+	config   *params.CheckpointOracleConfig
+	contract *CheckpointOracle
+}
+
+// NewOracle binds the checkpoint oracle contract at the configured address and
+// returns a wrapper verifying its checkpoints against the configured signers.
+func NewOracle(config *params.CheckpointOracleConfig, backend bind.ContractBackend) (*Oracle, error) {
+	if config.Threshold == 0 || config.Threshold > uint64(len(config.Signers)) {
+		return nil, fmt.Errorf("invalid oracle threshold %d for %d signers", config.Threshold, len(config.Signers))
+	}
+	seen := make(map[common.Address]bool)
+	for _, signer := range config.Signers {
+		if seen[signer] {
+			return nil, fmt.Errorf("duplicate oracle signer %x", signer)
+		}
+		seen[signer] = true
+	}
+	contract, err := NewCheckpointOracle(config.Address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Oracle{config: config, contract: contract}, nil
+}
+
+// Contract returns the underlying contract binding.
+func (o *Oracle) Contract() *CheckpointOracle {
+	return o.contract
+}
+
+// LatestCheckpoint retrieves the latest stable checkpoint from the contract and
+// checks that at least the configured threshold of trusted signers voted on it.
+func (o *Oracle) LatestCheckpoint(ctx context.Context) (*params.TrustedCheckpoint, error) {
+	opts := &bind.CallOpts{Context: ctx}
+
+	latest, err := o.contract.LatestHash(opts)
+	if err != nil {
+		return nil, err
+	}
+	if latest == ([32]byte{}) {
+		return nil, errNoCheckpoint
+	}
+	index, err := o.contract.LatestSection(opts)
+	if err != nil {
+		return nil, err
+	}
+	// Collect the votes of the trusted signers and make sure enough agree
+	votes := make([]common.Hash, len(o.config.Signers))
+	for i, signer := range o.config.Signers {
+		if votes[i], err = o.contract.Votes(opts, index, signer); err != nil {
+			return nil, err
+		}
+	}
+	hash, ok := tallyVotes(votes, o.config.Threshold)
+	if !ok {
+		return nil, errNotEnoughVotes
+	}
+	// Retrieve the approved checkpoint and verify it against the voted hash
+	cp, err := o.contract.Checkpoints(opts, hash)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &params.TrustedCheckpoint{
+		SectionIndex: cp.SectionIndex,
+		SectionHead:  cp.SectionHead,
+		CHTRoot:      cp.ChtRoot,
+		BloomRoot:    cp.BloomRoot,
+	}
+	if checkpoint.SectionIndex != index || !checkpoint.HashEqual(hash) {
+		return nil, errCheckpointMismatch
+	}
+	return checkpoint, nil
+}
+
+// tallyVotes returns the checkpoint hash voted on by at least threshold of the
+// given votes, if any. Empty votes are ignored.
+func tallyVotes(votes []common.Hash, threshold uint64) (common.Hash, bool) {
+	counts := make(map[common.Hash]uint64)
+	for _, vote := range votes {
+		if vote == (common.Hash{}) {
+			continue
+		}
+		if counts[vote]++; counts[vote] >= threshold {
+			return vote, true
+		}
+	}
+	return common.Hash{}, false
+}
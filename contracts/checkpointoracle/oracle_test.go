@@ -0,0 +1,69 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package checkpointoracle
+
+import (
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/params"
+)
+
+// Tests that votes are only accepted if enough of them agree on the same hash.
+func TestTallyVotes(t *testing.T) {
+	a, b := common.HexToHash("0x01"), common.HexToHash("0x02")
+
+	tests := []struct {
+		votes     []common.Hash
+		threshold uint64
+		hash      common.Hash
+		ok        bool
+	}{
+		{nil, 1, common.Hash{}, false},
+		{[]common.Hash{a}, 1, a, true},
+		{[]common.Hash{a, b}, 2, common.Hash{}, false},
+		{[]common.Hash{a, {}, a}, 2, a, true},
+		{[]common.Hash{{}, {}, {}}, 2, common.Hash{}, false},
+		{[]common.Hash{b, a, b, a, a}, 3, a, true},
+	}
+	for i, tt := range tests {
+		hash, ok := tallyVotes(tt.votes, tt.threshold)
+		if hash != tt.hash || ok != tt.ok {
+			t.Errorf("test %d: tally mismatch: have (%x, %v), want (%x, %v)", i, hash, ok, tt.hash, tt.ok)
+		}
+	}
+}
+
+// Tests that invalid oracle configurations are rejected.
+func TestOracleConfig(t *testing.T) {
+	a, b := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+
+	tests := []struct {
+		config *params.CheckpointOracleConfig
+		ok     bool
+	}{
+		{&params.CheckpointOracleConfig{Signers: []common.Address{a, b}, Threshold: 0}, false},
+		{&params.CheckpointOracleConfig{Signers: []common.Address{a, b}, Threshold: 3}, false},
+		{&params.CheckpointOracleConfig{Signers: []common.Address{a, a}, Threshold: 2}, false},
+		{&params.CheckpointOracleConfig{Signers: []common.Address{a, b}, Threshold: 2}, true},
+	}
+	for i, tt := range tests {
+		if _, err := NewOracle(tt.config, nil); (err == nil) != tt.ok {
+			t.Errorf("test %d: error mismatch: have %v, want ok %v", i, err, tt.ok)
+		}
+	}
+}
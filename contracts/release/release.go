@@ -62,19 +62,20 @@ type ReleaseService struct {
 func NewReleaseService(ctx *node.ServiceContext, config Config) (node.Service, error) {
 	// Retrieve the Bazacoin service dependency to access the blockchain
 	var apiBackend bzcapi.Backend
+	var lightMode bool
 	var bazacoin *bzc.Bazacoin
 	if err := ctx.Service(&bazacoin); err == nil {
 		apiBackend = bazacoin.ApiBackend
 	} else {
 		var bazacoin *les.LightBazacoin
 		if err := ctx.Service(&bazacoin); err == nil {
-			apiBackend = bazacoin.ApiBackend
+			apiBackend, lightMode = bazacoin.ApiBackend, true
 		} else {
 			return nil, err
 		}
 	}
 	// Construct the release service
-	contract, err := NewReleaseOracle(config.Oracle, bzc.NewContractBackend(apiBackend))
+	contract, err := NewReleaseOracle(config.Oracle, bzc.NewContractBackend(apiBackend, lightMode))
 	if err != nil {
 		return nil, err
 	}
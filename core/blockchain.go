@@ -32,6 +32,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/common/mclock"
 	"github.com/bazacoin/go-bazacoin/consensus"
 	"github.com/bazacoin/go-bazacoin/core/state"
+	"github.com/bazacoin/go-bazacoin/core/state/snapshot"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/core/vm"
 	"github.com/bazacoin/go-bazacoin/crypto"
@@ -57,6 +58,7 @@ const (
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
 	badBlockLimit       = 10
+	snapshotLayers      = 128 // Number of in-memory diff layers kept on top of the disk snapshot
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	BlockChainVersion = 3
@@ -93,6 +95,7 @@ type BlockChain struct {
 	currentFastBlock *types.Block // Current head of the fast-sync chain (may be above the block chain!)
 
 	stateCache   *state.StateDB // State database to reuse between imports (contains state cache)
+	snaps        *snapshot.Tree // Flat state snapshot for constant time state reads
 	bodyCache    *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
 	blockCache   *lru.Cache     // Cache for the most recent entire blocks
@@ -214,8 +217,14 @@ func (bc *BlockChain) loadLastState() error {
 			bc.currentFastBlock = block
 		}
 	}
+	// Open the flat state snapshot, regenerating it if it doesn't cover the head
+	if bc.snaps == nil {
+		bc.snaps = snapshot.New(bc.chainDb, bc.currentBlock.Root())
+	} else if bc.snaps.Snapshot(bc.currentBlock.Root()) == nil {
+		bc.snaps.Rebuild(bc.currentBlock.Root())
+	}
 	// Initialize a statedb cache to ensure singleton account bloom filter generation
-	statedb, err := state.New(bc.currentBlock.Root(), bc.chainDb)
+	statedb, err := state.NewWithSnapshot(bc.currentBlock.Root(), bc.chainDb, bc.snaps)
 	if err != nil {
 		return err
 	}
@@ -603,9 +612,30 @@ func (bc *BlockChain) Stop() {
 	atomic.StoreInt32(&bc.procInterrupt, 1)
 
 	bc.wg.Wait()
+
+	// Flatten the state snapshot into the database so it survives a restart
+	if bc.snaps != nil {
+		if err := bc.snaps.Cap(bc.CurrentBlock().Root(), 0); err != nil {
+			log.Warn("Failed to persist state snapshot", "err", err)
+		}
+		bc.snaps.Stop()
+	}
 	log.Info("Blockchain manager stopped")
 }
 
+// capSnapshot flattens the state snapshot layers below the given head state,
+// or rebuilds the snapshot if the head is not covered (e.g. after a deep reorg
+// or a fast sync).
+func (bc *BlockChain) capSnapshot(root common.Hash) {
+	if bc.snaps.Snapshot(root) == nil {
+		bc.snaps.Rebuild(root)
+		return
+	}
+	if err := bc.snaps.Cap(root, snapshotLayers); err != nil {
+		log.Warn("Failed to flatten state snapshot", "root", root, "err", err)
+	}
+}
+
 func (bc *BlockChain) procFutureBlocks() {
 	blocks := make([]*types.Block, 0, bc.futureBlocks.Len())
 	for _, hash := range bc.futureBlocks.Keys() {
@@ -1008,6 +1038,9 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			blockInsertTimer.UpdateSince(bstart)
 			events = append(events, ChainEvent{block, block.Hash(), logs})
 
+			// Keep the flat state snapshot following the canonical head
+			bc.capSnapshot(block.Root())
+
 			// This puts transactions in a extra db for rpc
 			if err := WriteTransactions(bc.chainDb, block); err != nil {
 				return i, err
@@ -0,0 +1,88 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import "errors"
+
+var (
+	// errMissingData is returned from decompression if the byte references go
+	// beyond the end of the payload.
+	errMissingData = errors.New("missing bytes on input")
+
+	// errUnreferencedData is returned from decompression if not all bytes were
+	// used up from the input payload.
+	errUnreferencedData = errors.New("extra bytes on input")
+
+	// errExceededTarget is returned from decompression if the input payload is
+	// larger than the requested decompression target.
+	errExceededTarget = errors.New("target data size exceeded")
+)
+
+// CompressBytes compresses a sparse bit vector by replacing its zero bytes with
+// a bitmap marking which of them are non-zero, followed by the non-zero bytes
+// themselves. If the result would not be smaller, the input is returned as is.
+func CompressBytes(data []byte) []byte {
+	var (
+		nonZero = make([]byte, (len(data)+7)/8)
+		values  []byte
+	)
+	for i, b := range data {
+		if b != 0 {
+			nonZero[i/8] |= 1 << byte(7-i%8)
+			values = append(values, b)
+			if len(nonZero)+len(values) >= len(data) {
+				return data
+			}
+		}
+	}
+	return append(nonZero, values...)
+}
+
+// DecompressBytes decompresses data with a known target size. If the input is
+// the size of the target, it's assumed to be stored uncompressed.
+func DecompressBytes(data []byte, target int) ([]byte, error) {
+	if len(data) > target {
+		return nil, errExceededTarget
+	}
+	if len(data) == target {
+		cpy := make([]byte, len(data))
+		copy(cpy, data)
+		return cpy, nil
+	}
+	nonZero := (target + 7) / 8
+	if len(data) < nonZero {
+		return nil, errMissingData
+	}
+	var (
+		out = make([]byte, target)
+		pos = nonZero
+	)
+	for i := 0; i < target; i++ {
+		if data[i/8]&(1<<byte(7-i%8)) == 0 {
+			continue
+		}
+		if pos >= len(data) {
+			return nil, errMissingData
+		}
+		out[i] = data[pos]
+		pos++
+	}
+	if pos != len(data) {
+		return nil, errUnreferencedData
+	}
+	return out, nil
+}
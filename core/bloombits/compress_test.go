@@ -0,0 +1,73 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// Tests that compressed bit vectors decompress into the original data.
+func TestCompression(t *testing.T) {
+	tests := [][]byte{
+		{},
+		make([]byte, 512),
+		{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80},
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	for i := 0; i < 100; i++ {
+		data := make([]byte, 512)
+		for j := 0; j < rand.Intn(64); j++ {
+			data[rand.Intn(len(data))] = byte(rand.Intn(256))
+		}
+		tests = append(tests, data)
+	}
+	for i, data := range tests {
+		comp := CompressBytes(data)
+		if len(comp) > len(data) {
+			t.Errorf("test %d: compressed data larger than input: %d > %d", i, len(comp), len(data))
+		}
+		have, err := DecompressBytes(comp, len(data))
+		if err != nil {
+			t.Errorf("test %d: failed to decompress: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(have, data) {
+			t.Errorf("test %d: decompressed data mismatch: have %x, want %x", i, have, data)
+		}
+	}
+}
+
+// Tests that invalid compressed payloads are rejected.
+func TestDecompressionErrors(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		target int
+		err    error
+	}{
+		{[]byte{0x00, 0x00, 0x00}, 2, errExceededTarget},
+		{[]byte{}, 16, errMissingData},
+		{[]byte{0x80, 0x00}, 16, errMissingData},
+		{[]byte{0x00, 0x00, 0x01}, 16, errUnreferencedData},
+	}
+	for i, tt := range tests {
+		if _, err := DecompressBytes(tt.data, tt.target); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
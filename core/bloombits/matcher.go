@@ -0,0 +1,145 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"errors"
+
+	"github.com/bazacoin/go-bazacoin/crypto"
+)
+
+// errMissingBitset is returned if a bit vector needed by a filter was not
+// provided to the matcher.
+var errMissingBitset = errors.New("missing bloom bit vector")
+
+// bloomIndexes represents the bit indexes inside the bloom filter that belong
+// to some key.
+type bloomIndexes [3]uint
+
+// calcBloomIndexes returns the bloom filter bit indexes belonging to the given key.
+func calcBloomIndexes(b []byte) bloomIndexes {
+	b = crypto.Keccak256(b)
+
+	var idxs bloomIndexes
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(b[2*i])<<8)&2047 + uint(b[2*i+1])
+	}
+	return idxs
+}
+
+// Matcher evaluates filters against the rotated bloom bits of whole chain
+// sections, finding the blocks whose header blooms may contain the filtered
+// items without having to look at the headers themselves.
+type Matcher struct {
+	sectionSize uint64           // Number of blocks (and bits in a vector) in a section
+	filters     [][]bloomIndexes // Filter groups the matcher is matching for
+}
+
+// NewMatcher creates a new bloom bits matcher. The filters are a list of groups:
+// a block matches if for every group at least one of the keys within is present
+// in its bloom. Empty groups and groups containing a nil (wildcard) key match
+// every block.
+func NewMatcher(sectionSize uint64, filters [][][]byte) *Matcher {
+	m := &Matcher{sectionSize: sectionSize}
+	for _, filter := range filters {
+		if len(filter) == 0 {
+			continue
+		}
+		group := make([]bloomIndexes, 0, len(filter))
+		for _, clause := range filter {
+			if clause == nil {
+				group = nil
+				break
+			}
+			group = append(group, calcBloomIndexes(clause))
+		}
+		if group != nil {
+			m.filters = append(m.filters, group)
+		}
+	}
+	return m
+}
+
+// Bits returns the sorted list of bloom bits whose vectors are needed to match
+// a section.
+func (m *Matcher) Bits() []uint {
+	var seen [BloomBitLength]bool
+	for _, group := range m.filters {
+		for _, idxs := range group {
+			for _, idx := range idxs {
+				seen[idx] = true
+			}
+		}
+	}
+	var bits []uint
+	for bit, needed := range seen {
+		if needed {
+			bits = append(bits, uint(bit))
+		}
+	}
+	return bits
+}
+
+// Match returns the numbers of the blocks within the given section whose blooms
+// satisfy the filters. The bit vectors of all the bits returned by Bits must be
+// provided, uncompressed.
+func (m *Matcher) Match(section uint64, bits map[uint][]byte) ([]uint64, error) {
+	size := int(m.sectionSize / 8)
+
+	// Start with every block matching and narrow it down group by group
+	result := make([]byte, size)
+	for i := range result {
+		result[i] = 0xff
+	}
+	for _, group := range m.filters {
+		matches := make([]byte, size)
+		for _, idxs := range group {
+			clause := make([]byte, size)
+			for i := range clause {
+				clause[i] = 0xff
+			}
+			for _, idx := range idxs {
+				vector, ok := bits[idx]
+				if !ok || len(vector) != size {
+					return nil, errMissingBitset
+				}
+				for i := range clause {
+					clause[i] &= vector[i]
+				}
+			}
+			for i := range matches {
+				matches[i] |= clause[i]
+			}
+		}
+		for i := range result {
+			result[i] &= matches[i]
+		}
+	}
+	// Convert the resulting bit vector into block numbers
+	var numbers []uint64
+	for i, b := range result {
+		if b == 0 {
+			continue
+		}
+		for j := uint(0); j < 8; j++ {
+			if b&(1<<(7-j)) != 0 {
+				numbers = append(numbers, section*m.sectionSize+uint64(i)*8+uint64(j))
+			}
+		}
+	}
+	return numbers, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/types"
+)
+
+// Tests that the matcher finds the blocks whose blooms contain the filtered
+// addresses and topics.
+func TestMatcher(t *testing.T) {
+	const sectionSize = 16
+
+	addrs := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")}
+	topics := []common.Hash{common.HexToHash("0x04"), common.HexToHash("0x05")}
+
+	// Generate a section where block i logs addrs[i%3] with topics[i%2]
+	gen, err := NewGenerator(sectionSize)
+	if err != nil {
+		t.Fatalf("failed to create bloombit generator: %v", err)
+	}
+	for i := 0; i < sectionSize; i++ {
+		logs := []*types.Log{{Address: addrs[i%3], Topics: []common.Hash{topics[i%2]}}}
+		if err := gen.AddBloom(uint(i), types.BytesToBloom(types.LogsBloom(logs).Bytes())); err != nil {
+			t.Fatalf("block %d: failed to add bloom: %v", i, err)
+		}
+	}
+	tests := []struct {
+		filters [][][]byte
+		want    []uint64
+	}{
+		{nil, []uint64{32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47}},
+		{[][][]byte{{addrs[0].Bytes()}}, []uint64{32, 35, 38, 41, 44, 47}},
+		{[][][]byte{{addrs[0].Bytes(), addrs[1].Bytes()}}, []uint64{32, 33, 35, 36, 38, 39, 41, 42, 44, 45, 47}},
+		{[][][]byte{{addrs[0].Bytes()}, {topics[1].Bytes()}}, []uint64{35, 41, 47}},
+		{[][][]byte{{addrs[2].Bytes()}, {nil}}, []uint64{34, 37, 40, 43, 46}},
+		{[][][]byte{{common.HexToAddress("0x06").Bytes()}}, nil},
+	}
+	for i, tt := range tests {
+		matcher := NewMatcher(sectionSize, tt.filters)
+
+		bits := make(map[uint][]byte)
+		for _, bit := range matcher.Bits() {
+			if bits[bit], err = gen.Bitset(bit); err != nil {
+				t.Fatalf("test %d: failed to retrieve bit %d: %v", i, bit, err)
+			}
+		}
+		have, err := matcher.Match(2, bits)
+		if err != nil {
+			t.Fatalf("test %d: failed to match: %v", i, err)
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: matches mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	// Ensure missing bit vectors are reported
+	if _, err := NewMatcher(sectionSize, [][][]byte{{addrs[0].Bytes()}}).Match(0, nil); err != errMissingBitset {
+		t.Errorf("missing bitset error mismatch: have %v, want %v", err, errMissingBitset)
+	}
+}
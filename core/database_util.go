@@ -51,6 +51,8 @@ var (
 	receiptsPrefix = []byte("receipts-")
 
 	mipmapPre    = []byte("mipmap-log-bloom-")
+
+	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) -> bloom bits
 	MIPMapLevels = []uint64{1000000, 500000, 100000, 50000, 1000}
 
 	configPrefix = []byte("bazacoin-config-") // config prefix for the db
@@ -577,6 +579,33 @@ func GetMipmapBloom(db bzcdb.Database, number, level uint64) types.Bloom {
 	return types.BytesToBloom(bloomDat)
 }
 
+// bloomBitsKey returns the database key of the bloom bit vector belonging to the
+// given bit index and chain section.
+func bloomBitsKey(bit uint, section uint64) []byte {
+	key := append(append([]byte{}, bloomBitsPrefix...), make([]byte, 10)...)
+
+	binary.BigEndian.PutUint16(key[1:], uint16(bit))
+	binary.BigEndian.PutUint64(key[3:], section)
+
+	return key
+}
+
+// GetBloomBits retrieves the compressed bloom bit vector belonging to the given
+// bit index and chain section, or nil if it's not available.
+func GetBloomBits(db bzcdb.Database, bit uint, section uint64) []byte {
+	data, _ := db.Get(bloomBitsKey(bit, section))
+	return data
+}
+
+// WriteBloomBits stores the compressed bloom bit vector belonging to the given
+// bit index and chain section.
+func WriteBloomBits(db bzcdb.Database, bit uint, section uint64, bits []byte) error {
+	if err := db.Put(bloomBitsKey(bit, section), bits); err != nil {
+		return fmt.Errorf("failed to store bloom bits %d of section %d: %v", bit, section, err)
+	}
+	return nil
+}
+
 // PreimageTable returns a Database instance with the key prefix for preimage entries.
 func PreimageTable(db bzcdb.Database) bzcdb.Database {
 	return bzcdb.NewTable(db, preimagePrefix)
@@ -0,0 +1,155 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/params"
+	"github.com/bazacoin/go-bazacoin/rlp"
+)
+
+// TestCreation tests that different genesis and fork rule combinations result in
+// the correct fork ID.
+func TestCreation(t *testing.T) {
+	type testcase struct {
+		head uint64
+		want ID
+	}
+	tests := []struct {
+		config  *params.ChainConfig
+		genesis [32]byte
+		cases   []testcase
+	}{
+		// Mainnet test cases
+		{
+			params.MainnetChainConfig,
+			params.MainNetGenesisHash,
+			[]testcase{
+				{0, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},             // Unsynced
+				{1149999, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},       // Last Frontier block
+				{1150000, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}},       // First Homestead block
+				{1919999, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}},       // Last Homestead block
+				{1920000, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}},       // First DAO block
+				{2462999, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}},       // Last DAO block
+				{2463000, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}},       // First Tangerine block
+				{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}},       // Last Tangerine block
+				{2675000, ID{Hash: checksumToBytes(0x3edd5b10), Next: math.MaxInt64}}, // First Spurious block
+				{7000000, ID{Hash: checksumToBytes(0x3edd5b10), Next: math.MaxInt64}}, // Future Spurious block
+			},
+		},
+	}
+	for i, tt := range tests {
+		for j, ttt := range tt.cases {
+			if have := newID(tt.config, tt.genesis, ttt.head); have != ttt.want {
+				t.Errorf("test %d, case %d: fork ID mismatch: have %x, want %x", i, j, have, ttt.want)
+			}
+		}
+	}
+}
+
+// TestValidation tests that a local peer correctly validates and accepts a remote
+// fork ID.
+func TestValidation(t *testing.T) {
+	// Use a config with a fork schedule that can be passed locally.
+	config := *params.MainnetChainConfig
+	config.MetropolisBlock = nil
+
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Local is mainnet Spurious, remote announces the same. No future fork is announced.
+		{7000000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}, nil},
+
+		// Local is mainnet Spurious, remote announces the same. Remote also announces a next fork
+		// at block 0xffffffff, but that is uncertain.
+		{7000000, ID{Hash: checksumToBytes(0x3edd5b10), Next: math.MaxUint32}, nil},
+
+		// Local is mainnet currently in Tangerine only (so it's aware of Spurious), remote announces
+		// also Tangerine, but it's not yet aware of Spurious (e.g. non updated node before the fork).
+		// In this case we don't know if Spurious passed yet or not.
+		{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: 0}, nil},
+
+		// Local is mainnet currently in Tangerine only (so it's aware of Spurious), remote announces
+		// also Tangerine, and it's also aware of Spurious (e.g. updated node before the fork). We
+		// don't know if Spurious passed yet (will pass) or not.
+		{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}, nil},
+
+		// Local is mainnet currently in Tangerine only (so it's aware of Spurious), remote announces
+		// also Tangerine, and it's also aware of some random fork (e.g. misconfigured Spurious). As
+		// neither forks passed at neither nodes, they may mismatch, but we still connect for now.
+		{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: math.MaxUint64}, nil},
+
+		// Local is mainnet Spurious, remote announces Tangerine + knowledge about Spurious. Remote
+		// is simply out of sync, accept.
+		{7000000, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}, nil},
+
+		// Local is mainnet Spurious, remote announces Homestead + knowledge about DAO. Remote
+		// is definitely out of sync. It may or may not need the Spurious update, we don't know yet.
+		{7000000, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}, nil},
+
+		// Local is mainnet Tangerine, remote announces Spurious. Local is out of sync, accept.
+		{2463000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}, nil},
+
+		// Local is mainnet Homestead, remote announces Spurious. Local is out of sync, accept.
+		{1150000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}, nil},
+
+		// Local is mainnet Spurious, remote announces Tangerine but is not aware of further forks.
+		// Remote needs software update.
+		{7000000, ID{Hash: checksumToBytes(0x7a64da13), Next: 0}, ErrRemoteStale},
+
+		// Local is mainnet Spurious, remote announces Spurious + a fork block which
+		// local already passed. The chains are incompatible, reject.
+		{7000000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 6999999}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet Spurious, remote is random Spurious.
+		{7000000, ID{Hash: checksumToBytes(0x12345678), Next: 0}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := newFilter(&config, params.MainNetGenesisHash, func() uint64 { return tt.head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that IDs are properly RLP encoded.
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		id   ID
+		want []byte
+	}{
+		{ID{Hash: checksumToBytes(0), Next: 0}, common.Hex2Bytes("c6840000000080")},
+		{ID{Hash: checksumToBytes(0xdeadbeef), Next: 0xBADDCAFE}, common.Hex2Bytes("ca84deadbeef84baddcafe")},
+		{ID{Hash: checksumToBytes(math.MaxUint32), Next: math.MaxUint64}, common.Hex2Bytes("ce84ffffffff88ffffffffffffffff")},
+	}
+	for i, tt := range tests {
+		have, err := rlp.EncodeToBytes(tt.id)
+		if err != nil {
+			t.Errorf("test %d: failed to encode forkid: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(have, tt.want) {
+			t.Errorf("test %d: RLP mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
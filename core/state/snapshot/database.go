@@ -0,0 +1,116 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/bazacoin/go-bazacoin/bzcdb"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	snapshotRootKey      = []byte("SnapshotRoot")      // snapshotRootKey -> state root the flat snapshot represents
+	snapshotGeneratorKey = []byte("SnapshotGenerator") // snapshotGeneratorKey -> progress marker of an unfinished generation
+
+	accountPrefix = []byte("a") // accountPrefix + account hash -> account trie value
+	storagePrefix = []byte("o") // storagePrefix + account hash + storage hash -> storage trie value
+
+	accountKeyLength = len(accountPrefix) + common.HashLength
+	storageKeyLength = len(storagePrefix) + 2*common.HashLength
+
+	// errWipeUnsupported is returned if the snapshot data cannot be cleaned out
+	// of a database because it does not support iterating over its keys.
+	errWipeUnsupported = errors.New("database iteration unsupported")
+)
+
+// accountKey = accountPrefix + hash
+func accountKey(hash common.Hash) []byte {
+	return append(append([]byte{}, accountPrefix...), hash[:]...)
+}
+
+// storageKey = storagePrefix + account hash + storage hash
+func storageKey(account, slot common.Hash) []byte {
+	return append(append(append([]byte{}, storagePrefix...), account[:]...), slot[:]...)
+}
+
+// readSnapshotRoot retrieves the root of the persisted flat snapshot, or an
+// empty hash if there is none.
+func readSnapshotRoot(db bzcdb.Database) common.Hash {
+	data, _ := db.Get(snapshotRootKey)
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// readSnapshotGenerator retrieves the progress marker of a previously interrupted
+// snapshot generation, or nil if the snapshot was fully generated.
+func readSnapshotGenerator(db bzcdb.Database) []byte {
+	data, err := db.Get(snapshotGeneratorKey)
+	if err != nil {
+		return nil
+	}
+	return append([]byte{}, data...)
+}
+
+// deletePrefix removes all the database entries with the given prefix and key
+// length. The length check avoids clashing with differently typed entries (e.g.
+// trie nodes) that happen to start with the same byte.
+func deletePrefix(db bzcdb.Database, prefix []byte, length int) error {
+	switch db := db.(type) {
+	case *bzcdb.LDBDatabase:
+		it := db.LDB().NewIterator(util.BytesPrefix(prefix), nil)
+		defer it.Release()
+
+		for it.Next() {
+			if key := it.Key(); len(key) == length {
+				if err := db.Delete(common.CopyBytes(key)); err != nil {
+					return err
+				}
+			}
+		}
+		return it.Error()
+
+	case interface {
+		Keys() [][]byte
+		Delete(key []byte) error
+	}:
+		for _, key := range db.Keys() {
+			if len(key) == length && bytes.HasPrefix(key, prefix) {
+				if err := db.Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	default:
+		return errWipeUnsupported
+	}
+}
+
+// wipeSnapshot removes all the flat account and storage entries from the
+// database.
+func wipeSnapshot(db bzcdb.Database) error {
+	if err := deletePrefix(db, accountPrefix, accountKeyLength); err != nil {
+		return err
+	}
+	return deletePrefix(db, storagePrefix, storageKeyLength)
+}
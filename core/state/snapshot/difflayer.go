@@ -0,0 +1,145 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"sync"
+
+	"github.com/bazacoin/go-bazacoin/common"
+)
+
+// diffLayer represents a collection of modifications made to a state snapshot
+// after running a block on top. It contains one map for the account trie and one
+// map for each modified storage trie.
+//
+// The goal of a diff layer is to act as a journal, tracking recent modifications
+// made to the state, that have not yet graduated into a semi-immutable state.
+type diffLayer struct {
+	parent snapshot    // Parent snapshot modified by this one, never nil
+	root   common.Hash // Root hash to which this snapshot diff belongs to
+	stale  bool        // Signals that the layer became stale (state progressed)
+
+	destructs map[common.Hash]struct{}               // Keyed markers for deleted (and potentially recreated) accounts
+	accounts  map[common.Hash][]byte                 // Keyed accounts for direct retrieval
+	storage   map[common.Hash]map[common.Hash][]byte // Keyed storage slots for direct retrieval, one map per account (nil means deleted)
+
+	lock sync.RWMutex
+}
+
+// newDiffLayer creates a new diff on top of an existing snapshot, whether that's
+// a low level persistent database or a hierarchical diff already.
+func newDiffLayer(parent snapshot, root common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer {
+	return &diffLayer{
+		parent:    parent,
+		root:      root,
+		destructs: destructs,
+		accounts:  accounts,
+		storage:   storage,
+	}
+}
+
+// Root returns the root hash for which this snapshot was made.
+func (dl *diffLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Parent returns the subsequent layer of a diff layer.
+func (dl *diffLayer) Parent() snapshot {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.parent
+}
+
+// Stale return whether this layer has become stale (was flattened across) or if
+// it's still live.
+func (dl *diffLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// setParent relinks the diff layer onto a new parent after the old one was
+// flattened into the disk layer.
+func (dl *diffLayer) setParent(parent snapshot) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.parent = parent
+}
+
+// markStale flags the diff layer as no longer representing live state.
+func (dl *diffLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// Account directly retrieves the RLP encoded account associated with a particular
+// hash in the snapshot slim data format.
+func (dl *diffLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.stale {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	// If the account is known locally, return it
+	if data, ok := dl.accounts[hash]; ok {
+		dl.lock.RUnlock()
+		return data, nil
+	}
+	// If the account is known locally, but deleted, return it
+	if _, ok := dl.destructs[hash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	// Account unknown to this diff, resolve from parent
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.Account(hash)
+}
+
+// Storage directly retrieves the RLP encoded storage data associated with a
+// particular hash within a particular account. If the slot is unknown to this
+// diff, its parent is consulted.
+func (dl *diffLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.stale {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	// If the account is known locally, try to resolve the slot locally
+	if storage, ok := dl.storage[accountHash]; ok {
+		if data, ok := storage[storageHash]; ok {
+			dl.lock.RUnlock()
+			return data, nil
+		}
+	}
+	// If the account is known locally, but deleted, return an empty slot
+	if _, ok := dl.destructs[accountHash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	// Storage slot unknown to this diff, resolve from parent
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.Storage(accountHash, storageHash)
+}
@@ -0,0 +1,226 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/bazacoin/go-bazacoin/bzcdb"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/log"
+)
+
+// diskLayer is a low level persistent snapshot built on top of a key-value store.
+type diskLayer struct {
+	db    bzcdb.Database // Key-value store containing the base snapshot
+	root  common.Hash    // Root hash of the base snapshot
+	stale bool           // Signals that the layer became stale (state progressed)
+
+	genMarker []byte             // Marker for the state that's indexed during initial layer generation
+	genWipe   bool               // Whether leftover snapshot data needs wiping before generation
+	genAbort  chan chan struct{} // Notification channel to abort generating the snapshot in this layer
+
+	lock sync.RWMutex
+}
+
+// loadDiskLayer opens the persisted snapshot if it matches the requested root,
+// resuming any interrupted generation. Otherwise the existing data is discarded
+// and a new snapshot is generated in the background.
+func loadDiskLayer(db bzcdb.Database, root common.Hash) *diskLayer {
+	if base := readSnapshotRoot(db); base != root {
+		log.Info("State snapshot missing or stale, regenerating", "root", root, "snapshot", base)
+		return generateDiskLayer(db, root)
+	}
+	dl := &diskLayer{
+		db:        db,
+		root:      root,
+		genMarker: readSnapshotGenerator(db),
+	}
+	if dl.genMarker != nil {
+		log.Info("Resuming state snapshot generation", "root", root, "marker", common.ToHex(dl.genMarker))
+		dl.startGeneration()
+	}
+	return dl
+}
+
+// generateDiskLayer creates an empty disk layer and starts wiping any leftover
+// snapshot data, after which the new snapshot is generated from the state trie.
+func generateDiskLayer(db bzcdb.Database, root common.Hash) *diskLayer {
+	// Drop the persisted metadata so an interrupted generation starts anew
+	db.Delete(snapshotRootKey)
+	db.Delete(snapshotGeneratorKey)
+
+	dl := &diskLayer{
+		db:        db,
+		root:      root,
+		genMarker: []byte{},
+		genWipe:   true,
+	}
+	dl.startGeneration()
+	return dl
+}
+
+// Root returns root hash for which this snapshot was made.
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Parent always returns nil as there's no layer below the disk.
+func (dl *diskLayer) Parent() snapshot {
+	return nil
+}
+
+// Stale return whether this layer has become stale (was flattened across) or if
+// it's still live.
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// markStale flags the disk layer as no longer representing live state.
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// covered returns whether the given flat key (without the database prefix) was
+// already indexed by the snapshot generator. The caller must hold the lock.
+func (dl *diskLayer) covered(key []byte) bool {
+	return dl.genMarker == nil || bytes.Compare(key, dl.genMarker) <= 0
+}
+
+// Account directly retrieves the RLP encoded account associated with a particular
+// hash in the snapshot slim data format.
+func (dl *diskLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	// If the layer was flattened into, consider it invalid (any live reference to
+	// the original should be marked as unusable).
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	// If the layer is being generated, ensure the requested hash has already been
+	// covered by the generator.
+	if !dl.covered(hash[:]) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.db.Get(accountKey(hash))
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// Storage directly retrieves the RLP encoded storage data associated with a
+// particular hash within a particular account.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	key := storageKey(accountHash, storageHash)
+	if !dl.covered(key[len(storagePrefix):]) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.db.Get(key)
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// merge flattens a diff layer directly on top of this disk layer into the
+// database, returning the new disk layer. Data not yet covered by a running
+// generation is skipped, as the restarted generator will index it from the new
+// state root. The caller must hold the snapshot tree lock.
+func (dl *diskLayer) merge(diff *diffLayer) (*diskLayer, error) {
+	// Pause any running generator so it does not race with the flush
+	dl.stopGeneration()
+
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	// Invalidate the persisted root so that an interrupted flush forces a rebuild
+	if err := dl.db.Delete(snapshotRootKey); err != nil {
+		return nil, err
+	}
+	// Delete all destructed accounts along with their storage, then write the
+	// updated data items on top
+	for hash := range diff.destructs {
+		if !dl.covered(hash[:]) {
+			continue
+		}
+		if err := dl.db.Delete(accountKey(hash)); err != nil {
+			return nil, err
+		}
+		prefix := append(append([]byte{}, storagePrefix...), hash[:]...)
+		if err := deletePrefix(dl.db, prefix, storageKeyLength); err != nil {
+			return nil, err
+		}
+	}
+	batch := dl.db.NewBatch()
+	for hash, data := range diff.accounts {
+		if dl.covered(hash[:]) {
+			batch.Put(accountKey(hash), data)
+		}
+	}
+	for accountHash, storage := range diff.storage {
+		for storageHash, data := range storage {
+			key := storageKey(accountHash, storageHash)
+			if !dl.covered(key[len(storagePrefix):]) {
+				continue
+			}
+			if len(data) == 0 {
+				if err := dl.db.Delete(key); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			batch.Put(key, data)
+		}
+	}
+	// Only reference the new root if the data is not still being wiped, otherwise
+	// the generator will persist it when done
+	if !dl.genWipe {
+		batch.Put(snapshotRootKey, diff.root[:])
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	merged := &diskLayer{
+		db:        dl.db,
+		root:      diff.root,
+		genMarker: dl.genMarker,
+		genWipe:   dl.genWipe,
+	}
+	dl.stale = true
+	diff.markStale()
+
+	// If the snapshot was still being generated, continue from the new root
+	if merged.genMarker != nil {
+		merged.startGeneration()
+	}
+	return merged, nil
+}
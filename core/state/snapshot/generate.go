@@ -0,0 +1,217 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/rlp"
+	"github.com/bazacoin/go-bazacoin/trie"
+)
+
+// generatorBatchSize is the number of flat entries the generator accumulates
+// before persisting them along with its progress marker.
+const generatorBatchSize = 10000
+
+// emptyRoot is the known root hash of an empty trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// account is the consensus representation of accounts, needed to locate the
+// storage tries while generating the snapshot.
+type account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// startGeneration spins up a background generator filling the disk layer from
+// the state trie, starting at the current marker.
+func (dl *diskLayer) startGeneration() {
+	dl.genAbort = make(chan chan struct{})
+	go dl.generate(dl.genAbort)
+}
+
+// stopGeneration aborts a running generator, waiting until its progress has
+// been persisted. The caller must hold the snapshot tree lock.
+func (dl *diskLayer) stopGeneration() {
+	if dl.genAbort == nil {
+		return
+	}
+	done := make(chan struct{})
+	dl.genAbort <- done
+	<-done
+
+	dl.genAbort = nil
+}
+
+// generate is a background thread that iterates over the state and storage tries
+// and constructs the state snapshot. Since the disk layer moves along with the
+// chain, the generator is often aborted and restarted on the new root, resuming
+// from the last persisted marker.
+func (dl *diskLayer) generate(abort chan chan struct{}) {
+	// Unless aborted midway, keep the goroutine alive until explicitly stopped
+	var aborted bool
+	defer func() {
+		if !aborted {
+			done := <-abort
+			close(done)
+		}
+	}()
+	// Clean out any leftover snapshot data before indexing anything new
+	if dl.genWipe {
+		if err := wipeSnapshot(dl.db); err != nil {
+			log.Error("Failed to wipe state snapshot", "err", err)
+			return
+		}
+		batch := dl.db.NewBatch()
+		batch.Put(snapshotRootKey, dl.root[:])
+		batch.Put(snapshotGeneratorKey, []byte{})
+		if err := batch.Write(); err != nil {
+			log.Error("Failed to initialise state snapshot", "err", err)
+			return
+		}
+		dl.lock.Lock()
+		dl.genWipe = false
+		dl.lock.Unlock()
+	}
+	accTrie, err := trie.New(dl.root, dl.db)
+	if err != nil {
+		log.Error("Failed to open state trie for snapshot", "root", dl.root, "err", err)
+		return
+	}
+	dl.lock.RLock()
+	marker := dl.genMarker
+	dl.lock.RUnlock()
+
+	var (
+		batch   = dl.db.NewBatch()
+		pending int
+
+		accounts, slots int
+		start           = time.Now()
+		logged          = time.Now()
+	)
+	// flush persists the accumulated data with the given progress marker and
+	// reports whether the generator was asked to abort meanwhile.
+	flush := func(marker []byte) (bool, error) {
+		batch.Put(snapshotGeneratorKey, marker)
+		if err := batch.Write(); err != nil {
+			return false, err
+		}
+		batch, pending = dl.db.NewBatch(), 0
+
+		dl.lock.Lock()
+		dl.genMarker = marker
+		dl.lock.Unlock()
+
+		select {
+		case done := <-abort:
+			log.Debug("Aborted state snapshot generation", "root", dl.root, "marker", common.ToHex(marker))
+			close(done)
+
+			aborted = true
+			return true, nil
+		default:
+			return false, nil
+		}
+	}
+	var accMarker []byte
+	if len(marker) > 0 {
+		accMarker = marker[:common.HashLength]
+	}
+	it := trie.NewIterator(accTrie.NodeIterator(accMarker))
+	for it.Next() {
+		accountHash := common.BytesToHash(it.Key)
+		batch.Put(accountKey(accountHash), it.Value)
+		accounts++
+		pending++
+
+		var acc account
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			log.Error("Invalid account encountered during snapshot creation", "hash", accountHash, "err", err)
+			return
+		}
+		// If the account has storage, index it too, resuming where we left off
+		if acc.Root != emptyRoot {
+			var storeMarker []byte
+			if len(marker) > common.HashLength && bytes.Equal(accountHash[:], marker[:common.HashLength]) {
+				storeMarker = marker[common.HashLength:]
+			}
+			storeTrie, err := trie.New(acc.Root, dl.db)
+			if err != nil {
+				log.Error("Failed to open storage trie for snapshot", "root", acc.Root, "err", err)
+				return
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(storeMarker))
+			for storeIt.Next() {
+				batch.Put(storageKey(accountHash, common.BytesToHash(storeIt.Key)), storeIt.Value)
+				slots++
+				pending++
+
+				if pending >= generatorBatchSize {
+					aborted, err := flush(append(accountHash[:], storeIt.Key...))
+					if err != nil {
+						log.Error("Failed to persist state snapshot", "err", err)
+						return
+					}
+					if aborted {
+						return
+					}
+				}
+			}
+			if storeIt.Err != nil {
+				log.Error("Failed to iterate storage trie for snapshot", "root", acc.Root, "err", storeIt.Err)
+				return
+			}
+		}
+		if pending >= generatorBatchSize {
+			aborted, err := flush(accountHash[:])
+			if err != nil {
+				log.Error("Failed to persist state snapshot", "err", err)
+				return
+			}
+			if aborted {
+				return
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Generating state snapshot", "at", accountHash, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		log.Error("Failed to iterate state trie for snapshot", "root", dl.root, "err", it.Err)
+		return
+	}
+	// Snapshot fully generated, persist the remainder and drop the marker
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to persist state snapshot", "err", err)
+		return
+	}
+	dl.db.Delete(snapshotGeneratorKey)
+
+	dl.lock.Lock()
+	dl.genMarker = nil
+	dl.lock.Unlock()
+
+	log.Info("Generated state snapshot", "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+}
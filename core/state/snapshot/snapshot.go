@@ -0,0 +1,234 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snapshot implements a flat, layered view of the account and storage
+// tries for constant time state reads.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bazacoin/go-bazacoin/bzcdb"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/log"
+)
+
+var (
+	// ErrSnapshotStale is returned from data accessors if the underlying snapshot
+	// layer had been invalidated due to the chain progressing forward far enough
+	// to not maintain the layer's original state.
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	// ErrNotCoveredYet is returned from data accessors if the underlying snapshot
+	// is being generated currently and the requested data item is not yet in the
+	// range of accounts covered.
+	ErrNotCoveredYet = errors.New("not covered yet")
+)
+
+// Snapshot represents the functionality supported by a snapshot storage layer.
+type Snapshot interface {
+	// Root returns the root hash for which this snapshot was made.
+	Root() common.Hash
+
+	// Account directly retrieves the RLP encoded account associated with a
+	// particular hash in the snapshot slim data format. A nil result with no
+	// error means the account does not exist.
+	Account(hash common.Hash) ([]byte, error)
+
+	// Storage directly retrieves the RLP encoded storage data associated with
+	// a particular hash within a particular account. A nil result with no error
+	// means the slot is empty.
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is the internal version of the snapshot data layer that supports
+// some additional methods compared to the public API.
+type snapshot interface {
+	Snapshot
+
+	// Parent returns the subsequent layer of a snapshot, or nil if the base was
+	// reached.
+	Parent() snapshot
+
+	// Stale return whether this layer has become stale (was flattened across) or
+	// if it's still live.
+	Stale() bool
+}
+
+// Tree is an Bazacoin state snapshot tree. It consists of one persistent base
+// layer backed by a key-value store, on top of which arbitrarily many in-memory
+// diff layers are topped. The memory diffs can form a tree with branching, but
+// the disk layer is singleton and common to all. If a reorg goes deeper than the
+// disk layer, everything needs to be deleted.
+//
+// The goal of a state snapshot is to allow direct access to account and storage
+// data to avoid expensive multi-level trie lookups.
+type Tree struct {
+	db     bzcdb.Database           // Persistent database to store the snapshot
+	layers map[common.Hash]snapshot // Collection of all known layers
+	lock   sync.RWMutex
+}
+
+// New attempts to load an already existing snapshot from a persistent key-value
+// store, ensuring that the head of the snapshot matches the expected one.
+//
+// If the snapshot is missing or inconsistent, the entirety is deleted and will
+// be reconstructed from scratch based on the tries in the key-value store, on a
+// background thread.
+func New(db bzcdb.Database, root common.Hash) *Tree {
+	return &Tree{
+		db: db,
+		layers: map[common.Hash]snapshot{
+			root: loadDiskLayer(db, root),
+		},
+	}
+}
+
+// Snapshot retrieves a snapshot belonging to the given block root, or nil if no
+// snapshot is maintained for that block.
+func (t *Tree) Snapshot(blockRoot common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if snap, ok := t.layers[blockRoot]; ok {
+		return snap
+	}
+	return nil
+}
+
+// Update adds a new snapshot into the tree, if that can be linked to an existing
+// old parent. It is disallowed to insert a disk layer (the origin of all).
+func (t *Tree) Update(blockRoot common.Hash, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	// Reject noop updates to avoid self-loops in the snapshot tree
+	if blockRoot == parentRoot {
+		return fmt.Errorf("snapshot cycle [%#x]", blockRoot)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// The same state may be reached from multiple blocks, keep the first
+	if _, ok := t.layers[blockRoot]; ok {
+		return nil
+	}
+	parent, ok := t.layers[parentRoot]
+	if !ok {
+		return fmt.Errorf("parent [%#x] snapshot missing", parentRoot)
+	}
+	t.layers[blockRoot] = newDiffLayer(parent, blockRoot, destructs, accounts, storage)
+	return nil
+}
+
+// Cap traverses downwards the snapshot tree from a head block hash until the
+// number of allowed layers are crossed. All layers beyond the permitted number
+// are flattened downwards into the disk layer, and any layers not descending
+// from the new disk layer are discarded.
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	snap, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	// Gather the diff layers from the head down to the disk layer
+	var chain []*diffLayer
+	for {
+		diff, ok := snap.(*diffLayer)
+		if !ok {
+			break
+		}
+		chain = append(chain, diff)
+		snap = diff.Parent()
+	}
+	if len(chain) <= layers {
+		return nil
+	}
+	// Flatten all the surplus layers into the disk, oldest first
+	base := snap.(*diskLayer)
+	for i := len(chain) - 1; i >= layers; i-- {
+		merged, err := base.merge(chain[i])
+		if err != nil {
+			return err
+		}
+		delete(t.layers, base.root)
+		delete(t.layers, chain[i].root)
+
+		base = merged
+	}
+	t.layers[base.root] = base
+	if layers > 0 {
+		chain[layers-1].setParent(base)
+	}
+	// Drop all the layers that were not descendants of the flattened chain
+	for hash, snap := range t.layers {
+		if bottom(snap) != base {
+			if diff, ok := snap.(*diffLayer); ok {
+				diff.markStale()
+			}
+			delete(t.layers, hash)
+		}
+	}
+	log.Debug("Flattened state snapshot", "root", base.root, "layers", len(t.layers))
+	return nil
+}
+
+// Rebuild wipes all available snapshot data from the persistent database and
+// discards all caches and diff layers. Afterwards, it starts a new snapshot
+// generator with the given root hash.
+func (t *Tree) Rebuild(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, snap := range t.layers {
+		switch layer := snap.(type) {
+		case *diskLayer:
+			layer.stopGeneration()
+			layer.markStale()
+		case *diffLayer:
+			layer.markStale()
+		}
+	}
+	log.Info("Rebuilding state snapshot", "root", root)
+	t.layers = map[common.Hash]snapshot{
+		root: generateDiskLayer(t.db, root),
+	}
+}
+
+// Stop aborts any background snapshot generation, persisting its progress so
+// that it can be resumed on the next startup.
+func (t *Tree) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, snap := range t.layers {
+		if disk, ok := snap.(*diskLayer); ok {
+			disk.stopGeneration()
+		}
+	}
+}
+
+// bottom returns the disk layer a snapshot is ultimately based on.
+func bottom(snap snapshot) snapshot {
+	for {
+		parent := snap.Parent()
+		if parent == nil {
+			return snap
+		}
+		snap = parent
+	}
+}
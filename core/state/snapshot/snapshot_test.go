@@ -0,0 +1,221 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/bzcdb"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/rlp"
+	"github.com/bazacoin/go-bazacoin/trie"
+)
+
+// makeTestState creates a state trie with a handful of accounts, one of them
+// having a few storage slots.
+func makeTestState(t *testing.T, db bzcdb.Database) (common.Hash, map[common.Hash][]byte, map[common.Hash][]byte) {
+	storage := make(map[common.Hash][]byte)
+	storeTrie, _ := trie.New(common.Hash{}, db)
+	for i := byte(1); i <= 5; i++ {
+		key := common.Hash{i}
+		val, _ := rlp.EncodeToBytes([]byte{i})
+		storeTrie.Update(key[:], val)
+		storage[key] = val
+	}
+	storeRoot, err := storeTrie.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit storage trie: %v", err)
+	}
+	accounts := make(map[common.Hash][]byte)
+	accTrie, _ := trie.New(common.Hash{}, db)
+	for i := byte(1); i <= 3; i++ {
+		acc := account{Nonce: uint64(i), Balance: big.NewInt(int64(i)), Root: emptyRoot, CodeHash: []byte{i}}
+		if i == 2 {
+			acc.Root = storeRoot
+		}
+		key := common.Hash{i}
+		val, _ := rlp.EncodeToBytes(acc)
+		accTrie.Update(key[:], val)
+		accounts[key] = val
+	}
+	root, err := accTrie.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit account trie: %v", err)
+	}
+	return root, accounts, storage
+}
+
+// waitGeneration blocks until the disk layer of the tree finishes generating.
+func waitGeneration(t *testing.T, snaps *Tree, root common.Hash) {
+	disk := snaps.Snapshot(root).(*diskLayer)
+	for i := 0; i < 100; i++ {
+		disk.lock.RLock()
+		done := disk.genMarker == nil
+		disk.lock.RUnlock()
+
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("snapshot generation timed out")
+}
+
+// Tests that a snapshot is generated from the state trie and that it matches
+// the original contents.
+func TestGeneration(t *testing.T) {
+	db, _ := bzcdb.NewMemDatabase()
+	root, accounts, storage := makeTestState(t, db)
+
+	snaps := New(db, root)
+	waitGeneration(t, snaps, root)
+
+	snap := snaps.Snapshot(root)
+	for hash, want := range accounts {
+		if have, err := snap.Account(hash); err != nil || !bytes.Equal(have, want) {
+			t.Errorf("account %x mismatch: have %x/%v, want %x", hash, have, err, want)
+		}
+	}
+	for hash, want := range storage {
+		if have, err := snap.Storage(common.Hash{2}, hash); err != nil || !bytes.Equal(have, want) {
+			t.Errorf("slot %x mismatch: have %x/%v, want %x", hash, have, err, want)
+		}
+	}
+	if have, err := snap.Account(common.Hash{4}); err != nil || have != nil {
+		t.Errorf("missing account mismatch: have %x/%v, want nil", have, err)
+	}
+	// Reopen the snapshot and ensure it's not regenerated
+	if got := readSnapshotRoot(db); got != root {
+		t.Fatalf("persisted root mismatch: have %x, want %x", got, root)
+	}
+	if snap := New(db, root).Snapshot(root).(*diskLayer); snap.genMarker != nil {
+		t.Errorf("complete snapshot regenerated: marker %x", snap.genMarker)
+	}
+}
+
+// Tests that data not yet indexed by the generator is reported as such.
+func TestGenerationCoverage(t *testing.T) {
+	db, _ := bzcdb.NewMemDatabase()
+	disk := &diskLayer{db: db, genMarker: append(common.Hash{2}.Bytes(), common.Hash{3}.Bytes()...)}
+
+	if _, err := disk.Account(common.Hash{2}); err != nil {
+		t.Errorf("covered account rejected: %v", err)
+	}
+	if _, err := disk.Account(common.Hash{3}); err != ErrNotCoveredYet {
+		t.Errorf("uncovered account error mismatch: have %v, want %v", err, ErrNotCoveredYet)
+	}
+	if _, err := disk.Storage(common.Hash{2}, common.Hash{3}); err != nil {
+		t.Errorf("covered slot rejected: %v", err)
+	}
+	if _, err := disk.Storage(common.Hash{2}, common.Hash{4}); err != ErrNotCoveredYet {
+		t.Errorf("uncovered slot error mismatch: have %v, want %v", err, ErrNotCoveredYet)
+	}
+}
+
+// Tests that diff layers shadow their parents correctly and that capping the
+// tree flattens them into the disk layer.
+func TestDiffLayers(t *testing.T) {
+	db, _ := bzcdb.NewMemDatabase()
+	root, accounts, _ := makeTestState(t, db)
+
+	snaps := New(db, root)
+	waitGeneration(t, snaps, root)
+
+	// Layer 1 modifies an account and a slot, layer 2 destructs the account
+	var (
+		root1 = common.Hash{0xa1}
+		root2 = common.Hash{0xa2}
+		side  = common.Hash{0xb1}
+	)
+	if err := snaps.Update(root1, root, nil, map[common.Hash][]byte{{1}: {0x01}}, map[common.Hash]map[common.Hash][]byte{{2}: {{1}: nil, {6}: {0x06}}}); err != nil {
+		t.Fatalf("failed to create diff layer: %v", err)
+	}
+	if err := snaps.Update(root2, root1, map[common.Hash]struct{}{{2}: {}}, nil, nil); err != nil {
+		t.Fatalf("failed to create diff layer: %v", err)
+	}
+	if err := snaps.Update(side, root, nil, map[common.Hash][]byte{{3}: {0x03}}, nil); err != nil {
+		t.Fatalf("failed to create diff layer: %v", err)
+	}
+	if err := snaps.Update(common.Hash{0xff}, common.Hash{0xfe}, nil, nil, nil); err == nil {
+		t.Fatalf("dangling diff layer accepted")
+	}
+	check := func(snap Snapshot, account common.Hash, slot *common.Hash, want []byte) {
+		t.Helper()
+
+		var (
+			have []byte
+			err  error
+		)
+		if slot == nil {
+			have, err = snap.Account(account)
+		} else {
+			have, err = snap.Storage(account, *slot)
+		}
+		if err != nil || !bytes.Equal(have, want) {
+			t.Errorf("%x: item %x/%v mismatch: have %x/%v, want %x", snap.Root(), account, slot, have, err, want)
+		}
+	}
+	check(snaps.Snapshot(root1), common.Hash{1}, nil, []byte{0x01})
+	check(snaps.Snapshot(root1), common.Hash{2}, &common.Hash{1}, nil)
+	check(snaps.Snapshot(root1), common.Hash{2}, &common.Hash{2}, []byte{0x02})
+	check(snaps.Snapshot(root1), common.Hash{2}, &common.Hash{6}, []byte{0x06})
+	check(snaps.Snapshot(root2), common.Hash{1}, nil, []byte{0x01})
+	check(snaps.Snapshot(root2), common.Hash{2}, nil, nil)
+	check(snaps.Snapshot(root2), common.Hash{2}, &common.Hash{2}, nil)
+	check(snaps.Snapshot(side), common.Hash{1}, nil, accounts[common.Hash{1}])
+	check(snaps.Snapshot(side), common.Hash{3}, nil, []byte{0x03})
+
+	// Flatten the first layer into the disk and ensure the side chain is dropped
+	disk, layer1 := snaps.Snapshot(root), snaps.Snapshot(root1)
+	if err := snaps.Cap(root2, 1); err != nil {
+		t.Fatalf("failed to cap snapshot tree: %v", err)
+	}
+	if !disk.(snapshot).Stale() || !layer1.(snapshot).Stale() {
+		t.Errorf("flattened layers not marked stale")
+	}
+	if _, err := layer1.Account(common.Hash{1}); err != ErrSnapshotStale {
+		t.Errorf("stale layer error mismatch: have %v, want %v", err, ErrSnapshotStale)
+	}
+	if snaps.Snapshot(side) != nil {
+		t.Errorf("side chain layer not dropped")
+	}
+	if _, ok := snaps.Snapshot(root1).(*diskLayer); !ok {
+		t.Fatalf("flattened layer not on disk")
+	}
+	check(snaps.Snapshot(root1), common.Hash{2}, &common.Hash{1}, nil)
+	check(snaps.Snapshot(root1), common.Hash{2}, &common.Hash{6}, []byte{0x06})
+	check(snaps.Snapshot(root2), common.Hash{1}, nil, []byte{0x01})
+	check(snaps.Snapshot(root2), common.Hash{2}, &common.Hash{2}, nil)
+
+	// Flatten everything and ensure the destruct wiped the storage from disk
+	if err := snaps.Cap(root2, 0); err != nil {
+		t.Fatalf("failed to cap snapshot tree: %v", err)
+	}
+	check(snaps.Snapshot(root2), common.Hash{2}, nil, nil)
+	check(snaps.Snapshot(root2), common.Hash{2}, &common.Hash{2}, nil)
+	for _, key := range db.Keys() {
+		if len(key) == storageKeyLength && bytes.HasPrefix(key, storagePrefix) {
+			t.Errorf("leftover storage slot %x", key)
+		}
+	}
+	if got := readSnapshotRoot(db); got != root2 {
+		t.Errorf("persisted root mismatch: have %x, want %x", got, root2)
+	}
+}
@@ -62,9 +62,10 @@ func (self Storage) Copy() Storage {
 // Account values can be accessed and modified through the object.
 // Finally, call CommitTrie to write the modified storage trie into a database.
 type stateObject struct {
-	address common.Address // Bazacoin address of this account
-	data    Account
-	db      *StateDB
+	address  common.Address // Bazacoin address of this account
+	addrHash common.Hash    // hash of bazacoin address of the account
+	data     Account
+	db       *StateDB
 
 	// DB error.
 	// State objects are used by the consensus core and VM which are
@@ -83,11 +84,13 @@ type stateObject struct {
 	// Cache flags.
 	// When an object is marked suicided it will be delete from the trie
 	// during the "update" phase of the state transition.
-	dirtyCode bool // true if the code was updated
-	suicided  bool
-	touched   bool
-	deleted   bool
-	onDirty   func(addr common.Address) // Callback method to mark a state object newly dirty
+	dirtyCode   bool // true if the code was updated
+	suicided    bool
+	touched     bool
+	deleted     bool
+	recreated   bool                      // true if the object replaced an existing account, orphaning its storage
+	flatStorage bool                      // true if storage reads may be served from the flat state snapshot
+	onDirty     func(addr common.Address) // Callback method to mark a state object newly dirty
 }
 
 // empty returns whether the account is considered empty.
@@ -112,7 +115,7 @@ func newObject(db *StateDB, address common.Address, data Account, onDirty func(a
 	if data.CodeHash == nil {
 		data.CodeHash = emptyCodeHash
 	}
-	return &stateObject{db: db, address: address, data: data, cachedStorage: make(Storage), dirtyStorage: make(Storage), onDirty: onDirty}
+	return &stateObject{db: db, address: address, addrHash: crypto.Keccak256Hash(address[:]), data: data, cachedStorage: make(Storage), dirtyStorage: make(Storage), onDirty: onDirty}
 }
 
 // EncodeRLP implements rlp.Encoder.
@@ -166,8 +169,20 @@ func (self *stateObject) GetState(db trie.Database, key common.Hash) common.Hash
 	if exists {
 		return value
 	}
-	// Load from DB in case it is missing.
-	if enc := self.getTrie(db).Get(key[:]); len(enc) > 0 {
+	// Load from the flat snapshot if possible, or the DB in case it is missing.
+	var (
+		enc  []byte
+		flat bool
+	)
+	if self.flatStorage && self.db.snap != nil {
+		if blob, err := self.db.snap.Storage(self.addrHash, crypto.Keccak256Hash(key[:])); err == nil {
+			enc, flat = blob, true
+		}
+	}
+	if !flat {
+		enc = self.getTrie(db).Get(key[:])
+	}
+	if len(enc) > 0 {
 		_, content, _, err := rlp.Split(enc)
 		if err != nil {
 			self.setError(err)
@@ -203,15 +218,39 @@ func (self *stateObject) setState(key, value common.Hash) {
 // updateTrie writes cached storage modifications into the object's storage trie.
 func (self *stateObject) updateTrie(db trie.Database) *trie.SecureTrie {
 	tr := self.getTrie(db)
+	if len(self.dirtyStorage) > 0 {
+		self.flatStorage = false
+	}
+	// Track the storage modifications for the flat state snapshot
+	var storage map[common.Hash][]byte
+	if self.db.snap != nil {
+		if self.recreated {
+			self.db.snapDestructs[self.addrHash] = struct{}{}
+			delete(self.db.snapStorage, self.addrHash)
+			self.recreated = false
+		}
+		if len(self.dirtyStorage) > 0 {
+			if storage = self.db.snapStorage[self.addrHash]; storage == nil {
+				storage = make(map[common.Hash][]byte)
+				self.db.snapStorage[self.addrHash] = storage
+			}
+		}
+	}
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
 		if (value == common.Hash{}) {
 			tr.Delete(key[:])
+			if storage != nil {
+				storage[crypto.Keccak256Hash(key[:])] = nil
+			}
 			continue
 		}
 		// Encoding []byte cannot fail, ok to ignore the error.
 		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
 		tr.Update(key[:], v)
+		if storage != nil {
+			storage[crypto.Keccak256Hash(key[:])] = v
+		}
 	}
 	return tr
 }
@@ -292,6 +331,8 @@ func (self *stateObject) deepCopy(db *StateDB, onDirty func(addr common.Address)
 	stateObject.suicided = self.suicided
 	stateObject.dirtyCode = self.dirtyCode
 	stateObject.deleted = self.deleted
+	stateObject.recreated = self.recreated
+	stateObject.flatStorage = self.flatStorage
 	return stateObject
 }
 
@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/state/snapshot"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/bzcdb"
@@ -61,6 +62,13 @@ type StateDB struct {
 	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
 
+	// Flat state snapshot serving reads and the modifications to layer on top.
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects           map[common.Address]*stateObject
 	stateObjectsDirty      map[common.Address]struct{}
@@ -87,22 +95,31 @@ type StateDB struct {
 
 // Create a new state from a given trie
 func New(root common.Hash, db bzcdb.Database) (*StateDB, error) {
+	return NewWithSnapshot(root, db, nil)
+}
+
+// NewWithSnapshot creates a new state from a given trie, serving account and
+// storage reads from the flat state snapshot tree if it covers the root.
+func NewWithSnapshot(root common.Hash, db bzcdb.Database, snaps *snapshot.Tree) (*StateDB, error) {
 	tr, err := trie.NewSecure(root, db, MaxTrieCacheGen)
 	if err != nil {
 		return nil, err
 	}
 	csc, _ := lru.New(codeSizeCacheSize)
-	return &StateDB{
+	sdb := &StateDB{
 		db:                     db,
 		trie:                   tr,
 		codeSizeCache:          csc,
+		snaps:                  snaps,
 		stateObjects:           make(map[common.Address]*stateObject),
 		stateObjectsDirty:      make(map[common.Address]struct{}),
 		stateObjectsDestructed: make(map[common.Address]struct{}),
 		refund:                 new(big.Int),
 		logs:                   make(map[common.Hash][]*types.Log),
 		preimages:              make(map[common.Hash][]byte),
-	}, nil
+	}
+	sdb.openSnapshot(root)
+	return sdb, nil
 }
 
 // New creates a new statedb by reusing any journalled tries to avoid costly
@@ -115,17 +132,20 @@ func (self *StateDB) New(root common.Hash) (*StateDB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &StateDB{
+	sdb := &StateDB{
 		db:                     self.db,
 		trie:                   tr,
 		codeSizeCache:          self.codeSizeCache,
+		snaps:                  self.snaps,
 		stateObjects:           make(map[common.Address]*stateObject),
 		stateObjectsDirty:      make(map[common.Address]struct{}),
 		stateObjectsDestructed: make(map[common.Address]struct{}),
 		refund:                 new(big.Int),
 		logs:                   make(map[common.Hash][]*types.Log),
 		preimages:              make(map[common.Hash][]byte),
-	}, nil
+	}
+	sdb.openSnapshot(root)
+	return sdb, nil
 }
 
 // Reset clears out all emphemeral state objects from the state db, but keeps
@@ -148,6 +168,7 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.logs = make(map[common.Hash][]*types.Log)
 	self.logSize = 0
 	self.preimages = make(map[common.Hash][]byte)
+	self.openSnapshot(root)
 	self.clearJournalAndRefund()
 
 	return nil
@@ -165,6 +186,34 @@ func (self *StateDB) openTrie(root common.Hash) (*trie.SecureTrie, error) {
 	return trie.NewSecure(root, self.db, MaxTrieCacheGen)
 }
 
+// openSnapshot attaches the flat state snapshot belonging to the given root if
+// one is maintained, discarding any modifications tracked for the previous one.
+func (self *StateDB) openSnapshot(root common.Hash) {
+	self.snap, self.snapDestructs, self.snapAccounts, self.snapStorage = nil, nil, nil, nil
+	if self.snaps == nil {
+		return
+	}
+	if self.snap = self.snaps.Snapshot(root); self.snap != nil {
+		self.snapDestructs = make(map[common.Hash]struct{})
+		self.snapAccounts = make(map[common.Hash][]byte)
+		self.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+}
+
+// updateSnapshot layers the tracked modifications on top of the flat snapshot
+// the state was opened with, switching reads over to the new layer.
+func (self *StateDB) updateSnapshot(root common.Hash) {
+	if self.snap == nil {
+		return
+	}
+	if parent := self.snap.Root(); parent != root {
+		if err := self.snaps.Update(root, parent, self.snapDestructs, self.snapAccounts, self.snapStorage); err != nil {
+			log.Warn("Failed to update state snapshot", "from", parent, "to", root, "err", err)
+		}
+	}
+	self.openSnapshot(root)
+}
+
 func (self *StateDB) pushTrie(t *trie.SecureTrie) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
@@ -395,6 +444,10 @@ func (self *StateDB) updateStateObject(stateObject *stateObject) {
 		panic(fmt.Errorf("can't encode object at %x: %v", addr[:], err))
 	}
 	self.trie.Update(addr[:], data)
+
+	if self.snap != nil {
+		self.snapAccounts[stateObject.addrHash] = data
+	}
 }
 
 // deleteStateObject removes the given object from the state trie.
@@ -402,6 +455,12 @@ func (self *StateDB) deleteStateObject(stateObject *stateObject) {
 	stateObject.deleted = true
 	addr := stateObject.Address()
 	self.trie.Delete(addr[:])
+
+	if self.snap != nil {
+		self.snapDestructs[stateObject.addrHash] = struct{}{}
+		delete(self.snapAccounts, stateObject.addrHash)
+		delete(self.snapStorage, stateObject.addrHash)
+	}
 }
 
 // Retrieve a state object given my the address. Returns nil if not found.
@@ -414,8 +473,19 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 		return obj
 	}
 
-	// Load the object from the database.
-	enc := self.trie.Get(addr[:])
+	// Load the object from the flat snapshot if covered, or the database.
+	var (
+		enc  []byte
+		flat bool
+	)
+	if self.snap != nil {
+		if blob, err := self.snap.Account(crypto.Keccak256Hash(addr[:])); err == nil {
+			enc, flat = blob, true
+		}
+	}
+	if !flat {
+		enc = self.trie.Get(addr[:])
+	}
 	if len(enc) == 0 {
 		return nil
 	}
@@ -426,6 +496,7 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 	}
 	// Insert into the live set.
 	obj := newObject(self, addr, data, self.MarkStateObjectDirty)
+	obj.flatStorage = self.snap != nil
 	self.setStateObject(obj)
 	return obj
 }
@@ -455,6 +526,7 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObjec
 	prev = self.getStateObject(addr)
 	newobj = newObject(self, addr, Account{}, self.MarkStateObjectDirty)
 	newobj.setNonce(0) // sets the object to dirty
+	newobj.recreated = prev != nil
 	if prev == nil {
 		self.journal = append(self.journal, createObjectChange{account: &addr})
 	} else {
@@ -514,6 +586,8 @@ func (self *StateDB) Copy() *StateDB {
 		trie:                   self.trie,
 		pastTries:              self.pastTries,
 		codeSizeCache:          self.codeSizeCache,
+		snaps:                  self.snaps,
+		snap:                   self.snap,
 		stateObjects:           make(map[common.Address]*stateObject, len(self.stateObjectsDirty)),
 		stateObjectsDirty:      make(map[common.Address]struct{}, len(self.stateObjectsDirty)),
 		stateObjectsDestructed: make(map[common.Address]struct{}, len(self.stateObjectsDestructed)),
@@ -537,6 +611,24 @@ func (self *StateDB) Copy() *StateDB {
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
+	// Copy the snapshot modifications tracked so far
+	if self.snap != nil {
+		state.snapDestructs = make(map[common.Hash]struct{}, len(self.snapDestructs))
+		for hash := range self.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte, len(self.snapAccounts))
+		for hash, data := range self.snapAccounts {
+			state.snapAccounts[hash] = data
+		}
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte, len(self.snapStorage))
+		for hash, storage := range self.snapStorage {
+			state.snapStorage[hash] = make(map[common.Hash][]byte, len(storage))
+			for key, data := range storage {
+				state.snapStorage[hash][key] = data
+			}
+		}
+	}
 	return state
 }
 
@@ -692,6 +784,7 @@ func (s *StateDB) CommitTo(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (ro
 	root, err = s.trie.CommitTo(dbw)
 	if err == nil {
 		s.pushTrie(s.trie)
+		s.updateSnapshot(root)
 	}
 	return root, err
 }
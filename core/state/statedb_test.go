@@ -27,8 +27,10 @@ import (
 	"strings"
 	"testing"
 	"testing/quick"
+	"time"
 
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/state/snapshot"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/bzcdb"
 )
@@ -372,3 +374,74 @@ func TestTouchDelete(t *testing.T) {
 		t.Fatal("expected no dirty state object")
 	}
 }
+
+// Tests that state reads served through the flat snapshot match the ones done
+// directly on the tries across a number of modifying commits.
+func TestSnapshotReads(t *testing.T) {
+	db, _ := bzcdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+
+	addrs := make([]common.Address, 8)
+	for i := range addrs {
+		addrs[i] = common.BytesToAddress([]byte{byte(i + 1)})
+		state.AddBalance(addrs[i], big.NewInt(int64(i+1)))
+		state.SetState(addrs[i], common.Hash{1}, common.Hash{byte(i + 1)})
+		state.SetState(addrs[i], common.Hash{2}, common.Hash{byte(i + 1)})
+	}
+	root, _ := state.Commit(false)
+
+	// Create the snapshot and wait until it's generated
+	snaps := snapshot.New(db, root)
+	for {
+		if _, err := snaps.Snapshot(root).Account(common.Hash{0xff}); err != snapshot.ErrNotCoveredYet {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Apply various modifications and cross check the results
+	for round := byte(0); round < 6; round++ {
+		state, _ := NewWithSnapshot(root, db, snaps)
+		for i, addr := range addrs {
+			switch (i + int(round)) % 4 {
+			case 0:
+				state.AddBalance(addr, big.NewInt(1))
+				state.SetState(addr, common.Hash{1}, common.Hash{})
+				state.SetState(addr, common.Hash{round + 3}, common.Hash{round})
+			case 1:
+				state.Suicide(addr)
+			case 2:
+				state.CreateAccount(addr)
+				state.SetState(addr, common.Hash{2}, common.Hash{round})
+			}
+		}
+		state.IntermediateRoot(false)
+		state.AddBalance(addrs[0], big.NewInt(1))
+
+		parent := root
+		root, _ = state.Commit(false)
+		if snaps.Snapshot(root) == nil {
+			t.Fatalf("round %d: snapshot layer missing", round)
+		}
+		if err := snaps.Cap(root, 2); err != nil {
+			t.Fatalf("round %d: failed to cap snapshot: %v", round, err)
+		}
+		if root == parent {
+			t.Fatalf("round %d: state unchanged", round)
+		}
+		flat, _ := NewWithSnapshot(root, db, snaps)
+		plain, _ := New(root, db)
+		for _, addr := range addrs {
+			if flat.Exist(addr) != plain.Exist(addr) {
+				t.Errorf("round %d: %x existence mismatch: flat %v, trie %v", round, addr, flat.Exist(addr), plain.Exist(addr))
+			}
+			if flat.GetBalance(addr).Cmp(plain.GetBalance(addr)) != 0 {
+				t.Errorf("round %d: %x balance mismatch: flat %v, trie %v", round, addr, flat.GetBalance(addr), plain.GetBalance(addr))
+			}
+			for key := byte(1); key < 10; key++ {
+				if have, want := flat.GetState(addr, common.Hash{key}), plain.GetState(addr, common.Hash{key}); have != want {
+					t.Errorf("round %d: %x slot %d mismatch: flat %x, trie %x", round, addr, key, have, want)
+				}
+			}
+		}
+	}
+}
@@ -20,8 +20,18 @@ import (
 	"math/big"
 
 	"github.com/bazacoin/go-bazacoin/common"
+	lru "github.com/hashicorp/golang-lru"
 )
 
+// jumpdestCacheLimit is the maximum number of analysed contracts retained in
+// the shared jump destination cache.
+const jumpdestCacheLimit = 4096
+
+// jumpdestCache holds the JUMPDEST bitmaps of recently executed code, keyed by
+// code hash. Unlike destinations it outlives a single transaction, so hot
+// contracts only need to be analysed once.
+var jumpdestCache, _ = lru.New(jumpdestCacheLimit)
+
 // destinations stores one map per contract (keyed by hash of code).
 // The maps contain an entry for each location of a JUMPDEST
 // instruction.
@@ -38,12 +48,27 @@ func (d destinations) has(codehash common.Hash, code []byte, dest *big.Int) bool
 
 	m, analysed := d[codehash]
 	if !analysed {
-		m = jumpdests(code)
+		m = cachedJumpdests(codehash, code)
 		d[codehash] = m
 	}
 	return (m[udest/8] & (1 << (udest % 8))) != 0
 }
 
+// cachedJumpdests returns the JUMPDEST bitmap of code, consulting the shared
+// cache first. Code without a known hash is analysed but never cached, since
+// the empty hash doesn't identify it.
+func cachedJumpdests(codehash common.Hash, code []byte) []byte {
+	if codehash == (common.Hash{}) {
+		return jumpdests(code)
+	}
+	if m, ok := jumpdestCache.Get(codehash); ok {
+		return m.([]byte)
+	}
+	m := jumpdests(code)
+	jumpdestCache.Add(codehash, m)
+	return m
+}
+
 // jumpdests creates a map that contains an entry for each
 // PC location that is a JUMPDEST instruction.
 func jumpdests(code []byte) []byte {
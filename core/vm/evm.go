@@ -19,12 +19,17 @@ package vm
 import (
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/params"
 )
 
+// emptyCodeHash is used by create to ensure deployment is disallowed to already
+// deployed contract addresses.
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
 type (
 	CanTransferFunc func(StateDB, common.Address, *big.Int) bool
 	TransferFunc    func(StateDB, common.Address, common.Address, *big.Int)
@@ -42,7 +47,20 @@ func run(evm *EVM, snapshot int, contract *Contract, input []byte) ([]byte, erro
 		}
 	}
 
-	return evm.interpreter.Run(snapshot, contract, input)
+	for _, interpreter := range evm.interpreters {
+		if interpreter.CanRun(contract.Code) {
+			if evm.interpreter != interpreter {
+				// Ensure that the interpreter pointer is set back
+				// to its current value upon return.
+				defer func(i Interpreter) {
+					evm.interpreter = i
+				}(evm.interpreter)
+				evm.interpreter = interpreter
+			}
+			return interpreter.Run(snapshot, contract, input)
+		}
+	}
+	return nil, ErrNoCompatibleInterpreter
 }
 
 // Context provides the EVM with auxiliary information. Once provided
@@ -92,9 +110,12 @@ type EVM struct {
 	// virtual machine configuration options used to initialise the
 	// evm.
 	vmConfig Config
-	// global (to this context) bazacoin virtual machine
-	// used throughout the execution of the tx.
-	interpreter *Interpreter
+	// interpreters available to execute contract code, the first one
+	// accepting a contract's code is used to run it.
+	interpreters []Interpreter
+	// interpreter currently executing code, used throughout the
+	// execution of the tx.
+	interpreter Interpreter
 	// abort is used to abort the EVM calling operations
 	// NOTE: must be set atomically
 	abort int32
@@ -111,7 +132,19 @@ func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmCon
 		chainRules:  chainConfig.Rules(ctx.BlockNumber),
 	}
 
-	evm.interpreter = NewInterpreter(evm, vmConfig)
+	factory, ok := lookupInterpreter(vmConfig.EVMInterpreter)
+	if !ok {
+		warnUnknownInterpreter(vmConfig.EVMInterpreter)
+		factory, _ = lookupInterpreter(DefaultInterpreter)
+	}
+	if chainConfig.IsEWASM(ctx.BlockNumber) {
+		// WebAssembly contracts are recognised by their preamble, so the
+		// eWASM interpreter gets the first pick of the code.
+		evm.interpreters = append(evm.interpreters, NewEWASMInterpreter(evm, vmConfig))
+	}
+	evm.interpreters = append(evm.interpreters, factory(evm, vmConfig))
+	evm.interpreter = evm.interpreters[0]
+
 	return evm
 }
 
@@ -137,6 +170,10 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	if !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
 		return nil, gas, ErrInsufficientBalance
 	}
+	if evm.vmConfig.Debug {
+		evm.captureBegin(CALL, caller.Address(), addr, input, gas, value)
+		defer func(start time.Time) { evm.captureEnd(ret, gas, leftOverGas, start, err) }(time.Now())
+	}
 
 	var (
 		to       = AccountRef(addr)
@@ -186,6 +223,10 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
 		return nil, gas, ErrInsufficientBalance
 	}
+	if evm.vmConfig.Debug {
+		evm.captureBegin(CALLCODE, caller.Address(), addr, input, gas, value)
+		defer func(start time.Time) { evm.captureEnd(ret, gas, leftOverGas, start, err) }(time.Now())
+	}
 
 	var (
 		snapshot = evm.StateDB.Snapshot()
@@ -221,6 +262,10 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
+	if evm.vmConfig.Debug {
+		evm.captureBegin(DELEGATECALL, caller.Address(), addr, input, gas, nil)
+		defer func(start time.Time) { evm.captureEnd(ret, gas, leftOverGas, start, err) }(time.Now())
+	}
 
 	var (
 		snapshot = evm.StateDB.Snapshot()
@@ -240,27 +285,23 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	return ret, contract.Gas, err
 }
 
-// Create creates a new contract using code as deployment code.
-func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
-	if evm.vmConfig.NoRecursion && evm.depth > 0 {
-		return nil, common.Address{}, gas, nil
-	}
-
-	// Depth check execution. Fail if we're trying to execute above the
-	// limit.
-	if evm.depth > int(params.CallCreateDepth) {
-		return nil, common.Address{}, gas, ErrDepth
+// create creates a new contract at the given address using code as deployment
+// code. The creator's nonce is expected to be already bumped.
+func (evm *EVM) create(typ OpCode, caller ContractRef, code []byte, gas uint64, value *big.Int, contractAddr common.Address) (ret []byte, _ common.Address, leftOverGas uint64, err error) {
+	if evm.vmConfig.Debug {
+		evm.captureBegin(typ, caller.Address(), contractAddr, code, gas, value)
+		defer func(start time.Time) { evm.captureEnd(ret, gas, leftOverGas, start, err) }(time.Now())
 	}
-	if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
-		return nil, common.Address{}, gas, ErrInsufficientBalance
+	// Ensure there's no existing contract already at the designated address.
+	// Only enforced from Constantinople on, as addresses derived by CREATE2
+	// can be targeted deliberately.
+	if evm.ChainConfig().IsConstantinople(evm.BlockNumber) {
+		contractHash := evm.StateDB.GetCodeHash(contractAddr)
+		if evm.StateDB.GetNonce(contractAddr) != 0 || (contractHash != (common.Hash{}) && contractHash != emptyCodeHash) {
+			return nil, common.Address{}, 0, ErrContractAddressCollision
+		}
 	}
-
-	// Create a new account on the state
-	nonce := evm.StateDB.GetNonce(caller.Address())
-	evm.StateDB.SetNonce(caller.Address(), nonce+1)
-
 	snapshot := evm.StateDB.Snapshot()
-	contractAddr = crypto.CreateAddress(caller.Address(), nonce)
 	evm.StateDB.CreateAccount(contractAddr)
 	if evm.ChainConfig().IsEIP158(evm.BlockNumber) {
 		evm.StateDB.SetNonce(contractAddr, 1)
@@ -307,8 +348,79 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.I
 	return ret, contractAddr, contract.Gas, err
 }
 
+// Create creates a new contract using code as deployment code.
+func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	if evm.vmConfig.NoRecursion && evm.depth > 0 {
+		return nil, common.Address{}, gas, nil
+	}
+
+	// Depth check execution. Fail if we're trying to execute above the
+	// limit.
+	if evm.depth > int(params.CallCreateDepth) {
+		return nil, common.Address{}, gas, ErrDepth
+	}
+	if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
+		return nil, common.Address{}, gas, ErrInsufficientBalance
+	}
+
+	// Create a new account on the state
+	nonce := evm.StateDB.GetNonce(caller.Address())
+	evm.StateDB.SetNonce(caller.Address(), nonce+1)
+
+	return evm.create(CREATE, caller, code, gas, value, crypto.CreateAddress(caller.Address(), nonce))
+}
+
+// Create2 creates a new contract using code as deployment code.
+//
+// Create2 differs from Create in that the contract address is derived from
+// keccak256(0xff ++ msg.sender ++ salt ++ keccak256(init_code))[12:] instead
+// of the usual sender-and-nonce-hash.
+func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, value *big.Int, salt *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	if evm.vmConfig.NoRecursion && evm.depth > 0 {
+		return nil, common.Address{}, gas, nil
+	}
+
+	// Depth check execution. Fail if we're trying to execute above the
+	// limit.
+	if evm.depth > int(params.CallCreateDepth) {
+		return nil, common.Address{}, gas, ErrDepth
+	}
+	if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
+		return nil, common.Address{}, gas, ErrInsufficientBalance
+	}
+
+	// Bump the nonce of the creator, the address is independent of it
+	nonce := evm.StateDB.GetNonce(caller.Address())
+	evm.StateDB.SetNonce(caller.Address(), nonce+1)
+
+	var saltBytes [32]byte
+	copy(saltBytes[:], common.LeftPadBytes(salt.Bytes(), 32))
+
+	return evm.create(CREATE2, caller, code, gas, value, crypto.CreateAddress2(caller.Address(), saltBytes, crypto.Keccak256(code)))
+}
+
+// captureBegin notifies the tracer about a newly entered call frame. The top
+// level frame is reported through CaptureStart, nested ones through CaptureEnter.
+func (evm *EVM) captureBegin(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if evm.depth == 0 {
+		evm.vmConfig.Tracer.CaptureStart(from, to, typ == CREATE || typ == CREATE2, input, gas, value)
+	} else {
+		evm.vmConfig.Tracer.CaptureEnter(typ, from, to, input, gas, value)
+	}
+}
+
+// captureEnd notifies the tracer that the current call frame returned. The top
+// level frame is reported through CaptureEnd, nested ones through CaptureExit.
+func (evm *EVM) captureEnd(output []byte, startGas, leftOverGas uint64, start time.Time, err error) {
+	if evm.depth == 0 {
+		evm.vmConfig.Tracer.CaptureEnd(output, startGas-leftOverGas, time.Since(start), err)
+	} else {
+		evm.vmConfig.Tracer.CaptureExit(output, startGas-leftOverGas, err)
+	}
+}
+
 // ChainConfig returns the evmironment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
 
 // Interpreter returns the EVM interpreter
-func (evm *EVM) Interpreter() *Interpreter { return evm.interpreter }
+func (evm *EVM) Interpreter() Interpreter { return evm.interpreter }
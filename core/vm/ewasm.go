@@ -0,0 +1,471 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/math"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/core/vm/wasm"
+	"github.com/bazacoin/go-bazacoin/params"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// wasmPageGas is charged for every page of WebAssembly linear memory, the
+	// linear cost of the same amount of EVM memory.
+	wasmPageGas = params.MemoryGas * wasm.PageSize / 32
+
+	// wasmMaxPages limits the linear memory of a contract to 16MB.
+	wasmMaxPages = 256
+
+	// wasmModuleCacheLimit is the number of decoded contracts kept around.
+	wasmModuleCacheLimit = 256
+)
+
+// wasmModules caches decoded and compiled contracts by code hash.
+var wasmModules, _ = lru.New(wasmModuleCacheLimit)
+
+var (
+	errWasmFinish = errors.New("ewasm: finish")
+	errWasmRevert = errors.New("ewasm: revert")
+
+	errEEIMemoryAccess     = errors.New("ewasm: out of bounds memory access")
+	errEEIReturnDataAccess = errors.New("ewasm: out of bounds return data access")
+	errEEITooManyTopics    = errors.New("ewasm: too many log topics")
+)
+
+// EWASMInterpreter runs contracts written in WebAssembly. Contracts interact
+// with the chain through the Ethereum environment interface (EEI), a set of
+// host functions imported from the "ethereum" module, and are entered through
+// their exported "main" function.
+//
+// The interpreter is experimental and only enabled on chains configured with
+// an eWASM fork block.
+type EWASMInterpreter struct {
+	evm      *EVM
+	cfg      Config
+	gasTable params.GasTable
+}
+
+// NewEWASMInterpreter returns a new instance of the EWASMInterpreter.
+func NewEWASMInterpreter(evm *EVM, cfg Config) *EWASMInterpreter {
+	return &EWASMInterpreter{
+		evm:      evm,
+		cfg:      cfg,
+		gasTable: evm.ChainConfig().GasTable(evm.BlockNumber),
+	}
+}
+
+// CanRun tells if the contract, passed as an argument, can be run by the
+// current interpreter, which is the case for code starting with the WebAssembly
+// magic bytes.
+func (in *EWASMInterpreter) CanRun(code []byte) bool {
+	return bytes.HasPrefix(code, wasm.Magic)
+}
+
+// Run instantiates the contract's module and calls its main function. Data
+// handed to the finish host function is returned. As the EVM has no notion of
+// reverting without consuming all gas yet, revert is reported as an error.
+func (in *EWASMInterpreter) Run(snapshot int, contract *Contract, input []byte) ([]byte, error) {
+	in.evm.depth++
+	defer func() { in.evm.depth-- }()
+
+	contract.Input = input
+
+	module, err := in.module(contract)
+	if err != nil {
+		return nil, err
+	}
+	env := &eei{in: in, contract: contract}
+	vm, err := wasm.Instantiate(module, env.resolve, wasm.Config{
+		UseGas:   contract.UseGas,
+		PageGas:  wasmPageGas,
+		MaxPages: wasmMaxPages,
+	})
+	if err == nil {
+		_, err = vm.Invoke("main")
+	}
+	switch err {
+	case nil, errWasmFinish:
+		return env.output, nil
+	case errWasmRevert:
+		return env.output, ErrExecutionReverted
+	case wasm.ErrOutOfGas:
+		return nil, ErrOutOfGas
+	default:
+		return nil, err
+	}
+}
+
+// module returns the decoded module of the contract's code.
+func (in *EWASMInterpreter) module(contract *Contract) (*wasm.Module, error) {
+	if contract.CodeHash != (common.Hash{}) {
+		if m, ok := wasmModules.Get(contract.CodeHash); ok {
+			return m.(*wasm.Module), nil
+		}
+	}
+	m, err := wasm.Decode(contract.Code)
+	if err != nil {
+		return nil, err
+	}
+	if exp, ok := m.Exports["main"]; !ok || exp.Kind != wasm.ExportFunction {
+		return nil, errors.New("ewasm: contract doesn't export main")
+	}
+	if contract.CodeHash != (common.Hash{}) {
+		wasmModules.Add(contract.CodeHash, m)
+	}
+	return m, nil
+}
+
+// eei implements the Ethereum environment interface for a single contract
+// invocation.
+type eei struct {
+	in         *EWASMInterpreter
+	contract   *Contract
+	returnData []byte // output of the last call made by the contract
+	output     []byte // data passed to finish or revert
+}
+
+// eeiFunction is a host function of the EEI.
+type eeiFunction struct {
+	params  []wasm.ValueType
+	results []wasm.ValueType
+	call    func(e *eei, vm *wasm.VM, args []uint64) (uint64, error)
+}
+
+var (
+	i32 = wasm.I32
+	i64 = wasm.I64
+)
+
+// eeiFunctions are the host functions importable from the "ethereum" module.
+// Values (balances and call values) are 128 bit little endian integers,
+// addresses are 20 and storage keys and values 32 bytes long.
+var eeiFunctions = map[string]eeiFunction{
+	"useGas":            {[]wasm.ValueType{i64}, nil, (*eei).useGas},
+	"getGasLeft":        {nil, []wasm.ValueType{i64}, (*eei).getGasLeft},
+	"getAddress":        {[]wasm.ValueType{i32}, nil, (*eei).getAddress},
+	"getCaller":         {[]wasm.ValueType{i32}, nil, (*eei).getCaller},
+	"getCallValue":      {[]wasm.ValueType{i32}, nil, (*eei).getCallValue},
+	"getCallDataSize":   {nil, []wasm.ValueType{i32}, (*eei).getCallDataSize},
+	"callDataCopy":      {[]wasm.ValueType{i32, i32, i32}, nil, (*eei).callDataCopy},
+	"getBalance":        {[]wasm.ValueType{i32, i32}, nil, (*eei).getBalance},
+	"getBlockNumber":    {nil, []wasm.ValueType{i64}, (*eei).getBlockNumber},
+	"storageStore":      {[]wasm.ValueType{i32, i32}, nil, (*eei).storageStore},
+	"storageLoad":       {[]wasm.ValueType{i32, i32}, nil, (*eei).storageLoad},
+	"log":               {[]wasm.ValueType{i32, i32, i32, i32, i32, i32, i32}, nil, (*eei).log},
+	"call":              {[]wasm.ValueType{i64, i32, i32, i32, i32}, []wasm.ValueType{i32}, (*eei).call},
+	"getReturnDataSize": {nil, []wasm.ValueType{i32}, (*eei).getReturnDataSize},
+	"returnDataCopy":    {[]wasm.ValueType{i32, i32, i32}, nil, (*eei).returnDataCopy},
+	"finish":            {[]wasm.ValueType{i32, i32}, nil, (*eei).finish},
+	"revert":            {[]wasm.ValueType{i32, i32}, nil, (*eei).revert},
+}
+
+// resolve binds the imports of a contract to the EEI.
+func (e *eei) resolve(module, name string) (*wasm.HostFunction, error) {
+	if module != "ethereum" {
+		return nil, fmt.Errorf("ewasm: unknown import module %q", module)
+	}
+	fn, ok := eeiFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("ewasm: unknown import ethereum.%s", name)
+	}
+	return &wasm.HostFunction{
+		Type: wasm.FuncType{Params: fn.params, Results: fn.results},
+		Call: func(vm *wasm.VM, args []uint64) (uint64, error) {
+			return fn.call(e, vm, args)
+		},
+	}, nil
+}
+
+// wasmMemory returns the region of linear memory at offset, failing if it's
+// out of bounds.
+func wasmMemory(vm *wasm.VM, offset, length uint64) ([]byte, error) {
+	mem := vm.Memory()
+	offset, length = uint64(uint32(offset)), uint64(uint32(length))
+	if offset+length > uint64(len(mem)) {
+		return nil, errEEIMemoryAccess
+	}
+	return mem[offset : offset+length], nil
+}
+
+// u128 encodes v as a 128 bit little endian integer.
+func u128(v *big.Int) []byte {
+	b := make([]byte, 16)
+	if v != nil {
+		be := math.PaddedBigBytes(v, 16)
+		be = be[len(be)-16:]
+		for i := range b {
+			b[i] = be[15-i]
+		}
+	}
+	return b
+}
+
+// fromU128 decodes a 128 bit little endian integer.
+func fromU128(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// write charges for and stores data at the given memory offset.
+func (e *eei) write(vm *wasm.VM, offset uint64, data []byte) error {
+	mem, err := wasmMemory(vm, offset, uint64(len(data)))
+	if err != nil {
+		return err
+	}
+	copy(mem, data)
+	return nil
+}
+
+func (e *eei) useGas(vm *wasm.VM, args []uint64) (uint64, error) {
+	return 0, vm.UseGas(args[0])
+}
+
+func (e *eei) getGasLeft(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(GasQuickStep); err != nil {
+		return 0, err
+	}
+	return e.contract.Gas, nil
+}
+
+func (e *eei) getAddress(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(GasQuickStep); err != nil {
+		return 0, err
+	}
+	return 0, e.write(vm, args[0], e.contract.Address().Bytes())
+}
+
+func (e *eei) getCaller(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(GasQuickStep); err != nil {
+		return 0, err
+	}
+	return 0, e.write(vm, args[0], e.contract.Caller().Bytes())
+}
+
+func (e *eei) getCallValue(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(GasQuickStep); err != nil {
+		return 0, err
+	}
+	return 0, e.write(vm, args[0], u128(e.contract.value))
+}
+
+func (e *eei) getCallDataSize(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(GasQuickStep); err != nil {
+		return 0, err
+	}
+	return uint64(len(e.contract.Input)), nil
+}
+
+func (e *eei) callDataCopy(vm *wasm.VM, args []uint64) (uint64, error) {
+	length := uint64(uint32(args[2]))
+	if err := vm.UseGas(GasFastestStep + toWordSize(length)*params.CopyGas); err != nil {
+		return 0, err
+	}
+	data := getData(e.contract.Input, new(big.Int).SetUint64(uint64(uint32(args[1]))), new(big.Int).SetUint64(length))
+	return 0, e.write(vm, args[0], data)
+}
+
+func (e *eei) getBalance(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(e.in.gasTable.Balance); err != nil {
+		return 0, err
+	}
+	addr, err := wasmMemory(vm, args[0], common.AddressLength)
+	if err != nil {
+		return 0, err
+	}
+	balance := e.in.evm.StateDB.GetBalance(common.BytesToAddress(addr))
+	return 0, e.write(vm, args[1], u128(balance))
+}
+
+func (e *eei) getBlockNumber(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(GasQuickStep); err != nil {
+		return 0, err
+	}
+	return e.in.evm.BlockNumber.Uint64(), nil
+}
+
+func (e *eei) storageStore(vm *wasm.VM, args []uint64) (uint64, error) {
+	key, err := wasmMemory(vm, args[0], common.HashLength)
+	if err != nil {
+		return 0, err
+	}
+	val, err := wasmMemory(vm, args[1], common.HashLength)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		loc   = common.BytesToHash(key)
+		value = common.BytesToHash(val)
+		db    = e.in.evm.StateDB
+		old   = db.GetState(e.contract.Address(), loc)
+		cost  = params.SstoreResetGas
+		clear bool
+	)
+	// Same pricing as SSTORE, see gasSStore.
+	if common.EmptyHash(old) && !common.EmptyHash(value) {
+		cost = params.SstoreSetGas
+	} else if !common.EmptyHash(old) && common.EmptyHash(value) {
+		cost, clear = params.SstoreClearGas, true
+	}
+	if err := vm.UseGas(cost); err != nil {
+		return 0, err
+	}
+	if clear {
+		db.AddRefund(new(big.Int).SetUint64(params.SstoreRefundGas))
+	}
+	db.SetState(e.contract.Address(), loc, value)
+	return 0, nil
+}
+
+func (e *eei) storageLoad(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(e.in.gasTable.SLoad); err != nil {
+		return 0, err
+	}
+	key, err := wasmMemory(vm, args[0], common.HashLength)
+	if err != nil {
+		return 0, err
+	}
+	val := e.in.evm.StateDB.GetState(e.contract.Address(), common.BytesToHash(key))
+	return 0, e.write(vm, args[1], val.Bytes())
+}
+
+func (e *eei) log(vm *wasm.VM, args []uint64) (uint64, error) {
+	size, topics := uint64(uint32(args[1])), uint64(uint32(args[2]))
+	if topics > 4 {
+		return 0, errEEITooManyTopics
+	}
+	if err := vm.UseGas(params.LogGas + topics*params.LogTopicGas + size*params.LogDataGas); err != nil {
+		return 0, err
+	}
+	data, err := wasmMemory(vm, args[0], size)
+	if err != nil {
+		return 0, err
+	}
+	entry := &types.Log{
+		Address:     e.contract.Address(),
+		Topics:      make([]common.Hash, topics),
+		Data:        common.CopyBytes(data),
+		BlockNumber: e.in.evm.BlockNumber.Uint64(),
+	}
+	for i := range entry.Topics {
+		topic, err := wasmMemory(vm, args[3+i], common.HashLength)
+		if err != nil {
+			return 0, err
+		}
+		entry.Topics[i] = common.BytesToHash(topic)
+	}
+	e.in.evm.StateDB.AddLog(entry)
+	return 0, nil
+}
+
+// call performs a message call, returning 0 on success, 1 on failure and 2 if
+// the callee reverted. The gas forwarded is capped like for CALL post EIP150.
+func (e *eei) call(vm *wasm.VM, args []uint64) (uint64, error) {
+	addr, err := wasmMemory(vm, args[1], common.AddressLength)
+	if err != nil {
+		return 0, err
+	}
+	val, err := wasmMemory(vm, args[2], 16)
+	if err != nil {
+		return 0, err
+	}
+	input, err := wasmMemory(vm, args[3], args[4])
+	if err != nil {
+		return 0, err
+	}
+	var (
+		evm     = e.in.evm
+		address = common.BytesToAddress(addr)
+		value   = fromU128(val)
+		cost    = e.in.gasTable.Calls
+	)
+	if value.Sign() != 0 {
+		cost += params.CallValueTransferGas
+		if evm.StateDB.Empty(address) {
+			cost += params.CallNewAccountGas
+		}
+	}
+	if err := vm.UseGas(cost); err != nil {
+		return 0, err
+	}
+	gas := e.contract.Gas - e.contract.Gas/64
+	if args[0] < gas {
+		gas = args[0]
+	}
+	e.contract.UseGas(gas)
+	if value.Sign() != 0 {
+		gas += params.CallStipend
+	}
+	ret, returnGas, err := evm.Call(e.contract, address, common.CopyBytes(input), gas, value)
+	e.contract.Gas += returnGas
+	e.returnData = ret
+
+	switch err {
+	case nil:
+		return 0, nil
+	case ErrExecutionReverted:
+		return 2, nil
+	default:
+		return 1, nil
+	}
+}
+
+func (e *eei) getReturnDataSize(vm *wasm.VM, args []uint64) (uint64, error) {
+	if err := vm.UseGas(GasQuickStep); err != nil {
+		return 0, err
+	}
+	return uint64(len(e.returnData)), nil
+}
+
+func (e *eei) returnDataCopy(vm *wasm.VM, args []uint64) (uint64, error) {
+	offset, length := uint64(uint32(args[1])), uint64(uint32(args[2]))
+	if err := vm.UseGas(GasFastestStep + toWordSize(length)*params.CopyGas); err != nil {
+		return 0, err
+	}
+	if offset+length > uint64(len(e.returnData)) {
+		return 0, errEEIReturnDataAccess
+	}
+	return 0, e.write(vm, args[0], e.returnData[offset:offset+length])
+}
+
+func (e *eei) finish(vm *wasm.VM, args []uint64) (uint64, error) {
+	data, err := wasmMemory(vm, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	e.output = common.CopyBytes(data)
+	return 0, errWasmFinish
+}
+
+func (e *eei) revert(vm *wasm.VM, args []uint64) (uint64, error) {
+	data, err := wasmMemory(vm, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	e.output = common.CopyBytes(data)
+	return 0, errWasmRevert
+}
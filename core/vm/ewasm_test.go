@@ -0,0 +1,127 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/bzcdb"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/state"
+	"github.com/bazacoin/go-bazacoin/core/vm/wasm"
+	"github.com/bazacoin/go-bazacoin/params"
+)
+
+func wasmVec(items ...[]byte) []byte {
+	return append([]byte{byte(len(items))}, bytes.Join(items, nil)...)
+}
+
+func wasmName(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// wasmBody encodes a function body without locals.
+func wasmBody(code ...byte) []byte {
+	return append([]byte{byte(len(code) + 1), 0}, code...)
+}
+
+func wasmSection(id byte, items ...[]byte) []byte {
+	payload := wasmVec(items...)
+	return append([]byte{id, byte(len(payload))}, payload...)
+}
+
+// storeAndFinish is a contract storing 0x2a under key 1, logging "hi" and
+// returning "done".
+var storeAndFinish = bytes.Join([][]byte{
+	wasm.Magic, {1, 0, 0, 0},
+	// (i32, i32) -> (), () -> () and (i32 x 7) -> ()
+	wasmSection(1,
+		[]byte{0x60, 2, 0x7f, 0x7f, 0},
+		[]byte{0x60, 0, 0},
+		[]byte{0x60, 7, 0x7f, 0x7f, 0x7f, 0x7f, 0x7f, 0x7f, 0x7f, 0},
+	),
+	wasmSection(2,
+		append(append(wasmName("ethereum"), wasmName("storageStore")...), 0, 0),
+		append(append(wasmName("ethereum"), wasmName("finish")...), 0, 0),
+		append(append(wasmName("ethereum"), wasmName("log")...), 0, 2),
+	),
+	wasmSection(3, []byte{1}),
+	wasmSection(5, []byte{0, 1}),
+	wasmSection(7, append(wasmName("main"), 0, 3), append(wasmName("memory"), 2, 0)),
+	wasmSection(10, wasmBody(
+		0x41, 0, 0x41, 32, 0x10, 0, // storageStore(0, 32)
+		0x41, 0xc4, 0, 0x41, 2, 0x41, 0, 0x41, 0, 0x41, 0, 0x41, 0, 0x41, 0, 0x10, 2, // log(68, 2, 0, ...)
+		0x41, 0xc0, 0, 0x41, 4, 0x10, 1, // finish(64, 4)
+		0x0b,
+	)),
+	wasmSection(11, bytes.Join([][]byte{
+		{0, 0x41, 0, 0x0b},
+		{70},
+		common.LeftPadBytes([]byte{1}, 32),
+		common.LeftPadBytes([]byte{0x2a}, 32),
+		[]byte("donehi"),
+	}, nil)),
+}, nil)
+
+func TestEWASMCall(t *testing.T) {
+	code := storeAndFinish
+
+	tests := []struct {
+		ewasm  bool
+		gas    uint64
+		output []byte
+		stored common.Hash
+		err    error
+	}{
+		{true, 100000, []byte("done"), common.BytesToHash([]byte{0x2a}), nil},
+		{true, 20000, nil, common.Hash{}, ErrOutOfGas},
+		// Without the fork, the leading zero byte is an EVM STOP.
+		{false, 100000, nil, common.Hash{}, nil},
+	}
+	for i, tt := range tests {
+		db, _ := bzcdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+		contract := common.StringToAddress("contract")
+		statedb.SetCode(contract, code)
+
+		config := *params.TestChainConfig
+		if tt.ewasm {
+			config.EWASMBlock = big.NewInt(0)
+		}
+		evm := NewEVM(Context{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: big.NewInt(1),
+		}, statedb, &config, Config{})
+
+		ret, _, err := evm.Call(AccountRef(common.Address{}), contract, nil, tt.gas, new(big.Int))
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if !bytes.Equal(ret, tt.output) {
+			t.Errorf("test %d: output mismatch: have %q, want %q", i, ret, tt.output)
+		}
+		if stored := statedb.GetState(contract, common.BytesToHash([]byte{1})); stored != tt.stored {
+			t.Errorf("test %d: storage mismatch: have %x, want %x", i, stored, tt.stored)
+		}
+		if logs := statedb.Logs(); tt.err == nil && tt.ewasm && (len(logs) != 1 || string(logs[0].Data) != "hi") {
+			t.Errorf("test %d: log mismatch: have %v", i, logs)
+		}
+	}
+}
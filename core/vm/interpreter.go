@@ -32,10 +32,6 @@ import (
 type Config struct {
 	// Debug enabled debugging Interpreter options
 	Debug bool
-	// EnableJit enabled the JIT VM
-	EnableJit bool
-	// ForceJit forces the JIT VM
-	ForceJit bool
 	// Tracer is the op code logger
 	Tracer Tracer
 	// NoRecursion disabled Interpreter call, callcode,
@@ -49,13 +45,26 @@ type Config struct {
 	// may me left uninitialised and will be set the default
 	// table.
 	JumpTable [256]operation
+	// EVMInterpreter is the name of the registered interpreter used to
+	// execute EVM byte code. If left empty the built-in one is used.
+	EVMInterpreter string
 }
 
 // Interpreter is used to run Bazacoin based contracts and will utilise the
-// passed evmironment to query external sources for state information.
-// The Interpreter will run the byte code VM or JIT VM based on the passed
-// configuration.
-type Interpreter struct {
+// passed environment to query external sources for state information.
+type Interpreter interface {
+	// Run loops and evaluates the contract's code with the given input data and
+	// returns the return byte-slice and an error if one occurred.
+	Run(snapshot int, contract *Contract, input []byte) ([]byte, error)
+	// CanRun tells if the contract code passed as an argument can be run by
+	// the interpreter. This allows engines for different byte code formats to
+	// coexist within a single EVM.
+	CanRun(code []byte) bool
+}
+
+// EVMInterpreter is the default Interpreter, executing EVM byte code through
+// the configured jump table.
+type EVMInterpreter struct {
 	evm      *EVM
 	cfg      Config
 	gasTable params.GasTable
@@ -64,13 +73,15 @@ type Interpreter struct {
 	readonly bool
 }
 
-// NewInterpreter returns a new instance of the Interpreter.
-func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
+// NewEVMInterpreter returns a new instance of the EVMInterpreter.
+func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	// We use the STOP instruction whether to see
 	// the jump table was initialised. If it was not
 	// we'll set the default jump table.
 	if !cfg.JumpTable[STOP].valid {
 		switch {
+		case evm.ChainConfig().IsConstantinople(evm.BlockNumber):
+			cfg.JumpTable = constantinopleInstructionSet
 		case evm.ChainConfig().IsHomestead(evm.BlockNumber):
 			cfg.JumpTable = homesteadInstructionSet
 		default:
@@ -78,7 +89,7 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 		}
 	}
 
-	return &Interpreter{
+	return &EVMInterpreter{
 		evm:      evm,
 		cfg:      cfg,
 		gasTable: evm.ChainConfig().GasTable(evm.BlockNumber),
@@ -86,7 +97,7 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 	}
 }
 
-func (in *Interpreter) enforceRestrictions(op OpCode, operation operation, stack *Stack) error {
+func (in *EVMInterpreter) enforceRestrictions(op OpCode, operation operation, stack *Stack) error {
 	return nil
 }
 
@@ -96,7 +107,7 @@ func (in *Interpreter) enforceRestrictions(op OpCode, operation operation, stack
 // It's important to note that any errors returned by the interpreter should be
 // considered a revert-and-consume-all-gas operation. No error specific checks
 // should be handled to reduce complexity and errors further down the in.
-func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret []byte, err error) {
+func (in *EVMInterpreter) Run(snapshot int, contract *Contract, input []byte) (ret []byte, err error) {
 	in.evm.depth++
 	defer func() { in.evm.depth-- }()
 
@@ -111,9 +122,9 @@ func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret
 	}
 
 	var (
-		op    OpCode        // current opcode
-		mem   = NewMemory() // bound memory
-		stack = newstack()  // local stack
+		op    OpCode              // current opcode
+		mem   = newPooledMemory() // bound memory
+		stack = newstack()        // local stack
 		// For optimisation reason we're using uint64 as the program counter.
 		// It's theoretically possible to go above 2^64. The YP defines the PC
 		// to be uint256. Practically much less so feasible.
@@ -122,12 +133,19 @@ func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret
 	)
 	contract.Input = input
 
+	// Hand the memory and stack back for reuse by later call frames. Anything
+	// escaping this frame (e.g. RETURN data) must have been copied out.
+	defer func() {
+		returnStack(stack)
+		returnMemory(mem)
+	}()
+
 	// User defer pattern to check for an error and, based on the error being nil or not, use all gas and return.
 	defer func() {
 		if err != nil && in.cfg.Debug {
 			// XXX For debugging
 			//fmt.Printf("%04d: %8v    cost = %-8d stack = %-8d ERR = %v\n", pc, op, cost, stack.len(), err)
-			in.cfg.Tracer.CaptureState(in.evm, pc, op, contract.Gas, cost, mem, stack, contract, in.evm.depth, err)
+			in.cfg.Tracer.CaptureFault(in.evm, pc, op, contract.Gas, cost, mem, stack, contract, in.evm.depth, err)
 		}
 	}()
 
@@ -194,7 +212,7 @@ func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret
 		//fmt.Printf("%04d: %8v    cost = %-8d stack = %-8d\n", pc, op, cost, stack.len())
 
 		// execute the operation
-		res, err := operation.execute(&pc, in.evm, contract, mem, stack)
+		res, err := operation.execute(&pc, in, contract, mem, stack)
 		// verifyPool is a build flag. Pool verification makes sure the integrity
 		// of the integer pool by comparing values to a default value.
 		if verifyPool {
@@ -217,3 +235,9 @@ func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret
 	}
 	return nil, nil
 }
+
+// CanRun tells if the contract, passed as an argument, can be run by the
+// current interpreter. Any code is considered EVM byte code.
+func (in *EVMInterpreter) CanRun(code []byte) bool {
+	return true
+}
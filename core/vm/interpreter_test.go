@@ -0,0 +1,85 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/params"
+)
+
+// echoInterpreter returns the code it's asked to run, but only accepts code
+// starting with a marker byte.
+type echoInterpreter struct{}
+
+func (echoInterpreter) Run(snapshot int, contract *Contract, input []byte) ([]byte, error) {
+	return contract.Code, nil
+}
+
+func (echoInterpreter) CanRun(code []byte) bool {
+	return len(code) > 0 && code[0] == 0xfe
+}
+
+// unregisterInterpreter removes a previously registered interpreter, allowing
+// tests to clean up after themselves.
+func unregisterInterpreter(name string) {
+	interpretersMu.Lock()
+	defer interpretersMu.Unlock()
+
+	delete(interpreters, name)
+}
+
+func TestRegisterInterpreter(t *testing.T) {
+	RegisterInterpreter("echo", func(evm *EVM, cfg Config) Interpreter {
+		return echoInterpreter{}
+	})
+	defer unregisterInterpreter("echo")
+
+	found := false
+	for _, name := range Interpreters() {
+		found = found || name == "echo"
+	}
+	if !found {
+		t.Fatalf("registered interpreter missing from %v", Interpreters())
+	}
+
+	env := NewEVM(Context{}, nil, params.TestChainConfig, Config{EVMInterpreter: "echo"})
+	if _, ok := env.Interpreter().(echoInterpreter); !ok {
+		t.Fatalf("wrong interpreter selected: %T", env.Interpreter())
+	}
+	code := []byte{0xfe, 0x01, 0x02}
+	contract := NewContract(AccountRef(common.Address{}), AccountRef(common.Address{}), nil, 0)
+	contract.Code = code
+
+	ret, err := run(env, 0, contract, nil)
+	if err != nil || !bytes.Equal(ret, code) {
+		t.Fatalf("run mismatch: have %x, %v; want %x, nil", ret, err, code)
+	}
+	contract.Code = []byte{byte(STOP)}
+	if _, err := run(env, 0, contract, nil); err != ErrNoCompatibleInterpreter {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoCompatibleInterpreter)
+	}
+}
+
+func TestUnknownInterpreter(t *testing.T) {
+	env := NewEVM(Context{}, nil, params.TestChainConfig, Config{EVMInterpreter: "nonexistent"})
+	if _, ok := env.Interpreter().(*EVMInterpreter); !ok {
+		t.Fatalf("expected fallback to default interpreter, got %T", env.Interpreter())
+	}
+}
@@ -80,13 +80,21 @@ func (s *StructLog) OpName() string {
 }
 
 // Tracer is used to collect execution traces from an EVM transaction
-// execution. CaptureState is called for each step of the VM with the
-// current VM state.
+// execution. CaptureStart and CaptureEnd bracket the top level call or
+// create, CaptureEnter and CaptureExit bracket every nested call frame
+// (including calls into precompiles and plain value transfers) and
+// CaptureState is called for each step of the VM with the current VM
+// state. CaptureFault is called instead of CaptureState when a step
+// fails.
 // Note that reference types are actual VM data structures; make copies
 // if you need to retain them beyond the current call.
 type Tracer interface {
+	CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error
 	CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error
-	CaptureEnd(output []byte, gasUsed uint64, t time.Duration) error
+	CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error
+	CaptureExit(output []byte, gasUsed uint64, err error) error
+	CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error
+	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 }
 
 // StructLogger is an EVM state logger and implements Tracer.
@@ -99,6 +107,9 @@ type StructLogger struct {
 
 	logs          []StructLog
 	changedValues map[common.Address]Storage
+
+	output []byte
+	err    error
 }
 
 // NewStructLogger returns a new logger
@@ -112,6 +123,11 @@ func NewStructLogger(cfg *LogConfig) *StructLogger {
 	return logger
 }
 
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (l *StructLogger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
 // CaptureState logs a new structured log message and pushes it out to the environment
 //
 // CaptureState also tracks SSTORE ops to track dirty values.
@@ -183,8 +199,28 @@ func (l *StructLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost ui
 	return nil
 }
 
-func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration) error {
-	fmt.Printf("0x%x", output)
+// CaptureEnter implements the Tracer interface. Call frames are already
+// reflected by the depth of the captured steps, so it's a noop.
+func (l *StructLogger) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+// CaptureExit implements the Tracer interface, it's a noop.
+func (l *StructLogger) CaptureExit(output []byte, gasUsed uint64, err error) error {
+	return nil
+}
+
+// CaptureFault logs the failing step the same way a successful one is
+// logged, carrying the error that aborted the execution.
+func (l *StructLogger) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return l.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// CaptureEnd is called after the top level call finishes and stores the
+// output and error of the execution.
+func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	l.output = output
+	l.err = err
 	return nil
 }
 
@@ -193,6 +229,12 @@ func (l *StructLogger) StructLogs() []StructLog {
 	return l.logs
 }
 
+// Error returns the VM error captured by the trace.
+func (l *StructLogger) Error() error { return l.err }
+
+// Output returns the VM return value captured by the trace.
+func (l *StructLogger) Output() []byte { return l.output }
+
 // WriteTrace writes a formatted trace to the given writer
 func WriteTrace(writer io.Writer, logs []StructLog) {
 	for _, log := range logs {
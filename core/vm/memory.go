@@ -16,7 +16,23 @@
 
 package vm
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
+
+// memoryPoolLimit is the largest backing store (in bytes) that is kept around
+// for reuse. Larger ones are released to the garbage collector so that a
+// single memory hungry call doesn't pin its allocation forever.
+const memoryPoolLimit = 1024 * 1024
+
+// memoryPool recycles the backing stores of interpreter memories between call
+// frames.
+var memoryPool = sync.Pool{
+	New: func() interface{} {
+		return new(Memory)
+	},
+}
 
 // Memory implements a simple memory model for the bazacoin virtual machine.
 type Memory struct {
@@ -29,6 +45,24 @@ func NewMemory() *Memory {
 	return &Memory{}
 }
 
+// newPooledMemory retrieves an empty memory from the pool. It must be handed
+// back with returnMemory once the call frame owning it has finished.
+func newPooledMemory() *Memory {
+	return memoryPool.Get().(*Memory)
+}
+
+// returnMemory resets the memory and hands it back to the pool. Any slice
+// previously obtained through GetPtr or Data becomes invalid.
+func returnMemory(m *Memory) {
+	if cap(m.store) > memoryPoolLimit {
+		m.store = nil
+	} else {
+		m.store = m.store[:0]
+	}
+	m.lastGasCost, m.lastReturn = 0, nil
+	memoryPool.Put(m)
+}
+
 // Set sets offset + size to value
 func (m *Memory) Set(offset, size uint64, value []byte) {
 	// length of store may never be less than offset + size.
@@ -19,6 +19,7 @@ package vm
 import (
 	"math/big"
 
+	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/common/math"
 )
 
@@ -39,21 +40,25 @@ func memoryExtCodeCopy(stack *Stack) *big.Int {
 }
 
 func memoryMLoad(stack *Stack) *big.Int {
-	return calcMemSize(stack.Back(0), big.NewInt(32))
+	return calcMemSize(stack.Back(0), common.Big32)
 }
 
 func memoryMStore8(stack *Stack) *big.Int {
-	return calcMemSize(stack.Back(0), big.NewInt(1))
+	return calcMemSize(stack.Back(0), common.Big1)
 }
 
 func memoryMStore(stack *Stack) *big.Int {
-	return calcMemSize(stack.Back(0), big.NewInt(32))
+	return calcMemSize(stack.Back(0), common.Big32)
 }
 
 func memoryCreate(stack *Stack) *big.Int {
 	return calcMemSize(stack.Back(1), stack.Back(2))
 }
 
+func memoryCreate2(stack *Stack) *big.Int {
+	return calcMemSize(stack.Back(1), stack.Back(2))
+}
+
 func memoryCall(stack *Stack) *big.Int {
 	x := calcMemSize(stack.Back(5), stack.Back(6))
 	y := calcMemSize(stack.Back(3), stack.Back(4))
@@ -63,6 +63,9 @@ const (
 	XOR
 	NOT
 	BYTE
+	SHL
+	SHR
+	SAR
 
 	SHA3 = 0x20
 )
@@ -82,6 +85,8 @@ const (
 	GASPRICE
 	EXTCODESIZE
 	EXTCODECOPY
+
+	EXTCODEHASH = 0x3f
 )
 
 const (
@@ -201,6 +206,7 @@ const (
 	CALLCODE
 	RETURN
 	DELEGATECALL
+	CREATE2
 
 	SELFDESTRUCT = 0xff
 )
@@ -231,6 +237,9 @@ var opCodeToString = map[OpCode]string{
 	OR:     "OR",
 	XOR:    "XOR",
 	BYTE:   "BYTE",
+	SHL:    "SHL",
+	SHR:    "SHR",
+	SAR:    "SAR",
 	ADDMOD: "ADDMOD",
 	MULMOD: "MULMOD",
 
@@ -259,6 +268,7 @@ var opCodeToString = map[OpCode]string{
 	GASLIMIT:    "GASLIMIT",
 	EXTCODESIZE: "EXTCODESIZE",
 	EXTCODECOPY: "EXTCODECOPY",
+	EXTCODEHASH: "EXTCODEHASH",
 
 	// 0x50 range - 'storage' and execution
 	POP: "POP",
@@ -355,6 +365,7 @@ var opCodeToString = map[OpCode]string{
 	RETURN:       "RETURN",
 	CALLCODE:     "CALLCODE",
 	DELEGATECALL: "DELEGATECALL",
+	CREATE2:      "CREATE2",
 	SELFDESTRUCT: "SELFDESTRUCT",
 
 	PUSH: "PUSH",
@@ -393,6 +404,9 @@ var stringToOp = map[string]OpCode{
 	"OR":           OR,
 	"XOR":          XOR,
 	"BYTE":         BYTE,
+	"SHL":          SHL,
+	"SHR":          SHR,
+	"SAR":          SAR,
 	"ADDMOD":       ADDMOD,
 	"MULMOD":       MULMOD,
 	"SHA3":         SHA3,
@@ -416,6 +430,7 @@ var stringToOp = map[string]OpCode{
 	"GASLIMIT":     GASLIMIT,
 	"EXTCODESIZE":  EXTCODESIZE,
 	"EXTCODECOPY":  EXTCODECOPY,
+	"EXTCODEHASH":  EXTCODEHASH,
 	"POP":          POP,
 	"MLOAD":        MLOAD,
 	"MSTORE":       MSTORE,
@@ -498,6 +513,7 @@ var stringToOp = map[string]OpCode{
 	"LOG3":         LOG3,
 	"LOG4":         LOG4,
 	"CREATE":       CREATE,
+	"CREATE2":      CREATE2,
 	"CALL":         CALL,
 	"RETURN":       RETURN,
 	"CALLCODE":     CALLCODE,
@@ -0,0 +1,95 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bazacoin/go-bazacoin/log"
+)
+
+// DefaultInterpreter is the name under which the built-in EVM byte code
+// interpreter is registered.
+const DefaultInterpreter = "evm"
+
+// InterpreterFactory creates an interpreter bound to the given EVM.
+type InterpreterFactory func(evm *EVM, cfg Config) Interpreter
+
+var (
+	interpretersMu sync.RWMutex
+	interpreters   = map[string]InterpreterFactory{
+		DefaultInterpreter: func(evm *EVM, cfg Config) Interpreter {
+			return NewEVMInterpreter(evm, cfg)
+		},
+	}
+	unknownWarned = make(map[string]bool) // Unknown interpreters already warned about
+)
+
+// RegisterInterpreter makes an interpreter available for executing EVM byte
+// code under the given name, selectable through Config.EVMInterpreter. It is
+// meant to be called from the init function of the package implementing it
+// and panics if the name is already taken.
+func RegisterInterpreter(name string, factory InterpreterFactory) {
+	interpretersMu.Lock()
+	defer interpretersMu.Unlock()
+
+	if _, exists := interpreters[name]; exists {
+		panic(fmt.Sprintf("vm: interpreter %q registered twice", name))
+	}
+	interpreters[name] = factory
+}
+
+// Interpreters returns the sorted names of all registered interpreters.
+func Interpreters() []string {
+	interpretersMu.RLock()
+	defer interpretersMu.RUnlock()
+
+	names := make([]string, 0, len(interpreters))
+	for name := range interpreters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupInterpreter returns the factory registered under name, with the empty
+// name standing for the default interpreter.
+func lookupInterpreter(name string) (InterpreterFactory, bool) {
+	if name == "" {
+		name = DefaultInterpreter
+	}
+	interpretersMu.RLock()
+	defer interpretersMu.RUnlock()
+
+	factory, ok := interpreters[name]
+	return factory, ok
+}
+
+// warnUnknownInterpreter logs that the interpreter with the given name is not
+// registered. The warning is only logged once per name, not for every EVM.
+func warnUnknownInterpreter(name string) {
+	interpretersMu.Lock()
+	defer interpretersMu.Unlock()
+
+	if unknownWarned[name] {
+		return
+	}
+	unknownWarned[name] = true
+	log.Warn("Unknown EVM interpreter, using default", "name", name, "default", DefaultInterpreter)
+}
@@ -17,9 +17,13 @@
 package runtime
 
 import (
+	"bytes"
+	"fmt"
 	"math/big"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bazacoin/go-bazacoin/accounts/abi"
 	"github.com/bazacoin/go-bazacoin/common"
@@ -117,6 +121,67 @@ func TestCall(t *testing.T) {
 	}
 }
 
+// frameTracer is a vm.Tracer recording the call frame events it receives.
+type frameTracer struct {
+	events []string
+}
+
+func (t *frameTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.events = append(t.events, "start")
+	return nil
+}
+func (t *frameTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+func (t *frameTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	t.events = append(t.events, fmt.Sprintf("enter %v %x", typ, to))
+	return nil
+}
+func (t *frameTracer) CaptureExit(output []byte, gasUsed uint64, err error) error {
+	t.events = append(t.events, fmt.Sprintf("exit %x", output))
+	return nil
+}
+func (t *frameTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	t.events = append(t.events, fmt.Sprintf("fault %v", op))
+	return nil
+}
+func (t *frameTracer) CaptureEnd(output []byte, gasUsed uint64, tm time.Duration, err error) error {
+	t.events = append(t.events, "end")
+	return nil
+}
+
+func TestCallFrameTracing(t *testing.T) {
+	tracer := new(frameTracer)
+	cfg := &Config{EVMConfig: vm.Config{Debug: true, Tracer: tracer}}
+
+	// Store 0x2a in memory, send it to the identity precompile, then transfer
+	// value to an account without code and finally hit an invalid opcode.
+	code := common.Hex2Bytes(
+		"602a600052" + // mstore(0, 0x2a)
+			"6020600060206000600060046103e8f150" + // call(1000, 0x04, 0, 0, 32, 0, 32)
+			"6000600060006000600173ffffffffffffffffffffffffffffffffffffffff6103e8f150" + // call(1000, 0xff..ff, 1, 0, 0, 0, 0)
+			"fe") // invalid
+	db, _ := bzcdb.NewMemDatabase()
+	cfg.State, _ = state.New(common.Hash{}, db)
+	cfg.State.AddBalance(common.StringToAddress("contract"), big.NewInt(1))
+
+	if _, _, err := Execute(code, nil, cfg); err == nil {
+		t.Fatal("expected invalid opcode error")
+	}
+	want := []string{
+		"start",
+		"enter CALL 0000000000000000000000000000000000000004",
+		"exit 000000000000000000000000000000000000000000000000000000000000002a",
+		"enter CALL ffffffffffffffffffffffffffffffffffffffff",
+		"exit ",
+		"fault Missing opcode 0xfe",
+		"end",
+	}
+	if !reflect.DeepEqual(tracer.events, want) {
+		t.Errorf("trace mismatch:\nhave %q\nwant %q", tracer.events, want)
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
@@ -149,3 +214,76 @@ func BenchmarkCall(b *testing.B) {
 		}
 	}
 }
+
+// benchmarkCode installs code (and any additional accounts) into a fresh state
+// and repeatedly calls into it. The code must not modify state, so that every
+// iteration does the same amount of work.
+func benchmarkCode(b *testing.B, code []byte, accounts map[common.Address][]byte) {
+	cfg := &Config{GasLimit: 100000000}
+	setDefaults(cfg)
+
+	db, _ := bzcdb.NewMemDatabase()
+	cfg.State, _ = state.New(common.Hash{}, db)
+	for addr, code := range accounts {
+		cfg.State.CreateAccount(addr)
+		cfg.State.SetCode(addr, code)
+	}
+	destination := common.StringToAddress("contract")
+	cfg.State.CreateAccount(destination)
+	cfg.State.SetCode(destination, code)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := Call(destination, nil, cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkArithmeticLoop measures the raw interpreter loop: a counter is
+// decremented 10000 times, doing a handful of arithmetic operations each round.
+func BenchmarkArithmeticLoop(b *testing.B) {
+	code := common.Hex2Bytes(
+		"612710" + // PUSH2 10000
+			"5b" + // JUMPDEST
+			"6003600502600701600205" + // (3 * 5 + 7) / 2
+			"50" + // POP
+			"60019003" + // counter - 1
+			"80600357" + // DUP1 PUSH1 3 JUMPI
+			"5000", // POP STOP
+	)
+	benchmarkCode(b, code, nil)
+}
+
+// BenchmarkJumpdestAnalysis calls into a large contract that executes a single
+// jump, so the cost is dominated by the JUMPDEST analysis of the code.
+func BenchmarkJumpdestAnalysis(b *testing.B) {
+	code := common.Hex2Bytes("61500456") // PUSH2 0x5004 JUMP
+	code = append(code, bytes.Repeat([]byte{byte(vm.JUMPDEST)}, 0x5000)...)
+	code = append(code, byte(vm.JUMPDEST), byte(vm.STOP))
+
+	benchmarkCode(b, code, nil)
+}
+
+// BenchmarkNestedCalls performs 100 calls into a contract which writes into its
+// memory and returns a word, stressing the set up and tear down of call frames.
+func BenchmarkNestedCalls(b *testing.B) {
+	callee := common.StringToAddress("callee")
+
+	code := common.Hex2Bytes("60645b" + // PUSH1 100 JUMPDEST
+		"60206000600060006000" + // retSize, retOffset, inSize, inOffset, value
+		"73") // PUSH20
+	code = append(code, callee.Bytes()...)
+	code = append(code, common.Hex2Bytes(
+		"5af150"+ // GAS CALL POP
+			"60019003"+ // counter - 1
+			"80600257"+ // DUP1 PUSH1 2 JUMPI
+			"5000", // POP STOP
+	)...)
+
+	benchmarkCode(b, code, map[common.Address][]byte{
+		// PUSH1 42 PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+		callee: common.Hex2Bytes("602a60005260206000f3"),
+	})
+}
@@ -19,6 +19,7 @@ package vm
 import (
 	"fmt"
 	"math/big"
+	"sync"
 )
 
 // stack is an object for basic stack operations. Items popped to the stack are
@@ -28,8 +29,23 @@ type Stack struct {
 	data []*big.Int
 }
 
+// stackPool recycles the 1024 item backing arrays of stacks between call
+// frames so that nested calls don't have to allocate a fresh one each time.
+var stackPool = sync.Pool{
+	New: func() interface{} {
+		return &Stack{data: make([]*big.Int, 0, 1024)}
+	},
+}
+
 func newstack() *Stack {
-	return &Stack{data: make([]*big.Int, 0, 1024)}
+	return stackPool.Get().(*Stack)
+}
+
+// returnStack resets the stack and hands it back to the pool. The stack must
+// not be used by the caller afterwards.
+func returnStack(s *Stack) {
+	s.data = s.data[:0]
+	stackPool.Put(s)
 }
 
 func (st *Stack) Data() []*big.Int {
@@ -0,0 +1,231 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// instr is a decoded instruction with its immediates resolved. Structured
+// control flow is compiled into absolute positions, so branches don't need to
+// scan the code at runtime.
+type instr struct {
+	op    byte
+	arity byte     // number of results of a block, loop or if
+	imm   uint64   // constant, index, memory offset, branch depth or gas cost
+	end   uint32   // position of the matching end of a block, loop, if or else
+	els   uint32   // position of the matching else of an if, or its end if absent
+	table []uint32 // branch depths of a br_table, the default one last
+}
+
+// simpleOps are the instructions without immediates that don't affect
+// control flow.
+var simpleOps [256]bool
+
+func init() {
+	for _, op := range []byte{opNop, opDrop, opSelect, opI32WrapI64, opI64ExtendI32S, opI64ExtendI32U} {
+		simpleOps[op] = true
+	}
+	for op := opI32Eqz; op <= opI64GeU; op++ {
+		simpleOps[op] = true
+	}
+	for op := opI32Clz; op <= opI64Rotr; op++ {
+		simpleOps[op] = true
+	}
+}
+
+// endsBlock reports whether op terminates a basic block, i.e. whether the
+// instruction following it may be reached by a jump.
+func endsBlock(op byte) bool {
+	switch op {
+	case opUnreachable, opBlock, opLoop, opIf, opElse, opEnd, opBr, opBrIf, opBrTable, opReturn, opCall:
+		return true
+	}
+	return false
+}
+
+// compile translates the body of fn into instructions, resolving the targets
+// of structured control flow and injecting a gas counter at the start of each
+// basic block. Every instruction costs one unit of gas.
+func compile(m *Module, fn *Function) ([]instr, error) {
+	var (
+		r         = &reader{data: fn.Body}
+		code      []instr
+		blocks    []int // positions of the enclosing block, loop and if instructions
+		meter     = -1  // position of the current block's gas counter, -1 if none
+		done      bool  // whether the end of the function was reached
+		numLocals = uint64(len(m.Types[fn.Type].Params) + len(fn.Locals))
+		funcs     = uint64(len(m.Imports) + len(m.Functions))
+	)
+	for r.len() > 0 {
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if meter < 0 {
+			code = append(code, instr{op: opUseGas})
+			meter = len(code) - 1
+		}
+		in := instr{op: op}
+
+		switch {
+		case simpleOps[op], op == opUnreachable, op == opReturn:
+
+		case op == opBlock || op == opLoop || op == opIf:
+			bt, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			if bt != 0x40 {
+				if t := ValueType(bt); t != I32 && t != I64 {
+					return nil, fmt.Errorf("unsupported block type 0x%x", bt)
+				}
+				in.arity = 1
+			}
+			blocks = append(blocks, len(code))
+
+		case op == opElse:
+			if len(blocks) == 0 || code[blocks[len(blocks)-1]].op != opIf || code[blocks[len(blocks)-1]].els != 0 {
+				return nil, errors.New("else without if")
+			}
+			code[blocks[len(blocks)-1]].els = uint32(len(code))
+
+		case op == opEnd:
+			if len(blocks) == 0 {
+				if r.len() != 0 {
+					return nil, errors.New("instructions after function end")
+				}
+				done = true
+				break
+			}
+			pos := uint32(len(code))
+			start := &code[blocks[len(blocks)-1]]
+			blocks = blocks[:len(blocks)-1]
+
+			start.end = pos
+			if start.op == opIf {
+				if start.els == 0 {
+					start.els = pos
+				} else {
+					code[start.els].end = pos
+				}
+			}
+
+		case op == opBr || op == opBrIf:
+			if in.imm, err = r.uleb(35); err != nil {
+				return nil, err
+			}
+			if in.imm > uint64(len(blocks)) {
+				return nil, fmt.Errorf("branch depth %d out of range", in.imm)
+			}
+
+		case op == opBrTable:
+			n, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			if n > uint32(r.len()) {
+				return nil, errTruncated
+			}
+			in.table = make([]uint32, n+1)
+			for i := range in.table {
+				if in.table[i], err = r.u32(); err != nil {
+					return nil, err
+				}
+				if in.table[i] > uint32(len(blocks)) {
+					return nil, fmt.Errorf("branch depth %d out of range", in.table[i])
+				}
+			}
+
+		case op == opCall:
+			if in.imm, err = r.uleb(35); err != nil {
+				return nil, err
+			}
+			if in.imm >= funcs {
+				return nil, fmt.Errorf("call to unknown function %d", in.imm)
+			}
+
+		case op >= opLocalGet && op <= opLocalTee:
+			if in.imm, err = r.uleb(35); err != nil {
+				return nil, err
+			}
+			if in.imm >= numLocals {
+				return nil, fmt.Errorf("unknown local %d", in.imm)
+			}
+
+		case op == opGlobalGet || op == opGlobalSet:
+			if in.imm, err = r.uleb(35); err != nil {
+				return nil, err
+			}
+			if in.imm >= uint64(len(m.Globals)) {
+				return nil, fmt.Errorf("unknown global %d", in.imm)
+			}
+			if op == opGlobalSet && !m.Globals[in.imm].Mutable {
+				return nil, fmt.Errorf("assignment to immutable global %d", in.imm)
+			}
+
+		case op >= opI32Load && op <= opI64Store32 && op != 0x2a && op != 0x2b && op != 0x38 && op != 0x39:
+			if m.Memory == nil {
+				return nil, errors.New("memory access without memory")
+			}
+			if _, err := r.u32(); err != nil { // alignment hint
+				return nil, err
+			}
+			if in.imm, err = r.uleb(35); err != nil {
+				return nil, err
+			}
+
+		case op == opMemorySize || op == opMemoryGrow:
+			if m.Memory == nil {
+				return nil, errors.New("memory access without memory")
+			}
+			if reserved, err := r.byte(); err != nil {
+				return nil, err
+			} else if reserved != 0 {
+				return nil, errors.New("invalid memory index")
+			}
+
+		case op == opI32Const:
+			v, err := r.sleb(35)
+			if err != nil {
+				return nil, err
+			}
+			in.imm = uint64(uint32(v))
+
+		case op == opI64Const:
+			v, err := r.sleb(70)
+			if err != nil {
+				return nil, err
+			}
+			in.imm = uint64(v)
+
+		default:
+			return nil, fmt.Errorf("unsupported instruction 0x%x", op)
+		}
+		code = append(code, in)
+		code[meter].imm++
+
+		if endsBlock(op) {
+			meter = -1
+		}
+	}
+	if !done {
+		return nil, errors.New("unterminated function body")
+	}
+	return code, nil
+}
@@ -0,0 +1,676 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package wasm implements a small, deterministic WebAssembly engine.
+//
+// The engine supports the integer subset of the WebAssembly MVP: modules may
+// import host functions, define a single linear memory and mutable globals,
+// but may not use floating point values, tables or indirect calls. Execution
+// is metered by gas counters injected at the start of every basic block.
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Magic is the preamble every binary WebAssembly module starts with.
+var Magic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+// Version is the only binary format version understood by the decoder.
+const Version = 1
+
+// PageSize is the size in bytes of a linear memory page.
+const PageSize = 65536
+
+var (
+	errBadMagic   = errors.New("wasm: invalid magic number")
+	errBadVersion = errors.New("wasm: unsupported binary version")
+	errTruncated  = errors.New("wasm: unexpected end of module")
+	errOverflow   = errors.New("wasm: integer encoding overflow")
+)
+
+// ValueType is the type of a WebAssembly value.
+type ValueType byte
+
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	default:
+		return fmt.Sprintf("type(0x%x)", byte(t))
+	}
+}
+
+// FuncType is the signature of a function.
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+// Equal reports whether both signatures are identical.
+func (t FuncType) Equal(o FuncType) bool {
+	return bytes.Equal(valueTypeBytes(t.Params), valueTypeBytes(o.Params)) &&
+		bytes.Equal(valueTypeBytes(t.Results), valueTypeBytes(o.Results))
+}
+
+func (t FuncType) String() string {
+	return fmt.Sprintf("%v -> %v", t.Params, t.Results)
+}
+
+func valueTypeBytes(types []ValueType) []byte {
+	b := make([]byte, len(types))
+	for i, t := range types {
+		b[i] = byte(t)
+	}
+	return b
+}
+
+// Import is a function imported from the host.
+type Import struct {
+	Module string
+	Name   string
+	Type   uint32 // index into the type section
+}
+
+// Function is a function defined by the module.
+type Function struct {
+	Type   uint32      // index into the type section
+	Locals []ValueType // declared locals, excluding parameters
+	Body   []byte      // raw expression, including the final end
+
+	code []instr // compiled and gas metered body
+}
+
+// Global is a global variable defined by the module.
+type Global struct {
+	Type    ValueType
+	Mutable bool
+	Init    uint64
+}
+
+// Export kinds.
+const (
+	ExportFunction byte = 0x00
+	ExportTable    byte = 0x01
+	ExportMemory   byte = 0x02
+	ExportGlobal   byte = 0x03
+)
+
+// Export is an entity exposed by the module to the host.
+type Export struct {
+	Kind  byte
+	Index uint32
+}
+
+// Limits are the bounds of a linear memory in pages.
+type Limits struct {
+	Min    uint32
+	Max    uint32
+	HasMax bool
+}
+
+// DataSegment initialises a region of linear memory.
+type DataSegment struct {
+	Offset uint32
+	Data   []byte
+}
+
+// Module is a decoded and compiled WebAssembly module. It is immutable and may
+// be shared between any number of concurrently running VMs.
+type Module struct {
+	Types     []FuncType
+	Imports   []Import
+	Functions []Function
+	Memory    *Limits
+	Globals   []Global
+	Exports   map[string]Export
+	Start     *uint32
+	Data      []DataSegment
+}
+
+// FuncType returns the signature of the function with the given index, where
+// imported functions come before the ones defined by the module.
+func (m *Module) FuncType(index uint32) (FuncType, bool) {
+	if int(index) < len(m.Imports) {
+		return m.Types[m.Imports[index].Type], true
+	}
+	index -= uint32(len(m.Imports))
+	if int(index) < len(m.Functions) {
+		return m.Types[m.Functions[index].Type], true
+	}
+	return FuncType{}, false
+}
+
+// Decode parses a binary WebAssembly module and compiles its functions.
+func Decode(code []byte) (*Module, error) {
+	r := &reader{data: code}
+	magic, err := r.bytes(4)
+	if err != nil || !bytes.Equal(magic, Magic) {
+		return nil, errBadMagic
+	}
+	version, err := r.bytes(4)
+	if err != nil || version[0] != Version || version[1] != 0 || version[2] != 0 || version[3] != 0 {
+		return nil, errBadVersion
+	}
+	m := &Module{Exports: make(map[string]Export)}
+
+	var (
+		funcTypes []uint32 // function section, matched up with the code section
+		lastID    byte
+	)
+	for r.len() > 0 {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		if id == 0 {
+			continue // custom sections carry no semantics
+		}
+		if id <= lastID {
+			return nil, fmt.Errorf("wasm: section %d out of order", id)
+		}
+		lastID = id
+
+		s := &reader{data: payload}
+		switch id {
+		case 1:
+			err = m.decodeTypes(s)
+		case 2:
+			err = m.decodeImports(s)
+		case 3:
+			funcTypes, err = m.decodeFunctions(s)
+		case 5:
+			err = m.decodeMemory(s)
+		case 6:
+			err = m.decodeGlobals(s)
+		case 7:
+			err = m.decodeExports(s)
+		case 8:
+			var start uint32
+			if start, err = s.u32(); err == nil {
+				m.Start = &start
+			}
+		case 10:
+			err = m.decodeCode(s, funcTypes)
+		case 11:
+			err = m.decodeData(s)
+		default:
+			err = fmt.Errorf("wasm: unsupported section %d", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s.len() != 0 {
+			return nil, fmt.Errorf("wasm: trailing bytes in section %d", id)
+		}
+	}
+	if len(funcTypes) != len(m.Functions) {
+		return nil, errors.New("wasm: function and code section mismatch")
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	for i := range m.Functions {
+		code, err := compile(m, &m.Functions[i])
+		if err != nil {
+			return nil, fmt.Errorf("wasm: function %d: %v", len(m.Imports)+i, err)
+		}
+		m.Functions[i].code = code
+	}
+	return m, nil
+}
+
+func (m *Module) decodeTypes(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		if form, err := r.byte(); err != nil {
+			return err
+		} else if form != 0x60 {
+			return fmt.Errorf("wasm: invalid function type form 0x%x", form)
+		}
+		var t FuncType
+		if t.Params, err = r.valueTypes(); err != nil {
+			return err
+		}
+		if t.Results, err = r.valueTypes(); err != nil {
+			return err
+		}
+		if len(t.Results) > 1 {
+			return errors.New("wasm: multiple return values not supported")
+		}
+		m.Types = append(m.Types, t)
+	}
+	return nil
+}
+
+func (m *Module) decodeImports(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		var imp Import
+		if imp.Module, err = r.name(); err != nil {
+			return err
+		}
+		if imp.Name, err = r.name(); err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if kind != ExportFunction {
+			return fmt.Errorf("wasm: import %s.%s: only functions can be imported", imp.Module, imp.Name)
+		}
+		if imp.Type, err = r.u32(); err != nil {
+			return err
+		}
+		m.Imports = append(m.Imports, imp)
+	}
+	return nil
+}
+
+func (m *Module) decodeFunctions(r *reader) ([]uint32, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxFunctions {
+		return nil, errors.New("wasm: too many functions")
+	}
+	if n > uint32(r.len()) {
+		return nil, errTruncated
+	}
+	types := make([]uint32, n)
+	for i := range types {
+		if types[i], err = r.u32(); err != nil {
+			return nil, err
+		}
+	}
+	return types, nil
+}
+
+func (m *Module) decodeMemory(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if n > 1 {
+		return errors.New("wasm: multiple memories not supported")
+	}
+	if n == 1 {
+		limits, err := r.limits()
+		if err != nil {
+			return err
+		}
+		m.Memory = &limits
+	}
+	return nil
+}
+
+func (m *Module) decodeGlobals(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		var g Global
+		if g.Type, err = r.valueType(); err != nil {
+			return err
+		}
+		mut, err := r.byte()
+		if err != nil {
+			return err
+		}
+		g.Mutable = mut == 1
+		if g.Init, err = r.constExpr(g.Type); err != nil {
+			return err
+		}
+		m.Globals = append(m.Globals, g)
+	}
+	return nil
+}
+
+func (m *Module) decodeExports(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		var exp Export
+		if exp.Kind, err = r.byte(); err != nil {
+			return err
+		}
+		if exp.Index, err = r.u32(); err != nil {
+			return err
+		}
+		if _, dup := m.Exports[name]; dup {
+			return fmt.Errorf("wasm: duplicate export %q", name)
+		}
+		m.Exports[name] = exp
+	}
+	return nil
+}
+
+func (m *Module) decodeCode(r *reader, types []uint32) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(types) {
+		return errors.New("wasm: function and code section mismatch")
+	}
+	for i := uint32(0); i < n; i++ {
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+		b := &reader{data: body}
+		groups, err := b.u32()
+		if err != nil {
+			return err
+		}
+		fn := Function{Type: types[i]}
+		for j := uint32(0); j < groups; j++ {
+			count, err := b.u32()
+			if err != nil {
+				return err
+			}
+			t, err := b.valueType()
+			if err != nil {
+				return err
+			}
+			if uint64(len(fn.Locals))+uint64(count) > maxLocals {
+				return errors.New("wasm: too many locals")
+			}
+			for k := uint32(0); k < count; k++ {
+				fn.Locals = append(fn.Locals, t)
+			}
+		}
+		fn.Body = b.data[b.pos:]
+		m.Functions = append(m.Functions, fn)
+	}
+	return nil
+}
+
+func (m *Module) decodeData(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		if index, err := r.u32(); err != nil {
+			return err
+		} else if index != 0 {
+			return errors.New("wasm: data segment for unknown memory")
+		}
+		offset, err := r.constExpr(I32)
+		if err != nil {
+			return err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		data, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+		m.Data = append(m.Data, DataSegment{Offset: uint32(offset), Data: data})
+	}
+	return nil
+}
+
+// validate checks the cross references between sections.
+func (m *Module) validate() error {
+	for _, imp := range m.Imports {
+		if int(imp.Type) >= len(m.Types) {
+			return fmt.Errorf("wasm: import %s.%s has unknown type %d", imp.Module, imp.Name, imp.Type)
+		}
+	}
+	for i, fn := range m.Functions {
+		if int(fn.Type) >= len(m.Types) {
+			return fmt.Errorf("wasm: function %d has unknown type %d", len(m.Imports)+i, fn.Type)
+		}
+	}
+	for name, exp := range m.Exports {
+		switch exp.Kind {
+		case ExportFunction:
+			if _, ok := m.FuncType(exp.Index); !ok {
+				return fmt.Errorf("wasm: export %q of unknown function %d", name, exp.Index)
+			}
+		case ExportMemory:
+			if m.Memory == nil || exp.Index != 0 {
+				return fmt.Errorf("wasm: export %q of unknown memory %d", name, exp.Index)
+			}
+		case ExportGlobal:
+			if int(exp.Index) >= len(m.Globals) {
+				return fmt.Errorf("wasm: export %q of unknown global %d", name, exp.Index)
+			}
+		default:
+			return fmt.Errorf("wasm: export %q of unsupported kind %d", name, exp.Kind)
+		}
+	}
+	if m.Start != nil {
+		t, ok := m.FuncType(*m.Start)
+		if !ok || len(t.Params) != 0 || len(t.Results) != 0 {
+			return errors.New("wasm: invalid start function")
+		}
+	}
+	if len(m.Data) > 0 && m.Memory == nil {
+		return errors.New("wasm: data segments without memory")
+	}
+	return nil
+}
+
+// reader decodes the primitive values of the binary format.
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) len() int { return len(r.data) - r.pos }
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errTruncated
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > r.len() {
+		return nil, errTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(int(n))
+	return string(b), err
+}
+
+// uleb decodes an unsigned LEB128 integer of at most the given bit size.
+func (r *reader) uleb(size uint) (uint64, error) {
+	var result uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift >= size {
+			return 0, errOverflow
+		}
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+}
+
+// sleb decodes a signed LEB128 integer of at most the given bit size.
+func (r *reader) sleb(size uint) (int64, error) {
+	var (
+		result int64
+		shift  uint
+		b      byte
+		err    error
+	)
+	for {
+		if shift >= size {
+			return 0, errOverflow
+		}
+		if b, err = r.byte(); err != nil {
+			return 0, err
+		}
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if shift < 64 && b&0x40 != 0 {
+		result |= -1 << shift
+	}
+	return result, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	v, err := r.uleb(35)
+	if v > 0xffffffff {
+		return 0, errOverflow
+	}
+	return uint32(v), err
+}
+
+func (r *reader) valueType() (ValueType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch t := ValueType(b); t {
+	case I32, I64:
+		return t, nil
+	default:
+		return 0, fmt.Errorf("wasm: unsupported value type 0x%x", b)
+	}
+}
+
+func (r *reader) valueTypes() ([]ValueType, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxLocals {
+		return nil, errors.New("wasm: too many values")
+	}
+	if n > uint32(r.len()) {
+		return nil, errTruncated
+	}
+	types := make([]ValueType, n)
+	for i := range types {
+		if types[i], err = r.valueType(); err != nil {
+			return nil, err
+		}
+	}
+	return types, nil
+}
+
+func (r *reader) limits() (Limits, error) {
+	var l Limits
+	flag, err := r.byte()
+	if err != nil {
+		return l, err
+	}
+	if l.Min, err = r.u32(); err != nil {
+		return l, err
+	}
+	if flag == 1 {
+		l.HasMax = true
+		if l.Max, err = r.u32(); err != nil {
+			return l, err
+		}
+	}
+	return l, nil
+}
+
+// constExpr decodes an initialiser expression consisting of a single constant.
+func (r *reader) constExpr(t ValueType) (uint64, error) {
+	op, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	switch {
+	case op == opI32Const && t == I32:
+		n, err := r.sleb(35)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(uint32(n))
+	case op == opI64Const && t == I64:
+		n, err := r.sleb(70)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(n)
+	default:
+		return 0, fmt.Errorf("wasm: unsupported initialiser 0x%x", op)
+	}
+	if end, err := r.byte(); err != nil {
+		return 0, err
+	} else if end != opEnd {
+		return 0, errors.New("wasm: unterminated initialiser")
+	}
+	return v, nil
+}
@@ -0,0 +1,658 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	maxLocals       = 4096    // maximum number of locals (including parameters) of a function
+	maxFunctions    = 1 << 16 // maximum number of functions defined by a module
+	maxStackHeight  = 1 << 16 // maximum number of values on the operand stack
+	defaultMaxDepth = 1024    // call depth limit if none is configured
+	maxPages        = 65536   // number of pages addressable with 32 bit pointers
+)
+
+// ErrOutOfGas is returned if the gas counter injected into the code or a call
+// to UseGas runs out of gas.
+var ErrOutOfGas = errors.New("wasm: out of gas")
+
+// Trap is the error returned when execution is aborted by a runtime fault.
+type Trap struct {
+	msg string
+}
+
+func (t *Trap) Error() string { return "wasm: " + t.msg }
+
+var (
+	errUnreachable        = &Trap{"unreachable executed"}
+	errMemoryAccess       = &Trap{"out of bounds memory access"}
+	errDivideByZero       = &Trap{"integer divide by zero"}
+	errIntegerOverflow    = &Trap{"integer overflow"}
+	errStackUnderflow     = &Trap{"operand stack underflow"}
+	errStackOverflow      = &Trap{"operand stack overflow"}
+	errCallStackExhausted = &Trap{"call stack exhausted"}
+)
+
+// HostFunction is a function implemented by the host and imported by modules.
+// Its result is ignored if the signature has no results.
+type HostFunction struct {
+	Type FuncType
+	Call func(vm *VM, args []uint64) (uint64, error)
+}
+
+// Resolver returns the host function satisfying an import.
+type Resolver func(module, name string) (*HostFunction, error)
+
+// Config are the execution options of a VM.
+type Config struct {
+	// UseGas is invoked with the cost of each basic block before it runs and
+	// with the cost of memory growth. Returning false aborts execution with
+	// ErrOutOfGas. If nil, execution is not metered.
+	UseGas func(amount uint64) bool
+	// PageGas is charged for every page of linear memory, both the initial
+	// ones and those added by memory.grow.
+	PageGas uint64
+	// MaxPages limits the size of linear memory, in addition to the limit
+	// declared by the module itself. Zero means no additional limit.
+	MaxPages uint32
+	// MaxCallDepth limits the nesting of function calls. Zero selects a
+	// default of 1024.
+	MaxCallDepth int
+}
+
+// VM is an instance of a module, holding its memory, globals and host
+// functions. It is not safe for concurrent use.
+type VM struct {
+	module   *Module
+	cfg      Config
+	host     []*HostFunction
+	memory   []byte
+	maxPages uint32
+	globals  []uint64
+	stack    []uint64
+	depth    int
+}
+
+// Instantiate creates a VM for the module, resolving its imports, setting up
+// memory and running the start function if there is one.
+func Instantiate(m *Module, resolve Resolver, cfg Config) (vm *VM, err error) {
+	if cfg.MaxCallDepth == 0 {
+		cfg.MaxCallDepth = defaultMaxDepth
+	}
+	vm = &VM{
+		module:   m,
+		cfg:      cfg,
+		maxPages: maxPages,
+		globals:  make([]uint64, len(m.Globals)),
+	}
+	for _, imp := range m.Imports {
+		fn, err := resolve(imp.Module, imp.Name)
+		if err != nil {
+			return nil, err
+		}
+		if want := m.Types[imp.Type]; !fn.Type.Equal(want) {
+			return nil, fmt.Errorf("wasm: import %s.%s: signature mismatch, have %v, want %v", imp.Module, imp.Name, fn.Type, want)
+		}
+		vm.host = append(vm.host, fn)
+	}
+	for i, g := range m.Globals {
+		vm.globals[i] = g.Init
+	}
+	if m.Memory != nil {
+		if m.Memory.HasMax && m.Memory.Max < vm.maxPages {
+			vm.maxPages = m.Memory.Max
+		}
+		if cfg.MaxPages != 0 && cfg.MaxPages < vm.maxPages {
+			vm.maxPages = cfg.MaxPages
+		}
+		if m.Memory.Min > vm.maxPages {
+			return nil, fmt.Errorf("wasm: initial memory of %d pages exceeds limit of %d", m.Memory.Min, vm.maxPages)
+		}
+		if !vm.useGas(uint64(m.Memory.Min) * cfg.PageGas) {
+			return nil, ErrOutOfGas
+		}
+		vm.memory = make([]byte, int(m.Memory.Min)*PageSize)
+	}
+	for _, seg := range m.Data {
+		if uint64(seg.Offset)+uint64(len(seg.Data)) > uint64(len(vm.memory)) {
+			return nil, errors.New("wasm: data segment out of bounds")
+		}
+		copy(vm.memory[seg.Offset:], seg.Data)
+	}
+	if m.Start != nil {
+		if err := vm.run(*m.Start); err != nil {
+			return nil, err
+		}
+	}
+	return vm, nil
+}
+
+// Memory returns the linear memory of the VM. The slice is only valid until
+// the memory is grown.
+func (vm *VM) Memory() []byte {
+	return vm.memory
+}
+
+// UseGas charges the given amount of gas, as host functions are expected to
+// pay for the work they perform.
+func (vm *VM) UseGas(amount uint64) error {
+	if !vm.useGas(amount) {
+		return ErrOutOfGas
+	}
+	return nil
+}
+
+func (vm *VM) useGas(amount uint64) bool {
+	return vm.cfg.UseGas == nil || vm.cfg.UseGas(amount)
+}
+
+// Invoke calls the exported function with the given arguments and returns its
+// results.
+func (vm *VM) Invoke(name string, args ...uint64) ([]uint64, error) {
+	exp, ok := vm.module.Exports[name]
+	if !ok || exp.Kind != ExportFunction {
+		return nil, fmt.Errorf("wasm: no exported function %q", name)
+	}
+	t, _ := vm.module.FuncType(exp.Index)
+	if len(args) != len(t.Params) {
+		return nil, fmt.Errorf("wasm: %s expects %d arguments, got %d", name, len(t.Params), len(args))
+	}
+	vm.stack = append(vm.stack[:0], args...)
+	if err := vm.run(exp.Index); err != nil {
+		return nil, err
+	}
+	return append([]uint64(nil), vm.stack...), nil
+}
+
+// run calls a function, converting traps raised while executing it into errors.
+func (vm *VM) run(index uint32) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			trap, ok := r.(*Trap)
+			if !ok {
+				panic(r)
+			}
+			err = trap
+		}
+	}()
+	return vm.call(index)
+}
+
+func (vm *VM) push(v uint64) {
+	if len(vm.stack) >= maxStackHeight {
+		panic(errStackOverflow)
+	}
+	vm.stack = append(vm.stack, v)
+}
+
+func (vm *VM) pop() uint64 {
+	if len(vm.stack) == 0 {
+		panic(errStackUnderflow)
+	}
+	v := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return v
+}
+
+// popN removes the n topmost values from the stack, returning them in the
+// order they were pushed.
+func (vm *VM) popN(n int) []uint64 {
+	if len(vm.stack) < n {
+		panic(errStackUnderflow)
+	}
+	vals := append([]uint64(nil), vm.stack[len(vm.stack)-n:]...)
+	vm.stack = vm.stack[:len(vm.stack)-n]
+	return vals
+}
+
+// unwind drops everything above height, except for the arity topmost values.
+func (vm *VM) unwind(height, arity int) {
+	top := len(vm.stack)
+	if top-arity < height {
+		panic(errStackUnderflow)
+	}
+	copy(vm.stack[height:], vm.stack[top-arity:])
+	vm.stack = vm.stack[:height+arity]
+}
+
+func (vm *VM) call(index uint32) error {
+	t, _ := vm.module.FuncType(index)
+	if int(index) < len(vm.host) {
+		host := vm.host[index]
+		res, err := host.Call(vm, vm.popN(len(t.Params)))
+		if err != nil {
+			return err
+		}
+		if len(t.Results) > 0 {
+			if t.Results[0] == I32 {
+				res = uint64(uint32(res))
+			}
+			vm.push(res)
+		}
+		return nil
+	}
+	if vm.depth >= vm.cfg.MaxCallDepth {
+		return errCallStackExhausted
+	}
+	vm.depth++
+	defer func() { vm.depth-- }()
+
+	fn := &vm.module.Functions[int(index)-len(vm.host)]
+	locals := make([]uint64, len(t.Params)+len(fn.Locals))
+	copy(locals, vm.popN(len(t.Params)))
+
+	return vm.execute(fn.code, locals, len(t.Results))
+}
+
+// label is the target of a branch.
+type label struct {
+	cont   int // position to continue at
+	height int // stack height when the label was entered
+	arity  int // number of values carried by a branch
+}
+
+// branch unwinds the stack to the label at the given depth and returns the
+// position to continue at, along with the remaining labels.
+func (vm *VM) branch(labels []label, depth int) (int, []label) {
+	l := labels[len(labels)-1-depth]
+	vm.unwind(l.height, l.arity)
+	return l.cont, labels[:len(labels)-1-depth]
+}
+
+// address computes the effective address of a memory access of the given
+// size, trapping if it's out of bounds.
+func (vm *VM) address(offset uint64, size uint64) uint64 {
+	addr := uint64(uint32(vm.pop())) + offset
+	if addr+size > uint64(len(vm.memory)) {
+		panic(errMemoryAccess)
+	}
+	return addr
+}
+
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (vm *VM) execute(code []instr, locals []uint64, results int) error {
+	base := len(vm.stack)
+	labels := []label{{cont: len(code), height: base, arity: results}}
+
+	for pc := 0; pc < len(code); {
+		in := &code[pc]
+		pc++
+
+		switch in.op {
+		case opUseGas:
+			if !vm.useGas(in.imm) {
+				return ErrOutOfGas
+			}
+		case opUnreachable:
+			return errUnreachable
+		case opNop:
+
+		case opBlock:
+			labels = append(labels, label{cont: int(in.end) + 1, height: len(vm.stack), arity: int(in.arity)})
+		case opLoop:
+			labels = append(labels, label{cont: pc - 1, height: len(vm.stack)})
+		case opIf:
+			cond := uint32(vm.pop())
+			labels = append(labels, label{cont: int(in.end) + 1, height: len(vm.stack), arity: int(in.arity)})
+			if cond == 0 {
+				if in.els != in.end {
+					pc = int(in.els) + 1
+				} else {
+					pc = int(in.end)
+				}
+			}
+		case opElse:
+			pc = int(in.end)
+		case opEnd:
+			labels = labels[:len(labels)-1]
+		case opBr:
+			pc, labels = vm.branch(labels, int(in.imm))
+		case opBrIf:
+			if uint32(vm.pop()) != 0 {
+				pc, labels = vm.branch(labels, int(in.imm))
+			}
+		case opBrTable:
+			i := uint64(uint32(vm.pop()))
+			depth := in.table[len(in.table)-1]
+			if i < uint64(len(in.table)-1) {
+				depth = in.table[i]
+			}
+			pc, labels = vm.branch(labels, int(depth))
+		case opReturn:
+			pc, labels = vm.branch(labels, len(labels)-1)
+		case opCall:
+			if err := vm.call(uint32(in.imm)); err != nil {
+				return err
+			}
+
+		case opDrop:
+			vm.pop()
+		case opSelect:
+			cond, b, a := uint32(vm.pop()), vm.pop(), vm.pop()
+			if cond != 0 {
+				vm.push(a)
+			} else {
+				vm.push(b)
+			}
+
+		case opLocalGet:
+			vm.push(locals[in.imm])
+		case opLocalSet:
+			locals[in.imm] = vm.pop()
+		case opLocalTee:
+			v := vm.pop()
+			locals[in.imm] = v
+			vm.push(v)
+		case opGlobalGet:
+			vm.push(vm.globals[in.imm])
+		case opGlobalSet:
+			vm.globals[in.imm] = vm.pop()
+
+		case opI32Load:
+			a := vm.address(in.imm, 4)
+			vm.push(uint64(binary.LittleEndian.Uint32(vm.memory[a:])))
+		case opI64Load:
+			a := vm.address(in.imm, 8)
+			vm.push(binary.LittleEndian.Uint64(vm.memory[a:]))
+		case opI32Load8S:
+			a := vm.address(in.imm, 1)
+			vm.push(uint64(uint32(int8(vm.memory[a]))))
+		case opI32Load8U, opI64Load8U:
+			a := vm.address(in.imm, 1)
+			vm.push(uint64(vm.memory[a]))
+		case opI32Load16S:
+			a := vm.address(in.imm, 2)
+			vm.push(uint64(uint32(int16(binary.LittleEndian.Uint16(vm.memory[a:])))))
+		case opI32Load16U, opI64Load16U:
+			a := vm.address(in.imm, 2)
+			vm.push(uint64(binary.LittleEndian.Uint16(vm.memory[a:])))
+		case opI64Load8S:
+			a := vm.address(in.imm, 1)
+			vm.push(uint64(int8(vm.memory[a])))
+		case opI64Load16S:
+			a := vm.address(in.imm, 2)
+			vm.push(uint64(int16(binary.LittleEndian.Uint16(vm.memory[a:]))))
+		case opI64Load32S:
+			a := vm.address(in.imm, 4)
+			vm.push(uint64(int32(binary.LittleEndian.Uint32(vm.memory[a:]))))
+		case opI64Load32U:
+			a := vm.address(in.imm, 4)
+			vm.push(uint64(binary.LittleEndian.Uint32(vm.memory[a:])))
+		case opI32Store, opI64Store32:
+			v := vm.pop()
+			a := vm.address(in.imm, 4)
+			binary.LittleEndian.PutUint32(vm.memory[a:], uint32(v))
+		case opI64Store:
+			v := vm.pop()
+			a := vm.address(in.imm, 8)
+			binary.LittleEndian.PutUint64(vm.memory[a:], v)
+		case opI32Store8, opI64Store8:
+			v := vm.pop()
+			a := vm.address(in.imm, 1)
+			vm.memory[a] = byte(v)
+		case opI32Store16, opI64Store16:
+			v := vm.pop()
+			a := vm.address(in.imm, 2)
+			binary.LittleEndian.PutUint16(vm.memory[a:], uint16(v))
+		case opMemorySize:
+			vm.push(uint64(len(vm.memory) / PageSize))
+		case opMemoryGrow:
+			delta := uint64(uint32(vm.pop()))
+			pages := uint64(len(vm.memory) / PageSize)
+			if pages+delta > uint64(vm.maxPages) {
+				vm.push(uint64(uint32(0xffffffff)))
+				break
+			}
+			if !vm.useGas(delta * vm.cfg.PageGas) {
+				return ErrOutOfGas
+			}
+			vm.memory = append(vm.memory, make([]byte, delta*PageSize)...)
+			vm.push(pages)
+
+		case opI32Const, opI64Const:
+			vm.push(in.imm)
+
+		case opI32Eqz:
+			vm.push(b2u(uint32(vm.pop()) == 0))
+		case opI64Eqz:
+			vm.push(b2u(vm.pop() == 0))
+
+		default:
+			if in.op >= opI32Eq && in.op <= opI32GeU {
+				y, x := uint32(vm.pop()), uint32(vm.pop())
+				vm.push(b2u(compare32(in.op, x, y)))
+			} else if in.op >= opI64Eq && in.op <= opI64GeU {
+				y, x := vm.pop(), vm.pop()
+				vm.push(b2u(compare64(in.op, x, y)))
+			} else if in.op >= opI32Clz && in.op <= opI32Popcnt {
+				x := uint32(vm.pop())
+				vm.push(uint64(unary32(in.op, x)))
+			} else if in.op >= opI64Clz && in.op <= opI64Popcnt {
+				vm.push(unary64(in.op, vm.pop()))
+			} else if in.op >= opI32Add && in.op <= opI32Rotr {
+				y, x := uint32(vm.pop()), uint32(vm.pop())
+				vm.push(uint64(binary32(in.op, x, y)))
+			} else if in.op >= opI64Add && in.op <= opI64Rotr {
+				y, x := vm.pop(), vm.pop()
+				vm.push(binary64(in.op, x, y))
+			} else {
+				switch in.op {
+				case opI32WrapI64:
+					vm.push(uint64(uint32(vm.pop())))
+				case opI64ExtendI32S:
+					vm.push(uint64(int64(int32(uint32(vm.pop())))))
+				case opI64ExtendI32U:
+					vm.push(uint64(uint32(vm.pop())))
+				default:
+					return fmt.Errorf("wasm: unsupported instruction 0x%x", in.op)
+				}
+			}
+		}
+	}
+	// Leave exactly the results of the function on the stack.
+	vm.unwind(base, results)
+	return nil
+}
+
+func compare32(op byte, x, y uint32) bool {
+	switch op {
+	case opI32Eq:
+		return x == y
+	case opI32Ne:
+		return x != y
+	case opI32LtS:
+		return int32(x) < int32(y)
+	case opI32LtU:
+		return x < y
+	case opI32GtS:
+		return int32(x) > int32(y)
+	case opI32GtU:
+		return x > y
+	case opI32LeS:
+		return int32(x) <= int32(y)
+	case opI32LeU:
+		return x <= y
+	case opI32GeS:
+		return int32(x) >= int32(y)
+	default: // opI32GeU
+		return x >= y
+	}
+}
+
+func compare64(op byte, x, y uint64) bool {
+	switch op {
+	case opI64Eq:
+		return x == y
+	case opI64Ne:
+		return x != y
+	case opI64LtS:
+		return int64(x) < int64(y)
+	case opI64LtU:
+		return x < y
+	case opI64GtS:
+		return int64(x) > int64(y)
+	case opI64GtU:
+		return x > y
+	case opI64LeS:
+		return int64(x) <= int64(y)
+	case opI64LeU:
+		return x <= y
+	case opI64GeS:
+		return int64(x) >= int64(y)
+	default: // opI64GeU
+		return x >= y
+	}
+}
+
+func unary32(op byte, x uint32) uint32 {
+	switch op {
+	case opI32Clz:
+		return uint32(bits.LeadingZeros32(x))
+	case opI32Ctz:
+		return uint32(bits.TrailingZeros32(x))
+	default: // opI32Popcnt
+		return uint32(bits.OnesCount32(x))
+	}
+}
+
+func unary64(op byte, x uint64) uint64 {
+	switch op {
+	case opI64Clz:
+		return uint64(bits.LeadingZeros64(x))
+	case opI64Ctz:
+		return uint64(bits.TrailingZeros64(x))
+	default: // opI64Popcnt
+		return uint64(bits.OnesCount64(x))
+	}
+}
+
+func binary32(op byte, x, y uint32) uint32 {
+	switch op {
+	case opI32Add:
+		return x + y
+	case opI32Sub:
+		return x - y
+	case opI32Mul:
+		return x * y
+	case opI32DivS:
+		if y == 0 {
+			panic(errDivideByZero)
+		}
+		if int32(x) == -1<<31 && int32(y) == -1 {
+			panic(errIntegerOverflow)
+		}
+		return uint32(int32(x) / int32(y))
+	case opI32DivU:
+		if y == 0 {
+			panic(errDivideByZero)
+		}
+		return x / y
+	case opI32RemS:
+		if y == 0 {
+			panic(errDivideByZero)
+		}
+		if int32(y) == -1 {
+			return 0
+		}
+		return uint32(int32(x) % int32(y))
+	case opI32RemU:
+		if y == 0 {
+			panic(errDivideByZero)
+		}
+		return x % y
+	case opI32And:
+		return x & y
+	case opI32Or:
+		return x | y
+	case opI32Xor:
+		return x ^ y
+	case opI32Shl:
+		return x << (y & 31)
+	case opI32ShrS:
+		return uint32(int32(x) >> (y & 31))
+	case opI32ShrU:
+		return x >> (y & 31)
+	case opI32Rotl:
+		return bits.RotateLeft32(x, int(y&31))
+	default: // opI32Rotr
+		return bits.RotateLeft32(x, -int(y&31))
+	}
+}
+
+func binary64(op byte, x, y uint64) uint64 {
+	switch op {
+	case opI64Add:
+		return x + y
+	case opI64Sub:
+		return x - y
+	case opI64Mul:
+		return x * y
+	case opI64DivS:
+		if y == 0 {
+			panic(errDivideByZero)
+		}
+		if int64(x) == -1<<63 && int64(y) == -1 {
+			panic(errIntegerOverflow)
+		}
+		return uint64(int64(x) / int64(y))
+	case opI64DivU:
+		if y == 0 {
+			panic(errDivideByZero)
+		}
+		return x / y
+	case opI64RemS:
+		if y == 0 {
+			panic(errDivideByZero)
+		}
+		if int64(y) == -1 {
+			return 0
+		}
+		return uint64(int64(x) % int64(y))
+	case opI64RemU:
+		if y == 0 {
+			panic(errDivideByZero)
+		}
+		return x % y
+	case opI64And:
+		return x & y
+	case opI64Or:
+		return x | y
+	case opI64Xor:
+		return x ^ y
+	case opI64Shl:
+		return x << (y & 63)
+	case opI64ShrS:
+		return uint64(int64(x) >> (y & 63))
+	case opI64ShrU:
+		return x >> (y & 63)
+	case opI64Rotl:
+		return bits.RotateLeft64(x, int(y&63))
+	default: // opI64Rotr
+		return bits.RotateLeft64(x, -int(y&63))
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Helpers to assemble binary modules.
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func cat(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+func vec(items ...[]byte) []byte { return cat(uleb(uint64(len(items))), cat(items...)) }
+
+func name(s string) []byte { return cat(uleb(uint64(len(s))), []byte(s)) }
+
+func section(id byte, items ...[]byte) []byte {
+	payload := vec(items...)
+	return cat([]byte{id}, uleb(uint64(len(payload))), payload)
+}
+
+func functype(params, results []ValueType) []byte {
+	return cat([]byte{0x60}, vec(types(params)...), vec(types(results)...))
+}
+
+func types(ts []ValueType) [][]byte {
+	out := make([][]byte, len(ts))
+	for i, t := range ts {
+		out[i] = []byte{byte(t)}
+	}
+	return out
+}
+
+// body encodes a function body with the given locals (one group per local).
+func body(locals []ValueType, code ...byte) []byte {
+	groups := make([][]byte, len(locals))
+	for i, t := range locals {
+		groups[i] = []byte{1, byte(t)}
+	}
+	b := cat(vec(groups...), code)
+	return cat(uleb(uint64(len(b))), b)
+}
+
+func export(n string, kind byte, index uint32) []byte {
+	return cat(name(n), []byte{kind}, uleb(uint64(index)))
+}
+
+func assemble(sections ...[]byte) []byte {
+	return cat(Magic, []byte{Version, 0, 0, 0}, cat(sections...))
+}
+
+// singleFunc assembles a module exporting a single function named "f", with a
+// memory of one page.
+func singleFunc(t FuncType, locals []ValueType, code ...byte) []byte {
+	return assemble(
+		section(1, functype(t.Params, t.Results)),
+		section(3, []byte{0}),
+		section(5, []byte{0x00, 1}),
+		section(7, export("f", ExportFunction, 0)),
+		section(10, body(locals, code...)),
+	)
+}
+
+func instantiate(t *testing.T, code []byte, resolve Resolver, cfg Config) *VM {
+	m, err := Decode(code)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	vm, err := Instantiate(m, resolve, cfg)
+	if err != nil {
+		t.Fatalf("instantiate failed: %v", err)
+	}
+	return vm
+}
+
+func noImports(module, name string) (*HostFunction, error) {
+	return nil, nil
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		code []byte
+		x, y uint64
+		want uint64
+	}{
+		{[]byte{opI32Add}, 2, 3, 5},
+		{[]byte{opI32Add}, 0xffffffff, 1, 0},
+		{[]byte{opI32Sub}, 0, 1, 0xffffffff},
+		{[]byte{opI32DivS}, 0xfffffff6, 3, 0xfffffffd}, // -10 / 3 = -3
+		{[]byte{opI32RemS}, 0xfffffff6, 3, 0xffffffff}, // -10 % 3 = -1
+		{[]byte{opI32ShrS}, 0x80000000, 33, 0xc0000000},
+		{[]byte{opI32Rotl}, 0x80000001, 1, 3},
+		{[]byte{opI32LtS}, 0xffffffff, 0, 1},
+		{[]byte{opI32LtU}, 0xffffffff, 0, 0},
+		{[]byte{opI32Clz, opI32Add}, 1, 0x00ff0000, 9},
+	}
+	sig := FuncType{Params: []ValueType{I32, I32}, Results: []ValueType{I32}}
+	for i, tt := range tests {
+		code := cat([]byte{opLocalGet, 0, opLocalGet, 1}, tt.code, []byte{opEnd})
+		vm := instantiate(t, singleFunc(sig, nil, code...), noImports, Config{})
+		res, err := vm.Invoke("f", tt.x, tt.y)
+		if err != nil {
+			t.Errorf("test %d: invoke failed: %v", i, err)
+			continue
+		}
+		if len(res) != 1 || res[0] != tt.want {
+			t.Errorf("test %d: result mismatch: have %x, want %x", i, res, tt.want)
+		}
+	}
+}
+
+// factorial computes n! iteratively with a loop.
+var factorial = singleFunc(
+	FuncType{Params: []ValueType{I64}, Results: []ValueType{I64}},
+	[]ValueType{I64},
+	opI64Const, 1, opLocalSet, 1,
+	opBlock, 0x40,
+	opLoop, 0x40,
+	opLocalGet, 0, opI64Eqz, opBrIf, 1,
+	opLocalGet, 1, opLocalGet, 0, opI64Mul, opLocalSet, 1,
+	opLocalGet, 0, opI64Const, 1, opI64Sub, opLocalSet, 0,
+	opBr, 0,
+	opEnd,
+	opEnd,
+	opLocalGet, 1,
+	opEnd,
+)
+
+func TestLoop(t *testing.T) {
+	var used uint64
+	vm := instantiate(t, factorial, noImports, Config{UseGas: func(amount uint64) bool {
+		used += amount
+		return true
+	}})
+	gas := make(map[uint64]uint64)
+	for _, n := range []uint64{0, 10, 20} {
+		used = 0
+		res, err := vm.Invoke("f", n)
+		if err != nil {
+			t.Fatalf("f(%d): invoke failed: %v", n, err)
+		}
+		if n == 10 && res[0] != 3628800 {
+			t.Errorf("f(10): result mismatch: have %d, want %d", res[0], 3628800)
+		}
+		gas[n] = used
+	}
+	// Every iteration executes the same basic blocks, so must cost the same.
+	if gas[10] <= gas[0] || gas[20]-gas[10] != gas[10]-gas[0] {
+		t.Errorf("gas not metered per iteration: %v", gas)
+	}
+}
+
+func TestOutOfGas(t *testing.T) {
+	gas := uint64(100)
+	vm := instantiate(t, factorial, noImports, Config{UseGas: func(amount uint64) bool {
+		if amount > gas {
+			return false
+		}
+		gas -= amount
+		return true
+	}})
+	if _, err := vm.Invoke("f", 1000); err != ErrOutOfGas {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrOutOfGas)
+	}
+}
+
+func TestIfElse(t *testing.T) {
+	code := singleFunc(
+		FuncType{Params: []ValueType{I32}, Results: []ValueType{I32}}, nil,
+		opLocalGet, 0,
+		opIf, byte(I32), opI32Const, 10, opElse, opI32Const, 20, opEnd,
+		opEnd,
+	)
+	vm := instantiate(t, code, noImports, Config{})
+	for _, tt := range []struct{ in, want uint64 }{{1, 10}, {0, 20}} {
+		if res, err := vm.Invoke("f", tt.in); err != nil || res[0] != tt.want {
+			t.Errorf("f(%d): have %v, %v; want %d", tt.in, res, err, tt.want)
+		}
+	}
+}
+
+func TestBrTable(t *testing.T) {
+	// Returns 100 for 0, 200 for 1 and 300 for anything else.
+	code := singleFunc(
+		FuncType{Params: []ValueType{I32}, Results: []ValueType{I32}}, nil,
+		opBlock, 0x40,
+		opBlock, 0x40,
+		opBlock, 0x40,
+		opLocalGet, 0, opBrTable, 2, 0, 1, 2,
+		opEnd,
+		opI32Const, 0xe4, 0x00, opReturn,
+		opEnd,
+		opI32Const, 0xc8, 0x01, opReturn,
+		opEnd,
+		opI32Const, 0xac, 0x02,
+		opEnd,
+	)
+	vm := instantiate(t, code, noImports, Config{})
+	for _, tt := range []struct{ in, want uint64 }{{0, 100}, {1, 200}, {2, 300}, {7, 300}} {
+		if res, err := vm.Invoke("f", tt.in); err != nil || res[0] != tt.want {
+			t.Errorf("f(%d): have %v, %v; want %d", tt.in, res, err, tt.want)
+		}
+	}
+}
+
+func TestMemory(t *testing.T) {
+	code := assemble(
+		section(1, functype([]ValueType{I32}, []ValueType{I32}), functype(nil, []ValueType{I32})),
+		section(3, []byte{0}, []byte{1}),
+		section(5, []byte{0x01, 1, 2}),
+		section(7, export("load", ExportFunction, 0), export("grow", ExportFunction, 1), export("memory", ExportMemory, 0)),
+		section(10,
+			body(nil, opLocalGet, 0, opI32Load8U, 0, 0, opEnd),
+			body(nil, opI32Const, 1, opMemoryGrow, 0, opEnd),
+		),
+		section(11, cat([]byte{0, opI32Const, 8, opEnd}, name("hello"))),
+	)
+	vm := instantiate(t, code, noImports, Config{})
+	if res, err := vm.Invoke("load", 9); err != nil || res[0] != 'e' {
+		t.Errorf("load mismatch: have %v, %v; want %d", res, err, 'e')
+	}
+	if _, err := vm.Invoke("load", PageSize); err != errMemoryAccess {
+		t.Errorf("out of bounds load: have %v, want %v", err, errMemoryAccess)
+	}
+	if res, err := vm.Invoke("grow"); err != nil || res[0] != 1 {
+		t.Errorf("first grow: have %v, %v; want 1", res, err)
+	}
+	if res, err := vm.Invoke("grow"); err != nil || res[0] != 0xffffffff {
+		t.Errorf("grow beyond maximum: have %v, %v; want -1", res, err)
+	}
+	if len(vm.Memory()) != 2*PageSize {
+		t.Errorf("memory size mismatch: have %d, want %d", len(vm.Memory()), 2*PageSize)
+	}
+}
+
+func TestHostFunction(t *testing.T) {
+	sig := FuncType{Params: []ValueType{I32}, Results: []ValueType{I32}}
+	code := assemble(
+		section(1, functype(sig.Params, sig.Results)),
+		section(2, cat(name("env"), name("double"), []byte{ExportFunction, 0})),
+		section(3, []byte{0}),
+		section(7, export("f", ExportFunction, 1)),
+		section(10, body(nil, opLocalGet, 0, opCall, 0, opI32Const, 1, opI32Add, opEnd)),
+	)
+	resolve := func(module, field string) (*HostFunction, error) {
+		return &HostFunction{Type: sig, Call: func(vm *VM, args []uint64) (uint64, error) {
+			return args[0] * 2, nil
+		}}, nil
+	}
+	vm := instantiate(t, code, resolve, Config{})
+	if res, err := vm.Invoke("f", 20); err != nil || res[0] != 41 {
+		t.Errorf("result mismatch: have %v, %v; want 41", res, err)
+	}
+}
+
+func TestTraps(t *testing.T) {
+	sig := FuncType{Params: []ValueType{I32, I32}, Results: []ValueType{I32}}
+	tests := []struct {
+		code []byte
+		want error
+	}{
+		{[]byte{opLocalGet, 0, opLocalGet, 1, opI32DivU, opEnd}, errDivideByZero},
+		{[]byte{opI32Const, 0x80, 0x80, 0x80, 0x80, 0x78, opI32Const, 0x7f, opI32DivS, opEnd}, errIntegerOverflow},
+		{[]byte{opUnreachable, opEnd}, errUnreachable},
+		{[]byte{opI32Add, opEnd}, errStackUnderflow},
+		{[]byte{opLocalGet, 0, opLocalGet, 1, opCall, 0, opEnd}, errCallStackExhausted},
+	}
+	for i, tt := range tests {
+		vm := instantiate(t, singleFunc(sig, nil, tt.code...), noImports, Config{})
+		if _, err := vm.Invoke("f", 1, 0); err != tt.want {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"bad magic":   []byte("\x00asn\x01\x00\x00\x00"),
+		"bad version": []byte("\x00asm\x02\x00\x00\x00"),
+		"float types": assemble(section(1, functype([]ValueType{0x7d}, nil))),
+		"float ops": singleFunc(FuncType{}, nil,
+			0x43, 0, 0, 0, 0, opDrop, opEnd),
+		"bad branch": singleFunc(FuncType{}, nil, opBr, 1, opEnd),
+		"unterminated": singleFunc(FuncType{}, nil,
+			opBlock, 0x40, opEnd),
+		"truncated": assemble(section(1, functype(nil, nil)))[:12],
+		"oversized function count": assemble(
+			section(1, functype(nil, nil)),
+			cat([]byte{3, 5}, uleb(0xffffffff)),
+		),
+		"oversized value count": assemble(
+			cat([]byte{1, 5}, []byte{1, 0x60}, uleb(maxLocals), []byte{0}),
+		),
+	}
+	for name, code := range tests {
+		if _, err := Decode(code); err == nil {
+			t.Errorf("%s: expected decode error", name)
+		}
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"encoding/hex"
 	"testing"
+
+	"github.com/bazacoin/go-bazacoin/common"
 )
 
 func TestRecoverSanity(t *testing.T) {
@@ -34,3 +36,62 @@ func TestRecoverSanity(t *testing.T) {
 		t.Errorf("pubkey mismatch: want: %x have: %x", pubkey1, pubkey2)
 	}
 }
+
+func TestVerifySignature(t *testing.T) {
+	msg, _ := hex.DecodeString("ce0677bb30baa8cf067c88db9811f4333d131bf8bcf12fe7065d211dce971008")
+	sig, _ := hex.DecodeString("90f27b8b488db00b00606796d2987f6a5f59ae62ea05effe84fef5b8b0e549984a691139ad57a3f0b906637673aa2f63d1f55cb1a69199d4009eea23ceaddc93")
+	pubkey, _ := hex.DecodeString("04e32df42865e97135acfb65f3bae71bdc86f4d49150ad6a440b6f15878109880a0a2b2667f7e725ceea70c673093bf67663e0312623c8e091b13cf2c0f11ef652")
+	pubkeyc, _ := hex.DecodeString("02e32df42865e97135acfb65f3bae71bdc86f4d49150ad6a440b6f15878109880a")
+
+	if !VerifySignature(pubkey, msg, sig) {
+		t.Errorf("can't verify signature with uncompressed key")
+	}
+	if !VerifySignature(pubkeyc, msg, sig) {
+		t.Errorf("can't verify signature with compressed key")
+	}
+	if VerifySignature(nil, msg, sig) {
+		t.Errorf("signature valid with no key")
+	}
+	if VerifySignature(pubkey, nil, sig) {
+		t.Errorf("signature valid with no message")
+	}
+	if VerifySignature(pubkey, msg, nil) {
+		t.Errorf("nil signature valid")
+	}
+	if VerifySignature(pubkey, msg, append(common.CopyBytes(sig), 1, 2, 3)) {
+		t.Errorf("signature valid with extra bytes at the end")
+	}
+	if VerifySignature(pubkey, msg, sig[:len(sig)-2]) {
+		t.Errorf("signature valid even though it's incomplete")
+	}
+	wrongkey := common.CopyBytes(pubkey)
+	wrongkey[10]++
+	if VerifySignature(wrongkey, msg, sig) {
+		t.Errorf("signature valid with with wrong public key")
+	}
+}
+
+func TestCompressPubkey(t *testing.T) {
+	pubkey, _ := hex.DecodeString("04e32df42865e97135acfb65f3bae71bdc86f4d49150ad6a440b6f15878109880a0a2b2667f7e725ceea70c673093bf67663e0312623c8e091b13cf2c0f11ef652")
+	pubkeyc, _ := hex.DecodeString("02e32df42865e97135acfb65f3bae71bdc86f4d49150ad6a440b6f15878109880a")
+
+	key, err := DecompressPubkey(pubkeyc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uncompressed := FromECDSAPub(key); !bytes.Equal(uncompressed, pubkey) {
+		t.Errorf("wrong public key result: got %x, want %x", uncompressed, pubkey)
+	}
+	if compressed := CompressPubkey(key); !bytes.Equal(compressed, pubkeyc) {
+		t.Errorf("wrong public key result: got %x, want %x", compressed, pubkeyc)
+	}
+	if _, err := DecompressPubkey(nil); err == nil {
+		t.Errorf("no error for nil pubkey")
+	}
+	if _, err := DecompressPubkey(pubkeyc[:5]); err == nil {
+		t.Errorf("no error for incomplete pubkey")
+	}
+	if _, err := DecompressPubkey(append(common.CopyBytes(pubkeyc), 1, 2, 3)); err == nil {
+		t.Errorf("no error for pubkey with extra bytes at the end")
+	}
+}
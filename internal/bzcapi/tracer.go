@@ -212,12 +212,19 @@ type JavascriptTracer struct {
 	dbvalue       otto.Value             // JS view of `db`
 	contract      *contractWrapper       // Wrapper around the contract object
 	contractvalue otto.Value             // JS view of `contract`
+	ctx           map[string]interface{} // Transaction context gathered throughout execution
 	err           error                  // Error, if one has occurred
+
+	hasFault bool // Whether the user-supplied object exposes a fault function
+	hasEnter bool // Whether the user-supplied object exposes an enter function
+	hasExit  bool // Whether the user-supplied object exposes an exit function
 }
 
 // NewJavascriptTracer instantiates a new JavascriptTracer instance.
 // code specifies a Javascript snippet, which must evaluate to an expression
-// returning an object with 'step' and 'result' functions.
+// returning an object with 'step' and 'result' functions. The object may
+// optionally expose 'fault', 'enter' and 'exit' functions to be notified of
+// failing steps and of nested call frames being entered and returned from.
 func NewJavascriptTracer(code string) (*JavascriptTracer, error) {
 	vm := otto.New()
 	vm.Interrupt = make(chan func(), 1)
@@ -247,6 +254,11 @@ func NewJavascriptTracer(code string) (*JavascriptTracer, error) {
 	if !result.IsFunction() {
 		return nil, fmt.Errorf("Trace object must expose a function result()")
 	}
+	// Check which of the optional functions exist
+	hasFunction := func(name string) bool {
+		fn, err := jstracer.Get(name)
+		return err == nil && fn.IsFunction()
+	}
 
 	// Create the persistent log object
 	log := make(map[string]interface{})
@@ -271,7 +283,11 @@ func NewJavascriptTracer(code string) (*JavascriptTracer, error) {
 		dbvalue:       db.toValue(vm),
 		contract:      contract,
 		contractvalue: contract.toValue(vm),
+		ctx:           make(map[string]interface{}),
 		err:           nil,
+		hasFault:      hasFunction("fault"),
+		hasEnter:      hasFunction("enter"),
+		hasExit:       hasFunction("exit"),
 	}, nil
 }
 
@@ -316,8 +332,41 @@ func wrapError(context string, err error) error {
 	return fmt.Errorf("%v    in server-side tracer function '%v'", message, context)
 }
 
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (jst *JavascriptTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	jst.ctx["type"] = "CALL"
+	if create {
+		jst.ctx["type"] = "CREATE"
+	}
+	jst.ctx["from"] = from
+	jst.ctx["to"] = to
+	jst.ctx["input"] = input
+	jst.ctx["gas"] = gas
+	jst.ctx["value"] = value
+	return nil
+}
+
 // CaptureState implements the Tracer interface to trace a single step of VM execution
 func (jst *JavascriptTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	jst.callStep("step", env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode. If the tracer doesn't expose a fault function, the
+// failing step is reported through step instead.
+func (jst *JavascriptTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	method := "step"
+	if jst.hasFault {
+		method = "fault"
+	}
+	jst.callStep(method, env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	return nil
+}
+
+// callStep fills the reusable log object with the current VM state and passes
+// it to the given method of the user-supplied object.
+func (jst *JavascriptTracer) callStep(method string, env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
 	if jst.err == nil {
 		jst.memory.memory = memory
 		jst.stack.stack = stack
@@ -337,17 +386,58 @@ func (jst *JavascriptTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode,
 		jst.log["account"] = contract.Address()
 		jst.log["err"] = err
 
-		_, err := jst.callSafely("step", jst.logvalue, jst.dbvalue)
+		_, err := jst.callSafely(method, jst.logvalue, jst.dbvalue)
+		if err != nil {
+			jst.err = wrapError(method, err)
+		}
+	}
+}
+
+// CaptureEnter is called when the EVM enters a new call frame, either through
+// a call, a contract creation or a value transfer.
+func (jst *JavascriptTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	if jst.err == nil && jst.hasEnter {
+		frame := map[string]interface{}{
+			"type":  typ.String(),
+			"from":  from,
+			"to":    to,
+			"input": input,
+			"gas":   gas,
+			"value": value,
+		}
+		if _, err := jst.callSafely("enter", frame); err != nil {
+			jst.err = wrapError("enter", err)
+		}
+	}
+	return nil
+}
+
+// CaptureExit is called when the EVM returns from a call frame entered through
+// CaptureEnter.
+func (jst *JavascriptTracer) CaptureExit(output []byte, gasUsed uint64, err error) error {
+	if jst.err == nil && jst.hasExit {
+		result := map[string]interface{}{
+			"output":  output,
+			"gasUsed": gasUsed,
+		}
 		if err != nil {
-			jst.err = wrapError("step", err)
+			result["error"] = err.Error()
+		}
+		if _, err := jst.callSafely("exit", result); err != nil {
+			jst.err = wrapError("exit", err)
 		}
 	}
 	return nil
 }
 
 // CaptureEnd is called after the call finishes
-func (jst *JavascriptTracer) CaptureEnd(output []byte, gasUsed uint64, t time.Duration) error {
-	//TODO! @Arachnid please figure out of there's anything we can use this method for
+func (jst *JavascriptTracer) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	jst.ctx["output"] = output
+	jst.ctx["gasUsed"] = gasUsed
+	jst.ctx["time"] = t.String()
+	if err != nil {
+		jst.ctx["error"] = err.Error()
+	}
 	return nil
 }
 
@@ -357,7 +447,7 @@ func (jst *JavascriptTracer) GetResult() (result interface{}, err error) {
 		return nil, jst.err
 	}
 
-	result, err = jst.callSafely("result")
+	result, err = jst.callSafely("result", jst.ctx, jst.dbvalue)
 	if err != nil {
 		err = wrapError("result", err)
 	}
@@ -145,3 +145,44 @@ func TestHaltBetweenSteps(t *testing.T) {
 		t.Errorf("Expected timeout error, got %v", err)
 	}
 }
+
+func TestCallFrames(t *testing.T) {
+	tracer, err := NewJavascriptTracer(`{frames: [], step: function() {}, enter: function(frame) { this.frames.push(frame.type + " " + frame.gas); }, exit: function(res) { this.frames.push("exit " + res.gasUsed); }, result: function(ctx) { this.frames.push(ctx.type + " " + ctx.gasUsed); return this.frames; }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer.CaptureStart(common.Address{}, common.Address{}, false, nil, 1000, big.NewInt(0))
+	tracer.CaptureEnter(vm.DELEGATECALL, common.Address{}, common.Address{}, nil, 500, nil)
+	tracer.CaptureExit(nil, 100, nil)
+	tracer.CaptureEnd(nil, 300, 0, nil)
+
+	ret, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"DELEGATECALL 500", "exit 100", "CALL 300"}
+	if !reflect.DeepEqual(ret, expected) {
+		t.Errorf("Expected return value to be %#v, got %#v", expected, ret)
+	}
+}
+
+func TestFault(t *testing.T) {
+	tracer, err := NewJavascriptTracer("{faults: 0, step: function() {}, fault: function(log) { this.faults += 1; }, result: function() { return this.faults; }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := vm.NewEVM(vm.Context{}, nil, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	contract := vm.NewContract(account{}, account{}, big.NewInt(0), 10000)
+	contract.Code = []byte{byte(vm.PUSH1), 0x1, byte(vm.ADD)}
+
+	if _, err := env.Interpreter().Run(0, contract, []byte{}); err == nil {
+		t.Fatal("expected stack underflow")
+	}
+	ret, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret != float64(1) {
+		t.Errorf("Expected 1 fault, got %v", ret)
+	}
+}
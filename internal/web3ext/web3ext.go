@@ -127,6 +127,16 @@ web3._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'addTrustedPeer',
+			call: 'admin_addTrustedPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeTrustedPeer',
+			call: 'admin_removeTrustedPeer',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -366,6 +376,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'signTypedData',
+			call: 'bzc_signTypedData',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'resend',
 			call: 'bzc_resend',
@@ -487,11 +503,23 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'signTypedData',
+			call: 'personal_signTypedData',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'ecRecover',
 			call: 'personal_ecRecover',
 			params: 2
 		}),
+		new web3._extend.Method({
+			name: 'openWallet',
+			call: 'personal_openWallet',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
 		new web3._extend.Method({
 			name: 'deriveAccount',
 			call: 'personal_deriveAccount',
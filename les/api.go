@@ -0,0 +1,56 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	"github.com/bazacoin/go-bazacoin/params"
+)
+
+var errNoCheckpoint = errors.New("no local checkpoint available")
+
+// PrivateLightServerAPI provides an API to access the light server specific
+// state, such as the trusted checkpoints generated by the server.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new light server API.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server: server}
+}
+
+// LatestCheckpoint returns the checkpoint of the most recently generated
+// section, which can be configured on light clients or voted on in the oracle.
+func (api *PrivateLightServerAPI) LatestCheckpoint() (*params.TrustedCheckpoint, error) {
+	cp := latestCheckpoint(api.server.protocolManager.chainDb)
+	if cp == nil {
+		return nil, errNoCheckpoint
+	}
+	return cp, nil
+}
+
+// GetCheckpoint returns the checkpoint of the given section.
+func (api *PrivateLightServerAPI) GetCheckpoint(index hexutil.Uint64) (*params.TrustedCheckpoint, error) {
+	cp := getCheckpoint(api.server.protocolManager.chainDb, uint64(index))
+	if cp == nil {
+		return nil, errNoCheckpoint
+	}
+	return cp, nil
+}
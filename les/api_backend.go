@@ -86,6 +86,17 @@ func (b *LesApiBackend) GetReceipts(ctx context.Context, blockHash common.Hash)
 	return light.GetBlockReceipts(ctx, b.bzc.odr, blockHash, core.GetBlockNumber(b.bzc.chainDb, blockHash))
 }
 
+// BloomStatus returns the bloom bits section size and the number of sections
+// retrievable through the trusted bloom trie.
+func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
+	return b.bzc.blockchain.BloomStatus()
+}
+
+// GetBloomBits retrieves the bloom bits of the given bit in the given sections.
+func (b *LesApiBackend) GetBloomBits(ctx context.Context, bit uint, sections []uint64) ([][]byte, error) {
+	return b.bzc.blockchain.GetBloomBits(ctx, bit, sections)
+}
+
 func (b *LesApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.bzc.blockchain.GetTdByHash(blockHash)
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/common/hexutil"
 	"github.com/bazacoin/go-bazacoin/consensus"
+	"github.com/bazacoin/go-bazacoin/contracts/checkpointoracle"
 	"github.com/bazacoin/go-bazacoin/core"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/bzc"
@@ -69,6 +70,7 @@ type LightBazacoin struct {
 
 	networkId     uint64
 	netRPCService *bzcapi.PublicNetAPI
+	oracle        *checkpointoracle.Oracle // Checkpoint oracle, nil if not configured
 
 	quitSync chan struct{}
 	wg       sync.WaitGroup
@@ -107,6 +109,10 @@ func New(ctx *node.ServiceContext, config *bzc.Config) (*LightBazacoin, error) {
 	if bzc.blockchain, err = light.NewLightChain(bzc.odr, bzc.chainConfig, bzc.engine, bzc.eventMux); err != nil {
 		return nil, err
 	}
+	// Apply any explicitly configured checkpoint on top of the built-in ones
+	if config.Checkpoint != nil {
+		bzc.blockchain.AddTrustedCheckpoint(config.Checkpoint)
+	}
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -124,6 +130,12 @@ func New(ctx *node.ServiceContext, config *bzc.Config) (*LightBazacoin, error) {
 		gpoParams.Default = config.GasPrice
 	}
 	bzc.ApiBackend.gpo = gasprice.NewOracle(bzc.ApiBackend, gpoParams)
+
+	if config.CheckpointOracle != nil {
+		if bzc.oracle, err = newCheckpointOracle(config.CheckpointOracle, bzc.ApiBackend); err != nil {
+			return nil, err
+		}
+	}
 	return bzc, nil
 }
 
@@ -205,6 +217,10 @@ func (s *LightBazacoin) Start(srvr *p2p.Server) error {
 	s.netRPCService = bzcapi.NewPublicNetAPI(srvr, s.networkId)
 	s.serverPool.start(srvr, lesTopic(s.blockchain.Genesis().Hash()))
 	s.protocolManager.Start()
+	if s.oracle != nil {
+		s.wg.Add(1)
+		go s.checkpointLoop(s.oracle)
+	}
 	return nil
 }
 
@@ -0,0 +1,67 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/bzc"
+	"github.com/bazacoin/go-bazacoin/contracts/checkpointoracle"
+	"github.com/bazacoin/go-bazacoin/internal/bzcapi"
+	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/params"
+)
+
+const (
+	checkpointRecheckInterval = 30 * time.Minute // Time between two checkpoint oracle queries
+	checkpointQueryTimeout    = time.Minute      // Maximum time allowed for a single oracle query
+)
+
+// newCheckpointOracle binds the configured checkpoint oracle contract, accessing
+// the chain through the light client's own API backend.
+func newCheckpointOracle(config *params.CheckpointOracleConfig, backend bzcapi.Backend) (*checkpointoracle.Oracle, error) {
+	return checkpointoracle.NewOracle(config, bzc.NewContractBackend(backend, true))
+}
+
+// checkpointLoop periodically queries the checkpoint oracle contract and adds
+// any newer, sufficiently approved checkpoint to the light chain.
+func (s *LightBazacoin) checkpointLoop(oracle *checkpointoracle.Oracle) {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			ctx, cancel := context.WithTimeout(context.Background(), checkpointQueryTimeout)
+			cp, err := oracle.LatestCheckpoint(ctx)
+			cancel()
+
+			if err != nil {
+				log.Debug("Failed to retrieve oracle checkpoint", "err", err)
+			} else {
+				s.blockchain.AddTrustedCheckpoint(cp)
+			}
+			timer.Reset(checkpointRecheckInterval)
+
+		case <-s.quitSync:
+			return
+		}
+	}
+}
@@ -56,6 +56,7 @@ const (
 	MaxCodeFetch         = 64  // Amount of contract codes to allow fetching per request
 	MaxProofsFetch       = 64  // Amount of merkle proofs to be fetched per retrieval request
 	MaxHeaderProofsFetch = 64  // Amount of merkle proofs to be fetched per retrieval request
+	MaxBloomProofsFetch  = 64  // Amount of bloom trie proofs to be fetched per retrieval request
 	MaxTxSend            = 64  // Amount of transactions to be send per request
 
 	disableClientRemovePeer = false
@@ -322,7 +323,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 }
 
-var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsMsg, SendTxMsg, GetHeaderProofsMsg}
+var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsMsg, SendTxMsg, GetHeaderProofsMsg, GetBloomBitsProofsMsg}
 
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
@@ -789,6 +790,65 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			Obj:     resp.Data,
 		}
 
+	case GetBloomBitsProofsMsg:
+		p.Log().Trace("Received bloom bits proof request")
+		// Decode the retrieval message
+		var req struct {
+			ReqID uint64
+			Reqs  []BloomReq
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// Gather the proofs until the fetch or network limits is reached
+		var (
+			bytes  int
+			proofs [][]rlp.RawValue
+		)
+		reqCnt := len(req.Reqs)
+		if reject(uint64(reqCnt), MaxBloomProofsFetch) {
+			return errResp(ErrRequestRejected, "")
+		}
+		for _, req := range req.Reqs {
+			if bytes >= softResponseLimit {
+				break
+			}
+			// Answer every request in order, even unavailable ones with an empty proof
+			var proof []rlp.RawValue
+			if root := getBloomTrieRoot(pm.chainDb, req.BloomTrieNum); root != (common.Hash{}) {
+				if tr, _ := trie.New(root, pm.chainDb); tr != nil {
+					proof = tr.Prove(bloomTrieKey(uint(req.BitIdx), req.SectionIdx))
+				}
+			}
+			for _, node := range proof {
+				bytes += len(node)
+			}
+			proofs = append(proofs, proof)
+		}
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
+		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		return p.SendBloomBitsProofs(req.ReqID, bv, proofs)
+
+	case BloomBitsProofsMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received bloom bits proof response")
+		var resp struct {
+			ReqID, BV uint64
+			Data      [][]rlp.RawValue
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgBloomBitsProofs,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Data,
+		}
+
 	case SendTxMsg:
 		if pm.txpool == nil {
 			return errResp(ErrUnexpectedResponse, "")
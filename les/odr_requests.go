@@ -25,6 +25,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core"
+	"github.com/bazacoin/go-bazacoin/core/bloombits"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/bzcdb"
@@ -43,6 +44,7 @@ var (
 	errReceiptHashMismatch = errors.New("receipt hash mismatch")
 	errDataHashMismatch    = errors.New("data hash mismatch")
 	errCHTHashMismatch     = errors.New("cht hash mismatch")
+	errMissingProofs       = errors.New("missing bloom bits proofs")
 )
 
 type LesOdrRequest interface {
@@ -64,6 +66,8 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*CodeRequest)(r)
 	case *light.ChtRequest:
 		return (*ChtRequest)(r)
+	case *light.BloomRequest:
+		return (*BloomRequest)(r)
 	default:
 		return nil
 	}
@@ -303,6 +307,9 @@ func (r *ChtRequest) CanSend(peer *peer) bool {
 	peer.lock.RLock()
 	defer peer.lock.RUnlock()
 
+	if peer.headInfo.Number < light.ChtConfirmations {
+		return false
+	}
 	return r.ChtNum <= (peer.headInfo.Number-light.ChtConfirmations)/light.ChtFrequency
 }
 
@@ -354,3 +361,82 @@ func (r *ChtRequest) Validate(db bzcdb.Database, msg *Msg) error {
 
 	return nil
 }
+
+type BloomReq struct {
+	BloomTrieNum, BitIdx, SectionIdx uint64
+}
+
+// bloomTrieKey returns the bloom trie key of the bloom bits belonging to the
+// given bit index and section: the bit index (uint16 big endian) followed by
+// the section index (uint64 big endian).
+func bloomTrieKey(bitIdx uint, sectionIdx uint64) []byte {
+	var key [10]byte
+	binary.BigEndian.PutUint16(key[0:2], uint16(bitIdx))
+	binary.BigEndian.PutUint64(key[2:], sectionIdx)
+	return key[:]
+}
+
+// ODR request type for requesting bloom bits by bloom trie, see LesOdrRequest interface
+type BloomRequest light.BloomRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *BloomRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetBloomBitsProofsMsg, len(r.SectionIdxList))
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *BloomRequest) CanSend(peer *peer) bool {
+	peer.lock.RLock()
+	defer peer.lock.RUnlock()
+
+	if peer.version < lpv2 || peer.headInfo.Number < light.ChtConfirmations {
+		return false
+	}
+	return r.BloomTrieNum <= (peer.headInfo.Number-light.ChtConfirmations)/light.BloomTrieFrequency
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *BloomRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting bloom bits", "bloomTrie", r.BloomTrieNum, "bitIdx", r.BitIdx, "sections", r.SectionIdxList)
+	reqs := make([]*BloomReq, len(r.SectionIdxList))
+	for i, sectionIdx := range r.SectionIdxList {
+		reqs[i] = &BloomReq{
+			BloomTrieNum: r.BloomTrieNum,
+			BitIdx:       uint64(r.BitIdx),
+			SectionIdx:   sectionIdx,
+		}
+	}
+	return peer.RequestBloomBitsProofs(reqID, r.GetCost(peer), reqs)
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *BloomRequest) Validate(db bzcdb.Database, msg *Msg) error {
+	log.Debug("Validating bloom bits", "bloomTrie", r.BloomTrieNum, "bitIdx", r.BitIdx, "sections", r.SectionIdxList)
+
+	// Ensure we have a correct message with a proof for every section
+	if msg.MsgType != MsgBloomBitsProofs {
+		return errInvalidMessageType
+	}
+	proofs := msg.Obj.([][]rlp.RawValue)
+	if len(proofs) != len(r.SectionIdxList) {
+		return errMissingProofs
+	}
+	// Verify the proofs and the sanity of the proven bloom bits
+	bits := make([][]byte, len(proofs))
+	for i, sectionIdx := range r.SectionIdxList {
+		value, err := trie.VerifyProof(r.BloomTrieRoot, bloomTrieKey(r.BitIdx, sectionIdx), proofs[i])
+		if err != nil {
+			return err
+		}
+		if _, err := bloombits.DecompressBytes(value, int(light.BloomTrieFrequency/8)); err != nil {
+			return err
+		}
+		bits[i] = value
+	}
+	// Verifications passed, store and return
+	r.BloomBits = bits
+	return nil
+}
@@ -214,3 +214,25 @@ func testOdr(t *testing.T, protocol int, expFail uint64, fn odrTestFn) {
 	time.Sleep(time.Millisecond * 10) // ensure that all peerSetNotify callbacks are executed
 	test(5)
 }
+
+// Tests that trie proof requests are not sent to peers whose head is still
+// below the confirmation distance of the first section.
+func TestOdrTrieRequestCanSend(t *testing.T) {
+	tests := []struct {
+		head uint64
+		ok   bool
+	}{
+		{0, false},
+		{light.ChtConfirmations - 1, false},
+		{light.ChtConfirmations, true},
+	}
+	for i, tt := range tests {
+		p := &peer{version: lpv2, headInfo: &announceData{Number: tt.head}}
+		if ok := (&ChtRequest{ChtNum: 0}).CanSend(p); ok != tt.ok {
+			t.Errorf("test %d: CHT request sendability mismatch: have %v, want %v", i, ok, tt.ok)
+		}
+		if ok := (&BloomRequest{BloomTrieNum: 0}).CanSend(p); ok != tt.ok {
+			t.Errorf("test %d: bloom request sendability mismatch: have %v, want %v", i, ok, tt.ok)
+		}
+	}
+}
@@ -210,6 +210,11 @@ func (p *peer) SendHeaderProofs(reqID, bv uint64, proofs []ChtResp) error {
 	return sendResponse(p.rw, HeaderProofsMsg, reqID, bv, proofs)
 }
 
+// SendBloomBitsProofs sends a batch of bloom trie proofs, corresponding to the ones requested.
+func (p *peer) SendBloomBitsProofs(reqID, bv uint64, proofs [][]rlp.RawValue) error {
+	return sendResponse(p.rw, BloomBitsProofsMsg, reqID, bv, proofs)
+}
+
 // RequestHeadersByHash fetches a batch of blocks' headers corresponding to the
 // specified header query, based on the hash of an origin block.
 func (p *peer) RequestHeadersByHash(reqID, cost uint64, origin common.Hash, amount int, skip int, reverse bool) error {
@@ -256,6 +261,12 @@ func (p *peer) RequestHeaderProofs(reqID, cost uint64, reqs []*ChtReq) error {
 	return sendRequest(p.rw, GetHeaderProofsMsg, reqID, cost, reqs)
 }
 
+// RequestBloomBitsProofs fetches a batch of bloom trie merkle proofs from a remote node.
+func (p *peer) RequestBloomBitsProofs(reqID, cost uint64, reqs []*BloomReq) error {
+	p.Log().Debug("Fetching batch of bloom bits proofs", "count", len(reqs))
+	return sendRequest(p.rw, GetBloomBitsProofsMsg, reqID, cost, reqs)
+}
+
 func (p *peer) SendTxs(reqID, cost uint64, txs types.Transactions) error {
 	p.Log().Debug("Fetching batch of transactions", "count", len(txs))
 	return p2p.Send(p.rw, SendTxMsg, txs)
@@ -25,6 +25,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core"
+	"github.com/bazacoin/go-bazacoin/core/bloombits"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/bzc"
 	"github.com/bazacoin/go-bazacoin/bzcdb"
@@ -33,7 +34,9 @@ import (
 	"github.com/bazacoin/go-bazacoin/log"
 	"github.com/bazacoin/go-bazacoin/p2p"
 	"github.com/bazacoin/go-bazacoin/p2p/discv5"
+	"github.com/bazacoin/go-bazacoin/params"
 	"github.com/bazacoin/go-bazacoin/rlp"
+	"github.com/bazacoin/go-bazacoin/rpc"
 	"github.com/bazacoin/go-bazacoin/trie"
 )
 
@@ -71,6 +74,18 @@ func NewLesServer(bzc *bzc.Bazacoin, config *bzc.Config) (*LesServer, error) {
 	return srv, nil
 }
 
+// APIs returns the RPC services offered by the light server.
+func (s *LesServer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightServerAPI(s),
+			Public:    false,
+		},
+	}
+}
+
 func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.protocolManager.SubProtocols
 }
@@ -323,6 +338,7 @@ func (pm *ProtocolManager) blockLoop() {
 				go func() {
 					mu.Lock()
 					more := makeCht(pm.chainDb)
+					more = makeBloomTrie(pm.chainDb) || more
 					mu.Unlock()
 					if more {
 						time.Sleep(time.Millisecond * 10)
@@ -356,6 +372,34 @@ func storeChtRoot(db bzcdb.Database, num uint64, root common.Hash) {
 	db.Put(append(chtPrefix, encNumber[:]...), root[:])
 }
 
+// getCheckpoint assembles the trusted checkpoint of the given (zero based)
+// section from the locally generated CHT, returning nil if it is not available.
+func getCheckpoint(db bzcdb.Database, index uint64) *params.TrustedCheckpoint {
+	root := getChtRoot(db, index+1)
+	if root == (common.Hash{}) {
+		return nil
+	}
+	cp := &params.TrustedCheckpoint{SectionIndex: index, CHTRoot: root, BloomRoot: getBloomTrieRoot(db, index+1)}
+	if head := core.GetCanonicalHash(db, cp.HeadNumber()); head != (common.Hash{}) {
+		cp.SectionHead = head
+		return cp
+	}
+	return nil
+}
+
+// latestCheckpoint returns the checkpoint of the most recently generated CHT.
+func latestCheckpoint(db bzcdb.Database) *params.TrustedCheckpoint {
+	data, _ := db.Get(lastChtKey)
+	if len(data) != 8 {
+		return nil
+	}
+	count := binary.BigEndian.Uint64(data)
+	if count == 0 {
+		return nil
+	}
+	return getCheckpoint(db, count-1)
+}
+
 func makeCht(db bzcdb.Database) bool {
 	headHash := core.GetHeadBlockHash(db)
 	headNum := core.GetBlockNumber(db, headHash)
@@ -420,3 +464,98 @@ func makeCht(db bzcdb.Database) bool {
 
 	return newChtNum > lastChtNum
 }
+
+var (
+	lastBloomTrieKey = []byte("LastBloomTrieNumber") // bloomTrieNum (uint64 big endian)
+	bloomTriePrefix  = []byte("blt")                 // bloomTriePrefix + bloomTrieNum (uint64 big endian) -> trie root hash
+)
+
+func getBloomTrieRoot(db bzcdb.Database, num uint64) common.Hash {
+	var encNumber [8]byte
+	binary.BigEndian.PutUint64(encNumber[:], num)
+	data, _ := db.Get(append(bloomTriePrefix, encNumber[:]...))
+	return common.BytesToHash(data)
+}
+
+func storeBloomTrieRoot(db bzcdb.Database, num uint64, root common.Hash) {
+	var encNumber [8]byte
+	binary.BigEndian.PutUint64(encNumber[:], num)
+	db.Put(append(bloomTriePrefix, encNumber[:]...), root[:])
+}
+
+// makeBloomTrie rotates the header blooms of the next confirmed section into
+// bloom bit vectors, stores them and adds them to the bloom trie. It returns
+// whether there are more sections waiting to be processed.
+func makeBloomTrie(db bzcdb.Database) bool {
+	headHash := core.GetHeadBlockHash(db)
+	headNum := core.GetBlockNumber(db, headHash)
+
+	var newBloomTrieNum uint64
+	if headNum > light.ChtConfirmations {
+		newBloomTrieNum = (headNum - light.ChtConfirmations) / light.BloomTrieFrequency
+	}
+
+	var lastBloomTrieNum uint64
+	data, _ := db.Get(lastBloomTrieKey)
+	if len(data) == 8 {
+		lastBloomTrieNum = binary.BigEndian.Uint64(data[:])
+	}
+	if newBloomTrieNum <= lastBloomTrieNum {
+		return false
+	}
+
+	var t *trie.Trie
+	if lastBloomTrieNum > 0 {
+		var err error
+		t, err = trie.New(getBloomTrieRoot(db, lastBloomTrieNum), db)
+		if err != nil {
+			lastBloomTrieNum = 0
+		}
+	}
+	if lastBloomTrieNum == 0 {
+		t, _ = trie.New(common.Hash{}, db)
+	}
+
+	// Rotate the blooms of the section into per-bit vectors
+	section := lastBloomTrieNum
+	gen, err := bloombits.NewGenerator(uint(light.BloomTrieFrequency))
+	if err != nil {
+		log.Error("Failed to create bloom bits generator", "err", err)
+		return false
+	}
+	for i := uint64(0); i < light.BloomTrieFrequency; i++ {
+		num := section*light.BloomTrieFrequency + i
+		header := core.GetHeader(db, core.GetCanonicalHash(db, num), num)
+		if header == nil {
+			log.Error("Canonical header missing for bloom trie", "number", num)
+			return false
+		}
+		gen.AddBloom(uint(i), header.Bloom)
+	}
+	for bit := uint(0); bit < bloombits.BloomBitLength; bit++ {
+		bits, _ := gen.Bitset(bit)
+		comp := bloombits.CompressBytes(bits)
+
+		if err := core.WriteBloomBits(db, bit, section, comp); err != nil {
+			log.Error("Failed to store bloom bits", "err", err)
+			return false
+		}
+		t.Update(bloomTrieKey(bit, section), comp)
+	}
+
+	root, err := t.Commit()
+	if err != nil {
+		lastBloomTrieNum = 0
+	} else {
+		lastBloomTrieNum++
+
+		log.Trace("Generated bloom trie", "number", lastBloomTrieNum, "root", root.Hex())
+
+		storeBloomTrieRoot(db, lastBloomTrieNum, root)
+		var data [8]byte
+		binary.BigEndian.PutUint64(data[:], lastBloomTrieNum)
+		db.Put(lastBloomTrieKey, data[:])
+	}
+
+	return newBloomTrieNum > lastBloomTrieNum
+}
@@ -79,6 +79,6 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	pm.blockchain.(*light.LightChain).SyncCht(ctx)
+	pm.blockchain.(*light.LightChain).SyncCheckpoint(ctx)
 	pm.downloader.Synchronise(peer.id, peer.Head(), peer.Td(), downloader.LightSync)
 }
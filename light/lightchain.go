@@ -94,10 +94,8 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	if bc.genesisBlock == nil {
 		return nil, core.ErrNoGenesis
 	}
-	if bc.genesisBlock.Hash() == params.MainNetGenesisHash {
-		// add trusted CHT
-		WriteTrustedCht(bc.chainDb, TrustedCht{Number: 805, Root: common.HexToHash("85e4286fe0a730390245c49de8476977afdae0eb5530b277f62a52b12313d50f")})
-		log.Info("Added trusted CHT for mainnet")
+	if cp, ok := params.TrustedCheckpoints[bc.genesisBlock.Hash()]; ok {
+		bc.AddTrustedCheckpoint(cp)
 	}
 
 	if err := bc.loadLastState(); err != nil {
@@ -445,22 +443,70 @@ func (self *LightChain) GetHeaderByNumberOdr(ctx context.Context, number uint64)
 	return GetHeaderByNumber(ctx, self.odr, number)
 }
 
-func (self *LightChain) SyncCht(ctx context.Context) bool {
+// AddTrustedCheckpoint stores the given checkpoint as the trusted starting point
+// of light syncing if it is newer than the one already known.
+func (self *LightChain) AddTrustedCheckpoint(cp *params.TrustedCheckpoint) bool {
+	if cp == nil || cp.Empty() {
+		return false
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if old := GetTrustedCheckpoint(self.chainDb); !old.Empty() && old.SectionIndex >= cp.SectionIndex {
+		return false
+	}
+	WriteTrustedCheckpoint(self.chainDb, cp)
+	log.Info("Added trusted checkpoint", "section", cp.SectionIndex, "head", cp.SectionHead, "cht", cp.CHTRoot, "bloom", cp.BloomRoot)
+	return true
+}
+
+// TrustedCheckpoint returns the checkpoint light syncing currently starts from.
+func (self *LightChain) TrustedCheckpoint() *params.TrustedCheckpoint {
+	return GetTrustedCheckpoint(self.chainDb)
+}
+
+// SyncCheckpoint fetches the last header covered by the trusted checkpoint and
+// sets it as the current head if the local chain is behind it, so that header
+// syncing can continue from there instead of from the genesis.
+func (self *LightChain) SyncCheckpoint(ctx context.Context) bool {
+	cp := GetTrustedCheckpoint(self.chainDb)
+	if cp.Empty() {
+		return false
+	}
 	headNum := self.CurrentHeader().Number.Uint64()
-	cht := GetTrustedCht(self.chainDb)
-	if headNum+1 < cht.Number*ChtFrequency {
-		num := cht.Number*ChtFrequency - 1
-		header, err := GetHeaderByNumber(ctx, self.odr, num)
-		if header != nil && err == nil {
-			self.mu.Lock()
-			if self.hc.CurrentHeader().Number.Uint64() < header.Number.Uint64() {
-				self.hc.SetCurrentHeader(header)
-			}
-			self.mu.Unlock()
-			return true
-		}
+	if headNum >= cp.HeadNumber() {
+		return false
+	}
+	header, err := GetHeaderByNumber(ctx, self.odr, cp.HeadNumber())
+	if header == nil || err != nil {
+		return false
+	}
+	if cp.SectionHead != (common.Hash{}) && header.Hash() != cp.SectionHead {
+		log.Warn("Checkpoint section head mismatch", "section", cp.SectionIndex, "have", header.Hash(), "want", cp.SectionHead)
+		return false
+	}
+	self.mu.Lock()
+	if self.hc.CurrentHeader().Number.Uint64() < header.Number.Uint64() {
+		self.hc.SetCurrentHeader(header)
 	}
-	return false
+	self.mu.Unlock()
+	return true
+}
+
+// BloomStatus returns the number of blocks in a bloom bits section and the number
+// of sections whose bloom bits can be retrieved through the trusted bloom trie.
+func (self *LightChain) BloomStatus() (uint64, uint64) {
+	cp := GetTrustedCheckpoint(self.chainDb)
+	if cp.BloomRoot == (common.Hash{}) {
+		return BloomTrieFrequency, 0
+	}
+	return BloomTrieFrequency, cp.SectionIndex + 1
+}
+
+// GetBloomBits retrieves the uncompressed bloom bit vectors of the given bit
+// index in the given sections, from the database or the network.
+func (self *LightChain) GetBloomBits(ctx context.Context, bit uint, sections []uint64) ([][]byte, error) {
+	return GetBloomBits(ctx, self.odr, bit, sections)
 }
 
 // LockChain locks the chain mutex for reading so that multiple canonical hashes can be
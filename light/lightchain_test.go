@@ -358,3 +358,29 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 		t.Errorf("last header hash mismatch: have: %x, want %x", ncm.CurrentHeader().Hash(), headers[2].Hash())
 	}
 }
+
+// Tests that trusted checkpoints are persisted and only replaced by newer ones.
+func TestTrustedCheckpoint(t *testing.T) {
+	_, chain, err := newCanonical(0)
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	if cp := chain.TrustedCheckpoint(); !cp.Empty() {
+		t.Fatalf("unexpected initial checkpoint: %v", cp)
+	}
+	newer := &params.TrustedCheckpoint{SectionIndex: 2, CHTRoot: common.HexToHash("0x02")}
+	older := &params.TrustedCheckpoint{SectionIndex: 1, CHTRoot: common.HexToHash("0x01")}
+
+	if !chain.AddTrustedCheckpoint(newer) {
+		t.Fatalf("failed to add checkpoint")
+	}
+	if chain.AddTrustedCheckpoint(older) {
+		t.Fatalf("older checkpoint accepted")
+	}
+	if chain.AddTrustedCheckpoint(&params.TrustedCheckpoint{SectionIndex: 3}) {
+		t.Fatalf("empty checkpoint accepted")
+	}
+	if cp := chain.TrustedCheckpoint(); *cp != *newer {
+		t.Fatalf("checkpoint mismatch: have %v, want %v", cp, newer)
+	}
+}
@@ -157,3 +157,21 @@ func (req *ChtRequest) StoreResult(db bzcdb.Database) {
 	core.WriteCanonicalHash(db, hash, num)
 	//storeProof(db, req.Proof)
 }
+
+// BloomRequest is the ODR request type for retrieving the compressed bloom bit
+// vectors of some chain sections from the bloom trie, used for log filtering
+type BloomRequest struct {
+	OdrRequest
+	BloomTrieNum   uint64
+	BitIdx         uint
+	SectionIdxList []uint64
+	BloomTrieRoot  common.Hash
+	BloomBits      [][]byte
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *BloomRequest) StoreResult(db bzcdb.Database) {
+	for i, section := range req.SectionIdxList {
+		core.WriteBloomBits(db, req.BitIdx, section, req.BloomBits[i])
+	}
+}
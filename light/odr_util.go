@@ -24,21 +24,25 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/core"
+	"github.com/bazacoin/go-bazacoin/core/bloombits"
 	"github.com/bazacoin/go-bazacoin/core/types"
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/bzcdb"
+	"github.com/bazacoin/go-bazacoin/params"
 	"github.com/bazacoin/go-bazacoin/rlp"
 )
 
 var sha3_nil = crypto.Keccak256Hash(nil)
 
 var (
-	ErrNoTrustedCht = errors.New("No trusted canonical hash trie")
-	ErrNoHeader     = errors.New("Header not found")
-
-	ChtFrequency     = uint64(4096)
-	ChtConfirmations = uint64(2048)
-	trustedChtKey    = []byte("TrustedCHT")
+	ErrNoTrustedCht       = errors.New("No trusted canonical hash trie")
+	ErrNoTrustedBloomTrie = errors.New("No trusted bloom trie")
+	ErrNoHeader           = errors.New("Header not found")
+
+	ChtFrequency         = uint64(params.CHTFrequency)
+	ChtConfirmations     = uint64(2048)
+	BloomTrieFrequency   = uint64(params.CHTFrequency)
+	trustedCheckpointKey = []byte("TrustedCheckpoint")
 )
 
 type ChtNode struct {
@@ -46,27 +50,29 @@ type ChtNode struct {
 	Td   *big.Int
 }
 
-type TrustedCht struct {
-	Number uint64
-	Root   common.Hash
-}
-
-func GetTrustedCht(db bzcdb.Database) TrustedCht {
-	data, _ := db.Get(trustedChtKey)
-	var res TrustedCht
-	if err := rlp.DecodeBytes(data, &res); err != nil {
-		return TrustedCht{0, common.Hash{}}
+// GetTrustedCheckpoint retrieves the trusted checkpoint stored in the database,
+// returning an empty one if none is present.
+func GetTrustedCheckpoint(db bzcdb.Database) *params.TrustedCheckpoint {
+	data, _ := db.Get(trustedCheckpointKey)
+	res := new(params.TrustedCheckpoint)
+	if len(data) == 0 {
+		return res
+	}
+	if err := rlp.DecodeBytes(data, res); err != nil {
+		return new(params.TrustedCheckpoint)
 	}
 	return res
 }
 
-func WriteTrustedCht(db bzcdb.Database, cht TrustedCht) {
-	data, _ := rlp.EncodeToBytes(cht)
-	db.Put(trustedChtKey, data)
+// WriteTrustedCheckpoint stores a trusted checkpoint into the database.
+func WriteTrustedCheckpoint(db bzcdb.Database, cp *params.TrustedCheckpoint) {
+	data, _ := rlp.EncodeToBytes(cp)
+	db.Put(trustedCheckpointKey, data)
 }
 
-func DeleteTrustedCht(db bzcdb.Database) {
-	db.Delete(trustedChtKey)
+// DeleteTrustedCheckpoint removes the trusted checkpoint from the database.
+func DeleteTrustedCheckpoint(db bzcdb.Database) {
+	db.Delete(trustedCheckpointKey)
 }
 
 func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*types.Header, error) {
@@ -81,12 +87,12 @@ func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*typ
 		return header, nil
 	}
 
-	cht := GetTrustedCht(db)
-	if number >= cht.Number*ChtFrequency {
+	cp := GetTrustedCheckpoint(db)
+	if cp.Empty() || number > cp.HeadNumber() {
 		return nil, ErrNoTrustedCht
 	}
 
-	r := &ChtRequest{ChtRoot: cht.Root, ChtNum: cht.Number, BlockNum: number}
+	r := &ChtRequest{ChtRoot: cp.CHTRoot, ChtNum: cp.SectionIndex + 1, BlockNum: number}
 	if err := odr.Retrieve(ctx, r); err != nil {
 		return nil, err
 	} else {
@@ -181,3 +187,44 @@ func GetBlockReceipts(ctx context.Context, odr OdrBackend, hash common.Hash, num
 	}
 	return r.Receipts, nil
 }
+
+// GetBloomBits retrieves a batch of uncompressed bloom bit vectors belonging to
+// the given bit index and section indexes, from the local database if available
+// or from the network, proven against the bloom trie of the trusted checkpoint.
+func GetBloomBits(ctx context.Context, odr OdrBackend, bitIdx uint, sectionIdxList []uint64) ([][]byte, error) {
+	var (
+		db      = odr.Database()
+		cp      = GetTrustedCheckpoint(db)
+		result  = make([][]byte, len(sectionIdxList))
+		reqList []uint64
+		reqIdx  []int
+	)
+	for i, sectionIdx := range sectionIdxList {
+		if data := core.GetBloomBits(db, bitIdx, sectionIdx); data != nil {
+			result[i] = data
+			continue
+		}
+		if cp.BloomRoot == (common.Hash{}) || sectionIdx > cp.SectionIndex {
+			return nil, ErrNoTrustedBloomTrie
+		}
+		reqList = append(reqList, sectionIdx)
+		reqIdx = append(reqIdx, i)
+	}
+	if reqList != nil {
+		r := &BloomRequest{BloomTrieRoot: cp.BloomRoot, BloomTrieNum: cp.SectionIndex + 1, BitIdx: bitIdx, SectionIdxList: reqList}
+		if err := odr.Retrieve(ctx, r); err != nil {
+			return nil, err
+		}
+		for i, idx := range reqIdx {
+			result[idx] = r.BloomBits[i]
+		}
+	}
+	for i, data := range result {
+		bits, err := bloombits.DecompressBytes(data, int(BloomTrieFrequency/8))
+		if err != nil {
+			return nil, err
+		}
+		result[i] = bits
+	}
+	return result, nil
+}
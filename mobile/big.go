@@ -72,6 +72,18 @@ func (bi *BigInt) SetString(x string, base int) {
 // BigInts represents a slice of big ints.
 type BigInts struct{ bigints []*big.Int }
 
+// NewBigInts creates a slice of uninitialized big numbers.
+func NewBigInts(size int) *BigInts {
+	return &BigInts{
+		bigints: make([]*big.Int, size),
+	}
+}
+
+// NewBigIntsEmpty creates an empty slice of big numbers.
+func NewBigIntsEmpty() *BigInts {
+	return NewBigInts(0)
+}
+
 // Size returns the number of big ints in the slice.
 func (bi *BigInts) Size() int {
 	return len(bi.bigints)
@@ -94,6 +106,11 @@ func (bi *BigInts) Set(index int, bigint *BigInt) error {
 	return nil
 }
 
+// Append adds a new big int element to the end of the slice.
+func (bi *BigInts) Append(bigint *BigInt) {
+	bi.bigints = append(bi.bigints, bigint.bigint)
+}
+
 // GetString returns the value of x as a formatted string in some number base.
 func (bi *BigInt) GetString(base int) string {
 	return bi.bigint.Text(base)
@@ -19,7 +19,10 @@
 package geth
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
+	"reflect"
 	"strings"
 
 	"github.com/bazacoin/go-bazacoin/accounts/abi"
@@ -102,11 +105,70 @@ func (opts *TransactOpts) SetGasPrice(price *BigInt)   { opts.opts.GasPrice = pr
 func (opts *TransactOpts) SetGasLimit(limit int64)     { opts.opts.GasLimit = big.NewInt(limit) }
 func (opts *TransactOpts) SetContext(context *Context) { opts.opts.Context = context.context }
 
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	opts bind.FilterOpts
+}
+
+// NewFilterOpts creates a new option set for event filtering.
+func NewFilterOpts() *FilterOpts {
+	return new(FilterOpts)
+}
+
+func (opts *FilterOpts) GetStart() int64 { return int64(opts.opts.Start) }
+func (opts *FilterOpts) GetEnd() int64 {
+	if opts.opts.End == nil {
+		return -1
+	}
+	return int64(*opts.opts.End)
+}
+
+func (opts *FilterOpts) SetStart(start int64) { opts.opts.Start = uint64(start) }
+func (opts *FilterOpts) SetEnd(end int64) {
+	if end < 0 {
+		opts.opts.End = nil
+		return
+	}
+	last := uint64(end)
+	opts.opts.End = &last
+}
+func (opts *FilterOpts) SetContext(context *Context) { opts.opts.Context = context.context }
+
+// WatchOpts is the collection of options to fine tune subscribing for events
+// within a bound contract.
+type WatchOpts struct {
+	opts bind.WatchOpts
+}
+
+// NewWatchOpts creates a new option set for event subscriptions.
+func NewWatchOpts() *WatchOpts {
+	return new(WatchOpts)
+}
+
+func (opts *WatchOpts) GetStart() int64 {
+	if opts.opts.Start == nil {
+		return -1
+	}
+	return int64(*opts.opts.Start)
+}
+
+func (opts *WatchOpts) SetStart(start int64) {
+	if start < 0 {
+		opts.opts.Start = nil
+		return
+	}
+	first := uint64(start)
+	opts.opts.Start = &first
+}
+func (opts *WatchOpts) SetContext(context *Context) { opts.opts.Context = context.context }
+
 // BoundContract is the base wrapper object that reflects a contract on the
 // Bazacoin network. It contains a collection of methods that are used by the
 // higher level contract bindings to operate.
 type BoundContract struct {
 	contract *bind.BoundContract
+	abi      abi.ABI
 	address  common.Address
 	deployer *types.Transaction
 }
@@ -119,12 +181,17 @@ func DeployContract(opts *TransactOpts, abiJSON string, bytecode []byte, client
 	if err != nil {
 		return nil, err
 	}
-	addr, tx, bound, err := bind.DeployContract(&opts.opts, parsed, bytecode, client.client, args.objects...)
+	params, err := convertArgs(parsed.Constructor.Inputs, args)
+	if err != nil {
+		return nil, err
+	}
+	addr, tx, bound, err := bind.DeployContract(&opts.opts, parsed, bytecode, client.client, params...)
 	if err != nil {
 		return nil, err
 	}
 	return &BoundContract{
 		contract: bound,
+		abi:      parsed,
 		address:  addr,
 		deployer: tx,
 	}, nil
@@ -138,7 +205,8 @@ func BindContract(address *Address, abiJSON string, client *BazacoinClient) (con
 		return nil, err
 	}
 	return &BoundContract{
-		contract: bind.NewBoundContract(address.address, parsed, client.client, client.client),
+		contract: bind.NewBoundContract(address.address, parsed, client.client, client.client, client.client),
+		abi:      parsed,
 		address:  address.address,
 	}, nil
 }
@@ -152,20 +220,50 @@ func (c *BoundContract) GetDeployer() *Transaction {
 }
 
 // Call invokes the (constant) contract method with params as input values and
-// sets the output to result.
+// sets the output to result. The inputs are converted to the exact types of the
+// method arguments, and the outputs are converted to the types of the defaults
+// set in out (e.g. a uint8 return value may be retrieved as a BigInt).
 func (c *BoundContract) Call(opts *CallOpts, out *Interfaces, method string, args *Interfaces) error {
-	results := make([]interface{}, len(out.objects))
-	copy(results, out.objects)
-	if err := c.contract.Call(&opts.opts, &results, method, args.objects...); err != nil {
+	if opts == nil {
+		opts = NewCallOpts()
+	}
+	m, ok := c.abi.Methods[method]
+	if !ok {
+		return fmt.Errorf("method '%s' not found", method)
+	}
+	params, err := convertArgs(m.Inputs, args)
+	if err != nil {
 		return err
 	}
-	copy(out.objects, results)
-	return nil
+	if len(out.objects) != len(m.Outputs) {
+		return fmt.Errorf("output count mismatch: have %d, want %d", len(out.objects), len(m.Outputs))
+	}
+	// Unpack the results into their native types and convert them afterwards
+	results := make([]interface{}, len(m.Outputs))
+	for i, output := range m.Outputs {
+		results[i] = reflect.New(output.Type.GoType()).Interface()
+	}
+	var result interface{} = &results
+	if len(results) == 1 {
+		result = results[0]
+	}
+	if err := c.contract.Call(&opts.opts, result, method, params...); err != nil {
+		return err
+	}
+	return convertResults(out, results)
 }
 
 // Transact invokes the (paid) contract method with params as input values.
 func (c *BoundContract) Transact(opts *TransactOpts, method string, args *Interfaces) (tx *Transaction, _ error) {
-	rawTx, err := c.contract.Transact(&opts.opts, method, args.objects)
+	m, ok := c.abi.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("method '%s' not found", method)
+	}
+	params, err := convertArgs(m.Inputs, args)
+	if err != nil {
+		return nil, err
+	}
+	rawTx, err := c.contract.Transact(&opts.opts, method, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -181,3 +279,264 @@ func (c *BoundContract) Transfer(opts *TransactOpts) (tx *Transaction, _ error)
 	}
 	return &Transaction{rawTx}, nil
 }
+
+// FilterLogs retrieves the past logs of the given contract event. The query holds
+// an optional filter for each indexed event argument, in order. A filter is a
+// slice of accepted values (e.g. Addresses), a missing one matches anything.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query *Interfaces) (logs *Logs, _ error) {
+	if opts == nil {
+		opts = NewFilterOpts()
+	}
+	rules, err := c.topicRules(name, query)
+	if err != nil {
+		return nil, err
+	}
+	ch, sub, err := c.contract.FilterLogs(&opts.opts, name, rules...)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	var res []*types.Log
+	for {
+		select {
+		case log := <-ch:
+			res = append(res, &log)
+
+		case err := <-sub.Err():
+			if err != nil {
+				return nil, err
+			}
+			// Filtering done, collect any logs still queued up
+			for {
+				select {
+				case log := <-ch:
+					res = append(res, &log)
+				default:
+					return &Logs{res}, nil
+				}
+			}
+		}
+	}
+}
+
+// WatchLogs subscribes to the future logs of the given contract event, filtered
+// the same way as for FilterLogs.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query *Interfaces, handler FilterLogsHandler) (sub *Subscription, _ error) {
+	if opts == nil {
+		opts = NewWatchOpts()
+	}
+	rules, err := c.topicRules(name, query)
+	if err != nil {
+		return nil, err
+	}
+	ch, rawSub, err := c.contract.WatchLogs(&opts.opts, name, rules...)
+	if err != nil {
+		return nil, err
+	}
+	// Start up a dispatcher to feed into the callback
+	go func() {
+		for {
+			select {
+			case log := <-ch:
+				handler.OnFilterLogs(&Log{&log})
+
+			case err := <-rawSub.Err():
+				if err != nil {
+					handler.OnError(err.Error())
+				}
+				return
+			}
+		}
+	}()
+	return &Subscription{rawSub}, nil
+}
+
+// UnpackLog unpacks all the arguments of a contract event from a retrieved log.
+// Similarly to Call, the values are converted to the types of the defaults set
+// in out. Indexed arguments of dynamic types are only available as hashes.
+func (c *BoundContract) UnpackLog(out *Interfaces, name string, log *Log) error {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return fmt.Errorf("event '%s' not found", name)
+	}
+	if len(out.objects) != len(event.Inputs) {
+		return fmt.Errorf("argument count mismatch: have %d, want %d", len(out.objects), len(event.Inputs))
+	}
+	// Assemble a struct type the bind package can unpack all the arguments into
+	fields := make([]reflect.StructField, len(event.Inputs))
+	for i, input := range event.Inputs {
+		fields[i] = reflect.StructField{Name: abi.ToCamelCase(input.Name), Type: input.Type.GoType()}
+		if input.Name == "" {
+			fields[i].Name = fmt.Sprintf("Arg%d", i)
+		}
+		// Indexed arguments requested as hashes are dynamic ones stored hashed
+		if input.Indexed && out.objects[i] != nil && reflect.TypeOf(out.objects[i]) == reflect.TypeOf(new(common.Hash)) {
+			fields[i].Type = reflect.TypeOf(common.Hash{})
+		}
+	}
+	unpacked := reflect.New(reflect.StructOf(fields))
+	if err := c.contract.UnpackLog(unpacked.Interface(), name, *log.log); err != nil {
+		return err
+	}
+	results := make([]interface{}, len(fields))
+	for i := range fields {
+		results[i] = unpacked.Elem().Field(i).Addr().Interface()
+	}
+	return convertResults(out, results)
+}
+
+// topicRules converts a mobile event filter query into the topic rules expected
+// by the bind package, converting each accepted value to its native type.
+func (c *BoundContract) topicRules(name string, query *Interfaces) ([][]interface{}, error) {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return nil, fmt.Errorf("event '%s' not found", name)
+	}
+	if query == nil {
+		return nil, nil
+	}
+	var indexed []abi.Argument
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(query.objects) > len(indexed) {
+		return nil, fmt.Errorf("too many filters: have %d, want at most %d", len(query.objects), len(indexed))
+	}
+	rules := make([][]interface{}, len(query.objects))
+	for i, filter := range query.objects {
+		if filter == nil {
+			continue
+		}
+		values := reflect.Indirect(reflect.ValueOf(filter))
+		if values.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("filter %d: not a list of values: %T", i, filter)
+		}
+		for j := 0; j < values.Len(); j++ {
+			// Hashes are used as is, allowing to filter on dynamic arguments
+			value := values.Index(j)
+			if value.Type() != reflect.TypeOf(common.Hash{}) {
+				var err error
+				if value, err = convertValue(value, indexed[i].Type.GoType()); err != nil {
+					return nil, fmt.Errorf("filter %d: %v", i, err)
+				}
+			}
+			rules[i] = append(rules[i], value.Interface())
+		}
+	}
+	return rules, nil
+}
+
+// convertArgs converts the values wrapped in a mobile interface list to the
+// native Go types of the given abi arguments.
+func convertArgs(inputs []abi.Argument, args *Interfaces) ([]interface{}, error) {
+	var objects []interface{}
+	if args != nil {
+		objects = args.objects
+	}
+	if len(objects) != len(inputs) {
+		return nil, fmt.Errorf("argument count mismatch: have %d, want %d", len(objects), len(inputs))
+	}
+	params := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		if objects[i] == nil {
+			return nil, fmt.Errorf("argument %d: missing value", i)
+		}
+		value, err := convertValue(reflect.ValueOf(objects[i]), input.Type.GoType())
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i, err)
+		}
+		params[i] = value.Interface()
+	}
+	return params, nil
+}
+
+// convertResults converts a list of native results to the types of the defaults
+// set in the mobile interface list. Results without defaults are stored as is.
+func convertResults(out *Interfaces, results []interface{}) error {
+	for i, result := range results {
+		if out.objects[i] == nil {
+			out.objects[i] = result
+			continue
+		}
+		dest := reflect.ValueOf(out.objects[i])
+		if dest.Kind() != reflect.Ptr {
+			return fmt.Errorf("result %d: cannot store into %T", i, out.objects[i])
+		}
+		value, err := convertValue(reflect.ValueOf(result), dest.Elem().Type())
+		if err != nil {
+			return fmt.Errorf("result %d: %v", i, err)
+		}
+		dest.Elem().Set(value)
+	}
+	return nil
+}
+
+var bigT = reflect.TypeOf(new(big.Int))
+
+// convertValue converts a value between the loosely typed representations used
+// by the mobile wrappers and the native types of the abi package, e.g. BigInts to
+// small integers, byte slices to fixed size arrays and slices to arrays.
+func convertValue(value reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	// Dereference the pointers the mobile interfaces wrap values into
+	for value.Kind() == reflect.Ptr && value.Type() != bigT {
+		if value.IsNil() {
+			return reflect.Value{}, errors.New("nil value")
+		}
+		value = value.Elem()
+	}
+	if value.Type().AssignableTo(typ) {
+		return value, nil
+	}
+	switch kind := value.Kind(); {
+	case value.Type() == bigT:
+		num := value.Interface().(*big.Int)
+		if num == nil {
+			return reflect.Value{}, errors.New("nil big int")
+		}
+		result := reflect.New(typ).Elem()
+		switch typ.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !num.IsInt64() || result.OverflowInt(num.Int64()) {
+				return reflect.Value{}, fmt.Errorf("%v overflows %v", num, typ)
+			}
+			result.SetInt(num.Int64())
+			return result, nil
+
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if !num.IsUint64() || result.OverflowUint(num.Uint64()) {
+				return reflect.Value{}, fmt.Errorf("%v overflows %v", num, typ)
+			}
+			result.SetUint(num.Uint64())
+			return result, nil
+		}
+
+	case typ == bigT && kind >= reflect.Int8 && kind <= reflect.Int64:
+		return reflect.ValueOf(big.NewInt(value.Int())), nil
+
+	case typ == bigT && kind >= reflect.Uint8 && kind <= reflect.Uint64:
+		return reflect.ValueOf(new(big.Int).SetUint64(value.Uint())), nil
+
+	case (kind == reflect.Slice || kind == reflect.Array) && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array):
+		var result reflect.Value
+		if typ.Kind() == reflect.Slice {
+			result = reflect.MakeSlice(typ, value.Len(), value.Len())
+		} else {
+			if value.Len() != typ.Len() {
+				return reflect.Value{}, fmt.Errorf("length mismatch: have %d, want %d", value.Len(), typ.Len())
+			}
+			result = reflect.New(typ).Elem()
+		}
+		for i := 0; i < value.Len(); i++ {
+			elem, err := convertValue(value.Index(i), typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Index(i).Set(elem)
+		}
+		return result, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %v to %v", value.Type(), typ)
+}
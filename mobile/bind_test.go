@@ -0,0 +1,121 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package geth
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/accounts/abi"
+	"github.com/bazacoin/go-bazacoin/accounts/abi/bind"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/core/types"
+)
+
+// Tests that values are converted between the types of the mobile wrappers and
+// the native types of the abi package, rejecting lossy conversions.
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		typ   reflect.Type
+		want  interface{}
+		fail  bool
+	}{
+		{value: big.NewInt(255), typ: reflect.TypeOf(uint8(0)), want: uint8(255)},
+		{value: big.NewInt(256), typ: reflect.TypeOf(uint8(0)), fail: true},
+		{value: big.NewInt(-1), typ: reflect.TypeOf(uint64(0)), fail: true},
+		{value: big.NewInt(-128), typ: reflect.TypeOf(int8(0)), want: int8(-128)},
+		{value: int16(-3), typ: bigT, want: big.NewInt(-3)},
+		{value: uint32(7), typ: bigT, want: big.NewInt(7)},
+		{value: []byte{1, 2}, typ: reflect.TypeOf([2]byte{}), want: [2]byte{1, 2}},
+		{value: []byte{1, 2}, typ: reflect.TypeOf([3]byte{}), fail: true},
+		{value: [2]byte{1, 2}, typ: reflect.TypeOf([]byte{}), want: []byte{1, 2}},
+		{value: []*big.Int{big.NewInt(1), big.NewInt(2)}, typ: reflect.TypeOf([2]uint8{}), want: [2]uint8{1, 2}},
+		{value: [][]byte{{1}, {2}}, typ: reflect.TypeOf([][1]byte{}), want: [][1]byte{{1}, {2}}},
+		{value: &[]common.Address{{1}}, typ: reflect.TypeOf([]common.Address{}), want: []common.Address{{1}}},
+		{value: "hello", typ: reflect.TypeOf(false), fail: true},
+	}
+	for i, tt := range tests {
+		have, err := convertValue(reflect.ValueOf(tt.value), tt.typ)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: conversion of %v to %v succeeded", i, tt.value, tt.typ)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to convert %v to %v: %v", i, tt.value, tt.typ, err)
+			continue
+		}
+		if !reflect.DeepEqual(have.Interface(), tt.want) {
+			t.Errorf("test %d: conversion mismatch: have %v, want %v", i, have.Interface(), tt.want)
+		}
+	}
+}
+
+// Tests that the arguments of a retrieved log are unpacked into the defaults of
+// the requested types, including unnamed and underscored ones.
+func TestUnpackLog(t *testing.T) {
+	definition := `[{"anonymous":false,"inputs":[
+		{"indexed":true,"name":"","type":"address"},
+		{"indexed":false,"name":"","type":"uint256"},
+		{"indexed":true,"name":"old_value","type":"uint256"},
+		{"indexed":false,"name":"new_value","type":"uint256"}
+	],"name":"Changed","type":"event"}]`
+
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	contract := &BoundContract{
+		contract: bind.NewBoundContract(common.Address{}, parsed, nil, nil, nil),
+		abi:      parsed,
+	}
+	log := &Log{&types.Log{
+		Topics: []common.Hash{
+			parsed.Events["Changed"].Id(),
+			common.HexToHash("0x0102"),
+			common.BigToHash(big.NewInt(3)),
+		},
+		Data: append(common.LeftPadBytes([]byte{2}, 32), common.LeftPadBytes([]byte{4}, 32)...),
+	}}
+	out := NewInterfaces(4)
+	for i := 0; i < 4; i++ {
+		iface := NewInterface()
+		if i == 0 {
+			iface.SetDefaultAddress()
+		} else {
+			iface.SetDefaultBigInt()
+		}
+		out.Set(i, iface)
+	}
+	if err := contract.UnpackLog(out, "Changed", log); err != nil {
+		t.Fatalf("failed to unpack log: %v", err)
+	}
+	addr, _ := out.Get(0)
+	if have, want := addr.GetAddress().address, common.HexToAddress("0x0102"); have != want {
+		t.Errorf("argument 0 mismatch: have %x, want %x", have, want)
+	}
+	for i := 1; i < 4; i++ {
+		num, _ := out.Get(i)
+		if have := num.GetBigInt().bigint; have == nil || have.Int64() != int64(i+1) {
+			t.Errorf("argument %d mismatch: have %v, want %d", i, have, i+1)
+		}
+	}
+}
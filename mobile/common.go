@@ -25,6 +25,7 @@ import (
 	"strings"
 
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
 )
 
 // Hash represents the 32 byte Keccak256 hash of arbitrary data.
@@ -228,3 +229,13 @@ func (a *Addresses) Set(index int, address *Address) error {
 func (a *Addresses) Append(address *Address) {
 	a.addresses = append(a.addresses, address.address)
 }
+
+// EncodeToHex encodes b as a hex string with 0x prefix.
+func EncodeToHex(b []byte) string {
+	return hexutil.Encode(b)
+}
+
+// DecodeFromHex decodes a hex string with 0x prefix.
+func DecodeFromHex(s string) ([]byte, error) {
+	return hexutil.Decode(s)
+}
@@ -21,11 +21,25 @@ package geth
 import (
 	"errors"
 	"fmt"
+
+	"github.com/bazacoin/go-bazacoin/common"
 )
 
 // Strings represents s slice of strs.
 type Strings struct{ strs []string }
 
+// NewStrings creates a slice of empty strings.
+func NewStrings(size int) *Strings {
+	return &Strings{
+		strs: make([]string, size),
+	}
+}
+
+// NewStringsEmpty creates an empty slice of strings.
+func NewStringsEmpty() *Strings {
+	return NewStrings(0)
+}
+
 // Size returns the number of strs in the slice.
 func (s *Strings) Size() int {
 	return len(s.strs)
@@ -48,7 +62,106 @@ func (s *Strings) Set(index int, str string) error {
 	return nil
 }
 
+// Append adds a new string element to the end of the slice.
+func (s *Strings) Append(str string) {
+	s.strs = append(s.strs, str)
+}
+
 // String implements the Stringer interface.
 func (s *Strings) String() string {
 	return fmt.Sprintf("%v", s.strs)
 }
+
+// Bools represents a slice of booleans.
+type Bools struct{ bools []bool }
+
+// NewBools creates a slice of false booleans.
+func NewBools(size int) *Bools {
+	return &Bools{
+		bools: make([]bool, size),
+	}
+}
+
+// NewBoolsEmpty creates an empty slice of booleans.
+func NewBoolsEmpty() *Bools {
+	return NewBools(0)
+}
+
+// Size returns the number of booleans in the slice.
+func (b *Bools) Size() int {
+	return len(b.bools)
+}
+
+// Get returns the boolean at the given index from the slice.
+func (b *Bools) Get(index int) (flag bool, _ error) {
+	if index < 0 || index >= len(b.bools) {
+		return false, errors.New("index out of bounds")
+	}
+	return b.bools[index], nil
+}
+
+// Set sets the boolean at the given index in the slice.
+func (b *Bools) Set(index int, flag bool) error {
+	if index < 0 || index >= len(b.bools) {
+		return errors.New("index out of bounds")
+	}
+	b.bools[index] = flag
+	return nil
+}
+
+// Append adds a new boolean element to the end of the slice.
+func (b *Bools) Append(flag bool) {
+	b.bools = append(b.bools, flag)
+}
+
+// String implements the Stringer interface.
+func (b *Bools) String() string {
+	return fmt.Sprintf("%v", b.bools)
+}
+
+// Binaries represents a slice of binary blobs.
+type Binaries struct{ binaries [][]byte }
+
+// NewBinaries creates a slice of empty binary blobs.
+func NewBinaries(size int) *Binaries {
+	return &Binaries{
+		binaries: make([][]byte, size),
+	}
+}
+
+// NewBinariesEmpty creates an empty slice of binary blobs.
+func NewBinariesEmpty() *Binaries {
+	return NewBinaries(0)
+}
+
+// Size returns the number of binary blobs in the slice.
+func (b *Binaries) Size() int {
+	return len(b.binaries)
+}
+
+// Get returns the binary blob at the given index from the slice.
+func (b *Binaries) Get(index int) (binary []byte, _ error) {
+	if index < 0 || index >= len(b.binaries) {
+		return nil, errors.New("index out of bounds")
+	}
+	return b.binaries[index], nil
+}
+
+// Set sets the binary blob at the given index in the slice.
+func (b *Binaries) Set(index int, binary []byte) error {
+	if index < 0 || index >= len(b.binaries) {
+		return errors.New("index out of bounds")
+	}
+	b.binaries[index] = common.CopyBytes(binary)
+	return nil
+}
+
+// Append adds a new binary blob element to the end of the slice.
+func (b *Binaries) Append(binary []byte) {
+	b.binaries = append(b.binaries, common.CopyBytes(binary))
+}
+
+// String implements the Stringer interface.
+func (b *Binaries) String() string {
+	return fmt.Sprintf("%x", b.binaries)
+}
@@ -26,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/bazacoin/go-bazacoin/accounts"
+	"github.com/bazacoin/go-bazacoin/accounts/external"
 	"github.com/bazacoin/go-bazacoin/accounts/keystore"
 	"github.com/bazacoin/go-bazacoin/accounts/usbwallet"
 	"github.com/bazacoin/go-bazacoin/common"
@@ -82,9 +83,19 @@ type Config struct {
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
 
+	// KeyStoreScrypt is the scrypt work factor tier used to encrypt new keys. It can
+	// be one of standard, bulk or light, or a custom N:P parameter pair. If set, it
+	// takes precedence over UseLightweightKDF.
+	KeyStoreScrypt string `toml:",omitempty"`
+
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the endpoint (IPC path or URL) of an external signer
+	// daemon. If set, account management and transaction signing are delegated
+	// to it and the local keystore and hardware wallets are not opened.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -360,6 +371,13 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 		scryptN = keystore.LightScryptN
 		scryptP = keystore.LightScryptP
 	}
+	if conf.KeyStoreScrypt != "" {
+		n, p, err := keystore.ParseScryptTier(conf.KeyStoreScrypt)
+		if err != nil {
+			return nil, "", err
+		}
+		scryptN, scryptP = n, p
+	}
 
 	var (
 		keydir    string
@@ -389,14 +407,29 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 		return nil, "", err
 	}
 	// Assemble the account manager and supported backends
-	backends := []accounts.Backend{
-		keystore.NewKeyStore(keydir, scryptN, scryptP),
-	}
-	if !conf.NoUSB {
-		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
-			log.Warn(fmt.Sprintf("Failed to start Ledger hub, disabling: %v", err))
-		} else {
-			backends = append(backends, ledgerhub)
+	var backends []accounts.Backend
+	if conf.ExternalSigner != "" {
+		log.Info("Using external signer", "endpoint", conf.ExternalSigner)
+		extapi, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("error connecting to external signer: %v", err)
+		}
+		backends = append(backends, extapi)
+	} else {
+		// Local and external accounts are not mixed, the same keys showing up
+		// twice through different wallets would only confuse users.
+		backends = append(backends, keystore.NewKeyStore(keydir, scryptN, scryptP))
+		if !conf.NoUSB {
+			if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
+				log.Warn(fmt.Sprintf("Failed to start Ledger hub, disabling: %v", err))
+			} else {
+				backends = append(backends, ledgerhub)
+			}
+			if trezorhub, err := usbwallet.NewTrezorHub(); err != nil {
+				log.Warn(fmt.Sprintf("Failed to start Trezor hub, disabling: %v", err))
+			} else {
+				backends = append(backends, trezorhub)
+			}
 		}
 	}
 	return accounts.NewManager(backends...), ephemeral, nil
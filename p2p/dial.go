@@ -26,6 +26,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/log"
 	"github.com/bazacoin/go-bazacoin/p2p/discover"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 	"github.com/bazacoin/go-bazacoin/p2p/netutil"
 )
 
@@ -42,23 +43,48 @@ const (
 	// attempted to be connected.
 	fallbackInterval = 20 * time.Second
 
+	// If DNS node lists are the only source of dial candidates,
+	// they are checked for new nodes at this interval.
+	nodeListInterval = 10 * time.Second
+
 	// Endpoint resolution is throttled with bounded backoff.
 	initialResolveDelay = 60 * time.Second
 	maxResolveDelay     = time.Hour
 )
 
+// NodeDialer is used to connect to nodes in the network, typically by using
+// an underlying net.Dialer but also using net.Pipe in tests.
+type NodeDialer interface {
+	Dial(*discover.Node) (net.Conn, error)
+}
+
+// TCPDialer implements the NodeDialer interface by using a net.Dialer to
+// create TCP connections to nodes in the network.
+type TCPDialer struct {
+	*net.Dialer
+}
+
+// Dial creates a TCP connection to the node.
+func (t TCPDialer) Dial(dest *discover.Node) (net.Conn, error) {
+	addr := &net.TCPAddr{IP: dest.IP, Port: int(dest.TCP)}
+	return t.Dialer.Dial("tcp", addr.String())
+}
+
 // dialstate schedules dials and discovery lookups.
 // it get's a chance to compute new tasks on every iteration
 // of the main loop in Server.run.
 type dialstate struct {
 	maxDynDials int
 	ntab        discoverTable
+	lists       nodeList // DNS node lists
 	netrestrict *netutil.Netlist
+	filter      func(*enr.Record) bool
 
 	lookupRunning bool
 	dialing       map[discover.NodeID]connFlag
 	lookupBuf     []*discover.Node // current discovery lookup results
 	randomNodes   []*discover.Node // filled from Table
+	listNodes     []*discover.Node // filled from node lists
 	static        map[discover.NodeID]*dialTask
 	hist          *dialHistory
 
@@ -74,6 +100,12 @@ type discoverTable interface {
 	ReadRandomNodes([]*discover.Node) int
 }
 
+// nodeList is a source of dial candidates that doesn't support
+// lookups. It is implemented by dnsdisc.Client.
+type nodeList interface {
+	ReadRandomNodes([]*discover.Node) int
+}
+
 // the dial history remembers recent dials.
 type dialHistory []pastDial
 
@@ -109,15 +141,18 @@ type waitExpireTask struct {
 	time.Duration
 }
 
-func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab discoverTable, maxdyn int, netrestrict *netutil.Netlist) *dialstate {
+func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab discoverTable, lists nodeList, maxdyn int, netrestrict *netutil.Netlist, filter func(*enr.Record) bool) *dialstate {
 	s := &dialstate{
 		maxDynDials: maxdyn,
 		ntab:        ntab,
+		lists:       lists,
 		netrestrict: netrestrict,
+		filter:      filter,
 		static:      make(map[discover.NodeID]*dialTask),
 		dialing:     make(map[discover.NodeID]connFlag),
 		bootnodes:   make([]*discover.Node, len(bootnodes)),
 		randomNodes: make([]*discover.Node, maxdyn/2),
+		listNodes:   make([]*discover.Node, maxdyn),
 		hist:        new(dialHistory),
 	}
 	copy(s.bootnodes, bootnodes)
@@ -197,7 +232,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	// Use random nodes from the table for half of the necessary
 	// dynamic dials.
 	randomCandidates := needDynDials / 2
-	if randomCandidates > 0 {
+	if randomCandidates > 0 && s.ntab != nil {
 		n := s.ntab.ReadRandomNodes(s.randomNodes)
 		for i := 0; i < randomCandidates && i < n; i++ {
 			if addDial(dynDialedConn, s.randomNodes[i]) {
@@ -205,6 +240,20 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 			}
 		}
 	}
+	// Use random nodes from the node lists for half of the remaining
+	// dynamic dials, or all of them if there is no discovery table.
+	if s.lists != nil && needDynDials > 0 {
+		listCandidates := needDynDials / 2
+		if s.ntab == nil {
+			listCandidates = needDynDials
+		}
+		n := s.lists.ReadRandomNodes(s.listNodes)
+		for i := 0; i < listCandidates && i < n; i++ {
+			if addDial(dynDialedConn, s.listNodes[i]) {
+				needDynDials--
+			}
+		}
+	}
 	// Create dynamic dials from random lookup results, removing tried
 	// items from the result buffer.
 	i := 0
@@ -215,7 +264,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	}
 	s.lookupBuf = s.lookupBuf[:copy(s.lookupBuf, s.lookupBuf[i:])]
 	// Launch a discovery lookup if more candidates are needed.
-	if len(s.lookupBuf) < needDynDials && !s.lookupRunning {
+	if len(s.lookupBuf) < needDynDials && !s.lookupRunning && s.ntab != nil {
 		s.lookupRunning = true
 		newtasks = append(newtasks, &discoverTask{})
 	}
@@ -228,6 +277,11 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 		t := &waitExpireTask{s.hist.min().exp.Sub(now)}
 		newtasks = append(newtasks, t)
 	}
+	// Without a discovery table, nothing else keeps the loop ticking
+	// until the node lists have been synced.
+	if nRunning == 0 && len(newtasks) == 0 && s.lists != nil && needDynDials > 0 {
+		newtasks = append(newtasks, &waitExpireTask{nodeListInterval})
+	}
 	return newtasks
 }
 
@@ -237,6 +291,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errFiltered         = errors.New("rejected by node record filter")
 )
 
 func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer) error {
@@ -250,6 +305,8 @@ func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer)
 		return errSelf
 	case s.netrestrict != nil && !s.netrestrict.Contains(n.IP):
 		return errNotWhitelisted
+	case s.filter != nil && n.Record() != nil && !s.filter(n.Record()):
+		return errFiltered
 	case s.hist.contains(n.ID):
 		return errRecentlyDialed
 	}
@@ -318,14 +375,13 @@ func (t *dialTask) resolve(srv *Server) bool {
 
 // dial performs the actual connection attempt.
 func (t *dialTask) dial(srv *Server, dest *discover.Node) bool {
-	addr := &net.TCPAddr{IP: dest.IP, Port: int(dest.TCP)}
-	fd, err := srv.Dialer.Dial("tcp", addr.String())
+	fd, err := srv.Dialer.Dial(dest)
 	if err != nil {
 		log.Trace("Dial error", "task", t, "err", err)
 		return false
 	}
 	mfd := newMeteredConn(fd, false)
-	srv.setupConn(mfd, t.flags, dest)
+	srv.SetupConn(mfd, t.flags, dest)
 	return true
 }
 
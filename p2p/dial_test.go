@@ -24,7 +24,9 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/p2p/discover"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 	"github.com/bazacoin/go-bazacoin/p2p/netutil"
 )
 
@@ -87,7 +89,7 @@ func (t fakeTable) ReadRandomNodes(buf []*discover.Node) int { return copy(buf,
 // This test checks that dynamic dials are launched from discovery results.
 func TestDialStateDynDial(t *testing.T) {
 	runDialTest(t, dialtest{
-		init: newDialState(nil, nil, fakeTable{}, 5, nil),
+		init: newDialState(nil, nil, fakeTable{}, nil, 5, nil, nil),
 		rounds: []round{
 			// A discovery query is launched.
 			{
@@ -234,7 +236,7 @@ func TestDialStateDynDialBootnode(t *testing.T) {
 		{ID: uintID(8)},
 	}
 	runDialTest(t, dialtest{
-		init: newDialState(nil, bootnodes, table, 5, nil),
+		init: newDialState(nil, bootnodes, table, nil, 5, nil, nil),
 		rounds: []round{
 			// 2 dynamic dials attempted, bootnodes pending fallback interval
 			{
@@ -322,7 +324,7 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 	}
 
 	runDialTest(t, dialtest{
-		init: newDialState(nil, nil, table, 10, nil),
+		init: newDialState(nil, nil, table, nil, 10, nil, nil),
 		rounds: []round{
 			// 5 out of 8 of the nodes returned by ReadRandomNodes are dialed.
 			{
@@ -420,7 +422,7 @@ func TestDialStateNetRestrict(t *testing.T) {
 	restrict.Add("127.0.2.0/24")
 
 	runDialTest(t, dialtest{
-		init: newDialState(nil, nil, table, 10, restrict),
+		init: newDialState(nil, nil, table, nil, 10, restrict, nil),
 		rounds: []round{
 			{
 				new: []task{
@@ -432,6 +434,89 @@ func TestDialStateNetRestrict(t *testing.T) {
 	})
 }
 
+// This test checks that nodes from node lists are dialed when
+// there is no discovery table.
+func TestDialStateNodeLists(t *testing.T) {
+	list := fakeTable{
+		{ID: uintID(1), IP: net.ParseIP("127.0.0.1")},
+		{ID: uintID(2), IP: net.ParseIP("127.0.0.2")},
+		{ID: uintID(3), IP: net.ParseIP("127.0.0.3")},
+	}
+
+	runDialTest(t, dialtest{
+		init: newDialState(nil, nil, nil, list, 4, nil, nil),
+		rounds: []round{
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: list[0]},
+					&dialTask{flags: dynDialedConn, dest: list[1]},
+					&dialTask{flags: dynDialedConn, dest: list[2]},
+				},
+			},
+			// Once the dials are done and all candidates are in the
+			// dial history, the dialer waits for the history to expire.
+			{
+				done: []task{
+					&dialTask{flags: dynDialedConn, dest: list[0]},
+					&dialTask{flags: dynDialedConn, dest: list[1]},
+					&dialTask{flags: dynDialedConn, dest: list[2]},
+				},
+				new: []task{
+					&waitExpireTask{Duration: 30 * time.Second},
+				},
+			},
+		},
+	})
+}
+
+// This test checks that candidates with a node record are dialed
+// only if the record is accepted by the node filter.
+func TestDialStateNodeFilter(t *testing.T) {
+	table := fakeTable{
+		recordNode(t, enr.WithEntry("bzc", uint(1))),
+		recordNode(t),
+		{ID: uintID(3), IP: net.ParseIP("127.0.0.3")},
+	}
+	filter := func(r *enr.Record) bool {
+		var version uint
+		return r.Load(enr.WithEntry("bzc", &version)) == nil
+	}
+
+	runDialTest(t, dialtest{
+		init: newDialState(nil, nil, table, nil, 10, nil, filter),
+		rounds: []round{
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: table[0]},
+					&dialTask{flags: dynDialedConn, dest: table[2]},
+					&discoverTask{},
+				},
+			},
+		},
+	})
+}
+
+func recordNode(t *testing.T, entries ...enr.Entry) *discover.Node {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r enr.Record
+	r.Set(enr.IP(net.IP{127, 0, 0, 1}))
+	r.Set(enr.TCP(30303))
+	for _, e := range entries {
+		r.Set(e)
+	}
+	if err := r.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	n, err := discover.NodeFromRecord(&r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
 // This test checks that static dials are launched.
 func TestDialStateStaticDial(t *testing.T) {
 	wantStatic := []*discover.Node{
@@ -443,7 +528,7 @@ func TestDialStateStaticDial(t *testing.T) {
 	}
 
 	runDialTest(t, dialtest{
-		init: newDialState(wantStatic, nil, fakeTable{}, 0, nil),
+		init: newDialState(wantStatic, nil, fakeTable{}, nil, 0, nil, nil),
 		rounds: []round{
 			// Static dials are launched for the nodes that
 			// aren't yet connected.
@@ -524,7 +609,7 @@ func TestDialStateCache(t *testing.T) {
 	}
 
 	runDialTest(t, dialtest{
-		init: newDialState(wantStatic, nil, fakeTable{}, 0, nil),
+		init: newDialState(wantStatic, nil, fakeTable{}, nil, 0, nil, nil),
 		rounds: []round{
 			// Static dials are launched for the nodes that
 			// aren't yet connected.
@@ -586,7 +671,7 @@ func TestDialStateCache(t *testing.T) {
 func TestDialResolve(t *testing.T) {
 	resolved := discover.NewNode(uintID(1), net.IP{127, 0, 55, 234}, 3333, 4444)
 	table := &resolveMock{answer: resolved}
-	state := newDialState(nil, nil, table, 0, nil)
+	state := newDialState(nil, nil, table, nil, 0, nil, nil)
 
 	// Check that the task is generated with an incomplete ID.
 	dest := discover.NewNode(uintID(1), nil, 0, 0)
@@ -597,7 +682,7 @@ func TestDialResolve(t *testing.T) {
 	}
 
 	// Now run the task, it should resolve the ID once.
-	config := Config{Dialer: &net.Dialer{Deadline: time.Now().Add(-5 * time.Minute)}}
+	config := Config{Dialer: TCPDialer{&net.Dialer{Deadline: time.Now().Add(-5 * time.Minute)}}}
 	srv := &Server{ntab: table, Config: config}
 	tasks[0].Do(srv)
 	if !reflect.DeepEqual(table.resolveCalls, []discover.NodeID{dest.ID}) {
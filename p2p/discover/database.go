@@ -61,6 +61,8 @@ var (
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
 	nodeDBDiscoverPong      = nodeDBDiscoverRoot + ":lastpong"
 	nodeDBDiscoverFindFails = nodeDBDiscoverRoot + ":findfail"
+
+	nodeDBLocalSeq = ":local:seq"
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
@@ -298,6 +300,16 @@ func (db *nodeDB) updateFindFails(id NodeID, fails int) error {
 	return db.storeInt64(makeKey(id, nodeDBDiscoverFindFails), int64(fails))
 }
 
+// localSeq retrieves the sequence number of the local node record.
+func (db *nodeDB) localSeq() uint64 {
+	return uint64(db.fetchInt64(makeKey(db.self, nodeDBLocalSeq)))
+}
+
+// storeLocalSeq stores the sequence number of the local node record.
+func (db *nodeDB) storeLocalSeq(seq uint64) error {
+	return db.storeInt64(makeKey(db.self, nodeDBLocalSeq), int64(seq))
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
@@ -29,6 +29,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 )
 
 func ExampleNewNode() {
@@ -179,6 +180,35 @@ func TestNodeString(t *testing.T) {
 	}
 }
 
+func TestParseNodeRecord(t *testing.T) {
+	key := newkey()
+	var r enr.Record
+	r.Set(enr.IP(net.IP{10, 0, 0, 1}))
+	r.Set(enr.UDP(30301))
+	r.Set(enr.TCP(30303))
+	if err := r.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	text, err := r.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := ParseNode(string(text))
+	if err != nil {
+		t.Fatalf("can't parse record: %v", err)
+	}
+	want := NewNode(PubkeyID(&key.PublicKey), net.IP{10, 0, 0, 1}, 30301, 30303)
+	if n.ID != want.ID || !n.IP.Equal(want.IP) || n.UDP != want.UDP || n.TCP != want.TCP {
+		t.Errorf("node mismatch:\ngot:  %v\nwant: %v", n, want)
+	}
+	if n.Record() == nil || n.Record().Seq() != r.Seq() {
+		t.Errorf("node has wrong record: %v", n.Record())
+	}
+	if _, err := ParseNode("enr:-invalid"); err == nil {
+		t.Error("expected error for invalid record")
+	}
+}
+
 func TestHexID(t *testing.T) {
 	ref := NodeID{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128, 106, 217, 182, 31, 165, 174, 1, 67, 7, 235, 220, 150, 66, 83, 173, 205, 159, 44, 10, 57, 42, 161, 26, 188}
 	id1 := MustHexID("0x000000000000000000000000000000000000000000000000000000000000000000000000000000806ad9b61fa5ae014307ebdc964253adcd9f2c0a392aa11abc")
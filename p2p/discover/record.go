@@ -0,0 +1,90 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
+	"github.com/bazacoin/go-bazacoin/rlp"
+)
+
+var errNoRecord = errors.New("local node has no record")
+
+// localRecord holds the signed node record of the local node.
+type localRecord struct {
+	mu     sync.Mutex
+	priv   *ecdsa.PrivateKey
+	db     *nodeDB
+	r      enr.Record  // the record being edited
+	signed *enr.Record // copy of r as of the last signing, handed out to callers
+}
+
+// newLocalRecord creates the local node record, containing the given
+// endpoint. The sequence number continues from the one stored in db so
+// remote nodes never see an older record with a higher number.
+func newLocalRecord(priv *ecdsa.PrivateKey, db *nodeDB, ep rpcEndpoint) (*localRecord, error) {
+	lr := &localRecord{priv: priv, db: db}
+	lr.r.SetSeq(db.localSeq())
+	entries := []enr.Entry{enr.UDP(ep.UDP), enr.TCP(ep.TCP)}
+	// A node listening on all interfaces doesn't know its address.
+	if ip := net.IP(ep.IP); !ip.IsUnspecified() {
+		entries = append(entries, enr.IP(ip))
+	}
+	if err := lr.set(entries...); err != nil {
+		return nil, err
+	}
+	return lr, nil
+}
+
+// set adds or updates the given entries and signs the record again.
+func (lr *localRecord) set(entries ...enr.Entry) error {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	for _, e := range entries {
+		lr.r.Set(e)
+	}
+	if err := lr.r.Sign(lr.priv); err != nil {
+		return err
+	}
+	blob, err := rlp.EncodeToBytes(lr.r)
+	if err != nil {
+		return err
+	}
+	signed := new(enr.Record)
+	if err := rlp.DecodeBytes(blob, signed); err != nil {
+		return err
+	}
+	lr.signed = signed
+	return lr.db.storeLocalSeq(signed.Seq())
+}
+
+// record returns the current signed record. It must not be modified.
+func (lr *localRecord) record() *enr.Record {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.signed
+}
+
+// seq returns the sequence number of the current signed record.
+func (lr *localRecord) seq() uint64 {
+	return lr.record().Seq()
+}
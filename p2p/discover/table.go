@@ -35,6 +35,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 )
 
 const (
@@ -68,7 +69,8 @@ type Table struct {
 	nodeAddedHook func(*Node) // for testing
 
 	net  transport
-	self *Node // metadata of the local node
+	self *Node        // metadata of the local node
+	rec  *localRecord // signed record of the local node, nil in tests
 }
 
 type bondproc struct {
@@ -81,9 +83,12 @@ type bondproc struct {
 // it is an interface so we can test without opening lots of UDP
 // sockets and without generating a private key.
 type transport interface {
-	ping(NodeID, *net.UDPAddr) error
+	// ping returns the record sequence number advertised by the
+	// remote node, zero if it doesn't support node records.
+	ping(NodeID, *net.UDPAddr) (uint64, error)
 	waitping(NodeID) error
 	findnode(toid NodeID, addr *net.UDPAddr, target NodeID) ([]*Node, error)
+	requestENR(toid NodeID, addr *net.UDPAddr) (*enr.Record, error)
 	close()
 }
 
@@ -123,6 +128,25 @@ func (tab *Table) Self() *Node {
 	return tab.self
 }
 
+// Record returns the signed record of the local node.
+// The returned record should not be modified by the caller.
+func (tab *Table) Record() *enr.Record {
+	if tab.rec == nil {
+		return nil
+	}
+	return tab.rec.record()
+}
+
+// SetRecordEntries adds or updates entries of the local node record.
+// The record is signed again with an incremented sequence number and
+// will be served to nodes requesting it from then on.
+func (tab *Table) SetRecordEntries(entries ...enr.Entry) error {
+	if tab.rec == nil {
+		return errNoRecord
+	}
+	return tab.rec.set(entries...)
+}
+
 // ReadRandomNodes fills the given slice with random nodes from the
 // table. It will not write the same node more than once. The nodes in
 // the slice are copies and can be modified by the caller.
@@ -510,7 +534,8 @@ func (tab *Table) pingpong(w *bondproc, pinged bool, id NodeID, addr *net.UDPAdd
 	defer func() { tab.bondslots <- struct{}{} }()
 
 	// Ping the remote side and wait for a pong.
-	if w.err = tab.ping(id, addr); w.err != nil {
+	seq, err := tab.ping(id, addr)
+	if w.err = err; w.err != nil {
 		close(w.done)
 		return
 	}
@@ -522,16 +547,37 @@ func (tab *Table) pingpong(w *bondproc, pinged bool, id NodeID, addr *net.UDPAdd
 	}
 	// Bonding succeeded, update the node database.
 	w.n = NewNode(id, addr.IP, uint16(addr.Port), tcpPort)
+	if seq > 0 {
+		tab.fetchRecord(w.n)
+	}
 	tab.db.updateNode(w.n)
 	close(w.done)
 }
 
+// fetchRecord requests the record of a freshly bonded node and attaches
+// it to n. Records are optional, failures are only logged.
+func (tab *Table) fetchRecord(n *Node) {
+	r, err := tab.net.requestENR(n.ID, n.addr())
+	if err != nil {
+		log.Trace("Node record request failed", "id", n.ID, "err", err)
+		return
+	}
+	rn, err := NodeFromRecord(r)
+	if err != nil || rn.ID != n.ID {
+		log.Trace("Invalid node record", "id", n.ID, "err", err)
+		return
+	}
+	n.record = r
+}
+
 // ping a remote endpoint and wait for a reply, also updating the node
-// database accordingly.
-func (tab *Table) ping(id NodeID, addr *net.UDPAddr) error {
+// database accordingly. It returns the record sequence number advertised
+// in the reply.
+func (tab *Table) ping(id NodeID, addr *net.UDPAddr) (uint64, error) {
 	tab.db.updateLastPing(id, time.Now())
-	if err := tab.net.ping(id, addr); err != nil {
-		return err
+	seq, err := tab.net.ping(id, addr)
+	if err != nil {
+		return 0, err
 	}
 	tab.db.updateLastPong(id, time.Now())
 
@@ -541,7 +587,7 @@ func (tab *Table) ping(id NodeID, addr *net.UDPAddr) error {
 	// so that the search for seed nodes also considers older nodes
 	// that would otherwise be removed by the expiration.
 	tab.db.ensureExpirer()
-	return nil
+	return seq, nil
 }
 
 // add attempts to add the given node its corresponding bucket. If the
@@ -569,7 +615,7 @@ func (tab *Table) add(new *Node) {
 		// Let go of the mutex so other goroutines can access
 		// the table while we ping the least recently active node.
 		tab.mutex.Unlock()
-		err := tab.ping(oldest.ID, oldest.addr())
+		_, err := tab.ping(oldest.ID, oldest.addr())
 		tab.mutex.Lock()
 		oldest.contested = false
 		if err == nil {
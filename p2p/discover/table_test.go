@@ -29,6 +29,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 )
 
 func TestTable_pingReplace(t *testing.T) {
@@ -164,14 +165,17 @@ func (t *pingRecorder) close() {}
 func (t *pingRecorder) waitping(from NodeID) error {
 	return nil // remote always pings
 }
-func (t *pingRecorder) ping(toid NodeID, toaddr *net.UDPAddr) error {
+func (t *pingRecorder) ping(toid NodeID, toaddr *net.UDPAddr) (uint64, error) {
 	t.pinged[toid] = true
 	if t.responding[toid] {
-		return nil
+		return 0, nil
 	} else {
-		return errTimeout
+		return 0, errTimeout
 	}
 }
+func (t *pingRecorder) requestENR(toid NodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	panic("requestENR called on pingRecorder")
+}
 
 func TestTable_closest(t *testing.T) {
 	t.Parallel()
@@ -523,9 +527,12 @@ func (tn *preminedTestnet) findnode(toid NodeID, toaddr *net.UDPAddr, target Nod
 	return result, nil
 }
 
-func (*preminedTestnet) close()                                      {}
-func (*preminedTestnet) waitping(from NodeID) error                  { return nil }
-func (*preminedTestnet) ping(toid NodeID, toaddr *net.UDPAddr) error { return nil }
+func (*preminedTestnet) close()                                                {}
+func (*preminedTestnet) waitping(from NodeID) error                            { return nil }
+func (*preminedTestnet) ping(toid NodeID, toaddr *net.UDPAddr) (uint64, error) { return 0, nil }
+func (*preminedTestnet) requestENR(toid NodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	return nil, errTimeout
+}
 
 // mine generates a testnet struct literal with nodes at
 // various distances to the given target.
@@ -27,6 +27,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 	"github.com/bazacoin/go-bazacoin/p2p/nat"
 	"github.com/bazacoin/go-bazacoin/p2p/netutil"
 	"github.com/bazacoin/go-bazacoin/rlp"
@@ -63,6 +64,8 @@ const (
 	pongPacket
 	findnodePacket
 	neighborsPacket
+	enrRequestPacket
+	enrResponsePacket
 )
 
 // RPC request structures
@@ -104,6 +107,21 @@ type (
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
+	// enrRequest queries the node record of the recipient.
+	enrRequest struct {
+		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
+	// enrResponse is the reply to enrRequest.
+	enrResponse struct {
+		ReplyTok []byte // Hash of the enrRequest packet.
+		Record   enr.Record
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
 	rpcNode struct {
 		IP  net.IP // len 4 for IPv4 or 16 for IPv6
 		UDP uint16 // for discovery protocol
@@ -145,6 +163,23 @@ func nodeToRPC(n *Node) rpcNode {
 	return rpcNode{ID: n.ID, IP: n.IP, UDP: n.UDP, TCP: n.TCP}
 }
 
+// The sequence number of the sender's node record is carried as the
+// first element of the ping and pong packet tails. Nodes that don't
+// support records leave the tail empty.
+
+func (t *udp) seqTail() []rlp.RawValue {
+	blob, _ := rlp.EncodeToBytes(t.rec.seq())
+	return []rlp.RawValue{blob}
+}
+
+func tailSeq(rest []rlp.RawValue) uint64 {
+	var seq uint64
+	if len(rest) == 0 || rlp.DecodeBytes(rest[0], &seq) != nil {
+		return 0
+	}
+	return seq
+}
+
 type packet interface {
 	handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error
 	name() string
@@ -253,6 +288,10 @@ func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface, nodeDBPath strin
 	if err != nil {
 		return nil, nil, err
 	}
+	if tab.rec, err = newLocalRecord(priv, tab.db, udp.ourEndpoint); err != nil {
+		tab.Close()
+		return nil, nil, err
+	}
 	udp.Table = tab
 
 	go udp.loop()
@@ -267,16 +306,23 @@ func (t *udp) close() {
 }
 
 // ping sends a ping message to the given node and waits for a reply.
-func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) error {
+// It returns the record sequence number advertised by the remote node.
+func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) (uint64, error) {
 	// TODO: maybe check for ReplyTo field in callback to measure RTT
-	errc := t.pending(toid, pongPacket, func(interface{}) bool { return true })
+	var seq uint64
+	errc := t.pending(toid, pongPacket, func(r interface{}) bool {
+		seq = tailSeq(r.(*pong).Rest)
+		return true
+	})
 	t.send(toaddr, pingPacket, &ping{
 		Version:    Version,
 		From:       t.ourEndpoint,
 		To:         makeEndpoint(toaddr, 0), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		Rest:       t.seqTail(),
 	})
-	return <-errc
+	err := <-errc
+	return seq, err
 }
 
 func (t *udp) waitping(from NodeID) error {
@@ -309,6 +355,34 @@ func (t *udp) findnode(toid NodeID, toaddr *net.UDPAddr, target NodeID) ([]*Node
 	return nodes, err
 }
 
+// requestENR sends an enrRequest to the given node and waits for
+// its record.
+func (t *udp) requestENR(toid NodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	packet, err := encodePacket(t.priv, enrRequestPacket, &enrRequest{
+		Expiration: uint64(time.Now().Add(expiration).Unix()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		hash   = packet[:macSize]
+		record *enr.Record
+	)
+	errc := t.pending(toid, enrResponsePacket, func(r interface{}) bool {
+		resp := r.(*enrResponse)
+		if !bytes.Equal(resp.ReplyTok, hash) {
+			return false
+		}
+		record = &resp.Record
+		return true
+	})
+	t.write(toaddr, "ENRREQUEST/v4", packet)
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
 // pending adds a reply callback to the pending reply queue.
 // see the documentation of type pending for a detailed explanation.
 func (t *udp) pending(id NodeID, ptype byte, callback func(interface{}) bool) <-chan error {
@@ -464,8 +538,12 @@ func (t *udp) send(toaddr *net.UDPAddr, ptype byte, req packet) error {
 	if err != nil {
 		return err
 	}
-	_, err = t.conn.WriteToUDP(packet, toaddr)
-	log.Trace(">> "+req.name(), "addr", toaddr, "err", err)
+	return t.write(toaddr, req.name(), packet)
+}
+
+func (t *udp) write(toaddr *net.UDPAddr, what string, packet []byte) error {
+	_, err := t.conn.WriteToUDP(packet, toaddr)
+	log.Trace(">> "+what, "addr", toaddr, "err", err)
 	return err
 }
 
@@ -547,6 +625,10 @@ func decodePacket(buf []byte) (packet, NodeID, []byte, error) {
 		req = new(findnode)
 	case neighborsPacket:
 		req = new(neighbors)
+	case enrRequestPacket:
+		req = new(enrRequest)
+	case enrResponsePacket:
+		req = new(enrResponse)
 	default:
 		return nil, fromID, hash, fmt.Errorf("unknown type: %d", ptype)
 	}
@@ -563,6 +645,7 @@ func (req *ping) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) er
 		To:         makeEndpoint(from, req.From.TCP),
 		ReplyTok:   mac,
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		Rest:       t.seqTail(),
 	})
 	if !t.handleReply(fromID, pingPacket, req) {
 		// Note: we're ignoring the provided IP address right now
@@ -634,6 +717,31 @@ func (req *neighbors) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byt
 
 func (req *neighbors) name() string { return "NEIGHBORS/v4" }
 
+func (req *enrRequest) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error {
+	if expired(req.Expiration) {
+		return errExpired
+	}
+	// Unlike findnode, no bond is required here. The reply is a single
+	// packet bounded by enr.SizeLimit, so it can't be used to amplify
+	// traffic in any meaningful way, and requiring a bond would race
+	// with the bonding process which requests the record.
+	return t.send(from, enrResponsePacket, &enrResponse{
+		ReplyTok: mac,
+		Record:   *t.rec.record(),
+	})
+}
+
+func (req *enrRequest) name() string { return "ENRREQUEST/v4" }
+
+func (req *enrResponse) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error {
+	if !t.handleReply(fromID, enrResponsePacket, req) {
+		return errUnsolicitedReply
+	}
+	return nil
+}
+
+func (req *enrResponse) name() string { return "ENRRESPONSE/v4" }
+
 func expired(ts uint64) bool {
 	return time.Unix(int64(ts), 0).Before(time.Now())
 }
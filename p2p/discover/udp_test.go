@@ -36,6 +36,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 	"github.com/bazacoin/go-bazacoin/rlp"
 )
 
@@ -135,7 +136,7 @@ func TestUDP_pingTimeout(t *testing.T) {
 
 	toaddr := &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 2222}
 	toid := NodeID{1, 2, 3, 4}
-	if err := test.udp.ping(toid, toaddr); err != errTimeout {
+	if _, err := test.udp.ping(toid, toaddr); err != errTimeout {
 		t.Error("expected timeout error, got", err)
 	}
 }
@@ -347,6 +348,9 @@ func TestUDP_successfulPing(t *testing.T) {
 		if !reflect.DeepEqual(p.To, wantTo) {
 			t.Errorf("got pong.To %v, want %v", p.To, wantTo)
 		}
+		if seq, want := tailSeq(p.Rest), test.table.Record().Seq(); seq != want {
+			t.Errorf("got pong record seq %d, want %d", seq, want)
+		}
 	})
 
 	// remote is unknown, the table pings back.
@@ -388,6 +392,83 @@ func TestUDP_successfulPing(t *testing.T) {
 	}
 }
 
+func TestUDP_enrRequest(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	// The record is served without a bond.
+	test.packetIn(nil, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.waitPacketOut(func(p *enrResponse) {
+		reqhash := test.sent[0][:macSize]
+		if !bytes.Equal(p.ReplyTok, reqhash) {
+			t.Errorf("got enrResponse.ReplyTok %x, want %x", p.ReplyTok, reqhash)
+		}
+		n, err := NodeFromRecord(&p.Record)
+		if err != nil {
+			t.Fatalf("invalid record: %v", err)
+		}
+		if n.ID != test.table.Self().ID {
+			t.Errorf("record has wrong ID: got %v, want %v", n.ID, test.table.Self().ID)
+		}
+		if p.Record.Seq() != test.table.Record().Seq() {
+			t.Errorf("wrong record seq: got %d, want %d", p.Record.Seq(), test.table.Record().Seq())
+		}
+	})
+
+	// Updating the record increments the sequence number.
+	seq := test.table.Record().Seq()
+	if err := test.table.SetRecordEntries(enr.WithEntry("foo", "bar")); err != nil {
+		t.Fatal(err)
+	}
+	if test.table.Record().Seq() != seq+1 {
+		t.Errorf("record seq not incremented: got %d, want %d", test.table.Record().Seq(), seq+1)
+	}
+}
+
+func TestUDP_requestENR(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	var remote enr.Record
+	remote.Set(enr.IP(test.remoteaddr.IP))
+	remote.Set(enr.UDP(test.remoteaddr.Port))
+	if err := remote.Sign(test.remotekey); err != nil {
+		t.Fatal(err)
+	}
+	type result struct {
+		r   *enr.Record
+		err error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		r, err := test.udp.requestENR(PubkeyID(&test.remotekey.PublicKey), test.remoteaddr)
+		resc <- result{r, err}
+	}()
+
+	// Answer the request with the remote record.
+	dgram := test.pipe.waitPacketOut()
+	p, _, hash, err := decodePacket(dgram)
+	if err != nil {
+		t.Fatalf("sent packet decode error: %v", err)
+	}
+	if _, ok := p.(*enrRequest); !ok {
+		t.Fatalf("sent packet type mismatch, got: %T, want: *enrRequest", p)
+	}
+	test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: hash, Record: remote})
+
+	res := <-resc
+	if res.err != nil {
+		t.Fatalf("requestENR error: %v", res.err)
+	}
+	n, err := NodeFromRecord(res.r)
+	if err != nil {
+		t.Fatalf("invalid record: %v", err)
+	}
+	if n.ID != PubkeyID(&test.remotekey.PublicKey) || !n.IP.Equal(test.remoteaddr.IP) {
+		t.Errorf("wrong node from record: %v", n)
+	}
+}
+
 var testPackets = []struct {
 	input      string
 	wantPacket interface{}
@@ -64,6 +64,8 @@ var (
 	nodeDBDiscoverFindFails     = nodeDBDiscoverRoot + ":findfail"
 	nodeDBDiscoverLocalEndpoint = nodeDBDiscoverRoot + ":localendpoint"
 	nodeDBTopicRegTickets       = ":tickets"
+
+	nodeDBLocalSeq = ":local:seq"
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
@@ -325,6 +327,16 @@ func (db *nodeDB) updateLocalEndpoint(id NodeID, ep rpcEndpoint) error {
 	return db.storeRLP(makeKey(id, nodeDBDiscoverLocalEndpoint), &ep)
 }
 
+// localSeq retrieves the sequence number of the local node record.
+func (db *nodeDB) localSeq() uint64 {
+	return uint64(db.fetchInt64(makeKey(db.self, nodeDBLocalSeq)))
+}
+
+// storeLocalSeq stores the sequence number of the local node record.
+func (db *nodeDB) storeLocalSeq(seq uint64) error {
+	return db.storeInt64(makeKey(db.self, nodeDBLocalSeq), int64(seq))
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
@@ -29,6 +29,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/crypto/sha3"
 	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 	"github.com/bazacoin/go-bazacoin/p2p/nat"
 	"github.com/bazacoin/go-bazacoin/p2p/netutil"
 	"github.com/bazacoin/go-bazacoin/rlp"
@@ -80,6 +81,7 @@ type Network struct {
 	topicSearchReq   chan topicSearchReq
 
 	// State of the main loop.
+	rec           *localRecord // signed record of the local node, nil in tests
 	tab           *Table
 	topictab      *topicTable
 	ticketStore   *ticketStore
@@ -194,6 +196,25 @@ func (net *Network) Self() *Node {
 	return net.tab.self
 }
 
+// Record returns the signed record of the local node.
+// The returned record should not be modified by the caller.
+func (net *Network) Record() *enr.Record {
+	if net.rec == nil {
+		return nil
+	}
+	return net.rec.record()
+}
+
+// SetRecordEntries adds or updates entries of the local node record.
+// The record is signed again with an incremented sequence number and
+// will be served to nodes requesting it from then on.
+func (net *Network) SetRecordEntries(entries ...enr.Entry) error {
+	if net.rec == nil {
+		return errNoRecord
+	}
+	return net.rec.set(entries...)
+}
+
 // ReadRandomNodes fills the given slice with random nodes from the
 // table. It will not write the same node more than once. The nodes in
 // the slice are copies and can be modified by the caller.
@@ -778,6 +799,7 @@ type nodeNetGuts struct {
 	state             *nodeState
 	pingEcho          []byte           // hash of last ping sent by us
 	pingTopics        []Topic          // topic set sent by us in last ping
+	enrEcho           []byte           // hash of last enrRequest sent by us
 	deferredQueries   []*findnodeQuery // queries that can't be sent yet
 	pendingNeighbours *findnodeQuery   // current query, waiting for reply
 	queryTimeouts     int
@@ -838,6 +860,8 @@ const (
 	topicRegisterPacket
 	topicQueryPacket
 	topicNodesPacket
+	enrRequestPacket
+	enrResponsePacket
 
 	// Non-packet events.
 	// Event values in this category are allocated outside
@@ -1043,6 +1067,13 @@ func (net *Network) handle(n *Node, ev nodeEvent, pkt *ingressPacket) error {
 			net.db.ensureExpirer()
 		}
 	}
+	// Node records are exchanged regardless of the node state.
+	switch ev {
+	case enrRequestPacket:
+		return net.handleENRRequest(n, pkt)
+	case enrResponsePacket:
+		return net.handleENRResponse(n, pkt)
+	}
 	if n.state == nil {
 		n.state = unknown //???
 	}
@@ -1064,6 +1095,11 @@ func (net *Network) checkPacket(n *Node, ev nodeEvent, pkt *ingressPacket) error
 			return fmt.Errorf("pong reply token mismatch")
 		}
 		n.pingEcho = nil
+	case enrResponsePacket:
+		if n.enrEcho == nil || !bytes.Equal(pkt.data.(*enrResponse).ReplyTok, n.enrEcho) {
+			return fmt.Errorf("enr response reply token mismatch")
+		}
+		n.enrEcho = nil
 	}
 	// Address validation.
 	// TODO: Ideally we would do the following:
@@ -1125,6 +1161,7 @@ func (net *Network) handlePing(n *Node, pkt *ingressPacket) {
 		To:         makeEndpoint(n.addr(), n.TCP), // TODO: maybe use known TCP port from DB
 		ReplyTok:   pkt.hash,
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		Rest:       net.seqTail(),
 	}
 	ticketToPong(t, pong)
 	net.conn.send(n, pongPacket, pong)
@@ -1144,9 +1181,55 @@ func (net *Network) handleKnownPong(n *Node, pkt *ingressPacket) error {
 
 	n.pingEcho = nil
 	n.pingTopics = nil
+
+	// Fetch the node record if the node advertises a newer one.
+	if seq := tailSeq(pkt.data.(*pong).Rest); seq > 0 && (n.record == nil || seq > n.record.Seq()) {
+		net.requestENR(n)
+	}
 	return err
 }
 
+// requestENR asks n for its node record. Lost requests aren't retried, the
+// record is requested again when the next pong advertises it.
+func (net *Network) requestENR(n *Node) {
+	debugLog(fmt.Sprintf("requestENR(node = %x)", n.ID[:8]))
+	n.enrEcho = net.conn.send(n, enrRequestPacket, &enrRequest{
+		Expiration: uint64(time.Now().Add(expiration).Unix()),
+	})
+}
+
+func (net *Network) handleENRRequest(n *Node, pkt *ingressPacket) error {
+	if net.rec == nil {
+		return errNoRecord
+	}
+	if time.Unix(int64(pkt.data.(*enrRequest).Expiration), 0).Before(time.Now()) {
+		return errExpired
+	}
+	// No verification of the sender is required here. The reply is a single
+	// packet bounded by enr.SizeLimit, so it can't be used to amplify traffic
+	// in any meaningful way.
+	net.conn.send(n, enrResponsePacket, &enrResponse{
+		ReplyTok: pkt.hash,
+		Record:   *net.rec.record(),
+	})
+	return nil
+}
+
+func (net *Network) handleENRResponse(n *Node, pkt *ingressPacket) error {
+	record := &pkt.data.(*enrResponse).Record
+	rn, err := NodeFromRecord(record)
+	if err != nil {
+		return fmt.Errorf("invalid node record: %v", err)
+	}
+	if rn.ID != n.ID {
+		return errors.New("node record of wrong node")
+	}
+	if n.record == nil || record.Seq() > n.record.Seq() {
+		n.record = record
+	}
+	return nil
+}
+
 func (net *Network) handleQueryEvent(n *Node, ev nodeEvent, pkt *ingressPacket) (*nodeState, error) {
 	switch ev {
 	case findnodePacket:
@@ -17,6 +17,7 @@
 package discv5
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"net"
 	"testing"
@@ -24,8 +25,52 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 )
 
+// Tests that nodes exchange their records once they have verified each other.
+func TestNetwork_RecordExchange(t *testing.T) {
+	var (
+		keys = []*ecdsa.PrivateKey{newkey(), newkey()}
+		nets = make([]*Network, len(keys))
+	)
+	for i, key := range keys {
+		network, err := ListenUDP(key, "127.0.0.1:0", nil, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer network.Close()
+		nets[i] = network
+	}
+	if err := nets[1].SetRecordEntries(enr.TCP(30303)); err != nil {
+		t.Fatalf("failed to update record: %v", err)
+	}
+	addr := nets[1].conn.localAddr()
+	seed := NewNode(nets[1].Self().ID, addr.IP, uint16(addr.Port), uint16(addr.Port))
+	if err := nets[0].SetFallbackNodes([]*Node{seed}); err != nil {
+		t.Fatal(err)
+	}
+	// Both nodes should eventually know the latest record of the other
+	for i, network := range nets {
+		remote := nets[1-i]
+		var record *enr.Record
+		for deadline := time.Now().Add(5 * time.Second); record == nil && time.Now().Before(deadline); {
+			time.Sleep(50 * time.Millisecond)
+			network.reqTableOp(func() {
+				if n := network.nodes[remote.Self().ID]; n != nil {
+					record = n.Record()
+				}
+			})
+		}
+		if record == nil {
+			t.Fatalf("node %d: record of remote node not received", i)
+		}
+		if record.Seq() != remote.Record().Seq() {
+			t.Errorf("node %d: record seq mismatch: have %d, want %d", i, record.Seq(), remote.Record().Seq())
+		}
+	}
+}
+
 func TestNetwork_Lookup(t *testing.T) {
 	key, _ := crypto.GenerateKey()
 	network, err := newNetwork(lookupTestnet, key.PublicKey, nil, "", nil)
@@ -32,6 +32,7 @@ import (
 
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 )
 
 // Node represents a host on the network.
@@ -41,6 +42,9 @@ type Node struct {
 	UDP, TCP uint16 // port numbers
 	ID       NodeID // the node's public key
 
+	// the signed node record, if one is known
+	record *enr.Record
+
 	// Network-related fields are contained in nodeNetGuts.
 	// These fields are not supposed to be used off the
 	// Network.loop goroutine.
@@ -62,6 +66,42 @@ func NewNode(id NodeID, ip net.IP, udpPort, tcpPort uint16) *Node {
 	}
 }
 
+// NodeFromRecord creates a node from a signed node record. The record
+// must contain a secp256k1 public key. IP address and ports are optional.
+func NodeFromRecord(r *enr.Record) (*Node, error) {
+	if !r.Signed() {
+		return nil, errors.New("record is not signed")
+	}
+	var (
+		key enr.Secp256k1
+		ip  enr.IP
+		udp enr.UDP
+		tcp enr.TCP
+	)
+	if err := r.Load(&key); err != nil {
+		return nil, err
+	}
+	if err := r.Load(&ip); err != nil && !enr.IsNotFound(err) {
+		return nil, err
+	}
+	if err := r.Load(&udp); err != nil && !enr.IsNotFound(err) {
+		return nil, err
+	}
+	if err := r.Load(&tcp); err != nil && !enr.IsNotFound(err) {
+		return nil, err
+	}
+	n := NewNode(PubkeyID((*ecdsa.PublicKey)(&key)), net.IP(ip), uint16(udp), uint16(tcp))
+	n.record = r
+	return n, nil
+}
+
+// Record returns the node record of n. The return value is nil
+// if no record is known for the node. The returned record must
+// not be modified.
+func (n *Node) Record() *enr.Record {
+	return n.record
+}
+
 func (n *Node) addr() *net.UDPAddr {
 	return &net.UDPAddr{IP: n.IP, Port: int(n.UDP)}
 }
@@ -148,7 +188,17 @@ var incompleteNodeURL = regexp.MustCompile("(?i)^(?:enode://)?([0-9a-f]+)$")
 // and UDP discovery port 30301.
 //
 //    enode://<hex node id>@10.3.58.6:30303?discport=30301
+//
+// ParseNode also accepts the text form of a signed node record,
+// which starts with "enr:".
 func ParseNode(rawurl string) (*Node, error) {
+	if strings.HasPrefix(rawurl, "enr:") {
+		var r enr.Record
+		if err := r.UnmarshalText([]byte(rawurl)); err != nil {
+			return nil, fmt.Errorf("invalid node record (%v)", err)
+		}
+		return NodeFromRecord(&r)
+	}
 	if m := incompleteNodeURL.FindStringSubmatch(rawurl); m != nil {
 		id, err := HexID(m[1])
 		if err != nil {
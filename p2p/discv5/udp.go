@@ -27,6 +27,7 @@ import (
 	"github.com/bazacoin/go-bazacoin/common"
 	"github.com/bazacoin/go-bazacoin/crypto"
 	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 	"github.com/bazacoin/go-bazacoin/p2p/nat"
 	"github.com/bazacoin/go-bazacoin/p2p/netutil"
 	"github.com/bazacoin/go-bazacoin/rlp"
@@ -131,6 +132,21 @@ type (
 		Nodes []rpcNode
 	}
 
+	// enrRequest queries the node record of the recipient.
+	enrRequest struct {
+		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
+	// enrResponse is the reply to enrRequest.
+	enrResponse struct {
+		ReplyTok []byte // Hash of the enrRequest packet.
+		Record   enr.Record
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
 	rpcNode struct {
 		IP  net.IP // len 4 for IPv4 or 16 for IPv6
 		UDP uint16 // for discovery protocol
@@ -246,6 +262,12 @@ func ListenUDP(priv *ecdsa.PrivateKey, laddr string, natm nat.Interface, nodeDBP
 	if err != nil {
 		return nil, err
 	}
+	rec, err := newLocalRecord(priv, net.db, transport.ourEndpoint)
+	if err != nil {
+		net.Close()
+		return nil, err
+	}
+	net.reqTableOp(func() { net.rec = rec })
 	transport.net = net
 	go transport.readLoop()
 	return net, nil
@@ -283,6 +305,7 @@ func (t *udp) sendPing(remote *Node, toaddr *net.UDPAddr, topics []Topic) (hash
 		To:         makeEndpoint(toaddr, uint16(toaddr.Port)), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
 		Topics:     topics,
+		Rest:       t.net.seqTail(),
 	})
 	return hash
 }
@@ -449,6 +472,10 @@ func decodePacket(buffer []byte, pkt *ingressPacket) error {
 		pkt.data = new(topicQuery)
 	case topicNodesPacket:
 		pkt.data = new(topicNodes)
+	case enrRequestPacket:
+		pkt.data = new(enrRequest)
+	case enrResponsePacket:
+		pkt.data = new(enrResponse)
 	default:
 		return fmt.Errorf("unknown packet type: %d", sigdata[0])
 	}
@@ -0,0 +1,361 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dnsdisc implements node discovery via DNS.
+//
+// Node lists are published as a merkle tree of TXT records. The root of
+// the tree is signed by the list operator and lives at the domain
+// itself; all other entries live at subdomains named after the hash of
+// their content. A list is referenced by an URL of the form
+//
+//	enrtree://<base32 compressed public key>@<domain>
+//
+// The tree can also link to other lists, which are followed by the client.
+package dnsdisc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/log"
+	"github.com/bazacoin/go-bazacoin/p2p/discover"
+	"github.com/hashicorp/golang-lru"
+)
+
+// Client discovers nodes by querying DNS servers.
+type Client struct {
+	cfg     Config
+	entries *lru.Cache
+	links   []*linkEntry
+
+	mu    sync.Mutex
+	trees map[string]*clientTree // synced trees by domain
+
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+// clientTree is a synced node list.
+type clientTree struct {
+	root  rootEntry
+	nodes []*discover.Node
+	links []*linkEntry
+}
+
+// Config holds configuration options for the client.
+type Config struct {
+	Timeout         time.Duration // timeout used for DNS lookups (default 5s)
+	RecheckInterval time.Duration // time between tree root update checks (default 30min)
+	CacheLimit      int           // maximum number of cached tree entries (default 1000)
+	Resolver        Resolver      // the DNS resolver to use (defaults to system DNS)
+}
+
+// Resolver is a DNS resolver that can query TXT records.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+func (cfg Config) withDefaults() Config {
+	const (
+		defaultTimeout = 5 * time.Second
+		defaultRecheck = 30 * time.Minute
+		defaultCache   = 1000
+	)
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.RecheckInterval == 0 {
+		cfg.RecheckInterval = defaultRecheck
+	}
+	if cfg.CacheLimit == 0 {
+		cfg.CacheLimit = defaultCache
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = new(net.Resolver)
+	}
+	return cfg
+}
+
+// NewClient creates a client. The node lists at the given URLs, and all
+// lists linked from them, are synced in the background. Their nodes can
+// be retrieved using ReadRandomNodes.
+func NewClient(cfg Config, urls ...string) (*Client, error) {
+	c := &Client{
+		cfg:    cfg.withDefaults(),
+		trees:  make(map[string]*clientTree),
+		closed: make(chan struct{}),
+	}
+	var err error
+	if c.entries, err = lru.New(c.cfg.CacheLimit); err != nil {
+		return nil, err
+	}
+	for _, url := range urls {
+		le, err := parseLink(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enrtree URL %q: %v", url, err)
+		}
+		c.links = append(c.links, le)
+	}
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	go c.loop(ctx)
+	return c, nil
+}
+
+// Close stops background syncing.
+func (c *Client) Close() {
+	c.cancel()
+	<-c.closed
+}
+
+// SyncTree downloads the entire node tree at the given URL. It doesn't
+// follow links to other trees.
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	le, err := parseLink(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enrtree URL: %v", err)
+	}
+	return c.syncTree(context.Background(), le)
+}
+
+// ReadRandomNodes fills the given slice with random nodes from the
+// synced node lists. The nodes in the slice are copies and can be
+// modified by the caller.
+func (c *Client) ReadRandomNodes(buf []*discover.Node) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var all []*discover.Node
+	for _, t := range c.trees {
+		all = append(all, t.nodes...)
+	}
+	n := 0
+	for _, i := range rand.Perm(len(all)) {
+		if n == len(buf) {
+			break
+		}
+		cpy := *all[i]
+		buf[n] = &cpy
+		n++
+	}
+	return n
+}
+
+// loop syncs all configured lists until the client is closed.
+func (c *Client) loop(ctx context.Context) {
+	defer close(c.closed)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			c.syncLists(ctx)
+			timer.Reset(c.cfg.RecheckInterval)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// syncLists updates all configured trees and the trees linked from them.
+// Trees which are no longer reachable are dropped.
+func (c *Client) syncLists(ctx context.Context) {
+	var (
+		seen  = make(map[string]bool)
+		queue = append([]*linkEntry{}, c.links...)
+	)
+	for len(queue) > 0 && ctx.Err() == nil {
+		le := queue[0]
+		queue = queue[1:]
+		if seen[le.domain] {
+			continue
+		}
+		seen[le.domain] = true
+
+		t, err := c.updateTree(ctx, le)
+		if err != nil {
+			log.Debug("Can't sync DNS node list", "domain", le.domain, "err", err)
+			// Keep using the previous version of the tree.
+			c.mu.Lock()
+			t = c.trees[le.domain]
+			c.mu.Unlock()
+			if t == nil {
+				continue
+			}
+		}
+		queue = append(queue, t.links...)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	c.mu.Lock()
+	for domain := range c.trees {
+		if !seen[domain] {
+			delete(c.trees, domain)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// updateTree syncs the tree at le if its root has changed.
+func (c *Client) updateTree(ctx context.Context, le *linkEntry) (*clientTree, error) {
+	root, err := c.resolveRoot(ctx, le)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	old := c.trees[le.domain]
+	c.mu.Unlock()
+	if old != nil && old.root.eroot == root.eroot && old.root.lroot == root.lroot {
+		return old, nil
+	}
+
+	tree, err := c.syncRoot(ctx, le, root)
+	if err != nil {
+		return nil, err
+	}
+	t := &clientTree{root: root}
+	for _, r := range tree.Nodes() {
+		n, err := discover.NodeFromRecord(r)
+		if err != nil || n.Incomplete() || n.TCP == 0 {
+			log.Trace("Skipping unusable node record", "domain", le.domain, "err", err)
+			continue
+		}
+		t.nodes = append(t.nodes, n)
+	}
+	for _, e := range tree.entries {
+		if link, ok := e.(*linkEntry); ok {
+			t.links = append(t.links, link)
+		}
+	}
+	log.Debug("Synced DNS node list", "domain", le.domain, "seq", root.seq, "nodes", len(t.nodes), "links", len(t.links))
+
+	c.mu.Lock()
+	c.trees[le.domain] = t
+	c.mu.Unlock()
+	return t, nil
+}
+
+func (c *Client) syncTree(ctx context.Context, le *linkEntry) (*Tree, error) {
+	root, err := c.resolveRoot(ctx, le)
+	if err != nil {
+		return nil, err
+	}
+	return c.syncRoot(ctx, le, root)
+}
+
+func (c *Client) syncRoot(ctx context.Context, le *linkEntry, root rootEntry) (*Tree, error) {
+	t := &Tree{root: &root, entries: make(map[string]entry)}
+	if err := c.syncAll(ctx, le.domain, root.eroot, t.entries, false); err != nil {
+		return nil, err
+	}
+	if err := c.syncAll(ctx, le.domain, root.lroot, t.entries, true); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncAll downloads the subtree at hash into entries. Subtrees already present
+// in entries are skipped, so shared subtrees are only fetched once.
+func (c *Client) syncAll(ctx context.Context, domain, hash string, entries map[string]entry, links bool) error {
+	if _, ok := entries[hash]; ok {
+		return nil
+	}
+	e, err := c.resolveEntry(ctx, domain, hash)
+	if err != nil {
+		return err
+	}
+	entries[hash] = e
+	switch e := e.(type) {
+	case *branchEntry:
+		for _, child := range e.children {
+			if err := c.syncAll(ctx, domain, child, entries, links); err != nil {
+				return err
+			}
+		}
+	case *enrEntry:
+		if links {
+			return nameError{hash + "." + domain, errENRInLinkTree}
+		}
+	case *linkEntry:
+		if !links {
+			return nameError{hash + "." + domain, errLinkInENRTree}
+		}
+	}
+	return nil
+}
+
+// resolveRoot retrieves a root entry and verifies its signature.
+func (c *Client) resolveRoot(ctx context.Context, le *linkEntry) (rootEntry, error) {
+	txts, err := c.lookupTXT(ctx, le.domain)
+	if err != nil {
+		return rootEntry{}, err
+	}
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, rootPrefix) {
+			continue
+		}
+		root, err := parseRoot(txt)
+		if err != nil {
+			return rootEntry{}, nameError{le.domain, err}
+		}
+		if !root.verifySignature(le.pubkey) {
+			return rootEntry{}, nameError{le.domain, errRootSig}
+		}
+		return root, nil
+	}
+	return rootEntry{}, nameError{le.domain, errNoRoot}
+}
+
+// resolveEntry retrieves an entry from the cache or fetches it from the network
+// if it isn't cached.
+func (c *Client) resolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	if e, ok := c.entries.Get(hash); ok {
+		return e.(entry), nil
+	}
+	name := hash + "." + domain
+	txts, err := c.lookupTXT(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		e, err := parseEntry(txt)
+		if err == errUnknownEntry {
+			continue
+		}
+		if err != nil {
+			return nil, nameError{name, err}
+		}
+		if subdomain(e) != hash {
+			return nil, nameError{name, errHashMismatch}
+		}
+		c.entries.Add(hash, e)
+		return e, nil
+	}
+	return nil, nameError{name, errNoEntry}
+}
+
+func (c *Client) lookupTXT(ctx context.Context, name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	return c.cfg.Resolver.LookupTXT(ctx, name)
+}
@@ -0,0 +1,250 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/p2p/discover"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
+)
+
+func TestClientSyncTree(t *testing.T) {
+	key := testKey()
+	nodes := testNodes(3)
+	tree, url := makeTestTree(t, key, "n", nodes, nil)
+	r := mapResolver(tree.ToTXT("n"))
+
+	c, err := NewClient(Config{Resolver: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	stree, err := c.SyncTree(url)
+	if err != nil {
+		t.Fatal("sync error:", err)
+	}
+	if !reflect.DeepEqual(recordStrings(stree.Nodes()), recordStrings(tree.Nodes())) {
+		t.Errorf("wrong nodes in synced tree:\ngot  %v\nwant %v", stree.Nodes(), tree.Nodes())
+	}
+	if stree.Seq() != tree.Seq() {
+		t.Errorf("synced tree has wrong seq: got %d, want %d", stree.Seq(), tree.Seq())
+	}
+}
+
+// This test checks that syncing fails if the tree is signed by a
+// different key than the one in the URL.
+func TestClientSyncTreeBadSig(t *testing.T) {
+	tree, _ := makeTestTree(t, testKey(), "n", testNodes(1), nil)
+	_, url := makeTestTree(t, testKey(), "n", nil, nil)
+	r := mapResolver(tree.ToTXT("n"))
+
+	c, err := NewClient(Config{Resolver: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	wantErr := nameError{"n", errRootSig}
+	if _, err := c.SyncTree(url); err != wantErr {
+		t.Fatalf("expected error %q, got %v", wantErr, err)
+	}
+}
+
+// This test checks that the client rejects entries whose hash
+// doesn't match their content.
+func TestClientSyncTreeBadEntry(t *testing.T) {
+	tree, url := makeTestTree(t, testKey(), "n", testNodes(3), nil)
+	r := mapResolver(tree.ToTXT("n"))
+	for name := range r {
+		if name != "n" {
+			r[name] = testNodes(1)[0].String()
+			break
+		}
+	}
+	c, err := NewClient(Config{Resolver: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.SyncTree(url); err == nil {
+		t.Fatal("expected error for corrupt tree")
+	}
+}
+
+// This test checks that subtrees referenced by several branches are
+// only fetched once.
+func TestClientSyncTreeDiamond(t *testing.T) {
+	const depth = 10
+	key := testKey()
+	tree, _ := makeTestTree(t, key, "n", testNodes(1), nil)
+
+	// Stack branches whose children both point to the branch below.
+	hash := tree.root.eroot
+	for i := 0; i < depth; i++ {
+		b := &branchEntry{[]string{hash, hash}}
+		hash = subdomain(b)
+		tree.entries[hash] = b
+	}
+	tree.root.eroot = hash
+	url, err := tree.Sign(key, "n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &countResolver{r: mapResolver(tree.ToTXT("n")), lookups: make(map[string]int)}
+
+	// Keep the cache tiny so repeated visits would hit the resolver.
+	c, err := NewClient(Config{Resolver: r, CacheLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	stree, err := c.SyncTree(url)
+	if err != nil {
+		t.Fatal("sync error:", err)
+	}
+	if !reflect.DeepEqual(recordStrings(stree.Nodes()), recordStrings(tree.Nodes())) {
+		t.Errorf("wrong nodes in synced tree:\ngot  %v\nwant %v", stree.Nodes(), tree.Nodes())
+	}
+	for name, n := range r.lookups {
+		if n > 1 {
+			t.Errorf("%s resolved %d times", name, n)
+		}
+	}
+}
+
+// This test checks that nodes of linked trees are found by the
+// background sync.
+func TestClientLinks(t *testing.T) {
+	var (
+		nodesA = testNodes(2)
+		nodesB = testNodes(3)
+	)
+	treeB, urlB := makeTestTree(t, testKey(), "b", nodesB, nil)
+	treeA, urlA := makeTestTree(t, testKey(), "a", nodesA, []string{urlB})
+	r := mapResolver(treeA.ToTXT("a"))
+	for name, txt := range treeB.ToTXT("b") {
+		r[name] = txt
+	}
+
+	c, err := NewClient(Config{Resolver: r}, urlA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	want := make(map[discover.NodeID]bool)
+	for _, r := range append(nodesA, nodesB...) {
+		n, _ := discover.NodeFromRecord(r)
+		want[n.ID] = true
+	}
+	buf := make([]*discover.Node, 10)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n := c.ReadRandomNodes(buf)
+		if n == len(want) {
+			for _, node := range buf[:n] {
+				if !want[node.ID] {
+					t.Errorf("unexpected node %v", node)
+				}
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d nodes, want %d", n, len(want))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func makeTestTree(t *testing.T, key *ecdsa.PrivateKey, domain string, nodes []*enr.Record, links []string) (*Tree, string) {
+	tree, err := MakeTree(1, nodes, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree, url
+}
+
+func testKey() *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func testNodes(n int) []*enr.Record {
+	nodes := make([]*enr.Record, n)
+	for i := range nodes {
+		var r enr.Record
+		r.Set(enr.IP(net.IP{127, 0, 0, byte(i + 1)}))
+		r.Set(enr.UDP(30303))
+		r.Set(enr.TCP(30303))
+		if err := r.Sign(testKey()); err != nil {
+			panic(err)
+		}
+		nodes[i] = &r
+	}
+	return nodes
+}
+
+func recordStrings(rs []*enr.Record) []string {
+	s := make([]string, len(rs))
+	for i, r := range rs {
+		s[i] = r.String()
+	}
+	return s
+}
+
+// mapResolver is an in-memory stand-in for DNS.
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, fmt.Errorf("not found: %s", name)
+}
+
+// countResolver counts the lookups of each name.
+type countResolver struct {
+	r       Resolver
+	mu      sync.Mutex
+	lookups map[string]int
+}
+
+func (cr *countResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	cr.mu.Lock()
+	cr.lookups[name]++
+	cr.mu.Unlock()
+	return cr.r.LookupTXT(ctx, name)
+}
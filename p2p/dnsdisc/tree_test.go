@@ -0,0 +1,99 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMakeTree(t *testing.T) {
+	nodes := testNodes(50)
+	tree, err := MakeTree(2, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortByAddr(nodes)
+	if !reflect.DeepEqual(recordStrings(tree.Nodes()), recordStrings(nodes)) {
+		t.Error("tree does not contain all nodes")
+	}
+	// Every entry must fit into a TXT record and be reachable by its hash.
+	for name, txt := range tree.ToTXT("") {
+		if name == "" {
+			continue
+		}
+		if strings.HasPrefix(txt, branchPrefix) && len(txt) > 370 {
+			t.Errorf("entry %s too large: %d bytes", name, len(txt))
+		}
+		e, err := parseEntry(txt)
+		if err != nil {
+			t.Fatalf("can't parse entry %s: %v", name, err)
+		}
+		if subdomain(e) != name {
+			t.Errorf("entry %s has wrong hash %s", name, subdomain(e))
+		}
+	}
+}
+
+func TestParseRoot(t *testing.T) {
+	key := testKey()
+	tree, url := makeTestTree(t, key, "nodes.example.org", testNodes(2), nil)
+
+	root, err := parseRoot(tree.root.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(root, *tree.root) {
+		t.Errorf("root mismatch:\ngot  %+v\nwant %+v", root, *tree.root)
+	}
+	if !root.verifySignature(&key.PublicKey) {
+		t.Error("root signature does not verify")
+	}
+	if root.verifySignature(&testKey().PublicKey) {
+		t.Error("root signature verifies with wrong key")
+	}
+
+	domain, pubkey, err := ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain != "nodes.example.org" || !reflect.DeepEqual(*pubkey, key.PublicKey) {
+		t.Errorf("wrong URL components: %s %v", domain, pubkey)
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{input: "enrtree-branch:", err: nil},
+		{input: "enrtree-branch:AAAAAAAAAAAAAAAAAAAAAAAAAA", err: nil},
+		{input: "enrtree-branch:AAAAAAAAAA", err: entryError{"branch", errInvalidChild}},
+		{input: "enrtree://AM5FCQLWIZX2QFPNJAP7VUERCCRNGRHWZG3YYHIUV7BVDQ5FDPRT2@nodes.example.org", err: nil},
+		{input: "enrtree://nodes.example.org", err: entryError{"link", errNoPubkey}},
+		{input: "enrtree://AAAA@nodes.example.org", err: entryError{"link", errBadPubkey}},
+		{input: "foo", err: errUnknownEntry},
+	}
+	for _, test := range tests {
+		_, err := parseEntry(test.input)
+		if !reflect.DeepEqual(err, test.err) {
+			t.Errorf("%q: got error %v, want %v", test.input, err, test.err)
+		}
+	}
+}
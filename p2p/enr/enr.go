@@ -0,0 +1,343 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package enr implements signed node records.
+//
+// A node record holds arbitrary information about a node on the peer-to-peer
+// network. Node information is stored in key/value pairs. To store and retrieve
+// key/values in a record, use the Entry interface.
+//
+// Records must be signed before transmitting them to another node. Decoding a
+// record verifies its signature. When creating a record, set the entries you
+// want, then call Sign to add the signature. Modifying a record invalidates the
+// signature.
+//
+// The only signature scheme currently supported is "v4": the record is signed
+// with a secp256k1 key over the keccak256 hash of its content, and the compressed
+// public key of the signer is stored under the "secp256k1" key.
+//
+// Records are exchanged in their RLP encoding. They can also be written down as
+// text using the "enr:" prefix followed by the URL-safe base64 encoding of the
+// RLP, which is what MarshalText and UnmarshalText produce and consume.
+package enr
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/rlp"
+)
+
+// SizeLimit is the maximum encoded size of a node record in bytes.
+const SizeLimit = 300
+
+const sigLength = 64 // length of a v4 signature, [R || S]
+
+var (
+	errNoID           = errors.New("unknown or unspecified identity scheme")
+	errInvalidSig     = errors.New("invalid signature")
+	errNotSorted      = errors.New("record key/value pairs are not sorted by key")
+	errDuplicateKey   = errors.New("record contains duplicate key")
+	errIncompletePair = errors.New("record contains incomplete k/v pair")
+	errTooBig         = fmt.Errorf("record bigger than %d bytes", SizeLimit)
+	errEncodeUnsigned = errors.New("can't encode unsigned record")
+	errNotFound       = errors.New("no such key in record")
+	errNoPrefix       = errors.New("missing 'enr:' prefix")
+)
+
+// Record represents a node record. The zero value is an empty record.
+type Record struct {
+	seq       uint64 // sequence number
+	signature []byte // the signature
+	raw       []byte // RLP encoded record
+	pairs     []pair // sorted list of all key/value pairs
+}
+
+// pair is a key/value pair in a record.
+type pair struct {
+	k string
+	v rlp.RawValue
+}
+
+// Signed reports whether the record has a valid signature.
+func (r *Record) Signed() bool {
+	return r.signature != nil
+}
+
+// Seq returns the sequence number.
+func (r *Record) Seq() uint64 {
+	return r.seq
+}
+
+// SetSeq updates the record sequence number. This invalidates any signature on the record.
+// Calling SetSeq is usually not required because signing the record increments the
+// sequence number.
+func (r *Record) SetSeq(s uint64) {
+	r.signature = nil
+	r.raw = nil
+	r.seq = s
+}
+
+// Load retrieves the value of a key/value pair. The given Entry must be a pointer and will
+// be set to the value of the entry in the record.
+//
+// Errors returned by Load are wrapped in KeyError. You can distinguish decoding errors
+// from missing keys using the IsNotFound function.
+func (r *Record) Load(e Entry) error {
+	i := sort.Search(len(r.pairs), func(i int) bool { return r.pairs[i].k >= e.ENRKey() })
+	if i < len(r.pairs) && r.pairs[i].k == e.ENRKey() {
+		if err := rlp.DecodeBytes(r.pairs[i].v, e); err != nil {
+			return &KeyError{Key: e.ENRKey(), Err: err}
+		}
+		return nil
+	}
+	return &KeyError{Key: e.ENRKey(), Err: errNotFound}
+}
+
+// Set adds or updates the given entry in the record.
+// It panics if the value can't be encoded.
+func (r *Record) Set(e Entry) {
+	r.signature = nil
+	r.raw = nil
+	blob, err := rlp.EncodeToBytes(e)
+	if err != nil {
+		panic(fmt.Errorf("enr: can't encode %s: %v", e.ENRKey(), err))
+	}
+
+	i := sort.Search(len(r.pairs), func(i int) bool { return r.pairs[i].k >= e.ENRKey() })
+
+	if i < len(r.pairs) && r.pairs[i].k == e.ENRKey() {
+		// element is present at r.pairs[i]
+		r.pairs[i].v = blob
+		return
+	} else if i < len(r.pairs) {
+		// insert pair before i-th elem
+		el := pair{e.ENRKey(), blob}
+		r.pairs = append(r.pairs, pair{})
+		copy(r.pairs[i+1:], r.pairs[i:])
+		r.pairs[i] = el
+		return
+	}
+
+	// element should be placed at the end of r.pairs
+	r.pairs = append(r.pairs, pair{e.ENRKey(), blob})
+}
+
+// Keys returns the keys of all entries in the record, in sorted order.
+func (r *Record) Keys() []string {
+	keys := make([]string, len(r.pairs))
+	for i, p := range r.pairs {
+		keys[i] = p.k
+	}
+	return keys
+}
+
+// EncodeRLP implements rlp.Encoder. Encoding fails if
+// the record is unsigned.
+func (r Record) EncodeRLP(w io.Writer) error {
+	if !r.Signed() {
+		return errEncodeUnsigned
+	}
+	_, err := w.Write(r.raw)
+	return err
+}
+
+// DecodeRLP implements rlp.Decoder. Decoding verifies the signature.
+func (r *Record) DecodeRLP(s *rlp.Stream) error {
+	raw, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	if len(raw) > SizeLimit {
+		return errTooBig
+	}
+
+	// Decode the RLP container.
+	dec := Record{raw: raw}
+	s = rlp.NewStream(bytes.NewReader(raw), 0)
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	if err = s.Decode(&dec.signature); err != nil {
+		return err
+	}
+	if err = s.Decode(&dec.seq); err != nil {
+		return err
+	}
+	// The rest of the record contains sorted k/v pairs.
+	var prevkey string
+	for i := 0; ; i++ {
+		var kv pair
+		if err := s.Decode(&kv.k); err != nil {
+			if err == rlp.EOL {
+				break
+			}
+			return err
+		}
+		if err := s.Decode(&kv.v); err != nil {
+			if err == rlp.EOL {
+				return errIncompletePair
+			}
+			return err
+		}
+		if i > 0 {
+			if kv.k == prevkey {
+				return errDuplicateKey
+			}
+			if kv.k < prevkey {
+				return errNotSorted
+			}
+		}
+		dec.pairs = append(dec.pairs, kv)
+		prevkey = kv.k
+	}
+	if err := s.ListEnd(); err != nil {
+		return err
+	}
+
+	// Verify signature.
+	if err = dec.verifySignature(); err != nil {
+		return err
+	}
+	*r = dec
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler. The text form of a record is
+// "enr:" followed by the URL-safe base64 encoding of its RLP.
+func (r *Record) MarshalText() ([]byte, error) {
+	if !r.Signed() {
+		return nil, errEncodeUnsigned
+	}
+	return []byte("enr:" + base64.RawURLEncoding.EncodeToString(r.raw)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding the text form
+// created by MarshalText. Decoding verifies the signature.
+func (r *Record) UnmarshalText(text []byte) error {
+	s := string(text)
+	if !strings.HasPrefix(s, "enr:") {
+		return errNoPrefix
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s[4:])
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(raw, r)
+}
+
+// String returns the text form of a signed record, or a short description of
+// an unsigned one.
+func (r *Record) String() string {
+	text, err := r.MarshalText()
+	if err != nil {
+		return fmt.Sprintf("<unsigned record seq=%d keys=%v>", r.seq, r.Keys())
+	}
+	return string(text)
+}
+
+// NodeAddr returns the node address. The return value will be nil if the record is
+// unsigned.
+func (r *Record) NodeAddr() []byte {
+	var entry Secp256k1
+	if r.Load(&entry) != nil {
+		return nil
+	}
+	return crypto.Keccak256(crypto.FromECDSAPub((*ecdsa.PublicKey)(&entry))[1:])
+}
+
+// Sign signs the record with the given private key. It updates the record's
+// signature and increments the sequence number.
+func (r *Record) Sign(privkey *ecdsa.PrivateKey) error {
+	r.seq = r.seq + 1
+	r.Set(ID("v4"))
+	r.Set(Secp256k1(privkey.PublicKey))
+	return r.signAndEncode(privkey)
+}
+
+func (r *Record) appendPairs(list []interface{}) []interface{} {
+	list = append(list, r.seq)
+	for _, p := range r.pairs {
+		list = append(list, p.k, p.v)
+	}
+	return list
+}
+
+func (r *Record) signAndEncode(privkey *ecdsa.PrivateKey) error {
+	// Put record elements into a flat list. Leave room for the signature.
+	list := make([]interface{}, 1, len(r.pairs)*2+2)
+	list = r.appendPairs(list)
+
+	// Sign the tail of the list.
+	h := crypto.Keccak256(mustEncode(list[1:]))
+	sig, err := crypto.Sign(h, privkey)
+	if err != nil {
+		return err
+	}
+	sig = sig[:len(sig)-1] // remove v
+
+	// Put signature in front.
+	r.signature, list[0] = sig, sig
+	r.raw, err = rlp.EncodeToBytes(list)
+	if err != nil {
+		return err
+	}
+	if len(r.raw) > SizeLimit {
+		r.signature, r.raw = nil, nil
+		return errTooBig
+	}
+	return nil
+}
+
+func (r *Record) verifySignature() error {
+	// Get identity scheme, public key, signature.
+	var id ID
+	var entry Secp256k1
+	if err := r.Load(&id); err != nil {
+		return err
+	} else if id != "v4" {
+		return errNoID
+	}
+	if err := r.Load(&entry); err != nil {
+		return err
+	} else if len(r.signature) != sigLength {
+		return errInvalidSig
+	}
+
+	// Verify the signature.
+	list := make([]interface{}, 0, len(r.pairs)*2+1)
+	list = r.appendPairs(list)
+	h := crypto.Keccak256(mustEncode(list))
+	if !crypto.VerifySignature(crypto.CompressPubkey((*ecdsa.PublicKey)(&entry)), h, r.signature) {
+		return errInvalidSig
+	}
+	return nil
+}
+
+func mustEncode(v interface{}) []byte {
+	blob, err := rlp.EncodeToBytes(v)
+	if err != nil {
+		panic(fmt.Errorf("enr: can't encode: %v", err))
+	}
+	return blob
+}
@@ -0,0 +1,288 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package enr
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/rlp"
+)
+
+var (
+	privkey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	pubkey     = &privkey.PublicKey
+)
+
+// TestGetSetIP checks that IP keys are encoded in their short form and
+// survive a round trip through a record.
+func TestGetSetIP(t *testing.T) {
+	tests := []struct {
+		ip   net.IP
+		size int
+	}{
+		{net.IPv4(192, 168, 0, 3), 4},
+		{net.ParseIP("2001:db8:85a3::8a2e:370:7334"), 16},
+	}
+	for _, test := range tests {
+		var r Record
+		r.Set(IP(test.ip))
+
+		var ip IP
+		if err := r.Load(&ip); err != nil {
+			t.Fatalf("can't load %v: %v", test.ip, err)
+		}
+		if !net.IP(ip).Equal(test.ip) || len(ip) != test.size {
+			t.Errorf("IP mismatch: got %v (%d bytes), want %v (%d bytes)", ip, len(ip), test.ip, test.size)
+		}
+	}
+}
+
+// TestGetSetPorts tests encoding/decoding and setting/getting of the port keys.
+func TestGetSetPorts(t *testing.T) {
+	var r Record
+	r.Set(UDP(30309))
+	r.Set(TCP(30303))
+
+	var udp UDP
+	var tcp TCP
+	if err := r.Load(&udp); err != nil || udp != 30309 {
+		t.Errorf("UDP mismatch: %d, %v", udp, err)
+	}
+	if err := r.Load(&tcp); err != nil || tcp != 30303 {
+		t.Errorf("TCP mismatch: %d, %v", tcp, err)
+	}
+}
+
+// TestLoadErrors tests IsNotFound for errors returned by Load.
+func TestLoadErrors(t *testing.T) {
+	var r Record
+	ip4 := IP{127, 0, 0, 1}
+	r.Set(ip4)
+
+	// Check error for missing keys.
+	var udp UDP
+	err := r.Load(&udp)
+	if !IsNotFound(err) {
+		t.Error("IsNotFound should return true for missing key")
+	}
+	if want := (&KeyError{Key: "udp", Err: errNotFound}).Error(); err.Error() != want {
+		t.Errorf("wrong error for missing key: got %q, want %q", err, want)
+	}
+
+	// Check error for invalid keys.
+	var list []uint
+	err = r.Load(WithEntry(ip4.ENRKey(), &list))
+	if _, ok := err.(*KeyError); !ok || IsNotFound(err) {
+		t.Errorf("wrong error for invalid key: %v", err)
+	}
+}
+
+// TestSortedGetAndSet tests that Set produced a sorted pairs slice.
+func TestSortedGetAndSet(t *testing.T) {
+	type pairTest struct {
+		k string
+		v uint32
+	}
+
+	for _, tt := range []struct {
+		input []pairTest
+		want  []pairTest
+	}{
+		{
+			input: []pairTest{{"a", 1}, {"c", 2}, {"b", 3}},
+			want:  []pairTest{{"a", 1}, {"b", 3}, {"c", 2}},
+		},
+		{
+			input: []pairTest{{"a", 1}, {"c", 2}, {"b", 3}, {"d", 4}, {"a", 5}, {"bb", 6}},
+			want:  []pairTest{{"a", 5}, {"b", 3}, {"bb", 6}, {"c", 2}, {"d", 4}},
+		},
+		{
+			input: []pairTest{{"c", 2}, {"b", 3}, {"d", 4}, {"a", 5}, {"bb", 6}},
+			want:  []pairTest{{"a", 5}, {"b", 3}, {"bb", 6}, {"c", 2}, {"d", 4}},
+		},
+	} {
+		var r Record
+		for _, i := range tt.input {
+			r.Set(WithEntry(i.k, &i.v))
+		}
+		for i, w := range tt.want {
+			// set got's key from r.pair[i], so that we preserve order of pairs
+			got := pairTest{k: r.pairs[i].k}
+			if err := r.Load(WithEntry(w.k, &got.v)); err != nil {
+				t.Fatal(err)
+			}
+			if got != w {
+				t.Errorf("pair %d mismatch: got %v, want %v", i, got, w)
+			}
+		}
+	}
+}
+
+// TestDirty tests record signature removal on setting of new key/value pair in record.
+func TestDirty(t *testing.T) {
+	var r Record
+
+	if r.Signed() {
+		t.Error("Signed returned true for zero record")
+	}
+	if _, err := rlp.EncodeToBytes(r); err != errEncodeUnsigned {
+		t.Errorf("expected errEncodeUnsigned, got %#v", err)
+	}
+
+	if err := r.Sign(privkey); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Signed() {
+		t.Error("Signed return false for signed record")
+	}
+	if _, err := rlp.EncodeToBytes(r); err != nil {
+		t.Fatal(err)
+	}
+
+	r.SetSeq(3)
+	if r.Signed() {
+		t.Error("Signed returned true for modified record")
+	}
+	if _, err := rlp.EncodeToBytes(r); err != errEncodeUnsigned {
+		t.Errorf("expected errEncodeUnsigned, got %#v", err)
+	}
+}
+
+// TestSignEncodeAndDecode tests signing, RLP encoding and RLP decoding of a record.
+func TestSignEncodeAndDecode(t *testing.T) {
+	var r Record
+	r.Set(UDP(30303))
+	r.Set(IP{127, 0, 0, 1})
+	if err := r.Sign(privkey); err != nil {
+		t.Fatal(err)
+	}
+	if r.Seq() != 1 {
+		t.Errorf("wrong sequence number after signing: %d", r.Seq())
+	}
+
+	blob, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r2 Record
+	if err := rlp.DecodeBytes(blob, &r2); err != nil {
+		t.Fatal(err)
+	}
+	if r2.Seq() != r.Seq() || !bytes.Equal(r2.raw, r.raw) || fmt.Sprint(r2.Keys()) != "[id ip secp256k1 udp]" {
+		t.Fatalf("decoded record mismatch: seq %d, keys %v", r2.Seq(), r2.Keys())
+	}
+	var pk Secp256k1
+	if err := r2.Load(&pk); err != nil {
+		t.Fatal(err)
+	}
+	if pk.X.Cmp(pubkey.X) != 0 || pk.Y.Cmp(pubkey.Y) != 0 {
+		t.Error("decoded public key mismatch")
+	}
+}
+
+// TestTamperedRecord checks that decoding rejects records whose content was
+// modified after signing.
+func TestTamperedRecord(t *testing.T) {
+	var r Record
+	r.Set(UDP(30303))
+	if err := r.Sign(privkey); err != nil {
+		t.Fatal(err)
+	}
+	blob, _ := rlp.EncodeToBytes(r)
+
+	// The last byte is part of the UDP port value.
+	blob[len(blob)-1]++
+	var r2 Record
+	if err := rlp.DecodeBytes(blob, &r2); err != errInvalidSig {
+		t.Fatalf("wrong error for tampered record: %v", err)
+	}
+}
+
+func TestNodeAddr(t *testing.T) {
+	var r Record
+	if addr := r.NodeAddr(); addr != nil {
+		t.Errorf("wrong address on empty record: got %v, want %v", addr, nil)
+	}
+
+	if err := r.Sign(privkey); err != nil {
+		t.Fatal(err)
+	}
+	expected := crypto.Keccak256(crypto.FromECDSAPub(pubkey)[1:])
+	if addr := r.NodeAddr(); !bytes.Equal(addr, expected) {
+		t.Errorf("wrong address: got %x, want %x", addr, expected)
+	}
+}
+
+// TestTextEncoding checks the round trip through the "enr:" text form.
+func TestTextEncoding(t *testing.T) {
+	var r Record
+	r.Set(IP{10, 0, 0, 1})
+	r.Set(TCP(30303))
+	if err := r.Sign(privkey); err != nil {
+		t.Fatal(err)
+	}
+	text, err := r.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(text, []byte("enr:")) || r.String() != string(text) {
+		t.Fatalf("bad text encoding: %s", text)
+	}
+
+	var r2 Record
+	if err := r2.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r2.raw, r.raw) {
+		t.Fatal("record changed in text round trip")
+	}
+	if err := r2.UnmarshalText(text[4:]); err != errNoPrefix {
+		t.Errorf("wrong error for missing prefix: %v", err)
+	}
+	var unsigned Record
+	if _, err := unsigned.MarshalText(); err != errEncodeUnsigned {
+		t.Errorf("wrong error for unsigned record: %v", err)
+	}
+}
+
+func TestRecordTooBig(t *testing.T) {
+	var r Record
+	key := randomString(10)
+
+	// set a big value for random key, expect error
+	r.Set(WithEntry(key, randomString(SizeLimit)))
+	if err := r.Sign(privkey); err != errTooBig {
+		t.Fatalf("expected to get errTooBig, got %#v", err)
+	}
+
+	// set an acceptable value for random key, expect no error
+	r.Set(WithEntry(key, randomString(100)))
+	if err := r.Sign(privkey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func randomString(strlen int) string {
+	b := make([]byte, strlen)
+	rand.Read(b)
+	return string(b)
+}
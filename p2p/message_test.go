@@ -25,6 +25,9 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/bazacoin/go-bazacoin/event"
+	"github.com/bazacoin/go-bazacoin/p2p/discover"
 )
 
 func ExampleMsgPipe() {
@@ -87,6 +90,47 @@ func TestMsgPipeConcurrentClose(t *testing.T) {
 	}
 }
 
+func TestMsgEventer(t *testing.T) {
+	var (
+		feed   = new(event.Feed)
+		events = make(chan *PeerEvent, 2)
+		id     = discover.NodeID{1}
+	)
+	sub := feed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	rw1, rw2 := MsgPipe()
+	defer rw1.Close()
+	ev1, ev2 := newMsgEventer(rw1, feed, id, "test"), newMsgEventer(rw2, feed, id, "test")
+
+	go func() {
+		if err := Send(ev1, 3, []uint{1, 2, 3}); err != nil {
+			t.Errorf("send error: %v", err)
+		}
+	}()
+	msg, err := ev2.ReadMsg()
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	msg.Discard()
+
+	seen := make(map[PeerEventType]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-events:
+			if ev.Peer != id || ev.Protocol != "test" || *ev.MsgCode != 3 || *ev.MsgSize != msg.Size {
+				t.Errorf("event mismatch: %+v", ev)
+			}
+			seen[ev.Type] = true
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for message event")
+		}
+	}
+	if !seen[PeerEventTypeMsgSend] || !seen[PeerEventTypeMsgRecv] {
+		t.Errorf("missing events, got %v", seen)
+	}
+}
+
 func TestEOFSignal(t *testing.T) {
 	rb := make([]byte, 10)
 
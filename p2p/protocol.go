@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/bazacoin/go-bazacoin/p2p/discover"
+	"github.com/bazacoin/go-bazacoin/p2p/enr"
 )
 
 // Protocol represents a P2P subprotocol implementation.
@@ -52,6 +53,10 @@ type Protocol struct {
 	// about a certain peer in the network. If an info retrieval function is set,
 	// but returns nil, it is assumed that the protocol handshake is still running.
 	PeerInfo func(id discover.NodeID) interface{}
+
+	// Attributes contains protocol specific information for the node record.
+	// The entries are added to the local record when the server starts.
+	Attributes []enr.Entry
 }
 
 func (p Protocol) cap() Cap {
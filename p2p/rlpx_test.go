@@ -28,6 +28,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
@@ -374,6 +375,66 @@ func TestRLPXFrameRW(t *testing.T) {
 	}
 }
 
+func TestRLPXFrameRWSnappy(t *testing.T) {
+	var secret = make([]byte, 16)
+	rand.Read(secret)
+
+	conn := new(bytes.Buffer)
+	newRW := func(egress, ingress byte) *rlpxFrameRW {
+		s := secrets{
+			AES:        secret,
+			MAC:        secret,
+			EgressMAC:  sha3.NewKeccak256(),
+			IngressMAC: sha3.NewKeccak256(),
+		}
+		s.EgressMAC.Write([]byte{egress})
+		s.IngressMAC.Write([]byte{ingress})
+		rw := newRLPXFrameRW(conn, s)
+		rw.snappy = true
+		return rw
+	}
+	rw1, rw2 := newRW(1, 2), newRW(2, 1)
+
+	// compressible payloads must round-trip and shrink on the wire
+	wmsg := []interface{}{strings.Repeat("test", 1000)}
+	if err := Send(rw1, 8, wmsg); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	if conn.Len() >= 4000 {
+		t.Errorf("payload not compressed: %d bytes on the wire", conn.Len())
+	}
+	msg, err := rw2.ReadMsg()
+	if err != nil {
+		t.Fatalf("ReadMsg error: %v", err)
+	}
+	payload, _ := ioutil.ReadAll(msg.Payload)
+	wantPayload, _ := rlp.EncodeToBytes(wmsg)
+	if msg.Code != 8 || int(msg.Size) != len(wantPayload) || !bytes.Equal(payload, wantPayload) {
+		t.Fatalf("msg mismatch: code %d, size %d, payload %x", msg.Code, msg.Size, payload)
+	}
+
+	// failures reading the payload must be reported without writing a frame
+	rw1.snappy = true
+	failing := iotest.TimeoutReader(bytes.NewReader([]byte{0xc0}))
+	if err := rw1.WriteMsg(Msg{Code: 9, Size: 1, Payload: failing}); err != iotest.ErrTimeout {
+		t.Fatalf("WriteMsg error mismatch: got %v, want %v", err, iotest.ErrTimeout)
+	}
+	if conn.Len() != 0 {
+		t.Fatalf("frame written despite payload error: %d bytes", conn.Len())
+	}
+
+	// a frame claiming a decompressed size above the limit must be rejected
+	// before anything is allocated
+	bomb := []byte{0x80, 0x80, 0x80, 0x08} // uvarint 1<<24
+	rw1.snappy = false
+	if err := rw1.WriteMsg(Msg{Code: 9, Size: uint32(len(bomb)), Payload: bytes.NewReader(bomb)}); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	if _, err := rw2.ReadMsg(); err != errPlainMessageTooLarge {
+		t.Fatalf("ReadMsg error mismatch: got %v, want %v", err, errPlainMessageTooLarge)
+	}
+}
+
 type handshakeAuthTest struct {
 	input       string
 	isPlain     bool
@@ -0,0 +1,112 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/p2p/simulations/adapters"
+)
+
+func TestHTTPNetwork(t *testing.T) {
+	adapter := adapters.NewSimAdapter(adapters.Services{"test": newTestService})
+	network := NewNetwork(adapter, &NetworkConfig{DefaultService: "test"})
+	defer network.Shutdown()
+
+	s := httptest.NewServer(NewServer(network))
+	defer s.Close()
+	client := NewClient(s.URL)
+
+	events := make(chan *Event, 100)
+	sub, err := client.SubscribeNetwork(events)
+	if err != nil {
+		t.Fatalf("error subscribing to network events: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// create and start two nodes, referring to them by name
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := client.CreateNode(&adapters.NodeConfig{Name: name}); err != nil {
+			t.Fatalf("error creating node %s: %v", name, err)
+		}
+	}
+	if _, err := client.CreateNode(&adapters.NodeConfig{Name: "alice"}); err == nil {
+		t.Fatal("created node with duplicate name")
+	}
+	if err := client.StartNetwork(); err != nil {
+		t.Fatalf("error starting network: %v", err)
+	}
+	nodes, err := client.GetNodes()
+	if err != nil {
+		t.Fatalf("error getting nodes: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "alice" || nodes[1].Name != "bob" {
+		t.Fatalf("unexpected nodes: %+v", nodes)
+	}
+
+	waitConn := func(up bool) {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case ev := <-events:
+				if ev.Type == EventTypeConn && !ev.Control && ev.Conn.Up == up {
+					return
+				}
+			case err := <-sub.Err():
+				t.Fatalf("network event subscription failed: %v", err)
+			case <-timeout:
+				t.Fatalf("timeout waiting for connection event (up: %t)", up)
+			}
+		}
+	}
+	if err := client.ConnectNode("alice", nodes[1].ID); err != nil {
+		t.Fatalf("error connecting nodes: %v", err)
+	}
+	waitConn(true)
+
+	net, err := client.GetNetwork()
+	if err != nil {
+		t.Fatalf("error getting network: %v", err)
+	}
+	if len(net.Nodes) != 2 || len(net.Conns) != 1 || !net.Conns[0].Up {
+		t.Fatalf("unexpected network state: %d nodes, conns %v", len(net.Nodes), net.Conns)
+	}
+	snap, err := client.CreateSnapshot()
+	if err != nil {
+		t.Fatalf("error creating snapshot: %v", err)
+	}
+	if len(snap.Nodes) != 2 || snap.Nodes[1].Node.Config.Name != "bob" || len(snap.Nodes[1].Snapshots) != 1 {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+
+	if err := client.DisconnectNode("alice", "bob"); err != nil {
+		t.Fatalf("error disconnecting nodes: %v", err)
+	}
+	waitConn(false)
+
+	if err := client.StopNode("bob"); err != nil {
+		t.Fatalf("error stopping node: %v", err)
+	}
+	if err := client.StopNode("bob"); err == nil {
+		t.Fatal("stopped node twice")
+	}
+	if _, err := client.GetNode("carol"); err == nil {
+		t.Fatal("got unknown node")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/event"
+)
+
+// Journal records the events emitted by a Network in the order they arrive,
+// so that scenario tests can inspect what happened once the simulation has
+// settled.
+type Journal struct {
+	lock   sync.Mutex
+	events []*Event
+	update chan struct{}
+
+	sub  event.Subscription
+	quit chan struct{}
+}
+
+// NewJournal creates a journal recording all events of the given network.
+func NewJournal(net *Network) *Journal {
+	ch := make(chan *Event, 64)
+	j := &Journal{
+		update: make(chan struct{}),
+		sub:    net.Events().Subscribe(ch),
+		quit:   make(chan struct{}),
+	}
+	go j.loop(ch)
+	return j
+}
+
+func (j *Journal) loop(ch chan *Event) {
+	defer close(j.quit)
+	for {
+		select {
+		case ev := <-ch:
+			j.lock.Lock()
+			j.events = append(j.events, ev)
+			close(j.update)
+			j.update = make(chan struct{})
+			j.lock.Unlock()
+		case <-j.sub.Err():
+			return
+		}
+	}
+}
+
+// Events returns a copy of all events recorded so far.
+func (j *Journal) Events() []*Event {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	events := make([]*Event, len(j.events))
+	copy(events, j.events)
+	return events
+}
+
+// Filter returns the recorded events of the given type.
+func (j *Journal) Filter(typ EventType) []*Event {
+	var events []*Event
+	for _, ev := range j.Events() {
+		if ev.Type == typ {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// WaitFor blocks until an event satisfying the predicate has been recorded or
+// the timeout expires, returning whether such an event was found. Events
+// recorded before the call are considered too.
+func (j *Journal) WaitFor(match func(*Event) bool, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	seen := 0
+	for {
+		j.lock.Lock()
+		events, update := j.events[seen:], j.update
+		seen = len(j.events)
+		j.lock.Unlock()
+
+		for _, ev := range events {
+			if match(ev) {
+				return true
+			}
+		}
+		select {
+		case <-update:
+		case <-deadline.C:
+			return false
+		case <-j.quit:
+			return false
+		}
+	}
+}
+
+// Close stops recording events.
+func (j *Journal) Close() {
+	j.sub.Unsubscribe()
+	<-j.quit
+}
@@ -0,0 +1,214 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bazacoin/go-bazacoin/node"
+	"github.com/bazacoin/go-bazacoin/p2p"
+	"github.com/bazacoin/go-bazacoin/p2p/discover"
+	"github.com/bazacoin/go-bazacoin/p2p/simulations/adapters"
+	"github.com/bazacoin/go-bazacoin/rpc"
+)
+
+const (
+	pingMsg = iota
+	pongMsg
+)
+
+// testService runs a ping-pong protocol with every connected peer and can
+// snapshot a small piece of state.
+type testService struct {
+	id       discover.NodeID
+	peers    int64
+	restored []byte
+}
+
+func newTestService(ctx *adapters.ServiceContext) (node.Service, error) {
+	return &testService{id: ctx.Config.ID, restored: ctx.Snapshot}, nil
+}
+
+func (s *testService) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    "test",
+		Version: 1,
+		Length:  2,
+		Run:     s.run,
+	}}
+}
+
+func (s *testService) run(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+	atomic.AddInt64(&s.peers, 1)
+	defer atomic.AddInt64(&s.peers, -1)
+
+	if err := p2p.Send(rw, pingMsg, []byte("ping")); err != nil {
+		return err
+	}
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		msg.Discard()
+		if msg.Code == pingMsg {
+			if err := p2p.Send(rw, pongMsg, []byte("pong")); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *testService) Snapshot() ([]byte, error) {
+	return []byte("snapshot " + s.id.String()), nil
+}
+
+func (s *testService) APIs() []rpc.API         { return nil }
+func (s *testService) Start(*p2p.Server) error { return nil }
+func (s *testService) Stop() error             { return nil }
+
+// newTestNetwork creates a network of n started nodes running the test
+// service with message events enabled.
+func newTestNetwork(t *testing.T, n int) (*Network, []discover.NodeID) {
+	adapter := adapters.NewSimAdapter(adapters.Services{"test": newTestService})
+	network := NewNetwork(adapter, &NetworkConfig{DefaultService: "test"})
+
+	ids := make([]discover.NodeID, n)
+	for i := range ids {
+		conf := adapters.RandomNodeConfig()
+		conf.EnableMsgEvents = true
+		node, err := network.NewNodeWithConfig(conf)
+		if err != nil {
+			t.Fatalf("error creating node %d: %v", i, err)
+		}
+		ids[i] = node.ID()
+	}
+	if err := network.StartAll(); err != nil {
+		t.Fatalf("error starting nodes: %v", err)
+	}
+	return network, ids
+}
+
+func connEvent(one, other discover.NodeID, up bool) func(*Event) bool {
+	return func(ev *Event) bool {
+		return ev.Type == EventTypeConn && !ev.Control && ev.Conn.Up == up &&
+			ConnLabel(ev.Conn.One, ev.Conn.Other) == ConnLabel(one, other)
+	}
+}
+
+func TestNetworkSimulation(t *testing.T) {
+	network, ids := newTestNetwork(t, 3)
+	defer network.Shutdown()
+
+	journal := NewJournal(network)
+	defer journal.Close()
+
+	// connect the nodes in a chain and wait for the ping-pong exchange
+	for i := 0; i < len(ids)-1; i++ {
+		if err := network.Connect(ids[i], ids[i+1]); err != nil {
+			t.Fatalf("error connecting %d to %d: %v", i, i+1, err)
+		}
+	}
+	for i := 0; i < len(ids)-1; i++ {
+		if !journal.WaitFor(connEvent(ids[i], ids[i+1], true), 5*time.Second) {
+			t.Fatalf("timeout waiting for connection %d-%d", i, i+1)
+		}
+	}
+	pong := func(ev *Event) bool {
+		return ev.Type == EventTypeMsg && ev.Msg.Received && ev.Msg.Protocol == "test" &&
+			ev.Msg.Code == pongMsg && ev.Msg.One == ids[0] && ev.Msg.Other == ids[1]
+	}
+	if !journal.WaitFor(pong, 5*time.Second) {
+		t.Fatal("timeout waiting for pong message event")
+	}
+	if conn := network.GetConn(ids[1], ids[0]); conn == nil || !conn.Up {
+		t.Fatalf("connection not tracked as up: %v", conn)
+	}
+
+	// change the topology: drop one connection and kill the last node
+	if err := network.Disconnect(ids[0], ids[1]); err != nil {
+		t.Fatalf("error disconnecting: %v", err)
+	}
+	if !journal.WaitFor(connEvent(ids[0], ids[1], false), 5*time.Second) {
+		t.Fatal("timeout waiting for disconnect")
+	}
+	if err := network.Stop(ids[2]); err != nil {
+		t.Fatalf("error stopping node: %v", err)
+	}
+	if network.GetNode(ids[2]).Up {
+		t.Fatal("stopped node still up")
+	}
+	if conn := network.GetConn(ids[1], ids[2]); conn.Up {
+		t.Fatal("connection to stopped node still up")
+	}
+	if err := network.Connect(ids[1], ids[2]); err == nil {
+		t.Fatal("connected to stopped node")
+	}
+}
+
+func TestNetworkSnapshot(t *testing.T) {
+	network, ids := newTestNetwork(t, 3)
+	defer network.Shutdown()
+
+	journal := NewJournal(network)
+	defer journal.Close()
+
+	if err := network.Connect(ids[0], ids[1]); err != nil {
+		t.Fatalf("error connecting nodes: %v", err)
+	}
+	if !journal.WaitFor(connEvent(ids[0], ids[1], true), 5*time.Second) {
+		t.Fatal("timeout waiting for connection")
+	}
+	if err := network.Stop(ids[2]); err != nil {
+		t.Fatalf("error stopping node: %v", err)
+	}
+	snap, err := network.Snapshot()
+	if err != nil {
+		t.Fatalf("error creating snapshot: %v", err)
+	}
+	if len(snap.Nodes) != 3 || len(snap.Conns) != 1 || !snap.Conns[0].Up {
+		t.Fatalf("unexpected snapshot: %d nodes, conns %v", len(snap.Nodes), snap.Conns)
+	}
+	if snap.Nodes[2].Node.Up || snap.Nodes[2].Snapshots != nil {
+		t.Fatalf("stopped node snapshotted as running")
+	}
+
+	// load the snapshot into a fresh network
+	adapter := adapters.NewSimAdapter(adapters.Services{"test": newTestService})
+	restored := NewNetwork(adapter, &NetworkConfig{DefaultService: "test"})
+	defer restored.Shutdown()
+
+	rjournal := NewJournal(restored)
+	defer rjournal.Close()
+
+	if err := restored.Load(snap); err != nil {
+		t.Fatalf("error loading snapshot: %v", err)
+	}
+	if !rjournal.WaitFor(connEvent(ids[0], ids[1], true), 5*time.Second) {
+		t.Fatal("timeout waiting for restored connection")
+	}
+	if restored.GetNode(ids[2]).Up {
+		t.Fatal("stopped node restored as running")
+	}
+	node, _ := adapter.GetNode(ids[0])
+	service := node.Services()[0].(*testService)
+	if want := "snapshot " + ids[0].String(); string(service.restored) != want {
+		t.Fatalf("service snapshot mismatch: got %q, want %q", service.restored, want)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/crypto"
+)
+
+// CHTFrequency is the number of blocks covered by a single section of the
+// canonical hash trie (and the bloom trie built alongside it).
+const CHTFrequency = 4096
+
+// TrustedCheckpoint represents a set of post-processed trie roots (CHT and
+// bloom trie) associated with the appropriate section index and head hash. It
+// is used to start light syncing from this checkpoint and avoid downloading the
+// entire header chain while still being able to securely access old headers.
+type TrustedCheckpoint struct {
+	SectionIndex uint64      `json:"sectionIndex"`
+	SectionHead  common.Hash `json:"sectionHead"`
+	CHTRoot      common.Hash `json:"chtRoot"`
+	BloomRoot    common.Hash `json:"bloomRoot"`
+}
+
+// HashEqual returns an indicator comparing the itself hash with given one.
+func (c *TrustedCheckpoint) HashEqual(hash common.Hash) bool {
+	if c.Empty() {
+		return hash == common.Hash{}
+	}
+	return c.Hash() == hash
+}
+
+// Hash returns the hash of checkpoint's four key fields (index, section head,
+// CHT root and bloom trie root). This is the value signed by the oracle signers.
+func (c *TrustedCheckpoint) Hash() common.Hash {
+	buf := make([]byte, 8+3*common.HashLength)
+	binary.BigEndian.PutUint64(buf, c.SectionIndex)
+	copy(buf[8:], c.SectionHead.Bytes())
+	copy(buf[8+common.HashLength:], c.CHTRoot.Bytes())
+	copy(buf[8+2*common.HashLength:], c.BloomRoot.Bytes())
+	return crypto.Keccak256Hash(buf)
+}
+
+// Empty returns an indicator whether the checkpoint is regarded as empty. The
+// section head is optional: checkpoints without it are trusted by CHT root only.
+func (c *TrustedCheckpoint) Empty() bool {
+	return c.CHTRoot == (common.Hash{})
+}
+
+// HeadNumber returns the number of the last block covered by the checkpoint.
+func (c *TrustedCheckpoint) HeadNumber() uint64 {
+	return (c.SectionIndex+1)*CHTFrequency - 1
+}
+
+// String implements fmt.Stringer.
+func (c *TrustedCheckpoint) String() string {
+	return fmt.Sprintf("%d,%x,%x,%x", c.SectionIndex, c.SectionHead, c.CHTRoot, c.BloomRoot)
+}
+
+// CheckpointOracleConfig represents a set of checkpoint contract (which acts as
+// an oracle) config which used for light client checkpoint syncing.
+type CheckpointOracleConfig struct {
+	Address   common.Address   `json:"address"`
+	Signers   []common.Address `json:"signers"`
+	Threshold uint64           `json:"threshold"`
+}
+
+var (
+	// MainnetTrustedCheckpoint contains the light client trusted checkpoint
+	// for the main network.
+	MainnetTrustedCheckpoint = &TrustedCheckpoint{
+		SectionIndex: 804,
+		CHTRoot:      common.HexToHash("0x85e4286fe0a730390245c49de8476977afdae0eb5530b277f62a52b12313d50f"),
+	}
+
+	// TrustedCheckpoints associates each known checkpoint with the genesis hash
+	// of the chain it belongs to.
+	TrustedCheckpoints = map[common.Hash]*TrustedCheckpoint{
+		MainNetGenesisHash: MainnetTrustedCheckpoint,
+	}
+)
@@ -70,6 +70,17 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored: &ChainConfig{ConstantinopleBlock: big.NewInt(30)},
+			new:    &ChainConfig{ConstantinopleBlock: big.NewInt(40)},
+			head:   35,
+			wantErr: &ConfigCompatError{
+				What:         "Constantinople fork block",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    big.NewInt(40),
+				RewindTo:     29,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -19,6 +19,7 @@ package params
 type GasTable struct {
 	ExtcodeSize uint64
 	ExtcodeCopy uint64
+	ExtcodeHash uint64
 	Balance     uint64
 	SLoad       uint64
 	Calls       uint64
@@ -74,4 +75,19 @@ var (
 
 		CreateBySuicide: 25000,
 	}
+
+	// GasTableConstantinople contain the gas prices for
+	// the Constantinople phase.
+	GasTableConstantinople = GasTable{
+		ExtcodeSize: 700,
+		ExtcodeCopy: 700,
+		ExtcodeHash: 400,
+		Balance:     400,
+		SLoad:       200,
+		Calls:       700,
+		Suicide:     5000,
+		ExpByte:     50,
+
+		CreateBySuicide: 25000,
+	}
 )
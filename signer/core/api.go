@@ -0,0 +1,232 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package core implements a standalone transaction and data signer, serving
+// signing requests over RPC only after they were approved by a user interface
+// or a set of rules.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/bazacoin/go-bazacoin/accounts"
+	"github.com/bazacoin/go-bazacoin/accounts/keystore"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/rlp"
+)
+
+// ExternalAPIVersion is the version of the signer API served to untrusted
+// callers. It is bumped whenever the API changes in an incompatible way.
+const ExternalAPIVersion = "1.0.0"
+
+// ErrRequestDenied is returned if a request was rejected by the user or the
+// rules governing the signer.
+var ErrRequestDenied = errors.New("request denied")
+
+// ExternalAPI defines the methods the signer exposes to untrusted callers under
+// the "account" namespace.
+type ExternalAPI interface {
+	// List returns the accounts the caller is allowed to see.
+	List(ctx context.Context) ([]common.Address, error)
+
+	// New creates a new password protected account in the signer's keystore.
+	New(ctx context.Context) (common.Address, error)
+
+	// SignTransaction signs the given transaction, returning both its RLP and
+	// JSON representation.
+	SignTransaction(ctx context.Context, args SendTxArgs) (*SignTxResult, error)
+
+	// Sign calculates a signature for keccak256("\x19Bazacoin Signed Message:\n" +
+	// len(data) + data), in the same format as the node's bzc_sign.
+	Sign(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error)
+
+	// Version returns the version of the external API.
+	Version(ctx context.Context) (string, error)
+}
+
+// SignerUI is the interface through which every request is approved or
+// rejected before the signer acts on it.
+type SignerUI interface {
+	// ApproveTx prompts the user for confirmation of a transaction signing request.
+	ApproveTx(request *SignTxRequest) (SignTxResponse, error)
+
+	// ApproveSignData prompts the user for confirmation of a data signing request.
+	ApproveSignData(request *SignDataRequest) (SignDataResponse, error)
+
+	// ApproveListing prompts the user for the accounts to reveal to the caller.
+	ApproveListing(request *ListRequest) (ListResponse, error)
+
+	// ApproveNewAccount prompts the user for confirmation of account creation.
+	ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error)
+
+	// ShowError displays an error message to the user.
+	ShowError(message string)
+
+	// ShowInfo displays an informational message to the user.
+	ShowInfo(message string)
+}
+
+// SignerAPI implements ExternalAPI on top of an account manager, asking the
+// configured UI for approval of every request.
+type SignerAPI struct {
+	chainID *big.Int
+	am      *accounts.Manager
+	ui      SignerUI
+}
+
+// NewSignerAPI creates a signer API which signs transactions for the given
+// chain with the accounts found in the account manager.
+func NewSignerAPI(chainID *big.Int, am *accounts.Manager, ui SignerUI) *SignerAPI {
+	return &SignerAPI{
+		chainID: chainID,
+		am:      am,
+		ui:      ui,
+	}
+}
+
+// List returns the accounts approved by the UI for disclosure.
+func (api *SignerAPI) List(ctx context.Context) ([]common.Address, error) {
+	var addresses []common.Address
+	for _, wallet := range api.am.Wallets() {
+		for _, account := range wallet.Accounts() {
+			addresses = append(addresses, account.Address)
+		}
+	}
+	resp, err := api.ui.ApproveListing(&ListRequest{Accounts: addresses})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Accounts == nil {
+		return nil, ErrRequestDenied
+	}
+	// Never reveal accounts the UI made up, only a subset of the real ones
+	known := make(map[common.Address]bool)
+	for _, addr := range addresses {
+		known[addr] = true
+	}
+	approved := make([]common.Address, 0, len(resp.Accounts))
+	for _, addr := range resp.Accounts {
+		if known[addr] {
+			approved = append(approved, addr)
+		}
+	}
+	return approved, nil
+}
+
+// New creates a new account in the signer's keystore, encrypted with the
+// password provided by the UI.
+func (api *SignerAPI) New(ctx context.Context) (common.Address, error) {
+	backends := api.am.Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		return common.Address{}, errors.New("password based accounts not supported")
+	}
+	resp, err := api.ui.ApproveNewAccount(&NewAccountRequest{})
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !resp.Approved {
+		return common.Address{}, ErrRequestDenied
+	}
+	account, err := backends[0].(*keystore.KeyStore).NewAccount(resp.Password)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return account.Address, nil
+}
+
+// SignTransaction signs the given transaction if the UI approves it.
+func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs) (*SignTxResult, error) {
+	resp, err := api.ui.ApproveTx(&SignTxRequest{Transaction: args})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: args.From}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		unsigned = args.toTransaction()
+		signed   *types.Transaction
+	)
+	if resp.Password != "" {
+		signed, err = wallet.SignTxWithPassphrase(account, resp.Password, unsigned, api.chainID)
+	} else {
+		signed, err = wallet.SignTx(account, unsigned, api.chainID)
+	}
+	if err != nil {
+		api.ui.ShowError(err.Error())
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	api.ui.ShowInfo(fmt.Sprintf("Signed transaction %s", signed.Hash().Hex()))
+	return &SignTxResult{Raw: raw, Tx: signed}, nil
+}
+
+// Sign signs the hash of the given data if the UI approves it.
+func (api *SignerAPI) Sign(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	hash := signHash(data)
+
+	resp, err := api.ui.ApproveSignData(&SignDataRequest{Address: addr, Data: data, Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: addr}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	var signature []byte
+	if resp.Password != "" {
+		signature, err = wallet.SignHashWithPassphrase(account, resp.Password, hash)
+	} else {
+		signature, err = wallet.SignHash(account, hash)
+	}
+	if err != nil {
+		api.ui.ShowError(err.Error())
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
+// Version returns the version of the external API.
+func (api *SignerAPI) Version(ctx context.Context) (string, error) {
+	return ExternalAPIVersion, nil
+}
+
+// signHash is a helper function that calculates a hash for the given message
+// that can be safely used to calculate a signature from. It matches the hash
+// used by the node's bzc_sign.
+func signHash(data []byte) []byte {
+	msg := fmt.Sprintf("\x19Bazacoin Signed Message:\n%d%s", len(data), data)
+	return crypto.Keccak256([]byte(msg))
+}
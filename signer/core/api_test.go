@@ -0,0 +1,203 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/accounts"
+	"github.com/bazacoin/go-bazacoin/accounts/keystore"
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	"github.com/bazacoin/go-bazacoin/core/types"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/rlp"
+)
+
+// testUI is a SignerUI answering every request with a preconfigured decision.
+type testUI struct {
+	approve  bool
+	password string
+}
+
+func (ui *testUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	return SignTxResponse{Approved: ui.approve, Password: ui.password}, nil
+}
+
+func (ui *testUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	return SignDataResponse{Approved: ui.approve, Password: ui.password}, nil
+}
+
+func (ui *testUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	if !ui.approve {
+		return ListResponse{}, nil
+	}
+	// Try to sneak in an unknown account, the API must filter it out
+	return ListResponse{Accounts: append(request.Accounts, common.Address{0xff})}, nil
+}
+
+func (ui *testUI) ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error) {
+	return NewAccountResponse{Approved: ui.approve, Password: ui.password}, nil
+}
+
+func (ui *testUI) ShowError(message string) {}
+func (ui *testUI) ShowInfo(message string)  {}
+
+// newTestSigner creates a signer around a keystore holding a single account,
+// encrypted with the password "foobar".
+func newTestSigner(t *testing.T) (string, *SignerAPI, *testUI, common.Address) {
+	dir, err := ioutil.TempDir("", "signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("foobar")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	ui := new(testUI)
+	return dir, NewSignerAPI(big.NewInt(1), accounts.NewManager(ks), ui), ui, account.Address
+}
+
+func TestSignerAccounts(t *testing.T) {
+	dir, api, ui, addr := newTestSigner(t)
+	defer os.RemoveAll(dir)
+
+	// Listing must be approved and never reveal unknown accounts
+	if _, err := api.List(context.Background()); err != ErrRequestDenied {
+		t.Fatalf("rejected listing: error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	ui.approve = true
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list accounts: %v", err)
+	}
+	if len(list) != 1 || list[0] != addr {
+		t.Fatalf("account list mismatch: have %x, want [%x]", list, addr)
+	}
+	// Account creation must be approved by the UI
+	ui.approve = false
+	if _, err := api.New(context.Background()); err != ErrRequestDenied {
+		t.Fatalf("rejected creation: error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	ui.approve, ui.password = true, "foobar"
+	created, err := api.New(context.Background())
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	ks := api.am.Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	if !ks.HasAddress(created) {
+		t.Fatalf("created account %x missing from keystore", created)
+	}
+}
+
+func TestSignerSignTransaction(t *testing.T) {
+	dir, api, ui, from := newTestSigner(t)
+	defer os.RemoveAll(dir)
+
+	to := common.HexToAddress("0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192")
+	args := SendTxArgs{
+		From:     from,
+		To:       &to,
+		Gas:      hexutil.Big(*big.NewInt(21000)),
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Value:    hexutil.Big(*big.NewInt(1000)),
+		Nonce:    3,
+	}
+	// Rejected requests and wrong passwords must not produce signatures
+	ui.approve = false
+	if _, err := api.SignTransaction(context.Background(), args); err != ErrRequestDenied {
+		t.Fatalf("rejected signing: error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	ui.approve, ui.password = true, "wrong"
+	if _, err := api.SignTransaction(context.Background(), args); err != keystore.ErrDecrypt {
+		t.Fatalf("bad password: error mismatch: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	// Approved requests must be signed by the sender for the configured chain
+	ui.password = "foobar"
+	res, err := api.SignTransaction(context.Background(), args)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	sender, err := types.Sender(types.NewEIP155Signer(big.NewInt(1)), res.Tx)
+	if err != nil {
+		t.Fatalf("failed to recover sender: %v", err)
+	}
+	if sender != from {
+		t.Errorf("sender mismatch: have %x, want %x", sender, from)
+	}
+	if res.Tx.Nonce() != 3 || *res.Tx.To() != to || res.Tx.Value().Int64() != 1000 {
+		t.Errorf("signed transaction mismatch: %v", res.Tx)
+	}
+	raw, _ := rlp.EncodeToBytes(res.Tx)
+	if string(raw) != string(res.Raw) {
+		t.Errorf("raw transaction mismatch: have %x, want %x", res.Raw, raw)
+	}
+}
+
+func TestSignerSignData(t *testing.T) {
+	dir, api, ui, addr := newTestSigner(t)
+	defer os.RemoveAll(dir)
+
+	ui.approve, ui.password = true, "foobar"
+	data := hexutil.Bytes("hello world")
+	signature, err := api.Sign(context.Background(), addr, data)
+	if err != nil {
+		t.Fatalf("failed to sign data: %v", err)
+	}
+	if len(signature) != 65 || signature[64] < 27 {
+		t.Fatalf("invalid signature: %x", signature)
+	}
+	signature[64] -= 27
+	pubkey, err := crypto.SigToPub(signHash(data), signature)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pubkey); recovered != addr {
+		t.Errorf("signer mismatch: have %x, want %x", recovered, addr)
+	}
+}
+
+func TestAuditLogger(t *testing.T) {
+	dir, api, ui, _ := newTestSigner(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	logger, err := NewAuditLogger(path, api)
+	if err != nil {
+		t.Fatalf("failed to create audit logger: %v", err)
+	}
+	ui.approve = false
+	if _, err := logger.New(context.Background()); err != ErrRequestDenied {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	for _, want := range []string{"type=request", "type=response", "err=\"request denied\""} {
+		if !strings.Contains(string(blob), want) {
+			t.Errorf("audit log missing %q:\n%s", want, blob)
+		}
+	}
+}
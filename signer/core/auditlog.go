@@ -0,0 +1,87 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	"github.com/bazacoin/go-bazacoin/log"
+)
+
+// AuditLogger wraps an ExternalAPI, recording every request and its outcome.
+type AuditLogger struct {
+	log log.Logger
+	api ExternalAPI
+}
+
+// NewAuditLogger creates an audit logger appending to the file at path.
+func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
+	handler, err := log.FileHandler(path, log.LogfmtFormat())
+	if err != nil {
+		return nil, err
+	}
+	logger := log.New("api", "signer")
+	logger.SetHandler(handler)
+
+	return &AuditLogger{log: logger, api: api}, nil
+}
+
+// List implements ExternalAPI.
+func (l *AuditLogger) List(ctx context.Context) ([]common.Address, error) {
+	l.log.Info("List", "type", "request")
+	res, err := l.api.List(ctx)
+	l.log.Info("List", "type", "response", "accounts", res, "err", err)
+	return res, err
+}
+
+// New implements ExternalAPI.
+func (l *AuditLogger) New(ctx context.Context) (common.Address, error) {
+	l.log.Info("New", "type", "request")
+	addr, err := l.api.New(ctx)
+	l.log.Info("New", "type", "response", "address", addr, "err", err)
+	return addr, err
+}
+
+// SignTransaction implements ExternalAPI.
+func (l *AuditLogger) SignTransaction(ctx context.Context, args SendTxArgs) (*SignTxResult, error) {
+	l.log.Info("SignTransaction", "type", "request", "tx", args)
+	res, err := l.api.SignTransaction(ctx, args)
+	if res != nil {
+		l.log.Info("SignTransaction", "type", "response", "hash", res.Tx.Hash(), "raw", res.Raw, "err", err)
+	} else {
+		l.log.Info("SignTransaction", "type", "response", "err", err)
+	}
+	return res, err
+}
+
+// Sign implements ExternalAPI.
+func (l *AuditLogger) Sign(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	l.log.Info("Sign", "type", "request", "address", addr, "data", data)
+	signature, err := l.api.Sign(ctx, addr, data)
+	l.log.Info("Sign", "type", "response", "signature", signature, "err", err)
+	return signature, err
+}
+
+// Version implements ExternalAPI.
+func (l *AuditLogger) Version(ctx context.Context) (string, error) {
+	l.log.Info("Version", "type", "request")
+	version, err := l.api.Version(ctx)
+	l.log.Info("Version", "type", "response", "version", version, "err", err)
+	return version, err
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// CommandlineUI is an interactive SignerUI, prompting the operator on the
+// terminal for every request. Requests are handled one at a time.
+type CommandlineUI struct {
+	in *bufio.Reader
+	mu sync.Mutex
+}
+
+// NewCommandlineUI creates an interactive UI reading from standard input.
+func NewCommandlineUI() *CommandlineUI {
+	return &CommandlineUI{in: bufio.NewReader(os.Stdin)}
+}
+
+// readString reads a single line of input, stripped of surrounding whitespace.
+func (ui *CommandlineUI) readString() string {
+	text, _ := ui.in.ReadString('\n')
+	return strings.TrimSpace(text)
+}
+
+// readPassword reads a password without echoing it if standard input is a
+// terminal, or a plain line otherwise.
+func (ui *CommandlineUI) readPassword() string {
+	fmt.Print("Password: ")
+	if fd := int(os.Stdin.Fd()); terminal.IsTerminal(fd) {
+		password, err := terminal.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return ""
+		}
+		return string(password)
+	}
+	return ui.readString()
+}
+
+// confirm asks the operator a yes/no question, defaulting to no.
+func (ui *CommandlineUI) confirm() bool {
+	fmt.Print("Approve? [y/N] > ")
+	answer := strings.ToLower(ui.readString())
+	return answer == "y" || answer == "yes"
+}
+
+// ApproveTx implements SignerUI, asking the operator to confirm the transaction
+// and provide the password of the sending account.
+func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	tx := request.Transaction
+	fmt.Println("-------- Transaction request --------")
+	fmt.Printf("from:     %s\n", tx.From.Hex())
+	if tx.To != nil {
+		fmt.Printf("to:       %s\n", tx.To.Hex())
+	} else {
+		fmt.Printf("to:       <contract creation>\n")
+	}
+	fmt.Printf("value:    %v wei\n", tx.Value.ToInt())
+	fmt.Printf("gas:      %v\n", tx.Gas.ToInt())
+	fmt.Printf("gasprice: %v wei\n", tx.GasPrice.ToInt())
+	fmt.Printf("nonce:    %d\n", uint64(tx.Nonce))
+	if len(tx.Data) > 0 {
+		fmt.Printf("data:     %s\n", tx.Data)
+	}
+	fmt.Println("-------------------------------------")
+	if !ui.confirm() {
+		return SignTxResponse{Approved: false}, nil
+	}
+	return SignTxResponse{Approved: true, Password: ui.readPassword()}, nil
+}
+
+// ApproveSignData implements SignerUI, asking the operator to confirm the
+// message signature and provide the password of the signing account.
+func (ui *CommandlineUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Println("-------- Sign data request --------")
+	fmt.Printf("account: %s\n", request.Address.Hex())
+	fmt.Printf("message: %q\n", string(request.Data))
+	fmt.Printf("raw:     %s\n", request.Data)
+	fmt.Printf("hash:    %s\n", request.Hash)
+	fmt.Println("-----------------------------------")
+	if !ui.confirm() {
+		return SignDataResponse{Approved: false}, nil
+	}
+	return SignDataResponse{Approved: true, Password: ui.readPassword()}, nil
+}
+
+// ApproveListing implements SignerUI, asking the operator whether to reveal
+// the accounts to the caller.
+func (ui *CommandlineUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Println("-------- List accounts request --------")
+	fmt.Println("A request has been made to list all accounts:")
+	for _, addr := range request.Accounts {
+		fmt.Printf("  %s\n", addr.Hex())
+	}
+	fmt.Println("---------------------------------------")
+	if !ui.confirm() {
+		return ListResponse{}, nil
+	}
+	return ListResponse{Accounts: request.Accounts}, nil
+}
+
+// ApproveNewAccount implements SignerUI, asking the operator to confirm the
+// account creation and choose a password for it.
+func (ui *CommandlineUI) ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Println("-------- New account request --------")
+	fmt.Println("A request has been made to create a new account.")
+	fmt.Println("-------------------------------------")
+	if !ui.confirm() {
+		return NewAccountResponse{Approved: false}, nil
+	}
+	return NewAccountResponse{Approved: true, Password: ui.readPassword()}, nil
+}
+
+// ShowError implements SignerUI, printing the message to the terminal.
+func (ui *CommandlineUI) ShowError(message string) {
+	fmt.Printf("ERROR: %s\n", message)
+}
+
+// ShowInfo implements SignerUI, printing the message to the terminal.
+func (ui *CommandlineUI) ShowInfo(message string) {
+	fmt.Printf("INFO: %s\n", message)
+}
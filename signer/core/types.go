@@ -0,0 +1,120 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	"github.com/bazacoin/go-bazacoin/core/types"
+)
+
+// SendTxArgs represents a transaction submitted to the signer. The signer has no
+// access to the chain, so contrary to the node's own API all fields except the
+// recipient and the payload need to be filled in by the caller.
+type SendTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Big     `json:"gas"`
+	GasPrice hexutil.Big     `json:"gasPrice"`
+	Value    hexutil.Big     `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+// String implements fmt.Stringer, producing a one line summary of the request.
+func (args SendTxArgs) String() string {
+	to := "[contract creation]"
+	if args.To != nil {
+		to = args.To.Hex()
+	}
+	return fmt.Sprintf("from %s to %s, value %v, nonce %d, gas %v @ %v, %d bytes of data",
+		args.From.Hex(), to, args.Value.ToInt(), uint64(args.Nonce), args.Gas.ToInt(), args.GasPrice.ToInt(), len(args.Data))
+}
+
+// toTransaction converts the arguments into an unsigned transaction.
+func (args *SendTxArgs) toTransaction() *types.Transaction {
+	var (
+		value    = new(big.Int).Set(args.Value.ToInt())
+		gas      = new(big.Int).Set(args.Gas.ToInt())
+		gasPrice = new(big.Int).Set(args.GasPrice.ToInt())
+	)
+	if args.To == nil {
+		return types.NewContractCreation(uint64(args.Nonce), value, gas, gasPrice, args.Data)
+	}
+	return types.NewTransaction(uint64(args.Nonce), *args.To, value, gas, gasPrice, args.Data)
+}
+
+// SignTxResult is the response of a successful transaction signing request,
+// containing both the RLP encoded and the decoded signed transaction.
+type SignTxResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// SignTxRequest is the approval request presented to the UI for transaction
+// signing.
+type SignTxRequest struct {
+	Transaction SendTxArgs `json:"transaction"`
+}
+
+// SignTxResponse is the UI's decision on a transaction signing request. The
+// password is optional, an empty one means the account is expected to be
+// unlocked already.
+type SignTxResponse struct {
+	Approved bool   `json:"approved"`
+	Password string `json:"password"`
+}
+
+// SignDataRequest is the approval request presented to the UI for signing an
+// arbitrary message.
+type SignDataRequest struct {
+	Address common.Address `json:"address"`
+	Data    hexutil.Bytes  `json:"data"`
+	Hash    hexutil.Bytes  `json:"hash"`
+}
+
+// SignDataResponse is the UI's decision on a data signing request.
+type SignDataResponse struct {
+	Approved bool   `json:"approved"`
+	Password string `json:"password"`
+}
+
+// ListRequest is the approval request presented to the UI when the accounts
+// of the signer are enumerated.
+type ListRequest struct {
+	Accounts []common.Address `json:"accounts"`
+}
+
+// ListResponse is the UI's decision on an account listing request, containing
+// the subset of accounts the caller is allowed to see.
+type ListResponse struct {
+	Accounts []common.Address `json:"accounts"`
+}
+
+// NewAccountRequest is the approval request presented to the UI for creating a
+// new account in the signer's keystore.
+type NewAccountRequest struct{}
+
+// NewAccountResponse is the UI's decision on an account creation request,
+// containing the password to encrypt the new key with.
+type NewAccountResponse struct {
+	Approved bool   `json:"approved"`
+	Password string `json:"password"`
+}
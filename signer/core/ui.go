@@ -0,0 +1,58 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import "github.com/bazacoin/go-bazacoin/log"
+
+// HeadlessUI is a SignerUI without a human behind it, rejecting every request
+// it is asked about. It is meant to be wrapped by a rule set for unattended
+// operation, where anything not explicitly allowed by the rules is denied.
+type HeadlessUI struct{}
+
+// ApproveTx implements SignerUI, rejecting the request.
+func (HeadlessUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	log.Info("Rejected transaction signing request", "tx", request.Transaction)
+	return SignTxResponse{Approved: false}, nil
+}
+
+// ApproveSignData implements SignerUI, rejecting the request.
+func (HeadlessUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	log.Info("Rejected data signing request", "address", request.Address)
+	return SignDataResponse{Approved: false}, nil
+}
+
+// ApproveListing implements SignerUI, rejecting the request.
+func (HeadlessUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	log.Info("Rejected account listing request")
+	return ListResponse{}, nil
+}
+
+// ApproveNewAccount implements SignerUI, rejecting the request.
+func (HeadlessUI) ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error) {
+	log.Info("Rejected account creation request")
+	return NewAccountResponse{Approved: false}, nil
+}
+
+// ShowError implements SignerUI, logging the message.
+func (HeadlessUI) ShowError(message string) {
+	log.Error(message)
+}
+
+// ShowInfo implements SignerUI, logging the message.
+func (HeadlessUI) ShowInfo(message string) {
+	log.Info(message)
+}
@@ -0,0 +1,244 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package rules implements declarative approval rules for the external signer.
+//
+// A rule set is a JSON document deciding the fate of each request before it
+// reaches the interactive UI:
+//
+//	{
+//	  "list":    "approve",
+//	  "sign":    "ask",
+//	  "new":     "reject",
+//	  "default": "ask",
+//	  "recipients": {
+//	    "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192": {
+//	      "maxValue": "1000000000000000000",
+//	      "methods":  ["transfer(address,uint256)", "0x095ea7b3"]
+//	    }
+//	  }
+//	}
+//
+// Transactions to a listed recipient are approved automatically if they set
+// both gas and gas price, their value plus the maximum fee (gas * gasPrice)
+// does not exceed maxValue (unlimited if omitted) and they either carry no
+// payload or call one of the allowed contract methods. All other transactions
+// are subject to the default action. Automatically approved requests are signed
+// without a password, so the accounts need to be unlocked in the signer.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	"github.com/bazacoin/go-bazacoin/common/math"
+	"github.com/bazacoin/go-bazacoin/crypto"
+	"github.com/bazacoin/go-bazacoin/signer/core"
+)
+
+// Action is the decision a rule takes for a request.
+type Action string
+
+const (
+	Ask     Action = "ask"     // Forward the request to the next UI (default)
+	Approve Action = "approve" // Approve the request without asking
+	Reject  Action = "reject"  // Reject the request without asking
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler, validating the action.
+func (a *Action) UnmarshalText(input []byte) error {
+	switch action := Action(input); action {
+	case "", Ask, Approve, Reject:
+		*a = action
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+// RecipientRule defines which transactions to a single recipient are approved
+// automatically.
+type RecipientRule struct {
+	MaxValue *math.HexOrDecimal256 `json:"maxValue"` // Highest value plus fee per transaction, unlimited if nil
+	Methods  []string              `json:"methods"`  // Contract methods allowed to be called, as signatures or selectors
+
+	selectors map[[4]byte]bool
+}
+
+// Rules is a declarative rule set deciding on signer requests.
+type Rules struct {
+	List       Action                            `json:"list"`       // Action for account listing requests
+	Sign       Action                            `json:"sign"`       // Action for data signing requests
+	New        Action                            `json:"new"`        // Action for account creation requests
+	Default    Action                            `json:"default"`    // Action for transactions not covered by a recipient rule
+	Recipients map[common.Address]*RecipientRule `json:"recipients"` // Per-recipient auto approval rules
+}
+
+// Load reads and validates a rule set from a JSON file.
+func Load(path string) (*Rules, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(blob)
+}
+
+// Parse decodes and validates a JSON rule set.
+func Parse(blob []byte) (*Rules, error) {
+	rules := new(Rules)
+	if err := json.Unmarshal(blob, rules); err != nil {
+		return nil, err
+	}
+	for addr, rule := range rules.Recipients {
+		if rule == nil {
+			return nil, fmt.Errorf("recipient %x: empty rule", addr)
+		}
+		rule.selectors = make(map[[4]byte]bool)
+		for _, method := range rule.Methods {
+			selector, err := parseSelector(method)
+			if err != nil {
+				return nil, fmt.Errorf("recipient %x: %v", addr, err)
+			}
+			rule.selectors[selector] = true
+		}
+	}
+	return rules, nil
+}
+
+// parseSelector converts a method signature or a hex encoded 4 byte selector
+// into the selector of the method.
+func parseSelector(method string) ([4]byte, error) {
+	var selector [4]byte
+	if strings.HasPrefix(method, "0x") {
+		blob, err := hexutil.Decode(method)
+		if err != nil || len(blob) != 4 {
+			return selector, fmt.Errorf("invalid method selector %q", method)
+		}
+		copy(selector[:], blob)
+		return selector, nil
+	}
+	if !strings.Contains(method, "(") || !strings.HasSuffix(method, ")") {
+		return selector, fmt.Errorf("invalid method signature %q", method)
+	}
+	copy(selector[:], crypto.Keccak256([]byte(strings.Replace(method, " ", "", -1))))
+	return selector, nil
+}
+
+// allows checks whether the transaction is covered by the recipient rule. The
+// fee counts against the value limit, so transactions must set their gas and
+// gas price explicitly.
+func (r *RecipientRule) allows(tx *core.SendTxArgs) bool {
+	gas, price := tx.Gas.ToInt(), tx.GasPrice.ToInt()
+	if gas.Sign() <= 0 || price.Sign() <= 0 {
+		return false
+	}
+	if r.MaxValue != nil {
+		cost := new(big.Int).Mul(gas, price)
+		if cost.Add(cost, tx.Value.ToInt()).Cmp((*big.Int)(r.MaxValue)) > 0 {
+			return false
+		}
+	}
+	if len(tx.Data) == 0 {
+		return true
+	}
+	if len(tx.Data) < 4 {
+		return false
+	}
+	var selector [4]byte
+	copy(selector[:], tx.Data[:4])
+	return r.selectors[selector]
+}
+
+// transaction decides on a transaction signing request.
+func (r *Rules) transaction(tx *core.SendTxArgs) Action {
+	if tx.To != nil {
+		if rule, ok := r.Recipients[*tx.To]; ok && rule.allows(tx) {
+			return Approve
+		}
+	}
+	return r.Default
+}
+
+// RuleEvaluator is a SignerUI applying a rule set to each request, forwarding
+// the requests the rules ask about to the next UI.
+type RuleEvaluator struct {
+	rules *Rules
+	next  core.SignerUI
+}
+
+// NewRuleEvaluator wraps a UI with a rule set.
+func NewRuleEvaluator(rules *Rules, next core.SignerUI) *RuleEvaluator {
+	return &RuleEvaluator{rules: rules, next: next}
+}
+
+// ApproveTx implements core.SignerUI.
+func (r *RuleEvaluator) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	switch r.rules.transaction(&request.Transaction) {
+	case Approve:
+		r.next.ShowInfo(fmt.Sprintf("Transaction approved by rules: %v", request.Transaction))
+		return core.SignTxResponse{Approved: true}, nil
+	case Reject:
+		r.next.ShowInfo(fmt.Sprintf("Transaction rejected by rules: %v", request.Transaction))
+		return core.SignTxResponse{Approved: false}, nil
+	}
+	return r.next.ApproveTx(request)
+}
+
+// ApproveSignData implements core.SignerUI.
+func (r *RuleEvaluator) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	switch r.rules.Sign {
+	case Approve:
+		return core.SignDataResponse{Approved: true}, nil
+	case Reject:
+		return core.SignDataResponse{Approved: false}, nil
+	}
+	return r.next.ApproveSignData(request)
+}
+
+// ApproveListing implements core.SignerUI.
+func (r *RuleEvaluator) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	switch r.rules.List {
+	case Approve:
+		return core.ListResponse{Accounts: request.Accounts}, nil
+	case Reject:
+		return core.ListResponse{}, nil
+	}
+	return r.next.ApproveListing(request)
+}
+
+// ApproveNewAccount implements core.SignerUI. Accounts need a password, so
+// creation can only be rejected by the rules, never approved automatically.
+func (r *RuleEvaluator) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAccountResponse, error) {
+	if r.rules.New == Reject {
+		return core.NewAccountResponse{Approved: false}, nil
+	}
+	return r.next.ApproveNewAccount(request)
+}
+
+// ShowError implements core.SignerUI.
+func (r *RuleEvaluator) ShowError(message string) {
+	r.next.ShowError(message)
+}
+
+// ShowInfo implements core.SignerUI.
+func (r *RuleEvaluator) ShowInfo(message string) {
+	r.next.ShowInfo(message)
+}
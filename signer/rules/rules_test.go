@@ -0,0 +1,143 @@
+// Copyright 2017 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
+	"github.com/bazacoin/go-bazacoin/signer/core"
+)
+
+// askUI is a SignerUI recording whether it was asked about a request.
+type askUI struct {
+	core.HeadlessUI
+	asked bool
+}
+
+func (ui *askUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	ui.asked = true
+	return core.SignTxResponse{Approved: false}, nil
+}
+
+func (ui *askUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	ui.asked = true
+	return core.ListResponse{}, nil
+}
+
+var (
+	token = common.HexToAddress("0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192")
+	payee = common.HexToAddress("0xb0f0a0a8bd1c6b9b0b63ab8e2c2b3b16bb0a8e7e")
+	other = common.HexToAddress("0x0000000000000000000000000000000000000001")
+)
+
+const testRules = `{
+	"list": "approve",
+	"default": "reject",
+	"recipients": {
+		"0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192": {
+			"maxValue": "100",
+			"methods":  ["transfer(address, uint256)", "0x095ea7b3"]
+		},
+		"0xb0f0a0a8bd1c6b9b0b63ab8e2c2b3b16bb0a8e7e": {
+			"maxValue": "0x3e8"
+		}
+	}
+}`
+
+func TestRuleParsing(t *testing.T) {
+	tests := []string{
+		`{"default": "maybe"}`,
+		`{"recipients": {"0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192": null}}`,
+		`{"recipients": {"0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192": {"methods": ["0x1234"]}}}`,
+		`{"recipients": {"0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192": {"methods": ["transfer"]}}}`,
+	}
+	for i, test := range tests {
+		if _, err := Parse([]byte(test)); err == nil {
+			t.Errorf("test %d: invalid rules accepted: %s", i, test)
+		}
+	}
+}
+
+func TestRuleTransactions(t *testing.T) {
+	rules, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	tests := []struct {
+		to         *common.Address
+		value      int64
+		gas, price int64
+		data       string
+		want       Action
+	}{
+		{&payee, 900, 10, 10, "", Approve},           // within the recipient's limit
+		{&payee, 901, 10, 10, "", Reject},            // above the recipient's limit
+		{&payee, 0, 10, 100, "", Approve},            // fee within the recipient's limit
+		{&payee, 0, 10, 101, "", Reject},             // fee above the recipient's limit
+		{&payee, 0, 0, 10, "", Reject},               // gas not set
+		{&payee, 0, 10, 0, "", Reject},               // gas price not set
+		{&payee, 0, 10, 10, "0xa9059cbb", Reject},    // contract calls not allowed
+		{&token, 0, 10, 10, "0xa9059cbb00", Approve}, // allowed method by signature
+		{&token, 0, 10, 10, "0x095ea7b3", Approve},   // allowed method by selector
+		{&token, 0, 10, 10, "0x23b872dd", Reject},    // method not allowed
+		{&token, 0, 10, 10, "0xa905", Reject},        // truncated selector
+		{&token, 1, 10, 10, "0xa9059cbb", Reject},    // value plus fee above the limit
+		{&other, 0, 10, 10, "", Reject},              // unknown recipient
+		{nil, 0, 10, 10, "0x6060", Reject},           // contract creation
+	}
+	for i, test := range tests {
+		args := &core.SendTxArgs{
+			To:       test.to,
+			Value:    hexutil.Big(*big.NewInt(test.value)),
+			Gas:      hexutil.Big(*big.NewInt(test.gas)),
+			GasPrice: hexutil.Big(*big.NewInt(test.price)),
+		}
+		if test.data != "" {
+			args.Data = hexutil.MustDecode(test.data)
+		}
+		if have := rules.transaction(args); have != test.want {
+			t.Errorf("test %d: action mismatch: have %q, want %q", i, have, test.want)
+		}
+	}
+}
+
+func TestRuleEvaluator(t *testing.T) {
+	rules, err := Parse([]byte(`{"recipients": {"0xb0f0a0a8bd1c6b9b0b63ab8e2c2b3b16bb0a8e7e": {}}}`))
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	ui := new(askUI)
+	evaluator := NewRuleEvaluator(rules, ui)
+
+	// Transactions covered by the rules must not reach the next UI
+	covered := core.SendTxArgs{To: &payee, Gas: hexutil.Big(*big.NewInt(21000)), GasPrice: hexutil.Big(*big.NewInt(1))}
+	resp, err := evaluator.ApproveTx(&core.SignTxRequest{Transaction: covered})
+	if err != nil || !resp.Approved || ui.asked {
+		t.Fatalf("covered transaction: approved %v, asked %v, err %v", resp.Approved, ui.asked, err)
+	}
+	// Anything else must be forwarded, the default action being to ask
+	if resp, err = evaluator.ApproveTx(&core.SignTxRequest{Transaction: core.SendTxArgs{To: &other}}); err != nil || resp.Approved || !ui.asked {
+		t.Fatalf("uncovered transaction: approved %v, asked %v, err %v", resp.Approved, ui.asked, err)
+	}
+	ui.asked = false
+	if _, err := evaluator.ApproveListing(&core.ListRequest{}); err != nil || !ui.asked {
+		t.Fatalf("listing: asked %v, err %v", ui.asked, err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 The go-bazacoin Authors
+// This file is part of the go-bazacoin library.
+//
+// The go-bazacoin library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-bazacoin library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-bazacoin library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build none
+
+// This program fills state tests: it executes the transactions of the given
+// filler file on Constantinople rules and writes the completed tests, holding
+// the expected output, post state and logs, to the given output file.
+//
+//	go run fillstate.go <filler.json> <test.json>
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/bazacoin/go-bazacoin/params"
+	"github.com/bazacoin/go-bazacoin/tests"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fatalf("Usage: %s <filler.json> <test.json>", os.Args[0])
+	}
+	chainConfig := &params.ChainConfig{
+		HomesteadBlock:      new(big.Int),
+		EIP150Block:         new(big.Int),
+		EIP155Block:         new(big.Int),
+		EIP158Block:         new(big.Int),
+		ConstantinopleBlock: new(big.Int),
+	}
+	in, err := os.Open(os.Args[1])
+	if err != nil {
+		fatalf("Failed to open filler: %v", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(os.Args[2])
+	if err != nil {
+		fatalf("Failed to create test file: %v", err)
+	}
+	defer out.Close()
+
+	if err := tests.FillStateTests(chainConfig, in, out); err != nil {
+		fatalf("Failed to fill state tests: %v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
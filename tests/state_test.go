@@ -27,7 +27,7 @@ import (
 
 func BenchmarkStateCall1024(b *testing.B) {
 	fn := filepath.Join(stateTestDir, "stCallCreateCallCodeTest.json")
-	if err := BenchVmTest(fn, bconf{"Call1024BalanceTooLow", true, os.Getenv("JITVM") == "true"}, b); err != nil {
+	if err := BenchVmTest(fn, bconf{"Call1024BalanceTooLow"}, b); err != nil {
 		b.Error(err)
 	}
 }
@@ -984,3 +984,21 @@ func TestEIP158HomesteadBounds(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+//go:generate go run fillstate.go files/StateTests/Constantinople/stConstantinopleFiller.json files/StateTests/Constantinople/stConstantinopleTest.json
+
+// Constantinople tests, filled from stConstantinopleFiller.json by fillstate.go
+func TestConstantinople(t *testing.T) {
+	chainConfig := &params.ChainConfig{
+		HomesteadBlock:      new(big.Int),
+		EIP150Block:         new(big.Int),
+		EIP155Block:         new(big.Int),
+		EIP158Block:         new(big.Int),
+		ConstantinopleBlock: new(big.Int),
+	}
+
+	fn := filepath.Join(stateTestDir, "Constantinople", "stConstantinopleTest.json")
+	if err := RunStateTest(chainConfig, fn, StateSkipTests); err != nil {
+		t.Error(err)
+	}
+}
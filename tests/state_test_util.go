@@ -18,6 +18,7 @@ package tests
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math/big"
@@ -26,6 +27,7 @@ import (
 	"testing"
 
 	"github.com/bazacoin/go-bazacoin/common"
+	"github.com/bazacoin/go-bazacoin/common/hexutil"
 	"github.com/bazacoin/go-bazacoin/common/math"
 	"github.com/bazacoin/go-bazacoin/core"
 	"github.com/bazacoin/go-bazacoin/core/state"
@@ -72,18 +74,7 @@ func BenchStateTest(chainConfig *params.ChainConfig, p string, conf bconf, b *te
 		return fmt.Errorf("test not found: %s", conf.name)
 	}
 
-	// XXX Yeah, yeah...
-	env := make(map[string]string)
-	env["currentCoinbase"] = test.Env.CurrentCoinbase
-	env["currentDifficulty"] = test.Env.CurrentDifficulty
-	env["currentGasLimit"] = test.Env.CurrentGasLimit
-	env["currentNumber"] = test.Env.CurrentNumber
-	env["previousHash"] = test.Env.PreviousHash
-	if n, ok := test.Env.CurrentTimestamp.(float64); ok {
-		env["currentTimestamp"] = strconv.Itoa(int(n))
-	} else {
-		env["currentTimestamp"] = test.Env.CurrentTimestamp.(string)
-	}
+	env := stateTestEnv(test)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -102,6 +93,105 @@ func benchStateTest(chainConfig *params.ChainConfig, test VmTest, env map[string
 	RunState(chainConfig, statedb, env, test.Exec)
 }
 
+// FillStateTests reads a set of state test fillers from r, executes them with
+// the given chain configuration and writes the completed state tests to w. A
+// filler is a regular state test with only the env, pre and transaction
+// sections present; the expected output, post state, logs and post state root
+// are generated by running the transaction.
+func FillStateTests(chainConfig *params.ChainConfig, r io.Reader, w io.Writer) error {
+	tests := make(map[string]VmTest)
+	if err := readJson(r, &tests); err != nil {
+		return err
+	}
+	for name, test := range tests {
+		filled, err := fillStateTest(chainConfig, test)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		tests[name] = filled
+	}
+	out, err := json.MarshalIndent(tests, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
+
+func fillStateTest(chainConfig *params.ChainConfig, test VmTest) (VmTest, error) {
+	db, err := bzcdb.NewMemDatabase()
+	if err != nil {
+		return test, err
+	}
+	statedb := makePreState(db, test.Pre)
+
+	ret, logs, _, err := RunState(chainConfig, statedb, stateTestEnv(test), test.Transaction)
+	if err != nil {
+		return test, fmt.Errorf("transaction failed: %v", err)
+	}
+	test.Out = hexutil.Encode(ret)
+
+	// Gather the post state of every live account
+	root, err := statedb.Commit(false)
+	if err != nil {
+		return test, err
+	}
+	if statedb, err = state.New(root, db); err != nil {
+		return test, err
+	}
+
+	test.Post = make(map[string]Account)
+	for addr := range statedb.RawDump().Accounts {
+		address := common.HexToAddress(addr)
+		account := Account{
+			Balance: hexutil.EncodeBig(statedb.GetBalance(address)),
+			Code:    hexutil.Encode(statedb.GetCode(address)),
+			Nonce:   hexutil.EncodeUint64(statedb.GetNonce(address)),
+			Storage: make(map[string]string),
+		}
+		statedb.ForEachStorage(address, func(key, _ common.Hash) bool {
+			if value := statedb.GetState(address, key); value != (common.Hash{}) {
+				account.Storage[hexutil.EncodeBig(key.Big())] = hexutil.EncodeBig(value.Big())
+			}
+			return true
+		})
+		test.Post[addr] = account
+	}
+	test.PostStateRoot = common.Bytes2Hex(root[:])
+
+	test.Logs = make([]Log, len(logs))
+	for i, entry := range logs {
+		topics := make([]string, len(entry.Topics))
+		for j, topic := range entry.Topics {
+			topics[j] = common.Bytes2Hex(topic[:])
+		}
+		test.Logs[i] = Log{
+			AddressF: common.Bytes2Hex(entry.Address[:]),
+			DataF:    hexutil.Encode(entry.Data),
+			TopicsF:  topics,
+			BloomF:   common.Bytes2Hex(math.PaddedBigBytes(types.LogsBloom([]*types.Log{entry}), 256)),
+		}
+	}
+	return test, nil
+}
+
+// stateTestEnv converts the block environment of a state test into the flat
+// format expected by RunState.
+func stateTestEnv(test VmTest) map[string]string {
+	env := make(map[string]string)
+	env["currentCoinbase"] = test.Env.CurrentCoinbase
+	env["currentDifficulty"] = test.Env.CurrentDifficulty
+	env["currentGasLimit"] = test.Env.CurrentGasLimit
+	env["currentNumber"] = test.Env.CurrentNumber
+	env["previousHash"] = test.Env.PreviousHash
+	if n, ok := test.Env.CurrentTimestamp.(float64); ok {
+		env["currentTimestamp"] = strconv.Itoa(int(n))
+	} else {
+		env["currentTimestamp"] = test.Env.CurrentTimestamp.(string)
+	}
+	return env
+}
+
 func runStateTests(chainConfig *params.ChainConfig, tests map[string]VmTest, skipTests []string) error {
 	skipTest := make(map[string]bool, len(skipTests))
 	for _, name := range skipTests {
@@ -130,18 +220,7 @@ func runStateTest(chainConfig *params.ChainConfig, test VmTest) error {
 	db, _ := bzcdb.NewMemDatabase()
 	statedb := makePreState(db, test.Pre)
 
-	// XXX Yeah, yeah...
-	env := make(map[string]string)
-	env["currentCoinbase"] = test.Env.CurrentCoinbase
-	env["currentDifficulty"] = test.Env.CurrentDifficulty
-	env["currentGasLimit"] = test.Env.CurrentGasLimit
-	env["currentNumber"] = test.Env.CurrentNumber
-	env["previousHash"] = test.Env.PreviousHash
-	if n, ok := test.Env.CurrentTimestamp.(float64); ok {
-		env["currentTimestamp"] = strconv.Itoa(int(n))
-	} else {
-		env["currentTimestamp"] = test.Env.CurrentTimestamp.(string)
-	}
+	env := stateTestEnv(test)
 
 	var (
 		ret []byte
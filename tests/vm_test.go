@@ -17,21 +17,20 @@
 package tests
 
 import (
-	"os"
 	"path/filepath"
 	"testing"
 )
 
 func BenchmarkVmAckermann32Tests(b *testing.B) {
 	fn := filepath.Join(vmTestDir, "vmPerformanceTest.json")
-	if err := BenchVmTest(fn, bconf{"ackermann32", os.Getenv("JITFORCE") == "true", os.Getenv("JITVM") == "true"}, b); err != nil {
+	if err := BenchVmTest(fn, bconf{"ackermann32"}, b); err != nil {
 		b.Error(err)
 	}
 }
 
 func BenchmarkVmFibonacci16Tests(b *testing.B) {
 	fn := filepath.Join(vmTestDir, "vmPerformanceTest.json")
-	if err := BenchVmTest(fn, bconf{"fibonacci16", os.Getenv("JITFORCE") == "true", os.Getenv("JITVM") == "true"}, b); err != nil {
+	if err := BenchVmTest(fn, bconf{"fibonacci16"}, b); err != nil {
 		b.Error(err)
 	}
 }
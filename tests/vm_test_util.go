@@ -53,9 +53,7 @@ func RunVmTestWithReader(r io.Reader, skipTests []string) error {
 }
 
 type bconf struct {
-	name    string
-	precomp bool
-	jit     bool
+	name string
 }
 
 func BenchVmTest(p string, conf bconf, b *testing.B) error {
@@ -82,16 +80,6 @@ func BenchVmTest(p string, conf bconf, b *testing.B) error {
 		env["currentTimestamp"] = test.Env.CurrentTimestamp.(string)
 	}
 
-	/*
-		if conf.precomp {
-			program := vm.NewProgram(test.code)
-			err := vm.AttachProgram(program)
-			if err != nil {
-				return err
-			}
-		}
-	*/
-
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		benchVmTest(test, env, b)
@@ -146,3 +146,173 @@ func get(tn node, key []byte) ([]byte, node) {
 	}
 	return nil, tn.(valueNode)
 }
+
+// VerifyRangeProof checks whether the given key-value pairs are all the
+// consecutive entries of the trie with the given root hash, starting at
+// firstKey. The keys must be sorted in ascending order and none of them
+// may be smaller than firstKey.
+//
+// The proof must contain the merkle proofs of firstKey and of the last key
+// in the range. If no keys are given, the proof of firstKey is expected to
+// prove that the trie contains no entries at or after firstKey. If the
+// proof is empty, the key-value pairs must make up the entire trie.
+func VerifyRangeProof(rootHash common.Hash, firstKey []byte, keys [][]byte, values [][]byte, proof []rlp.RawValue) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("inconsistent proof data, keys: %d, values: %d", len(keys), len(values))
+	}
+	// Ensure the range is sorted, starts at firstKey and contains no deletions.
+	for i, key := range keys {
+		if i == 0 && bytes.Compare(key, firstKey) < 0 {
+			return errors.New("range starts before first key")
+		}
+		if i > 0 && bytes.Compare(keys[i-1], key) >= 0 {
+			return errors.New("range is not monotonically increasing")
+		}
+		if len(values[i]) == 0 {
+			return errors.New("range contains deletion")
+		}
+	}
+	// Without any proof, the range must be the whole trie.
+	if len(proof) == 0 {
+		tr := new(Trie)
+		for i, key := range keys {
+			tr.Update(key, values[i])
+		}
+		if have := tr.Hash(); have != rootHash {
+			return fmt.Errorf("invalid proof, want hash %x, got %x", rootHash, have)
+		}
+		return nil
+	}
+	// Index the proof nodes by hash.
+	nodes := make(map[common.Hash][]byte, len(proof))
+	sha := sha3.NewKeccak256()
+	for _, buf := range proof {
+		var hash common.Hash
+		sha.Reset()
+		sha.Write(buf)
+		sha.Sum(hash[:0])
+		nodes[hash] = buf
+	}
+	// Rebuild the trie along the edge paths, dropping everything in between.
+	// Reinserting the range must then reproduce the original root.
+	r := &rangePruner{nodes: nodes, open: len(keys) == 0}
+	r.left = keybytesToHex(firstKey)
+	r.left = r.left[:len(r.left)-1]
+	if len(keys) > 0 {
+		r.right = keybytesToHex(keys[len(keys)-1])
+		r.right = r.right[:len(r.right)-1]
+	}
+	root, err := r.prune(hashNode(rootHash[:]), nil)
+	if err != nil {
+		return err
+	}
+	// The pruned trie has every node on the paths of the range resolved, so
+	// the insertions never need to access a database.
+	tr := &Trie{root: root}
+	for i, key := range keys {
+		if err := tr.TryUpdate(key, values[i]); err != nil {
+			return err
+		}
+	}
+	if have := tr.Hash(); have != rootHash {
+		return fmt.Errorf("invalid proof, want hash %x, got %x", rootHash, have)
+	}
+	return nil
+}
+
+// rangePruner resolves the proof nodes on the edges of a key range and drops
+// all subtries that lie entirely within the range. Paths are hex encoded
+// without the terminator.
+type rangePruner struct {
+	nodes       map[common.Hash][]byte
+	left, right []byte
+	open        bool // whether the range extends to the end of the key space
+}
+
+// Relations of a subtrie to the pruned range.
+const (
+	rangeOutside = iota
+	rangeInside
+	rangeEdge
+)
+
+// classify determines whether all keys below the given path are outside or
+// inside the range, or whether the path lies on one of the range edges.
+func (r *rangePruner) classify(path []byte) int {
+	if bytes.HasPrefix(r.left, path) || (!r.open && bytes.HasPrefix(r.right, path)) {
+		return rangeEdge
+	}
+	if bytes.Compare(path, r.left) > 0 && (r.open || bytes.Compare(path, r.right) < 0) {
+		return rangeInside
+	}
+	return rangeOutside
+}
+
+// contains reports whether the value at the given path is within the range.
+func (r *rangePruner) contains(path []byte) bool {
+	return bytes.Compare(path, r.left) >= 0 && (r.open || bytes.Compare(path, r.right) <= 0)
+}
+
+// prune returns a copy of the edge node n at the given path, with all subtries
+// inside the range removed.
+func (r *rangePruner) prune(n node, path []byte) (node, error) {
+	switch n := n.(type) {
+	case nil:
+		return nil, nil
+	case hashNode:
+		buf, ok := r.nodes[common.BytesToHash(n)]
+		if !ok {
+			return nil, fmt.Errorf("missing proof node %x", []byte(n))
+		}
+		dec, err := decodeNode(n, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %x: %v", []byte(n), err)
+		}
+		return r.prune(dec, path)
+	case *shortNode:
+		if hasTerm(n.Key) {
+			if r.contains(concat(path, n.Key[:len(n.Key)-1]...)) {
+				return nil, nil
+			}
+			return n, nil
+		}
+		path = concat(path, n.Key...)
+		switch r.classify(path) {
+		case rangeInside:
+			return nil, nil
+		case rangeOutside:
+			return n, nil
+		}
+		child, err := r.prune(n.Val, path)
+		if child == nil || err != nil {
+			return nil, err
+		}
+		return &shortNode{Key: n.Key, Val: child, flags: nodeFlag{dirty: true}}, nil
+	case *fullNode:
+		cpy := &fullNode{flags: nodeFlag{dirty: true}}
+		for i, child := range &n.Children {
+			if child == nil {
+				continue
+			}
+			if i == 16 {
+				if !r.contains(path) {
+					cpy.Children[i] = child
+				}
+				continue
+			}
+			switch childPath := concat(path, byte(i)); r.classify(childPath) {
+			case rangeOutside:
+				cpy.Children[i] = child
+			case rangeEdge:
+				pruned, err := r.prune(child, childPath)
+				if err != nil {
+					return nil, err
+				}
+				cpy.Children[i] = pruned
+			}
+		}
+		return cpy, nil
+	default:
+		return nil, fmt.Errorf("%T: invalid proof node", n)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -84,6 +85,161 @@ func TestVerifyBadProof(t *testing.T) {
 }
 
 // mutateByte changes one byte in b.
+func TestRangeProof(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(entries))
+		end := start + mrand.Intn(len(entries)-start)
+
+		keys, values := rangeOf(entries[start : end+1])
+		proof := rangeProof(trie, keys[0], keys[len(keys)-1])
+		if err := VerifyRangeProof(root, keys[0], keys, values, proof); err != nil {
+			t.Fatalf("case %d (%d->%d): %v", i, start, end, err)
+		}
+	}
+}
+
+func TestRangeProofNonExistentFirstKey(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 200; i++ {
+		start := 1 + mrand.Intn(len(entries)-1)
+		end := start + mrand.Intn(len(entries)-start)
+
+		// Pick a first key between the previous entry and the range start.
+		first := common.CopyBytes(entries[start].k)
+		if bytes.Equal(entries[start-1].k, decrement(first)) {
+			continue
+		}
+		first = decrement(first)
+		keys, values := rangeOf(entries[start : end+1])
+		proof := rangeProof(trie, first, keys[len(keys)-1])
+		if err := VerifyRangeProof(root, first, keys, values, proof); err != nil {
+			t.Fatalf("case %d (%d->%d): %v", i, start, end, err)
+		}
+	}
+}
+
+func TestRangeProofEmptyTail(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+	entries := sortedEntries(vals)
+
+	// There are no keys after the last entry.
+	first := common.CopyBytes(entries[len(entries)-1].k)
+	first[len(first)-1]++
+	if err := VerifyRangeProof(root, first, nil, nil, trie.Prove(first)); err != nil {
+		t.Fatalf("empty tail rejected: %v", err)
+	}
+	// Claiming the same about an earlier key must fail.
+	first = entries[len(entries)-10].k
+	if err := VerifyRangeProof(root, first, nil, nil, trie.Prove(first)); err == nil {
+		t.Fatalf("non-empty tail accepted")
+	}
+}
+
+func TestRangeProofWholeTrie(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+	keys, values := rangeOf(sortedEntries(vals))
+
+	if err := VerifyRangeProof(root, nil, keys, values, nil); err != nil {
+		t.Fatalf("whole trie rejected: %v", err)
+	}
+	if err := VerifyRangeProof(root, nil, keys[1:], values[1:], nil); err == nil {
+		t.Fatalf("partial trie accepted without proof")
+	}
+}
+
+func TestBadRangeProof(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(entries) - 3)
+		end := start + 2 + mrand.Intn(len(entries)-start-2)
+
+		keys, values := rangeOf(entries[start : end+1])
+		proof := rangeProof(trie, keys[0], keys[len(keys)-1])
+
+		switch mrand.Intn(4) {
+		case 0:
+			// Drop an entry from the middle of the range.
+			index := 1 + mrand.Intn(len(keys)-2)
+			keys = append(keys[:index:index], keys[index+1:]...)
+			values = append(values[:index:index], values[index+1:]...)
+		case 1:
+			// Modify a value.
+			index := mrand.Intn(len(keys))
+			values[index] = randBytes(20)
+		case 2:
+			// Drop the first entry but keep the first key.
+			first := keys[0]
+			if err := VerifyRangeProof(root, first, keys[1:], values[1:], proof); err == nil {
+				t.Fatalf("case %d: missing first entry accepted", i)
+			}
+			continue
+		case 3:
+			// Drop a proof node.
+			index := mrand.Intn(len(proof))
+			proof = append(proof[:index:index], proof[index+1:]...)
+		}
+		if err := VerifyRangeProof(root, keys[0], keys, values, proof); err == nil {
+			t.Fatalf("case %d: bad range accepted", i)
+		}
+	}
+}
+
+// sortedEntries returns the entries of a random trie in key order.
+func sortedEntries(vals map[string]*kv) []*kv {
+	entries := make([]*kv, 0, len(vals))
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].k, entries[j].k) < 0 })
+	return entries
+}
+
+func rangeOf(entries []*kv) (keys, values [][]byte) {
+	for _, kv := range entries {
+		keys = append(keys, kv.k)
+		values = append(values, kv.v)
+	}
+	return keys, values
+}
+
+// rangeProof merges the edge proofs of a key range.
+func rangeProof(trie *Trie, first, last []byte) []rlp.RawValue {
+	proof := trie.Prove(first)
+	seen := make(map[string]bool)
+	for _, node := range proof {
+		seen[string(node)] = true
+	}
+	for _, node := range trie.Prove(last) {
+		if !seen[string(node)] {
+			proof = append(proof, node)
+		}
+	}
+	return proof
+}
+
+func decrement(key []byte) []byte {
+	key = common.CopyBytes(key)
+	for i := len(key) - 1; i >= 0; i-- {
+		key[i]--
+		if key[i] != 0xff {
+			break
+		}
+	}
+	return key
+}
+
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {
 		new := byte(mrand.Intn(255))